@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/rancher/fleet/integrationtests/utils"
+	"github.com/rancher/fleet/modules/agent/pkg/clusterupgrade"
 	"github.com/rancher/fleet/modules/agent/pkg/controllers/bundledeployment"
 	"github.com/rancher/fleet/modules/agent/pkg/deployer"
 	"github.com/rancher/fleet/modules/agent/pkg/trigger"
@@ -156,7 +157,7 @@ func registerBundleDeploymentController(cfg *rest.Config, namespace string, look
 		helmDeployer,
 		wranglerApply)
 
-	bundledeployment.Register(ctx, trig, mapper, dyn, deployManager, factory.Fleet().V1alpha1().BundleDeployment())
+	bundledeployment.Register(ctx, trig, mapper, dyn, deployManager, factory.Fleet().V1alpha1().BundleDeployment(), clusterupgrade.New())
 
 	err = factory.Start(ctx, 50)
 	Expect(err).ToNot(HaveOccurred())
@@ -122,6 +122,8 @@ func registerBundleController(cfg *rest.Config, namespace string) fleet.Interfac
 		factory.Fleet().V1alpha1().Bundle().Cache(),
 		factory.Fleet().V1alpha1().BundleNamespaceMapping().Cache(),
 		coreFactory.Core().V1().Namespace().Cache(),
+		coreFactory.Core().V1().Secret().Cache(),
+		coreFactory.Core().V1().ConfigMap().Cache(),
 		manifest.NewStore(factory.Fleet().V1alpha1().Content()),
 		factory.Fleet().V1alpha1().BundleDeployment().Cache())
 
@@ -133,7 +135,11 @@ func registerBundleController(cfg *rest.Config, namespace string) fleet.Interfac
 		factory.Fleet().V1alpha1().Cluster(),
 		factory.Fleet().V1alpha1().ImageScan(),
 		factory.Fleet().V1alpha1().GitRepo().Cache(),
-		factory.Fleet().V1alpha1().BundleDeployment())
+		factory.Fleet().V1alpha1().BundleDeployment(),
+		factory.Fleet().V1alpha1().Content(),
+		coreFactory.Core().V1().Secret(),
+		coreFactory.Core().V1().ConfigMap(),
+		nil)
 
 	err = factory.Start(ctx, 50)
 	Expect(err).ToNot(HaveOccurred())
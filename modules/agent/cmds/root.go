@@ -1,17 +1,22 @@
 package cmds
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/rancher/fleet/modules/agent/pkg/agent"
+	"github.com/rancher/fleet/modules/agent/pkg/standalone"
 	"github.com/rancher/fleet/pkg/version"
 
 	command "github.com/rancher/wrangler-cli"
+
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 var (
@@ -19,10 +24,21 @@ var (
 )
 
 type FleetAgent struct {
-	Kubeconfig      string `usage:"kubeconfig file"`
-	Namespace       string `usage:"namespace to watch" env:"NAMESPACE"`
-	AgentScope      string `usage:"An identifier used to scope the agent bundleID names, typically the same as namespace" env:"AGENT_SCOPE"`
-	CheckinInterval string `usage:"How often to post cluster status" env:"CHECKIN_INTERVAL"`
+	Kubeconfig       string `usage:"kubeconfig file"`
+	Namespace        string `usage:"namespace to watch" env:"NAMESPACE"`
+	AgentScope       string `usage:"An identifier used to scope the agent bundleID names, typically the same as namespace" env:"AGENT_SCOPE"`
+	CheckinInterval  string `usage:"How often to post cluster status" env:"CHECKIN_INTERVAL"`
+	ApplyConcurrency int    `usage:"Number of resources applied in parallel per bundle deployment, namespaces and CRDs are applied first" env:"APPLY_CONCURRENCY"`
+
+	StandaloneRepo     string `usage:"Git repository to deploy to this cluster without a fleet controller, the fleet CRDs have to be installed" name:"standalone-repo" env:"STANDALONE_REPO"`
+	StandaloneBranch   string `usage:"Branch of the standalone repository" name:"standalone-branch" env:"STANDALONE_BRANCH" default:"master"`
+	StandalonePaths    string `usage:"Comma separated paths of the standalone repository to deploy, defaults to the root" name:"standalone-paths" env:"STANDALONE_PATHS"`
+	StandaloneInterval string `usage:"How often to poll the standalone repository" name:"standalone-interval" env:"STANDALONE_INTERVAL" default:"15s"`
+	StandaloneSecret   string `usage:"Secret in the agent's namespace with the credentials of the standalone repository, like a GitRepo's client secret" name:"standalone-secret" env:"STANDALONE_SECRET"`
+	StandaloneCABundle string `usage:"PEM encoded CA certificates for the standalone repository" name:"standalone-ca-bundle" env:"STANDALONE_CA_BUNDLE"`
+	StandaloneInsecure bool   `usage:"Skip the TLS verification of the standalone repository" name:"standalone-insecure-skip-tls-verify" env:"STANDALONE_INSECURE_SKIP_TLS_VERIFY"`
+	ClusterName        string `usage:"Name of this cluster, matched by the targets of the standalone repository's bundles" name:"cluster-name" env:"CLUSTER_NAME" default:"local"`
+	ClusterLabels      string `usage:"Comma separated labels of this cluster, e.g. env=dev, matched by the targets of the standalone repository's bundles" name:"cluster-labels" env:"CLUSTER_LABELS"`
 }
 
 func (a *FleetAgent) Run(cmd *cobra.Command, args []string) error {
@@ -42,16 +58,58 @@ func (a *FleetAgent) Run(cmd *cobra.Command, args []string) error {
 			return err
 		}
 	}
+	opts.ApplyConcurrency = a.ApplyConcurrency
 	if a.Namespace == "" {
 		return fmt.Errorf("--namespace or env NAMESPACE is required to be set")
 	}
-	if err := agent.Start(cmd.Context(), a.Kubeconfig, a.Namespace, a.AgentScope, &opts); err != nil {
+	if a.StandaloneRepo != "" {
+		opts.Standalone, err = a.standaloneOptions()
+		if err != nil {
+			return err
+		}
+	}
+	// the agent is stopped to register again with a changed credential,
+	// it's restarted by its deployment
+	ctx, restart := context.WithCancel(cmd.Context())
+	defer restart()
+	opts.Restart = restart
+	if err := agent.Start(ctx, a.Kubeconfig, a.Namespace, a.AgentScope, &opts); err != nil {
 		return err
 	}
-	<-cmd.Context().Done()
+	<-ctx.Done()
 	return nil
 }
 
+func (a *FleetAgent) standaloneOptions() (*standalone.Options, error) {
+	interval, err := time.ParseDuration(a.StandaloneInterval)
+	if err != nil {
+		return nil, err
+	}
+	clusterLabels, err := labels.ConvertSelectorToLabelsMap(a.ClusterLabels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cluster labels: %w", err)
+	}
+
+	var paths []string
+	for _, path := range strings.Split(a.StandalonePaths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	return &standalone.Options{
+		Repo:                  a.StandaloneRepo,
+		Branch:                a.StandaloneBranch,
+		Paths:                 paths,
+		Interval:              interval,
+		ClientSecretName:      a.StandaloneSecret,
+		CABundle:              []byte(a.StandaloneCABundle),
+		InsecureSkipTLSverify: a.StandaloneInsecure,
+		ClusterName:           a.ClusterName,
+		ClusterLabels:         clusterLabels,
+	}, nil
+}
+
 func App() *cobra.Command {
 	cmd := command.Command(&FleetAgent{}, cobra.Command{
 		Version: version.FriendlyVersion(),
@@ -8,6 +8,7 @@ import (
 
 	"github.com/rancher/fleet/modules/agent/pkg/controllers"
 	"github.com/rancher/fleet/modules/agent/pkg/register"
+	"github.com/rancher/fleet/modules/agent/pkg/standalone"
 
 	"github.com/rancher/lasso/pkg/mapper"
 	"github.com/rancher/wrangler/pkg/kubeconfig"
@@ -26,6 +27,14 @@ type Options struct {
 	DefaultNamespace string
 	ClusterID        string
 	CheckinInterval  time.Duration
+	// ApplyConcurrency is the number of resources applied in parallel
+	ApplyConcurrency int
+	// Standalone deploys a git repository to the local cluster instead of
+	// registering with a fleet controller
+	Standalone *standalone.Options
+	// Restart stops the agent, so it's started again and registers with
+	// its changed credential
+	Restart func()
 }
 
 // Start the fleet agent
@@ -43,19 +52,31 @@ func Start(ctx context.Context, kubeConfig, namespace, agentScope string, opts *
 		return err
 	}
 
-	agentInfo, err := register.Register(ctx, namespace, opts.ClusterID, kc)
-	if err != nil {
-		return err
-	}
+	var (
+		fleetNamespace   = namespace
+		fleetRestConfig  = kc
+		clusterNamespace = namespace
+		clusterName      string
+	)
+	if opts.Standalone != nil {
+		// the bundle deployments are created in the agent's namespace
+		clusterName = opts.Standalone.ClusterName
+	} else {
+		agentInfo, err := register.Register(ctx, namespace, opts.ClusterID, kc)
+		if err != nil {
+			return err
+		}
 
-	fleetNamespace, _, err := agentInfo.ClientConfig.Namespace()
-	if err != nil {
-		return err
-	}
+		fleetNamespace, _, err = agentInfo.ClientConfig.Namespace()
+		if err != nil {
+			return err
+		}
 
-	fleetRestConfig, err := agentInfo.ClientConfig.ClientConfig()
-	if err != nil {
-		return err
+		fleetRestConfig, err = agentInfo.ClientConfig.ClientConfig()
+		if err != nil {
+			return err
+		}
+		clusterNamespace, clusterName = agentInfo.ClusterNamespace, agentInfo.ClusterName
 	}
 
 	fleetMapper, mapper, discovery, err := NewMappers(ctx, fleetRestConfig, clientConfig, opts)
@@ -68,9 +89,12 @@ func Start(ctx context.Context, kubeConfig, namespace, agentScope string, opts *
 		namespace,
 		opts.DefaultNamespace,
 		agentScope,
-		agentInfo.ClusterNamespace,
-		agentInfo.ClusterName,
+		clusterNamespace,
+		clusterName,
 		opts.CheckinInterval,
+		opts.ApplyConcurrency,
+		opts.Standalone,
+		opts.Restart,
 		fleetRestConfig,
 		clientConfig,
 		fleetMapper,
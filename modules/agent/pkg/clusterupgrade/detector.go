@@ -0,0 +1,120 @@
+// Package clusterupgrade detects upgrades of the downstream cluster's control plane, so deployments can be paused until it is stable again. (fleetagent)
+package clusterupgrade
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/rancher/fleet/pkg/durations"
+
+	"github.com/rancher/wrangler/pkg/ticker"
+
+	"k8s.io/client-go/discovery"
+)
+
+// versionSamples is the number of times the API server version is queried
+// per check, so API servers with different versions behind a load balancer
+// are noticed
+const versionSamples = 3
+
+// Detector tracks the API server version. The cluster is upgrading, if the
+// version changed or API servers report different versions, until the
+// version was stable for durations.ClusterUpgradeStabilization. Failing to
+// reach the API server during an upgrade extends it.
+type Detector struct {
+	lock    sync.Mutex
+	version string
+	changed time.Time
+	// observed is the state after the last check
+	observed bool
+	stable   time.Duration
+	now      func() time.Time
+}
+
+func New() *Detector {
+	return &Detector{
+		stable: durations.ClusterUpgradeStabilization,
+		now:    time.Now,
+	}
+}
+
+// Upgrading returns true, if the cluster's control plane is upgrading.
+func (d *Detector) Upgrading() bool {
+	if d == nil {
+		return false
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.upgrading()
+}
+
+func (d *Detector) upgrading() bool {
+	return !d.changed.IsZero() && d.now().Sub(d.changed) < d.stable
+}
+
+// Observe records the API server versions seen by a check, or the error
+// which prevented it. It returns true, if the upgrading state changed.
+func (d *Detector) Observe(versions []string, err error) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	switch {
+	case err != nil || len(versions) == 0:
+		if d.upgrading() {
+			d.changed = d.now()
+		}
+	case skewed(versions):
+		// the version the API servers settle on is not a new change
+		d.changed = d.now()
+		d.version = ""
+	default:
+		if d.version != "" && d.version != versions[0] {
+			d.changed = d.now()
+		}
+		d.version = versions[0]
+	}
+	was := d.observed
+	d.observed = d.upgrading()
+	return d.observed != was
+}
+
+func skewed(versions []string) bool {
+	for _, v := range versions[1:] {
+		if v != versions[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// Run checks the API server version periodically and calls onChange, when
+// the cluster starts or stops upgrading.
+func (d *Detector) Run(ctx context.Context, client discovery.DiscoveryInterface, onChange func()) {
+	for range ticker.Context(ctx, durations.ClusterUpgradeCheckInterval) {
+		versions, err := serverVersions(client)
+		if !d.Observe(versions, err) {
+			continue
+		}
+		if d.Upgrading() {
+			logrus.Infof("Cluster is upgrading, pausing deployments (API server versions: %v)", versions)
+		} else {
+			logrus.Infof("Cluster upgrade finished, resuming deployments")
+		}
+		onChange()
+	}
+}
+
+func serverVersions(client discovery.DiscoveryInterface) ([]string, error) {
+	var result []string
+	for i := 0; i < versionSamples; i++ {
+		version, err := client.ServerVersion()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, version.GitVersion)
+	}
+	return result, nil
+}
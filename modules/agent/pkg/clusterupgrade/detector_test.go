@@ -0,0 +1,44 @@
+package clusterupgrade
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDetector(t *testing.T) {
+	now := time.Unix(0, 0)
+	d := New()
+	d.now = func() time.Time { return now }
+
+	if d.Observe([]string{"v1.26.1", "v1.26.1"}, nil) || d.Upgrading() {
+		t.Fatal("expected a stable cluster not to be upgrading")
+	}
+
+	if !d.Observe([]string{"v1.26.1", "v1.27.2"}, nil) || !d.Upgrading() {
+		t.Fatal("expected skewed API servers to start an upgrade")
+	}
+
+	now = now.Add(d.stable - time.Second)
+	if d.Observe(nil, errors.New("connection refused")) {
+		t.Fatal("expected an unreachable API server not to change the state")
+	}
+
+	now = now.Add(d.stable - time.Second)
+	if d.Observe([]string{"v1.27.2"}, nil) || !d.Upgrading() {
+		t.Fatal("expected an error during the upgrade to extend it")
+	}
+
+	now = now.Add(time.Second)
+	if !d.Observe([]string{"v1.27.2"}, nil) || d.Upgrading() {
+		t.Fatal("expected the upgrade to finish, once the version is stable")
+	}
+
+	if d.Observe(nil, errors.New("connection refused")) || d.Upgrading() {
+		t.Fatal("expected an error on a stable cluster not to start an upgrade")
+	}
+
+	if !d.Observe([]string{"v1.28.0"}, nil) {
+		t.Fatal("expected a version change to start an upgrade")
+	}
+}
@@ -12,12 +12,14 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/rancher/fleet/modules/agent/pkg/clusterupgrade"
 	"github.com/rancher/fleet/modules/agent/pkg/deployer"
 	"github.com/rancher/fleet/modules/agent/pkg/trigger"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/durations"
 	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/helmdeployer"
+	"github.com/rancher/fleet/pkg/name"
 
 	"github.com/rancher/wrangler/pkg/condition"
 	"github.com/rancher/wrangler/pkg/merr"
@@ -29,15 +31,32 @@ import (
 	"k8s.io/client-go/dynamic"
 )
 
+// deployManager deploys bundle deployments, it's implemented by
+// deployer.Manager
+type deployManager interface {
+	BreakingCRDChanges(ctx context.Context, client dynamic.Interface, bd *fleet.BundleDeployment) ([]string, error)
+	Cleanup() error
+	Delete(bundleDeploymentKey string) error
+	Deploy(bd *fleet.BundleDeployment) (*helmdeployer.Resources, error)
+	MonitorBundle(bd *fleet.BundleDeployment) (deployer.DeploymentStatus, error)
+	Recreate(ctx context.Context, client dynamic.Interface, bd *fleet.BundleDeployment) error
+	RemoveBlueGreen(bd *fleet.BundleDeployment) error
+	ReplaceImmutable(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, bd *fleet.BundleDeployment) (int, error)
+	Resources(bd *fleet.BundleDeployment) (*helmdeployer.Resources, error)
+	SwitchBlueGreen(bd *fleet.BundleDeployment, color, previousRelease string) error
+	WithColor(bd *fleet.BundleDeployment, color string) *fleet.BundleDeployment
+}
+
 type handler struct {
 	cleanupOnce sync.Once
 
 	ctx           context.Context
 	trigger       *trigger.Trigger
-	deployManager *deployer.Manager
+	deployManager deployManager
 	bdController  fleetcontrollers.BundleDeploymentController
 	restMapper    meta.RESTMapper
 	dynamic       dynamic.Interface
+	upgrade       *clusterupgrade.Detector
 }
 
 func Register(ctx context.Context,
@@ -45,7 +64,8 @@ func Register(ctx context.Context,
 	restMapper meta.RESTMapper,
 	dynamic dynamic.Interface,
 	deployManager *deployer.Manager,
-	bdController fleetcontrollers.BundleDeploymentController) {
+	bdController fleetcontrollers.BundleDeploymentController,
+	upgrade *clusterupgrade.Detector) {
 
 	h := &handler{
 		ctx:           ctx,
@@ -54,6 +74,7 @@ func Register(ctx context.Context,
 		bdController:  bdController,
 		restMapper:    restMapper,
 		dynamic:       dynamic,
+		upgrade:       upgrade,
 	}
 
 	fleetcontrollers.RegisterBundleDeploymentStatusHandler(ctx,
@@ -97,17 +118,112 @@ func (h *handler) Cleanup(key string, bd *fleet.BundleDeployment) (*fleet.Bundle
 }
 
 func (h *handler) DeployBundle(bd *fleet.BundleDeployment, status fleet.BundleDeploymentStatus) (fleet.BundleDeploymentStatus, error) {
+	if bd.DeletionTimestamp != nil {
+		return h.postDelete(bd, status)
+	}
+
 	if bd.Spec.Paused {
 		// nothing to do
 		return status, nil
 	}
 
+	if bd.Spec.Options.RunOnce && status.AppliedDeploymentID != "" &&
+		condition.Cond(fleet.BundleDeploymentConditionInstalled).IsTrue(&status) {
+		// run-once bundles are not applied again
+		return status, nil
+	}
+
+	if h.upgrade.Upgrading() {
+		// applying while the API servers are replaced fails or flaps,
+		// retry once the control plane is stable
+		logrus.Debugf("Delaying deployment of %s, the cluster is upgrading", bd.Name)
+		h.bdController.EnqueueAfter(bd.Namespace, bd.Name, durations.ClusterUpgradeCheckInterval)
+		return status, nil
+	}
+
 	if err := h.checkDependency(bd); err != nil {
 		return status, err
 	}
 
-	release, err := h.deployManager.Deploy(bd)
+	if retry := status.WebhookRetry; retry != nil && retry.DeploymentID == bd.Spec.DeploymentID {
+		if wait := time.Until(retry.After.Time); wait > 0 {
+			// backing off after admission webhooks rejected the deployment
+			h.bdController.EnqueueAfter(bd.Namespace, bd.Name, wait)
+			return status, nil
+		}
+	}
+
+	redeploy := bd.Annotations[fleet.RedeployAnnotation]
+	if redeploy != "" && redeploy != status.Redeploy {
+		logrus.Infof("Redeploying %s, as requested by annotation %s=%s", bd.Name, fleet.RedeployAnnotation, redeploy)
+		bd = bd.DeepCopy()
+		bd.Status.AppliedDeploymentID = ""
+	}
+
+	retry := pendingReplaceRetry(bd, status)
+	if retry != nil {
+		if wait := time.Until(retry.After.Time); wait > 0 {
+			// backing off, replaced resources failed to deploy
+			h.bdController.EnqueueAfter(bd.Namespace, bd.Name, wait)
+			return status, nil
+		}
+		// the failed deployment was recorded as applied, replace and
+		// deploy the resources again
+		bd = bd.DeepCopy()
+		bd.Status.AppliedDeploymentID = ""
+	}
+
+	if bd.Spec.DeploymentID != bd.Status.AppliedDeploymentID {
+		changes, err := h.deployManager.BreakingCRDChanges(h.ctx, h.dynamic, bd)
+		if err != nil {
+			return status, err
+		}
+		c := condition.Cond(fleet.BundleDeploymentConditionManualIntervention)
+		if len(changes) > 0 {
+			// Block the rollout, instead of failing while applying
+			// resources. It is retried, once the CRs are migrated. The
+			// error is not returned, as the status would not be saved.
+			msg := "manual intervention required: " + strings.Join(changes, "; ")
+			c.SetStatusBool(&status, true)
+			c.Message(&status, msg)
+			condition.Cond(fleet.BundleDeploymentConditionReady).SetError(&status, "", errors.New(msg))
+			h.bdController.EnqueueAfter(bd.Namespace, bd.Name, durations.ManualInterventionRecheck)
+			return status, nil
+		}
+		if c.IsTrue(&status) {
+			c.SetStatusBool(&status, false)
+			c.Message(&status, "")
+		}
+	}
+
+	deploy, color := bd, ""
+	if bd.Spec.Options.BlueGreen != nil {
+		color = blueGreenColor(bd)
+		deploy = h.deployManager.WithColor(bd, color)
+	}
+
+	if bd.Spec.Options.AllowRecreate && bd.Spec.DeploymentID != bd.Status.AppliedDeploymentID {
+		if err := h.deployManager.Recreate(h.ctx, h.dynamic, deploy); err != nil {
+			return status, err
+		}
+	}
+
+	if bd.Spec.DeploymentID != bd.Status.AppliedDeploymentID {
+		replaced, err := h.deployManager.ReplaceImmutable(h.ctx, h.dynamic, h.restMapper, deploy)
+		if replaced > 0 || retry != nil {
+			status.ReplaceRetry = nextReplaceRetry(retry, bd.Spec.DeploymentID, time.Now())
+		}
+		if err != nil {
+			return h.replaceFailed(bd, status, err)
+		}
+	}
+
+	resources, err := h.deployManager.Deploy(deploy)
 	if err != nil {
+		var webhookErr *helmdeployer.WebhookError
+		if errors.As(err, &webhookErr) {
+			return h.webhookRejected(bd, status, redeploy, webhookErr), nil
+		}
 		// When an error from DeployBundle is returned it causes DeployBundle
 		// to requeue and keep trying to deploy on a loop. If there is something
 		// wrong with the deployed manifests this will be a loop that re-deploying
@@ -123,18 +239,159 @@ func (h *handler) DeployBundle(bd *fleet.BundleDeployment, status fleet.BundleDe
 			// current one is running properly.
 			newStatus.Release = ""
 			newStatus.AppliedDeploymentID = bd.Spec.DeploymentID
+			newStatus.Redeploy = redeploy
+			recordDeployment(&newStatus, bd, fleet.DeploymentResultFailed, err.Error(), time.Now())
+			if retry := pendingReplaceRetry(bd, newStatus); retry != nil {
+				h.bdController.EnqueueAfter(bd.Namespace, bd.Name, time.Until(retry.After.Time))
+			}
 			return newStatus, nil
 		}
-		return status, err
+		return h.replaceFailed(bd, status, err)
+	}
+	if bd.Spec.DeploymentID != bd.Status.AppliedDeploymentID {
+		recordDeployment(&status, bd, fleet.DeploymentResultDeployed, "", time.Now())
 	}
-	status.Release = release
+	status.Release = resources.ID
 	status.AppliedDeploymentID = bd.Spec.DeploymentID
+	status.Redeploy = redeploy
+
+	if err := h.updateBlueGreen(bd, &status, color); err != nil {
+		return status, err
+	}
+	status.OptionalResourceErrors = resources.OptionalErrors
+	status.WebhookRejections = resources.WebhookRejections
+	status.WebhookRetry = nil
+	status.ReplaceRetry = nil
 
 	// Setting the error to nil clears any existing error
 	condition.Cond(fleet.BundleDeploymentConditionInstalled).SetError(&status, "", nil)
 	return status, nil
 }
 
+// webhookRejected reports the resources, which admission webhooks rejected,
+// in the status. Unless the webhook policy is "fail", the deployment is
+// retried with exponential backoff.
+func (h *handler) webhookRejected(bd *fleet.BundleDeployment, status fleet.BundleDeploymentStatus, redeploy string, err *helmdeployer.WebhookError) fleet.BundleDeploymentStatus {
+	var rejected []string
+	for _, r := range err.Rejections {
+		if r.Name == "" {
+			rejected = append(rejected, fmt.Sprintf("webhook %q: %s", r.Webhook, r.Message))
+			continue
+		}
+		key := r.Name
+		if r.Namespace != "" {
+			key = r.Namespace + "/" + r.Name
+		}
+		rejected = append(rejected, fmt.Sprintf("%s %s rejected by webhook %q", r.Kind, key, r.Webhook))
+	}
+	msg := strings.Join(rejected, "; ")
+
+	status.WebhookRejections = err.Rejections
+	status.Ready = false
+	condition.Cond(fleet.BundleDeploymentConditionReady).SetError(&status, "", fmt.Errorf("not ready: %s", msg))
+	condition.Cond(fleet.BundleDeploymentConditionInstalled).SetError(&status, "", fmt.Errorf("not installed: %s", msg))
+
+	now := time.Now()
+	if bd.Spec.Options.WebhookPolicy == fleet.WebhookPolicyFail {
+		status.Release = ""
+		status.AppliedDeploymentID = bd.Spec.DeploymentID
+		status.Redeploy = redeploy
+		status.WebhookRetry = nil
+		recordDeployment(&status, bd, fleet.DeploymentResultFailed, err.Error(), now)
+		return status
+	}
+
+	attempts := 1
+	if retry := status.WebhookRetry; retry != nil && retry.DeploymentID == bd.Spec.DeploymentID {
+		attempts = retry.Attempts + 1
+	}
+	wait := webhookBackoff(attempts)
+	status.WebhookRetry = &fleet.WebhookRetry{
+		DeploymentID: bd.Spec.DeploymentID,
+		Attempts:     attempts,
+		After:        metav1.NewTime(now.Add(wait)),
+	}
+	logrus.Infof("Retrying deployment of %s in %s, admission webhooks rejected it: %s", bd.Name, wait, msg)
+	h.bdController.EnqueueAfter(bd.Namespace, bd.Name, wait)
+	return status
+}
+
+// pendingReplaceRetry returns the retry of the bundle deployment, if it
+// replaced resources, which were not deployed successfully yet.
+func pendingReplaceRetry(bd *fleet.BundleDeployment, status fleet.BundleDeploymentStatus) *fleet.ReplaceRetry {
+	retry := status.ReplaceRetry
+	if retry == nil || retry.DeploymentID != bd.Spec.DeploymentID {
+		return nil
+	}
+	return retry
+}
+
+// replaceFailed returns the error, unless immutable resources were
+// replaced. Then the error is reported in the status and the deployment is
+// retried after the backoff, as returning the error would drop the retry
+// state from the status.
+func (h *handler) replaceFailed(bd *fleet.BundleDeployment, status fleet.BundleDeploymentStatus, err error) (fleet.BundleDeploymentStatus, error) {
+	retry := pendingReplaceRetry(bd, status)
+	if retry == nil {
+		return status, err
+	}
+	condition.Cond(fleet.BundleDeploymentConditionInstalled).SetError(&status, "", err)
+	h.bdController.EnqueueAfter(bd.Namespace, bd.Name, time.Until(retry.After.Time))
+	return status, nil
+}
+
+// nextReplaceRetry returns when to replace the immutable resources of the
+// deployment again, if deploying them fails, so failing resources are not
+// recreated in a tight loop.
+func nextReplaceRetry(retry *fleet.ReplaceRetry, deploymentID string, now time.Time) *fleet.ReplaceRetry {
+	attempts := 1
+	if retry != nil && retry.DeploymentID == deploymentID {
+		attempts = retry.Attempts + 1
+	}
+	wait := backoff(attempts, durations.ImmutableReplaceRetryBase, durations.ImmutableReplaceRetryMax)
+	return &fleet.ReplaceRetry{
+		DeploymentID: deploymentID,
+		Attempts:     attempts,
+		After:        metav1.NewTime(now.Add(wait)),
+	}
+}
+
+// webhookBackoff returns how long to wait before the given attempt to
+// deploy again, doubling with each attempt.
+func webhookBackoff(attempts int) time.Duration {
+	return backoff(attempts, durations.WebhookRejectionRetryBase, durations.WebhookRejectionRetryMax)
+}
+
+// backoff doubles the base duration with each attempt, up to max.
+func backoff(attempts int, base, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		return max
+	}
+	return wait
+}
+
+// postDelete uninstalls a terminating bundle deployment, which waits for
+// its post-delete hooks, and reports the result in the PostDeleteHooks
+// condition, so the controller removes the finalizer. Failed hooks are not
+// retried, as uninstalling removed the release.
+func (h *handler) postDelete(bd *fleet.BundleDeployment, status fleet.BundleDeploymentStatus) (fleet.BundleDeploymentStatus, error) {
+	c := condition.Cond(fleet.BundleDeploymentConditionPostDeleteHooks)
+	if !bd.Spec.Options.PostDeleteHooks || c.GetStatus(&status) != "" {
+		return status, nil
+	}
+
+	err := h.deployManager.Delete(bd.Namespace + "/" + bd.Name)
+	if err != nil {
+		logrus.Errorf("Post-delete hooks of %s failed: %v", bd.Name, err)
+	}
+	c.SetError(&status, "", err)
+	return status, nil
+}
+
 // deployErrToStatus converts an error into a status update
 func deployErrToStatus(err error, status fleet.BundleDeploymentStatus) (bool, fleet.BundleDeploymentStatus) {
 	if err == nil {
@@ -202,7 +459,7 @@ func (h *handler) checkDependency(bd *fleet.BundleDeployment) error {
 			}
 
 			if depend.Name != "" {
-				ls = metav1.AddLabelToSelector(ls, fleet.BundleLabel, depend.Name)
+				ls = metav1.AddLabelToSelector(ls, fleet.BundleLabel, name.LabelValue(depend.Name))
 				ls = metav1.AddLabelToSelector(ls, fleet.BundleNamespaceLabel, bundleNamespace)
 			}
 
@@ -260,6 +517,42 @@ func (h *handler) Trigger(key string, bd *fleet.BundleDeployment) (*fleet.Bundle
 	return bd, nil
 }
 
+// blueGreenColor returns the color to deploy to. An unchanged deployment
+// stays with the active color.
+func blueGreenColor(bd *fleet.BundleDeployment) string {
+	bg := bd.Status.BlueGreen
+	if bd.Spec.DeploymentID == bd.Status.AppliedDeploymentID && bg != nil && bg.Active != "" && bg.Pending == "" {
+		return bg.Active
+	}
+	return deployer.BlueGreenColor(bg)
+}
+
+// updateBlueGreen records the color the release was deployed to. The first
+// release becomes active right away, later ones once they are ready.
+func (h *handler) updateBlueGreen(bd *fleet.BundleDeployment, status *fleet.BundleDeploymentStatus, color string) error {
+	if bd.Spec.Options.BlueGreen == nil {
+		if status.BlueGreen != nil {
+			status.BlueGreen = nil
+			return h.deployManager.RemoveBlueGreen(bd)
+		}
+		return nil
+	}
+
+	bg := status.BlueGreen
+	switch {
+	case bg == nil || bg.Active == "":
+		if err := h.deployManager.SwitchBlueGreen(bd, color, ""); err != nil {
+			return err
+		}
+		status.BlueGreen = &fleet.BlueGreenStatus{Active: color, ActiveRelease: status.Release}
+	case bg.Active == color:
+		bg.ActiveRelease = status.Release
+	default:
+		bg.Pending = color
+	}
+	return nil
+}
+
 func isAgent(bd *fleet.BundleDeployment) bool {
 	return strings.HasPrefix(bd.Name, "fleet-agent")
 }
@@ -309,6 +602,10 @@ func removePrivateFields(s1 *fleet.BundleDeploymentStatus) {
 }
 
 func (h *handler) MonitorBundle(bd *fleet.BundleDeployment, status fleet.BundleDeploymentStatus) (fleet.BundleDeploymentStatus, error) {
+	if bd.DeletionTimestamp != nil {
+		return status, nil
+	}
+
 	if bd.Spec.DeploymentID != status.AppliedDeploymentID {
 		return status, nil
 	}
@@ -342,9 +639,31 @@ func (h *handler) MonitorBundle(bd *fleet.BundleDeployment, status fleet.BundleD
 	status.ModifiedStatus = deploymentStatus.ModifiedStatus
 	status.Ready = deploymentStatus.Ready
 	status.NonModified = deploymentStatus.NonModified
+	status.RolloutHold = deploymentStatus.RolloutHold
+	if status.Ready {
+		recordReady(&status)
+	}
+	if bd.Spec.Options.RunOnce {
+		// run-once resources are not corrected, e.g. finished jobs
+		// may be removed
+		status.ModifiedStatus = nil
+		status.NonModified = true
+	}
+
+	if bg := status.BlueGreen; bd.Spec.Options.BlueGreen != nil && bg != nil && bg.Pending != "" && status.Ready {
+		logrus.Infof("Switching %s from %s to %s", bd.Name, bg.Active, bg.Pending)
+		if err := h.deployManager.SwitchBlueGreen(bd, bg.Pending, bg.ActiveRelease); err != nil {
+			return status, err
+		}
+		status.BlueGreen = &fleet.BlueGreenStatus{Active: bg.Pending, ActiveRelease: status.Release}
+	}
 
 	readyError := readyError(status)
 	condition.Cond(fleet.BundleDeploymentConditionReady).SetError(&status, "", readyError)
+	if len(status.RolloutHold) > 0 {
+		// recheck, as the canary might complete without changes to the deployed resources
+		h.bdController.EnqueueAfter(bd.Namespace, bd.Name, durations.MonitorBundleDelay)
+	}
 	if len(status.ModifiedStatus) > 0 {
 		h.bdController.EnqueueAfter(bd.Namespace, bd.Name, durations.MonitorBundleDelay)
 		if shouldRedeploy(bd) {
@@ -363,6 +682,8 @@ func (h *handler) MonitorBundle(bd *fleet.BundleDeployment, status fleet.BundleD
 		logrus.Errorf("bundle %s: %v", bd.Name, readyError)
 	}
 
+	reduceDetail(&status, bd.Spec.Options.StatusDetail)
+	compactHistory(&status, bd.Spec.Options.StatusRetention, time.Now())
 	removePrivateFields(&status)
 	return status, nil
 }
@@ -0,0 +1,178 @@
+package bundledeployment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rancher/fleet/modules/agent/pkg/deployer"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/helmdeployer"
+
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/generic"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+type fakeDeployManager struct {
+	deployManager
+	breaking  []string
+	replaced  int
+	replaces  int
+	deployErr error
+	deployed  int
+}
+
+func (f *fakeDeployManager) BreakingCRDChanges(context.Context, dynamic.Interface, *fleet.BundleDeployment) ([]string, error) {
+	return f.breaking, nil
+}
+
+func (f *fakeDeployManager) Recreate(context.Context, dynamic.Interface, *fleet.BundleDeployment) error {
+	return nil
+}
+
+func (f *fakeDeployManager) ReplaceImmutable(context.Context, dynamic.Interface, meta.RESTMapper, *fleet.BundleDeployment) (int, error) {
+	f.replaces++
+	return f.replaced, nil
+}
+
+func (f *fakeDeployManager) Deploy(*fleet.BundleDeployment) (*helmdeployer.Resources, error) {
+	if f.deployErr != nil {
+		return nil, f.deployErr
+	}
+	f.deployed++
+	return &helmdeployer.Resources{ID: "ns/release:1"}, nil
+}
+
+func (f *fakeDeployManager) MonitorBundle(*fleet.BundleDeployment) (deployer.DeploymentStatus, error) {
+	return deployer.DeploymentStatus{}, nil
+}
+
+type fakeBundleDeployments struct {
+	fleetcontrollers.BundleDeploymentController
+	handlers map[string]generic.Handler
+	updated  *fleet.BundleDeployment
+	enqueued time.Duration
+}
+
+func (f *fakeBundleDeployments) AddGenericHandler(_ context.Context, name string, handler generic.Handler) {
+	f.handlers[name] = handler
+}
+
+func (f *fakeBundleDeployments) UpdateStatus(bd *fleet.BundleDeployment) (*fleet.BundleDeployment, error) {
+	f.updated = bd
+	return bd, nil
+}
+
+func (f *fakeBundleDeployments) EnqueueAfter(_, _ string, d time.Duration) {
+	f.enqueued = d
+}
+
+func TestDeployBundleManualIntervention(t *testing.T) {
+	ctx := context.Background()
+	manager := &fakeDeployManager{breaking: []string{"crd foos.example.com: version v1 removed"}}
+	bds := &fakeBundleDeployments{handlers: map[string]generic.Handler{}}
+	h := &handler{ctx: ctx, deployManager: manager, bdController: bds}
+	fleetcontrollers.RegisterBundleDeploymentStatusHandler(ctx, bds, "Deployed", "bundle-deploy", h.DeployBundle)
+
+	bd := &fleet.BundleDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-ns", Name: "bd"},
+		Spec:       fleet.BundleDeploymentSpec{DeploymentID: "id:1"},
+	}
+	if _, err := bds.handlers["bundle-deploy"]("cluster-ns/bd", bd); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if bds.updated == nil {
+		t.Fatal("expected the status to be saved")
+	}
+	c := condition.Cond(fleet.BundleDeploymentConditionManualIntervention)
+	if !c.IsTrue(&bds.updated.Status) || c.GetMessage(&bds.updated.Status) != "manual intervention required: "+manager.breaking[0] {
+		t.Errorf("expected the manual intervention condition to be saved, got %+v", bds.updated.Status.Conditions)
+	}
+	if manager.deployed != 0 {
+		t.Error("expected the deployment to be blocked")
+	}
+	if bds.enqueued == 0 {
+		t.Error("expected the bundle deployment to be rechecked")
+	}
+
+	// resolved by migrating the CRs, the rollout continues
+	manager.breaking = nil
+	bds.updated = nil
+	if _, err := bds.handlers["bundle-deploy"]("cluster-ns/bd", bd); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if manager.deployed != 1 {
+		t.Error("expected the bundle deployment to be deployed")
+	}
+}
+
+func TestDeployBundleReplaceRetry(t *testing.T) {
+	ctx := context.Background()
+	manager := &fakeDeployManager{replaced: 1, deployErr: errors.New("connection refused")}
+	bds := &fakeBundleDeployments{handlers: map[string]generic.Handler{}}
+	h := &handler{ctx: ctx, deployManager: manager, bdController: bds}
+	fleetcontrollers.RegisterBundleDeploymentStatusHandler(ctx, bds, "Deployed", "bundle-deploy", h.DeployBundle)
+
+	bd := &fleet.BundleDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-ns", Name: "bd"},
+		Spec: fleet.BundleDeploymentSpec{
+			DeploymentID: "id:1",
+			Options:      fleet.BundleDeploymentOptions{ReplaceImmutable: true},
+		},
+	}
+	sync := func() {
+		t.Helper()
+		bds.updated, bds.enqueued = nil, 0
+		if _, err := bds.handlers["bundle-deploy"]("cluster-ns/bd", bd); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if bds.updated != nil {
+			bd.Status = bds.updated.Status
+		}
+	}
+	expire := func() {
+		bd.Status.ReplaceRetry.After = metav1.NewTime(time.Now().Add(-time.Second))
+	}
+
+	// the retry state is kept, if deploying the replaced resources fails
+	sync()
+	if retry := bd.Status.ReplaceRetry; retry == nil || retry.Attempts != 1 || bds.enqueued == 0 {
+		t.Fatalf("expected the retry to be saved and scheduled, got %+v", retry)
+	}
+	if !condition.Cond(fleet.BundleDeploymentConditionInstalled).IsFalse(&bd.Status) {
+		t.Error("expected the deploy error to be reported")
+	}
+
+	// backing off
+	sync()
+	if manager.replaces != 1 || bds.enqueued == 0 {
+		t.Errorf("expected to back off, replaced %d times", manager.replaces)
+	}
+
+	// errors, which are recorded as applied, are retried as well
+	expire()
+	manager.deployErr = errors.New("timed out waiting for the condition")
+	sync()
+	if manager.replaces != 2 || bd.Status.AppliedDeploymentID != "id:1" || bd.Status.ReplaceRetry.Attempts != 2 || bds.enqueued == 0 {
+		t.Fatalf("expected the failed deployment to be retried, got %+v", bd.Status)
+	}
+
+	expire()
+	manager.replaced, manager.deployErr = 0, nil
+	sync()
+	if manager.replaces != 3 || manager.deployed != 1 || bd.Status.ReplaceRetry != nil {
+		t.Errorf("expected the retry to succeed, got %+v", bd.Status)
+	}
+
+	// succeeded, nothing left to retry
+	sync()
+	if manager.replaces != 3 {
+		t.Error("expected no more retries")
+	}
+}
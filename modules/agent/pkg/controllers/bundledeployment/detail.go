@@ -0,0 +1,26 @@
+package bundledeployment
+
+import (
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// reduceDetail removes the per-resource detail from the status, which is
+// not requested by the bundle's status detail.
+func reduceDetail(status *fleet.BundleDeploymentStatus, detail string) {
+	if detail == fleet.StatusDetailMinimal {
+		status.NonReadyCount, status.ModifiedCount = 0, 0
+		status.NonReadyStatus, status.ModifiedStatus = nil, nil
+		return
+	}
+
+	status.NonReadyCount, status.ModifiedCount = len(status.NonReadyStatus), len(status.ModifiedStatus)
+	if detail == fleet.StatusDetailFull {
+		return
+	}
+	if len(status.NonReadyStatus) > fleet.MaxStatusResources {
+		status.NonReadyStatus = status.NonReadyStatus[:fleet.MaxStatusResources]
+	}
+	if len(status.ModifiedStatus) > fleet.MaxStatusResources {
+		status.ModifiedStatus = status.ModifiedStatus[:fleet.MaxStatusResources]
+	}
+}
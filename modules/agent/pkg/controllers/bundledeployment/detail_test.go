@@ -0,0 +1,34 @@
+package bundledeployment
+
+import (
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestReduceDetail(t *testing.T) {
+	status := func() *fleet.BundleDeploymentStatus {
+		return &fleet.BundleDeploymentStatus{
+			NonReadyStatus: make([]fleet.NonReadyStatus, 12),
+			ModifiedStatus: make([]fleet.ModifiedStatus, 3),
+		}
+	}
+
+	tests := []struct {
+		detail                    string
+		nonReady, modified, count int
+	}{
+		{"", 10, 3, 12},
+		{fleet.StatusDetailNormal, 10, 3, 12},
+		{fleet.StatusDetailFull, 12, 3, 12},
+		{fleet.StatusDetailMinimal, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		s := status()
+		reduceDetail(s, tt.detail)
+		if len(s.NonReadyStatus) != tt.nonReady || len(s.ModifiedStatus) != tt.modified || s.NonReadyCount != tt.count {
+			t.Errorf("%q: unexpected status with %d non-ready, %d modified resources and non-ready count %d",
+				tt.detail, len(s.NonReadyStatus), len(s.ModifiedStatus), s.NonReadyCount)
+		}
+	}
+}
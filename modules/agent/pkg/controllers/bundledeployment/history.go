@@ -0,0 +1,98 @@
+package bundledeployment
+
+import (
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recordDeployment adds the deployment of the bundle deployment's current
+// deployment ID to the history. The newest entry is replaced, if it is for
+// the same deployment ID, e.g. after a failed attempt or a redeploy, unless
+// the outcome didn't change.
+func recordDeployment(status *fleet.BundleDeploymentStatus, bd *fleet.BundleDeployment, result, message string, now time.Time) {
+	entry := fleet.DeploymentHistory{
+		DeploymentID: bd.Spec.DeploymentID,
+		Commit:       bd.Labels["fleet.cattle.io/commit"],
+		DeployedAt:   metav1.Time{Time: now},
+		Result:       result,
+		Message:      message,
+	}
+
+	history := status.History
+	if len(history) > 0 && history[0].DeploymentID == entry.DeploymentID {
+		if history[0].Result == result && history[0].Message == message {
+			// retried with the same outcome
+			return
+		}
+		history = history[1:]
+	}
+	// copy, the status shares the slice with the cached object
+	status.History = append([]fleet.DeploymentHistory{entry}, history...)
+	compactHistory(status, bd.Spec.Options.StatusRetention, now)
+}
+
+// compactHistory removes the deployments beyond the retention's limit and,
+// except for the newest one, those older than its max age from the
+// history, and counts them in the history's summary.
+func compactHistory(status *fleet.BundleDeploymentStatus, retention *fleet.StatusRetention, now time.Time) {
+	limit := fleet.MaxDeploymentHistory
+	var maxAge time.Duration
+	if retention != nil {
+		if retention.HistoryLimit > 0 {
+			limit = retention.HistoryLimit
+		}
+		if retention.HistoryMaxAge != nil {
+			maxAge = retention.HistoryMaxAge.Duration
+		}
+	}
+
+	keep := len(status.History)
+	if keep > limit {
+		keep = limit
+	}
+	if maxAge > 0 {
+		for keep > 1 && now.Sub(status.History[keep-1].DeployedAt.Time) > maxAge {
+			keep--
+		}
+	}
+	if keep == len(status.History) {
+		return
+	}
+
+	// copy, the status shares the summary with the cached object
+	var summary fleet.DeploymentHistorySummary
+	if status.HistorySummary != nil {
+		summary = *status.HistorySummary
+	}
+	for _, entry := range status.History[keep:] {
+		if summary.Deployments == 0 || entry.DeployedAt.Before(&summary.Since) {
+			summary.Since = entry.DeployedAt
+		}
+		if summary.Deployments == 0 || summary.Until.Before(&entry.DeployedAt) {
+			summary.Until = entry.DeployedAt
+		}
+		summary.Deployments++
+		if entry.Result == fleet.DeploymentResultFailed {
+			summary.Failed++
+		}
+	}
+	status.History = status.History[:keep]
+	status.HistorySummary = &summary
+}
+
+// recordReady marks the newest entry of the history as ready, if it is for
+// the applied deployment ID.
+func recordReady(status *fleet.BundleDeploymentStatus) {
+	if len(status.History) == 0 {
+		return
+	}
+	newest := status.History[0]
+	if newest.DeploymentID != status.AppliedDeploymentID || newest.Result != fleet.DeploymentResultDeployed {
+		return
+	}
+	newest.Result = fleet.DeploymentResultReady
+	status.History = append([]fleet.DeploymentHistory{newest}, status.History[1:]...)
+}
@@ -0,0 +1,83 @@
+package bundledeployment
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecordDeployment(t *testing.T) {
+	now := time.Now()
+	status := &fleet.BundleDeploymentStatus{}
+	bd := func(id string) *fleet.BundleDeployment {
+		return &fleet.BundleDeployment{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"fleet.cattle.io/commit": "c-" + id}},
+			Spec:       fleet.BundleDeploymentSpec{DeploymentID: id},
+		}
+	}
+
+	recordDeployment(status, bd("1"), fleet.DeploymentResultFailed, "broken", now)
+	recordDeployment(status, bd("1"), fleet.DeploymentResultFailed, "broken", now.Add(time.Minute))
+	if len(status.History) != 1 || !status.History[0].DeployedAt.Time.Equal(now) {
+		t.Fatalf("expected retry with the same outcome not to be recorded, got %+v", status.History)
+	}
+
+	recordDeployment(status, bd("1"), fleet.DeploymentResultDeployed, "", now)
+	status.AppliedDeploymentID = "1"
+	recordReady(status)
+	if len(status.History) != 1 || status.History[0].Result != fleet.DeploymentResultReady || status.History[0].Commit != "c-1" {
+		t.Fatalf("expected a single ready entry, got %+v", status.History)
+	}
+
+	for i := 2; i <= fleet.MaxDeploymentHistory+2; i++ {
+		recordDeployment(status, bd(fmt.Sprint(i)), fleet.DeploymentResultDeployed, "", now)
+	}
+	if len(status.History) != fleet.MaxDeploymentHistory || status.History[0].DeploymentID != fmt.Sprint(fleet.MaxDeploymentHistory+2) {
+		t.Errorf("expected the newest %d entries, got %+v", fleet.MaxDeploymentHistory, status.History)
+	}
+
+	// the newest entry isn't for the applied deployment
+	recordReady(status)
+	if status.History[0].Result != fleet.DeploymentResultDeployed {
+		t.Errorf("expected entry not to be ready, got %+v", status.History[0])
+	}
+}
+
+func TestCompactHistory(t *testing.T) {
+	now := time.Now()
+	status := &fleet.BundleDeploymentStatus{}
+	for i := 0; i < 5; i++ {
+		result := fleet.DeploymentResultReady
+		if i%2 == 0 {
+			result = fleet.DeploymentResultFailed
+		}
+		status.History = append(status.History, fleet.DeploymentHistory{
+			DeploymentID: fmt.Sprint(i),
+			DeployedAt:   metav1.NewTime(now.Add(-time.Duration(i) * time.Hour)),
+			Result:       result,
+		})
+	}
+
+	compactHistory(status, &fleet.StatusRetention{HistoryLimit: 4}, now)
+	if len(status.History) != 4 || status.HistorySummary == nil || status.HistorySummary.Deployments != 1 || status.HistorySummary.Failed != 1 {
+		t.Fatalf("expected the oldest entry to be compacted, got %+v, %+v", status.History, status.HistorySummary)
+	}
+
+	compactHistory(status, &fleet.StatusRetention{HistoryMaxAge: &metav1.Duration{Duration: 90 * time.Minute}}, now)
+	if len(status.History) != 2 || status.HistorySummary.Deployments != 3 || status.HistorySummary.Failed != 2 {
+		t.Fatalf("expected entries older than the max age to be compacted, got %+v, %+v", status.History, status.HistorySummary)
+	}
+	if !status.HistorySummary.Since.Time.Equal(now.Add(-4*time.Hour)) || !status.HistorySummary.Until.Time.Equal(now.Add(-2*time.Hour)) {
+		t.Errorf("unexpected summary period %+v", status.HistorySummary)
+	}
+
+	// the newest entry is kept, regardless of its age
+	compactHistory(status, &fleet.StatusRetention{HistoryMaxAge: &metav1.Duration{Duration: time.Second}}, now.Add(time.Hour))
+	if len(status.History) != 1 || status.History[0].DeploymentID != "0" || status.HistorySummary.Deployments != 4 {
+		t.Errorf("expected only the newest entry to be kept, got %+v, %+v", status.History, status.HistorySummary)
+	}
+}
@@ -4,11 +4,16 @@ package cluster
 import (
 	"context"
 	"encoding/json"
+	"path"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/rancher/fleet/modules/agent/pkg/clusterupgrade"
+	"github.com/rancher/fleet/modules/agent/pkg/deployer"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/durations"
 	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
@@ -18,18 +23,26 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
 )
 
 type handler struct {
-	agentNamespace   string
-	clusterName      string
-	clusterNamespace string
-	nodes            corecontrollers.NodeCache
-	clusters         fleetcontrollers.ClusterClient
-	reported         fleet.AgentStatus
+	lock              sync.Mutex
+	agentNamespace    string
+	clusterName       string
+	clusterNamespace  string
+	nodes             corecontrollers.NodeCache
+	clusters          fleetcontrollers.ClusterClient
+	discovery         discovery.CachedDiscoveryInterface
+	upgrade           *clusterupgrade.Detector
+	bundleDeployments fleetcontrollers.BundleDeploymentClient
+	deployManager     *deployer.Manager
+	reported          fleet.AgentStatus
 }
 
 func Register(ctx context.Context,
@@ -38,14 +51,22 @@ func Register(ctx context.Context,
 	clusterName string,
 	checkinInterval time.Duration,
 	nodes corecontrollers.NodeCache,
-	clusters fleetcontrollers.ClusterClient) {
+	clusters fleetcontrollers.ClusterClient,
+	discovery discovery.CachedDiscoveryInterface,
+	upgrade *clusterupgrade.Detector,
+	bundleDeployments fleetcontrollers.BundleDeploymentClient,
+	deployManager *deployer.Manager) {
 
-	h := handler{
-		agentNamespace:   agentNamespace,
-		clusterName:      clusterName,
-		clusterNamespace: clusterNamespace,
-		nodes:            nodes,
-		clusters:         clusters,
+	h := &handler{
+		agentNamespace:    agentNamespace,
+		clusterName:       clusterName,
+		clusterNamespace:  clusterNamespace,
+		nodes:             nodes,
+		clusters:          clusters,
+		discovery:         discovery,
+		upgrade:           upgrade,
+		bundleDeployments: bundleDeployments,
+		deployManager:     deployManager,
 	}
 
 	go func() {
@@ -54,6 +75,11 @@ func Register(ctx context.Context,
 			logrus.Errorf("failed to report cluster node status: %v", err)
 		}
 	}()
+	go upgrade.Run(ctx, discovery, func() {
+		if err := h.Update(); err != nil {
+			logrus.Errorf("failed to report cluster upgrade status: %v", err)
+		}
+	})
 	go func() {
 		if checkinInterval == 0 {
 			checkinInterval = durations.DefaultClusterCheckInterval
@@ -68,6 +94,9 @@ func Register(ctx context.Context,
 
 // Update the cluster.fleet.cattle.io status in the upstream cluster with the current node status
 func (h *handler) Update() error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
 	nodes, err := h.nodes.List(labels.Everything())
 	if err != nil {
 		return err
@@ -80,6 +109,7 @@ func (h *handler) Update() error {
 		Namespace:     h.agentNamespace,
 		NonReadyNodes: len(nonReady),
 		ReadyNodes:    len(ready),
+		Upgrading:     h.upgrade.Upgrading(),
 	}
 
 	if len(ready) > 3 {
@@ -91,6 +121,25 @@ func (h *handler) Update() error {
 
 	agentStatus.ReadyNodeNames = ready
 	agentStatus.NonReadyNodeNames = nonReady
+	agentStatus.NodeArchitectures = nodeArchitectures(nodes)
+	agentStatus.CloudProvider = cloudProvider(nodes)
+
+	kubeVersion, apiVersions, err := h.capabilities()
+	if err != nil {
+		// the node status is still worth reporting
+		logrus.Errorf("failed to discover cluster capabilities: %v", err)
+		agentStatus.KubernetesVersion = h.reported.KubernetesVersion
+		agentStatus.APIVersions = h.reported.APIVersions
+	} else {
+		agentStatus.KubernetesVersion = kubeVersion
+		agentStatus.APIVersions = apiVersions
+	}
+	agentStatus.CNI = cni(nodes, agentStatus.APIVersions)
+
+	agentStatus.CompletedDeletions, err = h.uninstallDeleted()
+	if err != nil {
+		logrus.Errorf("failed to uninstall bundle deployments deleted while offline: %v", err)
+	}
 
 	if equality.Semantic.DeepEqual(h.reported, agentStatus) {
 		return nil
@@ -114,6 +163,130 @@ func (h *handler) Update() error {
 	return nil
 }
 
+// uninstallDeleted uninstalls the bundle deployments, which were deleted
+// while the agent was offline, and returns their names. Bundle
+// deployments, which were created again, are not uninstalled.
+func (h *handler) uninstallDeleted() ([]string, error) {
+	cluster, err := h.clusters.Get(h.clusterNamespace, h.clusterName, metav1.GetOptions{})
+	if err != nil {
+		return h.reported.CompletedDeletions, err
+	}
+
+	var completed []string
+	for _, t := range cluster.Status.PendingDeletions {
+		_, err := h.bundleDeployments.Get(cluster.Status.Namespace, t.Name, metav1.GetOptions{})
+		if err == nil {
+			completed = append(completed, t.Name)
+			continue
+		} else if !apierrors.IsNotFound(err) {
+			return completed, err
+		}
+
+		logrus.Infof("Uninstalling bundle deployment %s, which was deleted at %s while the agent was offline", t.Name, t.DeletedAt)
+		if err := h.deployManager.Delete(cluster.Status.Namespace + "/" + t.Name); err != nil {
+			return completed, err
+		}
+		completed = append(completed, t.Name)
+	}
+	sort.Strings(completed)
+	return completed, nil
+}
+
+// capabilities returns the server version and a sorted inventory of the
+// group versions and kinds served by the cluster, in the format used by
+// Helm's .Capabilities.APIVersions
+func (h *handler) capabilities() (string, []string, error) {
+	h.discovery.Invalidate()
+
+	version, err := h.discovery.ServerVersion()
+	if err != nil {
+		return "", nil, err
+	}
+
+	groups, resources, err := h.discovery.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return "", nil, err
+	}
+
+	seen := map[string]struct{}{}
+	for _, g := range groups {
+		for _, gv := range g.Versions {
+			seen[gv.GroupVersion] = struct{}{}
+		}
+	}
+	for _, r := range resources {
+		for _, rl := range r.APIResources {
+			seen[path.Join(r.GroupVersion, rl.Kind)] = struct{}{}
+		}
+	}
+
+	apiVersions := make([]string, 0, len(seen))
+	for k := range seen {
+		apiVersions = append(apiVersions, k)
+	}
+	sort.Strings(apiVersions)
+
+	return version.GitVersion, apiVersions, nil
+}
+
+// nodeArchitectures returns the sorted, distinct architectures of the nodes
+func nodeArchitectures(nodes []*corev1.Node) []string {
+	archs := sets.NewString()
+	for _, node := range nodes {
+		if arch := node.Status.NodeInfo.Architecture; arch != "" {
+			archs.Insert(arch)
+		}
+	}
+	return archs.List()
+}
+
+// cloudProvider returns the provider of the first node, which has a
+// provider ID, e.g. "aws" for "aws:///eu-west-1a/i-0123"
+func cloudProvider(nodes []*corev1.Node) string {
+	for _, node := range nodes {
+		if i := strings.Index(node.Spec.ProviderID, "://"); i > 0 {
+			return node.Spec.ProviderID[:i]
+		}
+	}
+	return ""
+}
+
+// cni detects the network plugin by the annotations it sets on nodes and by
+// the API groups it installs
+func cni(nodes []*corev1.Node, apiVersions []string) string {
+	var calico, flannel, cilium bool
+	for _, node := range nodes {
+		for k := range node.Annotations {
+			switch {
+			case strings.HasPrefix(k, "projectcalico.org/"):
+				calico = true
+			case strings.HasPrefix(k, "flannel.alpha.coreos.com/"):
+				flannel = true
+			case strings.HasPrefix(k, "io.cilium.network."):
+				cilium = true
+			}
+		}
+	}
+	groups := sets.NewString()
+	for _, v := range apiVersions {
+		groups.Insert(strings.SplitN(v, "/", 2)[0])
+	}
+
+	switch {
+	case cilium || groups.Has("cilium.io"):
+		return "cilium"
+	case calico && flannel:
+		return "canal"
+	case calico || groups.Has("crd.projectcalico.org"):
+		return "calico"
+	case groups.Has("crd.antrea.io"):
+		return "antrea"
+	case flannel:
+		return "flannel"
+	}
+	return ""
+}
+
 func sortReadyUnready(nodes []*corev1.Node) (ready []string, nonReady []string) {
 	var (
 		masterNodeNames         []string
@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCloudProvider(t *testing.T) {
+	nodes := []*corev1.Node{
+		{},
+		{Spec: corev1.NodeSpec{ProviderID: "aws:///eu-west-1a/i-0123456789"}},
+	}
+	if p := cloudProvider(nodes); p != "aws" {
+		t.Errorf("expected aws, got %q", p)
+	}
+	if p := cloudProvider(nodes[:1]); p != "" {
+		t.Errorf("expected no provider, got %q", p)
+	}
+}
+
+func TestCNI(t *testing.T) {
+	node := func(annotations ...string) *corev1.Node {
+		n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+		for _, a := range annotations {
+			n.Annotations[a] = "x"
+		}
+		return n
+	}
+
+	tests := []struct {
+		name        string
+		nodes       []*corev1.Node
+		apiVersions []string
+		want        string
+	}{
+		{"none", []*corev1.Node{node()}, []string{"apps/v1"}, ""},
+		{"flannel", []*corev1.Node{node("flannel.alpha.coreos.com/backend-type")}, nil, "flannel"},
+		{"canal", []*corev1.Node{node("flannel.alpha.coreos.com/backend-type", "projectcalico.org/IPv4Address")}, nil, "canal"},
+		{"calico by api", []*corev1.Node{node()}, []string{"crd.projectcalico.org/v1"}, "calico"},
+		{"cilium", []*corev1.Node{node("io.cilium.network.ipv4-cilium-host")}, nil, "cilium"},
+		{"antrea", nil, []string{"crd.antrea.io/v1beta1/Egress"}, "antrea"},
+	}
+	for _, tt := range tests {
+		if c := cni(tt.nodes, tt.apiVersions); c != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, c)
+		}
+	}
+}
@@ -10,9 +10,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/util/workqueue"
 
+	"github.com/rancher/fleet/modules/agent/pkg/clusterupgrade"
 	"github.com/rancher/fleet/modules/agent/pkg/controllers/bundledeployment"
 	"github.com/rancher/fleet/modules/agent/pkg/controllers/cluster"
+	"github.com/rancher/fleet/modules/agent/pkg/controllers/credential"
 	"github.com/rancher/fleet/modules/agent/pkg/deployer"
+	"github.com/rancher/fleet/modules/agent/pkg/standalone"
 	"github.com/rancher/fleet/modules/agent/pkg/trigger"
 	"github.com/rancher/fleet/pkg/durations"
 	"github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io"
@@ -81,6 +84,9 @@ func (a *appContext) start(ctx context.Context) error {
 func Register(ctx context.Context,
 	fleetNamespace, agentNamespace, defaultNamespace, agentScope, clusterNamespace, clusterName string,
 	checkinInterval time.Duration,
+	applyConcurrency int,
+	standaloneOpts *standalone.Options,
+	restart func(),
 	fleetConfig *rest.Config, clientConfig clientcmd.ClientConfig,
 	fleetMapper, mapper meta.RESTMapper,
 	discovery discovery.CachedDiscoveryInterface) error {
@@ -100,34 +106,69 @@ func Register(ctx context.Context,
 	if err != nil {
 		return err
 	}
+	helmDeployer.SetApplyConcurrency(applyConcurrency)
+
+	upgrade := clusterupgrade.New()
+
+	deployManager := deployer.NewManager(
+		fleetNamespace,
+		defaultNamespace,
+		labelPrefix,
+		agentScope,
+		appCtx.Fleet.BundleDeployment().Cache(),
+		manifest.NewLookup(appCtx.Fleet.Content()),
+		helmDeployer,
+		appCtx.Apply)
 
 	bundledeployment.Register(ctx,
 		trigger.New(ctx, appCtx.restMapper, appCtx.Dynamic),
 		appCtx.restMapper,
 		appCtx.Dynamic,
-		deployer.NewManager(
-			fleetNamespace,
-			defaultNamespace,
-			labelPrefix,
-			agentScope,
-			appCtx.Fleet.BundleDeployment().Cache(),
-			manifest.NewLookup(appCtx.Fleet.Content()),
-			helmDeployer,
-			appCtx.Apply),
-		appCtx.Fleet.BundleDeployment())
-
-	cluster.Register(ctx,
-		appCtx.AgentNamespace,
-		appCtx.ClusterNamespace,
-		appCtx.ClusterName,
-		checkinInterval,
-		appCtx.Core.Node().Cache(),
-		appCtx.Fleet.Cluster())
+		deployManager,
+		appCtx.Fleet.BundleDeployment(),
+		upgrade)
+
+	// without a fleet controller there are no credentials to rotate and no
+	// cluster resource to report the status to
+	var standaloneHandler *standalone.Handler
+	if standaloneOpts != nil {
+		standaloneHandler = standalone.New(
+			agentNamespace,
+			*standaloneOpts,
+			appCtx.Apply,
+			appCtx.Fleet.BundleDeployment(),
+			appCtx.Fleet.Content(),
+			appCtx.Core.Secret().Cache())
+	} else {
+		if err := credential.Register(ctx,
+			agentNamespace,
+			appCtx.ClusterNamespace,
+			fleetConfig,
+			appCtx.Core.Secret(),
+			restart); err != nil {
+			return err
+		}
+
+		cluster.Register(ctx,
+			appCtx.AgentNamespace,
+			appCtx.ClusterNamespace,
+			appCtx.ClusterName,
+			checkinInterval,
+			appCtx.Core.Node().Cache(),
+			appCtx.Fleet.Cluster(),
+			appCtx.cachedDiscoveryInterface,
+			upgrade,
+			appCtx.Fleet.BundleDeployment(),
+			deployManager)
+	}
 
 	leader.RunOrDie(ctx, agentNamespace, "fleet-agent-lock", appCtx.K8s, func(ctx context.Context) {
 		if err := appCtx.start(ctx); err != nil {
 			logrus.Fatal(err)
 		}
+		if standaloneHandler != nil {
+			standaloneHandler.Start(ctx)
+		}
 	})
 
 	return nil
@@ -0,0 +1,122 @@
+// Package credential watches the agent's credential secret and restarts the agent, when it points to a different management cluster. (fleetagent)
+//
+// This allows moving agents to a new management cluster. The new credential
+// is read from a secret in the cluster namespace on the current management
+// cluster and copied to the agent's credential secret.
+package credential
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/rancher/fleet/modules/agent/pkg/register"
+	"github.com/rancher/fleet/pkg/durations"
+
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/ticker"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+type handler struct {
+	agentNamespace string
+	fleetConfig    *rest.Config
+	secrets        corecontrollers.SecretClient
+	restart        func()
+}
+
+func Register(ctx context.Context,
+	agentNamespace, clusterNamespace string,
+	fleetConfig *rest.Config,
+	secrets corecontrollers.SecretController,
+	restart func()) error {
+	h := &handler{
+		agentNamespace: agentNamespace,
+		fleetConfig:    fleetConfig,
+		secrets:        secrets,
+		restart:        restart,
+	}
+
+	fleetClient, err := kubernetes.NewForConfig(fleetConfig)
+	if err != nil {
+		return err
+	}
+	go h.watchMigration(ctx, func() (*corev1.Secret, error) {
+		return fleetClient.CoreV1().Secrets(clusterNamespace).Get(ctx, register.MigrationSecretName, metav1.GetOptions{})
+	})
+
+	secrets.OnChange(ctx, "agent-credential", h.OnChange)
+	return nil
+}
+
+// OnChange restarts the agent, if the credential secret contains a
+// kubeconfig for another API server or token. The agent registers with the
+// new kubeconfig after it was restarted.
+func (h *handler) OnChange(key string, secret *corev1.Secret) (*corev1.Secret, error) {
+	if secret == nil || secret.Namespace != h.agentNamespace || secret.Name != register.CredName {
+		return secret, nil
+	}
+
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(secret.Data[register.Kubeconfig])
+	if err != nil {
+		logrus.Warnf("Ignoring invalid kubeconfig in agent credential %s: %v", key, err)
+		return secret, nil
+	}
+
+	if changed(h.fleetConfig, cfg) {
+		logrus.Infof("Agent credential %s changed to API server %s, restarting agent", key, cfg.Host)
+		h.restart()
+	}
+
+	return secret, nil
+}
+
+// watchMigration periodically copies the credential for a new management
+// cluster, if one was stored for the agent, to its credential secret
+func (h *handler) watchMigration(ctx context.Context, get func() (*corev1.Secret, error)) {
+	for range ticker.Context(ctx, durations.AgentMigrationCheckInterval) {
+		migration, err := get()
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			logrus.Debugf("Failed to look up agent migration secret: %v", err)
+			continue
+		}
+		if err := h.migrate(migration); err != nil {
+			logrus.Warnf("Failed to update agent credential from migration secret: %v", err)
+		}
+	}
+}
+
+// migrate copies the new credential to the agent's credential secret, if
+// it differs
+func (h *handler) migrate(migration *corev1.Secret) error {
+	secret, err := h.secrets.Get(h.agentNamespace, register.CredName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(secret.Data[register.Kubeconfig], migration.Data[register.Kubeconfig]) {
+		return nil
+	}
+
+	secret = secret.DeepCopy()
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for k, v := range migration.Data {
+		secret.Data[k] = v
+	}
+	_, err = h.secrets.Update(secret)
+	return err
+}
+
+func changed(current, next *rest.Config) bool {
+	return current.Host != next.Host || current.BearerToken != next.BearerToken
+}
@@ -0,0 +1,84 @@
+package credential
+
+import (
+	"testing"
+
+	"github.com/rancher/fleet/modules/agent/pkg/register"
+
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+type fakeSecrets struct {
+	corecontrollers.SecretController
+	secret *corev1.Secret
+}
+
+func (f *fakeSecrets) Get(namespace, name string, opts metav1.GetOptions) (*corev1.Secret, error) {
+	return f.secret, nil
+}
+
+func (f *fakeSecrets) Update(secret *corev1.Secret) (*corev1.Secret, error) {
+	f.secret = secret
+	return secret, nil
+}
+
+func kubeconfig(host, token string) []byte {
+	return []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: cluster
+  cluster:
+    server: ` + host + `
+users:
+- name: user
+  user:
+    token: ` + token + `
+contexts:
+- name: default
+  context:
+    cluster: cluster
+    user: user
+current-context: default
+`)
+}
+
+func TestMigrate(t *testing.T) {
+	secrets := &fakeSecrets{secret: &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cattle-fleet-system", Name: register.CredName},
+		Data: map[string][]byte{
+			register.Kubeconfig:  kubeconfig("https://old", "old"),
+			register.ClusterName: []byte("c-1"),
+		},
+	}}
+	restarted := false
+	h := &handler{
+		agentNamespace: "cattle-fleet-system",
+		fleetConfig:    &rest.Config{Host: "https://old", BearerToken: "old"},
+		secrets:        secrets,
+		restart:        func() { restarted = true },
+	}
+
+	migration := &corev1.Secret{Data: map[string][]byte{
+		register.Kubeconfig:       kubeconfig("https://new", "new"),
+		register.ClusterNamespace: []byte("fleet-default"),
+	}}
+	if err := h.migrate(migration); err != nil {
+		t.Fatal(err)
+	}
+	data := secrets.secret.Data
+	if string(data[register.Kubeconfig]) != string(migration.Data[register.Kubeconfig]) ||
+		string(data[register.ClusterNamespace]) != "fleet-default" || string(data[register.ClusterName]) != "c-1" {
+		t.Errorf("expected the new credential to be copied, got %v", data)
+	}
+
+	if _, err := h.OnChange("cattle-fleet-system/fleet-agent", secrets.secret); err != nil {
+		t.Fatal(err)
+	}
+	if !restarted {
+		t.Error("expected the agent to restart with the new credential")
+	}
+}
@@ -0,0 +1,88 @@
+package deployer
+
+import (
+	"fmt"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/wrangler/pkg/apply"
+	"github.com/rancher/wrangler/pkg/kv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BlueGreenColor returns the color the next version of a blue/green
+// deployment is deployed to. A pending deployment keeps its color.
+func BlueGreenColor(status *fleet.BlueGreenStatus) string {
+	switch {
+	case status == nil || status.Active == "":
+		return fleet.BlueGreenBlue
+	case status.Pending != "":
+		return status.Pending
+	case status.Active == fleet.BlueGreenBlue:
+		return fleet.BlueGreenGreen
+	default:
+		return fleet.BlueGreenBlue
+	}
+}
+
+// WithColor returns a copy of the bundle deployment, which is deployed to
+// the namespace of the color.
+func (m *Manager) WithColor(bd *fleet.BundleDeployment, color string) *fleet.BundleDeployment {
+	ns := m.namespace(bd) + "-" + color
+	bd = bd.DeepCopy()
+	if bd.Spec.Options.TargetNamespace != "" {
+		bd.Spec.Options.TargetNamespace = ns
+	} else {
+		bd.Spec.Options.DefaultNamespace = ns
+	}
+	return bd
+}
+
+// SwitchBlueGreen points the blue/green service of the bundle deployment
+// to the color and removes the previously active release.
+func (m *Manager) SwitchBlueGreen(bd *fleet.BundleDeployment, color, previousRelease string) error {
+	if service := bd.Spec.Options.BlueGreen.Service; service != "" {
+		ns := m.namespace(bd)
+		svc := &corev1.Service{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Service",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      service,
+				Namespace: ns,
+			},
+			Spec: corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: fmt.Sprintf("%s.%s-%s.svc.cluster.local", service, ns, color),
+			},
+		}
+		if err := m.blueGreenApply(bd.Name).WithDefaultNamespace(ns).ApplyObjects(svc); err != nil {
+			return err
+		}
+	}
+
+	if previousRelease == "" {
+		return nil
+	}
+	releaseKey, _ := kv.Split(previousRelease, ":")
+	return m.deployer.Delete(bd.Name, releaseKey)
+}
+
+// RemoveBlueGreen removes the blue/green service, after blue/green
+// deployments were disabled. The releases of the colors are removed by
+// Cleanup.
+func (m *Manager) RemoveBlueGreen(bd *fleet.BundleDeployment) error {
+	return m.deleteBlueGreenService(bd.Name)
+}
+
+func (m *Manager) deleteBlueGreenService(bundleID string) error {
+	return m.blueGreenApply(bundleID).ApplyObjects()
+}
+
+func (m *Manager) blueGreenApply(bundleID string) apply.Apply {
+	return m.apply.
+		WithSetID("fleet-bluegreen-" + bundleID).
+		WithGVK(corev1.SchemeGroupVersion.WithKind("Service"))
+}
@@ -0,0 +1,43 @@
+package deployer
+
+import (
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestBlueGreenColor(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *fleet.BlueGreenStatus
+		want   string
+	}{
+		{"first deployment", nil, fleet.BlueGreenBlue},
+		{"blue active", &fleet.BlueGreenStatus{Active: fleet.BlueGreenBlue}, fleet.BlueGreenGreen},
+		{"green active", &fleet.BlueGreenStatus{Active: fleet.BlueGreenGreen}, fleet.BlueGreenBlue},
+		{"pending", &fleet.BlueGreenStatus{Active: fleet.BlueGreenGreen, Pending: fleet.BlueGreenGreen}, fleet.BlueGreenGreen},
+	}
+	for _, tt := range tests {
+		if got := BlueGreenColor(tt.status); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestWithColor(t *testing.T) {
+	m := &Manager{defaultNamespace: "default"}
+
+	bd := &fleet.BundleDeployment{}
+	if ns := m.WithColor(bd, fleet.BlueGreenBlue).Spec.Options.DefaultNamespace; ns != "default-blue" {
+		t.Errorf("expected default-blue, got %s", ns)
+	}
+
+	bd.Spec.Options.TargetNamespace = "app"
+	colored := m.WithColor(bd, fleet.BlueGreenGreen)
+	if ns := colored.Spec.Options.TargetNamespace; ns != "app-green" {
+		t.Errorf("expected app-green, got %s", ns)
+	}
+	if bd.Spec.Options.TargetNamespace != "app" {
+		t.Error("expected the bundle deployment not to be modified")
+	}
+}
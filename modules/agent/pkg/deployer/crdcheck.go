@@ -0,0 +1,148 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/helmdeployer"
+
+	"github.com/rancher/wrangler/pkg/kv"
+
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// BreakingCRDChanges renders the bundle deployment and compares the contained
+// CRDs with the ones installed on the cluster. It returns a description of
+// every change, which would invalidate existing custom resources.
+func (m *Manager) BreakingCRDChanges(ctx context.Context, client dynamic.Interface, bd *fleet.BundleDeployment) ([]string, error) {
+	manifestID, _ := kv.Split(bd.Spec.DeploymentID, ":")
+	manifest, err := m.lookup.Get(manifestID)
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := helmdeployer.Template(bd.Name, manifest, bd.Spec.Options)
+	if err != nil {
+		// the deployment will report the error
+		logrus.Debugf("Skipping CRD check for bundle deployment %s, failed to render: %v", bd.Name, err)
+		return nil, nil
+	}
+
+	var result []string
+	for _, obj := range objs {
+		crd, ok := obj.(*unstructured.Unstructured)
+		if !ok || crd.GroupVersionKind().GroupKind() != (schema.GroupKind{Group: crdGVR.Group, Kind: "CustomResourceDefinition"}) {
+			continue
+		}
+
+		existing, err := client.Resource(crdGVR).Get(ctx, crd.GetName(), metav1.GetOptions{})
+		if apierror.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		changes, err := crdChanges(existing, crd)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		// only a problem if there are custom resources
+		inUse, err := hasCustomResources(ctx, client, existing)
+		if err != nil {
+			return nil, err
+		}
+		if inUse {
+			result = append(result, changes...)
+		}
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+// crdChanges returns the breaking changes between the existing and the
+// desired CRD. Removing a stored version, or not serving it anymore and
+// changing the scope are breaking.
+func crdChanges(existing, desired *unstructured.Unstructured) ([]string, error) {
+	var result []string
+
+	oldScope, _, err := unstructured.NestedString(existing.Object, "spec", "scope")
+	if err != nil {
+		return nil, err
+	}
+	newScope, _, err := unstructured.NestedString(desired.Object, "spec", "scope")
+	if err != nil {
+		return nil, err
+	}
+	if newScope != "" && oldScope != newScope {
+		result = append(result, fmt.Sprintf("CRD %s changes scope from %s to %s", desired.GetName(), oldScope, newScope))
+	}
+
+	stored, _, err := unstructured.NestedStringSlice(existing.Object, "status", "storedVersions")
+	if err != nil {
+		return nil, err
+	}
+	served, err := servedVersions(desired)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range stored {
+		if !served[version] {
+			result = append(result, fmt.Sprintf("CRD %s stops serving version %s, which is used to store existing resources", desired.GetName(), version))
+		}
+	}
+
+	return result, nil
+}
+
+func servedVersions(crd *unstructured.Unstructured) (map[string]bool, error) {
+	result := map[string]bool{}
+	versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		version, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, ok := version["name"].(string); ok && version["served"] == true {
+			result[name] = true
+		}
+	}
+	return result, nil
+}
+
+func hasCustomResources(ctx context.Context, client dynamic.Interface, crd *unstructured.Unstructured) (bool, error) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	served, err := servedVersions(crd)
+	if err != nil {
+		return false, err
+	}
+
+	for version := range served {
+		list, err := client.Resource(schema.GroupVersionResource{Group: group, Version: version, Resource: plural}).
+			List(ctx, metav1.ListOptions{Limit: 1})
+		if err != nil {
+			return false, err
+		}
+		return len(list.Items) > 0, nil
+	}
+
+	return false, nil
+}
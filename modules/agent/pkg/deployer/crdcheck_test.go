@@ -0,0 +1,49 @@
+package deployer
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func crd(scope string, versions map[string]bool, stored ...string) *unstructured.Unstructured {
+	var vs []interface{}
+	for name, served := range versions {
+		vs = append(vs, map[string]interface{}{"name": name, "served": served})
+	}
+	var st []interface{}
+	for _, s := range stored {
+		st = append(st, s)
+	}
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec":   map[string]interface{}{"scope": scope, "versions": vs},
+		"status": map[string]interface{}{"storedVersions": st},
+	}}
+	u.SetName("widgets.example.com")
+	return u
+}
+
+func TestCRDChanges(t *testing.T) {
+	existing := crd("Namespaced", map[string]bool{"v1alpha1": true, "v1": true}, "v1alpha1", "v1")
+
+	tests := map[string]struct {
+		desired *unstructured.Unstructured
+		changes int
+	}{
+		"compatible":         {crd("Namespaced", map[string]bool{"v1alpha1": true, "v1": true, "v2": true}), 0},
+		"stored not served":  {crd("Namespaced", map[string]bool{"v1alpha1": false, "v1": true}), 1},
+		"stored removed":     {crd("Namespaced", map[string]bool{"v1": true}), 1},
+		"scope changed":      {crd("Cluster", map[string]bool{"v1alpha1": true, "v1": true}), 1},
+		"scope and versions": {crd("Cluster", map[string]bool{"v2": true}), 3},
+	}
+
+	for name, test := range tests {
+		changes, err := crdChanges(existing, test.desired)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if len(changes) != test.changes {
+			t.Errorf("%s: expected %d changes, got %v", name, test.changes, changes)
+		}
+	}
+}
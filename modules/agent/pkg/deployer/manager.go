@@ -11,6 +11,7 @@ import (
 	"github.com/rancher/wrangler/pkg/kv"
 	apierror "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 type Manager struct {
@@ -43,14 +44,34 @@ func NewManager(fleetNamespace string,
 	}
 }
 
-// releaseKey returns a deploymentKey from namespace+releaseName
-func (m *Manager) releaseKey(bd *fleet.BundleDeployment) string {
-	ns := m.defaultNamespace
+// namespace returns the namespace the bundle deployment is deployed to
+func (m *Manager) namespace(bd *fleet.BundleDeployment) string {
 	if bd.Spec.Options.TargetNamespace != "" {
-		ns = bd.Spec.Options.TargetNamespace
+		return bd.Spec.Options.TargetNamespace
 	} else if bd.Spec.Options.DefaultNamespace != "" {
-		ns = bd.Spec.Options.DefaultNamespace
+		return bd.Spec.Options.DefaultNamespace
+	}
+	return m.defaultNamespace
+}
+
+// releaseKeys returns the keys of the releases, which belong to the bundle
+// deployment. Blue/green deployments have a release per color.
+func (m *Manager) releaseKeys(bd *fleet.BundleDeployment) []string {
+	if bd.Spec.Options.BlueGreen == nil || bd.Status.BlueGreen == nil {
+		return []string{m.releaseKey(bd)}
+	}
+	var keys []string
+	for _, color := range []string{bd.Status.BlueGreen.Active, bd.Status.BlueGreen.Pending} {
+		if color != "" {
+			keys = append(keys, m.releaseKey(m.WithColor(bd, color)))
+		}
 	}
+	return keys
+}
+
+// releaseKey returns a deploymentKey from namespace+releaseName
+func (m *Manager) releaseKey(bd *fleet.BundleDeployment) string {
+	ns := m.namespace(bd)
 
 	if bd.Spec.Options.Helm == nil || bd.Spec.Options.Helm.ReleaseName == "" {
 		return ns + "/" + bd.Name
@@ -78,10 +99,10 @@ func (m *Manager) Cleanup() error {
 			return err
 		}
 
-		key := m.releaseKey(bundleDeployment)
-		if key != deployed.ReleaseName {
+		keys := m.releaseKeys(bundleDeployment)
+		if !sets.NewString(keys...).Has(deployed.ReleaseName) {
 			// found helm secret and bundle deployment for BundleID, but release name doesn't match, so delete the release
-			logrus.Infof("Deleting unknown bundle ID %s, release %s, expecting release %s", deployed.BundleID, deployed.ReleaseName, key)
+			logrus.Infof("Deleting unknown bundle ID %s, release %s, expecting releases %v", deployed.BundleID, deployed.ReleaseName, keys)
 			if err := m.deployer.Delete(deployed.BundleID, deployed.ReleaseName); err != nil {
 				return err
 			}
@@ -93,7 +114,20 @@ func (m *Manager) Cleanup() error {
 
 func (m *Manager) Delete(bundleDeploymentKey string) error {
 	_, name := kv.RSplit(bundleDeploymentKey, "/")
-	return m.deployer.Delete(name, "")
+	deployed, err := m.deployer.ListDeployments()
+	if err != nil {
+		return err
+	}
+	// blue/green deployments have more than one release
+	for _, d := range deployed {
+		if d.BundleID != name {
+			continue
+		}
+		if err := m.deployer.Delete(name, d.ReleaseName); err != nil {
+			return err
+		}
+	}
+	return m.deleteBlueGreenService(name)
 }
 
 // Resources returns the resources that are deployed by the bundle deployment, used by trigger.Watches
@@ -123,26 +157,39 @@ func (m *Manager) Resources(bd *fleet.BundleDeployment) (*helmdeployer.Resources
 
 // Deploy the bundle deployment, i.e. with helmdeployer.
 // This loads the manifest and the contents from the upstream cluster.
-func (m *Manager) Deploy(bd *fleet.BundleDeployment) (string, error) {
+// Optional resources, which could not be applied, and resources rejected
+// by admission webhooks, which were left out, are returned with the release.
+func (m *Manager) Deploy(bd *fleet.BundleDeployment) (*helmdeployer.Resources, error) {
 	if bd.Spec.DeploymentID == bd.Status.AppliedDeploymentID {
 		if ok, err := m.deployer.EnsureInstalled(bd.Name, bd.Status.Release); err != nil {
-			return "", err
+			return nil, err
 		} else if ok {
-			return bd.Status.Release, nil
+			return &helmdeployer.Resources{
+				ID:                bd.Status.Release,
+				OptionalErrors:    bd.Status.OptionalResourceErrors,
+				WebhookRejections: bd.Status.WebhookRejections,
+			}, nil
 		}
 	}
 
-	manifestID, _ := kv.Split(bd.Spec.DeploymentID, ":")
-	manifest, err := m.lookup.Get(manifestID)
+	manifest, err := m.manifest(bd)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return m.deployer.Deploy(bd.Name, manifest, bd.Spec.Options)
+}
 
-	manifest.Commit = bd.Labels["fleet.cattle.io/commit"]
-	resource, err := m.deployer.Deploy(bd.Name, manifest, bd.Spec.Options)
+// manifest loads the bundle deployment's manifest, with its commit and
+// propagated labels, like it is deployed.
+func (m *Manager) manifest(bd *fleet.BundleDeployment) (*manifest.Manifest, error) {
+	manifestID, _ := kv.Split(bd.Spec.DeploymentID, ":")
+	manifest, err := m.lookup.Get(manifestID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return resource.ID, nil
+	manifest.Commit = bd.Labels["fleet.cattle.io/commit"]
+	manifest.Bundle = fleet.DeploymentBundleName(bd)
+	manifest.Labels = helmdeployer.ResourceLabels(bd)
+	return manifest, nil
 }
@@ -30,6 +30,7 @@ type DeploymentStatus struct {
 	NonModified    bool                   `json:"nonModified,omitempty"`
 	NonReadyStatus []fleet.NonReadyStatus `json:"nonReadyStatus,omitempty"`
 	ModifiedStatus []fleet.ModifiedStatus `json:"modifiedStatus,omitempty"`
+	RolloutHold    []string               `json:"rolloutHold,omitempty"`
 }
 
 func (m *Manager) plan(bd *fleet.BundleDeployment, ns string, objs ...runtime.Object) (apply.Plan, error) {
@@ -70,6 +71,12 @@ func (m *Manager) plan(bd *fleet.BundleDeployment, ns string, objs ...runtime.Ob
 			}
 			desiredObj.(*unstructured.Unstructured).SetNamespace(key.Namespace)
 
+			// create-only objects are never updated, changes are not drift
+			if helmdeployer.ApplyStrategy(desiredObj, bd.Spec.Options.ApplyStrategies) == fleet.ApplyStrategyCreateOnly {
+				delete(plan.Update[gvk], key)
+				continue
+			}
+
 			actualObj := live[gvk][key]
 			if actualObj == nil {
 				continue
@@ -103,7 +110,9 @@ func (m *Manager) plan(bd *fleet.BundleDeployment, ns string, objs ...runtime.Ob
 func (m *Manager) normalizers(live objectset.ObjectByGVK, bd *fleet.BundleDeployment) (diff.Normalizer, error) {
 	var ignore []resource.ResourceIgnoreDifferences
 	jsonPatchNorm := &fleetnorm.JSONPatchNormalizer{}
+	var presets []string
 	if bd.Spec.Options.Diff != nil {
+		presets = bd.Spec.Options.Diff.Presets
 		for _, patch := range bd.Spec.Options.Diff.ComparePatches {
 			groupVersion, err := schema.ParseGroupVersion(patch.APIVersion)
 			if err != nil {
@@ -138,7 +147,12 @@ func (m *Manager) normalizers(live objectset.ObjectByGVK, bd *fleet.BundleDeploy
 		return nil, err
 	}
 
-	norm := fleetnorm.New(live, ignoreNorm, jsonPatchNorm)
+	presetNorm, err := fleetnorm.NewPresetNormalizer(presets)
+	if err != nil {
+		return nil, err
+	}
+
+	norm := fleetnorm.New(live, ignoreNorm, jsonPatchNorm, presetNorm)
 	return norm, nil
 }
 
@@ -168,6 +182,9 @@ func (m *Manager) MonitorBundle(bd *fleet.BundleDeployment) (DeploymentStatus, e
 		return status, err
 	}
 
+	if bd.Spec.Options.ProgressiveDelivery {
+		plan.Objects, status.RolloutHold = excludeHeld(plan.Objects)
+	}
 	status.NonReadyStatus = nonReady(plan, bd.Spec.Options.IgnoreOptions)
 	status.ModifiedStatus = modified(plan, resourcesPreviuosRelease)
 	status.Ready = false
@@ -195,10 +212,6 @@ func modified(plan apply.Plan, resourcesPreviousRelease *helmdeployer.Resources)
 	}()
 	for gvk, keys := range plan.Create {
 		for _, key := range keys {
-			if len(result) >= 10 {
-				return
-			}
-
 			apiVersion, kind := gvk.ToAPIVersionAndKind()
 			result = append(result, fleet.ModifiedStatus{
 				Kind:       kind,
@@ -212,10 +225,6 @@ func modified(plan apply.Plan, resourcesPreviousRelease *helmdeployer.Resources)
 
 	for gvk, keys := range plan.Delete {
 		for _, key := range keys {
-			if len(result) >= 10 {
-				return
-			}
-
 			apiVersion, kind := gvk.ToAPIVersionAndKind()
 			// Check if resource was in a previous release. It is possible that some operators copy the
 			// objectset.rio.cattle.io/hash label into a dynamically created objects. We need to skip these resources
@@ -235,10 +244,6 @@ func modified(plan apply.Plan, resourcesPreviousRelease *helmdeployer.Resources)
 
 	for gvk, patches := range plan.Update {
 		for key, patch := range patches {
-			if len(result) >= 10 {
-				break
-			}
-
 			apiVersion, kind := gvk.ToAPIVersionAndKind()
 			result = append(result, fleet.ModifiedStatus{
 				Kind:       kind,
@@ -272,9 +277,6 @@ func nonReady(plan apply.Plan, ignoreOptions fleet.IgnoreOptions) (result []flee
 	}()
 
 	for _, obj := range plan.Objects {
-		if len(result) >= 10 {
-			return
-		}
 		if u, ok := obj.(*unstructured.Unstructured); ok {
 			if ignoreOptions.Conditions != nil {
 				if err := excludeIgnoredConditions(u, ignoreOptions); err != nil {
@@ -0,0 +1,138 @@
+package normalizers
+
+import (
+	"fmt"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fieldRemover removes runtime managed fields from matching objects
+type fieldRemover func(un *unstructured.Unstructured)
+
+var presets = map[string]fieldRemover{
+	fleet.DiffPresetHPAReplicas:     removeReplicas,
+	fleet.DiffPresetVPAResources:    removeContainerResources,
+	fleet.DiffPresetCABundle:        removeCABundle,
+	fleet.DiffPresetDefaultedFields: removeDefaultedFields,
+}
+
+// PresetNormalizer removes the fields of the enabled diff presets from
+// both, the desired and the live object, so they are never reported as
+// modified.
+type PresetNormalizer struct {
+	removers []fieldRemover
+}
+
+// NewPresetNormalizer returns a normalizer for the given preset names.
+func NewPresetNormalizer(names []string) (*PresetNormalizer, error) {
+	p := &PresetNormalizer{}
+	for _, name := range names {
+		remover, ok := presets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown diff preset %q", name)
+		}
+		p.removers = append(p.removers, remover)
+	}
+	return p, nil
+}
+
+func (p *PresetNormalizer) Normalize(un *unstructured.Unstructured) error {
+	if un == nil {
+		return nil
+	}
+	for _, remove := range p.removers {
+		remove(un)
+	}
+	return nil
+}
+
+func isWorkload(un *unstructured.Unstructured) bool {
+	if un.GroupVersionKind().Group != "apps" {
+		return false
+	}
+	switch un.GetKind() {
+	case "Deployment", "StatefulSet", "ReplicaSet", "DaemonSet":
+		return true
+	}
+	return false
+}
+
+func removeReplicas(un *unstructured.Unstructured) {
+	if isWorkload(un) && un.GetKind() != "DaemonSet" {
+		unstructured.RemoveNestedField(un.Object, "spec", "replicas")
+	}
+}
+
+func removeContainerResources(un *unstructured.Unstructured) {
+	if !isWorkload(un) {
+		return
+	}
+	for _, field := range []string{"containers", "initContainers"} {
+		path := []string{"spec", "template", "spec", field}
+		containers, ok, _ := unstructured.NestedSlice(un.Object, path...)
+		if !ok {
+			continue
+		}
+		for _, c := range containers {
+			if container, ok := c.(map[string]interface{}); ok {
+				delete(container, "resources")
+			}
+		}
+		_ = unstructured.SetNestedSlice(un.Object, containers, path...)
+	}
+}
+
+func removeCABundle(un *unstructured.Unstructured) {
+	gvk := un.GroupVersionKind()
+	switch {
+	case gvk.Group == "admissionregistration.k8s.io" &&
+		(gvk.Kind == "MutatingWebhookConfiguration" || gvk.Kind == "ValidatingWebhookConfiguration"):
+		webhooks, ok, _ := unstructured.NestedSlice(un.Object, "webhooks")
+		if !ok {
+			return
+		}
+		for _, w := range webhooks {
+			if webhook, ok := w.(map[string]interface{}); ok {
+				unstructured.RemoveNestedField(webhook, "clientConfig", "caBundle")
+			}
+		}
+		_ = unstructured.SetNestedSlice(un.Object, webhooks, "webhooks")
+	case gvk.Group == "apiextensions.k8s.io" && gvk.Kind == "CustomResourceDefinition":
+		unstructured.RemoveNestedField(un.Object, "spec", "conversion", "webhook", "clientConfig", "caBundle")
+		unstructured.RemoveNestedField(un.Object, "spec", "conversion", "webhookClientConfig", "caBundle")
+	case gvk.Group == "apiregistration.k8s.io" && gvk.Kind == "APIService":
+		unstructured.RemoveNestedField(un.Object, "spec", "caBundle")
+	}
+}
+
+func removeDefaultedFields(un *unstructured.Unstructured) {
+	gvk := un.GroupVersionKind()
+	if gvk.Group == "" && gvk.Kind == "Service" {
+		for _, field := range []string{"clusterIP", "clusterIPs", "ipFamilies", "ipFamilyPolicy", "internalTrafficPolicy", "sessionAffinity"} {
+			unstructured.RemoveNestedField(un.Object, "spec", field)
+		}
+		return
+	}
+	if !isWorkload(un) {
+		return
+	}
+	for _, field := range []string{"revisionHistoryLimit", "progressDeadlineSeconds"} {
+		unstructured.RemoveNestedField(un.Object, "spec", field)
+	}
+	for _, field := range []string{"dnsPolicy", "restartPolicy", "schedulerName", "terminationGracePeriodSeconds"} {
+		unstructured.RemoveNestedField(un.Object, "spec", "template", "spec", field)
+	}
+	containers, ok, _ := unstructured.NestedSlice(un.Object, "spec", "template", "spec", "containers")
+	if !ok {
+		return
+	}
+	for _, c := range containers {
+		if container, ok := c.(map[string]interface{}); ok {
+			delete(container, "terminationMessagePath")
+			delete(container, "terminationMessagePolicy")
+		}
+	}
+	_ = unstructured.SetNestedSlice(un.Object, containers, "spec", "template", "spec", "containers")
+}
@@ -0,0 +1,65 @@
+package normalizers
+
+import (
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPresetNormalizer(t *testing.T) {
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":      "app",
+							"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": "100m"}},
+						},
+					},
+				},
+			},
+		},
+	}}
+	webhook := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1",
+		"kind":       "ValidatingWebhookConfiguration",
+		"webhooks": []interface{}{
+			map[string]interface{}{
+				"name":         "hook",
+				"clientConfig": map[string]interface{}{"caBundle": "Zm9v"},
+			},
+		},
+	}}
+
+	norm, err := NewPresetNormalizer([]string{fleet.DiffPresetHPAReplicas, fleet.DiffPresetVPAResources, fleet.DiffPresetCABundle})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, un := range []*unstructured.Unstructured{deployment, webhook} {
+		if err := norm.Normalize(un); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, ok, _ := unstructured.NestedFieldNoCopy(deployment.Object, "spec", "replicas"); ok {
+		t.Error("expected replicas to be removed")
+	}
+	containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+	if _, ok := containers[0].(map[string]interface{})["resources"]; ok {
+		t.Error("expected container resources to be removed")
+	}
+	webhooks, _, _ := unstructured.NestedSlice(webhook.Object, "webhooks")
+	if _, ok, _ := unstructured.NestedFieldNoCopy(webhooks[0].(map[string]interface{}), "clientConfig", "caBundle"); ok {
+		t.Error("expected caBundle to be removed")
+	}
+
+	if _, err := NewPresetNormalizer([]string{"unknown"}); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+}
@@ -0,0 +1,144 @@
+package deployer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/helmdeployer"
+	"github.com/rancher/fleet/pkg/immutable"
+
+	"github.com/rancher/wrangler/pkg/kv"
+
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var statefulResources = map[string]string{
+	"StatefulSet":           "statefulsets",
+	"PersistentVolumeClaim": "persistentvolumeclaims",
+}
+
+// Recreate deletes the StatefulSets and PersistentVolumeClaims on the
+// cluster, whose immutable fields are changed by the bundle deployment, so
+// they are created again when deploying it. StatefulSets are deleted
+// without their pods.
+func (m *Manager) Recreate(ctx context.Context, client dynamic.Interface, bd *fleet.BundleDeployment) error {
+	manifestID, _ := kv.Split(bd.Spec.DeploymentID, ":")
+	manifest, err := m.lookup.Get(manifestID)
+	if err != nil {
+		return err
+	}
+
+	objs, err := helmdeployer.Template(bd.Name, manifest, bd.Spec.Options)
+	if err != nil {
+		// the deployment will report the error
+		logrus.Debugf("Skipping recreate check for bundle deployment %s, failed to render: %v", bd.Name, err)
+		return nil
+	}
+
+	for _, obj := range objs {
+		desired, ok := obj.(*unstructured.Unstructured)
+		if !ok || !immutable.IsStateful(desired) {
+			continue
+		}
+
+		gvk := desired.GroupVersionKind()
+		gvr := schema.GroupVersionResource{Group: gvk.Group, Version: gvk.Version, Resource: statefulResources[gvk.Kind]}
+		ns := desired.GetNamespace()
+		if ns == "" {
+			ns = m.namespace(bd)
+		}
+
+		existing, err := client.Resource(gvr).Namespace(ns).Get(ctx, desired.GetName(), metav1.GetOptions{})
+		if apierror.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		changes := immutable.Compare(existing, desired)
+		if len(changes) == 0 {
+			continue
+		}
+
+		logrus.Infof("Recreating %s %s/%s for bundle deployment %s: %s", gvk.Kind, ns, desired.GetName(), bd.Name, strings.Join(changes, "; "))
+		orphan := metav1.DeletePropagationOrphan
+		err = client.Resource(gvr).Namespace(ns).Delete(ctx, desired.GetName(), metav1.DeleteOptions{PropagationPolicy: &orphan})
+		if err != nil && !apierror.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReplaceImmutable deletes the resources on the cluster, which are replaced
+// instead of updated, if their spec hash differs from the bundle
+// deployment's, so they are created again when deploying it. These are the
+// resources with the replace apply strategy and Jobs and Pods, if immutable
+// resources are replaced. It returns the number of deleted resources. The
+// bundle deployment is rendered against the cluster, like it is deployed.
+func (m *Manager) ReplaceImmutable(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, bd *fleet.BundleDeployment) (int, error) {
+	manifest, err := m.manifest(bd)
+	if err != nil {
+		return 0, err
+	}
+
+	objs, err := m.deployer.Render(bd.Name, manifest, bd.Spec.Options)
+	if err != nil {
+		// the deployment will report the error
+		logrus.Debugf("Skipping replace check for bundle deployment %s, failed to render: %v", bd.Name, err)
+		return 0, nil
+	}
+
+	replaced := 0
+	for _, obj := range objs {
+		desired, ok := obj.(*unstructured.Unstructured)
+		if !ok || !helmdeployer.Replaced(desired, bd.Spec.Options) {
+			continue
+		}
+
+		gvk := desired.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return replaced, err
+		}
+		var resource dynamic.ResourceInterface = client.Resource(mapping.Resource)
+		ns := ""
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			if ns = desired.GetNamespace(); ns == "" {
+				ns = m.namespace(bd)
+			}
+			resource = client.Resource(mapping.Resource).Namespace(ns)
+		}
+
+		existing, err := resource.Get(ctx, desired.GetName(), metav1.GetOptions{})
+		if apierror.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return replaced, err
+		}
+
+		hash := desired.GetAnnotations()[helmdeployer.SpecHashAnnotation]
+		if hash == "" || existing.GetAnnotations()[helmdeployer.SpecHashAnnotation] == hash {
+			continue
+		}
+
+		logrus.Infof("Replacing %s %s/%s for bundle deployment %s, its spec changed", gvk.Kind, ns, desired.GetName(), bd.Name)
+		background := metav1.DeletePropagationBackground
+		err = resource.Delete(ctx, desired.GetName(), metav1.DeleteOptions{PropagationPolicy: &background})
+		if err != nil && !apierror.IsNotFound(err) {
+			return replaced, err
+		}
+		replaced++
+	}
+
+	return replaced, nil
+}
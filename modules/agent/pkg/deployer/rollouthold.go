@@ -0,0 +1,59 @@
+package deployer
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RolloutHoldAnnotation marks a resource as held by a progressive delivery
+// controller. While set to "true", the resource is not reported as not ready.
+const RolloutHoldAnnotation = "fleet.cattle.io/rollout-hold"
+
+// heldPhases are the status.phase values, for which a progressive delivery
+// controller is mid-rollout, by group and kind
+var heldPhases = map[string]map[string]bool{
+	"argoproj.io/Rollout": {
+		"Progressing": true,
+		"Paused":      true,
+	},
+	"flagger.app/Canary": {
+		"Progressing":      true,
+		"Waiting":          true,
+		"WaitingPromotion": true,
+		"Promoting":        true,
+		"Finalising":       true,
+	},
+}
+
+// excludeHeld removes the resources held by a progressive delivery
+// controller from objs and returns their names
+func excludeHeld(objs []runtime.Object) ([]runtime.Object, []string) {
+	var (
+		result []runtime.Object
+		held   []string
+	)
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if ok && isHeld(u) {
+			held = append(held, fmt.Sprintf("%s %s/%s", u.GetKind(), u.GetNamespace(), u.GetName()))
+			continue
+		}
+		result = append(result, obj)
+	}
+	return result, held
+}
+
+func isHeld(u *unstructured.Unstructured) bool {
+	if u.GetAnnotations()[RolloutHoldAnnotation] == "true" {
+		return true
+	}
+
+	phases, ok := heldPhases[u.GroupVersionKind().Group+"/"+u.GetKind()]
+	if !ok {
+		return false
+	}
+	phase, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+	return phases[phase]
+}
@@ -0,0 +1,46 @@
+package deployer
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestExcludeHeld(t *testing.T) {
+	obj := func(apiVersion, kind, name, phase string, annotations map[string]string) runtime.Object {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}}
+		u.SetAnnotations(annotations)
+		if phase != "" {
+			_ = unstructured.SetNestedField(u.Object, phase, "status", "phase")
+		}
+		return u
+	}
+
+	objs := []runtime.Object{
+		obj("argoproj.io/v1alpha1", "Rollout", "paused", "Paused", nil),
+		obj("argoproj.io/v1alpha1", "Rollout", "healthy", "Healthy", nil),
+		obj("flagger.app/v1beta1", "Canary", "canary", "Progressing", nil),
+		obj("flagger.app/v1beta1", "Canary", "done", "Succeeded", nil),
+		obj("apps/v1", "Deployment", "annotated", "", map[string]string{RolloutHoldAnnotation: "true"}),
+		obj("apps/v1", "Deployment", "plain", "Progressing", nil),
+	}
+
+	result, held := excludeHeld(objs)
+	if len(result) != 3 {
+		t.Errorf("expected 3 remaining objects, got %d", len(result))
+	}
+	expected := []string{"Rollout default/paused", "Canary default/canary", "Deployment default/annotated"}
+	if len(held) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, held)
+	}
+	for i := range expected {
+		if held[i] != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], held[i])
+		}
+	}
+}
@@ -39,6 +39,11 @@ const (
 	DeploymentNamespace = "deploymentNamespace"
 	ClusterNamespace    = "clusterNamespace"
 	ClusterName         = "clusterName"
+
+	// MigrationSecretName is the secret in the cluster namespace on the
+	// management cluster, which holds the agent's credential for a new
+	// management cluster, see 'fleet migrate-manager'
+	MigrationSecretName = "fleet-agent-migration"
 )
 
 type AgentInfo struct {
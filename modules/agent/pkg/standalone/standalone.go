@@ -0,0 +1,337 @@
+// Package standalone deploys the bundles of a git repository to the agent's
+// own cluster, without a fleet controller. (fleetagent)
+//
+// The repository is read like 'fleet apply' reads it, so fleet.yaml files
+// have the same semantics. Each bundle is matched against the local cluster,
+// whose name and labels are configured on the agent, and a BundleDeployment
+// is created in the agent's namespace. The agent deploys it as usual.
+// BundleDeployments of bundles removed from the repository are deleted. The
+// fleet CRDs have to be installed in the cluster.
+//
+// The repository is cloned with the credentials of a secret in the agent's
+// namespace, which has the format of a GitRepo's client secret.
+package standalone
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	cliapply "github.com/rancher/fleet/modules/cli/apply"
+	"github.com/rancher/fleet/modules/cli/pkg/client"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/git"
+	"github.com/rancher/fleet/pkg/manifest"
+	"github.com/rancher/fleet/pkg/options"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/apply"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/kv"
+	"github.com/rancher/wrangler/pkg/ticker"
+	"github.com/rancher/wrangler/pkg/yaml"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// RepoName is used as the fleet.cattle.io/repo-name label of the
+	// bundle deployments, like the name of a gitrepo
+	RepoName = "standalone"
+	setID    = "fleet-agent-standalone"
+)
+
+// Options configure the repository and how the local cluster is matched by
+// the bundles' targets
+type Options struct {
+	Repo     string
+	Branch   string
+	Paths    []string
+	Interval time.Duration
+	// ClientSecretName is the secret in the agent's namespace with the
+	// credentials for the repository, like a GitRepo's client secret
+	ClientSecretName string
+	// CABundle is added to the system's certificate pool for HTTPS
+	CABundle              []byte
+	InsecureSkipTLSverify bool
+	ClusterName           string
+	ClusterLabels         map[string]string
+}
+
+// Handler polls the repository and deploys the bundles of new commits
+type Handler struct {
+	namespace         string
+	opts              Options
+	git               git.Client
+	apply             apply.Apply
+	bundleDeployments fleetcontrollers.BundleDeploymentClient
+	contents          fleetcontrollers.ContentController
+	secrets           corecontrollers.SecretCache
+	store             manifest.Store
+
+	// lastCommit is the last commit, which was deployed successfully
+	lastCommit string
+	// lastContents are the contents used by lastCommit's deployments, they
+	// are looked up from the bundle deployments after a restart
+	lastContents map[string]bool
+}
+
+// New returns the handler, it has to be created before the controllers are
+// started, so the content cache is populated
+func New(namespace string,
+	opts Options,
+	apply apply.Apply,
+	bundleDeployments fleetcontrollers.BundleDeploymentClient,
+	contents fleetcontrollers.ContentController,
+	secrets corecontrollers.SecretCache,
+) *Handler {
+	return &Handler{
+		namespace: namespace,
+		opts:      opts,
+		git:       git.NewClient(),
+		apply: apply.
+			WithSetID(setID).
+			WithDefaultNamespace(namespace).
+			WithListerNamespace(namespace).
+			WithGVK(fleet.SchemeGroupVersion.WithKind("BundleDeployment")),
+		bundleDeployments: bundleDeployments,
+		contents:          contents,
+		secrets:           secrets,
+		store:             manifest.NewStore(contents),
+	}
+}
+
+// Start polls the repository until the context is done
+func (h *Handler) Start(ctx context.Context) {
+	logrus.Infof("Deploying %s (%s) to cluster %s without a fleet controller", h.opts.Repo, h.opts.Branch, h.opts.ClusterName)
+	go func() {
+		for range ticker.Context(ctx, h.opts.Interval) {
+			if err := h.sync(ctx); err != nil {
+				logrus.Errorf("Failed to deploy %s: %v", h.opts.Repo, err)
+			}
+		}
+	}()
+}
+
+func (h *Handler) sync(ctx context.Context) error {
+	gitOpts, err := h.gitOptions()
+	if err != nil {
+		return err
+	}
+	commit, err := h.git.LatestCommit(ctx, gitOpts)
+	if err != nil {
+		return err
+	}
+	if commit == h.lastCommit {
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "fleet-standalone-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := h.git.Clone(ctx, dir, gitOpts); err != nil {
+		return err
+	}
+
+	bundles, err := readBundles(ctx, dir, h.namespace, h.opts.Paths, commit)
+	if err != nil {
+		return err
+	}
+
+	objs, contents, err := h.deployments(bundles)
+	if err != nil {
+		return err
+	}
+	if h.lastContents == nil {
+		if h.lastContents, err = h.deployedContents(); err != nil {
+			return err
+		}
+	}
+	if err := h.apply.ApplyObjects(objs...); err != nil {
+		return err
+	}
+
+	h.purge(contents)
+	h.lastCommit = commit
+	h.lastContents = contents
+	logrus.Infof("Deployed %d bundles of commit %s", len(objs), commit)
+	return nil
+}
+
+// gitOptions returns the options for cloning the repository, with the
+// credentials of the client secret
+func (h *Handler) gitOptions() (*git.Options, error) {
+	opts := &git.Options{
+		URL:             h.opts.Repo,
+		Branch:          h.opts.Branch,
+		CABundle:        h.opts.CABundle,
+		InsecureSkipTLS: h.opts.InsecureSkipTLSverify,
+		Depth:           1,
+	}
+	if h.opts.ClientSecretName == "" {
+		return opts, nil
+	}
+	secret, err := h.secrets.Get(h.namespace, h.opts.ClientSecretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up client secret %s: %w", h.opts.ClientSecretName, err)
+	}
+	if opts.Auth, err = git.AuthFromSecret(secret, h.opts.Repo); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// deployedContents returns the contents used by the existing bundle
+// deployments, which were created before the agent was restarted
+func (h *Handler) deployedContents() (map[string]bool, error) {
+	bds, err := h.bundleDeployments.List(h.namespace, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{fleet.RepoLabel: RepoName}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	contents := map[string]bool{}
+	for _, bd := range bds.Items {
+		for _, id := range []string{bd.Spec.DeploymentID, bd.Spec.StagedDeploymentID} {
+			if manifestID, _ := kv.Split(id, ":"); manifestID != "" {
+				contents[manifestID] = true
+			}
+		}
+	}
+	return contents, nil
+}
+
+// readBundles reads the bundles of the checked out repository, like 'fleet
+// apply' does
+func readBundles(ctx context.Context, dir, namespace string, paths []string, commit string) ([]*fleet.Bundle, error) {
+	var buf bytes.Buffer
+	if err := cliapply.Apply(ctx, &client.Getter{Namespace: namespace}, RepoName, paths, cliapply.Options{
+		Root:   dir,
+		Output: &buf,
+		Labels: map[string]string{
+			fleet.RepoLabel:          RepoName,
+			"fleet.cattle.io/commit": commit,
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	objs, err := yaml.ToObjects(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundles []*fleet.Bundle
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok || u.GetKind() != "Bundle" {
+			continue
+		}
+		// the unstructured converter doesn't support the embedded options
+		data, err := u.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		bundle := &fleet.Bundle{}
+		if err := json.Unmarshal(data, bundle); err != nil {
+			return nil, fmt.Errorf("invalid bundle %s: %w", u.GetName(), err)
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles, nil
+}
+
+// deployments stores the bundles' manifests and returns the bundle
+// deployments for the bundles targeting the local cluster, together with
+// the contents they use.
+func (h *Handler) deployments(bundles []*fleet.Bundle) ([]runtime.Object, map[string]bool, error) {
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      h.opts.ClusterName,
+			Namespace: h.namespace,
+			Labels:    h.opts.ClusterLabels,
+		},
+	}
+	cluster.Status.Namespace = h.namespace
+
+	var objs []runtime.Object
+	contents := map[string]bool{}
+	for _, bundle := range bundles {
+		matches, err := target.Preview(bundle, []*fleet.Cluster{cluster}, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(matches) == 0 || !matches[0].Deployed() {
+			logrus.Debugf("Bundle %s does not target cluster %s", bundle.Name, cluster.Name)
+			continue
+		}
+		opts := *matches[0].Options
+
+		m, err := manifest.New(bundle.Spec.Resources)
+		if err != nil {
+			return nil, nil, err
+		}
+		id, err := h.store.Store(m)
+		if err != nil {
+			return nil, nil, err
+		}
+		contents[id] = true
+
+		deploymentID, err := options.DeploymentID(m, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		t := &target.Target{Bundle: bundle, Cluster: cluster, Options: opts}
+		t.ResetDeployment()
+		bd := t.Deployment
+		bd.Spec = fleet.BundleDeploymentSpec{
+			DeploymentID:       deploymentID,
+			StagedDeploymentID: deploymentID,
+			Options:            opts,
+			StagedOptions:      opts,
+			DependsOn:          bundle.Spec.DependsOn,
+			Paused:             bundle.Spec.Paused,
+		}
+		objs = append(objs, bd)
+	}
+	return objs, contents, nil
+}
+
+// purge deletes the contents of the previous commit, which are no longer
+// used, as there is no fleet controller to garbage collect them
+func (h *Handler) purge(contents map[string]bool) {
+	for id := range h.lastContents {
+		if contents[id] {
+			continue
+		}
+		content, err := h.contents.Get(id, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			logrus.Warnf("Failed to get content %s: %v", id, err)
+			continue
+		}
+		for _, chunk := range content.Chunks {
+			if err := h.contents.Delete(chunk, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				logrus.Warnf("Failed to delete content chunk %s: %v", chunk, err)
+			}
+		}
+		if err := h.contents.Delete(id, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			logrus.Warnf("Failed to delete content %s: %v", id, err)
+		}
+	}
+}
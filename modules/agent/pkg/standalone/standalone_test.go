@@ -0,0 +1,156 @@
+package standalone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/manifest"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeStore struct{}
+
+func (fakeStore) Store(m *manifest.Manifest) (string, error) {
+	_, id, err := m.Content()
+	return id, err
+}
+
+const fleetYaml = `defaultNamespace: app
+targets:
+- name: dev
+  clusterSelector:
+    matchLabels:
+      env: dev
+`
+
+const configMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  key: value
+`
+
+func TestDeployments(t *testing.T) {
+	dir := t.TempDir()
+	app := filepath.Join(dir, "app")
+	if err := os.Mkdir(app, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{"fleet.yaml": fleetYaml, "cm.yaml": configMap} {
+		if err := os.WriteFile(filepath.Join(app, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bundles, err := readBundles(context.Background(), dir, "fleet-local", nil, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles) != 1 {
+		t.Fatalf("expected one bundle, got %d", len(bundles))
+	}
+	if bundles[0].Labels[fleet.RepoLabel] != RepoName {
+		t.Errorf("expected repo label, got %v", bundles[0].Labels)
+	}
+
+	h := &Handler{
+		namespace: "fleet-local",
+		opts:      Options{ClusterName: "local", ClusterLabels: map[string]string{"env": "dev"}},
+		store:     fakeStore{},
+	}
+	objs, contents, err := h.deployments(bundles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 1 || len(contents) != 1 {
+		t.Fatalf("expected one bundle deployment and content, got %d and %d", len(objs), len(contents))
+	}
+	bd := objs[0].(*fleet.BundleDeployment)
+	if bd.Namespace != "fleet-local" || bd.Name != bundles[0].Name {
+		t.Errorf("unexpected bundle deployment %s/%s", bd.Namespace, bd.Name)
+	}
+	if bd.Spec.DeploymentID == "" || bd.Spec.DeploymentID != bd.Spec.StagedDeploymentID {
+		t.Errorf("expected deployment to be rolled out, got %q and %q", bd.Spec.DeploymentID, bd.Spec.StagedDeploymentID)
+	}
+	if bd.Spec.Options.DefaultNamespace != "app" {
+		t.Errorf("expected options from fleet.yaml, got %+v", bd.Spec.Options)
+	}
+	if bd.Labels[fleet.ClusterLabel] != "local" {
+		t.Errorf("expected cluster label, got %v", bd.Labels)
+	}
+
+	h.opts.ClusterLabels = map[string]string{"env": "prod"}
+	objs, _, err = h.deployments(bundles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 0 {
+		t.Errorf("expected bundle not to target the cluster, got %d bundle deployments", len(objs))
+	}
+}
+
+type fakeBundleDeployments struct {
+	fleetcontrollers.BundleDeploymentClient
+	items []fleet.BundleDeployment
+}
+
+func (f fakeBundleDeployments) List(namespace string, opts metav1.ListOptions) (*fleet.BundleDeploymentList, error) {
+	return &fleet.BundleDeploymentList{Items: f.items}, nil
+}
+
+type fakeSecretCache struct {
+	corecontrollers.SecretCache
+	secret *corev1.Secret
+}
+
+func (f fakeSecretCache) Get(namespace, name string) (*corev1.Secret, error) {
+	return f.secret, nil
+}
+
+func TestDeployedContents(t *testing.T) {
+	h := &Handler{
+		namespace: "fleet-local",
+		bundleDeployments: fakeBundleDeployments{items: []fleet.BundleDeployment{
+			{Spec: fleet.BundleDeploymentSpec{DeploymentID: "s-a:1", StagedDeploymentID: "s-b:2"}},
+			{Spec: fleet.BundleDeploymentSpec{DeploymentID: "s-c:3", StagedDeploymentID: "s-c:3"}},
+		}},
+	}
+	contents, err := h.deployedContents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 3 || !contents["s-a"] || !contents["s-b"] || !contents["s-c"] {
+		t.Errorf("unexpected contents %v", contents)
+	}
+}
+
+func TestGitOptions(t *testing.T) {
+	h := &Handler{
+		namespace: "fleet-local",
+		opts:      Options{Repo: "https://git.example.com/repo", Branch: "main", ClientSecretName: "auth", CABundle: []byte("ca")},
+		secrets: fakeSecretCache{secret: &corev1.Secret{
+			Type: corev1.SecretTypeBasicAuth,
+			Data: map[string][]byte{corev1.BasicAuthUsernameKey: []byte("user"), corev1.BasicAuthPasswordKey: []byte("pass")},
+		}},
+	}
+	opts, err := h.gitOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth, ok := opts.Auth.(*http.BasicAuth); !ok || auth.Username != "user" || auth.Password != "pass" {
+		t.Errorf("unexpected auth %v", opts.Auth)
+	}
+	if string(opts.CABundle) != "ca" || opts.Branch != "main" {
+		t.Errorf("unexpected options %+v", opts)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 var (
@@ -46,13 +47,50 @@ type Options struct {
 	HelmRepoURLRegex string
 	KeepResources    bool
 	AuthByPath       map[string]bundlereader.Auth
+	Chart            *bundlereader.Chart
+	// Root is the directory the baseDirs are relative to, defaults to
+	// the working directory. Bundle names do not include it.
+	Root string
+	// SkipUnchanged keeps the existing bundles of directories, whose files
+	// and options did not change since the bundles were created
+	SkipUnchanged bool
+	// SourceHash is set as an annotation on the bundles, so unchanged
+	// directories can be detected
+	SourceHash string
+	// PathHash is set as an annotation on the bundles, so directories
+	// without changes in the git diff can be detected
+	PathHash string
+	// Branch is the branch of the GitRepo the paths were checked out
+	// from, if it follows multiple branches. Its last applied commit is
+	// the base of the git diff.
+	Branch string
+	// Cache stores the rendered bundles, so applying the same commit again
+	// doesn't render them again
+	Cache *BundleCache
+	// ReportPathErrors stores the errors of paths, whose bundles could not
+	// be created, in the status of the GitRepo instead of failing, as long
+	// as other paths succeeded
+	ReportPathErrors bool
+	// VerifyKeysDir contains the trusted public keys. If set, no bundles
+	// are created, unless the commit is signed by one of them.
+	VerifyKeysDir string
+	// Submodules checks out the submodules of the repo in Root, before
+	// creating bundles
+	Submodules          bool
+	SubmodulesRecursive bool
+	// GitCredentials maps the hosts of submodules and LFS objects to
+	// directories with the keys of a basic-auth or ssh-auth secret
+	GitCredentials map[string]string
+	// LFS downloads the LFS objects of the repo in Root and of its checked
+	// out submodules, before creating bundles
+	LFS bool
 }
 
-func globDirs(baseDir string) (result []string, err error) {
+func globDirs(root, baseDir string) (result []string, err error) {
 	for strings.HasPrefix(baseDir, "/") {
 		baseDir = baseDir[1:]
 	}
-	paths, err := filepath.Glob(baseDir)
+	paths, err := filepath.Glob(filepath.Join(root, baseDir))
 	if err != nil {
 		return nil, err
 	}
@@ -72,10 +110,42 @@ func Apply(ctx context.Context, client *client.Getter, repoName string, baseDirs
 		baseDirs = []string{"."}
 	}
 
+	if opts.VerifyKeysDir != "" {
+		if err := verifyCommit(client, repoName, &opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.Submodules {
+		if err := updateSubmodules(opts.Root, opts.SubmodulesRecursive, opts.GitCredentials); err != nil {
+			return fmt.Errorf("failed to check out submodules: %w", err)
+		}
+	}
+	if opts.LFS {
+		if err := pullLFS(opts.Root, opts.Submodules, opts.SubmodulesRecursive, opts.GitCredentials); err != nil {
+			return fmt.Errorf("failed to download LFS objects: %w", err)
+		}
+	}
+
 	foundBundle := false
-	gitRepoBundlesMap := make(map[string]bool)
+	var pathErrors []fleet.GitRepoPathError
+	// bundle names of the repo and the paths they were created from
+	gitRepoBundlesMap := make(map[string]string)
+
+	var (
+		existing *existingHashes
+		changed  *changes
+	)
+	if opts.SkipUnchanged && opts.Output == nil {
+		var err error
+		if existing, err = existingSourceHashes(client, repoFilter(repoName, opts.Labels)); err != nil {
+			return err
+		}
+		changed = changesSinceApplied(client, repoName, &opts)
+	}
+
 	for i, baseDir := range baseDirs {
-		matches, err := globDirs(baseDir)
+		matches, err := globDirs(opts.Root, baseDir)
 		if err != nil {
 			return fmt.Errorf("invalid path glob %s: %w", baseDir, err)
 		}
@@ -97,11 +167,44 @@ func Apply(ctx context.Context, client *client.Getter, repoName string, baseDirs
 				if auth, ok := opts.AuthByPath[path]; ok {
 					opts.Auth = auth
 				}
+				if existing != nil {
+					dirHash, err := pathHash(path, &opts)
+					if err != nil {
+						return err
+					}
+					if names := existing.path[dirHash]; changed != nil && !changed.has(path) && len(names) > 0 {
+						logrus.Infof("%s: not in git diff, keeping bundles %v", path, names)
+						for _, name := range names {
+							gitRepoBundlesMap[name] = path
+						}
+						foundBundle = true
+						return nil
+					}
+					hash, err := sourceHash(path, &opts)
+					if err != nil {
+						return err
+					}
+					if names := existing.source[hash]; hash != "" && len(names) > 0 {
+						logrus.Infof("%s: unchanged, keeping bundles %v", path, names)
+						for _, name := range names {
+							gitRepoBundlesMap[name] = path
+						}
+						foundBundle = true
+						return nil
+					}
+					opts.SourceHash = hash
+					if hash != "" {
+						opts.PathHash = dirHash
+					}
+				}
 				if err := Dir(ctx, client, repoName, path, &opts, gitRepoBundlesMap); err == ErrNoResources {
 					logrus.Warnf("%s: %v", path, err)
 					return nil
 				} else if err != nil {
-					return err
+					// keep going, so a broken path doesn't block the others
+					logrus.Errorf("%s: %v", path, err)
+					pathErrors = append(pathErrors, fleet.GitRepoPathError{Path: path, Message: err.Error()})
+					return nil
 				}
 				foundBundle = true
 
@@ -113,13 +216,31 @@ func Apply(ctx context.Context, client *client.Getter, repoName string, baseDirs
 		}
 	}
 
+	if opts.Cache != nil {
+		logrus.Infof("bundle cache: %d hits, %d misses", opts.Cache.Hits, opts.Cache.Misses)
+	}
+
 	if opts.Output == nil {
-		err := pruneBundlesNotFoundInRepo(client, repoName, gitRepoBundlesMap)
-		if err != nil {
+		if len(pathErrors) > 0 {
+			// the bundles of the failed paths are unknown
+			logrus.Warnf("not pruning bundles of %s, as %d paths failed", repoName, len(pathErrors))
+		} else if err := pruneBundlesNotFoundInRepo(client, repoName, repoFilter(repoName, opts.Labels), gitRepoBundlesMap); err != nil {
 			return err
 		}
 	}
 
+	if opts.ReportPathErrors && opts.Output == nil {
+		if err := reportPathErrors(client, repoName, pathErrors); err != nil {
+			return err
+		}
+		if len(pathErrors) > 0 && foundBundle {
+			return nil
+		}
+	}
+	if len(pathErrors) > 0 {
+		return pathErrorsToError(pathErrors)
+	}
+
 	if !foundBundle {
 		return fmt.Errorf("no resource found at the following paths to deploy: %v", baseDirs)
 	}
@@ -127,20 +248,58 @@ func Apply(ctx context.Context, client *client.Getter, repoName string, baseDirs
 	return nil
 }
 
+// reportPathErrors replaces the path errors in the status of the GitRepo
+func reportPathErrors(client *client.Getter, repoName string, pathErrors []fleet.GitRepoPathError) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"pathErrors": pathErrors,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.Fleet.GitRepo().Patch(client.Namespace, repoName, types.MergePatchType, patch, "status")
+	return err
+}
+
+func pathErrorsToError(pathErrors []fleet.GitRepoPathError) error {
+	messages := make([]string, 0, len(pathErrors))
+	for _, e := range pathErrors {
+		messages = append(messages, fmt.Sprintf("%s: %s", e.Path, e.Message))
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// repoFilter selects the bundles of the repo. For a branch of a GitRepo,
+// which follows multiple branches, repoName is the branch's bundle prefix
+// and only the bundles of the branch are selected.
+func repoFilter(repoName string, bundleLabels map[string]string) labels.Set {
+	if branch := bundleLabels[fleet.RepoBranchLabel]; branch != "" {
+		return labels.Set{
+			fleet.RepoLabel:       bundleLabels[fleet.RepoLabel],
+			fleet.RepoBranchLabel: branch,
+		}
+	}
+	return labels.Set{fleet.RepoLabel: repoName}
+}
+
 // pruneBundlesNotFoundInRepo lists all bundles for this gitrepo and prunes those not found in the repo
-func pruneBundlesNotFoundInRepo(client *client.Getter, repoName string, gitRepoBundlesMap map[string]bool) error {
+func pruneBundlesNotFoundInRepo(client *client.Getter, repoName string, filter labels.Set, gitRepoBundlesMap map[string]string) error {
 	c, err := client.Get()
 	if err != nil {
 		return err
 	}
-	filter := labels.Set(map[string]string{fleet.RepoLabel: repoName})
 	bundles, err := c.Fleet.Bundle().List(client.Namespace, metav1.ListOptions{LabelSelector: filter.AsSelector().String()})
 	if err != nil {
 		return err
 	}
 
 	for _, bundle := range bundles.Items {
-		if ok := gitRepoBundlesMap[bundle.Name]; !ok {
+		if _, ok := gitRepoBundlesMap[bundle.Name]; !ok {
 			logrus.Debugf("Bundle to be deleted since it is not found in gitrepo %v anymore %v %v", repoName, bundle.Namespace, bundle.Name)
 			err = c.Fleet.Bundle().Delete(bundle.Namespace, bundle.Name, nil)
 			if err != nil {
@@ -173,6 +332,7 @@ func readBundle(ctx context.Context, name, baseDir string, opts *Options) (*flee
 		Auth:             opts.Auth,
 		HelmRepoURLRegex: opts.HelmRepoURLRegex,
 		KeepResources:    opts.KeepResources,
+		Chart:            opts.Chart,
 	})
 }
 
@@ -180,26 +340,64 @@ func readBundle(ctx context.Context, name, baseDir string, opts *Options) (*flee
 //
 // name: the gitrepo name, passed to 'fleet apply' on the cli
 // basedir: the path from the walk func in Dir, []baseDirs
-func Dir(ctx context.Context, client *client.Getter, name, baseDir string, opts *Options, gitRepoBundlesMap map[string]bool) error {
+func Dir(ctx context.Context, client *client.Getter, name, baseDir string, opts *Options, gitRepoBundlesMap map[string]string) error {
 	if opts == nil {
 		opts = &Options{}
 	}
 	// the bundleID is a valid helm release name, it's used as a default if a release name is not specified in helm options
-	bundleID := filepath.Join(name, baseDir)
+	relDir := baseDir
+	if opts.Root != "" {
+		if rel, err := filepath.Rel(opts.Root, baseDir); err == nil {
+			relDir = rel
+		}
+	}
+	bundleID := filepath.Join(name, relDir)
 	bundleID = name2.HelmReleaseName(bundleID)
 
-	bundle, scans, err := readBundle(ctx, bundleID, baseDir, opts)
+	if opts.BundleReader == nil && opts.Chart == nil {
+		charts, err := bundlereader.Charts(baseDir, opts.BundleFile)
+		if err != nil {
+			return err
+		}
+		// create a bundle per chart, each deploys a separate helm release
+		for i := range charts {
+			opts := *opts
+			opts.Chart = &charts[i]
+			if err := Dir(ctx, client, name, baseDir, &opts, gitRepoBundlesMap); err != nil {
+				return err
+			}
+		}
+		if len(charts) > 0 {
+			return nil
+		}
+	}
+
+	bundle, scans, err := readBundleCached(ctx, bundleID, baseDir, opts)
 	if err != nil {
 		return err
 	}
 
 	def := bundle.DeepCopy()
 	def.Namespace = client.Namespace
+	if opts.SourceHash != "" {
+		if def.Annotations == nil {
+			def.Annotations = map[string]string{}
+		}
+		def.Annotations[fleet.SourceHashAnnotation] = opts.SourceHash
+		if opts.PathHash != "" {
+			def.Annotations[fleet.PathHashAnnotation] = opts.PathHash
+		}
+	}
 
 	if len(def.Spec.Resources) == 0 {
 		return ErrNoResources
 	}
-	gitRepoBundlesMap[def.Name] = true
+	// names set in fleet.yaml are not derived from the path, so they might
+	// collide
+	if path, ok := gitRepoBundlesMap[def.Name]; ok {
+		return fmt.Errorf("bundle name %q of %s is already used by %s", def.Name, baseDir, path)
+	}
+	gitRepoBundlesMap[def.Name] = baseDir
 
 	objects := []runtime.Object{def}
 	for _, scan := range scans {
@@ -227,6 +425,9 @@ func save(client *client.Getter, bundle *fleet.Bundle, imageScans ...*fleet.Imag
 	}
 
 	obj, err := c.Fleet.Bundle().Get(bundle.Namespace, bundle.Name, metav1.GetOptions{})
+	if repo := obj.Labels[fleet.RepoLabel]; err == nil && repo != "" && repo != bundle.Labels[fleet.RepoLabel] {
+		return fmt.Errorf("bundle %s/%s already exists and belongs to gitrepo %q", obj.Namespace, obj.Name, obj.Labels[fleet.RepoLabel])
+	}
 	if apierrors.IsNotFound(err) {
 		if _, err = c.Fleet.Bundle().Create(bundle); err != nil {
 			return err
@@ -0,0 +1,70 @@
+package apply
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rancher/fleet/modules/cli/pkg/client"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestApplyDuplicateBundleNames(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "fleet.yaml"), []byte("name: payments\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "cm.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Apply(context.Background(), &client.Getter{Namespace: "fleet-local"}, "repo", nil, Options{Root: root, Output: &bytes.Buffer{}})
+	if err == nil || !strings.Contains(err.Error(), `bundle name "payments"`) {
+		t.Errorf("expected duplicate bundle name error, got %v", err)
+	}
+}
+
+func TestApplyBrokenPath(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"good/cm.yaml":      "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n",
+		"broken/fleet.yaml": "helm: [\n",
+		"broken/cm.yaml":    "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	err := Apply(context.Background(), &client.Getter{Namespace: "fleet-local"}, "repo", []string{"broken", "good"}, Options{Root: root, Output: out})
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(root, "broken")) {
+		t.Errorf("expected error naming the broken path, got %v", err)
+	}
+	if !strings.Contains(out.String(), "name: repo-good") {
+		t.Errorf("expected the bundle of the good path to be written, got %s", out.String())
+	}
+}
+
+func TestRepoFilter(t *testing.T) {
+	if filter := repoFilter("repo", map[string]string{fleet.RepoLabel: "repo"}); filter.String() != fleet.RepoLabel+"=repo" {
+		t.Errorf("expected bundles of the repo, got %s", filter)
+	}
+
+	filter := repoFilter("prod", map[string]string{fleet.RepoLabel: "repo", fleet.RepoBranchLabel: "prod"})
+	if filter[fleet.RepoLabel] != "repo" || filter[fleet.RepoBranchLabel] != "prod" {
+		t.Errorf("expected bundles of the repo's branch, got %s", filter)
+	}
+}
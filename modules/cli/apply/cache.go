@@ -0,0 +1,216 @@
+package apply
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/fleetyaml"
+	"github.com/rancher/fleet/pkg/version"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultCacheMaxEntries is the number of rendered bundles kept in the
+// cache, if no limit is given
+const DefaultCacheMaxEntries = 500
+
+const cacheEntrySuffix = ".json"
+
+// BundleCache stores rendered bundles in a directory, keyed by the commit,
+// the path and the fleet.yaml of the bundle, so applying the same commit
+// again doesn't download and render unchanged content. Bundles are not
+// cached if the commit is unknown.
+type BundleCache struct {
+	Dir string
+	// MaxEntries limits the number of cached bundles, the least recently
+	// used ones are removed first
+	MaxEntries int
+
+	Hits   int
+	Misses int
+}
+
+type cacheEntry struct {
+	Bundle *fleet.Bundle      `json:"bundle"`
+	Scans  []*fleet.ImageScan `json:"scans,omitempty"`
+}
+
+// NewBundleCache creates the cache directory, if it doesn't exist yet.
+func NewBundleCache(dir string, maxEntries int) (*BundleCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	return &BundleCache{Dir: dir, MaxEntries: maxEntries}, nil
+}
+
+// key returns the cache key of the bundle read from baseDir, or an empty
+// string if the bundle can't be cached.
+func (c *BundleCache) key(bundleID, baseDir string, opts *Options) (string, error) {
+	commit := opts.Labels[commitLabel]
+	if commit == "" || opts.BundleReader != nil {
+		return "", nil
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", version.Version, commit, bundleID, filepath.Clean(baseDir))
+	if opts.Chart != nil {
+		chart, err := json.Marshal(opts.Chart)
+		if err != nil {
+			return "", err
+		}
+		h.Write(chart)
+	}
+
+	fleetYAML := opts.BundleFile
+	if fleetYAML == "" {
+		fleetYAML = fleetyaml.GetFleetYamlPath(baseDir, false)
+		if _, err := os.Stat(fleetYAML); err != nil {
+			fleetYAML = fleetyaml.GetFleetYamlPath(baseDir, true)
+		}
+	} else if !filepath.IsAbs(fleetYAML) {
+		fleetYAML = filepath.Join(baseDir, fleetYAML)
+	}
+	data, err := os.ReadFile(fleetYAML)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	fmt.Fprintf(h, "%d\x00", len(data))
+	h.Write(data)
+
+	if err := writeOptions(h, opts); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *BundleCache) path(key string) string {
+	return filepath.Join(c.Dir, key+cacheEntrySuffix)
+}
+
+// get returns the cached bundle and image scans for key.
+func (c *BundleCache) get(key string) (*fleet.Bundle, []*fleet.ImageScan, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		c.Misses++
+		return nil, nil, false
+	}
+
+	entry := cacheEntry{}
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Bundle == nil {
+		logrus.Warnf("ignoring invalid bundle cache entry %s: %v", key, err)
+		c.Misses++
+		return nil, nil, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(c.path(key), now, now)
+	c.Hits++
+	return entry.Bundle, entry.Scans, true
+}
+
+// put stores the bundle and image scans for key and removes the least
+// recently used entries beyond the limit.
+func (c *BundleCache) put(key string, bundle *fleet.Bundle, scans []*fleet.ImageScan) error {
+	data, err := json.Marshal(cacheEntry{Bundle: bundle, Scans: scans})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.Dir, key+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return err
+	}
+
+	return c.prune()
+}
+
+func (c *BundleCache) prune() error {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return err
+	}
+
+	type file struct {
+		name    string
+		modTime time.Time
+	}
+	var files []file
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), cacheEntrySuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{name: entry.Name(), modTime: info.ModTime()})
+	}
+	if len(files) <= c.MaxEntries {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	for _, f := range files[c.MaxEntries:] {
+		if err := os.Remove(filepath.Join(c.Dir, f.name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// readBundleCached reads the bundle from the cache, if possible, and
+// stores it in the cache otherwise.
+func readBundleCached(ctx context.Context, name, baseDir string, opts *Options) (*fleet.Bundle, []*fleet.ImageScan, error) {
+	if opts.Cache == nil {
+		return readBundle(ctx, name, baseDir, opts)
+	}
+
+	key, err := opts.Cache.key(name, baseDir, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key == "" {
+		return readBundle(ctx, name, baseDir, opts)
+	}
+
+	if bundle, scans, ok := opts.Cache.get(key); ok {
+		logrus.Debugf("%s: using cached bundle %s", baseDir, bundle.Name)
+		// a forced sync doesn't change the content
+		bundle.Spec.ForceSyncGeneration = opts.SyncGeneration
+		return bundle, scans, nil
+	}
+
+	bundle, scans, err := readBundle(ctx, name, baseDir, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := opts.Cache.put(key, bundle, scans); err != nil {
+		logrus.Warnf("failed to cache bundle %s: %v", bundle.Name, err)
+	}
+	return bundle, scans, nil
+}
@@ -0,0 +1,74 @@
+package apply
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBundleCache(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("fleet.yaml", "namespace: app\n")
+	write("cm.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n")
+
+	cache, err := NewBundleCache(filepath.Join(t.TempDir(), "cache"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &Options{Cache: cache, Labels: map[string]string{commitLabel: "a"}}
+
+	first, _, err := readBundleCached(context.Background(), "repo-app", dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Hits != 0 || cache.Misses != 1 {
+		t.Fatalf("expected a miss, got %d hits, %d misses", cache.Hits, cache.Misses)
+	}
+
+	// the cache key only depends on the commit, not on the files
+	write("cm.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: changed\n")
+	opts.SyncGeneration = 2
+	second, _, err := readBundleCached(context.Background(), "repo-app", dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Hits != 1 {
+		t.Fatalf("expected a hit, got %d hits, %d misses", cache.Hits, cache.Misses)
+	}
+	if second.Spec.Resources[0].Content != first.Spec.Resources[0].Content {
+		t.Error("expected the cached resources")
+	}
+	if second.Spec.ForceSyncGeneration != 2 {
+		t.Errorf("expected the sync generation of the options, got %d", second.Spec.ForceSyncGeneration)
+	}
+
+	write("fleet.yaml", "namespace: other\n")
+	if _, _, err := readBundleCached(context.Background(), "repo-app", dir, opts); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Misses != 2 {
+		t.Errorf("expected a changed fleet.yaml to miss, got %d misses", cache.Misses)
+	}
+
+	entries, err := os.ReadDir(cache.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected the cache to be pruned to 1 entry, got %d", len(entries))
+	}
+
+	opts.Labels = nil
+	if _, _, err := readBundleCached(context.Background(), "repo-app", dir, opts); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Hits+cache.Misses != 3 {
+		t.Error("expected bundles without commit not to use the cache")
+	}
+}
@@ -0,0 +1,214 @@
+package apply
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+
+	"github.com/rancher/fleet/modules/cli/pkg/client"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// changes are the files, which changed since the last applied commit of
+// the GitRepo, relative to root
+type changes struct {
+	root  string
+	files []string
+}
+
+// changesSinceApplied diffs the checked out commit against the last applied
+// commit of the GitRepo. It returns nil, if the diff is not available, e.g.
+// because the GitRepo was never applied or the history of the commit was
+// not cloned. The paths of the last path errors are reported as changed,
+// as their bundles were not updated.
+func changesSinceApplied(client *client.Getter, repoName string, opts *Options) *changes {
+	name := repoName
+	if repo := opts.Labels[fleet.RepoLabel]; repo != "" {
+		// branches use their bundle prefix as repoName
+		name = repo
+	}
+	c, err := client.Get()
+	if err != nil {
+		logrus.Debugf("not diffing paths of %s: %v", name, err)
+		return nil
+	}
+	gitrepo, err := c.Fleet.GitRepo().Get(client.Namespace, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		logrus.Debugf("not diffing paths of %s: %v", name, err)
+		return nil
+	}
+
+	base := gitrepo.Status.AppliedCommit
+	if opts.Branch != "" {
+		base = ""
+		for _, b := range gitrepo.Status.Branches {
+			if b.Name == opts.Branch {
+				base = b.AppliedCommit
+			}
+		}
+	}
+	if base == "" {
+		return nil
+	}
+
+	root := opts.Root
+	if root == "" {
+		root = "."
+	}
+	files, err := gitDiff(root, base)
+	if err != nil {
+		logrus.Infof("comparing checksums of paths, failed to diff against applied commit %s: %v", base, err)
+		return nil
+	}
+	for _, e := range gitrepo.Status.PathErrors {
+		if rel, err := filepath.Rel(root, e.Path); err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+	}
+	logrus.Infof("%d files changed since applied commit %s", len(files), base)
+	return &changes{root: root, files: files}
+}
+
+// has returns true, if files in the path or its subdirectories changed
+func (c *changes) has(path string) bool {
+	rel, err := filepath.Rel(c.root, path)
+	if err != nil {
+		return true
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		return len(c.files) > 0
+	}
+	for _, f := range c.files {
+		if f == rel || strings.HasPrefix(f, rel+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// gitDiff lists the files in root, which differ between the base commit
+// and HEAD, relative to root. Shallow clones lack the base commit, it's
+// fetched without its history. The fetch uses the git binary, like pulling
+// submodules and LFS files, so it authenticates with the credentials the
+// clone was configured with.
+func gitDiff(root, base string) ([]string, error) {
+	repo, err := openRepo(root)
+	if err != nil {
+		return nil, err
+	}
+	baseCommit, err := repo.CommitObject(plumbing.NewHash(base))
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		if _, err := git(root, "fetch", "--quiet", "--depth=1", "origin", base); err != nil {
+			return nil, err
+		}
+		// reopen the repository to read the fetched objects
+		if repo, err = openRepo(root); err != nil {
+			return nil, err
+		}
+		baseCommit, err = repo.CommitObject(plumbing.NewHash(base))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading commit %s: %w", base, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	baseTree, err := baseCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	diff, err := object.DiffTree(baseTree, headTree)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, err := repoPrefix(repo, root)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	seen := map[string]bool{}
+	for _, change := range diff {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name == "" || seen[name] || !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			seen[name] = true
+			files = append(files, strings.TrimPrefix(name, prefix))
+		}
+	}
+	return files, nil
+}
+
+func openRepo(root string) (*gogit.Repository, error) {
+	return gogit.PlainOpenWithOptions(root, &gogit.PlainOpenOptions{DetectDotGit: true})
+}
+
+// repoPrefix returns the path of root in the repository's worktree, with a
+// trailing slash, or an empty string for the worktree's root
+func repoPrefix(repo *gogit.Repository, root string) (string, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	top, err := filepath.EvalSymlinks(worktree.Filesystem.Root())
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(top, dir)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel) + "/", nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		// config options precede the command
+		command := args[0]
+		for i := 0; i+2 < len(args) && args[i] == "-c"; i += 2 {
+			command = args[i+2]
+		}
+		return "", fmt.Errorf("git %s: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
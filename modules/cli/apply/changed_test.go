@@ -0,0 +1,82 @@
+package apply
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGitDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	run := func(args ...string) {
+		if _, err := git(root, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(name, content string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "--quiet")
+	run("config", "user.email", "fleet@example.com")
+	run("config", "user.name", "fleet")
+	write("apps/a/cm.yaml", "kind: ConfigMap\n")
+	write("apps/b/cm.yaml", "kind: ConfigMap\n")
+	run("add", ".")
+	run("commit", "--quiet", "-m", "first")
+	base, err := git(root, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write("apps/b/cm.yaml", "kind: ConfigMap\ndata: {}\n")
+	run("commit", "--quiet", "-am", "second")
+
+	files, err := gitDiff(root, base[:len(base)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, []string{"apps/b/cm.yaml"}) {
+		t.Errorf("unexpected changed files %v", files)
+	}
+
+	files, err = gitDiff(filepath.Join(root, "apps"), base[:len(base)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, []string{"b/cm.yaml"}) {
+		t.Errorf("expected changed files relative to apps, got %v", files)
+	}
+
+	// the base commit is fetched for shallow clones
+	shallow := filepath.Join(t.TempDir(), "shallow")
+	if _, err := git(root, "clone", "--quiet", "--depth=1", "file://"+root, shallow); err != nil {
+		t.Fatal(err)
+	}
+	files, err = gitDiff(shallow, base[:len(base)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, []string{"apps/b/cm.yaml"}) {
+		t.Errorf("unexpected changed files in shallow clone %v", files)
+	}
+
+	c := &changes{root: root, files: files}
+	if c.has(filepath.Join(root, "apps/a")) {
+		t.Error("expected apps/a to be unchanged")
+	}
+	if !c.has(filepath.Join(root, "apps/b")) || !c.has(filepath.Join(root, "apps")) || !c.has(root) {
+		t.Error("expected apps/b and its parents to be changed")
+	}
+}
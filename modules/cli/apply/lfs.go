@@ -0,0 +1,57 @@
+package apply
+
+import (
+	"fmt"
+	"os"
+)
+
+// lfsConfig enables the LFS filters for the git processes, like
+// "git lfs install" does, without changing the global git config
+var lfsConfig = []string{
+	"-c", "filter.lfs.clean=git-lfs clean -- %f",
+	"-c", "filter.lfs.smudge=git-lfs smudge -- %f",
+	"-c", "filter.lfs.process=git-lfs filter-process",
+	"-c", "filter.lfs.required=true",
+}
+
+// pullLFS downloads the LFS objects of the checked out commit of the repo
+// in root and replaces their pointer files. The LFS objects of the
+// checked out submodules are downloaded, too, if submodules is set.
+func pullLFS(root string, submodules, recursive bool, credentials map[string]string) error {
+	if root == "" {
+		root = "."
+	}
+	if _, err := git(root, "lfs", "version"); err != nil {
+		return fmt.Errorf("git-lfs is not installed: %w", err)
+	}
+
+	home, err := os.MkdirTemp("", "fleet-lfs")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(home)
+	config, err := gitAuth(home, credentials)
+	if err != nil {
+		return err
+	}
+	config = append(config, lfsConfig...)
+
+	pullArgs := append([]string{}, config...)
+	pullArgs = append(pullArgs, "lfs", "pull")
+	if _, err := git(root, pullArgs...); err != nil {
+		return err
+	}
+	if !submodules {
+		return nil
+	}
+
+	// the config is passed on to the git processes of the submodules
+	foreachArgs := append([]string{}, config...)
+	foreachArgs = append(foreachArgs, "submodule", "foreach", "--quiet")
+	if recursive {
+		foreachArgs = append(foreachArgs, "--recursive")
+	}
+	foreachArgs = append(foreachArgs, "git lfs pull")
+	_, err = git(root, foreachArgs...)
+	return err
+}
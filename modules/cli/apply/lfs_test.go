@@ -0,0 +1,49 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPullLFS(t *testing.T) {
+	if _, err := git(".", "lfs", "version"); err != nil {
+		t.Skip("git-lfs is not installed")
+	}
+
+	tmp := t.TempDir()
+	run := func(dir string, args ...string) {
+		if _, err := git(dir, append(append([]string{}, lfsConfig...), args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	origin := filepath.Join(tmp, "origin")
+	if err := os.Mkdir(origin, 0700); err != nil {
+		t.Fatal(err)
+	}
+	run(origin, "init", "--quiet")
+	run(origin, "lfs", "track", "*.tgz")
+	if err := os.WriteFile(filepath.Join(origin, "chart.tgz"), []byte("binary chart"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	run(origin, "add", ".")
+	run(origin, "-c", "user.email=fleet@example.com", "-c", "user.name=fleet", "commit", "--quiet", "-m", "chart")
+
+	// the clone contains the pointer files only
+	t.Setenv("GIT_LFS_SKIP_SMUDGE", "1")
+	clone := filepath.Join(tmp, "clone")
+	run(tmp, "clone", "--quiet", origin, clone)
+	if pointer, _ := os.ReadFile(filepath.Join(clone, "chart.tgz")); !strings.HasPrefix(string(pointer), "version https://git-lfs") {
+		t.Fatalf("expected an LFS pointer, got %q", pointer)
+	}
+	t.Setenv("GIT_LFS_SKIP_SMUDGE", "0")
+
+	if err := pullLFS(clone, false, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(clone, "chart.tgz")); string(content) != "binary chart" {
+		t.Errorf("expected the LFS object to be downloaded, got %q", content)
+	}
+}
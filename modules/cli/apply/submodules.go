@@ -0,0 +1,133 @@
+package apply
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// updateSubmodules checks out the submodules of the repo in root at the
+// commits recorded in it. The credentials map the hosts of submodules to
+// directories with the keys of a basic-auth or ssh-auth secret.
+func updateSubmodules(root string, recursive bool, credentials map[string]string) error {
+	if root == "" {
+		root = "."
+	}
+	if _, err := os.Stat(filepath.Join(root, ".gitmodules")); os.IsNotExist(err) {
+		return nil
+	}
+
+	home, err := os.MkdirTemp("", "fleet-submodules")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(home)
+	config, err := gitAuth(home, credentials)
+	if err != nil {
+		return err
+	}
+
+	// the config is passed on to the git processes of nested submodules
+	syncArgs := append([]string{}, config...)
+	syncArgs = append(syncArgs, "submodule", "sync")
+	updateArgs := append([]string{}, config...)
+	updateArgs = append(updateArgs, "submodule", "update", "--init", "--checkout")
+	if recursive {
+		syncArgs = append(syncArgs, "--recursive")
+		updateArgs = append(updateArgs, "--recursive")
+	}
+	if _, err := git(root, syncArgs...); err != nil {
+		return err
+	}
+	if _, err := git(root, updateArgs...); err != nil {
+		return err
+	}
+	out, err := git(root, "submodule", "status", "--recursive")
+	if err != nil {
+		return err
+	}
+	logrus.Infof("checked out submodules:\n%s", strings.TrimSpace(out))
+	return nil
+}
+
+// gitAuth writes the credentials to home and returns the git config
+// options, which use them. Basic auth credentials are stored for the git
+// credential helper, ssh keys are configured per host.
+func gitAuth(home string, credentials map[string]string) ([]string, error) {
+	hosts := make([]string, 0, len(credentials))
+	for host := range credentials {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var (
+		store     strings.Builder
+		sshConfig strings.Builder
+	)
+	knownHosts := filepath.Join(home, "known_hosts")
+	for i, host := range hosts {
+		dir := credentials[host]
+		if key, err := os.ReadFile(filepath.Join(dir, "ssh-privatekey")); err == nil {
+			keyFile := filepath.Join(home, fmt.Sprintf("id-%d", i))
+			if err := os.WriteFile(keyFile, key, 0600); err != nil {
+				return nil, err
+			}
+			checking := "accept-new"
+			if known, err := os.ReadFile(filepath.Join(dir, "known_hosts")); err == nil {
+				if err := appendFile(knownHosts, known); err != nil {
+					return nil, err
+				}
+				checking = "yes"
+			}
+			fmt.Fprintf(&sshConfig, "Host %s\n  IdentityFile %s\n  IdentitiesOnly yes\n  StrictHostKeyChecking %s\n  UserKnownHostsFile %s\n",
+				host, keyFile, checking, knownHosts)
+			continue
+		}
+
+		username, err := os.ReadFile(filepath.Join(dir, "username"))
+		if err != nil {
+			return nil, fmt.Errorf("git credentials for %s contain neither an ssh-privatekey nor a username", host)
+		}
+		password, _ := os.ReadFile(filepath.Join(dir, "password"))
+		u := url.URL{
+			Scheme: "https",
+			Host:   host,
+			User:   url.UserPassword(strings.TrimSpace(string(username)), strings.TrimSpace(string(password))),
+		}
+		store.WriteString(u.String() + "\n")
+	}
+
+	var config []string
+	if store.Len() > 0 {
+		file := filepath.Join(home, "git-credentials")
+		if err := os.WriteFile(file, []byte(store.String()), 0600); err != nil {
+			return nil, err
+		}
+		config = append(config, "-c", "credential.helper=store --file="+file)
+	}
+	if sshConfig.Len() > 0 {
+		file := filepath.Join(home, "ssh_config")
+		if err := os.WriteFile(file, []byte(sshConfig.String()), 0600); err != nil {
+			return nil, err
+		}
+		config = append(config, "-c", "core.sshCommand=ssh -F "+file)
+	}
+	return config, nil
+}
+
+func appendFile(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
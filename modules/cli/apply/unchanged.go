@@ -0,0 +1,145 @@
+package apply
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/rancher/fleet/modules/cli/pkg/client"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/version"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// commitLabel changes with every commit, it doesn't affect the bundles
+const commitLabel = "fleet.cattle.io/commit"
+
+// existingHashes are the names of the repo's bundles by their source
+// hash and path hash annotations
+type existingHashes struct {
+	source map[string][]string
+	path   map[string][]string
+}
+
+// existingSourceHashes returns the names of the repo's bundles, selected
+// by the filter, by their source and path hash annotations
+func existingSourceHashes(client *client.Getter, filter labels.Set) (*existingHashes, error) {
+	c, err := client.Get()
+	if err != nil {
+		return nil, err
+	}
+	bundles, err := c.Fleet.Bundle().List(client.Namespace, metav1.ListOptions{LabelSelector: filter.AsSelector().String()})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &existingHashes{source: map[string][]string{}, path: map[string][]string{}}
+	for _, bundle := range bundles.Items {
+		hash := bundle.Annotations[fleet.SourceHashAnnotation]
+		if hash == "" {
+			continue
+		}
+		result.source[hash] = append(result.source[hash], bundle.Name)
+		if hash := bundle.Annotations[fleet.PathHashAnnotation]; hash != "" {
+			result.path[hash] = append(result.path[hash], bundle.Name)
+		}
+	}
+	return result, nil
+}
+
+// sourceHash returns a checksum of the files in the bundle directory and
+// of the options affecting its bundles. It returns an empty string if the
+// bundle directory refers to files outside of it, as changes to those
+// can't be detected.
+func sourceHash(baseDir string, opts *Options) (string, error) {
+	h := sha256.New()
+	if err := writePath(h, baseDir, opts); err != nil {
+		return "", err
+	}
+
+	outside := false
+	err := filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == ".git" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			// submodules refer to their git directory in the parent repo
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(data, []byte("../")) {
+			outside = true
+			return io.EOF
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", path, len(data))
+		h.Write(data)
+		return nil
+	})
+	if outside {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// pathHash returns a checksum of the bundle directory's path and of the
+// options affecting its bundles, but not of its files. Together with the
+// git diff it finds the bundles of unchanged paths without reading them.
+func pathHash(baseDir string, opts *Options) (string, error) {
+	h := sha256.New()
+	if err := writePath(h, baseDir, opts); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writePath(w io.Writer, baseDir string, opts *Options) error {
+	fmt.Fprintf(w, "%s\x00%s\x00%d\x00", version.Version, filepath.Clean(baseDir), opts.SyncGeneration)
+	return writeOptions(w, opts)
+}
+
+// writeOptions writes the options, which affect the bundles read from a
+// directory, except for the commit and the sync generation
+func writeOptions(w io.Writer, opts *Options) error {
+	fmt.Fprintf(w, "%s\x00%s\x00%v\x00%v\x00%s\x00%v\x00",
+		opts.ServiceAccount, opts.TargetNamespace, opts.Paused, opts.KeepResources, opts.HelmRepoURLRegex, opts.Compress)
+
+	keys := make([]string, 0, len(opts.Labels))
+	for k := range opts.Labels {
+		if k != commitLabel {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s=%s\x00", k, opts.Labels[k])
+	}
+
+	if opts.TargetsFile != "" {
+		data, err := os.ReadFile(opts.TargetsFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		_, _ = w.Write(data)
+	}
+	return nil
+}
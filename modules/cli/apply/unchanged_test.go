@@ -0,0 +1,45 @@
+package apply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("fleet.yaml", "namespace: app\n")
+	write("cm.yaml", "kind: ConfigMap\n")
+
+	opts := &Options{Labels: map[string]string{commitLabel: "a", "env": "prod"}}
+	first, err := sourceHash(dir, opts)
+	if err != nil || first == "" {
+		t.Fatalf("expected hash, got %q, %v", first, err)
+	}
+
+	opts.Labels[commitLabel] = "b"
+	if hash, _ := sourceHash(dir, opts); hash != first {
+		t.Error("expected a new commit without changes to keep the hash")
+	}
+
+	opts.Paused = true
+	if hash, _ := sourceHash(dir, opts); hash == first {
+		t.Error("expected changed options to change the hash")
+	}
+	opts.Paused = false
+
+	write("cm.yaml", "kind: ConfigMap\ndata: {}\n")
+	if hash, _ := sourceHash(dir, opts); hash == first {
+		t.Error("expected changed files to change the hash")
+	}
+
+	write("kustomization.yaml", "resources:\n- ../base\n")
+	if hash, _ := sourceHash(dir, opts); hash != "" {
+		t.Errorf("expected no hash for a directory referring to files outside of it, got %s", hash)
+	}
+}
@@ -0,0 +1,106 @@
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/rancher/fleet/modules/cli/pkg/client"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetgit "github.com/rancher/fleet/pkg/git"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// verifyCommit checks that the checked out commit is signed by one of the
+// keys in opts.VerifyKeysDir. The result is stored in the status of the
+// GitRepo, unless the bundles are written to the output.
+func verifyCommit(client *client.Getter, repoName string, opts *Options) error {
+	root := opts.Root
+	if root == "" {
+		root = "."
+	}
+
+	verification := &fleet.CommitVerification{
+		Commit:      opts.Labels[commitLabel],
+		LastChecked: metav1.Now(),
+	}
+	keys, err := readTrustedKeys(opts.VerifyKeysDir)
+	if err == nil {
+		verification.Signer, err = fleetgit.VerifyCommit(root, verification.Commit, keys)
+	}
+	if err != nil {
+		verification.Message = err.Error()
+		logrus.Errorf("commit %s failed verification: %v", verification.Commit, err)
+	} else {
+		verification.Verified = true
+		logrus.Infof("commit %s is signed by %s", verification.Commit, verification.Signer)
+	}
+
+	if opts.Output == nil {
+		name := repoName
+		if repo := opts.Labels[fleet.RepoLabel]; repo != "" {
+			// branches use their bundle prefix as repoName
+			name = repo
+		}
+		if err := reportVerification(client, name, verification); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("commit %s failed verification: %w", verification.Commit, err)
+	}
+	return nil
+}
+
+// readTrustedKeys reads the keys of a mounted secret, ignoring the hidden
+// files kubernetes uses to update it
+func readTrustedKeys(dir string) (*fleetgit.TrustedKeys, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files [][]byte
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, data)
+	}
+	return fleetgit.ParseTrustedKeys(files...)
+}
+
+// reportVerification replaces the verification in the status of the GitRepo
+func reportVerification(client *client.Getter, repoName string, verification *fleet.CommitVerification) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+	// all fields are set, so the merge patch clears those of the last
+	// verification
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"verification": map[string]interface{}{
+				"commit":      verification.Commit,
+				"verified":    verification.Verified,
+				"signer":      verification.Signer,
+				"message":     verification.Message,
+				"lastChecked": verification.LastChecked,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.Fleet.GitRepo().Patch(client.Namespace, repoName, types.MergePatchType, patch, "status")
+	return err
+}
@@ -0,0 +1,279 @@
+// Package backup exports the Fleet resources of a namespace into a yaml
+// snapshot and restores them, e.g. into a new management cluster. (fleetapply)
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/rancher/fleet/modules/cli/pkg/client"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/gitrepodefaults"
+
+	"github.com/rancher/wrangler/pkg/yaml"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// FormatVersion is written to the header of every snapshot
+const FormatVersion = "v1"
+
+// restoreOrder is the order in which kinds are restored. Clusters are
+// restored before anything targeting them, secrets before the gitrepos using
+// them and gitrepos before their bundles, so the bundles are not considered
+// orphaned.
+var restoreOrder = []string{
+	"Secret",
+	"ClusterGroup",
+	"Cluster",
+	"ClusterRegistrationToken",
+	"GitRepoRestriction",
+	"GitRepoDefaults",
+	"BundleNamespaceMapping",
+	"GitRepo",
+	"Bundle",
+}
+
+type Options struct {
+	Output io.Writer
+	// IncludeSecrets adds the secrets referenced by gitrepos and clusters
+	// to the snapshot
+	IncludeSecrets bool
+}
+
+// Backup writes all Fleet resources of the client's namespace as a yaml
+// stream. Status and server generated metadata are not included.
+func Backup(ctx context.Context, client *client.Getter, opts Options) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+	ns := c.Namespace
+
+	var objs []runtime.Object
+	secrets := map[string]bool{}
+
+	clusterGroups, err := c.Fleet.ClusterGroup().List(ns, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range clusterGroups.Items {
+		objs = append(objs, &clusterGroups.Items[i])
+	}
+
+	clusters, err := c.Fleet.Cluster().List(ns, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range clusters.Items {
+		objs = append(objs, &clusters.Items[i])
+		secrets[clusters.Items[i].Spec.KubeConfigSecret] = true
+	}
+
+	tokens, err := c.Fleet.ClusterRegistrationToken().List(ns, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range tokens.Items {
+		objs = append(objs, &tokens.Items[i])
+	}
+
+	restrictions, err := c.Fleet.GitRepoRestriction().List(ns, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range restrictions.Items {
+		objs = append(objs, &restrictions.Items[i])
+		secrets[restrictions.Items[i].DefaultClientSecretName] = true
+	}
+
+	defaults, err := c.Fleet.GitRepoDefaults().List(ns, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var gitRepoDefaults []*fleet.GitRepoDefaults
+	for i := range defaults.Items {
+		objs = append(objs, &defaults.Items[i])
+		gitRepoDefaults = append(gitRepoDefaults, &defaults.Items[i])
+		secrets[defaults.Items[i].Spec.ClientSecretName] = true
+		secrets[defaults.Items[i].Spec.HelmSecretName] = true
+		secrets[defaults.Items[i].Spec.WebhookClientSecretName] = true
+	}
+	inherited := gitrepodefaults.Aggregate(gitRepoDefaults)
+
+	mappings, err := c.Fleet.BundleNamespaceMapping().List(ns, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range mappings.Items {
+		objs = append(objs, &mappings.Items[i])
+	}
+
+	gitrepos, err := c.Fleet.GitRepo().List(ns, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range gitrepos.Items {
+		objs = append(objs, &gitrepos.Items[i])
+		gitrepo := gitrepodefaults.Apply(&gitrepos.Items[i], inherited)
+		secrets[gitrepo.Spec.ClientSecretName] = true
+		secrets[gitrepo.Spec.HelmSecretName] = true
+		secrets[gitrepo.Spec.HelmSecretNameForPaths] = true
+		if gitrepo.Spec.Webhook != nil {
+			secrets[gitrepo.Spec.Webhook.ClientSecretName] = true
+		}
+	}
+
+	bundles, err := c.Fleet.Bundle().List(ns, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range bundles.Items {
+		objs = append(objs, &bundles.Items[i])
+	}
+
+	if opts.IncludeSecrets {
+		delete(secrets, "")
+		all, err := c.Core.Secret().List(ns, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		var result []runtime.Object
+		for i := range all.Items {
+			if secrets[all.Items[i].Name] {
+				result = append(result, &all.Items[i])
+			}
+		}
+		objs = append(result, objs...)
+	}
+
+	data, err := yaml.Export(objs...)
+	if err != nil {
+		return err
+	}
+
+	header := fmt.Sprintf("# fleet backup %s of namespace %s created %s\n", FormatVersion, ns, time.Now().UTC().Format(time.RFC3339))
+	if _, err := io.WriteString(opts.Output, header); err != nil {
+		return err
+	}
+	_, err = opts.Output.Write(data)
+	return err
+}
+
+// Restore creates the resources of a snapshot. Existing resources are not
+// modified. Clusters keep their client ID, so agents, which register again,
+// are assigned to their previous cluster resource.
+func Restore(ctx context.Context, client *client.Getter, input io.Reader) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return err
+	}
+	objs, err := yaml.ToObjects(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	byKind := map[string][]*unstructured.Unstructured{}
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		byKind[u.GetKind()] = append(byKind[u.GetKind()], u)
+	}
+
+	for _, kind := range restoreOrder {
+		for _, u := range byKind[kind] {
+			if u.GetNamespace() == "" {
+				u.SetNamespace(c.Namespace)
+			}
+			err := create(c, u)
+			if apierrors.IsAlreadyExists(err) {
+				logrus.Infof("Skipping existing %s %s/%s", kind, u.GetNamespace(), u.GetName())
+				continue
+			} else if err != nil {
+				return fmt.Errorf("restoring %s %s/%s: %w", kind, u.GetNamespace(), u.GetName(), err)
+			}
+			logrus.Infof("Restored %s %s/%s", kind, u.GetNamespace(), u.GetName())
+		}
+		delete(byKind, kind)
+	}
+
+	for kind, objs := range byKind {
+		logrus.Warnf("Skipped %d objects of unsupported kind %s", len(objs), kind)
+	}
+
+	return nil
+}
+
+func create(c *client.Client, u *unstructured.Unstructured) error {
+	var err error
+	switch u.GetKind() {
+	case "Secret":
+		obj := &corev1.Secret{}
+		if err = fromUnstructured(u, obj); err == nil {
+			_, err = c.Core.Secret().Create(obj)
+		}
+	case "ClusterGroup":
+		obj := &fleet.ClusterGroup{}
+		if err = fromUnstructured(u, obj); err == nil {
+			_, err = c.Fleet.ClusterGroup().Create(obj)
+		}
+	case "Cluster":
+		obj := &fleet.Cluster{}
+		if err = fromUnstructured(u, obj); err == nil {
+			_, err = c.Fleet.Cluster().Create(obj)
+		}
+	case "ClusterRegistrationToken":
+		obj := &fleet.ClusterRegistrationToken{}
+		if err = fromUnstructured(u, obj); err == nil {
+			_, err = c.Fleet.ClusterRegistrationToken().Create(obj)
+		}
+	case "GitRepoRestriction":
+		obj := &fleet.GitRepoRestriction{}
+		if err = fromUnstructured(u, obj); err == nil {
+			_, err = c.Fleet.GitRepoRestriction().Create(obj)
+		}
+	case "GitRepoDefaults":
+		obj := &fleet.GitRepoDefaults{}
+		if err = fromUnstructured(u, obj); err == nil {
+			_, err = c.Fleet.GitRepoDefaults().Create(obj)
+		}
+	case "BundleNamespaceMapping":
+		obj := &fleet.BundleNamespaceMapping{}
+		if err = fromUnstructured(u, obj); err == nil {
+			_, err = c.Fleet.BundleNamespaceMapping().Create(obj)
+		}
+	case "Bundle":
+		obj := &fleet.Bundle{}
+		if err = fromUnstructured(u, obj); err == nil {
+			_, err = c.Fleet.Bundle().Create(obj)
+		}
+	case "GitRepo":
+		obj := &fleet.GitRepo{}
+		if err = fromUnstructured(u, obj); err == nil {
+			_, err = c.Fleet.GitRepo().Create(obj)
+		}
+	default:
+		err = fmt.Errorf("unsupported kind %s", u.GetKind())
+	}
+	return err
+}
+
+func fromUnstructured(u *unstructured.Unstructured, obj runtime.Object) error {
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj)
+}
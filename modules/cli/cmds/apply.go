@@ -45,6 +45,16 @@ type Apply struct {
 	HelmRepoURLRegex          string            `usage:"Helm credentials will be used if the helm repo matches this regex. Credentials will always be used if this is empty or not provided" name:"helm-repo-url-regex"`
 	KeepResources             bool              `usage:"Keep resources created after the GitRepo or Bundle is deleted" name:"keep-resources"`
 	HelmCredentialsByPathFile string            `usage:"Path of file containing helm credentials for paths" name:"helm-credentials-by-path-file"`
+	SkipUnchanged             bool              `usage:"Keep the existing bundles of paths, whose files and options did not change" name:"skip-unchanged"`
+	CacheDir                  string            `usage:"Directory to cache rendered bundles in, by commit, path and fleet.yaml" name:"cache-dir"`
+	CacheMaxEntries           int               `usage:"Maximum number of bundles kept in the cache directory" name:"cache-max-entries"`
+	ReportPathErrors          bool              `usage:"Store errors of failed paths in the GitRepo status, instead of failing, if other paths succeed" name:"report-path-errors"`
+	Branch                    string            `usage:"Branch of the GitRepo the paths were checked out from, its last applied commit is diffed to skip unchanged paths"`
+	VerifyKeysDir             string            `usage:"Directory of trusted PGP and SSH public keys, the commit must be signed by one of them" name:"verify-keys-dir"`
+	Submodules                bool              `usage:"Check out the submodules of the repo at their recorded commits"`
+	SubmodulesRecursive       bool              `usage:"Also check out the submodules of submodules" name:"submodules-recursive"`
+	GitCredentials            map[string]string `usage:"Host of submodules or LFS objects and the directory of its basic-auth or ssh-auth secret, as host=directory" name:"git-credentials"`
+	LFS                       bool              `usage:"Download the Git LFS objects of the checked out commit" name:"lfs"`
 }
 
 func (a *Apply) Run(cmd *cobra.Command, args []string) error {
@@ -61,22 +71,35 @@ func (a *Apply) Run(cmd *cobra.Command, args []string) error {
 
 	name := ""
 	opts := apply.Options{
-		BundleFile:       a.BundleFile,
-		Output:           writer.NewDefaultNone(a.Output),
-		Compress:         a.Compress,
-		ServiceAccount:   a.ServiceAccount,
-		Labels:           a.Label,
-		TargetsFile:      a.TargetsFile,
-		TargetNamespace:  a.TargetNamespace,
-		Paused:           a.Paused,
-		SyncGeneration:   int64(a.SyncGeneration),
-		HelmRepoURLRegex: a.HelmRepoURLRegex,
-		KeepResources:    a.KeepResources,
+		BundleFile:          a.BundleFile,
+		Output:              writer.NewDefaultNone(a.Output),
+		Compress:            a.Compress,
+		ServiceAccount:      a.ServiceAccount,
+		Labels:              a.Label,
+		TargetsFile:         a.TargetsFile,
+		TargetNamespace:     a.TargetNamespace,
+		Paused:              a.Paused,
+		SyncGeneration:      int64(a.SyncGeneration),
+		HelmRepoURLRegex:    a.HelmRepoURLRegex,
+		KeepResources:       a.KeepResources,
+		SkipUnchanged:       a.SkipUnchanged,
+		ReportPathErrors:    a.ReportPathErrors,
+		Branch:              a.Branch,
+		VerifyKeysDir:       a.VerifyKeysDir,
+		Submodules:          a.Submodules,
+		SubmodulesRecursive: a.SubmodulesRecursive,
+		GitCredentials:      a.GitCredentials,
+		LFS:                 a.LFS,
 	}
 	err := a.addAuthToOpts(&opts, os.ReadFile)
 	if err != nil {
 		return err
 	}
+	if a.CacheDir != "" {
+		if opts.Cache, err = apply.NewBundleCache(a.CacheDir, a.CacheMaxEntries); err != nil {
+			return err
+		}
+	}
 	if a.File == "-" {
 		opts.BundleReader = os.Stdin
 		if len(args) != 1 {
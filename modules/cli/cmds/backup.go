@@ -0,0 +1,75 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/fleet/modules/cli/backup"
+	"github.com/rancher/fleet/modules/cli/pkg/writer"
+	command "github.com/rancher/wrangler-cli"
+)
+
+func NewBackup() *cobra.Command {
+	cmd := command.Command(&Backup{}, cobra.Command{
+		Short: "Export the Fleet resources of a namespace for disaster recovery",
+		Long: `Export the Fleet resources of a namespace for disaster recovery.
+
+The snapshot contains gitrepos, bundles, clusters, cluster groups, registration tokens,
+restrictions and defaults. Upload it to a versioned object store to keep a history.`,
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Backup struct {
+	OutputArgsNoDefault
+	IncludeSecrets bool `usage:"Include the secrets referenced by gitrepos and clusters"`
+}
+
+func (b *Backup) Run(cmd *cobra.Command, args []string) error {
+	output := b.Output
+	if output == "" {
+		output = fmt.Sprintf("fleet-backup-%s-%s.yaml", Client.Namespace, time.Now().UTC().Format("20060102-150405"))
+	}
+	w := writer.New(output)
+	defer w.Close()
+
+	return backup.Backup(cmd.Context(), Client, backup.Options{
+		Output:         w,
+		IncludeSecrets: b.IncludeSecrets,
+	})
+}
+
+func NewRestore() *cobra.Command {
+	cmd := command.Command(&Restore{}, cobra.Command{
+		Use:   "restore [flags] FILE",
+		Args:  cobra.ExactArgs(1),
+		Short: "Create the Fleet resources of a backup, existing resources are kept",
+		Long: `Create the Fleet resources of a backup, existing resources are kept.
+
+Restored clusters keep their client ID. Once the agents of the downstream clusters are
+bootstrapped with a registration token of the new management cluster, they are assigned
+to their previous cluster resource and keep their deployments.`,
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Restore struct{}
+
+func (r *Restore) Run(cmd *cobra.Command, args []string) error {
+	f := os.Stdin
+	if args[0] != "-" {
+		var err error
+		f, err = os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+	}
+
+	return backup.Restore(cmd.Context(), Client, f)
+}
@@ -0,0 +1,57 @@
+package cmds
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/fleet/modules/cli/migrate"
+	"github.com/rancher/fleet/modules/cli/pkg/client"
+	command "github.com/rancher/wrangler-cli"
+)
+
+func NewMigrateManager() *cobra.Command {
+	cmd := command.Command(&MigrateManager{}, cobra.Command{
+		Use:   "migrate-manager [flags]",
+		Short: "Move the registered clusters of a namespace to a new management cluster",
+		Long: `Move the registered clusters of a namespace to a new management cluster.
+
+Restore a backup of the namespace on the new management cluster first. Every cluster
+is then registered on the new management cluster and its new credential is stored in
+the "fleet-agent-migration" secret of its cluster namespace on the current management
+cluster, where only its agent can read it. The agents restart and connect to the new
+management cluster, keeping their cluster resource and deployments. Delete the
+"fleet-agent-migration" secrets once all clusters are ready on the new management
+cluster.`,
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type MigrateManager struct {
+	TargetKubeconfig      string `usage:"kubeconfig of the new management cluster" name:"target-kubeconfig"`
+	TargetContext         string `usage:"kubeconfig context of the new management cluster" name:"target-context"`
+	TargetSystemNamespace string `usage:"System namespace of the controller on the new management cluster" name:"target-system-namespace" default:"cattle-fleet-system"`
+	APIServerURL          string `usage:"API server URL of the new management cluster, as reached by the agents. Defaults to the URL configured in the new fleet controller" name:"api-server-url"`
+	APIServerCAFile       string `usage:"Path of the CA of the new management cluster's API server" name:"api-server-ca-file"`
+	Timeout               int    `usage:"Timeout in seconds for registering a single cluster" default:"120"`
+}
+
+func (m *MigrateManager) Run(cmd *cobra.Command, args []string) error {
+	opts := migrate.Options{
+		Target:          client.NewGetter(m.TargetKubeconfig, m.TargetContext, Client.Namespace),
+		SystemNamespace: m.TargetSystemNamespace,
+		APIServerURL:    m.APIServerURL,
+		Timeout:         time.Duration(m.Timeout) * time.Second,
+	}
+	if m.APIServerCAFile != "" {
+		ca, err := os.ReadFile(m.APIServerCAFile)
+		if err != nil {
+			return err
+		}
+		opts.APIServerCA = ca
+	}
+
+	return migrate.Migrate(cmd.Context(), Client, opts)
+}
@@ -0,0 +1,263 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/fleet/modules/cli/ops"
+	command "github.com/rancher/wrangler-cli"
+)
+
+func NewStatus() *cobra.Command {
+	cmd := command.Command(&Status{}, cobra.Command{
+		Use:   "status [flags] [GITREPO_NAME]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Show the status of gitrepos, their bundles and deployments per cluster",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Status struct{}
+
+func (s *Status) Run(cmd *cobra.Command, args []string) error {
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	return ops.Status(cmd.Context(), Client, os.Stdout, name)
+}
+
+func NewDiff() *cobra.Command {
+	cmd := command.Command(&Diff{}, cobra.Command{
+		Use:   "diff [flags] BUNDLE_NAME",
+		Args:  cobra.ExactArgs(1),
+		Short: "Show the resources of a bundle, which differ from the desired state on each cluster",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Diff struct{}
+
+func (d *Diff) Run(cmd *cobra.Command, args []string) error {
+	return ops.Diff(cmd.Context(), Client, os.Stdout, args[0])
+}
+
+func NewPause() *cobra.Command {
+	cmd := command.Command(&Pause{}, cobra.Command{
+		Use:   "pause [flags] NAME",
+		Args:  cobra.ExactArgs(1),
+		Short: "Pause a gitrepo or bundle, changes are not deployed to clusters",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Pause struct {
+	Bundle bool `usage:"Pause a bundle instead of a gitrepo"`
+}
+
+func (p *Pause) Run(cmd *cobra.Command, args []string) error {
+	return ops.Pause(cmd.Context(), Client, args[0], p.Bundle, true)
+}
+
+func NewResume() *cobra.Command {
+	cmd := command.Command(&Resume{}, cobra.Command{
+		Use:   "resume [flags] NAME",
+		Args:  cobra.ExactArgs(1),
+		Short: "Resume a paused gitrepo or bundle",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Resume struct {
+	Bundle bool `usage:"Resume a bundle instead of a gitrepo"`
+}
+
+func (r *Resume) Run(cmd *cobra.Command, args []string) error {
+	return ops.Pause(cmd.Context(), Client, args[0], r.Bundle, false)
+}
+
+func NewForceSync() *cobra.Command {
+	cmd := command.Command(&ForceSync{}, cobra.Command{
+		Use:   "force-sync [flags] GITREPO_NAME",
+		Args:  cobra.ExactArgs(1),
+		Short: "Re-create the bundles of a gitrepo from its current commit",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type ForceSync struct{}
+
+func (f *ForceSync) Run(cmd *cobra.Command, args []string) error {
+	return ops.ForceSync(cmd.Context(), Client, args[0])
+}
+
+func NewRedeploy() *cobra.Command {
+	cmd := command.Command(&Redeploy{}, cobra.Command{
+		Use:   "redeploy [flags] BUNDLE_NAME",
+		Args:  cobra.ExactArgs(1),
+		Short: "Deploy a bundle to a single cluster again, even if nothing changed",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Redeploy struct {
+	Cluster string `usage:"Name of the cluster to redeploy the bundle to"`
+}
+
+func (r *Redeploy) Run(cmd *cobra.Command, args []string) error {
+	if r.Cluster == "" {
+		return fmt.Errorf("--cluster is required")
+	}
+	return ops.Redeploy(cmd.Context(), Client, os.Stdout, args[0], r.Cluster)
+}
+
+func NewPin() *cobra.Command {
+	cmd := command.Command(&Pin{}, cobra.Command{
+		Use:   "pin [flags] BUNDLE_NAME",
+		Args:  cobra.ExactArgs(1),
+		Short: "Freeze the deployment of a bundle on a single cluster, while other clusters are updated",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Pin struct {
+	Cluster string `usage:"Name of the cluster to pin the bundle on"`
+	For     string `usage:"How long the pin lasts, e.g. 2h" default:"24h"`
+	Reason  string `usage:"Why the deployment is pinned, recorded together with the user"`
+}
+
+func (p *Pin) Run(cmd *cobra.Command, args []string) error {
+	if p.Cluster == "" {
+		return fmt.Errorf("--cluster is required")
+	}
+	if p.Reason == "" {
+		return fmt.Errorf("--reason is required")
+	}
+	d, err := time.ParseDuration(p.For)
+	if err != nil {
+		return fmt.Errorf("invalid --for: %w", err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("--for must be positive")
+	}
+	by := p.Reason
+	if user := os.Getenv("USER"); user != "" {
+		by = user + ": " + p.Reason
+	}
+	return ops.Pin(cmd.Context(), Client, os.Stdout, args[0], p.Cluster, time.Now().Add(d), by)
+}
+
+func NewUnpin() *cobra.Command {
+	cmd := command.Command(&Unpin{}, cobra.Command{
+		Use:   "unpin [flags] BUNDLE_NAME",
+		Args:  cobra.ExactArgs(1),
+		Short: "Remove the pin of a bundle on a single cluster",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Unpin struct {
+	Cluster string `usage:"Name of the cluster to unpin the bundle on"`
+}
+
+func (u *Unpin) Run(cmd *cobra.Command, args []string) error {
+	if u.Cluster == "" {
+		return fmt.Errorf("--cluster is required")
+	}
+	return ops.Unpin(cmd.Context(), Client, os.Stdout, args[0], u.Cluster)
+}
+
+func NewResync() *cobra.Command {
+	cmd := command.Command(&Resync{}, cobra.Command{
+		Use:   "resync [flags] BUNDLE_NAME",
+		Args:  cobra.ExactArgs(1),
+		Short: "Compute the targets of a bundle again, even if nothing changed",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Resync struct{}
+
+func (r *Resync) Run(cmd *cobra.Command, args []string) error {
+	return ops.Resync(cmd.Context(), Client, args[0])
+}
+
+func NewGraph() *cobra.Command {
+	cmd := command.Command(&Graph{}, cobra.Command{
+		Use:   "graph [flags]",
+		Args:  cobra.NoArgs,
+		Short: "Show the dependency graph of the bundles in the namespace",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Graph struct {
+	Output string `usage:"Output format, dot or json" default:"dot" short:"o"`
+}
+
+func (g *Graph) Run(cmd *cobra.Command, args []string) error {
+	return ops.Graph(cmd.Context(), Client, os.Stdout, g.Output)
+}
+
+func NewUndo() *cobra.Command {
+	cmd := command.Command(&Undo{}, cobra.Command{
+		Use:   "undo [flags] BUNDLE_NAME",
+		Args:  cobra.ExactArgs(1),
+		Short: "Restore a deleted or changed bundle from a recorded revision",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Undo struct {
+	Revision string `usage:"Name of the bundle revision to restore, defaults to the newest previous revision"`
+}
+
+func (u *Undo) Run(cmd *cobra.Command, args []string) error {
+	return ops.Undo(cmd.Context(), Client, os.Stdout, args[0], u.Revision)
+}
+
+func NewCost() *cobra.Command {
+	cmd := command.Command(&Cost{}, cobra.Command{
+		Use:   "cost [flags]",
+		Args:  cobra.NoArgs,
+		Short: "Show the estimated monthly cost of the bundles per cluster",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Cost struct{}
+
+func (c *Cost) Run(cmd *cobra.Command, args []string) error {
+	return ops.Cost(cmd.Context(), Client, os.Stdout)
+}
+
+func NewOverview() *cobra.Command {
+	cmd := command.Command(&Overview{}, cobra.Command{
+		Use:   "overview [flags]",
+		Args:  cobra.NoArgs,
+		Short: "Show a summary of the gitrepos, bundles and clusters in the namespace",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Overview struct{}
+
+func (o *Overview) Run(cmd *cobra.Command, args []string) error {
+	return ops.Overview(cmd.Context(), Client, os.Stdout)
+}
@@ -0,0 +1,41 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/fleet/modules/cli/ops"
+	command "github.com/rancher/wrangler-cli"
+)
+
+func NewRender() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Render resources as the agents deploy them",
+	}
+	cmd.AddCommand(NewRenderBundle())
+	return cmd
+}
+
+func NewRenderBundle() *cobra.Command {
+	cmd := command.Command(&RenderBundle{}, cobra.Command{
+		Use:   "bundle [flags] BUNDLE_NAME",
+		Args:  cobra.ExactArgs(1),
+		Short: "Write the resources of a bundle as multi-document YAML, as the agent of a cluster applies them",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type RenderBundle struct {
+	Target string `usage:"Name of the cluster to render the bundle for" short:"t"`
+}
+
+func (r *RenderBundle) Run(cmd *cobra.Command, args []string) error {
+	if r.Target == "" {
+		return fmt.Errorf("--target is required")
+	}
+	return ops.RenderBundle(cmd.Context(), Client, os.Stdout, args[0], r.Target)
+}
@@ -27,6 +27,25 @@ func App() *cobra.Command {
 	root.AddCommand(
 		NewApply(),
 		NewTest(),
+		NewBackup(),
+		NewRestore(),
+		NewMigrateManager(),
+		NewStatus(),
+		NewDiff(),
+		NewPause(),
+		NewResume(),
+		NewForceSync(),
+		NewRedeploy(),
+		NewPin(),
+		NewUnpin(),
+		NewResync(),
+		NewGraph(),
+		NewUndo(),
+		NewCost(),
+		NewSimulate(),
+		NewTarget(),
+		NewOverview(),
+		NewRender(),
 	)
 
 	return root
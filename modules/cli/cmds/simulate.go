@@ -0,0 +1,71 @@
+package cmds
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/fleet/modules/cli/ops"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/bundlereader"
+	command "github.com/rancher/wrangler-cli"
+	"github.com/rancher/wrangler/pkg/yaml"
+)
+
+func NewSimulate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate how the fleet controller handles a bundle",
+	}
+	cmd.AddCommand(NewSimulateRollout())
+	return cmd
+}
+
+func NewSimulateRollout() *cobra.Command {
+	cmd := command.Command(&SimulateRollout{}, cobra.Command{
+		Use:   "rollout [flags] [BASE_DIR]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Show the partitions of a bundle's clusters and the steps, in which new content is rolled out to them",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type SimulateRollout struct {
+	BundleInputArgs
+	Clusters string `usage:"Yaml file of Cluster and ClusterGroup resources to use instead of the ones in the namespace" short:"c"`
+}
+
+func (s *SimulateRollout) Run(cmd *cobra.Command, args []string) error {
+	baseDir := "."
+	if len(args) > 0 {
+		baseDir = args[0]
+	}
+
+	bundle, err := readBundle(cmd.Context(), "simulate", baseDir, s.BundleInputArgs)
+	if err != nil {
+		return err
+	}
+
+	return ops.SimulateRollout(cmd.Context(), Client, os.Stdout, bundle, s.Clusters)
+}
+
+// readBundle reads the bundle from the raw Bundle resource, if set,
+// otherwise from the fleet.yaml in baseDir
+func readBundle(ctx context.Context, name, baseDir string, args BundleInputArgs) (*fleet.Bundle, error) {
+	if args.BundleFile == "" {
+		bundle, _, err := bundlereader.Open(ctx, name, baseDir, args.File, nil)
+		return bundle, err
+	}
+
+	data, err := os.ReadFile(args.BundleFile)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &fleet.Bundle{}
+	if err := yaml.Unmarshal(data, bundle); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
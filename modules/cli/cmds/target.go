@@ -0,0 +1,57 @@
+package cmds
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rancher/fleet/modules/cli/ops"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	command "github.com/rancher/wrangler-cli"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func NewTarget() *cobra.Command {
+	cmd := command.Command(&Target{}, cobra.Command{
+		Use:   "target [flags] [BASE_DIR]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Show which target of a bundle matches each cluster and the merged options, without creating bundle deployments",
+	})
+	command.AddDebug(cmd, &Debug)
+	return cmd
+}
+
+type Target struct {
+	BundleInputArgs
+	Bundle   string `usage:"Name of a bundle in the namespace to use instead of a fleet.yaml"`
+	Clusters string `usage:"Yaml file of Cluster and ClusterGroup resources to use instead of the ones in the namespace" short:"c"`
+	Cluster  string `usage:"Only show this cluster and write the merged options as YAML" short:"t"`
+}
+
+func (t *Target) Run(cmd *cobra.Command, args []string) error {
+	baseDir := "."
+	if len(args) > 0 {
+		baseDir = args[0]
+	}
+
+	var bundle *fleet.Bundle
+	if t.Bundle != "" {
+		c, err := Client.Get()
+		if err != nil {
+			return err
+		}
+		bundle, err = c.Fleet.Bundle().Get(c.Namespace, t.Bundle, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+	} else {
+		var err error
+		bundle, err = readBundle(cmd.Context(), "target", baseDir, t.BundleInputArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ops.Targets(cmd.Context(), Client, os.Stdout, bundle, t.Clusters, t.Cluster)
+}
@@ -0,0 +1,221 @@
+// Package migrate moves the registered clusters of a namespace to a new management cluster. (fleetapply)
+//
+// The clusters are registered on the new management cluster on behalf of
+// their agents. The resulting credentials are stored in a secret in each
+// cluster namespace on the current management cluster, which only the
+// cluster's agent can read. The agents copy them to their credential secret
+// and re-home without a new bootstrap token.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/rancher/fleet/modules/agent/pkg/register"
+	"github.com/rancher/fleet/modules/cli/pkg/client"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/config"
+	"github.com/rancher/fleet/pkg/namespace"
+	"github.com/rancher/fleet/pkg/registration"
+
+	"github.com/rancher/wrangler/pkg/randomtoken"
+	"github.com/rancher/wrangler/pkg/yaml"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const pollInterval = 2 * time.Second
+
+type Options struct {
+	// Target is the new management cluster
+	Target *client.Getter
+	// SystemNamespace of the fleet controller on the new management cluster
+	SystemNamespace string
+	// APIServerURL and APIServerCA of the new management cluster, as used
+	// by the agents. Read from the fleet controller config if empty.
+	APIServerURL string
+	APIServerCA  []byte
+	// Timeout for the registration of a single cluster
+	Timeout time.Duration
+}
+
+// Migrate registers every cluster of the namespace on the new management
+// cluster and stores the new credential of each cluster's agent in its
+// cluster namespace on the current management cluster.
+func Migrate(ctx context.Context, current *client.Getter, opts Options) error {
+	from, err := current.Get()
+	if err != nil {
+		return err
+	}
+	to, err := opts.Target.Get()
+	if err != nil {
+		return err
+	}
+
+	if opts.APIServerURL == "" {
+		cfg, err := config.Lookup(ctx, opts.SystemNamespace, config.ManagerConfigName, to.Core.ConfigMap())
+		if err != nil {
+			return err
+		}
+		opts.APIServerURL = cfg.APIServerURL
+		if len(opts.APIServerCA) == 0 {
+			opts.APIServerCA = cfg.APIServerCA
+		}
+	}
+	if opts.APIServerURL == "" {
+		return fmt.Errorf("API server URL of the new management cluster is unknown, please provide it")
+	}
+
+	clusters, err := from.Fleet.Cluster().List(from.Namespace, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		if cluster.Spec.ClientID == "" || cluster.Spec.KubeConfigSecret != "" {
+			logrus.Infof("Skipping cluster %s/%s, clusters imported by the manager are imported again by the new management cluster", cluster.Namespace, cluster.Name)
+			continue
+		}
+		if err := migrateCluster(ctx, from, to, cluster, opts); err != nil {
+			return fmt.Errorf("migrating cluster %s/%s: %w", cluster.Namespace, cluster.Name, err)
+		}
+		logrus.Infof("Stored credential to move cluster %s/%s to %s", cluster.Namespace, cluster.Name, opts.APIServerURL)
+	}
+
+	return nil
+}
+
+func migrateCluster(ctx context.Context, from, to *client.Client, cluster *fleet.Cluster, opts Options) error {
+	if cluster.Status.Namespace == "" {
+		return fmt.Errorf("cluster has no namespace yet")
+	}
+
+	// the cluster is found by its client id, when the registration is
+	// processed, so it keeps its name
+	_, err := to.Fleet.Cluster().Create(&fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cluster.Name,
+			Namespace:   cluster.Namespace,
+			Labels:      cluster.Labels,
+			Annotations: yaml.CleanAnnotationsForExport(cluster.Annotations),
+		},
+		Spec: cluster.Spec,
+	})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	random, err := randomtoken.Generate()
+	if err != nil {
+		return err
+	}
+	_, err = to.Fleet.ClusterRegistration().Create(&fleet.ClusterRegistration{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "request-",
+			Namespace:    cluster.Namespace,
+		},
+		Spec: fleet.ClusterRegistrationSpec{
+			ClientID:      cluster.Spec.ClientID,
+			ClientRandom:  random,
+			ClusterLabels: cluster.Labels,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	secret, err := waitForSecret(ctx, to, opts, registration.SecretName(cluster.Spec.ClientID, random))
+	if err != nil {
+		return err
+	}
+
+	kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"cluster": {
+				Server:                   opts.APIServerURL,
+				CertificateAuthorityData: opts.APIServerCA,
+			},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"user": {
+				Token: string(secret.Data[register.Token]),
+			},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"default": {
+				Cluster:   "cluster",
+				AuthInfo:  "user",
+				Namespace: string(secret.Data[register.DeploymentNamespace]),
+			},
+		},
+		CurrentContext: "default",
+	})
+	if err != nil {
+		return err
+	}
+
+	return applySecret(from, credentialSecret(cluster, secret, kubeconfig))
+}
+
+// waitForSecret waits for the credential, which the fleet controller creates
+// for the cluster registration
+func waitForSecret(ctx context.Context, c *client.Client, opts Options, name string) (*corev1.Secret, error) {
+	ns := namespace.SystemRegistrationNamespace(opts.SystemNamespace)
+	timeout := time.After(opts.Timeout)
+	for {
+		secret, err := c.Core.Secret().Get(ns, name, metav1.GetOptions{})
+		if err == nil {
+			return secret, nil
+		} else if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+
+		select {
+		case <-timeout:
+			return nil, fmt.Errorf("timeout waiting for secret %s/%s", ns, name)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// credentialSecret returns the secret in the cluster namespace, which holds
+// the agent's credential for the new management cluster
+func credentialSecret(cluster *fleet.Cluster, secret *corev1.Secret, kubeconfig []byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      register.MigrationSecretName,
+			Namespace: cluster.Status.Namespace,
+		},
+		Data: map[string][]byte{
+			register.Kubeconfig:          kubeconfig,
+			register.DeploymentNamespace: secret.Data[register.DeploymentNamespace],
+			register.ClusterNamespace:    secret.Data[register.ClusterNamespace],
+			register.ClusterName:         secret.Data[register.ClusterName],
+		},
+	}
+}
+
+// applySecret creates or updates the secret with the agent's credential
+func applySecret(c *client.Client, secret *corev1.Secret) error {
+	existing, err := c.Core.Secret().Get(secret.Namespace, secret.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = c.Core.Secret().Create(secret)
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	existing.Data = secret.Data
+	_, err = c.Core.Secret().Update(existing)
+	return err
+}
@@ -0,0 +1,34 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/rancher/fleet/modules/agent/pkg/register"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCredentialSecret(t *testing.T) {
+	registration := &corev1.Secret{
+		Data: map[string][]byte{
+			register.Token:               []byte("token"),
+			register.DeploymentNamespace: []byte("cluster-fleet-default-c-1"),
+			register.ClusterNamespace:    []byte("fleet-default"),
+			register.ClusterName:         []byte("c-1"),
+		},
+	}
+
+	cluster := &fleet.Cluster{}
+	cluster.Status.Namespace = "cluster-fleet-default-c-1"
+	secret := credentialSecret(cluster, registration, []byte("kubeconfig"))
+	if secret.Namespace != "cluster-fleet-default-c-1" || secret.Name != register.MigrationSecretName {
+		t.Errorf("unexpected secret %s/%s", secret.Namespace, secret.Name)
+	}
+	if string(secret.Data[register.ClusterName]) != "c-1" || string(secret.Data[register.Kubeconfig]) != "kubeconfig" {
+		t.Errorf("unexpected secret data %v", secret.Data)
+	}
+	if _, ok := secret.Data[register.Token]; ok {
+		t.Errorf("expected token to be only part of the kubeconfig")
+	}
+}
@@ -0,0 +1,857 @@
+// Package ops implements common operations on the Fleet resources of a namespace, like showing their status, dependency graph and estimated cost, simulating rollouts, previewing targets, summarizing the namespace, rendering bundles, pausing, force-syncing, redeploying, pinning and restoring bundles. (fleetapply)
+package ops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rancher/fleet/modules/cli/pkg/client"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/bundlematcher"
+	"github.com/rancher/fleet/pkg/controllers/bundlegraph"
+	"github.com/rancher/fleet/pkg/controllers/revision"
+	"github.com/rancher/fleet/pkg/cost"
+	"github.com/rancher/fleet/pkg/helmdeployer"
+	"github.com/rancher/fleet/pkg/manifest"
+	name2 "github.com/rancher/fleet/pkg/name"
+	"github.com/rancher/fleet/pkg/rollout"
+	"github.com/rancher/fleet/pkg/summary"
+	"github.com/rancher/fleet/pkg/target"
+	"github.com/rancher/wrangler/pkg/kv"
+	"github.com/rancher/wrangler/pkg/yaml"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	kyaml "sigs.k8s.io/yaml"
+)
+
+// Status writes a tree of gitrepos, their bundles and the bundle's
+// deployments per cluster. If repoName is set, only that gitrepo is shown.
+func Status(ctx context.Context, client *client.Getter, w io.Writer, repoName string) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	gitrepos, err := c.Fleet.GitRepo().List(c.Namespace, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	bundles, err := c.Fleet.Bundle().List(c.Namespace, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	bds, err := c.Fleet.BundleDeployment().List("", metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{fleet.BundleNamespaceLabel: c.Namespace}).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	deployments := map[string][]fleet.BundleDeployment{}
+	for _, bd := range bds.Items {
+		name := bd.Labels[fleet.BundleLabel]
+		if full, ok := bd.Annotations[fleet.BundleAnnotation]; ok {
+			name = full
+		}
+		deployments[name] = append(deployments[name], bd)
+	}
+	byRepo := map[string][]fleet.Bundle{}
+	for _, bundle := range bundles.Items {
+		repo := bundle.Labels[fleet.RepoLabel]
+		byRepo[repo] = append(byRepo[repo], bundle)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tREADY\tSTATE\tMESSAGE")
+	for _, gitrepo := range gitrepos.Items {
+		if repoName != "" && gitrepo.Name != repoName {
+			continue
+		}
+		fmt.Fprintf(tw, "GitRepo/%s\t%s\t%s\t%s\n", gitrepo.Name, gitrepo.Status.Display.ReadyBundleDeployments,
+			gitrepo.Status.Display.State, gitrepo.Status.Display.Message)
+		writeBundles(tw, "  ", byRepo[gitrepo.Name], deployments)
+	}
+	if repoName == "" {
+		writeBundles(tw, "", byRepo[""], deployments)
+	}
+
+	return tw.Flush()
+}
+
+func writeBundles(w io.Writer, indent string, bundles []fleet.Bundle, deployments map[string][]fleet.BundleDeployment) {
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].Name < bundles[j].Name
+	})
+	for _, bundle := range bundles {
+		fmt.Fprintf(w, "%sBundle/%s\t%s\t%s\t%s\n", indent, bundle.Name, bundle.Status.Display.ReadyClusters,
+			bundle.Status.Display.State, summary.ReadyMessage(bundle.Status.Summary, "Cluster"))
+
+		bds := deployments[bundle.Name]
+		sort.Slice(bds, func(i, j int) bool {
+			return clusterName(&bds[i]) < clusterName(&bds[j])
+		})
+		for i := range bds {
+			fmt.Fprintf(w, "%s  Cluster/%s\t\t%s\t%s\n", indent, clusterName(&bds[i]),
+				summary.GetDeploymentState(&bds[i]), summary.MessageFromDeployment(&bds[i]))
+		}
+	}
+}
+
+// Diff writes the resources of the bundle, which are modified, missing or
+// extra on each cluster.
+func Diff(ctx context.Context, client *client.Getter, w io.Writer, bundleName string) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	bds, err := c.Fleet.BundleDeployment().List("", metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			fleet.BundleNamespaceLabel: c.Namespace,
+			fleet.BundleLabel:          name2.LabelValue(bundleName),
+		}).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(bds.Items, func(i, j int) bool {
+		return clusterName(&bds.Items[i]) < clusterName(&bds.Items[j])
+	})
+	for i := range bds.Items {
+		bd := &bds.Items[i]
+		if len(bd.Status.ModifiedStatus) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "Cluster/%s:\n", clusterName(bd))
+		for _, modified := range bd.Status.ModifiedStatus {
+			fmt.Fprintf(w, "  %s\n", modified.String())
+		}
+	}
+
+	return nil
+}
+
+// Pause sets the paused field of the gitrepo, or of the bundle if bundle is
+// true.
+func Pause(ctx context.Context, client *client.Getter, name string, bundle, paused bool) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	if bundle {
+		b, err := c.Fleet.Bundle().Get(c.Namespace, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		b.Spec.Paused = paused
+		_, err = c.Fleet.Bundle().Update(b)
+		return err
+	}
+
+	gitrepo, err := c.Fleet.GitRepo().Get(c.Namespace, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	gitrepo.Spec.Paused = paused
+	_, err = c.Fleet.GitRepo().Update(gitrepo)
+	return err
+}
+
+// ForceSync makes the gitrepo re-create its bundles from the current commit.
+func ForceSync(ctx context.Context, client *client.Getter, name string) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	gitrepo, err := c.Fleet.GitRepo().Get(c.Namespace, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	gitrepo.Spec.ForceSyncGeneration++
+	_, err = c.Fleet.GitRepo().Update(gitrepo)
+	return err
+}
+
+// Redeploy makes the agent of the cluster deploy the bundle again, even if
+// its deployment did not change. The cluster is expected in the same
+// namespace as the bundle.
+func Redeploy(ctx context.Context, client *client.Getter, w io.Writer, bundleName, cluster string) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	bds, err := clusterDeployments(c, bundleName, cluster)
+	if err != nil {
+		return err
+	}
+
+	for i := range bds {
+		bd := &bds[i]
+		if bd.Annotations == nil {
+			bd.Annotations = map[string]string{}
+		}
+		bd.Annotations[fleet.RedeployAnnotation] = time.Now().UTC().Format(time.RFC3339Nano)
+		if _, err := c.Fleet.BundleDeployment().Update(bd); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "Redeploying bundle %s on cluster %s\n", bundleName, clusterName(bd)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pin stops the controller from updating the bundle's deployment on the
+// cluster, until the pin expires or is removed by Unpin. The deployment
+// keeps its current content, while the other clusters receive new content.
+func Pin(ctx context.Context, client *client.Getter, w io.Writer, bundleName, cluster string, until time.Time, by string) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	bds, err := clusterDeployments(c, bundleName, cluster)
+	if err != nil {
+		return err
+	}
+
+	for i := range bds {
+		bd := &bds[i]
+		if bd.Annotations == nil {
+			bd.Annotations = map[string]string{}
+		}
+		bd.Annotations[fleet.PinnedUntilAnnotation] = until.UTC().Format(time.RFC3339)
+		bd.Annotations[fleet.PinnedByAnnotation] = by
+		if _, err := c.Fleet.BundleDeployment().Update(bd); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "Pinned bundle %s on cluster %s to %s until %s\n", bundleName, clusterName(bd), bd.Spec.DeploymentID, until.UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unpin removes the pin from the bundle's deployment on the cluster, so the
+// controller rolls out the bundle's current content to it again.
+func Unpin(ctx context.Context, client *client.Getter, w io.Writer, bundleName, cluster string) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	bds, err := clusterDeployments(c, bundleName, cluster)
+	if err != nil {
+		return err
+	}
+
+	for i := range bds {
+		bd := &bds[i]
+		if _, ok := bd.Annotations[fleet.PinnedUntilAnnotation]; !ok {
+			if _, err := fmt.Fprintf(w, "Bundle %s is not pinned on cluster %s\n", bundleName, clusterName(bd)); err != nil {
+				return err
+			}
+			continue
+		}
+		delete(bd.Annotations, fleet.PinnedUntilAnnotation)
+		delete(bd.Annotations, fleet.PinnedByAnnotation)
+		if _, err := c.Fleet.BundleDeployment().Update(bd); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "Unpinned bundle %s on cluster %s\n", bundleName, clusterName(bd)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clusterDeployments returns the deployments of the bundle on the cluster,
+// which is expected in the same namespace as the bundle.
+func clusterDeployments(c *client.Client, bundleName, cluster string) ([]fleet.BundleDeployment, error) {
+	bds, err := c.Fleet.BundleDeployment().List("", metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			fleet.BundleNamespaceLabel:  c.Namespace,
+			fleet.BundleLabel:           name2.LabelValue(bundleName),
+			fleet.ClusterNamespaceLabel: c.Namespace,
+			fleet.ClusterLabel:          name2.LabelValue(cluster),
+		}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(bds.Items) == 0 {
+		return nil, fmt.Errorf("bundle %s is not deployed to cluster %s/%s", bundleName, c.Namespace, cluster)
+	}
+	return bds.Items, nil
+}
+
+// Resync makes the controller compute the targets of the bundle again,
+// without using its caches, even if the bundle did not change.
+func Resync(ctx context.Context, client *client.Getter, bundleName string) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	bundle, err := c.Fleet.Bundle().Get(c.Namespace, bundleName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if bundle.Annotations == nil {
+		bundle.Annotations = map[string]string{}
+	}
+	bundle.Annotations[fleet.ResyncAnnotation] = time.Now().UTC().Format(time.RFC3339Nano)
+	_, err = c.Fleet.Bundle().Update(bundle)
+	return err
+}
+
+func clusterName(bd *fleet.BundleDeployment) string {
+	return bd.Labels[fleet.ClusterNamespaceLabel] + "/" + fleet.DeploymentClusterName(bd)
+}
+
+// Graph writes the bundle dependency graph of the namespace, as computed by
+// the fleet controller, in the DOT or JSON format.
+func Graph(ctx context.Context, client *client.Getter, w io.Writer, format string) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	key := bundlegraph.DOTKey
+	switch format {
+	case "dot":
+	case "json":
+		key = bundlegraph.JSONKey
+	default:
+		return fmt.Errorf("unknown format %q, use dot or json", format)
+	}
+
+	cm, err := c.Core.ConfigMap().Get(c.Namespace, bundlegraph.ConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("no bundles with dependencies in namespace %s", c.Namespace)
+	} else if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(w, cm.Data[key])
+	return err
+}
+
+// Undo restores a bundle from one of its revisions. Without a revision name
+// a deleted bundle is restored from its newest revision, an existing bundle
+// from the newest revision that differs from its current generation.
+// Bundles created from a gitrepo are overwritten again by its next sync.
+func Undo(ctx context.Context, client *client.Getter, w io.Writer, bundleName, revisionName string) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	bundle, err := c.Fleet.Bundle().Get(c.Namespace, bundleName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		bundle = nil
+	} else if err != nil {
+		return err
+	}
+
+	revisions, err := c.Fleet.BundleRevision().List(c.Namespace, metav1.ListOptions{
+		LabelSelector: revision.Selector(bundleName).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	rev, err := selectRevision(bundle, revisions.Items, bundleName, revisionName)
+	if err != nil {
+		return err
+	}
+
+	m, err := manifest.NewLookup(c.Fleet.Content()).Get(rev.ManifestID)
+	if err != nil {
+		return fmt.Errorf("reading content of revision %s: %w", rev.Name, err)
+	}
+
+	spec := rev.Spec.DeepCopy()
+	spec.Resources = m.Resources
+
+	if bundle == nil {
+		_, err = c.Fleet.Bundle().Create(&fleet.Bundle{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      bundleName,
+				Namespace: c.Namespace,
+				Labels:    rev.BundleLabels,
+			},
+			Spec: *spec,
+		})
+	} else {
+		bundle.Spec = *spec
+		bundle.Labels = rev.BundleLabels
+		_, err = c.Fleet.Bundle().Update(bundle)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Restored bundle %s/%s from revision %s (generation %d)\n", c.Namespace, bundleName, rev.Name, rev.BundleGeneration)
+	return err
+}
+
+// selectRevision returns the named revision or the newest revision that
+// differs from the current bundle, which may be nil
+func selectRevision(bundle *fleet.Bundle, revisions []fleet.BundleRevision, bundleName, revisionName string) (*fleet.BundleRevision, error) {
+	var candidates []*fleet.BundleRevision
+	for i := range revisions {
+		rev := &revisions[i]
+		if rev.BundleName != bundleName {
+			continue
+		}
+		if revisionName != "" {
+			if rev.Name == revisionName {
+				return rev, nil
+			}
+			continue
+		}
+		if bundle != nil && rev.BundleUID == bundle.UID && rev.BundleGeneration == bundle.Generation {
+			continue
+		}
+		candidates = append(candidates, rev)
+	}
+
+	if revisionName != "" {
+		return nil, fmt.Errorf("revision %s of bundle %s not found", revisionName, bundleName)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no revision to restore bundle %s from", bundleName)
+	}
+	revision.SortNewestFirst(candidates)
+	return candidates[0], nil
+}
+
+// Cost writes the estimated monthly cost of each bundle in the namespace
+// per cluster, followed by the totals per cluster and of the namespace.
+// Bundles only list their most expensive clusters, the cost on the other
+// clusters is summed up in one line and not part of the cluster totals.
+// Costs are estimated by the fleet controller, if it's configured with a
+// price sheet.
+func Cost(ctx context.Context, client *client.Getter, w io.Writer) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	bundles, err := c.Fleet.Bundle().List(c.Namespace, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	return writeCost(w, bundles.Items)
+}
+
+func writeCost(w io.Writer, bundles []fleet.Bundle) error {
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].Name < bundles[j].Name
+	})
+
+	var (
+		total    float64
+		currency string
+		clusters = map[string]float64{}
+		found    bool
+	)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "BUNDLE\tCLUSTER\tCPU\tMEMORY\tSTORAGE\tMONTHLY")
+	for _, bundle := range bundles {
+		if bundle.Status.Cost == nil {
+			continue
+		}
+		found = true
+		currency = bundle.Status.Cost.Currency
+		bundleTotal, err := cost.Parse(bundle.Status.Cost.Monthly)
+		if err != nil {
+			return fmt.Errorf("invalid cost of bundle %s: %w", bundle.Name, err)
+		}
+		total += bundleTotal
+
+		listed := 0.0
+		for _, cc := range bundle.Status.Cost.Clusters {
+			monthly, err := cost.Parse(cc.Monthly)
+			if err != nil {
+				return fmt.Errorf("invalid cost of bundle %s on cluster %s: %w", bundle.Name, cc.Cluster, err)
+			}
+			clusters[cc.Cluster] += monthly
+			listed += monthly
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", bundle.Name, cc.Cluster, cc.CPU, cc.Memory, cc.Storage, cc.Monthly)
+		}
+		if others := bundle.Status.Cost.ClusterCount - len(bundle.Status.Cost.Clusters); others > 0 {
+			fmt.Fprintf(tw, "%s\t(%d other clusters)\t\t\t\t%s\n", bundle.Name, others, cost.Format(bundleTotal-listed))
+		}
+	}
+	if !found {
+		return errors.New("no cost estimates, configure the costPriceSheet of the fleet controller")
+	}
+
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(tw, "TOTAL\t%s\t\t\t\t%s\n", name, cost.Format(clusters[name]))
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t\t\t\t%s\n", strings.TrimSpace(cost.Format(total)+" "+currency))
+
+	return tw.Flush()
+}
+
+// SimulateRollout writes the partitions of the clusters targeted by the
+// bundle and the steps, in which the fleet controller would roll out new
+// content to them. The clusters and cluster groups are read from
+// clustersFile, a yaml file of Cluster and ClusterGroup resources, or from
+// the namespace.
+func SimulateRollout(ctx context.Context, client *client.Getter, w io.Writer, bundle *fleet.Bundle, clustersFile string) error {
+	clusters, groups, err := listClusters(client, clustersFile)
+	if err != nil {
+		return err
+	}
+
+	return writeSimulation(w, bundle, clusters, groups)
+}
+
+// listClusters returns the clusters and cluster groups from the file, if
+// set, otherwise the ones in the namespace
+func listClusters(client *client.Getter, clustersFile string) ([]fleet.Cluster, []fleet.ClusterGroup, error) {
+	if clustersFile != "" {
+		return readClusters(clustersFile)
+	}
+
+	c, err := client.Get()
+	if err != nil {
+		return nil, nil, err
+	}
+	clusterList, err := c.Fleet.Cluster().List(c.Namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	groupList, err := c.Fleet.ClusterGroup().List(c.Namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return clusterList.Items, groupList.Items, nil
+}
+
+func readClusters(file string) ([]fleet.Cluster, []fleet.ClusterGroup, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	objs, err := yaml.ToObjects(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	var (
+		clusters []fleet.Cluster
+		groups   []fleet.ClusterGroup
+	)
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		switch u.GetKind() {
+		case "Cluster":
+			cluster := fleet.Cluster{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &cluster); err != nil {
+				return nil, nil, fmt.Errorf("invalid cluster %s: %w", u.GetName(), err)
+			}
+			clusters = append(clusters, cluster)
+		case "ClusterGroup":
+			group := fleet.ClusterGroup{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &group); err != nil {
+				return nil, nil, fmt.Errorf("invalid cluster group %s: %w", u.GetName(), err)
+			}
+			groups = append(groups, group)
+		}
+	}
+	return clusters, groups, nil
+}
+
+func writeSimulation(w io.Writer, bundle *fleet.Bundle, clusters []fleet.Cluster, groups []fleet.ClusterGroup) error {
+	bm, err := bundlematcher.New(bundle)
+	if err != nil {
+		return err
+	}
+
+	// the controller passes the targeted clusters sorted by name
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+
+	var (
+		candidates  []rollout.Cluster
+		clusterRefs []*fleet.Cluster
+		groupLabels []map[string]map[string]string
+	)
+	for i := range clusters {
+		cluster := &clusters[i]
+		target := rollout.Cluster{Name: cluster.Name, Labels: cluster.Labels}
+		clusterGroupLabels := map[string]map[string]string{}
+		for _, group := range groups {
+			if group.Spec.Selector == nil {
+				continue
+			}
+			sel, err := metav1.LabelSelectorAsSelector(group.Spec.Selector)
+			if err != nil {
+				return fmt.Errorf("invalid selector of cluster group %s: %w", group.Name, err)
+			}
+			if sel.Matches(labels.Set(cluster.Labels)) {
+				clusterGroupLabels[group.Name] = group.Labels
+				target.Groups = append(target.Groups, rollout.Group{Name: group.Name, Labels: group.Labels})
+			}
+		}
+		candidates = append(candidates, target)
+		clusterRefs = append(clusterRefs, cluster)
+		groupLabels = append(groupLabels, clusterGroupLabels)
+	}
+	// targets with a percentage select clusters among all matching clusters
+	bm.Sample(clusterRefs, groupLabels)
+
+	var targets []rollout.Cluster
+	for i, target := range candidates {
+		if bm.MatchCluster(clusterRefs[i], groupLabels[i]) != nil {
+			targets = append(targets, target)
+		}
+	}
+
+	opts := rollout.Options{Strategy: bundle.Spec.RolloutStrategy}
+	partitions, err := rollout.Partitions(targets, opts)
+	if err != nil {
+		return err
+	}
+	maxUnavailable, err := rollout.MaxUnavailable(len(targets), opts)
+	if err != nil {
+		return err
+	}
+	maxPartitions, err := rollout.MaxUnavailablePartitions(len(partitions), opts)
+	if err != nil {
+		return err
+	}
+
+	names := func(indexes []int) string {
+		result := make([]string, 0, len(indexes))
+		for _, i := range indexes {
+			result = append(result, targets[i].Name)
+		}
+		return strings.Join(result, ",")
+	}
+
+	fmt.Fprintf(w, "Clusters: %d, max unavailable: %d, max unavailable partitions: %d\n\n", len(targets), maxUnavailable, maxPartitions)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PARTITION\tMAX UNAVAILABLE\tCLUSTERS")
+	for _, p := range partitions {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", p.Name, p.MaxUnavailable, names(p.Clusters))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	steps, simErr := rollout.Simulate(targets, opts)
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tCLUSTERS")
+	for i, step := range steps {
+		fmt.Fprintf(tw, "%d\t%s\n", i+1, names(step.Clusters))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return simErr
+}
+
+// Targets writes the target, which matches the bundle for each cluster, and
+// whether a bundle deployment would be created. If cluster is set, only that
+// cluster is shown and the merged options are written as YAML.
+func Targets(ctx context.Context, client *client.Getter, w io.Writer, bundle *fleet.Bundle, clustersFile, cluster string) error {
+	clusters, groups, err := listClusters(client, clustersFile)
+	if err != nil {
+		return err
+	}
+
+	if cluster != "" {
+		var found []fleet.Cluster
+		for _, c := range clusters {
+			if c.Name == cluster {
+				found = append(found, c)
+			}
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("cluster %s not found", cluster)
+		}
+		clusters = found
+	}
+
+	return writeTargets(w, bundle, clusters, groups, cluster != "")
+}
+
+func writeTargets(w io.Writer, bundle *fleet.Bundle, clusters []fleet.Cluster, groups []fleet.ClusterGroup, withOptions bool) error {
+	clusterPtrs := make([]*fleet.Cluster, 0, len(clusters))
+	for i := range clusters {
+		clusterPtrs = append(clusterPtrs, &clusters[i])
+	}
+	groupPtrs := make([]*fleet.ClusterGroup, 0, len(groups))
+	for i := range groups {
+		groupPtrs = append(groupPtrs, &groups[i])
+	}
+
+	matches, err := target.Preview(bundle, clusterPtrs, groupPtrs)
+	if err != nil {
+		return err
+	}
+
+	if withOptions {
+		data, err := kyaml.Marshal(matches)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLUSTER\tGROUPS\tTARGET\tCUSTOMIZATION\tDEPLOYED")
+	for _, m := range matches {
+		deployed := "yes"
+		switch {
+		case m.Target == "":
+			deployed = "no, no target matches"
+		case m.DoNotDeploy:
+			deployed = "no, doNotDeploy"
+		}
+		// without target restrictions, the target is its own customization
+		customization := m.Customization
+		if customization == m.Target {
+			customization = ""
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", m.Cluster, strings.Join(m.ClusterGroups, ","), m.Target, customization, deployed)
+	}
+	return tw.Flush()
+}
+
+// Overview writes the summary of the gitrepos, bundles and clusters in the
+// namespace, which the fleet controller maintains.
+func Overview(ctx context.Context, client *client.Getter, w io.Writer) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	status, err := c.Fleet.FleetWorkspaceStatus().Get(c.Namespace, fleet.FleetWorkspaceStatusName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("no summary of namespace %s, it contains no gitrepos, bundles or clusters", c.Namespace)
+	} else if err != nil {
+		return err
+	}
+
+	return writeOverview(w, status)
+}
+
+func writeOverview(w io.Writer, status *fleet.FleetWorkspaceStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "GitRepos:\t%d/%d ready\n", status.ReadyGitRepos, status.GitRepos)
+	fmt.Fprintf(tw, "Bundles:\t%d/%d ready\n", status.ReadyBundles, status.Bundles)
+	fmt.Fprintf(tw, "Clusters:\t%d/%d ready\n", status.ReadyClusters, status.Clusters)
+	if status.LastActivity != nil {
+		fmt.Fprintf(tw, "Last activity:\t%s\n", status.LastActivity.UTC().Format(time.RFC3339))
+	}
+
+	states := make([]string, 0, len(status.BundleStates))
+	for state := range status.BundleStates {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+	for _, state := range states {
+		fmt.Fprintf(tw, "Bundles %s:\t%d\n", state, status.BundleStates[state])
+	}
+	if len(status.StalledBundles) > 0 {
+		fmt.Fprintf(tw, "Stalled bundles:\t%s\n", strings.Join(status.StalledBundles, ","))
+	}
+	if len(status.OfflineClusters) > 0 {
+		fmt.Fprintf(tw, "Offline clusters:\t%s\n", strings.Join(status.OfflineClusters, ","))
+	}
+	for _, e := range status.Summary.Errors {
+		fmt.Fprintf(tw, "Error:\t%s (%d deployments)\n", e.Message, e.Count)
+	}
+	return tw.Flush()
+}
+
+// RenderBundle writes the resources of the bundle, as the agent of the
+// cluster deploys them, as multi-document YAML. The cluster is expected in
+// the same namespace as the bundle.
+func RenderBundle(ctx context.Context, client *client.Getter, w io.Writer, bundleName, cluster string) error {
+	c, err := client.Get()
+	if err != nil {
+		return err
+	}
+
+	bds, err := c.Fleet.BundleDeployment().List("", metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			fleet.BundleNamespaceLabel:  c.Namespace,
+			fleet.BundleLabel:           name2.LabelValue(bundleName),
+			fleet.ClusterNamespaceLabel: c.Namespace,
+			fleet.ClusterLabel:          name2.LabelValue(cluster),
+		}).String(),
+	})
+	if err != nil {
+		return err
+	}
+	if len(bds.Items) == 0 {
+		return fmt.Errorf("bundle %s is not deployed to cluster %s/%s", bundleName, c.Namespace, cluster)
+	}
+	bd := &bds.Items[0]
+	if bd.Spec.DeploymentID == "" {
+		return fmt.Errorf("bundle %s is not rolled out to cluster %s/%s yet", bundleName, c.Namespace, cluster)
+	}
+
+	target, err := c.Fleet.Cluster().Get(c.Namespace, cluster, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	manifestID, _ := kv.Split(bd.Spec.DeploymentID, ":")
+	m, err := manifest.NewLookup(c.Fleet.Content()).Get(manifestID)
+	if err != nil {
+		return err
+	}
+
+	return writeRender(w, bd, m, target)
+}
+
+func writeRender(w io.Writer, bd *fleet.BundleDeployment, m *manifest.Manifest, cluster *fleet.Cluster) error {
+	objs, err := helmdeployer.TemplateBundleDeployment(bd, m, cluster)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Export(objs...)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
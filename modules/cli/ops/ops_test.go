@@ -0,0 +1,292 @@
+package ops
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/manifest"
+	"github.com/rancher/fleet/pkg/name"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestWriteBundles(t *testing.T) {
+	bundles := []fleet.Bundle{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "b"},
+			Status:     fleet.BundleStatus{Display: fleet.BundleDisplay{ReadyClusters: "1/1"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a"},
+		},
+	}
+	deployments := map[string][]fleet.BundleDeployment{
+		"b": {
+			{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					fleet.ClusterNamespaceLabel: "fleet-default",
+					fleet.ClusterLabel:          "c-1",
+				}},
+				Spec:   fleet.BundleDeploymentSpec{DeploymentID: "1", StagedDeploymentID: "1"},
+				Status: fleet.BundleDeploymentStatus{AppliedDeploymentID: "1", Ready: true, NonModified: true},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	writeBundles(&buf, "", bundles, deployments)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "Bundle/a") || !strings.HasPrefix(lines[1], "Bundle/b\t1/1") {
+		t.Errorf("unexpected bundle lines %q", lines[:2])
+	}
+	if lines[2] != "  Cluster/fleet-default/c-1\t\tReady" {
+		t.Errorf("unexpected deployment line %q", lines[2])
+	}
+}
+
+func TestClusterName(t *testing.T) {
+	long := strings.Repeat("c", 70)
+	bd := &fleet.BundleDeployment{ObjectMeta: metav1.ObjectMeta{
+		Labels: map[string]string{
+			fleet.ClusterNamespaceLabel: "fleet-default",
+			fleet.ClusterLabel:          name.LabelValue(long),
+		},
+		Annotations: map[string]string{fleet.ClusterAnnotation: long},
+	}}
+	if n := clusterName(bd); n != "fleet-default/"+long {
+		t.Errorf("unexpected cluster name %q", n)
+	}
+}
+
+func TestSelectRevision(t *testing.T) {
+	now := time.Now()
+	rev := func(name, uid string, gen int64, age time.Duration) fleet.BundleRevision {
+		return fleet.BundleRevision{
+			ObjectMeta:       metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))},
+			BundleName:       "app",
+			BundleUID:        types.UID(uid),
+			BundleGeneration: gen,
+		}
+	}
+	revisions := []fleet.BundleRevision{
+		rev("app-1", "a", 1, 3*time.Hour),
+		rev("app-2", "a", 2, 2*time.Hour),
+		rev("app-3", "a", 3, time.Hour),
+	}
+
+	bundle := &fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Name: "app", UID: "a", Generation: 3}}
+	if r, err := selectRevision(bundle, revisions, "app", ""); err != nil || r.Name != "app-2" {
+		t.Errorf("expected previous revision app-2, got %v, %v", r, err)
+	}
+	if r, err := selectRevision(nil, revisions, "app", ""); err != nil || r.Name != "app-3" {
+		t.Errorf("expected newest revision app-3 for deleted bundle, got %v, %v", r, err)
+	}
+	if r, err := selectRevision(bundle, revisions, "app", "app-1"); err != nil || r.Name != "app-1" {
+		t.Errorf("expected named revision app-1, got %v, %v", r, err)
+	}
+	if _, err := selectRevision(bundle, revisions, "app", "missing"); err == nil {
+		t.Error("expected error for missing revision")
+	}
+}
+
+func TestWriteCost(t *testing.T) {
+	bundles := []fleet.Bundle{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web"},
+			Status: fleet.BundleStatus{Cost: &fleet.BundleCost{Currency: "USD", Monthly: "30.00", Clusters: []fleet.ClusterCost{
+				{Cluster: "fleet-default/c-1", CPU: "1", Monthly: "10.00"},
+				{Cluster: "fleet-default/c-2", CPU: "2", Monthly: "20.00"},
+			}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "db"},
+			Status: fleet.BundleStatus{Cost: &fleet.BundleCost{Currency: "USD", Monthly: "8.50", ClusterCount: 3, Clusters: []fleet.ClusterCost{
+				{Cluster: "fleet-default/c-1", Storage: "10Gi", Monthly: "5.50"},
+			}}},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "no-cost"}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeCost(&buf, bundles); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("expected 8 lines, got %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[1], "db ") {
+		t.Errorf("expected bundles to be sorted, got %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); len(fields) != 5 || fields[1] != "(2" || fields[4] != "3.00" {
+		t.Errorf("unexpected cost of other clusters %q", lines[2])
+	}
+	if fields := strings.Fields(lines[5]); len(fields) != 3 || fields[1] != "fleet-default/c-1" || fields[2] != "15.50" {
+		t.Errorf("unexpected cluster total %q", lines[5])
+	}
+	if fields := strings.Fields(lines[7]); len(fields) != 3 || fields[1] != "38.50" || fields[2] != "USD" {
+		t.Errorf("unexpected total %q", lines[7])
+	}
+
+	if err := writeCost(&buf, []fleet.Bundle{{ObjectMeta: metav1.ObjectMeta{Name: "no-cost"}}}); err == nil {
+		t.Error("expected an error without cost estimates")
+	}
+}
+
+func TestWriteSimulation(t *testing.T) {
+	maxUnavailable := intstr.FromInt(1)
+	bundle := &fleet.Bundle{
+		Spec: fleet.BundleSpec{
+			RolloutStrategy: &fleet.RolloutStrategy{MaxUnavailable: &maxUnavailable},
+			Targets:         []fleet.BundleTarget{{ClusterGroup: "prod"}},
+		},
+	}
+	clusters := []fleet.Cluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "c-2", Labels: map[string]string{"env": "prod"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c-3", Labels: map[string]string{"env": "dev"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c-1", Labels: map[string]string{"env": "prod"}}},
+	}
+	groups := []fleet.ClusterGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+			Spec:       fleet.ClusterGroupSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeSimulation(&buf, bundle, clusters, groups); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `Clusters: 2, max unavailable: 1, max unavailable partitions: 0
+
+PARTITION  MAX UNAVAILABLE  CLUSTERS
+All        1                c-1,c-2
+
+STEP  CLUSTERS
+1     c-1
+2     c-2
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestWriteTargets(t *testing.T) {
+	bundle := &fleet.Bundle{
+		Spec: fleet.BundleSpec{
+			BundleDeploymentOptions: fleet.BundleDeploymentOptions{DefaultNamespace: "app"},
+			Targets: []fleet.BundleTarget{
+				{Name: "skip", ClusterName: "c-4", DoNotDeploy: true},
+				{Name: "prod", ClusterGroup: "prod", BundleDeploymentOptions: fleet.BundleDeploymentOptions{TargetNamespace: "app-${ .ClusterName }"}},
+				{Name: "staging", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "staging"}}},
+			},
+		},
+	}
+	clusters := []fleet.Cluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "c-2", Labels: map[string]string{"env": "dev"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c-1", Labels: map[string]string{"env": "prod"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c-3", Labels: map[string]string{"env": "staging"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c-4", Labels: map[string]string{"env": "staging"}}},
+	}
+	groups := []fleet.ClusterGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+			Spec:       fleet.ClusterGroupSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeTargets(&buf, bundle, clusters, groups, false); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `CLUSTER  GROUPS  TARGET   CUSTOMIZATION  DEPLOYED
+c-1      prod    prod                    yes
+c-2                                      no, no target matches
+c-3              staging                 yes
+c-4              skip                    no, doNotDeploy
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeTargets(&buf, bundle, clusters[1:2], groups, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"target: prod", "defaultNamespace: app", "namespace: app-c-1"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected %q in output:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestWriteOverview(t *testing.T) {
+	status := &fleet.FleetWorkspaceStatus{
+		GitRepos:        1,
+		ReadyGitRepos:   1,
+		Bundles:         2,
+		ReadyBundles:    1,
+		BundleStates:    map[string]int{"Ready": 1, "ErrApplied": 1},
+		StalledBundles:  []string{"app"},
+		Clusters:        2,
+		OfflineClusters: []string{"c-2"},
+		Summary:         fleet.BundleSummary{Errors: []fleet.SummaryError{{Message: "chart not found", Count: 1}}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeOverview(&buf, status); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `GitRepos:            1/1 ready
+Bundles:             1/2 ready
+Clusters:            0/2 ready
+Bundles ErrApplied:  1
+Bundles Ready:       1
+Stalled bundles:     app
+Offline clusters:    c-2
+Error:               chart not found (1 deployments)
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestWriteRender(t *testing.T) {
+	m, err := manifest.New([]fleet.BundleResource{{
+		Name:    "cm.yaml",
+		Content: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  key: value\n",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bd := &fleet.BundleDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "bundle"},
+		Spec: fleet.BundleDeploymentSpec{
+			Options: fleet.BundleDeploymentOptions{TargetNamespace: "app-ns"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeRender(&buf, bd, m, &fleet.Cluster{}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"kind: ConfigMap", "name: app", "namespace: app-ns", "key: value"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
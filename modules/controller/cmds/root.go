@@ -9,13 +9,16 @@ import (
 	"runtime/pprof"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/rancher/fleet/pkg/agent"
+	"github.com/rancher/fleet/pkg/controllers"
 	"github.com/rancher/fleet/pkg/durations"
 	"github.com/rancher/fleet/pkg/fleetcontroller"
+	"github.com/rancher/fleet/pkg/metrics"
 	"github.com/rancher/fleet/pkg/version"
 
 	command "github.com/rancher/wrangler-cli"
@@ -30,6 +33,10 @@ type FleetManager struct {
 	Namespace        string `usage:"namespace to watch" default:"cattle-fleet-system" env:"NAMESPACE"`
 	DisableGitops    bool   `usage:"disable gitops components" name:"disable-gitops"`
 	DisableBootstrap bool   `usage:"disable local cluster components" name:"disable-bootstrap"`
+	DisableImageScan bool   `usage:"disable image scan components, deprecated: use --controllers imagescan=false" name:"disable-image-scan"`
+	Controllers      string `usage:"enable or disable controller subsystems, e.g. gitops=false,imagescan=false (bundle, gitops, imagescan, clustergroups, notifications, bootstrap)" name:"controllers"`
+	MetricsAddr      string `usage:"address to serve prometheus metrics on, e.g. :8080, disabled if empty" name:"metrics-addr" env:"FLEET_METRICS_ADDR"`
+	WebhookAddr      string `usage:"address to receive git webhook events on, e.g. :8081, disabled if empty" name:"webhook-addr" env:"FLEET_WEBHOOK_ADDR"`
 }
 
 func (f *FleetManager) Run(cmd *cobra.Command, args []string) error {
@@ -38,7 +45,29 @@ func (f *FleetManager) Run(cmd *cobra.Command, args []string) error {
 		log.Println(http.ListenAndServe("localhost:6060", nil)) // nolint:gosec // Debugging only
 	}()
 	debugConfig.MustSetupDebug()
-	if err := fleetcontroller.Start(cmd.Context(), f.Namespace, f.Kubeconfig, f.DisableGitops, f.DisableBootstrap); err != nil {
+	if f.MetricsAddr != "" {
+		metrics.Enable()
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+			log.Println(http.ListenAndServe(f.MetricsAddr, mux)) // nolint:gosec // no timeouts needed for metrics
+		}()
+	}
+	subsystems, err := controllers.ParseSubsystems(f.Controllers)
+	if err != nil {
+		return err
+	}
+	if f.DisableGitops {
+		subsystems[controllers.SubsystemGitOps] = false
+	}
+	if f.DisableBootstrap {
+		subsystems[controllers.SubsystemBootstrap] = false
+	}
+	if f.DisableImageScan {
+		subsystems[controllers.SubsystemImageScan] = false
+	}
+
+	if err := fleetcontroller.Start(cmd.Context(), f.Namespace, f.Kubeconfig, subsystems, f.WebhookAddr); err != nil {
 		return err
 	}
 
@@ -54,6 +83,7 @@ func App() *cobra.Command {
 	cmd := command.Command(&FleetManager{}, cobra.Command{
 		Version: version.FriendlyVersion(),
 	})
+	_ = cmd.PersistentFlags().MarkDeprecated("disable-image-scan", "use --controllers imagescan=false instead")
 	return command.AddDebug(cmd, &debugConfig)
 }
 
@@ -35,6 +35,15 @@ var (
 // See https://github.com/helm/helm/blob/293b50c65d4d56187cd4e2f390f0ada46b4c4737/pkg/chartutil/validate_name.go#L54-L61
 const MaxHelmReleaseNameLen = 53
 
+const (
+	// VersionUpdateAuto is the helm version update policy, which re-creates
+	// bundles when a newer matching chart version is published.
+	VersionUpdateAuto = "auto"
+	// VersionUpdateCommit is the helm version update policy, which commits
+	// a newer chart version matching the VersionRange to the git repo.
+	VersionUpdateCommit = "commit"
+)
+
 type BundleState string
 
 // +genclient
@@ -59,10 +68,37 @@ type BundleNamespaceMapping struct {
 	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
 }
 
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// BundleRevision records a generation of a bundle, so it can be restored
+// after the bundle was deleted or broken by a bad commit. The bundle's
+// resources are kept in the content resource ManifestID refers to.
+type BundleRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	BundleName       string            `json:"bundleName,omitempty"`
+	BundleUID        types.UID         `json:"bundleUID,omitempty"`
+	BundleGeneration int64             `json:"bundleGeneration,omitempty"`
+	BundleLabels     map[string]string `json:"bundleLabels,omitempty"`
+	ManifestID       string            `json:"manifestID,omitempty"`
+	// Spec of the bundle, without its resources
+	Spec BundleSpec `json:"spec,omitempty"`
+}
+
 type BundleSpec struct {
 	BundleDeploymentOptions
 
+	// Extends is the name of a base bundle in the same namespace. The
+	// bundle inherits the base's raw manifests, which it can replace by
+	// using the same resource name, and its options, which it overrides.
+	// Targets, target restrictions and the rollout strategy are inherited,
+	// if the bundle has none. Base bundles can extend other bundles.
+	Extends string `json:"extends,omitempty"`
+
 	// Paused if set to true, will stop any BundleDeployments from being updated. It will be marked as out of sync.
+	// New content is staged, but not rolled out to any target. Unpausing continues the rollout with the staged content.
 	Paused bool `json:"paused,omitempty"`
 
 	// RolloutStrategy controls the rollout of bundles, by defining
@@ -78,8 +114,47 @@ type BundleSpec struct {
 	// TargetRestrictions restrict which clusters the bundle will be deployed to.
 	TargetRestrictions []BundleTargetRestriction `json:"targetRestrictions,omitempty"`
 
+	// MergeStrategy controls how the target customizations, which match a
+	// cluster, are combined. Matches are ordered by priority, highest first.
+	// "first-match", the default, uses the first match, "last-wins" the
+	// last match of the highest priority and "merge-all" merges the options
+	// of all matches, so higher priorities and later targets override.
+	MergeStrategy string `json:"mergeStrategy,omitempty"`
+
 	// DependsOn refers to the bundles which must be ready before this bundle can be deployed.
 	DependsOn []BundleRef `json:"dependsOn,omitempty"`
+
+	// DeployAt delays deploying changes of the bundle until a point in
+	// time, given in RFC3339 format, or until the next time of a cron
+	// schedule, e.g. "0 9 * * 1-5", evaluated in UTC. With a cron
+	// schedule the bundle is also deployed again at every scheduled time.
+	DeployAt string `json:"deployAt,omitempty"`
+
+	// TTL deletes the bundle, and with it its deployments on all
+	// clusters, once the duration passed, e.g. for load tests or demo
+	// environments. It only applies to bundles created outside of a
+	// GitRepo, e.g. by fleet apply, as the bundles of a GitRepo would be
+	// created again by its next update.
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// TTLAfter is when the TTL starts, either "created", the default, or
+	// "ready", when the bundle is ready for the first time.
+	TTLAfter string `json:"ttlAfter,omitempty"`
+
+	// MaxTargetClusters limits the number of clusters the bundle is
+	// deployed to. If more clusters match, e.g. because of a mistake in
+	// a selector, no deployments are created for the additional clusters.
+	// Zero means no limit.
+	MaxTargetClusters int `json:"maxTargetClusters,omitempty"`
+
+	// PruneConfirmationPeriod holds updates, which remove resources from
+	// the bundle, for the period. The resources are listed in the status,
+	// so their removal can be reviewed, e.g. by pausing the bundle, before
+	// they are pruned on the clusters. Setting the prune-confirmed
+	// annotation confirms the removal before the period passed.
+	PruneConfirmationPeriod *metav1.Duration `json:"pruneConfirmationPeriod,omitempty"`
+	// AutoPrune removes resources immediately, even if a prune
+	// confirmation period is set.
+	AutoPrune bool `json:"autoPrune,omitempty"`
 }
 
 type BundleRef struct {
@@ -98,6 +173,49 @@ type RolloutStrategy struct {
 	MaxUnavailablePartitions *intstr.IntOrString `json:"maxUnavailablePartitions,omitempty"`
 	AutoPartitionSize        *intstr.IntOrString `json:"autoPartitionSize,omitempty"`
 	Partitions               []Partition         `json:"partitions,omitempty"`
+	// MaxNewPerReconcile is the number of BundleDeployments created at
+	// once, when the bundle is deployed to new clusters. Defaults to the
+	// controller's maxNewBundleDeployments setting.
+	MaxNewPerReconcile int `json:"maxNewPerReconcile,omitempty"`
+	// AutoRollback reverts a rollout, which fails on too many clusters.
+	AutoRollback *AutoRollback `json:"autoRollback,omitempty"`
+	// Steps roll out new content progressively, e.g. to 10% of the
+	// clusters of a group, then to 50% and then to all of them. A step
+	// starts, once all clusters of the previous steps are ready with the
+	// new content and the previous step's pause passed. Clusters not
+	// selected by any step are updated after the last step. Partitions
+	// and maxUnavailable still apply within a step.
+	Steps []RolloutStep `json:"steps,omitempty"`
+}
+
+// RolloutStep selects the clusters updated in a step of a progressive
+// rollout.
+type RolloutStep struct {
+	// ClusterGroup is the name of the cluster group, whose clusters are
+	// updated. Defaults to all clusters of the bundle.
+	ClusterGroup string `json:"clusterGroup,omitempty"`
+	// Weight is the percentage of the group's clusters, which are updated
+	// once the step is done, including those updated by previous steps.
+	// It is rounded down, but at least one cluster is updated. Defaults
+	// to 100.
+	Weight int `json:"weight,omitempty"`
+	// Pause is how long to wait, after the step completed, before the
+	// next step starts.
+	Pause *metav1.Duration `json:"pause,omitempty"`
+}
+
+// AutoRollback reverts the clusters, which were updated to new content,
+// to the content which was last ready on all clusters, if more than
+// MaxFailures of them fail. The new content is not rolled out again, until
+// the bundle changes.
+type AutoRollback struct {
+	// MaxFailures is the number or percentage of targets, which may fail
+	// with new content. Defaults to 0.
+	MaxFailures *intstr.IntOrString `json:"maxFailures,omitempty"`
+	// Timeout is how long a target may be not ready after the rollout
+	// started, before it counts as failed. Targets failing to apply count
+	// right away. Defaults to 10m.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
 }
 
 type Partition struct {
@@ -107,6 +225,11 @@ type Partition struct {
 	ClusterSelector      *metav1.LabelSelector `json:"clusterSelector,omitempty"`
 	ClusterGroup         string                `json:"clusterGroup,omitempty"`
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// Order sequences the rollout of partitions. Partitions are rolled out
+	// in ascending order, a partition only starts, once all partitions
+	// with a lower order are ready. Partitions with the same order are
+	// rolled out together, limited by maxUnavailablePartitions.
+	Order int `json:"order,omitempty"`
 }
 
 type BundleTargetRestriction struct {
@@ -115,6 +238,12 @@ type BundleTargetRestriction struct {
 	ClusterSelector      *metav1.LabelSelector `json:"clusterSelector,omitempty"`
 	ClusterGroup         string                `json:"clusterGroup,omitempty"`
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// ExcludeClusterSelector excludes clusters, whose labels match it,
+	// even if they match the other selectors.
+	ExcludeClusterSelector *metav1.LabelSelector `json:"excludeClusterSelector,omitempty"`
+	// ExcludeClusterGroup excludes the clusters of this cluster group, even
+	// if they match the other selectors.
+	ExcludeClusterGroup string `json:"excludeClusterGroup,omitempty"`
 }
 
 type BundleTarget struct {
@@ -125,6 +254,79 @@ type BundleTarget struct {
 	ClusterGroup         string                `json:"clusterGroup,omitempty"`
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
 	DoNotDeploy          bool                  `json:"doNotDeploy,omitempty"`
+	// ExcludeClusterSelector excludes clusters, whose labels match it,
+	// even if they match the other selectors.
+	ExcludeClusterSelector *metav1.LabelSelector `json:"excludeClusterSelector,omitempty"`
+	// ExcludeClusterGroup excludes the clusters of this cluster group, even
+	// if they match the other selectors.
+	ExcludeClusterGroup string `json:"excludeClusterGroup,omitempty"`
+	// PropagationDelay is how long a new version of the bundle has to be
+	// deployed to other clusters, before the clusters of this target are
+	// updated to it. If all targets have a delay, it starts when the new
+	// version is staged. If unset, the propagation delay of the cluster's
+	// groups applies.
+	PropagationDelay *metav1.Duration `json:"propagationDelay,omitempty"`
+
+	// NodeArchitecture restricts a target customization to clusters with
+	// nodes of this architecture, e.g. "amd64", "arm64" or "s390x", as
+	// reported by the cluster's agent. Clusters with nodes of several
+	// architectures use the first matching customization.
+	NodeArchitecture string `json:"nodeArchitecture,omitempty"`
+
+	// ClusterSelectorExpression is a CEL expression, which clusters have to
+	// match in addition to the other selectors, e.g.
+	// `labels.region in ["eu-west", "eu-central"] && kubernetesMinor >= 27 && !("canary" in labels)`.
+	// It can use the cluster's name, namespace, labels, annotations and
+	// status, kubernetesMajor and kubernetesMinor, the Kubernetes version
+	// reported by its agent, and the agent's other reported capabilities:
+	// nodeArchitectures, apiVersions, cloudProvider and cni, e.g.
+	// `"arm64" in nodeArchitectures && "monitoring.coreos.com/v1" in apiVersions`.
+	// Clusters, for which it fails, e.g. because of a missing label, don't
+	// match.
+	ClusterSelectorExpression string `json:"clusterSelectorExpression,omitempty"`
+
+	// ClusterReady only deploys to clusters, whose agent checked in
+	// recently, i.e. within three check-in intervals. BundleDeployments
+	// for other clusters are neither created nor updated, they are
+	// reported as pending and don't count as unavailable.
+	ClusterReady bool `json:"clusterReady,omitempty"`
+
+	// ValuesFrom loads helm values from secrets and config maps in the
+	// cluster's namespace of the management cluster, so per-cluster
+	// secrets don't have to be stored in git. They are merged over the
+	// helm values. Changes to them are rolled out like changes to the
+	// bundle. Names can be templated, e.g. "values-${ .ClusterName }".
+	ValuesFrom []ValuesFrom `json:"valuesFrom,omitempty"`
+
+	// MaintenanceWindows restrict when the clusters of the target are
+	// updated to new content. New content is staged outside of the
+	// windows, but not deployed. A target customization's windows replace
+	// the target's windows.
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// Priority orders target customizations, which match the same cluster.
+	// Higher priorities take precedence, targets with the same priority
+	// are evaluated in order.
+	Priority int `json:"priority,omitempty"`
+
+	// Percentage only selects this share of the clusters matching the
+	// target, e.g. 10 for one in ten clusters. Clusters are picked by a
+	// hash of their name and the target's name, so the same clusters are
+	// selected across reconciles, as long as the target's name doesn't
+	// change. Zero selects all clusters.
+	Percentage int `json:"percentage,omitempty"`
+}
+
+// MaintenanceWindow is a recurring or one-off time window, in which
+// deployments may be updated.
+type MaintenanceWindow struct {
+	// Start is a cron schedule with five fields, e.g. "0 22 * * 1-5", or
+	// an RFC3339 time for a one-off window.
+	Start string `json:"start"`
+	// Duration is how long the window stays open.
+	Duration metav1.Duration `json:"duration"`
+	// TimeZone of the cron schedule, e.g. "Europe/Berlin". Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 type BundleSummary struct {
@@ -137,6 +339,17 @@ type BundleSummary struct {
 	Pending           int                `json:"pending,omitempty"`
 	DesiredReady      int                `json:"desiredReady"`
 	NonReadyResources []NonReadyResource `json:"nonReadyResources,omitempty"`
+	// Errors are the distinct error messages reported by the non-ready
+	// deployments, with the number of deployments reporting them. The
+	// most common errors are listed first, at most ten are kept.
+	Errors []SummaryError `json:"errors,omitempty"`
+}
+
+// SummaryError is an error message and the number of deployments, which
+// report it.
+type SummaryError struct {
+	Message string `json:"message,omitempty"`
+	Count   int    `json:"count,omitempty"`
 }
 
 type NonReadyResource struct {
@@ -152,6 +365,50 @@ var (
 	BundleDeploymentConditionReady     = "Ready"
 	BundleDeploymentConditionInstalled = "Installed"
 	BundleDeploymentConditionDeployed  = "Deployed"
+
+	// ManualInterventionRequired is set on bundle deployments and bundles,
+	// when a rollout is blocked by a breaking CRD change.
+	BundleConditionManualIntervention           = "ManualInterventionRequired"
+	BundleDeploymentConditionManualIntervention = "ManualInterventionRequired"
+
+	// PostDeleteHooks is set on terminating bundle deployments, after the
+	// agent ran the post-delete hooks.
+	BundleDeploymentConditionPostDeleteHooks = "PostDeleteHooks"
+
+	// MissingAPIs is set on bundles, when targets are not updated because
+	// their clusters lack APIs used by the bundle, or the Kubernetes
+	// version of their clusters removed them.
+	BundleConditionMissingAPIs = "MissingAPIs"
+
+	// RecreateRequired is set on bundles, when targets are not updated
+	// because the update changes immutable fields of StatefulSets or
+	// PersistentVolumeClaims and allowRecreate is not set.
+	BundleConditionRecreateRequired = "RecreateRequired"
+
+	// RolledBack is set on bundles, when a failed rollout was reverted
+	// by autoRollback.
+	BundleConditionRolledBack = "RolledBack"
+
+	// TooManyTargets is set on bundles, when more clusters match than
+	// maxTargetClusters allows.
+	BundleConditionTooManyTargets = "TooManyTargets"
+
+	// WaitingForWindow is set on bundles, when targets are not updated
+	// because their maintenance windows are closed.
+	BundleConditionWaitingForWindow = "WaitingForWindow"
+
+	// RolloutStep is the prefix of the conditions set on bundles for
+	// each step of the rollout strategy, e.g. "RolloutStep1". They are
+	// true, once the step completed for the content rolled out.
+	BundleConditionRolloutStep = "RolloutStep"
+
+	// Pinned is set on bundles, when bundle deployments are pinned by
+	// the fleet.cattle.io/pinned-until annotation.
+	BundleConditionPinned = "Pinned"
+
+	// PrunePending is set on bundles, while an update removing resources
+	// waits for its prune confirmation period.
+	BundleConditionPrunePending = "PrunePending"
 )
 
 type BundleStatus struct {
@@ -168,6 +425,195 @@ type BundleStatus struct {
 	Display                  BundleDisplay     `json:"display,omitempty"`
 	ResourceKey              []ResourceKey     `json:"resourceKey,omitempty"`
 	ObservedGeneration       int64             `json:"observedGeneration"`
+	// ChartDigests lists the digests of the remote charts contained in the bundle.
+	ChartDigests []ChartDigest `json:"chartDigests,omitempty"`
+	// MissingAPIs lists the first targets, which are not updated, because
+	// their cluster is missing APIs used by the bundle.
+	MissingAPIs []TargetMissingAPIs `json:"missingAPIs,omitempty"`
+	// PromotedManifestID is the content of the bundle, which was last
+	// deployed to its first cluster, at PromotedAt. Targets with a
+	// propagation delay are updated to it after their delay passed.
+	PromotedManifestID string       `json:"promotedManifestID,omitempty"`
+	PromotedAt         *metav1.Time `json:"promotedAt,omitempty"`
+	// KnownGoodManifestID is the content of the bundle, which was last
+	// ready on all targets. Failed rollouts are reverted to it.
+	KnownGoodManifestID string `json:"knownGoodManifestID,omitempty"`
+	// RolledBackManifestID is the content of the bundle, which was
+	// reverted by autoRollback. It is not rolled out again.
+	RolledBackManifestID string `json:"rolledBackManifestID,omitempty"`
+
+	// Cost is the estimated cost of the bundle's resource requests, if
+	// a price sheet is configured.
+	Cost *BundleCost `json:"cost,omitempty"`
+
+	// Schedule records the deployments scheduled by deployAt.
+	Schedule *BundleScheduleStatus `json:"schedule,omitempty"`
+
+	// ReadyAt is when the bundle was ready for the first time, if its TTL
+	// starts then.
+	ReadyAt *metav1.Time `json:"readyAt,omitempty"`
+	// ExpiresAt is when the bundle is deleted, because its TTL passed.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// Resync is the value of the resync annotation, which was last handled.
+	Resync string `json:"resync,omitempty"`
+
+	// RunOnce lists the first clusters a runOnce bundle was installed on.
+	RunOnce []RunOnceStatus `json:"runOnce,omitempty"`
+	// RunOnceClusters is the number of clusters a runOnce bundle was
+	// installed on.
+	RunOnceClusters int `json:"runOnceClusters,omitempty"`
+	// Pinned lists the clusters, whose bundle deployment is pinned and
+	// not updated to new content.
+	Pinned []PinnedStatus `json:"pinned,omitempty"`
+	// Namespaces lists the namespaces of the first clusters, which were
+	// rendered from namespace templates.
+	Namespaces []ClusterNamespace `json:"namespaces,omitempty"`
+	// NamespacesClusters is the number of clusters, whose namespace was
+	// rendered from a namespace template.
+	NamespacesClusters int `json:"namespacesClusters,omitempty"`
+
+	// RolloutSteps is the progress of the rollout strategy's steps.
+	RolloutSteps *RolloutStepsStatus `json:"rolloutSteps,omitempty"`
+
+	// PendingPrune lists the resources, which the held update removes.
+	PendingPrune *PendingPrune `json:"pendingPrune,omitempty"`
+}
+
+// PendingPrune is an update, which removes resources and waits for its
+// prune confirmation period.
+type PendingPrune struct {
+	// ManifestID is the content, which removes the resources
+	ManifestID string `json:"manifestID,omitempty"`
+	// Resources would be pruned on the clusters by the update
+	Resources []ResourceKey `json:"resources,omitempty"`
+	Since     metav1.Time   `json:"since,omitempty"`
+	// Until is when the update is rolled out, unless it's confirmed before
+	Until metav1.Time `json:"until,omitempty"`
+}
+
+// RolloutStepsStatus is the progress of a progressive rollout.
+type RolloutStepsStatus struct {
+	// ManifestID is the content being rolled out. The progress is reset,
+	// when it changes.
+	ManifestID string `json:"manifestID,omitempty"`
+	// Current is the index of the step in progress. It equals the number
+	// of steps, once all steps completed.
+	Current int `json:"current"`
+	// Steps are the states of the steps, in the order they are defined.
+	Steps []RolloutStepStatus `json:"steps,omitempty"`
+}
+
+// RolloutStepStatus is the state of a rollout step.
+type RolloutStepStatus struct {
+	ClusterGroup string `json:"clusterGroup,omitempty"`
+	Weight       int    `json:"weight"`
+	// Clusters is the number of clusters updated by this and the
+	// previous steps.
+	Clusters int `json:"clusters"`
+	// Ready is the number of these clusters, which are ready with the
+	// new content.
+	Ready int `json:"ready"`
+	// CompletedAt is when all clusters were ready for the first time.
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// ClusterNamespace is the namespace a bundle is deployed to on a cluster.
+type ClusterNamespace struct {
+	// Cluster is the namespace and name of the cluster
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type RunOnceStatus struct {
+	// Cluster is the namespace and name of the cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// DeploymentID is the content which was installed.
+	DeploymentID string `json:"deploymentID,omitempty"`
+}
+
+type PinnedStatus struct {
+	// Cluster is the namespace and name of the cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// Until is when the pin expires.
+	Until metav1.Time `json:"until,omitempty"`
+	// By is who pinned the deployment and why.
+	By string `json:"by,omitempty"`
+	// DeploymentID is the content the cluster is pinned to.
+	DeploymentID string `json:"deploymentID,omitempty"`
+}
+
+type BundleScheduleStatus struct {
+	// DeployAt is the schedule the other fields were calculated from.
+	DeployAt string `json:"deployAt,omitempty"`
+	// Next is the next scheduled deployment.
+	Next *metav1.Time `json:"next,omitempty"`
+	// Last is the last scheduled deployment.
+	Last *metav1.Time `json:"last,omitempty"`
+	// ReleasedGeneration is the generation of the bundle, which was
+	// staged at the last scheduled deployment. Targets are only updated
+	// to content of this generation.
+	ReleasedGeneration int64 `json:"releasedGeneration,omitempty"`
+}
+
+// BundleCost is the estimated monthly cost of a bundle, calculated from
+// the resource requests of its rendered resources and the price sheet in
+// the fleet-controller config.
+type BundleCost struct {
+	Currency string `json:"currency,omitempty"`
+	// Monthly is the estimated monthly cost on all clusters.
+	Monthly string `json:"monthly,omitempty"`
+	// CPU, Memory and Storage are the resources requested on all clusters.
+	CPU     string `json:"cpu,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	Storage string `json:"storage,omitempty"`
+	// ClusterCount is the number of clusters the cost was estimated for.
+	ClusterCount int `json:"clusterCount,omitempty"`
+	// Clusters lists the estimated cost on the most expensive clusters.
+	Clusters []ClusterCost `json:"clusters,omitempty"`
+}
+
+type ClusterCost struct {
+	// Cluster is the namespace/name of the target cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// CPU, Memory and Storage are the requested resources.
+	CPU     string `json:"cpu,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	Storage string `json:"storage,omitempty"`
+	// Monthly is the estimated monthly cost on the cluster.
+	Monthly string `json:"monthly,omitempty"`
+}
+
+type ChartDigest struct {
+	Chart   string `json:"chart,omitempty"`
+	Repo    string `json:"repo,omitempty"`
+	Version string `json:"version,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+	// ResolvedVersion is the chart version contained in the bundle.
+	ResolvedVersion string `json:"resolvedVersion,omitempty"`
+	// LatestVersion is the latest version in the helm repo matching Version.
+	LatestVersion string `json:"latestVersion,omitempty"`
+}
+
+type TargetMissingAPIs struct {
+	// Cluster is the namespace/name of the target cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// APIs lists the missing group/version/kinds.
+	APIs []string `json:"apis,omitempty"`
+	// Removed lists the missing APIs, which were removed in the
+	// Kubernetes version of the cluster.
+	Removed []RemovedAPI `json:"removed,omitempty"`
+}
+
+type RemovedAPI struct {
+	// API is the group/version/kind, like policy/v1beta1/PodSecurityPolicy
+	API string `json:"api,omitempty"`
+	// RemovedIn is the Kubernetes minor version the API was removed in,
+	// like 1.25
+	RemovedIn string `json:"removedIn,omitempty"`
+	// Replacement is the group/version to migrate to, it's empty if the
+	// kind was removed without replacement.
+	Replacement string `json:"replacement,omitempty"`
 }
 
 type ResourceKey struct {
@@ -210,6 +656,10 @@ type BundleDeploymentOptions struct {
 	// TargetNamespace if present will assign all resource to this
 	// namespace and if any cluster scoped resource exists the deployment
 	// will fail.
+	//
+	// Both namespace options can be templates like "app-${ .ClusterName }",
+	// which have the same values as helm value templates. Namespaces
+	// rendered from templates are listed in the bundle's status.
 	TargetNamespace string `json:"namespace,omitempty"`
 
 	// Kustomize options for the deployment, like the dir containing the
@@ -237,10 +687,270 @@ type BundleDeploymentOptions struct {
 
 	//IgnoreOptions can be used to ignore fields when monitoring the bundle.
 	IgnoreOptions `json:"ignore,omitempty"`
+
+	// OptionalResources are applied on a best-effort basis. Failing to
+	// apply them is reported, but does not fail the deployment. Resources
+	// can also be marked as optional with the "fleet.cattle.io/optional"
+	// annotation.
+	OptionalResources []OptionalResource `json:"optionalResources,omitempty"`
+
+	// StrictTemplates fails the target, if helm values reference a cluster
+	// label with the "global.fleet.clusterLabels." prefix, which is missing
+	// on the cluster, instead of using an empty string. Templates always
+	// fail on missing keys, unless they check for them, e.g. with hasKey.
+	StrictTemplates bool `json:"strictTemplates,omitempty"`
+
+	// ProgressiveDelivery enables interop with in-cluster progressive
+	// delivery controllers, like Argo Rollouts or Flagger. Resources of a
+	// canary in progress, or annotated with "fleet.cattle.io/rollout-hold",
+	// are not reported as not ready, so they don't count as unavailable.
+	ProgressiveDelivery bool `json:"progressiveDelivery,omitempty"`
+
+	// Propagation controls which bundle labels and annotations are copied
+	// to the BundleDeployments and which BundleDeployment labels are
+	// copied to the deployed resources.
+	Propagation *PropagationOptions `json:"propagation,omitempty"`
+
+	// EmptyRender controls what happens if the bundle renders no
+	// resources for a cluster: "delete" removes the previously deployed
+	// resources (the default), "keep" keeps them and "error" fails the
+	// deployment. It is ignored for charts rendered with agentRendering.
+	EmptyRender string `json:"emptyRender,omitempty"`
+
+	// BlueGreen deploys each new version next to the previous one and
+	// switches traffic to it, once it is ready, instead of upgrading the
+	// release in place.
+	BlueGreen *BlueGreenOptions `json:"blueGreen,omitempty"`
+
+	// AllowRecreate allows updates, which change immutable fields of
+	// StatefulSets or PersistentVolumeClaims. The agent deletes these
+	// resources before deploying them again. StatefulSets are deleted
+	// without their pods, claims lose their volume, depending on its
+	// reclaim policy. Without it, such updates are not rolled out.
+	AllowRecreate bool `json:"allowRecreate,omitempty"`
+
+	// RunOnce applies the bundle only once per cluster, e.g. for
+	// provisioning jobs or bootstrapping secrets. Once it was installed on
+	// a cluster, later changes are not deployed there, modified resources
+	// are not corrected and the resources are kept, when the bundle is
+	// removed.
+	RunOnce bool `json:"runOnce,omitempty"`
+
+	// PostDeleteHooks makes removing the bundle from a cluster wait for
+	// its post-delete hooks, resources annotated with
+	// "helm.sh/hook: post-delete", e.g. a Job deregistering the cluster
+	// from external systems. The BundleDeployment is only deleted after
+	// the hooks succeeded. If they fail, it is kept with the error in its
+	// PostDeleteHooks condition, until its finalizer is removed manually.
+	PostDeleteHooks bool `json:"postDeleteHooks,omitempty"`
+
+	// System marks the bundle as a critical system component. The agent
+	// injects a priority class and PodDisruptionBudgets into its
+	// workloads, so charts don't need to support them.
+	System *SystemOptions `json:"system,omitempty"`
+
+	// Identity labels all deployed resources with the bundle and GitRepo
+	// they belong to, so UIs can group them by application, without
+	// reading helm's release secrets.
+	Identity *IdentityOptions `json:"identity,omitempty"`
+
+	// StatusDetail controls how much detail about the deployed resources
+	// the agent writes to the BundleDeployment's status: "minimal" only
+	// reports whether they are ready and unmodified, "normal" adds the
+	// number of non-ready and modified resources and lists the first of
+	// them (the default), "full" lists all of them. Reducing it keeps
+	// large fleets from storing big objects in etcd.
+	StatusDetail string `json:"statusDetail,omitempty"`
+
+	// WebhookPolicy controls what happens if admission webhooks of the
+	// downstream cluster reject resources: "retry" deploys again with
+	// exponential backoff (the default), "fail" gives up until the bundle
+	// changes or is redeployed and "force" leaves the rejected resources
+	// out of the release and deploys the others. The rejected resources
+	// are listed in the BundleDeployment's status.
+	WebhookPolicy string `json:"webhookPolicy,omitempty"`
+
+	// StatusRetention limits the deployment history kept in the
+	// BundleDeployment's status. Older deployments are compacted into a
+	// summary, so long-lived bundles don't grow their status forever.
+	StatusRetention *StatusRetention `json:"statusRetention,omitempty"`
+
+	// ApplyStrategies override how resources are applied, by kind or by
+	// name. The first matching strategy is used. Resources can also set
+	// their strategy with the "fleet.cattle.io/apply-strategy"
+	// annotation. Resources without a strategy are patched.
+	ApplyStrategies []ApplyStrategy `json:"applyStrategies,omitempty"`
+
+	// ReplaceImmutable deletes Jobs and Pods, whose spec changed, before
+	// deploying them again, instead of failing on their immutable fields.
+	// Replacements, which keep failing, are backed off.
+	ReplaceImmutable bool `json:"replaceImmutable,omitempty"`
+}
+
+// ApplyStrategy selects resources like an OptionalResource and sets how they
+// are applied.
+type ApplyStrategy struct {
+	Kind       string `json:"kind,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	// Strategy is "patch", "replace" or "create-only". Patched resources
+	// are updated in place (the default). Replaced resources, like Jobs,
+	// are deleted and created again with each deployment. Create-only
+	// resources, like Secrets with generated passwords, are created if
+	// they are missing, but never updated and not reported as modified.
+	Strategy string `json:"strategy,omitempty"`
+}
+
+// StatusRetention configures how much of the deployment history is kept.
+type StatusRetention struct {
+	// HistoryLimit is the number of deployments kept in the history,
+	// defaults to 10.
+	HistoryLimit int `json:"historyLimit,omitempty"`
+	// HistoryMaxAge compacts deployments older than this, except for the
+	// newest one. Deployments are only compacted by count if empty.
+	HistoryMaxAge *metav1.Duration `json:"historyMaxAge,omitempty"`
+}
+
+// IdentityOptions configure how deployed resources are grouped. Resources
+// get the labels "fleet.cattle.io/bundle-name",
+// "fleet.cattle.io/bundle-namespace", "fleet.cattle.io/repo-name" and
+// "app.kubernetes.io/part-of". Owner references are not used, as the
+// garbage collector would delete the resources with their owner.
+type IdentityOptions struct {
+	// Application adds an app.k8s.io/v1beta1 Application, named after the
+	// bundle, which selects its resources by these labels. It is skipped,
+	// if the Application CRD is not installed in the cluster.
+	Application bool `json:"application,omitempty"`
+}
+
+// SystemOptions protect the workloads of system bundles from being evicted,
+// e.g. by node drains or preemption.
+type SystemOptions struct {
+	// PriorityClassName is set on the pods of Deployments, StatefulSets
+	// and DaemonSets, which don't specify a priority class.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// PodDisruptionBudget creates a PodDisruptionBudget for each
+	// Deployment and StatefulSet, unless the bundle has one with the same
+	// name or one, which selects its pods.
+	PodDisruptionBudget *PodDisruptionBudgetOptions `json:"podDisruptionBudget,omitempty"`
+}
+
+// PodDisruptionBudgetOptions is the policy of injected PodDisruptionBudgets.
+// Only one of the fields may be set. If neither is set, maxUnavailable
+// defaults to 1.
+type PodDisruptionBudgetOptions struct {
+	MinAvailable   *intstr.IntOrString `json:"minAvailable,omitempty"`
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// BlueGreenOptions configure blue/green deployments. The versions are
+// deployed into the namespaces "<namespace>-blue" and "<namespace>-green",
+// where namespace is the target or default namespace of the bundle.
+type BlueGreenOptions struct {
+	// Service is the name of a Service of the bundle. An ExternalName
+	// Service with that name is created in the namespace of the bundle,
+	// pointing to the Service of the active version.
+	Service string `json:"service,omitempty"`
+}
+
+// PostDeleteHooksFinalizer keeps bundle deployments with post-delete hooks,
+// until the agent ran them.
+const PostDeleteHooksFinalizer = "fleet.cattle.io/post-delete-hooks"
+
+const (
+	TTLAfterCreated = "created"
+	TTLAfterReady   = "ready"
+)
+
+const (
+	EmptyRenderDelete = "delete"
+	EmptyRenderKeep   = "keep"
+	EmptyRenderError  = "error"
+)
+
+const (
+	MergeStrategyFirstMatch = "first-match"
+	MergeStrategyMergeAll   = "merge-all"
+	MergeStrategyLastWins   = "last-wins"
+)
+
+const (
+	ApplyStrategyPatch      = "patch"
+	ApplyStrategyReplace    = "replace"
+	ApplyStrategyCreateOnly = "create-only"
+)
+
+const (
+	StatusDetailMinimal = "minimal"
+	StatusDetailNormal  = "normal"
+	StatusDetailFull    = "full"
+)
+
+// MaxStatusResources is the number of non-ready and modified resources
+// listed in a bundle deployment's status, unless its status detail is
+// "full".
+const MaxStatusResources = 10
+
+// PropagationOptions lists label and annotation keys to propagate. A key
+// ending in "*" matches all keys with that prefix.
+type PropagationOptions struct {
+	// Labels of the bundle, which are copied to its BundleDeployments, in
+	// addition to the labels copied by default.
+	Labels []string `json:"labels,omitempty"`
+	// Annotations of the bundle, which are copied to its BundleDeployments.
+	Annotations []string `json:"annotations,omitempty"`
+	// ResourceLabels are the labels of the BundleDeployment, which the
+	// agent adds to all deployed resources. Changes are applied with the
+	// next deployment.
+	ResourceLabels []string `json:"resourceLabels,omitempty"`
+}
+
+// Propagate returns the entries of m, whose keys match one of the keys.
+func Propagate(keys []string, m map[string]string) map[string]string {
+	result := map[string]string{}
+	for k, v := range m {
+		for _, key := range keys {
+			if k == key || (strings.HasSuffix(key, "*") && strings.HasPrefix(k, strings.TrimSuffix(key, "*"))) {
+				result[k] = v
+				break
+			}
+		}
+	}
+	return result
+}
+
+// OptionalResource selects resources by their kind, apiVersion, namespace
+// and name. Empty fields match any value.
+type OptionalResource struct {
+	Kind       string `json:"kind,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
 }
 
+const (
+	// DiffPresetHPAReplicas ignores the replicas of workloads, which are
+	// scaled by a HorizontalPodAutoscaler.
+	DiffPresetHPAReplicas = "hpa-replicas"
+	// DiffPresetVPAResources ignores the container resources of workloads,
+	// which are set by a VerticalPodAutoscaler.
+	DiffPresetVPAResources = "vpa-resources"
+	// DiffPresetCABundle ignores the caBundle of webhooks, CRD conversion
+	// webhooks and APIServices, which is injected by cert-manager.
+	DiffPresetCABundle = "cabundle"
+	// DiffPresetDefaultedFields ignores fields, which are defaulted by the
+	// API server, like the cluster IPs of services.
+	DiffPresetDefaultedFields = "defaulted-fields"
+)
+
 type DiffOptions struct {
 	ComparePatches []ComparePatch `json:"comparePatches,omitempty"`
+	// Presets are built-in normalizations for fields, which are commonly
+	// managed at runtime: "hpa-replicas", "vpa-resources", "cabundle" and
+	// "defaulted-fields".
+	Presets []string `json:"presets,omitempty"`
 }
 
 type ComparePatch struct {
@@ -260,6 +970,15 @@ type Operation struct {
 
 type YAMLOptions struct {
 	Overlays []string `json:"overlays,omitempty"`
+
+	// Template renders "${ }" templates in the raw manifests and overlays,
+	// with the same cluster values, labels, annotations and cluster
+	// groups, which are available in helm values.
+	Template bool `json:"template,omitempty"`
+
+	// TemplateContext is set by the controller for each cluster, if
+	// Template is enabled. It contains the values available to templates.
+	TemplateContext *GenericMap `json:"templateContext,omitempty"`
 }
 
 type KustomizeOptions struct {
@@ -279,9 +998,30 @@ type HelmOptions struct {
 	// invoking GitRepo.name + GitRepo.path.
 	ReleaseName string `json:"releaseName,omitempty"`
 
-	// Version of the chart to download
+	// Version of the chart to download. For charts from a helm repo this
+	// can be a semver range, e.g. "~1.2.0", which resolves to the latest
+	// matching version.
 	Version string `json:"version,omitempty"`
 
+	// VersionRange is a semver range of chart versions, e.g. "~1.2.0". It
+	// is used with the "commit" VersionUpdatePolicy, where Version is the
+	// deployed version in the range.
+	VersionRange string `json:"versionRange,omitempty"`
+
+	// VersionUpdatePolicy controls what happens when a newer chart
+	// version is published. If set to "auto" the bundle is re-created
+	// from the git repo to resolve the Version range again. If set to
+	// "commit" the Version in fleet.yaml is updated to the latest version
+	// matching VersionRange and pushed to the git repo, using the GitRepo's
+	// client secret and the author of its ImageScanCommit. Otherwise the
+	// latest version is only reported in the bundle status.
+	VersionUpdatePolicy string `json:"versionUpdatePolicy,omitempty"`
+
+	// ChartDigest pins a remote chart to the given digest of its files, e.g.
+	// "sha256:<hex>". Reading the bundle fails if the downloaded chart
+	// does not match. The digests are reported in the bundle status.
+	ChartDigest string `json:"chartDigest,omitempty"`
+
 	// TimeoutSeconds is the time to wait for Helm operations.
 	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 
@@ -314,6 +1054,12 @@ type HelmOptions struct {
 
 	// DisablePreProcess disables template processing in values
 	DisablePreProcess bool `json:"disablePreProcess,omitempty"`
+
+	// AgentRendering skips rendering the chart on the management cluster.
+	// The chart and values are shipped as is and only rendered by the
+	// agent against the downstream cluster, which allows charts to use
+	// lookup functions or detect capabilities of the target cluster.
+	AgentRendering bool `json:"agentRendering,omitempty"`
 }
 
 // IgnoreOptions defines conditions to be ignored when monitoring the Bundle.
@@ -368,8 +1114,120 @@ type BundleDeploymentStatus struct {
 	NonModified         bool                                `json:"nonModified,omitempty"`
 	NonReadyStatus      []NonReadyStatus                    `json:"nonReadyStatus,omitempty"`
 	ModifiedStatus      []ModifiedStatus                    `json:"modifiedStatus,omitempty"`
-	Display             BundleDeploymentDisplay             `json:"display,omitempty"`
-	SyncGeneration      *int64                              `json:"syncGeneration,omitempty"`
+	// NonReadyCount is the number of non-ready resources, NonReadyStatus
+	// might only list some of them.
+	NonReadyCount int `json:"nonReadyCount,omitempty"`
+	// ModifiedCount is the number of modified resources, ModifiedStatus
+	// might only list some of them.
+	ModifiedCount  int                     `json:"modifiedCount,omitempty"`
+	Display        BundleDeploymentDisplay `json:"display,omitempty"`
+	SyncGeneration *int64                  `json:"syncGeneration,omitempty"`
+	// OptionalResourceErrors lists the errors from applying optional
+	// resources, which did not fail the deployment.
+	OptionalResourceErrors []string `json:"optionalResourceErrors,omitempty"`
+	// RolloutHold lists the resources, which are held by a progressive
+	// delivery controller and excluded from the ready state.
+	RolloutHold []string `json:"rolloutHold,omitempty"`
+	// Redeploy is the value of the redeploy annotation, which was last
+	// deployed
+	Redeploy string `json:"redeploy,omitempty"`
+	// BlueGreen is the state of a blue/green deployment
+	BlueGreen *BlueGreenStatus `json:"blueGreen,omitempty"`
+	// History lists the last deployments to the cluster, newest first.
+	History []DeploymentHistory `json:"history,omitempty"`
+	// HistorySummary summarizes the deployments, which were compacted
+	// from the history.
+	HistorySummary *DeploymentHistorySummary `json:"historySummary,omitempty"`
+	// WebhookRejections lists the resources, which admission webhooks
+	// rejected in the last deployment.
+	WebhookRejections []WebhookRejection `json:"webhookRejections,omitempty"`
+	// WebhookRetry is set while a deployment, which admission webhooks
+	// rejected, waits to be retried.
+	WebhookRetry *WebhookRetry `json:"webhookRetry,omitempty"`
+	// ReplaceRetry is set while a deployment, which replaced resources and
+	// failed, waits to replace them again.
+	ReplaceRetry *ReplaceRetry `json:"replaceRetry,omitempty"`
+}
+
+const (
+	WebhookPolicyRetry = "retry"
+	WebhookPolicyFail  = "fail"
+	WebhookPolicyForce = "force"
+)
+
+// WebhookRetry backs off retrying a deployment, which admission webhooks
+// rejected.
+type WebhookRetry struct {
+	DeploymentID string      `json:"deploymentID"`
+	Attempts     int         `json:"attempts"`
+	After        metav1.Time `json:"after"`
+}
+
+// ReplaceRetry backs off replacing the resources of a deployment again.
+type ReplaceRetry struct {
+	DeploymentID string      `json:"deploymentID"`
+	Attempts     int         `json:"attempts"`
+	After        metav1.Time `json:"after"`
+}
+
+// WebhookRejection is a resource, which an admission webhook of the
+// downstream cluster rejected.
+type WebhookRejection struct {
+	// Webhook is the name of the rejecting webhook.
+	Webhook    string `json:"webhook"`
+	Kind       string `json:"kind,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// MaxDeploymentHistory is the number of deployments kept in the history of
+// a bundle deployment, unless its status retention sets a limit.
+const MaxDeploymentHistory = 10
+
+const (
+	DeploymentResultDeployed = "Deployed"
+	DeploymentResultReady    = "Ready"
+	DeploymentResultFailed   = "Failed"
+)
+
+// DeploymentHistory is a deployment of new content or options by the agent.
+type DeploymentHistory struct {
+	DeploymentID string `json:"deploymentID"`
+	// Commit is the git commit of the content, if it came from a gitrepo.
+	Commit     string      `json:"commit,omitempty"`
+	DeployedAt metav1.Time `json:"deployedAt"`
+	// Result is "Deployed", once the resources were applied, "Ready",
+	// once they were ready, or "Failed", if they couldn't be applied.
+	Result  string `json:"result"`
+	Message string `json:"message,omitempty"`
+}
+
+// DeploymentHistorySummary counts the deployments, which were removed from
+// the history.
+type DeploymentHistorySummary struct {
+	Deployments int `json:"deployments"`
+	Failed      int `json:"failed,omitempty"`
+	// Since is when the oldest compacted deployment happened.
+	Since metav1.Time `json:"since"`
+	// Until is when the newest compacted deployment happened.
+	Until metav1.Time `json:"until"`
+}
+
+const (
+	BlueGreenBlue  = "blue"
+	BlueGreenGreen = "green"
+)
+
+type BlueGreenStatus struct {
+	// Active is the color, blue or green, which receives the traffic
+	Active string `json:"active,omitempty"`
+	// ActiveRelease is the release of the active color
+	ActiveRelease string `json:"activeRelease,omitempty"`
+	// Pending is the color the new version was deployed to, until it is
+	// ready and traffic is switched to it
+	Pending string `json:"pending,omitempty"`
 }
 
 type BundleDeploymentDisplay struct {
@@ -433,4 +1291,9 @@ type Content struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	Content []byte `json:"content,omitempty"`
+
+	// Chunks lists the names of the content objects, which hold the
+	// content, if it is too large for a single object. The content is
+	// the concatenation of the chunks.
+	Chunks []string `json:"chunks,omitempty"`
 }
@@ -9,6 +9,52 @@ var (
 	RepoLabel            = "fleet.cattle.io/repo-name"
 	BundleLabel          = "fleet.cattle.io/bundle-name"
 	BundleNamespaceLabel = "fleet.cattle.io/bundle-namespace"
+	// LocalBundleLabel marks bundles created from the controller's local
+	// bundle directory. Their repo-name label doesn't refer to a GitRepo,
+	// so they are not purged as orphans.
+	LocalBundleLabel = "fleet.cattle.io/local-bundle"
+	// RepoBranchLabel identifies the branch of a GitRepo tracking
+	// multiple branches, which a bundle was created from. Its value is
+	// derived from the branch's bundle prefix.
+	RepoBranchLabel = "fleet.cattle.io/repo-branch"
+	// BundleAnnotation is used on a bundledeployment to refer to the full
+	// bundle name, if it's too long for the BundleLabel value
+	BundleAnnotation = "fleet.cattle.io/bundle-name"
+	// GitRepoForceDeleteAnnotation set to "true" on a GitRepo skips
+	// waiting for its bundle deployments to be removed on deletion
+	GitRepoForceDeleteAnnotation = "fleet.cattle.io/force-delete"
+	// SourceHashAnnotation is a checksum of the files and options a bundle
+	// was created from, used to skip unchanged paths of a GitRepo
+	SourceHashAnnotation = "fleet.cattle.io/source-hash"
+	// PathHashAnnotation is a checksum of the path and options a bundle
+	// was created from, without its files. It finds the bundles of paths
+	// without changes in the git diff.
+	PathHashAnnotation = "fleet.cattle.io/path-hash"
+	// RedeployAnnotation on a bundledeployment makes the agent deploy its
+	// bundle again, even if nothing changed. Setting it to a new value
+	// triggers another redeployment.
+	RedeployAnnotation = "fleet.cattle.io/redeploy"
+	// ResyncAnnotation on a bundle makes the controller compute its
+	// targets and the derived status again, without caches, even if the
+	// bundle did not change. Setting it to a new value triggers another
+	// resync.
+	ResyncAnnotation = "fleet.cattle.io/resync"
+	// PinnedUntilAnnotation on a bundledeployment stops the controller
+	// from staging and rolling out new content to it, until the RFC3339
+	// time it's set to. It's meant to freeze a cluster during an incident.
+	PinnedUntilAnnotation = "fleet.cattle.io/pinned-until"
+	// PinnedByAnnotation records who pinned a bundledeployment and why, it
+	// is reported in the bundle's status while the pin is active
+	PinnedByAnnotation = "fleet.cattle.io/pinned-by"
+	// PruneConfirmedAnnotation on a bundle, set to the manifest ID of its
+	// pending prune, rolls out the update before the prune confirmation
+	// period passed
+	PruneConfirmedAnnotation = "fleet.cattle.io/prune-confirmed"
+	// ChartVersionSyncAnnotation is counted up by the chart version
+	// controller on a GitRepo, to re-create its bundles when a newer chart
+	// version matching a version range is published. It's added to the
+	// ForceSyncGeneration, so the user owned spec is left untouched.
+	ChartVersionSyncAnnotation = "fleet.cattle.io/chart-version-sync"
 )
 
 // +genclient
@@ -29,9 +75,18 @@ type GitRepoSpec struct {
 	// Branch The git branch to follow
 	Branch string `json:"branch,omitempty"`
 
+	// Branches are followed instead of the branch, if set. Each branch is
+	// checked out by its own job and deploys its own bundles. Errors of
+	// paths are not reported in the status, they fail the branch's job.
+	Branches []GitBranch `json:"branches,omitempty"`
+
 	// Revision A specific commit or tag to operate on
 	Revision string `json:"revision,omitempty"`
 
+	// RevisionSelector makes the repo follow the highest tag matching it,
+	// instead of a branch. The branch and revision are ignored if it's set.
+	RevisionSelector *RevisionSelector `json:"revisionSelector,omitempty"`
+
 	// Ensure that all resources are created in this namespace
 	// Any cluster scoped resource will be rejected if this is set
 	// Additionally this namespace will be created on demand
@@ -39,6 +94,8 @@ type GitRepoSpec struct {
 
 	// ClientSecretName is the client secret to be used to connect to the repo
 	// It is expected the secret be of type "kubernetes.io/basic-auth" or "kubernetes.io/ssh-auth".
+	// Opaque secrets with GitHub App, token or AWS CodeCommit credentials are
+	// converted to a basic auth secret for cloning, which is renewed periodically.
 	ClientSecretName string `json:"clientSecretName,omitempty"`
 
 	// HelmSecretName contains the auth secret for private helm repository
@@ -87,6 +144,103 @@ type GitRepoSpec struct {
 
 	// KeepResources specifies if the resources created must be kept after deleting the GitRepo
 	KeepResources bool `json:"keepResources,omitempty"`
+
+	// Proxy configures the proxy used by the job, which clones the repo and downloads charts
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// SkipUnchangedPaths keeps the bundles of paths, whose files did not
+	// change since they were created, instead of recreating them for
+	// each commit. Paths are compared by the git diff against the last
+	// applied commit, if its history was cloned, otherwise by checksums of
+	// their files. Paths referring to files outside of them are always
+	// recreated. Helm charts from repositories are only updated when
+	// their path changes.
+	SkipUnchangedPaths bool `json:"skipUnchangedPaths,omitempty"`
+
+	// Webhook, if set, registers a push webhook on the git provider, which
+	// points at the webhook receiver. The client secret must allow
+	// managing the repository's webhooks.
+	Webhook *WebhookRegistration `json:"webhook,omitempty"`
+
+	// HelmValues are merged into the helm values of every bundle created
+	// from this repo, with the lowest precedence. Values in fleet.yaml and
+	// in target customizations override them.
+	HelmValues *GenericMap `json:"helmValues,omitempty"`
+
+	// Verification, if set, requires the checked out commit to be signed
+	// by a trusted key. No bundles are created from commits failing the
+	// verification.
+	Verification *GitRepoVerification `json:"verification,omitempty"`
+
+	// Submodules, if set, checks out the submodules of the repo at the
+	// commits recorded in it, before creating bundles.
+	Submodules *GitSubmodules `json:"submodules,omitempty"`
+
+	// LFS downloads the Git LFS objects of the checked out commit, so
+	// bundles contain the files instead of their LFS pointers. LFS objects
+	// of submodules are downloaded, if they are checked out. It requires
+	// git-lfs in the job's image.
+	LFS bool `json:"lfs,omitempty"`
+}
+
+const (
+	WebhookProviderGitHub = "github"
+	WebhookProviderGitLab = "gitlab"
+	WebhookProviderGitea  = "gitea"
+)
+
+type WebhookRegistration struct {
+	// Provider is github, gitlab or gitea. It is detected from the host
+	// of the repo URL if empty.
+	Provider string `json:"provider,omitempty"`
+	// ClientSecretName is the secret with the API token, which registers
+	// the webhook. It defaults to the GitRepo's client secret.
+	ClientSecretName string `json:"clientSecretName,omitempty"`
+}
+
+type GitBranch struct {
+	// Name of the git branch
+	Name string `json:"name,omitempty"`
+	// BundlePrefix is prepended to the names of the branch's bundles. It
+	// defaults to the GitRepo's name followed by the branch name.
+	BundlePrefix string `json:"bundlePrefix,omitempty"`
+	// Targets of the branch's bundles, instead of the GitRepo's targets
+	Targets []GitTarget `json:"targets,omitempty"`
+}
+
+type GitRepoVerification struct {
+	// PublicKeysSecret is the name of a secret with the trusted keys. Each
+	// key of the secret holds armored PGP public keys or SSH public keys
+	// in the authorized_keys format.
+	PublicKeysSecret string `json:"publicKeysSecret,omitempty"`
+}
+
+type GitSubmodules struct {
+	// Recursive also checks out the submodules of submodules
+	Recursive bool `json:"recursive,omitempty"`
+	// Credentials are used to clone submodules from other hosts than the
+	// repo's. The client secret of the GitRepo is used for the repo's host.
+	Credentials []SubmoduleCredential `json:"credentials,omitempty"`
+}
+
+type SubmoduleCredential struct {
+	// Host of the submodules' repos, like github.com
+	Host string `json:"host,omitempty"`
+	// SecretName of a "kubernetes.io/basic-auth" or
+	// "kubernetes.io/ssh-auth" secret
+	SecretName string `json:"secretName,omitempty"`
+}
+
+type RevisionSelector struct {
+	// Semver is a semantic version constraint, like ">=1.2.0 <2.0.0".
+	// Tags, which are not semantic versions, are ignored.
+	Semver string `json:"semver,omitempty"`
+}
+
+type ProxyConfig struct {
+	HTTPProxy  string `json:"httpProxy,omitempty"`
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	NoProxy    string `json:"noProxy,omitempty"`
 }
 
 type GitTarget struct {
@@ -95,6 +249,12 @@ type GitTarget struct {
 	ClusterSelector      *metav1.LabelSelector `json:"clusterSelector,omitempty"`
 	ClusterGroup         string                `json:"clusterGroup,omitempty"`
 	ClusterGroupSelector *metav1.LabelSelector `json:"clusterGroupSelector,omitempty"`
+	// ExcludeClusterSelector excludes clusters, whose labels match it,
+	// even if they match the other selectors.
+	ExcludeClusterSelector *metav1.LabelSelector `json:"excludeClusterSelector,omitempty"`
+	// ExcludeClusterGroup excludes the clusters of this cluster group, even
+	// if they match the other selectors.
+	ExcludeClusterGroup string `json:"excludeClusterGroup,omitempty"`
 }
 
 type GitRepoStatus struct {
@@ -110,6 +270,77 @@ type GitRepoStatus struct {
 	ResourceCounts          GitRepoResourceCounts               `json:"resourceCounts,omitempty"`
 	ResourceErrors          []string                            `json:"resourceErrors,omitempty"`
 	LastSyncedImageScanTime metav1.Time                         `json:"lastSyncedImageScanTime,omitempty"`
+	// Teardown lists the resources, which are still being removed after
+	// the GitRepo was deleted
+	Teardown *GitRepoTeardown `json:"teardown,omitempty"`
+	// Webhook is the state of the registered push webhook
+	Webhook *WebhookStatus `json:"webhook,omitempty"`
+	// PathErrors lists the paths, whose bundles could not be created from
+	// the last commit. The bundles of the other paths are still updated.
+	PathErrors []GitRepoPathError `json:"pathErrors,omitempty"`
+	// ResolvedRevision is the tag the revision selector resolved to
+	ResolvedRevision *ResolvedRevision `json:"resolvedRevision,omitempty"`
+	// Branches is the state of each followed branch, if the GitRepo
+	// follows multiple branches
+	Branches []GitBranchStatus `json:"branches,omitempty"`
+	// AppliedCommit is the last commit, whose bundles were created
+	// successfully. Unchanged paths are detected by the diff against it.
+	AppliedCommit string `json:"appliedCommit,omitempty"`
+	// Verification is the result of verifying the signature of the last
+	// checked out commit
+	Verification *CommitVerification `json:"verification,omitempty"`
+}
+
+type CommitVerification struct {
+	Commit   string `json:"commit,omitempty"`
+	Verified bool   `json:"verified,omitempty"`
+	// Signer is the ID of the PGP key or the fingerprint of the SSH key,
+	// which signed the commit
+	Signer      string      `json:"signer,omitempty"`
+	Message     string      `json:"message,omitempty"`
+	LastChecked metav1.Time `json:"lastChecked,omitempty"`
+}
+
+type GitBranchStatus struct {
+	Name         string `json:"name,omitempty"`
+	Commit       string `json:"commit,omitempty"`
+	GitJobStatus string `json:"gitJobStatus,omitempty"`
+	// AppliedCommit is the last commit of the branch, whose bundles were
+	// created successfully
+	AppliedCommit string `json:"appliedCommit,omitempty"`
+}
+
+type ResolvedRevision struct {
+	// Semver is the constraint the tag was selected by
+	Semver      string      `json:"semver,omitempty"`
+	Tag         string      `json:"tag,omitempty"`
+	Commit      string      `json:"commit,omitempty"`
+	LastChecked metav1.Time `json:"lastChecked,omitempty"`
+}
+
+type GitRepoPathError struct {
+	Path    string `json:"path"`
+	Message string `json:"message,omitempty"`
+}
+
+type WebhookStatus struct {
+	Provider string `json:"provider,omitempty"`
+	// ID of the webhook at the provider
+	ID string `json:"id,omitempty"`
+	// URL the webhook sends events to
+	URL string `json:"url,omitempty"`
+	// Healthy is false if the provider reported failed deliveries
+	Healthy     bool        `json:"healthy,omitempty"`
+	Message     string      `json:"message,omitempty"`
+	LastChecked metav1.Time `json:"lastChecked,omitempty"`
+}
+
+type GitRepoTeardown struct {
+	// RemainingBundles are the bundles of the GitRepo, which still exist
+	RemainingBundles []string `json:"remainingBundles,omitempty"`
+	// RemainingBundleDeployments is the number of bundle deployments,
+	// which have not been removed from their clusters yet
+	RemainingBundleDeployments int `json:"remainingBundleDeployments,omitempty"`
 }
 
 type GitRepoResourceCounts struct {
@@ -147,6 +378,29 @@ type GitRepoRestriction struct {
 	AllowedTargetNamespaces []string `json:"allowedTargetNamespaces,omitempty"`
 }
 
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitRepoDefaults contains default values for all GitRepos in its namespace.
+// Values set on a GitRepo take precedence.
+type GitRepoDefaults struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GitRepoDefaultsSpec `json:"spec,omitempty"`
+}
+
+type GitRepoDefaultsSpec struct {
+	ClientSecretName string           `json:"clientSecretName,omitempty"`
+	HelmSecretName   string           `json:"helmSecretName,omitempty"`
+	CABundle         []byte           `json:"caBundle,omitempty"`
+	PollingInterval  *metav1.Duration `json:"pollingInterval,omitempty"`
+	Proxy            *ProxyConfig     `json:"proxy,omitempty"`
+	// WebhookClientSecretName is the secret with the API token, which
+	// registers the webhooks of the GitRepos
+	WebhookClientSecretName string `json:"webhookClientSecretName,omitempty"`
+}
+
 type GitRepoResource struct {
 	APIVersion      string                    `json:"apiVersion,omitempty"`
 	Kind            string                    `json:"kind,omitempty"`
@@ -29,6 +29,26 @@ var (
 	ClusterLabel = "fleet.cattle.io/cluster"
 )
 
+// DeploymentClusterName returns the full name of the bundle deployment's
+// cluster. The ClusterLabel value is shortened, if the name is too long for
+// a label value.
+func DeploymentClusterName(bd *BundleDeployment) string {
+	if name, ok := bd.Annotations[ClusterAnnotation]; ok {
+		return name
+	}
+	return bd.Labels[ClusterLabel]
+}
+
+// DeploymentBundleName returns the full name of the bundle deployment's
+// bundle. The BundleLabel value is shortened, if the name is too long for
+// a label value.
+func DeploymentBundleName(bd *BundleDeployment) string {
+	if name, ok := bd.Annotations[BundleAnnotation]; ok {
+		return name
+	}
+	return bd.Labels[BundleLabel]
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -42,6 +62,20 @@ type ClusterGroup struct {
 
 type ClusterGroupSpec struct {
 	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// SelectorExpression is a CEL expression over the cluster, like a
+	// target's clusterSelectorExpression, e.g.
+	// `(labels.env == "prod" || labels.env == "staging") && !("canary" in labels)`.
+	// Clusters have to match it in addition to the selector, if both are
+	// set. Clusters, for which it fails, e.g. because of a missing label,
+	// are not members.
+	SelectorExpression string `json:"selectorExpression,omitempty"`
+
+	// PropagationDelay is how long a new version of a bundle has to be
+	// deployed to other clusters, before the clusters of this group are
+	// updated to it. It applies to targets, which don't set their own
+	// propagation delay. The longest delay of a cluster's groups is used.
+	PropagationDelay *metav1.Duration `json:"propagationDelay,omitempty"`
 }
 
 type ClusterGroupStatus struct {
@@ -106,6 +140,11 @@ type ClusterSpec struct {
 
 	// AgentResources sets the resources for the cluster's agent deployment.
 	AgentResources *v1.ResourceRequirements `json:"agentResources,omitempty"`
+
+	// MaintenanceWindows restrict when bundle deployments of the cluster
+	// are updated to new content, in addition to the windows of the
+	// bundle's targets.
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
 }
 
 type ClusterStatus struct {
@@ -135,6 +174,20 @@ type ClusterStatus struct {
 
 	Display ClusterDisplay `json:"display,omitempty"`
 	Agent   AgentStatus    `json:"agent,omitempty"`
+
+	// PendingDeletions lists the bundle deployments, which were deleted
+	// while the cluster's agent was offline. The agent uninstalls them,
+	// when it reconnects, and they are removed once it reports them in
+	// its completed deletions.
+	PendingDeletions []BundleDeploymentTombstone `json:"pendingDeletions,omitempty"`
+}
+
+// BundleDeploymentTombstone is a deleted bundle deployment, which the
+// cluster's agent has yet to uninstall.
+type BundleDeploymentTombstone struct {
+	// Name of the bundle deployment in the cluster namespace.
+	Name      string      `json:"name"`
+	DeletedAt metav1.Time `json:"deletedAt"`
 }
 
 type ClusterDisplay struct {
@@ -153,6 +206,31 @@ type AgentStatus struct {
 	NonReadyNodeNames []string `json:"nonReadyNodeNames"`
 	// At most 3 nodes
 	ReadyNodeNames []string `json:"readyNodeNames"`
+	// KubernetesVersion is the version of the downstream cluster's API server.
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// APIVersions is the inventory of group versions and kinds served by
+	// the downstream cluster, e.g. "apps/v1" and "apps/v1/Deployment".
+	APIVersions []string `json:"apiVersions,omitempty"`
+	// Upgrading is true while the agent detects an upgrade of the
+	// cluster's control plane. The agent doesn't deploy bundles while
+	// it's set and the cluster doesn't count as unavailable.
+	Upgrading bool `json:"upgrading,omitempty"`
+
+	// NodeArchitectures lists the distinct architectures of the cluster's
+	// nodes, e.g. "amd64" and "arm64".
+	NodeArchitectures []string `json:"nodeArchitectures,omitempty"`
+
+	// CloudProvider is the provider of the cluster's nodes, e.g. "aws",
+	// "gce" or "azure", as found in their provider ID.
+	CloudProvider string `json:"cloudProvider,omitempty"`
+
+	// CNI is the network plugin detected in the cluster, e.g. "calico",
+	// "canal", "cilium" or "flannel".
+	CNI string `json:"cni,omitempty"`
+
+	// CompletedDeletions lists the cluster's pending deletions, which the
+	// agent uninstalled.
+	CompletedDeletions []string `json:"completedDeletions,omitempty"`
 }
 
 // +genclient
@@ -196,3 +274,63 @@ type ClusterRegistrationTokenStatus struct {
 	Expires    *metav1.Time `json:"expires,omitempty"`
 	SecretName string       `json:"secretName,omitempty"`
 }
+
+const (
+	// ClusterImportFormatFleet is a secret in the format expected by
+	// Cluster.Spec.KubeConfigSecret, the kubeconfig is stored in the
+	// "value" key.
+	ClusterImportFormatFleet = "fleet"
+	// ClusterImportFormatCAPI is a kubeconfig secret created by Cluster
+	// API, the kubeconfig is stored in the "value" key.
+	ClusterImportFormatCAPI = "capi"
+	// ClusterImportFormatRancher is a secret with the "url" of the
+	// downstream API server, a bearer "token" and optionally the
+	// "ca.crt" of the API server.
+	ClusterImportFormatRancher = "rancher"
+	// ClusterImportFormatKubeConfig is a plain kubeconfig, stored in the
+	// "kubeconfig" key, unless Key is set.
+	ClusterImportFormatKubeConfig = "kubeconfig"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterImport imports a downstream cluster from an existing secret, which
+// is converted into the kubeconfig secret format of fleet.
+type ClusterImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterImportSpec   `json:"spec,omitempty"`
+	Status ClusterImportStatus `json:"status,omitempty"`
+}
+
+type ClusterImportSpec struct {
+	// SecretName is the name of the secret containing the credentials of
+	// the downstream cluster, in the same namespace.
+	SecretName string `json:"secretName,omitempty"`
+
+	// Format of the secret, one of "fleet", "capi", "rancher" or
+	// "kubeconfig". Defaults to "fleet".
+	Format string `json:"format,omitempty"`
+
+	// Key overrides the key of the secret, which contains the kubeconfig.
+	Key string `json:"key,omitempty"`
+
+	// Context selects the context of the kubeconfig to use, instead of
+	// its current context.
+	Context string `json:"context,omitempty"`
+
+	// ClusterName is the name of the created cluster, it defaults to the
+	// name of the cluster import.
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// ClusterLabels are added to the created cluster.
+	ClusterLabels map[string]string `json:"clusterLabels,omitempty"`
+}
+
+type ClusterImportStatus struct {
+	Conditions  []genericcondition.GenericCondition `json:"conditions,omitempty"`
+	ClusterName string                              `json:"clusterName,omitempty"`
+	SecretName  string                              `json:"secretName,omitempty"`
+}
@@ -0,0 +1,43 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FleetWorkspaceStatusName is the name of the FleetWorkspaceStatus in each
+// namespace.
+const FleetWorkspaceStatusName = "workspace"
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FleetWorkspaceStatus summarizes the gitrepos, bundles and clusters of a
+// namespace for dashboards. The fleet controller maintains one, named
+// "workspace", in each namespace containing any of them.
+type FleetWorkspaceStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	GitRepos      int `json:"gitRepos"`
+	ReadyGitRepos int `json:"readyGitRepos"`
+	Bundles       int `json:"bundles"`
+	ReadyBundles  int `json:"readyBundles"`
+	// BundleStates counts the bundles by their state, e.g. "Ready" or
+	// "ErrApplied".
+	BundleStates map[string]int `json:"bundleStates,omitempty"`
+	// Summary sums up the summaries of all bundles.
+	Summary BundleSummary `json:"summary,omitempty"`
+	// StalledBundles are the names of bundles, which haven't been ready for
+	// 30 minutes. At most 100 are listed.
+	StalledBundles []string `json:"stalledBundles,omitempty"`
+
+	Clusters      int `json:"clusters"`
+	ReadyClusters int `json:"readyClusters"`
+	// OfflineClusters are the names of clusters, whose agent missed three
+	// check-ins. At most 100 are listed.
+	OfflineClusters []string `json:"offlineClusters,omitempty"`
+
+	// LastActivity is the newest change of a condition of the gitrepos and
+	// bundles.
+	LastActivity *metav1.Time `json:"lastActivity,omitempty"`
+}
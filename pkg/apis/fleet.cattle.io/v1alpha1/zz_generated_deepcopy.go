@@ -43,6 +43,21 @@ func (in *AgentStatus) DeepCopyInto(out *AgentStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.APIVersions != nil {
+		in, out := &in.APIVersions, &out.APIVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeArchitectures != nil {
+		in, out := &in.NodeArchitectures, &out.NodeArchitectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletedDeletions != nil {
+		in, out := &in.CompletedDeletions, &out.CompletedDeletions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -72,6 +87,80 @@ func (in *AlphabeticalPolicy) DeepCopy() *AlphabeticalPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplyStrategy) DeepCopyInto(out *ApplyStrategy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplyStrategy.
+func (in *ApplyStrategy) DeepCopy() *ApplyStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplyStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoRollback) DeepCopyInto(out *AutoRollback) {
+	*out = *in
+	if in.MaxFailures != nil {
+		in, out := &in.MaxFailures, &out.MaxFailures
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoRollback.
+func (in *AutoRollback) DeepCopy() *AutoRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreenOptions) DeepCopyInto(out *BlueGreenOptions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreenOptions.
+func (in *BlueGreenOptions) DeepCopy() *BlueGreenOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreenStatus) DeepCopyInto(out *BlueGreenStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreenStatus.
+func (in *BlueGreenStatus) DeepCopy() *BlueGreenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Bundle) DeepCopyInto(out *Bundle) {
 	*out = *in
@@ -100,6 +189,27 @@ func (in *Bundle) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleCost) DeepCopyInto(out *BundleCost) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterCost, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleCost.
+func (in *BundleCost) DeepCopy() *BundleCost {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleCost)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BundleDeployment) DeepCopyInto(out *BundleDeployment) {
 	*out = *in
@@ -201,6 +311,41 @@ func (in *BundleDeploymentOptions) DeepCopyInto(out *BundleDeploymentOptions) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.IgnoreOptions.DeepCopyInto(&out.IgnoreOptions)
+	if in.OptionalResources != nil {
+		in, out := &in.OptionalResources, &out.OptionalResources
+		*out = make([]OptionalResource, len(*in))
+		copy(*out, *in)
+	}
+	if in.Propagation != nil {
+		in, out := &in.Propagation, &out.Propagation
+		*out = new(PropagationOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreenOptions)
+		**out = **in
+	}
+	if in.System != nil {
+		in, out := &in.System, &out.System
+		*out = new(SystemOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Identity != nil {
+		in, out := &in.Identity, &out.Identity
+		*out = new(IdentityOptions)
+		**out = **in
+	}
+	if in.StatusRetention != nil {
+		in, out := &in.StatusRetention, &out.StatusRetention
+		*out = new(StatusRetention)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ApplyStrategies != nil {
+		in, out := &in.ApplyStrategies, &out.ApplyStrategies
+		*out = make([]ApplyStrategy, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -265,6 +410,48 @@ func (in *BundleDeploymentStatus) DeepCopyInto(out *BundleDeploymentStatus) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.OptionalResourceErrors != nil {
+		in, out := &in.OptionalResourceErrors, &out.OptionalResourceErrors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RolloutHold != nil {
+		in, out := &in.RolloutHold, &out.RolloutHold
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreenStatus)
+		**out = **in
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]DeploymentHistory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HistorySummary != nil {
+		in, out := &in.HistorySummary, &out.HistorySummary
+		*out = new(DeploymentHistorySummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WebhookRejections != nil {
+		in, out := &in.WebhookRejections, &out.WebhookRejections
+		*out = make([]WebhookRejection, len(*in))
+		copy(*out, *in)
+	}
+	if in.WebhookRetry != nil {
+		in, out := &in.WebhookRetry, &out.WebhookRetry
+		*out = new(WebhookRetry)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplaceRetry != nil {
+		in, out := &in.ReplaceRetry, &out.ReplaceRetry
+		*out = new(ReplaceRetry)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -278,6 +465,23 @@ func (in *BundleDeploymentStatus) DeepCopy() *BundleDeploymentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleDeploymentTombstone) DeepCopyInto(out *BundleDeploymentTombstone) {
+	*out = *in
+	in.DeletedAt.DeepCopyInto(&out.DeletedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleDeploymentTombstone.
+func (in *BundleDeploymentTombstone) DeepCopy() *BundleDeploymentTombstone {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleDeploymentTombstone)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BundleDisplay) DeepCopyInto(out *BundleDisplay) {
 	*out = *in
@@ -433,6 +637,97 @@ func (in *BundleResource) DeepCopy() *BundleResource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleRevision) DeepCopyInto(out *BundleRevision) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.BundleLabels != nil {
+		in, out := &in.BundleLabels, &out.BundleLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleRevision.
+func (in *BundleRevision) DeepCopy() *BundleRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BundleRevision) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleRevisionList) DeepCopyInto(out *BundleRevisionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BundleRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleRevisionList.
+func (in *BundleRevisionList) DeepCopy() *BundleRevisionList {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleRevisionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BundleRevisionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleScheduleStatus) DeepCopyInto(out *BundleScheduleStatus) {
+	*out = *in
+	if in.Next != nil {
+		in, out := &in.Next, &out.Next
+		*out = (*in).DeepCopy()
+	}
+	if in.Last != nil {
+		in, out := &in.Last, &out.Last
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleScheduleStatus.
+func (in *BundleScheduleStatus) DeepCopy() *BundleScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BundleSpec) DeepCopyInto(out *BundleSpec) {
 	*out = *in
@@ -468,6 +763,16 @@ func (in *BundleSpec) DeepCopyInto(out *BundleSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.PruneConfirmationPeriod != nil {
+		in, out := &in.PruneConfirmationPeriod, &out.PruneConfirmationPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -503,6 +808,67 @@ func (in *BundleStatus) DeepCopyInto(out *BundleStatus) {
 		*out = make([]ResourceKey, len(*in))
 		copy(*out, *in)
 	}
+	if in.ChartDigests != nil {
+		in, out := &in.ChartDigests, &out.ChartDigests
+		*out = make([]ChartDigest, len(*in))
+		copy(*out, *in)
+	}
+	if in.MissingAPIs != nil {
+		in, out := &in.MissingAPIs, &out.MissingAPIs
+		*out = make([]TargetMissingAPIs, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PromotedAt != nil {
+		in, out := &in.PromotedAt, &out.PromotedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Cost != nil {
+		in, out := &in.Cost, &out.Cost
+		*out = new(BundleCost)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(BundleScheduleStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadyAt != nil {
+		in, out := &in.ReadyAt, &out.ReadyAt
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.RunOnce != nil {
+		in, out := &in.RunOnce, &out.RunOnce
+		*out = make([]RunOnceStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pinned != nil {
+		in, out := &in.Pinned, &out.Pinned
+		*out = make([]PinnedStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]ClusterNamespace, len(*in))
+		copy(*out, *in)
+	}
+	if in.RolloutSteps != nil {
+		in, out := &in.RolloutSteps, &out.RolloutSteps
+		*out = new(RolloutStepsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingPrune != nil {
+		in, out := &in.PendingPrune, &out.PendingPrune
+		*out = new(PendingPrune)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -526,6 +892,11 @@ func (in *BundleSummary) DeepCopyInto(out *BundleSummary) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]SummaryError, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -553,6 +924,28 @@ func (in *BundleTarget) DeepCopyInto(out *BundleTarget) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ExcludeClusterSelector != nil {
+		in, out := &in.ExcludeClusterSelector, &out.ExcludeClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PropagationDelay != nil {
+		in, out := &in.PropagationDelay, &out.PropagationDelay
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ValuesFrom != nil {
+		in, out := &in.ValuesFrom, &out.ValuesFrom
+		*out = make([]ValuesFrom, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -579,6 +972,11 @@ func (in *BundleTargetRestriction) DeepCopyInto(out *BundleTargetRestriction) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ExcludeClusterSelector != nil {
+		in, out := &in.ExcludeClusterSelector, &out.ExcludeClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -593,17 +991,33 @@ func (in *BundleTargetRestriction) DeepCopy() *BundleTargetRestriction {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Cluster) DeepCopyInto(out *Cluster) {
+func (in *ChartDigest) DeepCopyInto(out *ChartDigest) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cluster.
-func (in *Cluster) DeepCopy() *Cluster {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChartDigest.
+func (in *ChartDigest) DeepCopy() *ChartDigest {
+	if in == nil {
+		return nil
+	}
+	out := new(ChartDigest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Cluster) DeepCopyInto(out *Cluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cluster.
+func (in *Cluster) DeepCopy() *Cluster {
 	if in == nil {
 		return nil
 	}
@@ -620,6 +1034,22 @@ func (in *Cluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCost) DeepCopyInto(out *ClusterCost) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCost.
+func (in *ClusterCost) DeepCopy() *ClusterCost {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCost)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterDisplay) DeepCopyInto(out *ClusterDisplay) {
 	*out = *in
@@ -721,6 +1151,11 @@ func (in *ClusterGroupSpec) DeepCopyInto(out *ClusterGroupSpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PropagationDelay != nil {
+		in, out := &in.PropagationDelay, &out.PropagationDelay
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -763,6 +1198,111 @@ func (in *ClusterGroupStatus) DeepCopy() *ClusterGroupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterImport) DeepCopyInto(out *ClusterImport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterImport.
+func (in *ClusterImport) DeepCopy() *ClusterImport {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterImport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterImport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterImportList) DeepCopyInto(out *ClusterImportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterImport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterImportList.
+func (in *ClusterImportList) DeepCopy() *ClusterImportList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterImportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterImportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterImportSpec) DeepCopyInto(out *ClusterImportSpec) {
+	*out = *in
+	if in.ClusterLabels != nil {
+		in, out := &in.ClusterLabels, &out.ClusterLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterImportSpec.
+func (in *ClusterImportSpec) DeepCopy() *ClusterImportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterImportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterImportStatus) DeepCopyInto(out *ClusterImportStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]genericcondition.GenericCondition, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterImportStatus.
+func (in *ClusterImportStatus) DeepCopy() *ClusterImportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterImportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterList) DeepCopyInto(out *ClusterList) {
 	*out = *in
@@ -796,6 +1336,22 @@ func (in *ClusterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterNamespace) DeepCopyInto(out *ClusterNamespace) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterNamespace.
+func (in *ClusterNamespace) DeepCopy() *ClusterNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterRegistration) DeepCopyInto(out *ClusterRegistration) {
 	*out = *in
@@ -1029,6 +1585,11 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		*out = new(corev1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -1059,6 +1620,13 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	}
 	out.Display = in.Display
 	in.Agent.DeepCopyInto(&out.Agent)
+	if in.PendingDeletions != nil {
+		in, out := &in.PendingDeletions, &out.PendingDeletions
+		*out = make([]BundleDeploymentTombstone, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -1088,6 +1656,23 @@ func (in *CommitSpec) DeepCopy() *CommitSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CommitVerification) DeepCopyInto(out *CommitVerification) {
+	*out = *in
+	in.LastChecked.DeepCopyInto(&out.LastChecked)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommitVerification.
+func (in *CommitVerification) DeepCopy() *CommitVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(CommitVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComparePatch) DeepCopyInto(out *ComparePatch) {
 	*out = *in
@@ -1141,6 +1726,11 @@ func (in *Content) DeepCopyInto(out *Content) {
 		*out = make([]byte, len(*in))
 		copy(*out, *in)
 	}
+	if in.Chunks != nil {
+		in, out := &in.Chunks, &out.Chunks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -1195,6 +1785,41 @@ func (in *ContentList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentHistory) DeepCopyInto(out *DeploymentHistory) {
+	*out = *in
+	in.DeployedAt.DeepCopyInto(&out.DeployedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentHistory.
+func (in *DeploymentHistory) DeepCopy() *DeploymentHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentHistorySummary) DeepCopyInto(out *DeploymentHistorySummary) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+	in.Until.DeepCopyInto(&out.Until)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentHistorySummary.
+func (in *DeploymentHistorySummary) DeepCopy() *DeploymentHistorySummary {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentHistorySummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DiffOptions) DeepCopyInto(out *DiffOptions) {
 	*out = *in
@@ -1205,6 +1830,11 @@ func (in *DiffOptions) DeepCopyInto(out *DiffOptions) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Presets != nil {
+		in, out := &in.Presets, &out.Presets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -1218,38 +1848,48 @@ func (in *DiffOptions) DeepCopy() *DiffOptions {
 	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GenericMap.
-func (in *GenericMap) DeepCopy() *GenericMap {
-	if in == nil {
-		return nil
-	}
-	out := new(GenericMap)
-	in.DeepCopyInto(out)
-	return out
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GitRepo) DeepCopyInto(out *GitRepo) {
+func (in *FleetWorkspaceStatus) DeepCopyInto(out *FleetWorkspaceStatus) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.BundleStates != nil {
+		in, out := &in.BundleStates, &out.BundleStates
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Summary.DeepCopyInto(&out.Summary)
+	if in.StalledBundles != nil {
+		in, out := &in.StalledBundles, &out.StalledBundles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OfflineClusters != nil {
+		in, out := &in.OfflineClusters, &out.OfflineClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastActivity != nil {
+		in, out := &in.LastActivity, &out.LastActivity
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepo.
-func (in *GitRepo) DeepCopy() *GitRepo {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetWorkspaceStatus.
+func (in *FleetWorkspaceStatus) DeepCopy() *FleetWorkspaceStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(GitRepo)
+	out := new(FleetWorkspaceStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *GitRepo) DeepCopyObject() runtime.Object {
+func (in *FleetWorkspaceStatus) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1257,29 +1897,13 @@ func (in *GitRepo) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GitRepoDisplay) DeepCopyInto(out *GitRepoDisplay) {
-	*out = *in
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoDisplay.
-func (in *GitRepoDisplay) DeepCopy() *GitRepoDisplay {
-	if in == nil {
-		return nil
-	}
-	out := new(GitRepoDisplay)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GitRepoList) DeepCopyInto(out *GitRepoList) {
+func (in *FleetWorkspaceStatusList) DeepCopyInto(out *FleetWorkspaceStatusList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]GitRepo, len(*in))
+		*out = make([]FleetWorkspaceStatus, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1287,30 +1911,40 @@ func (in *GitRepoList) DeepCopyInto(out *GitRepoList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoList.
-func (in *GitRepoList) DeepCopy() *GitRepoList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetWorkspaceStatusList.
+func (in *FleetWorkspaceStatusList) DeepCopy() *FleetWorkspaceStatusList {
 	if in == nil {
 		return nil
 	}
-	out := new(GitRepoList)
+	out := new(FleetWorkspaceStatusList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *GitRepoList) DeepCopyObject() runtime.Object {
+func (in *FleetWorkspaceStatusList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
 	return nil
 }
 
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GenericMap.
+func (in *GenericMap) DeepCopy() *GenericMap {
+	if in == nil {
+		return nil
+	}
+	out := new(GenericMap)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GitRepoResource) DeepCopyInto(out *GitRepoResource) {
+func (in *GitBranch) DeepCopyInto(out *GitBranch) {
 	*out = *in
-	if in.PerClusterState != nil {
-		in, out := &in.PerClusterState, &out.PerClusterState
-		*out = make([]ResourcePerClusterState, len(*in))
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]GitTarget, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1318,72 +1952,54 @@ func (in *GitRepoResource) DeepCopyInto(out *GitRepoResource) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoResource.
-func (in *GitRepoResource) DeepCopy() *GitRepoResource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitBranch.
+func (in *GitBranch) DeepCopy() *GitBranch {
 	if in == nil {
 		return nil
 	}
-	out := new(GitRepoResource)
+	out := new(GitBranch)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GitRepoResourceCounts) DeepCopyInto(out *GitRepoResourceCounts) {
+func (in *GitBranchStatus) DeepCopyInto(out *GitBranchStatus) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoResourceCounts.
-func (in *GitRepoResourceCounts) DeepCopy() *GitRepoResourceCounts {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitBranchStatus.
+func (in *GitBranchStatus) DeepCopy() *GitBranchStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(GitRepoResourceCounts)
+	out := new(GitBranchStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GitRepoRestriction) DeepCopyInto(out *GitRepoRestriction) {
+func (in *GitRepo) DeepCopyInto(out *GitRepo) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	if in.AllowedServiceAccounts != nil {
-		in, out := &in.AllowedServiceAccounts, &out.AllowedServiceAccounts
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.AllowedRepoPatterns != nil {
-		in, out := &in.AllowedRepoPatterns, &out.AllowedRepoPatterns
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.AllowedClientSecretNames != nil {
-		in, out := &in.AllowedClientSecretNames, &out.AllowedClientSecretNames
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.AllowedTargetNamespaces != nil {
-		in, out := &in.AllowedTargetNamespaces, &out.AllowedTargetNamespaces
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoRestriction.
-func (in *GitRepoRestriction) DeepCopy() *GitRepoRestriction {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepo.
+func (in *GitRepo) DeepCopy() *GitRepo {
 	if in == nil {
 		return nil
 	}
-	out := new(GitRepoRestriction)
+	out := new(GitRepo)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *GitRepoRestriction) DeepCopyObject() runtime.Object {
+func (in *GitRepo) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1391,32 +2007,26 @@ func (in *GitRepoRestriction) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GitRepoRestrictionList) DeepCopyInto(out *GitRepoRestrictionList) {
+func (in *GitRepoDefaults) DeepCopyInto(out *GitRepoDefaults) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]GitRepoRestriction, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoRestrictionList.
-func (in *GitRepoRestrictionList) DeepCopy() *GitRepoRestrictionList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoDefaults.
+func (in *GitRepoDefaults) DeepCopy() *GitRepoDefaults {
 	if in == nil {
 		return nil
 	}
-	out := new(GitRepoRestrictionList)
+	out := new(GitRepoDefaults)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *GitRepoRestrictionList) DeepCopyObject() runtime.Object {
+func (in *GitRepoDefaults) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1424,221 +2034,213 @@ func (in *GitRepoRestrictionList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GitRepoSpec) DeepCopyInto(out *GitRepoSpec) {
+func (in *GitRepoDefaultsSpec) DeepCopyInto(out *GitRepoDefaultsSpec) {
 	*out = *in
 	if in.CABundle != nil {
 		in, out := &in.CABundle, &out.CABundle
 		*out = make([]byte, len(*in))
 		copy(*out, *in)
 	}
-	if in.Paths != nil {
-		in, out := &in.Paths, &out.Paths
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.Targets != nil {
-		in, out := &in.Targets, &out.Targets
-		*out = make([]GitTarget, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 	if in.PollingInterval != nil {
 		in, out := &in.PollingInterval, &out.PollingInterval
 		*out = new(v1.Duration)
 		**out = **in
 	}
-	if in.ImageSyncInterval != nil {
-		in, out := &in.ImageSyncInterval, &out.ImageSyncInterval
-		*out = new(v1.Duration)
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(ProxyConfig)
 		**out = **in
 	}
-	out.ImageScanCommit = in.ImageScanCommit
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoSpec.
-func (in *GitRepoSpec) DeepCopy() *GitRepoSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoDefaultsSpec.
+func (in *GitRepoDefaultsSpec) DeepCopy() *GitRepoDefaultsSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(GitRepoSpec)
+	out := new(GitRepoDefaultsSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GitRepoStatus) DeepCopyInto(out *GitRepoStatus) {
+func (in *GitRepoDefaultsList) DeepCopyInto(out *GitRepoDefaultsList) {
 	*out = *in
-	in.Summary.DeepCopyInto(&out.Summary)
-	out.Display = in.Display
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]genericcondition.GenericCondition, len(*in))
-		copy(*out, *in)
-	}
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = make([]GitRepoResource, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GitRepoDefaults, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	out.ResourceCounts = in.ResourceCounts
-	if in.ResourceErrors != nil {
-		in, out := &in.ResourceErrors, &out.ResourceErrors
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	in.LastSyncedImageScanTime.DeepCopyInto(&out.LastSyncedImageScanTime)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoStatus.
-func (in *GitRepoStatus) DeepCopy() *GitRepoStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoDefaultsList.
+func (in *GitRepoDefaultsList) DeepCopy() *GitRepoDefaultsList {
 	if in == nil {
 		return nil
 	}
-	out := new(GitRepoStatus)
+	out := new(GitRepoDefaultsList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitRepoDefaultsList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GitTarget) DeepCopyInto(out *GitTarget) {
+func (in *GitRepoDisplay) DeepCopyInto(out *GitRepoDisplay) {
 	*out = *in
-	if in.ClusterSelector != nil {
-		in, out := &in.ClusterSelector, &out.ClusterSelector
-		*out = new(v1.LabelSelector)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.ClusterGroupSelector != nil {
-		in, out := &in.ClusterGroupSelector, &out.ClusterGroupSelector
-		*out = new(v1.LabelSelector)
-		(*in).DeepCopyInto(*out)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitTarget.
-func (in *GitTarget) DeepCopy() *GitTarget {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoDisplay.
+func (in *GitRepoDisplay) DeepCopy() *GitRepoDisplay {
 	if in == nil {
 		return nil
 	}
-	out := new(GitTarget)
+	out := new(GitRepoDisplay)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HelmOptions) DeepCopyInto(out *HelmOptions) {
+func (in *GitRepoList) DeepCopyInto(out *GitRepoList) {
 	*out = *in
-	if in.Values != nil {
-		in, out := &in.Values, &out.Values
-		*out = (*in).DeepCopy()
-	}
-	if in.ValuesFrom != nil {
-		in, out := &in.ValuesFrom, &out.ValuesFrom
-		*out = make([]ValuesFrom, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GitRepo, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.ValuesFiles != nil {
-		in, out := &in.ValuesFiles, &out.ValuesFiles
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoList.
+func (in *GitRepoList) DeepCopy() *GitRepoList {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GitRepoList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoPathError) DeepCopyInto(out *GitRepoPathError) {
+	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmOptions.
-func (in *HelmOptions) DeepCopy() *HelmOptions {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoPathError.
+func (in *GitRepoPathError) DeepCopy() *GitRepoPathError {
 	if in == nil {
 		return nil
 	}
-	out := new(HelmOptions)
+	out := new(GitRepoPathError)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *IgnoreOptions) DeepCopyInto(out *IgnoreOptions) {
+func (in *GitRepoResource) DeepCopyInto(out *GitRepoResource) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]map[string]string, len(*in))
+	if in.PerClusterState != nil {
+		in, out := &in.PerClusterState, &out.PerClusterState
+		*out = make([]ResourcePerClusterState, len(*in))
 		for i := range *in {
-			if (*in)[i] != nil {
-				in, out := &(*in)[i], &(*out)[i]
-				*out = make(map[string]string, len(*in))
-				for key, val := range *in {
-					(*out)[key] = val
-				}
-			}
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnoreOptions.
-func (in *IgnoreOptions) DeepCopy() *IgnoreOptions {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoResource.
+func (in *GitRepoResource) DeepCopy() *GitRepoResource {
 	if in == nil {
 		return nil
 	}
-	out := new(IgnoreOptions)
+	out := new(GitRepoResource)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ImagePolicyChoice) DeepCopyInto(out *ImagePolicyChoice) {
+func (in *GitRepoResourceCounts) DeepCopyInto(out *GitRepoResourceCounts) {
 	*out = *in
-	if in.SemVer != nil {
-		in, out := &in.SemVer, &out.SemVer
-		*out = new(SemVerPolicy)
-		**out = **in
-	}
-	if in.Alphabetical != nil {
-		in, out := &in.Alphabetical, &out.Alphabetical
-		*out = new(AlphabeticalPolicy)
-		**out = **in
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePolicyChoice.
-func (in *ImagePolicyChoice) DeepCopy() *ImagePolicyChoice {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoResourceCounts.
+func (in *GitRepoResourceCounts) DeepCopy() *GitRepoResourceCounts {
 	if in == nil {
 		return nil
 	}
-	out := new(ImagePolicyChoice)
+	out := new(GitRepoResourceCounts)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ImageScan) DeepCopyInto(out *ImageScan) {
+func (in *GitRepoRestriction) DeepCopyInto(out *GitRepoRestriction) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.AllowedServiceAccounts != nil {
+		in, out := &in.AllowedServiceAccounts, &out.AllowedServiceAccounts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedRepoPatterns != nil {
+		in, out := &in.AllowedRepoPatterns, &out.AllowedRepoPatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedClientSecretNames != nil {
+		in, out := &in.AllowedClientSecretNames, &out.AllowedClientSecretNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedTargetNamespaces != nil {
+		in, out := &in.AllowedTargetNamespaces, &out.AllowedTargetNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageScan.
-func (in *ImageScan) DeepCopy() *ImageScan {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoRestriction.
+func (in *GitRepoRestriction) DeepCopy() *GitRepoRestriction {
 	if in == nil {
 		return nil
 	}
-	out := new(ImageScan)
+	out := new(GitRepoRestriction)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ImageScan) DeepCopyObject() runtime.Object {
+func (in *GitRepoRestriction) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1646,13 +2248,13 @@ func (in *ImageScan) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ImageScanList) DeepCopyInto(out *ImageScanList) {
+func (in *GitRepoRestrictionList) DeepCopyInto(out *GitRepoRestrictionList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ImageScan, len(*in))
+		*out = make([]GitRepoRestriction, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1660,18 +2262,18 @@ func (in *ImageScanList) DeepCopyInto(out *ImageScanList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageScanList.
-func (in *ImageScanList) DeepCopy() *ImageScanList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoRestrictionList.
+func (in *GitRepoRestrictionList) DeepCopy() *GitRepoRestrictionList {
 	if in == nil {
 		return nil
 	}
-	out := new(ImageScanList)
+	out := new(GitRepoRestrictionList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ImageScanList) DeepCopyObject() runtime.Object {
+func (in *GitRepoRestrictionList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1679,239 +2281,915 @@ func (in *ImageScanList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ImageScanSpec) DeepCopyInto(out *ImageScanSpec) {
+func (in *GitRepoSpec) DeepCopyInto(out *GitRepoSpec) {
 	*out = *in
-	out.Interval = in.Interval
-	if in.SecretRef != nil {
-		in, out := &in.SecretRef, &out.SecretRef
-		*out = new(corev1.LocalObjectReference)
+	if in.Branches != nil {
+		in, out := &in.Branches, &out.Branches
+		*out = make([]GitBranch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RevisionSelector != nil {
+		in, out := &in.RevisionSelector, &out.RevisionSelector
+		*out = new(RevisionSelector)
+		**out = **in
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]GitTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PollingInterval != nil {
+		in, out := &in.PollingInterval, &out.PollingInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ImageSyncInterval != nil {
+		in, out := &in.ImageSyncInterval, &out.ImageSyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	out.ImageScanCommit = in.ImageScanCommit
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(ProxyConfig)
+		**out = **in
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookRegistration)
+		**out = **in
+	}
+	if in.HelmValues != nil {
+		in, out := &in.HelmValues, &out.HelmValues
+		*out = (*in).DeepCopy()
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(GitRepoVerification)
+		**out = **in
+	}
+	if in.Submodules != nil {
+		in, out := &in.Submodules, &out.Submodules
+		*out = new(GitSubmodules)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoSpec.
+func (in *GitRepoSpec) DeepCopy() *GitRepoSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoStatus) DeepCopyInto(out *GitRepoStatus) {
+	*out = *in
+	in.Summary.DeepCopyInto(&out.Summary)
+	out.Display = in.Display
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]genericcondition.GenericCondition, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]GitRepoResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.ResourceCounts = in.ResourceCounts
+	if in.ResourceErrors != nil {
+		in, out := &in.ResourceErrors, &out.ResourceErrors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.LastSyncedImageScanTime.DeepCopyInto(&out.LastSyncedImageScanTime)
+	if in.Teardown != nil {
+		in, out := &in.Teardown, &out.Teardown
+		*out = new(GitRepoTeardown)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PathErrors != nil {
+		in, out := &in.PathErrors, &out.PathErrors
+		*out = make([]GitRepoPathError, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResolvedRevision != nil {
+		in, out := &in.ResolvedRevision, &out.ResolvedRevision
+		*out = new(ResolvedRevision)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Branches != nil {
+		in, out := &in.Branches, &out.Branches
+		*out = make([]GitBranchStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(CommitVerification)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoStatus.
+func (in *GitRepoStatus) DeepCopy() *GitRepoStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoTeardown) DeepCopyInto(out *GitRepoTeardown) {
+	*out = *in
+	if in.RemainingBundles != nil {
+		in, out := &in.RemainingBundles, &out.RemainingBundles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoTeardown.
+func (in *GitRepoTeardown) DeepCopy() *GitRepoTeardown {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoTeardown)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoVerification) DeepCopyInto(out *GitRepoVerification) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoVerification.
+func (in *GitRepoVerification) DeepCopy() *GitRepoVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSubmodules) DeepCopyInto(out *GitSubmodules) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = make([]SubmoduleCredential, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSubmodules.
+func (in *GitSubmodules) DeepCopy() *GitSubmodules {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSubmodules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitTarget) DeepCopyInto(out *GitTarget) {
+	*out = *in
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterGroupSelector != nil {
+		in, out := &in.ClusterGroupSelector, &out.ClusterGroupSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExcludeClusterSelector != nil {
+		in, out := &in.ExcludeClusterSelector, &out.ExcludeClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitTarget.
+func (in *GitTarget) DeepCopy() *GitTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(GitTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HelmOptions) DeepCopyInto(out *HelmOptions) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = (*in).DeepCopy()
+	}
+	if in.ValuesFrom != nil {
+		in, out := &in.ValuesFrom, &out.ValuesFrom
+		*out = make([]ValuesFrom, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ValuesFiles != nil {
+		in, out := &in.ValuesFiles, &out.ValuesFiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmOptions.
+func (in *HelmOptions) DeepCopy() *HelmOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(HelmOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityOptions) DeepCopyInto(out *IdentityOptions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityOptions.
+func (in *IdentityOptions) DeepCopy() *IdentityOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnoreOptions) DeepCopyInto(out *IgnoreOptions) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnoreOptions.
+func (in *IgnoreOptions) DeepCopy() *IgnoreOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnoreOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImagePolicyChoice) DeepCopyInto(out *ImagePolicyChoice) {
+	*out = *in
+	if in.SemVer != nil {
+		in, out := &in.SemVer, &out.SemVer
+		*out = new(SemVerPolicy)
+		**out = **in
+	}
+	if in.Alphabetical != nil {
+		in, out := &in.Alphabetical, &out.Alphabetical
+		*out = new(AlphabeticalPolicy)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePolicyChoice.
+func (in *ImagePolicyChoice) DeepCopy() *ImagePolicyChoice {
+	if in == nil {
+		return nil
+	}
+	out := new(ImagePolicyChoice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageScan) DeepCopyInto(out *ImageScan) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageScan.
+func (in *ImageScan) DeepCopy() *ImageScan {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageScan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ImageScan) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageScanList) DeepCopyInto(out *ImageScanList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ImageScan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageScanList.
+func (in *ImageScanList) DeepCopy() *ImageScanList {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageScanList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ImageScanList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageScanSpec) DeepCopyInto(out *ImageScanSpec) {
+	*out = *in
+	out.Interval = in.Interval
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	in.Policy.DeepCopyInto(&out.Policy)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageScanSpec.
+func (in *ImageScanSpec) DeepCopy() *ImageScanSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageScanSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageScanStatus) DeepCopyInto(out *ImageScanStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]genericcondition.GenericCondition, len(*in))
+		copy(*out, *in)
+	}
+	in.LastScanTime.DeepCopyInto(&out.LastScanTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageScanStatus.
+func (in *ImageScanStatus) DeepCopy() *ImageScanStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageScanStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KustomizeOptions) DeepCopyInto(out *KustomizeOptions) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KustomizeOptions.
+func (in *KustomizeOptions) DeepCopy() *KustomizeOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(KustomizeOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalObjectReference) DeepCopyInto(out *LocalObjectReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalObjectReference.
+func (in *LocalObjectReference) DeepCopy() *LocalObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModifiedStatus) DeepCopyInto(out *ModifiedStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModifiedStatus.
+func (in *ModifiedStatus) DeepCopy() *ModifiedStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ModifiedStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NonReadyResource) DeepCopyInto(out *NonReadyResource) {
+	*out = *in
+	if in.ModifiedStatus != nil {
+		in, out := &in.ModifiedStatus, &out.ModifiedStatus
+		*out = make([]ModifiedStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.NonReadyStatus != nil {
+		in, out := &in.NonReadyStatus, &out.NonReadyStatus
+		*out = make([]NonReadyStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NonReadyResource.
+func (in *NonReadyResource) DeepCopy() *NonReadyResource {
+	if in == nil {
+		return nil
+	}
+	out := new(NonReadyResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NonReadyStatus) DeepCopyInto(out *NonReadyStatus) {
+	*out = *in
+	in.Summary.DeepCopyInto(&out.Summary)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NonReadyStatus.
+func (in *NonReadyStatus) DeepCopy() *NonReadyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NonReadyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Operation) DeepCopyInto(out *Operation) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Operation.
+func (in *Operation) DeepCopy() *Operation {
+	if in == nil {
+		return nil
+	}
+	out := new(Operation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OptionalResource) DeepCopyInto(out *OptionalResource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OptionalResource.
+func (in *OptionalResource) DeepCopy() *OptionalResource {
+	if in == nil {
+		return nil
+	}
+	out := new(OptionalResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Partition) DeepCopyInto(out *Partition) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterGroupSelector != nil {
+		in, out := &in.ClusterGroupSelector, &out.ClusterGroupSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Partition.
+func (in *Partition) DeepCopy() *Partition {
+	if in == nil {
+		return nil
+	}
+	out := new(Partition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PartitionStatus) DeepCopyInto(out *PartitionStatus) {
+	*out = *in
+	in.Summary.DeepCopyInto(&out.Summary)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PartitionStatus.
+func (in *PartitionStatus) DeepCopy() *PartitionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PartitionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PendingPrune) DeepCopyInto(out *PendingPrune) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceKey, len(*in))
+		copy(*out, *in)
+	}
+	in.Since.DeepCopyInto(&out.Since)
+	in.Until.DeepCopyInto(&out.Until)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PendingPrune.
+func (in *PendingPrune) DeepCopy() *PendingPrune {
+	if in == nil {
+		return nil
+	}
+	out := new(PendingPrune)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PinnedStatus) DeepCopyInto(out *PinnedStatus) {
+	*out = *in
+	in.Until.DeepCopyInto(&out.Until)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PinnedStatus.
+func (in *PinnedStatus) DeepCopy() *PinnedStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PinnedStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodDisruptionBudgetOptions) DeepCopyInto(out *PodDisruptionBudgetOptions) {
+	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
 		**out = **in
 	}
-	in.Policy.DeepCopyInto(&out.Policy)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageScanSpec.
-func (in *ImageScanSpec) DeepCopy() *ImageScanSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodDisruptionBudgetOptions.
+func (in *PodDisruptionBudgetOptions) DeepCopy() *PodDisruptionBudgetOptions {
 	if in == nil {
 		return nil
 	}
-	out := new(ImageScanSpec)
+	out := new(PodDisruptionBudgetOptions)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ImageScanStatus) DeepCopyInto(out *ImageScanStatus) {
+func (in *PropagationOptions) DeepCopyInto(out *PropagationOptions) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]genericcondition.GenericCondition, len(*in))
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceLabels != nil {
+		in, out := &in.ResourceLabels, &out.ResourceLabels
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	in.LastScanTime.DeepCopyInto(&out.LastScanTime)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageScanStatus.
-func (in *ImageScanStatus) DeepCopy() *ImageScanStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropagationOptions.
+func (in *PropagationOptions) DeepCopy() *PropagationOptions {
 	if in == nil {
 		return nil
 	}
-	out := new(ImageScanStatus)
+	out := new(PropagationOptions)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KustomizeOptions) DeepCopyInto(out *KustomizeOptions) {
+func (in *ProxyConfig) DeepCopyInto(out *ProxyConfig) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KustomizeOptions.
-func (in *KustomizeOptions) DeepCopy() *KustomizeOptions {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyConfig.
+func (in *ProxyConfig) DeepCopy() *ProxyConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(KustomizeOptions)
+	out := new(ProxyConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LocalObjectReference) DeepCopyInto(out *LocalObjectReference) {
+func (in *RemovedAPI) DeepCopyInto(out *RemovedAPI) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalObjectReference.
-func (in *LocalObjectReference) DeepCopy() *LocalObjectReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemovedAPI.
+func (in *RemovedAPI) DeepCopy() *RemovedAPI {
 	if in == nil {
 		return nil
 	}
-	out := new(LocalObjectReference)
+	out := new(RemovedAPI)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ModifiedStatus) DeepCopyInto(out *ModifiedStatus) {
+func (in *ReplaceRetry) DeepCopyInto(out *ReplaceRetry) {
 	*out = *in
+	in.After.DeepCopyInto(&out.After)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModifiedStatus.
-func (in *ModifiedStatus) DeepCopy() *ModifiedStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplaceRetry.
+func (in *ReplaceRetry) DeepCopy() *ReplaceRetry {
 	if in == nil {
 		return nil
 	}
-	out := new(ModifiedStatus)
+	out := new(ReplaceRetry)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NonReadyResource) DeepCopyInto(out *NonReadyResource) {
+func (in *ResolvedRevision) DeepCopyInto(out *ResolvedRevision) {
 	*out = *in
-	if in.ModifiedStatus != nil {
-		in, out := &in.ModifiedStatus, &out.ModifiedStatus
-		*out = make([]ModifiedStatus, len(*in))
-		copy(*out, *in)
-	}
-	if in.NonReadyStatus != nil {
-		in, out := &in.NonReadyStatus, &out.NonReadyStatus
-		*out = make([]NonReadyStatus, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.LastChecked.DeepCopyInto(&out.LastChecked)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NonReadyResource.
-func (in *NonReadyResource) DeepCopy() *NonReadyResource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResolvedRevision.
+func (in *ResolvedRevision) DeepCopy() *ResolvedRevision {
 	if in == nil {
 		return nil
 	}
-	out := new(NonReadyResource)
+	out := new(ResolvedRevision)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NonReadyStatus) DeepCopyInto(out *NonReadyStatus) {
+func (in *ResourceKey) DeepCopyInto(out *ResourceKey) {
 	*out = *in
-	in.Summary.DeepCopyInto(&out.Summary)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NonReadyStatus.
-func (in *NonReadyStatus) DeepCopy() *NonReadyStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceKey.
+func (in *ResourceKey) DeepCopy() *ResourceKey {
 	if in == nil {
 		return nil
 	}
-	out := new(NonReadyStatus)
+	out := new(ResourceKey)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Operation) DeepCopyInto(out *Operation) {
+func (in *ResourcePerClusterState) DeepCopyInto(out *ResourcePerClusterState) {
 	*out = *in
+	if in.Patch != nil {
+		in, out := &in.Patch, &out.Patch
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Operation.
-func (in *Operation) DeepCopy() *Operation {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourcePerClusterState.
+func (in *ResourcePerClusterState) DeepCopy() *ResourcePerClusterState {
 	if in == nil {
 		return nil
 	}
-	out := new(Operation)
+	out := new(ResourcePerClusterState)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Partition) DeepCopyInto(out *Partition) {
+func (in *RevisionSelector) DeepCopyInto(out *RevisionSelector) {
 	*out = *in
-	if in.MaxUnavailable != nil {
-		in, out := &in.MaxUnavailable, &out.MaxUnavailable
-		*out = new(intstr.IntOrString)
-		**out = **in
-	}
-	if in.ClusterSelector != nil {
-		in, out := &in.ClusterSelector, &out.ClusterSelector
-		*out = new(v1.LabelSelector)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.ClusterGroupSelector != nil {
-		in, out := &in.ClusterGroupSelector, &out.ClusterGroupSelector
-		*out = new(v1.LabelSelector)
-		(*in).DeepCopyInto(*out)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Partition.
-func (in *Partition) DeepCopy() *Partition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevisionSelector.
+func (in *RevisionSelector) DeepCopy() *RevisionSelector {
 	if in == nil {
 		return nil
 	}
-	out := new(Partition)
+	out := new(RevisionSelector)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PartitionStatus) DeepCopyInto(out *PartitionStatus) {
+func (in *RolloutStep) DeepCopyInto(out *RolloutStep) {
 	*out = *in
-	in.Summary.DeepCopyInto(&out.Summary)
+	if in.Pause != nil {
+		in, out := &in.Pause, &out.Pause
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PartitionStatus.
-func (in *PartitionStatus) DeepCopy() *PartitionStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStep.
+func (in *RolloutStep) DeepCopy() *RolloutStep {
 	if in == nil {
 		return nil
 	}
-	out := new(PartitionStatus)
+	out := new(RolloutStep)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ResourceKey) DeepCopyInto(out *ResourceKey) {
+func (in *RolloutStepStatus) DeepCopyInto(out *RolloutStepStatus) {
 	*out = *in
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceKey.
-func (in *ResourceKey) DeepCopy() *ResourceKey {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStepStatus.
+func (in *RolloutStepStatus) DeepCopy() *RolloutStepStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ResourceKey)
+	out := new(RolloutStepStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ResourcePerClusterState) DeepCopyInto(out *ResourcePerClusterState) {
+func (in *RolloutStepsStatus) DeepCopyInto(out *RolloutStepsStatus) {
 	*out = *in
-	if in.Patch != nil {
-		in, out := &in.Patch, &out.Patch
-		*out = (*in).DeepCopy()
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]RolloutStepStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourcePerClusterState.
-func (in *ResourcePerClusterState) DeepCopy() *ResourcePerClusterState {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStepsStatus.
+func (in *RolloutStepsStatus) DeepCopy() *RolloutStepsStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ResourcePerClusterState)
+	out := new(RolloutStepsStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1941,6 +3219,18 @@ func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AutoRollback != nil {
+		in, out := &in.AutoRollback, &out.AutoRollback
+		*out = new(AutoRollback)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]RolloutStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -1954,6 +3244,22 @@ func (in *RolloutStrategy) DeepCopy() *RolloutStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunOnceStatus) DeepCopyInto(out *RunOnceStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunOnceStatus.
+func (in *RunOnceStatus) DeepCopy() *RunOnceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RunOnceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretKeySelector) DeepCopyInto(out *SecretKeySelector) {
 	*out = *in
@@ -1987,6 +3293,106 @@ func (in *SemVerPolicy) DeepCopy() *SemVerPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatusRetention) DeepCopyInto(out *StatusRetention) {
+	*out = *in
+	if in.HistoryMaxAge != nil {
+		in, out := &in.HistoryMaxAge, &out.HistoryMaxAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusRetention.
+func (in *StatusRetention) DeepCopy() *StatusRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(StatusRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubmoduleCredential) DeepCopyInto(out *SubmoduleCredential) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubmoduleCredential.
+func (in *SubmoduleCredential) DeepCopy() *SubmoduleCredential {
+	if in == nil {
+		return nil
+	}
+	out := new(SubmoduleCredential)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SummaryError) DeepCopyInto(out *SummaryError) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SummaryError.
+func (in *SummaryError) DeepCopy() *SummaryError {
+	if in == nil {
+		return nil
+	}
+	out := new(SummaryError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SystemOptions) DeepCopyInto(out *SystemOptions) {
+	*out = *in
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(PodDisruptionBudgetOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SystemOptions.
+func (in *SystemOptions) DeepCopy() *SystemOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(SystemOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetMissingAPIs) DeepCopyInto(out *TargetMissingAPIs) {
+	*out = *in
+	if in.APIs != nil {
+		in, out := &in.APIs, &out.APIs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Removed != nil {
+		in, out := &in.Removed, &out.Removed
+		*out = make([]RemovedAPI, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetMissingAPIs.
+func (in *TargetMissingAPIs) DeepCopy() *TargetMissingAPIs {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetMissingAPIs)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValuesFrom) DeepCopyInto(out *ValuesFrom) {
 	*out = *in
@@ -2013,6 +3419,72 @@ func (in *ValuesFrom) DeepCopy() *ValuesFrom {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookRegistration) DeepCopyInto(out *WebhookRegistration) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookRegistration.
+func (in *WebhookRegistration) DeepCopy() *WebhookRegistration {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookRegistration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookRejection) DeepCopyInto(out *WebhookRejection) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookRejection.
+func (in *WebhookRejection) DeepCopy() *WebhookRejection {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookRejection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookRetry) DeepCopyInto(out *WebhookRetry) {
+	*out = *in
+	in.After.DeepCopyInto(&out.After)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookRetry.
+func (in *WebhookRetry) DeepCopy() *WebhookRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookStatus) DeepCopyInto(out *WebhookStatus) {
+	*out = *in
+	in.LastChecked.DeepCopyInto(&out.LastChecked)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookStatus.
+func (in *WebhookStatus) DeepCopy() *WebhookStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *YAMLOptions) DeepCopyInto(out *YAMLOptions) {
 	*out = *in
@@ -2021,6 +3493,10 @@ func (in *YAMLOptions) DeepCopyInto(out *YAMLOptions) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TemplateContext != nil {
+		in, out := &in.TemplateContext, &out.TemplateContext
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
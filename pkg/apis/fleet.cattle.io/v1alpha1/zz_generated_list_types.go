@@ -77,6 +77,23 @@ func NewBundleNamespaceMapping(namespace, name string, obj BundleNamespaceMappin
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// BundleRevisionList is a list of BundleRevision resources
+type BundleRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []BundleRevision `json:"items"`
+}
+
+func NewBundleRevision(namespace, name string, obj BundleRevision) *BundleRevision {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("BundleRevision").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // ClusterList is a list of Cluster resources
 type ClusterList struct {
 	metav1.TypeMeta `json:",inline"`
@@ -111,6 +128,23 @@ func NewClusterGroup(namespace, name string, obj ClusterGroup) *ClusterGroup {
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// ClusterImportList is a list of ClusterImport resources
+type ClusterImportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ClusterImport `json:"items"`
+}
+
+func NewClusterImport(namespace, name string, obj ClusterImport) *ClusterImport {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("ClusterImport").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // ClusterRegistrationList is a list of ClusterRegistration resources
 type ClusterRegistrationList struct {
 	metav1.TypeMeta `json:",inline"`
@@ -162,6 +196,23 @@ func NewContent(namespace, name string, obj Content) *Content {
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// FleetWorkspaceStatusList is a list of FleetWorkspaceStatus resources
+type FleetWorkspaceStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []FleetWorkspaceStatus `json:"items"`
+}
+
+func NewFleetWorkspaceStatus(namespace, name string, obj FleetWorkspaceStatus) *FleetWorkspaceStatus {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("FleetWorkspaceStatus").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // GitRepoList is a list of GitRepo resources
 type GitRepoList struct {
 	metav1.TypeMeta `json:",inline"`
@@ -179,6 +230,23 @@ func NewGitRepo(namespace, name string, obj GitRepo) *GitRepo {
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// GitRepoDefaultsList is a list of GitRepoDefaults resources
+type GitRepoDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []GitRepoDefaults `json:"items"`
+}
+
+func NewGitRepoDefaults(namespace, name string, obj GitRepoDefaults) *GitRepoDefaults {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("GitRepoDefaults").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // GitRepoRestrictionList is a list of GitRepoRestriction resources
 type GitRepoRestrictionList struct {
 	metav1.TypeMeta `json:",inline"`
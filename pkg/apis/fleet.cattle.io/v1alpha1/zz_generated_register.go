@@ -31,12 +31,16 @@ var (
 	BundleResourceName                   = "bundles"
 	BundleDeploymentResourceName         = "bundledeployments"
 	BundleNamespaceMappingResourceName   = "bundlenamespacemappings"
+	BundleRevisionResourceName           = "bundlerevisions"
 	ClusterResourceName                  = "clusters"
 	ClusterGroupResourceName             = "clustergroups"
+	ClusterImportResourceName            = "clusterimports"
 	ClusterRegistrationResourceName      = "clusterregistrations"
 	ClusterRegistrationTokenResourceName = "clusterregistrationtokens"
 	ContentResourceName                  = "contents"
+	FleetWorkspaceStatusResourceName     = "fleetworkspacestatuses"
 	GitRepoResourceName                  = "gitrepos"
+	GitRepoDefaultsResourceName          = "gitrepodefaults"
 	GitRepoRestrictionResourceName       = "gitreporestrictions"
 	ImageScanResourceName                = "imagescans"
 )
@@ -68,18 +72,26 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&BundleDeploymentList{},
 		&BundleNamespaceMapping{},
 		&BundleNamespaceMappingList{},
+		&BundleRevision{},
+		&BundleRevisionList{},
 		&Cluster{},
 		&ClusterList{},
 		&ClusterGroup{},
 		&ClusterGroupList{},
+		&ClusterImport{},
+		&ClusterImportList{},
 		&ClusterRegistration{},
 		&ClusterRegistrationList{},
 		&ClusterRegistrationToken{},
 		&ClusterRegistrationTokenList{},
 		&Content{},
 		&ContentList{},
+		&FleetWorkspaceStatus{},
+		&FleetWorkspaceStatusList{},
 		&GitRepo{},
 		&GitRepoList{},
+		&GitRepoDefaults{},
+		&GitRepoDefaultsList{},
 		&GitRepoRestriction{},
 		&GitRepoRestrictionList{},
 		&ImageScan{},
@@ -1,8 +1,18 @@
 package bundlematcher
 
 import (
+	"hash/fnv"
+	"sort"
+	"strings"
+
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/match"
+	"github.com/rancher/fleet/pkg/options"
+
+	"github.com/sirupsen/logrus"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 // BundleMatch stores the bundle and the matcher for the bundle
@@ -35,31 +45,201 @@ func (a *BundleMatch) MatchForTarget(name string) *fleet.BundleTarget {
 // described in the fleet.yaml will be ignored.
 // All GitRepo targets are added as TargetRestrictions, which acts as a whitelist.
 func (a *BundleMatch) Match(clusterName string, clusterGroups map[string]map[string]string, clusterLabels map[string]string) *fleet.BundleTarget {
-	if m := a.matcher.match(clusterName, clusterLabels, clusterGroups, a.matcher.criteriaWithRestrictions); m != nil {
+	return a.MatchCluster(clusterOf(clusterName, clusterLabels), clusterGroups)
+}
+
+// MatchCluster is like Match, but also evaluates the targets' cluster
+// selector expressions against the cluster.
+func (a *BundleMatch) MatchCluster(cluster *fleet.Cluster, clusterGroups map[string]map[string]string) *fleet.BundleTarget {
+	if m := a.matcher.match(cluster, clusterGroups, a.matcher.criteriaWithRestrictions(clusterGroups, cluster.Labels)); m != nil {
 		return m
 	}
 
 	return nil
 }
 
-// MatchTargetCustomizations returns the first BundleTarget that matches the target criteria. Targets are evaluated in order.
+// MatchTargetCustomizations returns the BundleTarget that matches the target criteria. Targets are evaluated by priority,
+// then in order, and combined according to the bundle's merge strategy.
 // It doesn't check for restrictions, which means TargetCustomizations described in the fleet.yaml are considered.
-func (a *BundleMatch) MatchTargetCustomizations(clusterName string, clusterGroups map[string]map[string]string, clusterLabels map[string]string) *fleet.BundleTarget {
-	if m := a.matcher.match(clusterName, clusterLabels, clusterGroups, criteriaWithoutRestrictions); m != nil {
-		return m
+// Targets with a node architecture only match clusters, whose nodes have that architecture.
+func (a *BundleMatch) MatchTargetCustomizations(clusterName string, clusterGroups map[string]map[string]string, clusterLabels map[string]string, nodeArchitectures []string) *fleet.BundleTarget {
+	cluster := clusterOf(clusterName, clusterLabels)
+	cluster.Status.Agent.NodeArchitectures = nodeArchitectures
+	return a.MatchClusterTargetCustomizations(cluster, clusterGroups)
+}
+
+// MatchClusterTargetCustomizations is like MatchTargetCustomizations, but
+// also evaluates the targets' cluster selector expressions against the
+// cluster.
+func (a *BundleMatch) MatchClusterTargetCustomizations(cluster *fleet.Cluster, clusterGroups map[string]map[string]string) *fleet.BundleTarget {
+	return combine(a.bundle.Spec.MergeStrategy, a.matcher.matchAll(cluster, clusterGroups, criteriaWithArchitectures(cluster)))
+}
+
+// Samples returns true, if targets of the bundle select a percentage of
+// their clusters. Whether such a target matches a cluster depends on the
+// other clusters.
+func (a *BundleMatch) Samples() bool {
+	for _, t := range a.matcher.matches {
+		if sampled(t.bundleTarget.Percentage) {
+			return true
+		}
 	}
+	return false
+}
 
-	return nil
+// Sample selects the clusters of the targets with a percentage. Of the n
+// clusters matching such a target, the ceil(n*percentage/100) clusters with
+// the lowest hash of their namespace, name and the target's name are
+// selected. This makes the number of clusters exact and keeps the selection
+// stable across reconciles. The clusters are all clusters the bundle could
+// target, clusterGroups holds the labels of each cluster's groups. Sample
+// has to be called before matching clusters, otherwise each cluster is
+// sampled on its own by its hash, which only approximates the percentage.
+func (a *BundleMatch) Sample(clusters []*fleet.Cluster, clusterGroups []map[string]map[string]string) {
+	for i := range a.matcher.matches {
+		t := &a.matcher.matches[i]
+		if !sampled(t.bundleTarget.Percentage) {
+			continue
+		}
+
+		var candidates []*fleet.Cluster
+		for j, cluster := range clusters {
+			if t.matchesTarget(cluster, clusterGroups[j], criteriaWithArchitectures(cluster)) {
+				candidates = append(candidates, cluster)
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			hi, hj := t.sampleHash(candidates[i]), t.sampleHash(candidates[j])
+			if hi != hj {
+				return hi < hj
+			}
+			return candidates[i].Namespace+"/"+candidates[i].Name < candidates[j].Namespace+"/"+candidates[j].Name
+		})
+
+		n := (len(candidates)*t.bundleTarget.Percentage + 99) / 100
+		t.sampled = map[string]bool{}
+		for _, cluster := range candidates[:n] {
+			t.sampled[cluster.Namespace+"/"+cluster.Name] = true
+		}
+	}
+}
+
+// criteriaWithArchitectures returns a findCriteriaMatch for target
+// customizations, which also checks the node architecture of the target
+// against the cluster's nodes.
+func criteriaWithArchitectures(cluster *fleet.Cluster) findCriteriaMatch {
+	archs := sets.NewString(cluster.Status.Agent.NodeArchitectures...)
+	return func(targetMatch targetMatch, clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
+		if arch := targetMatch.bundleTarget.NodeArchitecture; arch != "" {
+			if !archs.Has(arch) {
+				return false
+			}
+			if targetMatch.architectureOnly {
+				return true
+			}
+		}
+		return criteriaWithoutRestrictions(targetMatch, clusterName, clusterGroup, clusterGroupLabels, clusterLabels)
+	}
+}
+
+// combine returns the target of the matching targets, which the merge
+// strategy selects, or a target merged from all of them.
+func combine(strategy string, targets []*fleet.BundleTarget) *fleet.BundleTarget {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	switch strategy {
+	case fleet.MergeStrategyMergeAll:
+		return mergeAll(targets)
+	case fleet.MergeStrategyLastWins:
+		sort.SliceStable(targets, func(i, j int) bool {
+			return targets[i].Priority < targets[j].Priority
+		})
+		return targets[len(targets)-1]
+	default:
+		sort.SliceStable(targets, func(i, j int) bool {
+			return targets[i].Priority > targets[j].Priority
+		})
+		return targets[0]
+	}
+}
+
+// mergeAll merges the targets from the lowest to the highest priority, so
+// the options of higher priorities and later targets override the others.
+// The merged target is not deployed if any of the targets isn't.
+func mergeAll(targets []*fleet.BundleTarget) *fleet.BundleTarget {
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].Priority < targets[j].Priority
+	})
+
+	result := &fleet.BundleTarget{}
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Name)
+		result.BundleDeploymentOptions = options.Merge(result.BundleDeploymentOptions, target.BundleDeploymentOptions)
+		result.DoNotDeploy = result.DoNotDeploy || target.DoNotDeploy
+		result.ClusterReady = result.ClusterReady || target.ClusterReady
+		if target.PropagationDelay != nil {
+			result.PropagationDelay = target.PropagationDelay
+		}
+		if len(target.MaintenanceWindows) > 0 {
+			result.MaintenanceWindows = target.MaintenanceWindows
+		}
+		result.ValuesFrom = append(result.ValuesFrom, target.ValuesFrom...)
+		result.Priority = target.Priority
+	}
+	result.Name = strings.Join(names, ",")
+
+	return result
+}
+
+func clusterOf(name string, labels map[string]string) *fleet.Cluster {
+	return &fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
 }
 
 type targetMatch struct {
 	bundleTarget *fleet.BundleTarget
 	criteria     *match.ClusterMatcher
+	expression   *match.Expression
+	exclusion    *match.Exclusion
+	// architectureOnly is true for targets, which only select clusters by
+	// node architecture
+	architectureOnly bool
+	// expressionOnly is true for targets, which only select clusters by
+	// a cluster selector expression
+	expressionOnly bool
+	// sampled are the namespace/name of the clusters, which are selected
+	// by the target's percentage. It's nil unless the clusters were sampled.
+	sampled map[string]bool
+}
+
+// matchesCriteria returns true, if the cluster matches the target's
+// selectors, or if the target only has an expression
+func (t targetMatch) matchesCriteria(clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
+	return t.expressionOnly || t.criteria.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels)
+}
+
+func (t targetMatch) matchesExpression(cluster *fleet.Cluster) bool {
+	if t.expression == nil {
+		return true
+	}
+	ok, err := t.expression.Match(cluster)
+	if err != nil {
+		logrus.Debugf("cluster selector expression of target %q failed for cluster %s/%s: %v", t.bundleTarget.Name, cluster.Namespace, cluster.Name, err)
+		return false
+	}
+	return ok
 }
 
 type matcher struct {
 	matches      []targetMatch
-	restrictions []*match.ClusterMatcher
+	restrictions []restriction
+}
+
+type restriction struct {
+	criteria  *match.ClusterMatcher
+	exclusion *match.Exclusion
 }
 
 func (a *BundleMatch) initMatcher() error {
@@ -72,9 +252,23 @@ func (a *BundleMatch) initMatcher() error {
 		if err != nil {
 			return err
 		}
+		selectorless := target.ClusterName == "" && target.ClusterGroup == "" &&
+			target.ClusterGroupSelector == nil && target.ClusterSelector == nil
 		t := targetMatch{
-			bundleTarget: &a.bundle.Spec.Targets[i],
-			criteria:     clusterMatcher,
+			bundleTarget:     &a.bundle.Spec.Targets[i],
+			criteria:         clusterMatcher,
+			architectureOnly: target.NodeArchitecture != "" && selectorless,
+		}
+		t.exclusion, err = match.NewExclusion(target.ExcludeClusterGroup, target.ExcludeClusterSelector)
+		if err != nil {
+			return err
+		}
+		if target.ClusterSelectorExpression != "" {
+			t.expression, err = match.NewExpression(target.ClusterSelectorExpression)
+			if err != nil {
+				return err
+			}
+			t.expressionOnly = selectorless
 		}
 
 		m.matches = append(m.matches, t)
@@ -85,21 +279,21 @@ func (a *BundleMatch) initMatcher() error {
 		if err != nil {
 			return err
 		}
-		m.restrictions = append(m.restrictions, clusterMatcher)
+		exclusion, err := match.NewExclusion(target.ExcludeClusterGroup, target.ExcludeClusterSelector)
+		if err != nil {
+			return err
+		}
+		m.restrictions = append(m.restrictions, restriction{criteria: clusterMatcher, exclusion: exclusion})
 	}
 
 	a.matcher = m
 	return nil
 }
 
-func (m *matcher) isRestricted(clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
-	// There are no restrictions. That means this Bundle was not created by a GitRepo, and there are no targetCustomizations
-	if len(m.restrictions) == 0 {
-		return false
-	}
-
-	for _, restriction := range m.restrictions {
-		if restriction.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
+// isRestricted checks the restrictions, which don't exclude the cluster.
+func isRestricted(restrictions []restriction, clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
+	for _, restriction := range restrictions {
+		if restriction.criteria.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
 			return false
 		}
 	}
@@ -108,37 +302,103 @@ func (m *matcher) isRestricted(clusterName, clusterGroup string, clusterGroupLab
 	return true
 }
 
-// checks if criteria is matched just if the target is inside the targetRestrictions. This is used for Targets defined
-// in the GitRepo, since these targets are also added as targetRestrictions.
-func (m *matcher) criteriaWithRestrictions(targetMatch targetMatch, clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
-	if !m.isRestricted(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) &&
-		targetMatch.criteria.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
-		return true
+// criteriaWithRestrictions returns a findCriteriaMatch, which checks if criteria is matched just if the target is inside
+// the targetRestrictions. This is used for Targets defined in the GitRepo, since these targets are also added as
+// targetRestrictions. Restrictions, which exclude the cluster, are ignored.
+func (m *matcher) criteriaWithRestrictions(clusterGroups map[string]map[string]string, clusterLabels map[string]string) findCriteriaMatch {
+	// There are no restrictions. That means this Bundle was not created by a GitRepo, and there are no targetCustomizations
+	if len(m.restrictions) == 0 {
+		return criteriaWithoutRestrictions
 	}
 
-	return false
+	var restrictions []restriction
+	for _, r := range m.restrictions {
+		if !r.exclusion.Excludes(clusterGroups, clusterLabels) {
+			restrictions = append(restrictions, r)
+		}
+	}
+
+	return func(targetMatch targetMatch, clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
+		return !isRestricted(restrictions, clusterName, clusterGroup, clusterGroupLabels, clusterLabels) &&
+			targetMatch.matchesCriteria(clusterName, clusterGroup, clusterGroupLabels, clusterLabels)
+	}
 }
 
 // Checks targetMatch's criteria for a match on the specified cluster name, group and labels, without checking if target is inside the targetRestrictions. This is used for TargetCustomizations.
 func criteriaWithoutRestrictions(targetMatch targetMatch, clusterName, clusterGroup string, clusterGroupLabels, clusterLabels map[string]string) bool {
-	return targetMatch.criteria.Match(clusterName, clusterGroup, clusterGroupLabels, clusterLabels)
+	return targetMatch.matchesCriteria(clusterName, clusterGroup, clusterGroupLabels, clusterLabels)
 }
 
-// match returns the first BundleTarget, from the matcher's target matches, which matches the specified cluster name, groups and labels, using matching logic implemented via findCriteriaMatch.
-func (m *matcher) match(clusterName string, clusterLabels map[string]string, clusterGroups map[string]map[string]string, findCriteriaMatch findCriteriaMatch) *fleet.BundleTarget {
+// match returns the first BundleTarget, from the matcher's target matches, which matches the specified cluster's name, labels and groups, using matching logic implemented via findCriteriaMatch, and its cluster selector expression.
+// Targets, which exclude the cluster, are skipped.
+func (m *matcher) match(cluster *fleet.Cluster, clusterGroups map[string]map[string]string, findCriteriaMatch findCriteriaMatch) *fleet.BundleTarget {
 	for _, targetMatch := range m.matches {
-		if len(clusterGroups) == 0 {
-			if findCriteriaMatch(targetMatch, clusterName, "", nil, clusterLabels) {
-				return targetMatch.bundleTarget
-			}
-		} else {
-			for clusterGroup, clusterGroupLabels := range clusterGroups {
-				if findCriteriaMatch(targetMatch, clusterName, clusterGroup, clusterGroupLabels, clusterLabels) {
-					return targetMatch.bundleTarget
-				}
-			}
+		if targetMatch.matches(cluster, clusterGroups, findCriteriaMatch) {
+			return targetMatch.bundleTarget
 		}
 	}
 
 	return nil
 }
+
+// matchAll is like match, but returns all matching BundleTargets in order.
+func (m *matcher) matchAll(cluster *fleet.Cluster, clusterGroups map[string]map[string]string, findCriteriaMatch findCriteriaMatch) []*fleet.BundleTarget {
+	var targets []*fleet.BundleTarget
+	for _, targetMatch := range m.matches {
+		if targetMatch.matches(cluster, clusterGroups, findCriteriaMatch) {
+			targets = append(targets, targetMatch.bundleTarget)
+		}
+	}
+
+	return targets
+}
+
+func (t targetMatch) matches(cluster *fleet.Cluster, clusterGroups map[string]map[string]string, findCriteriaMatch findCriteriaMatch) bool {
+	return t.matchesTarget(cluster, clusterGroups, findCriteriaMatch) && t.samples(cluster)
+}
+
+// matchesTarget is like matches, but ignores the target's percentage.
+func (t targetMatch) matchesTarget(cluster *fleet.Cluster, clusterGroups map[string]map[string]string, findCriteriaMatch findCriteriaMatch) bool {
+	if t.exclusion.Excludes(clusterGroups, cluster.Labels) {
+		return false
+	}
+	matched := false
+	if len(clusterGroups) == 0 {
+		matched = findCriteriaMatch(t, cluster.Name, "", nil, cluster.Labels)
+	} else {
+		for clusterGroup, clusterGroupLabels := range clusterGroups {
+			if findCriteriaMatch(t, cluster.Name, clusterGroup, clusterGroupLabels, cluster.Labels) {
+				matched = true
+				break
+			}
+		}
+	}
+	return matched && t.matchesExpression(cluster)
+}
+
+// samples returns true, if the cluster is in the target's percentage of
+// clusters. Unless the clusters were sampled, the cluster's hash is
+// compared to the percentage.
+func (t targetMatch) samples(cluster *fleet.Cluster) bool {
+	percentage := t.bundleTarget.Percentage
+	if !sampled(percentage) {
+		return true
+	}
+	if t.sampled != nil {
+		return t.sampled[cluster.Namespace+"/"+cluster.Name]
+	}
+	return int(t.sampleHash(cluster)%100) < percentage
+}
+
+// sampleHash hashes the cluster's namespace and name with the target's name,
+// so each target samples different clusters.
+func (t targetMatch) sampleHash(cluster *fleet.Cluster) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(t.bundleTarget.Name + "/" + cluster.Namespace + "/" + cluster.Name))
+	return h.Sum32()
+}
+
+// sampled returns true, if the percentage selects only part of the clusters
+func sampled(percentage int) bool {
+	return percentage > 0 && percentage < 100
+}
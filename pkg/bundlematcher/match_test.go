@@ -0,0 +1,288 @@
+package bundlematcher
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMatchTargetCustomizationsNodeArchitecture(t *testing.T) {
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{Targets: []fleet.BundleTarget{
+		{Name: "arm-prod", NodeArchitecture: "arm64", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}},
+		{Name: "arm", NodeArchitecture: "arm64"},
+		{Name: "s390x", NodeArchitecture: "s390x"},
+		{Name: "default", ClusterSelector: &metav1.LabelSelector{}},
+	}}}
+	bm, err := New(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		labels map[string]string
+		archs  []string
+		want   string
+	}{
+		{labels: map[string]string{"env": "prod"}, archs: []string{"amd64", "arm64"}, want: "arm-prod"},
+		{labels: map[string]string{"env": "dev"}, archs: []string{"arm64"}, want: "arm"},
+		{archs: []string{"s390x"}, want: "s390x"},
+		{archs: []string{"amd64"}, want: "default"},
+		{want: "default"},
+	}
+	for _, tt := range tests {
+		m := bm.MatchTargetCustomizations("cluster", nil, tt.labels, tt.archs)
+		if m == nil || m.Name != tt.want {
+			t.Errorf("expected %s for %v, got %v", tt.want, tt.archs, m)
+		}
+	}
+}
+
+func TestMatchClusterSelectorExpression(t *testing.T) {
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{Targets: []fleet.BundleTarget{
+		{Name: "eu", ClusterSelectorExpression: `labels.region in ["eu-west", "eu-central"] && kubernetesMinor >= 27 && !("canary" in labels)`},
+		{Name: "annotated", ClusterSelector: &metav1.LabelSelector{}, ClusterSelectorExpression: `annotations["team"] == "a"`},
+		{Name: "default", ClusterSelector: &metav1.LabelSelector{}},
+	}}}
+	bm, err := New(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cluster := func(version string, labels, annotations map[string]string) *fleet.Cluster {
+		c := &fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Labels: labels, Annotations: annotations}}
+		c.Status.Agent.KubernetesVersion = version
+		return c
+	}
+	tests := []struct {
+		name    string
+		cluster *fleet.Cluster
+		want    string
+	}{
+		{"match", cluster("v1.27.3", map[string]string{"region": "eu-west"}, nil), "eu"},
+		{"old version", cluster("v1.26.0", map[string]string{"region": "eu-west"}, nil), "default"},
+		{"canary", cluster("v1.28.0", map[string]string{"region": "eu-central", "canary": "true"}, nil), "default"},
+		{"missing label", cluster("v1.28.0", nil, map[string]string{"team": "a"}), "annotated"},
+	}
+	for _, tt := range tests {
+		m := bm.MatchCluster(tt.cluster, nil)
+		if m == nil || m.Name != tt.want {
+			t.Errorf("%s: expected %s, got %v", tt.name, tt.want, m)
+		}
+	}
+
+	bundle.Spec.Targets = []fleet.BundleTarget{
+		{Name: "arm-aws", ClusterSelectorExpression: `"arm64" in nodeArchitectures && cloudProvider == "aws" && cni == "cilium"`},
+		{Name: "monitoring", ClusterSelectorExpression: `"monitoring.coreos.com/v1" in apiVersions`},
+	}
+	if bm, err = New(bundle); err != nil {
+		t.Fatal(err)
+	}
+	c := cluster("v1.28.0", nil, nil)
+	if m := bm.MatchCluster(c, nil); m != nil {
+		t.Errorf("expected no match without capabilities, got %s", m.Name)
+	}
+	c.Status.Agent.NodeArchitectures = []string{"amd64", "arm64"}
+	c.Status.Agent.CloudProvider = "aws"
+	c.Status.Agent.CNI = "cilium"
+	if m := bm.MatchCluster(c, nil); m == nil || m.Name != "arm-aws" {
+		t.Errorf("expected arm-aws, got %v", m)
+	}
+	c.Status.Agent.CNI = "calico"
+	c.Status.Agent.APIVersions = []string{"apps/v1", "monitoring.coreos.com/v1"}
+	if m := bm.MatchCluster(c, nil); m == nil || m.Name != "monitoring" {
+		t.Errorf("expected monitoring, got %v", m)
+	}
+
+	bundle.Spec.Targets = []fleet.BundleTarget{{ClusterSelectorExpression: `labels.region`}}
+	if _, err := New(bundle); err == nil {
+		t.Error("expected error for expression, which isn't a bool")
+	}
+}
+
+func TestMatchExclusions(t *testing.T) {
+	prod := &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+	incident := &metav1.LabelSelector{MatchLabels: map[string]string{"incident": "true"}}
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{
+		Targets: []fleet.BundleTarget{
+			{Name: "prod", ClusterSelector: prod, ExcludeClusterSelector: incident, ExcludeClusterGroup: "frozen"},
+			{Name: "customization", ClusterSelector: &metav1.LabelSelector{}},
+		},
+		TargetRestrictions: []fleet.BundleTargetRestriction{
+			{Name: "prod", ClusterSelector: prod, ExcludeClusterSelector: incident, ExcludeClusterGroup: "frozen"},
+		},
+	}}
+	bm, err := New(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		groups map[string]map[string]string
+		want   string
+	}{
+		{"match", map[string]string{"env": "prod"}, nil, "prod"},
+		{"excluded by selector", map[string]string{"env": "prod", "incident": "true"}, nil, ""},
+		{"excluded by group", map[string]string{"env": "prod"}, map[string]map[string]string{"all": nil, "frozen": nil}, ""},
+		{"other group", map[string]string{"env": "prod"}, map[string]map[string]string{"all": nil}, "prod"},
+	}
+	for _, tt := range tests {
+		m := bm.MatchCluster(&fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Labels: tt.labels}}, tt.groups)
+		switch {
+		case tt.want == "" && m != nil:
+			t.Errorf("%s: expected no match, got %s", tt.name, m.Name)
+		case tt.want != "" && (m == nil || m.Name != tt.want):
+			t.Errorf("%s: expected %s, got %v", tt.name, tt.want, m)
+		}
+	}
+
+	// target customizations aren't restricted, but still excluded
+	m := bm.MatchClusterTargetCustomizations(&fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Labels: map[string]string{"env": "prod", "incident": "true"}}}, nil)
+	if m == nil || m.Name != "customization" {
+		t.Errorf("expected customization, got %v", m)
+	}
+}
+
+func TestMatchTargetCustomizationsMergeStrategy(t *testing.T) {
+	prod := &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+	targets := []fleet.BundleTarget{
+		{Name: "prod", ClusterSelector: prod, BundleDeploymentOptions: fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{Values: &fleet.GenericMap{Data: map[string]interface{}{"replicas": 3, "tier": "prod"}}}}},
+		{Name: "cluster", ClusterName: "cluster", Priority: 10, BundleDeploymentOptions: fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{Values: &fleet.GenericMap{Data: map[string]interface{}{"replicas": 5}}}}},
+		{Name: "all", ClusterSelector: &metav1.LabelSelector{}, BundleDeploymentOptions: fleet.BundleDeploymentOptions{DefaultNamespace: "all", Helm: &fleet.HelmOptions{Values: &fleet.GenericMap{Data: map[string]interface{}{"tier": "default"}}}}},
+	}
+
+	tests := []struct {
+		strategy string
+		want     string
+		values   map[string]interface{}
+	}{
+		{strategy: "", want: "cluster"},
+		{strategy: fleet.MergeStrategyFirstMatch, want: "cluster"},
+		{strategy: fleet.MergeStrategyLastWins, want: "cluster"},
+		{strategy: fleet.MergeStrategyMergeAll, want: "prod,all,cluster", values: map[string]interface{}{"replicas": 5, "tier": "default"}},
+	}
+	for _, tt := range tests {
+		bundle := &fleet.Bundle{Spec: fleet.BundleSpec{MergeStrategy: tt.strategy, Targets: targets}}
+		bm, err := New(bundle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := bm.MatchTargetCustomizations("cluster", nil, map[string]string{"env": "prod"}, nil)
+		if m == nil || m.Name != tt.want {
+			t.Errorf("%q: expected %s, got %v", tt.strategy, tt.want, m)
+			continue
+		}
+		if tt.values != nil {
+			if !reflect.DeepEqual(m.Helm.Values.Data, tt.values) {
+				t.Errorf("%q: expected values %v, got %v", tt.strategy, tt.values, m.Helm.Values.Data)
+			}
+			if m.DefaultNamespace != "all" {
+				t.Errorf("%q: expected default namespace all, got %q", tt.strategy, m.DefaultNamespace)
+			}
+		}
+	}
+
+	// without priorities, first-match and last-wins use the order of the targets
+	for strategy, want := range map[string]string{fleet.MergeStrategyFirstMatch: "prod", fleet.MergeStrategyLastWins: "all"} {
+		bundle := &fleet.Bundle{Spec: fleet.BundleSpec{MergeStrategy: strategy, Targets: []fleet.BundleTarget{targets[0], targets[2]}}}
+		bm, err := New(bundle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := bm.MatchTargetCustomizations("cluster", nil, map[string]string{"env": "prod"}, nil); m == nil || m.Name != want {
+			t.Errorf("%q: expected %s, got %v", strategy, want, m)
+		}
+	}
+}
+
+func TestMatchPercentage(t *testing.T) {
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{Targets: []fleet.BundleTarget{
+		{Name: "canary", Percentage: 10, ClusterSelector: &metav1.LabelSelector{}},
+		{Name: "default", ClusterSelector: &metav1.LabelSelector{}},
+	}}}
+
+	sampled := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		bm, err := New(bundle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for c := 0; c < 1000; c++ {
+			name := fmt.Sprintf("cluster-%d", c)
+			m := bm.Match(name, nil, nil)
+			if m == nil {
+				t.Fatalf("expected a target for %s", name)
+			}
+			if i == 0 {
+				sampled[name] = m.Name == "canary"
+			} else if sampled[name] != (m.Name == "canary") {
+				t.Errorf("expected stable sampling for %s", name)
+			}
+		}
+	}
+
+	count := 0
+	for _, ok := range sampled {
+		if ok {
+			count++
+		}
+	}
+	if count < 50 || count > 150 {
+		t.Errorf("expected about 100 of 1000 clusters in canary, got %d", count)
+	}
+}
+
+func TestSamplePercentage(t *testing.T) {
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{Targets: []fleet.BundleTarget{
+		{Name: "canary", Percentage: 30, ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}},
+		{Name: "default", ClusterSelector: &metav1.LabelSelector{}},
+	}}}
+
+	var (
+		clusters []*fleet.Cluster
+		groups   []map[string]map[string]string
+	)
+	for c := 0; c < 7; c++ {
+		clusters = append(clusters, &fleet.Cluster{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fleet-default",
+			Name:      fmt.Sprintf("cluster-%d", c),
+			Labels:    map[string]string{"env": "prod"},
+		}})
+		groups = append(groups, nil)
+	}
+	// not part of the canary's clusters
+	clusters = append(clusters, &fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "dev", Labels: map[string]string{"env": "dev"}}})
+	groups = append(groups, nil)
+
+	var sampled []string
+	for i := 0; i < 2; i++ {
+		bm, err := New(bundle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bm.Samples() {
+			t.Fatal("expected bundle to sample clusters")
+		}
+		bm.Sample(clusters, groups)
+
+		var canary []string
+		for _, cluster := range clusters {
+			if m := bm.MatchCluster(cluster, nil); m != nil && m.Name == "canary" {
+				canary = append(canary, cluster.Name)
+			}
+		}
+		// ceil(7 * 30 / 100)
+		if len(canary) != 3 {
+			t.Fatalf("expected 3 of 7 clusters in canary, got %v", canary)
+		}
+		if i == 1 && !reflect.DeepEqual(canary, sampled) {
+			t.Errorf("expected stable sampling, got %v and %v", sampled, canary)
+		}
+		sampled = canary
+	}
+}
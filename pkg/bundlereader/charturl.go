@@ -30,11 +30,69 @@ func chartURL(location *fleet.HelmOptions, auth Auth) (string, error) {
 		location.Repo = location.Repo + "/"
 	}
 
-	request, err := http.NewRequest("GET", location.Repo+"index.yaml", nil)
+	repo, err := repoIndex(location.Repo, auth)
 	if err != nil {
 		return "", err
 	}
 
+	chart, err := repo.Get(location.Chart, location.Version)
+	if err != nil {
+		return "", err
+	}
+
+	if len(chart.URLs) == 0 {
+		return "", fmt.Errorf("no URLs found for chart %s %s at %s", chart.Name, chart.Version, location.Repo)
+	}
+
+	chartURL, err := url.Parse(chart.URLs[0])
+	if err != nil {
+		return "", err
+	}
+
+	if chartURL.IsAbs() {
+		return chart.URLs[0], nil
+	}
+
+	repoURL, err := url.Parse(location.Repo)
+	if err != nil {
+		return "", err
+	}
+
+	return repoURL.ResolveReference(chartURL).String(), nil
+}
+
+// LatestChartVersion returns the latest version of the chart in the helm
+// repo, which matches the version range of the helm options.
+func LatestChartVersion(location *fleet.HelmOptions, auth Auth) (string, error) {
+	if location.Repo == "" || hasOCIURL.MatchString(location.Chart) {
+		return "", fmt.Errorf("chart %s is not from a helm repo", location.Chart)
+	}
+
+	repoURL := location.Repo
+	if !strings.HasSuffix(repoURL, "/") {
+		repoURL += "/"
+	}
+
+	repo, err := repoIndex(repoURL, auth)
+	if err != nil {
+		return "", err
+	}
+
+	chart, err := repo.Get(location.Chart, location.Version)
+	if err != nil {
+		return "", err
+	}
+
+	return chart.Version, nil
+}
+
+// repoIndex downloads and parses the index.yaml of the helm repo
+func repoIndex(repoURL string, auth Auth) (*repo.IndexFile, error) {
+	request, err := http.NewRequest("GET", repoURL+"index.yaml", nil)
+	if err != nil {
+		return nil, err
+	}
+
 	if auth.Username != "" && auth.Password != "" {
 		request.SetBasicAuth(auth.Username, auth.Password)
 	}
@@ -55,48 +113,25 @@ func chartURL(location *fleet.HelmOptions, auth Auth) (string, error) {
 
 	resp, err := client.Do(request)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	bytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("failed to read helm repo from %s, error code: %v, response body: %s", location.Repo+"index.yaml", resp.StatusCode, bytes)
+		return nil, fmt.Errorf("failed to read helm repo from %s, error code: %v, response body: %s", repoURL+"index.yaml", resp.StatusCode, bytes)
 	}
 
 	repo := &repo.IndexFile{}
 	if err := yaml.Unmarshal(bytes, repo); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	repo.SortEntries()
 
-	chart, err := repo.Get(location.Chart, location.Version)
-	if err != nil {
-		return "", err
-	}
-
-	if len(chart.URLs) == 0 {
-		return "", fmt.Errorf("no URLs found for chart %s %s at %s", chart.Name, chart.Version, location.Repo)
-	}
-
-	chartURL, err := url.Parse(chart.URLs[0])
-	if err != nil {
-		return "", err
-	}
-
-	if chartURL.IsAbs() {
-		return chart.URLs[0], nil
-	}
-
-	repoURL, err := url.Parse(location.Repo)
-	if err != nil {
-		return "", err
-	}
-
-	return repoURL.ResolveReference(chartURL).String(), nil
+	return repo, nil
 }
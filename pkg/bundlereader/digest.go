@@ -0,0 +1,150 @@
+package bundlereader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/content"
+	"github.com/rancher/fleet/pkg/durations"
+
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"sigs.k8s.io/yaml"
+)
+
+const digestPrefix = "sha256:"
+
+// chartCache holds the files of remote charts which were recently
+// downloaded, so charts referenced by several bundles are only fetched once.
+// Entries expire after durations.ChartCacheTTL, so charts which were
+// republished under the same version or unpinned versions are fetched again.
+var chartCache = struct {
+	sync.Mutex
+	charts map[string]cachedFiles
+}{charts: map[string]cachedFiles{}}
+
+type cachedFiles struct {
+	files   map[string][]byte
+	fetched time.Time
+}
+
+// chartCacheKey returns the cache key of the chart. It includes a hash of
+// the credentials, so charts fetched with one set of credentials are not
+// returned for another.
+func chartCacheKey(source, version string, auth Auth) string {
+	h := sha256.New()
+	for _, data := range [][]byte{[]byte(auth.Username), []byte(auth.Password), auth.CABundle, auth.SSHPrivateKey} {
+		h.Write(data)
+		h.Write([]byte{0})
+	}
+	return source + "@" + version + "@" + hex.EncodeToString(h.Sum(nil))
+}
+
+func cachedChart(key string, now time.Time) (map[string][]byte, bool) {
+	chartCache.Lock()
+	defer chartCache.Unlock()
+	cached, ok := chartCache.charts[key]
+	if !ok || now.Sub(cached.fetched) >= durations.ChartCacheTTL {
+		return nil, false
+	}
+	return cached.files, true
+}
+
+func cacheChart(key string, files map[string][]byte, now time.Time) {
+	chartCache.Lock()
+	defer chartCache.Unlock()
+	for k, cached := range chartCache.charts {
+		if now.Sub(cached.fetched) >= durations.ChartCacheTTL {
+			delete(chartCache.charts, k)
+		}
+	}
+	chartCache.charts[key] = cachedFiles{files: files, fetched: now}
+}
+
+// digest returns a digest of the files, which only depends on their names
+// and content.
+func digest(files map[string][]byte) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write(files[name])
+		h.Write([]byte{0})
+	}
+	return digestPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// chartVersion returns the version from the Chart.yaml of the chart files.
+func chartVersion(files map[string][]byte) string {
+	for name, data := range files {
+		if path.Base(name) != chartYAML || strings.Count(name, "/") > 1 {
+			continue
+		}
+		metadata := &helmchart.Metadata{}
+		if err := yaml.Unmarshal(data, metadata); err == nil && metadata.Version != "" {
+			return metadata.Version
+		}
+	}
+	return ""
+}
+
+// ChartDigests returns the digests of the remote charts, which were added
+// to the bundle's resources when reading the bundle.
+func ChartDigests(spec *fleet.BundleSpec) ([]fleet.ChartDigest, error) {
+	charts := []*fleet.HelmOptions{spec.Helm}
+	for _, target := range spec.Targets {
+		charts = append(charts, target.Helm)
+	}
+
+	var (
+		result []fleet.ChartDigest
+		seen   = map[string]bool{}
+	)
+	for _, chart := range charts {
+		if chart == nil || chart.Chart == "" {
+			continue
+		}
+		prefix := checksum(chart) + "/"
+		if seen[prefix] {
+			continue
+		}
+		seen[prefix] = true
+
+		files := map[string][]byte{}
+		for _, resource := range spec.Resources {
+			if !strings.HasPrefix(resource.Name, prefix) {
+				continue
+			}
+			data, err := content.Decode(resource.Content, resource.Encoding)
+			if err != nil {
+				return nil, err
+			}
+			files[strings.TrimPrefix(resource.Name, prefix)] = data
+		}
+		if len(files) == 0 {
+			// chart is not remote
+			continue
+		}
+
+		result = append(result, fleet.ChartDigest{
+			Chart:           chart.Chart,
+			Repo:            chart.Repo,
+			Version:         chart.Version,
+			Digest:          digest(files),
+			ResolvedVersion: chartVersion(files),
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package bundlereader
+
+import (
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+)
+
+func TestChartDigests(t *testing.T) {
+	remote := &fleet.HelmOptions{Chart: "app", Repo: "https://charts.example.com", Version: "1.0.0"}
+	spec := &fleet.BundleSpec{
+		BundleDeploymentOptions: fleet.BundleDeploymentOptions{Helm: remote},
+		Resources: []fleet.BundleResource{
+			{Name: checksum(remote) + "/Chart.yaml", Content: "name: app"},
+			{Name: checksum(remote) + "/templates/cm.yaml", Content: "kind: ConfigMap"},
+			{Name: "values.yaml", Content: "replicas: 1"},
+		},
+		Targets: []fleet.BundleTarget{
+			{BundleDeploymentOptions: fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{Chart: "./local"}}},
+		},
+	}
+
+	digests, err := ChartDigests(spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(digests) != 1 {
+		t.Fatalf("expected one remote chart digest, got %v", digests)
+	}
+
+	expected := digest(map[string][]byte{
+		"Chart.yaml":        []byte("name: app"),
+		"templates/cm.yaml": []byte("kind: ConfigMap"),
+	})
+	if digests[0].Chart != "app" || digests[0].Digest != expected {
+		t.Errorf("expected digest %s for chart app, got %v", expected, digests[0])
+	}
+}
+
+func TestChartCache(t *testing.T) {
+	now := time.Now()
+	key := chartCacheKey("https://charts.example.com/app", "1.0.0", Auth{Username: "a", Password: "secret"})
+	cacheChart(key, map[string][]byte{"Chart.yaml": []byte("name: app")}, now)
+
+	if _, ok := cachedChart(key, now.Add(time.Minute)); !ok {
+		t.Error("expected chart to be cached")
+	}
+	if _, ok := cachedChart(chartCacheKey("https://charts.example.com/app", "1.0.0", Auth{Username: "b", Password: "secret"}), now); ok {
+		t.Error("expected chart not to be cached for other credentials")
+	}
+	if _, ok := cachedChart(key, now.Add(durations.ChartCacheTTL)); ok {
+		t.Error("expected cached chart to expire")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/hashicorp/go-getter"
@@ -23,10 +24,10 @@ import (
 	"helm.sh/helm/v3/pkg/registry"
 )
 
-func loadDirectory(ctx context.Context, compress bool, prefix, base, source, version string, auth Auth) ([]fleet.BundleResource, error) {
+func loadDirectory(ctx context.Context, compress bool, dir directory) ([]fleet.BundleResource, error) {
 	var resources []fleet.BundleResource
 
-	files, err := getContent(ctx, base, source, version, auth)
+	files, err := remoteChartContent(ctx, dir)
 	if err != nil {
 		return nil, err
 	}
@@ -43,8 +44,8 @@ func loadDirectory(ctx context.Context, compress bool, prefix, base, source, ver
 		} else {
 			r.Content = string(data)
 		}
-		if prefix != "" {
-			r.Name = filepath.Join(prefix, name)
+		if dir.prefix != "" {
+			r.Name = filepath.Join(dir.prefix, name)
 		}
 		resources = append(resources, r)
 	}
@@ -52,6 +53,33 @@ func loadDirectory(ctx context.Context, compress bool, prefix, base, source, ver
 	return resources, nil
 }
 
+// remoteChartContent returns the files of the directory. Remote charts are
+// only downloaded once and verified against their pinned digest.
+func remoteChartContent(ctx context.Context, dir directory) (map[string][]byte, error) {
+	if !dir.remote {
+		return getContent(ctx, dir.base, dir.source, dir.version, dir.auth)
+	}
+
+	key := chartCacheKey(dir.source, dir.version, dir.auth)
+	files, ok := cachedChart(key, time.Now())
+	if !ok {
+		var err error
+		files, err = getContent(ctx, dir.base, dir.source, dir.version, dir.auth)
+		if err != nil {
+			return nil, err
+		}
+		cacheChart(key, files, time.Now())
+	}
+
+	if dir.digest != "" {
+		if d := digest(files); d != dir.digest {
+			return nil, fmt.Errorf("chart %s digest mismatch: expected %s, got %s", dir.source, dir.digest, d)
+		}
+	}
+
+	return files, nil
+}
+
 // getContent uses go-getter (and helm for oci) to read the files from directories and servers
 func getContent(ctx context.Context, base, source, version string, auth Auth) (map[string][]byte, error) {
 	temp, err := os.MkdirTemp("", "fleet")
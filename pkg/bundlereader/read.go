@@ -12,14 +12,21 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/fleetyaml"
+	"github.com/rancher/fleet/pkg/match"
+	name2 "github.com/rancher/fleet/pkg/name"
+	"github.com/rancher/fleet/pkg/schedule"
 
+	"github.com/rancher/wrangler/pkg/data"
 	name1 "github.com/rancher/wrangler/pkg/name"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/yaml"
 )
 
@@ -34,6 +41,22 @@ type Options struct {
 	Auth             Auth
 	HelmRepoURLRegex string
 	KeepResources    bool
+	// Chart selects an entry of the charts list in fleet.yaml, the
+	// returned bundle only deploys that chart.
+	Chart *Chart
+}
+
+// Chart is an entry of the charts list in fleet.yaml. Each chart is
+// deployed by its own bundle, as a separate helm release.
+type Chart struct {
+	// Name of the chart entry, it is appended to the bundle name.
+	Name string `json:"name,omitempty"`
+	// Helm options for the chart, these replace the top-level helm
+	// options of fleet.yaml.
+	Helm *fleet.HelmOptions `json:"helm,omitempty"`
+	// DependsOn refers to the chart entries which must be ready before
+	// this chart can be deployed.
+	DependsOn []string `json:"dependsOn,omitempty"`
 }
 
 // Open reads the fleet.yaml, from stdin, or basedir, or a file in basedir.
@@ -74,6 +97,42 @@ func Open(ctx context.Context, name, baseDir, file string, opts *Options) (*flee
 	return mayCompress(ctx, name, baseDir, in, opts)
 }
 
+// Charts returns the charts list from the fleet.yaml in baseDir, or from a
+// file in baseDir. It returns nil if there is no such list.
+func Charts(baseDir, file string) ([]Chart, error) {
+	if file == "-" {
+		return nil, nil
+	}
+	if baseDir == "" {
+		baseDir = "."
+	}
+
+	var (
+		f   *os.File
+		err error
+	)
+	if file == "" {
+		f, err = setupIOReader(baseDir)
+	} else {
+		f, err = os.Open(filepath.Join(baseDir, file))
+	}
+	if err != nil || f == nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	fy, err := unmarshalFleetYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	return fy.Charts, nil
+}
+
 // Try accessing the documented, primary fleet.yaml extension first. If that returns an "IsNotExist" error, then we
 // try the fallback extension. If we receive "IsNotExist" errors for both file extensions, then we return a "nil" file
 // and a "nil" error. If either return a non-"IsNotExist" error, then we return the error immediately.
@@ -130,12 +189,15 @@ func size(bundle *fleet.Bundle) (int, error) {
 }
 
 type fleetYAML struct {
-	Name   string            `json:"name,omitempty"`
-	Labels map[string]string `json:"labels,omitempty"`
+	// Name replaces the bundle name derived from the repo and path
+	Name        string            `json:"name,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
 	fleet.BundleSpec
 	TargetCustomizations []fleet.BundleTarget `json:"targetCustomizations,omitempty"`
 	ImageScans           []imageScan          `json:"imageScans,omitempty"`
 	OverrideTargets      []fleet.GitTarget    `json:"overrideTargets,omitempty"`
+	Charts               []Chart              `json:"charts,omitempty"`
 }
 
 type imageScan struct {
@@ -158,8 +220,8 @@ func read(ctx context.Context, name, baseDir string, bundleSpecReader io.Reader,
 		return nil, nil, err
 	}
 
-	fy := &fleetYAML{}
-	if err := yaml.Unmarshal(bytes, fy); err != nil {
+	fy, err := unmarshalFleetYAML(bytes)
+	if err != nil {
 		return nil, nil, err
 	}
 
@@ -189,9 +251,92 @@ func read(ctx context.Context, name, baseDir string, bundleSpecReader io.Reader,
 
 	meta.Name = name
 	if fy.Name != "" {
+		if errs := validation.IsDNS1123Subdomain(fy.Name); len(errs) > 0 {
+			return nil, nil, fmt.Errorf("invalid bundle name %q in fleet.yaml: %s", fy.Name, strings.Join(errs, ", "))
+		}
 		meta.Name = fy.Name
 	}
 
+	if err := validateEmptyRender(fy.BundleSpec.EmptyRender, fy.TargetCustomizations); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateStatusDetail(fy.BundleSpec.StatusDetail, fy.TargetCustomizations); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateWebhookPolicy(fy.BundleSpec.WebhookPolicy, fy.TargetCustomizations); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateStatusRetention(fy.BundleSpec.StatusRetention, fy.TargetCustomizations); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateApplyStrategies(fy.BundleSpec.ApplyStrategies, fy.TargetCustomizations); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateSystem(fy.BundleSpec.System, fy.TargetCustomizations); err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateVersionUpdatePolicy(fy.BundleSpec.Helm, fy.TargetCustomizations); err != nil {
+		return nil, nil, err
+	}
+
+	switch fy.MergeStrategy {
+	case "", fleet.MergeStrategyFirstMatch, fleet.MergeStrategyMergeAll, fleet.MergeStrategyLastWins:
+	default:
+		return nil, nil, fmt.Errorf("invalid mergeStrategy %q in fleet.yaml, must be one of %s, %s or %s", fy.MergeStrategy, fleet.MergeStrategyFirstMatch, fleet.MergeStrategyMergeAll, fleet.MergeStrategyLastWins)
+	}
+
+	if p := fy.PruneConfirmationPeriod; p != nil && p.Duration < 0 {
+		return nil, nil, fmt.Errorf("invalid pruneConfirmationPeriod %s in fleet.yaml, must not be negative", p.Duration)
+	}
+
+	switch fy.TTLAfter {
+	case "", fleet.TTLAfterCreated, fleet.TTLAfterReady:
+	default:
+		return nil, nil, fmt.Errorf("invalid ttlAfter %q in fleet.yaml, must be %s or %s", fy.TTLAfter, fleet.TTLAfterCreated, fleet.TTLAfterReady)
+	}
+
+	if fy.MaxTargetClusters < 0 {
+		return nil, nil, fmt.Errorf("invalid maxTargetClusters %d in fleet.yaml, must not be negative", fy.MaxTargetClusters)
+	}
+
+	if fy.DeployAt != "" {
+		if _, err := schedule.Parse(fy.DeployAt); err != nil {
+			return nil, nil, fmt.Errorf("invalid deployAt in fleet.yaml: %w", err)
+		}
+	}
+
+	for _, target := range fy.TargetCustomizations {
+		if target.Percentage < 0 || target.Percentage > 100 {
+			return nil, nil, fmt.Errorf("invalid percentage %d of target customization %q in fleet.yaml, must be between 0 and 100", target.Percentage, target.Name)
+		}
+		for _, w := range target.MaintenanceWindows {
+			if _, err := schedule.NewWindow(w.Start, w.Duration.Duration, w.TimeZone); err != nil {
+				return nil, nil, fmt.Errorf("invalid maintenance window of target customization %q in fleet.yaml: %w", target.Name, err)
+			}
+		}
+		if target.ClusterSelectorExpression != "" {
+			if _, err := match.NewExpression(target.ClusterSelectorExpression); err != nil {
+				return nil, nil, fmt.Errorf("invalid target customization %q in fleet.yaml: %w", target.Name, err)
+			}
+		}
+	}
+
+	if opts.Chart != nil {
+		if err := selectChart(fy, meta, opts.Chart); err != nil {
+			return nil, nil, err
+		}
+		if fy.Charts[0].Name != opts.Chart.Name {
+			// image scans are only created once, along with the first chart
+			scans = nil
+		}
+	}
+
 	setTargetNames(&fy.BundleSpec)
 
 	propagateHelmChartProperties(&fy.BundleSpec)
@@ -223,6 +368,13 @@ func read(ctx context.Context, name, baseDir string, bundleSpecReader io.Reader,
 		bundle.Labels[k] = v
 	}
 
+	for k, v := range fy.Annotations {
+		if bundle.Annotations == nil {
+			bundle.Annotations = make(map[string]string)
+		}
+		bundle.Annotations[k] = v
+	}
+
 	if opts.ServiceAccount != "" {
 		bundle.Spec.ServiceAccount = opts.ServiceAccount
 	}
@@ -235,19 +387,20 @@ func read(ctx context.Context, name, baseDir string, bundleSpecReader io.Reader,
 		logrus.Debugf("Overriding targets for Bundle '%s' ", bundle.Name)
 		for _, target := range fy.OverrideTargets {
 			bundle.Spec.Targets = append(bundle.Spec.Targets, fleet.BundleTarget{
-				Name:                 target.Name,
-				ClusterName:          target.ClusterName,
-				ClusterSelector:      target.ClusterSelector,
-				ClusterGroup:         target.ClusterGroup,
-				ClusterGroupSelector: target.ClusterGroupSelector,
+				Name:                   target.Name,
+				ClusterName:            target.ClusterName,
+				ClusterSelector:        target.ClusterSelector,
+				ClusterGroup:           target.ClusterGroup,
+				ClusterGroupSelector:   target.ClusterGroupSelector,
+				ExcludeClusterSelector: target.ExcludeClusterSelector,
+				ExcludeClusterGroup:    target.ExcludeClusterGroup,
 			})
 			bundle.Spec.TargetRestrictions = append(bundle.Spec.TargetRestrictions, fleet.BundleTargetRestriction(target))
 		}
-	} else {
-		bundle, err = appendTargets(bundle, opts.TargetsFile)
-		if err != nil {
-			return nil, nil, err
-		}
+	}
+	bundle, err = appendTargets(bundle, opts.TargetsFile, fy.OverrideTargets == nil)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if len(bundle.Spec.Targets) == 0 {
@@ -277,6 +430,34 @@ func read(ctx context.Context, name, baseDir string, bundleSpecReader io.Reader,
 	return bundle, scans, nil
 }
 
+// selectChart replaces the helm options in fy with the ones from chart, and
+// names the bundle after the chart. Dependencies on other charts are
+// added as dependencies on their bundles.
+func selectChart(fy *fleetYAML, meta *bundleMeta, chart *Chart) error {
+	names := map[string]string{}
+	for _, c := range fy.Charts {
+		if c.Name == "" {
+			return errors.New("the name of chart is required")
+		}
+		names[c.Name] = name2.HelmReleaseName(meta.Name + "-" + c.Name)
+	}
+	if _, ok := names[chart.Name]; !ok {
+		return fmt.Errorf("chart %s not found in fleet.yaml", chart.Name)
+	}
+
+	for _, dep := range chart.DependsOn {
+		bundleName, ok := names[dep]
+		if !ok {
+			return fmt.Errorf("chart %s depends on unknown chart %s", chart.Name, dep)
+		}
+		fy.DependsOn = append(fy.DependsOn, fleet.BundleRef{Name: bundleName})
+	}
+
+	fy.Helm = chart.Helm.DeepCopy()
+	meta.Name = names[chart.Name]
+	return nil
+}
+
 // propagateHelmChartProperties propagates root Helm chart properties to the child targets.
 // This is necessary, so we can download the correct chart version for each target.
 func propagateHelmChartProperties(spec *fleet.BundleSpec) {
@@ -301,21 +482,173 @@ func propagateHelmChartProperties(spec *fleet.BundleSpec) {
 	}
 }
 
-func appendTargets(def *fleet.Bundle, targetsFile string) (*fleet.Bundle, error) {
+func validateEmptyRender(emptyRender string, targets []fleet.BundleTarget) error {
+	values := []string{emptyRender}
+	for _, target := range targets {
+		values = append(values, target.EmptyRender)
+	}
+	for _, v := range values {
+		switch v {
+		case "", fleet.EmptyRenderDelete, fleet.EmptyRenderKeep, fleet.EmptyRenderError:
+		default:
+			return fmt.Errorf("invalid emptyRender %q in fleet.yaml, must be one of %s, %s or %s", v, fleet.EmptyRenderDelete, fleet.EmptyRenderKeep, fleet.EmptyRenderError)
+		}
+	}
+	return nil
+}
+
+func validateStatusDetail(statusDetail string, targets []fleet.BundleTarget) error {
+	values := []string{statusDetail}
+	for _, target := range targets {
+		values = append(values, target.StatusDetail)
+	}
+	for _, v := range values {
+		switch v {
+		case "", fleet.StatusDetailMinimal, fleet.StatusDetailNormal, fleet.StatusDetailFull:
+		default:
+			return fmt.Errorf("invalid statusDetail %q in fleet.yaml, must be one of %s, %s or %s", v, fleet.StatusDetailMinimal, fleet.StatusDetailNormal, fleet.StatusDetailFull)
+		}
+	}
+	return nil
+}
+
+func validateWebhookPolicy(policy string, targets []fleet.BundleTarget) error {
+	values := []string{policy}
+	for _, target := range targets {
+		values = append(values, target.WebhookPolicy)
+	}
+	for _, v := range values {
+		switch v {
+		case "", fleet.WebhookPolicyRetry, fleet.WebhookPolicyFail, fleet.WebhookPolicyForce:
+		default:
+			return fmt.Errorf("invalid webhookPolicy %q in fleet.yaml, must be one of %s, %s or %s", v, fleet.WebhookPolicyRetry, fleet.WebhookPolicyFail, fleet.WebhookPolicyForce)
+		}
+	}
+	return nil
+}
+
+func validateStatusRetention(retention *fleet.StatusRetention, targets []fleet.BundleTarget) error {
+	values := []*fleet.StatusRetention{retention}
+	for _, target := range targets {
+		values = append(values, target.StatusRetention)
+	}
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if v.HistoryLimit < 0 {
+			return fmt.Errorf("invalid statusRetention.historyLimit %d in fleet.yaml, must not be negative", v.HistoryLimit)
+		}
+		if v.HistoryMaxAge != nil && v.HistoryMaxAge.Duration < 0 {
+			return fmt.Errorf("invalid statusRetention.historyMaxAge %s in fleet.yaml, must not be negative", v.HistoryMaxAge.Duration)
+		}
+	}
+	return nil
+}
+
+func validateApplyStrategies(strategies []fleet.ApplyStrategy, targets []fleet.BundleTarget) error {
+	values := strategies
+	for _, target := range targets {
+		values = append(values[:len(values):len(values)], target.ApplyStrategies...)
+	}
+	for _, v := range values {
+		if err := ValidateApplyStrategy(v.Strategy); err != nil {
+			return fmt.Errorf("invalid applyStrategies strategy %q in fleet.yaml, %w", v.Strategy, err)
+		}
+	}
+	return nil
+}
+
+// ValidateApplyStrategy returns an error, if the strategy is unknown. It
+// validates the strategies in fleet.yaml and in the apply strategy
+// annotation of resources.
+func ValidateApplyStrategy(strategy string) error {
+	switch strategy {
+	case fleet.ApplyStrategyPatch, fleet.ApplyStrategyReplace, fleet.ApplyStrategyCreateOnly:
+		return nil
+	}
+	return fmt.Errorf("must be one of %s, %s or %s", fleet.ApplyStrategyPatch, fleet.ApplyStrategyReplace, fleet.ApplyStrategyCreateOnly)
+}
+
+func validateSystem(system *fleet.SystemOptions, targets []fleet.BundleTarget) error {
+	values := []*fleet.SystemOptions{system}
+	for _, target := range targets {
+		values = append(values, target.System)
+	}
+	for _, v := range values {
+		if v == nil || v.PodDisruptionBudget == nil {
+			continue
+		}
+		if v.PodDisruptionBudget.MinAvailable != nil && v.PodDisruptionBudget.MaxUnavailable != nil {
+			return errors.New("invalid system.podDisruptionBudget in fleet.yaml, only one of minAvailable and maxUnavailable may be set")
+		}
+	}
+	return nil
+}
+
+// validateVersionUpdatePolicy rejects unknown policies. The "commit"
+// policy needs a version range to look for new versions and an exact
+// version to update.
+func validateVersionUpdatePolicy(helm *fleet.HelmOptions, targets []fleet.BundleTarget) error {
+	values := []*fleet.HelmOptions{helm}
+	for _, target := range targets {
+		values = append(values, target.Helm)
+	}
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		switch v.VersionUpdatePolicy {
+		case "", fleet.VersionUpdateAuto:
+			if v.VersionRange != "" {
+				return fmt.Errorf("invalid helm.versionRange %q in fleet.yaml, only supported with versionUpdatePolicy %s", v.VersionRange, fleet.VersionUpdateCommit)
+			}
+		case fleet.VersionUpdateCommit:
+			if _, err := semver.NewConstraint(v.VersionRange); err != nil {
+				return fmt.Errorf("invalid helm.versionRange %q in fleet.yaml: %w", v.VersionRange, err)
+			}
+			if _, err := semver.NewVersion(v.Version); err != nil {
+				return fmt.Errorf("invalid helm.version %q in fleet.yaml, must be a single version with versionUpdatePolicy %s", v.Version, fleet.VersionUpdateCommit)
+			}
+		default:
+			return fmt.Errorf("invalid helm.versionUpdatePolicy %q in fleet.yaml, must be empty, %s or %s", v.VersionUpdatePolicy, fleet.VersionUpdateAuto, fleet.VersionUpdateCommit)
+		}
+	}
+	return nil
+}
+
+// appendTargets adds the targets from the targets file, unless the bundle
+// overrides them, and merges the helm values of the file beneath the
+// bundle's own values.
+func appendTargets(def *fleet.Bundle, targetsFile string, withTargets bool) (*fleet.Bundle, error) {
 	if targetsFile == "" {
 		return def, nil
 	}
 
-	data, err := os.ReadFile(targetsFile)
+	content, err := os.ReadFile(targetsFile)
 	if err != nil {
 		return nil, err
 	}
 
 	spec := &fleet.BundleSpec{}
-	if err := yaml.Unmarshal(data, spec); err != nil {
+	if err := yaml.Unmarshal(content, spec); err != nil {
 		return nil, err
 	}
 
+	if spec.Helm != nil && spec.Helm.Values != nil {
+		if def.Spec.Helm == nil {
+			def.Spec.Helm = &fleet.HelmOptions{}
+		}
+		if def.Spec.Helm.Values == nil {
+			def.Spec.Helm.Values = spec.Helm.Values
+		} else {
+			def.Spec.Helm.Values.Data = data.MergeMaps(spec.Helm.Values.Data, def.Spec.Helm.Values.Data)
+		}
+	}
+
+	if !withTargets {
+		return def, nil
+	}
 	def.Spec.Targets = append(def.Spec.Targets, spec.Targets...)
 	def.Spec.TargetRestrictions = append(def.Spec.TargetRestrictions, spec.TargetRestrictions...)
 
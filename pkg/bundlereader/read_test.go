@@ -0,0 +1,194 @@
+package bundlereader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestSelectChart(t *testing.T) {
+	fy := &fleetYAML{
+		BundleSpec: fleet.BundleSpec{
+			BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+				Helm: &fleet.HelmOptions{Chart: "ignored"},
+			},
+		},
+		Charts: []Chart{
+			{Name: "crds", Helm: &fleet.HelmOptions{Chart: "./crds"}},
+			{Name: "app", Helm: &fleet.HelmOptions{Chart: "./app"}, DependsOn: []string{"crds"}},
+		},
+	}
+	meta := &bundleMeta{}
+	meta.Name = "repo-path"
+
+	if err := selectChart(fy, meta, &fy.Charts[1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.Name != "repo-path-app" {
+		t.Errorf("expected bundle name repo-path-app, got %s", meta.Name)
+	}
+	if fy.Helm.Chart != "./app" {
+		t.Errorf("expected chart ./app, got %s", fy.Helm.Chart)
+	}
+	if len(fy.DependsOn) != 1 || fy.DependsOn[0].Name != "repo-path-crds" {
+		t.Errorf("expected dependency on repo-path-crds, got %v", fy.DependsOn)
+	}
+
+	if err := selectChart(fy, meta, &Chart{Name: "db", DependsOn: []string{"missing"}}); err == nil {
+		t.Errorf("expected error for unknown chart")
+	}
+}
+
+func TestReadNameLabelsAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fleetYAML := `name: payments
+labels:
+  team: payments
+annotations:
+  owner: payments@example.com
+`
+	bundle, _, err := read(context.Background(), "repo-path", dir, strings.NewReader(fleetYAML), &Options{
+		Labels: map[string]string{fleet.RepoLabel: "repo"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Name != "payments" {
+		t.Errorf("expected name from fleet.yaml, got %s", bundle.Name)
+	}
+	if bundle.Labels["team"] != "payments" || bundle.Labels[fleet.RepoLabel] != "repo" {
+		t.Errorf("unexpected labels %v", bundle.Labels)
+	}
+	if bundle.Annotations["owner"] != "payments@example.com" {
+		t.Errorf("unexpected annotations %v", bundle.Annotations)
+	}
+
+	if _, _, err := read(context.Background(), "repo-path", dir, strings.NewReader("name: Payments_App\n"), nil); err == nil {
+		t.Error("expected error for invalid bundle name")
+	}
+}
+
+func TestReadRepoHelmValues(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	targetsFile := filepath.Join(dir, "targets.yaml")
+	targets := `{"targets":[{"clusterGroup":"prod"}],"helm":{"values":{"env":"prod","replicas":1,"image":{"tag":"v1"}}}}`
+	if err := os.WriteFile(targetsFile, []byte(targets), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fleetYAML := `helm:
+  values:
+    replicas: 3
+    image:
+      repository: app
+`
+	bundle, _, err := read(context.Background(), "repo-path", dir, strings.NewReader(fleetYAML), &Options{TargetsFile: targetsFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := bundle.Spec.Helm.Values.Data
+	if values["env"] != "prod" {
+		t.Errorf("expected env from repo values, got %v", values["env"])
+	}
+	if values["replicas"] != float64(3) {
+		t.Errorf("expected replicas from fleet.yaml to take precedence, got %v", values["replicas"])
+	}
+	image, _ := values["image"].(map[string]interface{})
+	if image["tag"] != "v1" || image["repository"] != "app" {
+		t.Errorf("expected merged image values, got %v", image)
+	}
+	if len(bundle.Spec.Targets) != 1 || bundle.Spec.Targets[0].ClusterGroup != "prod" {
+		t.Errorf("unexpected targets %v", bundle.Spec.Targets)
+	}
+
+	bundle, _, err = read(context.Background(), "repo-path", dir, strings.NewReader("targets:\n- clusterGroup: dev\n"), &Options{TargetsFile: targetsFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Spec.Helm == nil || bundle.Spec.Helm.Values.Data["env"] != "prod" {
+		t.Errorf("expected repo values without helm options in fleet.yaml, got %v", bundle.Spec.Helm)
+	}
+}
+
+func TestReadEmptyRender(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, _, err := read(context.Background(), "repo-path", dir, strings.NewReader("emptyRender: keep\ntargetCustomizations:\n- name: prod\n  emptyRender: error\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Spec.EmptyRender != fleet.EmptyRenderKeep {
+		t.Errorf("expected emptyRender from fleet.yaml, got %q", bundle.Spec.EmptyRender)
+	}
+
+	if _, _, err := read(context.Background(), "repo-path", dir, strings.NewReader("targetCustomizations:\n- name: prod\n  emptyRender: ignore\n"), nil); err == nil {
+		t.Error("expected error for invalid emptyRender")
+	}
+}
+
+func TestReadStatusDetail(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, _, err := read(context.Background(), "repo-path", dir, strings.NewReader("statusDetail: minimal\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Spec.StatusDetail != fleet.StatusDetailMinimal {
+		t.Errorf("expected statusDetail from fleet.yaml, got %q", bundle.Spec.StatusDetail)
+	}
+
+	if _, _, err := read(context.Background(), "repo-path", dir, strings.NewReader("targetCustomizations:\n- name: prod\n  statusDetail: verbose\n"), nil); err == nil {
+		t.Error("expected error for invalid statusDetail")
+	}
+}
+
+func TestReadSystemPodDisruptionBudget(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := read(context.Background(), "repo-path", dir, strings.NewReader("system:\n  podDisruptionBudget:\n    minAvailable: 1\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := read(context.Background(), "repo-path", dir, strings.NewReader("targetCustomizations:\n- name: prod\n  system:\n    podDisruptionBudget:\n      minAvailable: 1\n      maxUnavailable: 1\n"), nil); err == nil {
+		t.Error("expected error for minAvailable and maxUnavailable")
+	}
+}
+
+func TestReadVersionUpdatePolicy(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := read(context.Background(), "repo-path", dir, strings.NewReader("targetCustomizations:\n- name: prod\n  helm:\n    versionUpdatePolicy: pullRequest\n"), nil); err == nil {
+		t.Error("expected error for unsupported versionUpdatePolicy")
+	}
+	if _, _, err := read(context.Background(), "repo-path", dir, strings.NewReader("helm:\n  version: ~1.2.0\n  versionUpdatePolicy: commit\n"), nil); err == nil {
+		t.Error("expected error for commit policy without versionRange")
+	}
+	if _, _, err := read(context.Background(), "repo-path", dir, strings.NewReader("helm:\n  version: 1.2.0\n  versionRange: ~1.2.0\n"), nil); err == nil {
+		t.Error("expected error for versionRange without commit policy")
+	}
+	if _, _, err := read(context.Background(), "repo-path", dir, strings.NewReader("helm:\n  version: 1.2.0\n  versionRange: ~1.2.0\n  versionUpdatePolicy: commit\n"), nil); err != nil {
+		t.Errorf("expected commit policy to be valid, got %v", err)
+	}
+}
@@ -86,6 +86,10 @@ type directory struct {
 	key     string
 	version string
 	auth    Auth
+	// remote is set for charts downloaded from a repository
+	remote bool
+	// digest is the expected digest of a remote chart's files
+	digest string
 }
 
 func addDirectory(base, customDir, defaultDir string) ([]directory, error) {
@@ -174,6 +178,8 @@ func addRemoteCharts(directories []directory, base string, charts []*fleet.HelmO
 				key:     checksum(chart),
 				auth:    auth,
 				version: chart.Version,
+				remote:  true,
+				digest:  chart.ChartDigest,
 			})
 		}
 	}
@@ -216,7 +222,7 @@ func loadDirectories(ctx context.Context, compress bool, directories ...director
 		dir := dir
 		eg.Go(func() error {
 			defer sem.Release(1)
-			resources, err := loadDirectory(ctx, compress, dir.prefix, dir.base, dir.source, dir.version, dir.auth)
+			resources, err := loadDirectory(ctx, compress, dir)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,72 @@
+package bundlereader
+
+import (
+	"fmt"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/fleetyaml"
+
+	"sigs.k8s.io/yaml"
+)
+
+// fleetYAMLV1beta1 is the fleet.yaml schema for fleet.cattle.io/v1beta1.
+// Targets replaces overrideTargets, the targets field of the v1alpha1 schema
+// is only available as targetCustomizations.
+type fleetYAMLV1beta1 struct {
+	Name        string            `json:"name,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	fleet.BundleSpec
+	Targets              []fleet.GitTarget    `json:"targets,omitempty"`
+	TargetCustomizations []fleet.BundleTarget `json:"targetCustomizations,omitempty"`
+	ImageScans           []imageScan          `json:"imageScans,omitempty"`
+	Charts               []Chart              `json:"charts,omitempty"`
+}
+
+// toFleetYAML converts to the internal representation, which matches the
+// v1alpha1 schema
+func (in *fleetYAMLV1beta1) toFleetYAML() *fleetYAML {
+	fy := &fleetYAML{
+		Name:                 in.Name,
+		Labels:               in.Labels,
+		Annotations:          in.Annotations,
+		BundleSpec:           in.BundleSpec,
+		TargetCustomizations: in.TargetCustomizations,
+		ImageScans:           in.ImageScans,
+		OverrideTargets:      in.Targets,
+		Charts:               in.Charts,
+	}
+	fy.BundleSpec.Targets = nil
+	return fy
+}
+
+// unmarshalFleetYAML reads fleet.yaml according to its apiVersion. An
+// unknown apiVersion is rejected, instead of silently ignoring fields
+// introduced by a newer schema.
+func unmarshalFleetYAML(data []byte) (*fleetYAML, error) {
+	header := struct {
+		APIVersion string `json:"apiVersion,omitempty"`
+	}{}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	switch header.APIVersion {
+	case "", fleetyaml.APIVersionV1alpha1:
+		fy := &fleetYAML{}
+		if err := yaml.Unmarshal(data, fy); err != nil {
+			return nil, err
+		}
+		return fy, nil
+	case fleetyaml.APIVersionV1beta1:
+		fy := &fleetYAMLV1beta1{}
+		if err := yaml.Unmarshal(data, fy); err != nil {
+			return nil, err
+		}
+		return fy.toFleetYAML(), nil
+	default:
+		return nil, fmt.Errorf("unsupported fleet.yaml apiVersion %q, supported versions are %s, a newer fleet version might be required",
+			header.APIVersion, strings.Join(fleetyaml.SupportedAPIVersions, ", "))
+	}
+}
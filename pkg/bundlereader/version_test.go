@@ -0,0 +1,50 @@
+package bundlereader
+
+import (
+	"testing"
+)
+
+func TestUnmarshalFleetYAML(t *testing.T) {
+	v1alpha1 := []byte(`
+defaultNamespace: app
+targets:
+- name: prod
+  clusterSelector:
+    matchLabels:
+      env: prod
+overrideTargets:
+- clusterName: local
+`)
+	v1beta1 := []byte(`
+apiVersion: fleet.cattle.io/v1beta1
+defaultNamespace: app
+targetCustomizations:
+- name: prod
+  clusterSelector:
+    matchLabels:
+      env: prod
+targets:
+- clusterName: local
+`)
+
+	for _, data := range [][]byte{v1alpha1, v1beta1} {
+		fy, err := unmarshalFleetYAML(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		customizations := append(fy.BundleSpec.Targets, fy.TargetCustomizations...)
+		if fy.DefaultNamespace != "app" {
+			t.Errorf("expected defaultNamespace app, got %q", fy.DefaultNamespace)
+		}
+		if len(customizations) != 1 || customizations[0].Name != "prod" {
+			t.Errorf("unexpected customizations %v", customizations)
+		}
+		if len(fy.OverrideTargets) != 1 || fy.OverrideTargets[0].ClusterName != "local" {
+			t.Errorf("unexpected targets %v", fy.OverrideTargets)
+		}
+	}
+
+	if _, err := unmarshalFleetYAML([]byte("apiVersion: fleet.cattle.io/v2\n")); err == nil {
+		t.Error("expected error for unsupported apiVersion")
+	}
+}
@@ -0,0 +1,198 @@
+package cloudevents
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/config"
+	"github.com/rancher/fleet/pkg/durations"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/summary"
+)
+
+// offlineCheckins is the number of missed agent check-ins, after which a
+// cluster is considered offline
+const offlineCheckins = 3
+
+type rollout struct {
+	generation int64
+	active     bool
+	failed     bool
+}
+
+type handler struct {
+	clusters fleetcontrollers.ClusterController
+
+	lock     sync.Mutex
+	rollouts map[string]*rollout
+	states   map[string]fleet.BundleState
+	offline  map[string]bool
+}
+
+// Register registers the event handlers, once an event sink is configured.
+// Without a sink, the resources are not watched for events.
+func Register(ctx context.Context,
+	bundles fleetcontrollers.BundleController,
+	bundleDeployments fleetcontrollers.BundleDeploymentController,
+	clusters fleetcontrollers.ClusterController) {
+	h := &handler{
+		clusters: clusters,
+		rollouts: map[string]*rollout{},
+		states:   map[string]fleet.BundleState{},
+		offline:  map[string]bool{},
+	}
+
+	var once sync.Once
+	register := func() {
+		once.Do(func() {
+			go send(ctx)
+
+			bundles.OnChange(ctx, "bundle-events", h.OnBundleChange)
+			bundleDeployments.OnChange(ctx, "bundledeployment-events", h.OnBundleDeploymentChange)
+			clusters.OnChange(ctx, "cluster-events", h.OnClusterChange)
+		})
+	}
+
+	if sinkURL() != "" {
+		register()
+		return
+	}
+	config.OnChange(ctx, func(cfg *config.Config) error {
+		if cfg.EventSinkURL != "" {
+			register()
+		}
+		return nil
+	})
+}
+
+// OnBundleChange emits rollout events. A rollout starts when the bundle's
+// generation changes and completes when all deployments are ready.
+// Transitions before a bundle was first seen are not reported, to avoid
+// events on restarts.
+func (h *handler) OnBundleChange(key string, bundle *fleet.Bundle) (*fleet.Bundle, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if bundle == nil {
+		delete(h.rollouts, key)
+		return nil, nil
+	}
+
+	r, ok := h.rollouts[key]
+	if !ok {
+		h.rollouts[key] = &rollout{generation: bundle.Generation}
+		return bundle, nil
+	}
+
+	source := "/fleet/bundles/" + key
+	if r.generation != bundle.Generation {
+		*r = rollout{generation: bundle.Generation, active: true}
+		Emit(BundleRolloutStarted, source, key, bundleData(bundle))
+		return bundle, nil
+	}
+	if !r.active || bundle.Status.ObservedGeneration != bundle.Generation {
+		return bundle, nil
+	}
+
+	s := bundle.Status.Summary
+	switch {
+	case s.DesiredReady > 0 && s.Ready == s.DesiredReady:
+		r.active = false
+		Emit(BundleRolloutCompleted, source, key, bundleData(bundle))
+	case s.ErrApplied > 0 && !r.failed:
+		r.failed = true
+		Emit(BundleRolloutFailed, source, key, bundleData(bundle))
+	}
+
+	return bundle, nil
+}
+
+// OnBundleDeploymentChange emits an event when a deployment's resources
+// are modified on the cluster.
+func (h *handler) OnBundleDeploymentChange(key string, bd *fleet.BundleDeployment) (*fleet.BundleDeployment, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if bd == nil {
+		delete(h.states, key)
+		return nil, nil
+	}
+
+	state := summary.GetDeploymentState(bd)
+	previous, ok := h.states[key]
+	h.states[key] = state
+	if ok && state == fleet.Modified && previous != fleet.Modified {
+		var modified []string
+		for _, m := range bd.Status.ModifiedStatus {
+			modified = append(modified, m.String())
+		}
+		Emit(DriftDetected, "/fleet/bundledeployments/"+key, key, map[string]interface{}{
+			"cluster":  bd.Labels[fleet.ClusterNamespaceLabel] + "/" + fleet.DeploymentClusterName(bd),
+			"bundle":   bd.Labels[fleet.BundleNamespaceLabel] + "/" + fleet.DeploymentBundleName(bd),
+			"modified": modified,
+		})
+	}
+
+	return bd, nil
+}
+
+// OnClusterChange emits an event when a cluster's agent misses several
+// check-ins and when it checks in again.
+func (h *handler) OnClusterChange(key string, cluster *fleet.Cluster) (*fleet.Cluster, error) {
+	if cluster == nil || cluster.Status.Agent.LastSeen.IsZero() {
+		h.lock.Lock()
+		delete(h.offline, key)
+		h.lock.Unlock()
+		return cluster, nil
+	}
+
+	threshold := OfflineThreshold()
+	offline := time.Since(cluster.Status.Agent.LastSeen.Time) > threshold
+	if !offline {
+		// check again, when the cluster would be offline
+		h.clusters.EnqueueAfter(cluster.Namespace, cluster.Name, threshold-time.Since(cluster.Status.Agent.LastSeen.Time)+time.Second)
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	previous, ok := h.offline[key]
+	h.offline[key] = offline
+	if !ok || previous == offline {
+		return cluster, nil
+	}
+
+	eventType := ClusterOnline
+	if offline {
+		eventType = ClusterOffline
+	}
+	Emit(eventType, "/fleet/clusters/"+key, key, map[string]interface{}{
+		"lastSeen": cluster.Status.Agent.LastSeen,
+	})
+
+	return cluster, nil
+}
+
+func bundleData(bundle *fleet.Bundle) map[string]interface{} {
+	return map[string]interface{}{
+		"generation": bundle.Generation,
+		"repo":       bundle.Labels[fleet.RepoLabel],
+		"commit":     bundle.Labels["fleet.cattle.io/commit"],
+		"summary":    bundle.Status.Summary,
+	}
+}
+
+// OfflineThreshold returns the time since the last check-in of a cluster's
+// agent, after which the cluster is considered offline.
+func OfflineThreshold() time.Duration {
+	return offlineCheckins * checkinInterval()
+}
+
+func checkinInterval() time.Duration {
+	if d := config.Get().AgentCheckinInterval.Duration; d > 0 {
+		return d
+	}
+	return durations.DefaultClusterCheckInterval
+}
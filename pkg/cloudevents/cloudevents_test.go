@@ -0,0 +1,136 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/config"
+	"github.com/rancher/fleet/pkg/name"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func drain() []string {
+	var types []string
+	for {
+		select {
+		case e := <-queue:
+			types = append(types, e.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestBundleRollout(t *testing.T) {
+	if err := config.Set(&config.Config{EventSinkURL: "http://sink"}); err != nil {
+		t.Fatal(err)
+	}
+	h := &handler{rollouts: map[string]*rollout{}}
+
+	bundle := &fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "ns", Generation: 1}}
+	bundle.Status.ObservedGeneration = 1
+	bundle.Status.Summary.DesiredReady = 2
+	bundle.Status.Summary.Ready = 2
+
+	steps := []struct {
+		mutate func()
+		want   []string
+	}{
+		{func() {}, nil},
+		{func() { bundle.Generation = 2; bundle.Status.Summary.Ready = 0 }, []string{BundleRolloutStarted}},
+		{func() { bundle.Status.ObservedGeneration = 2; bundle.Status.Summary.ErrApplied = 1 }, []string{BundleRolloutFailed}},
+		{func() {}, nil},
+		{func() { bundle.Status.Summary.ErrApplied = 0; bundle.Status.Summary.Ready = 2 }, []string{BundleRolloutCompleted}},
+		{func() {}, nil},
+	}
+	for i, step := range steps {
+		step.mutate()
+		if _, err := h.OnBundleChange("ns/b", bundle); err != nil {
+			t.Fatal(err)
+		}
+		got := drain()
+		if len(got) != len(step.want) || (len(got) > 0 && got[0] != step.want[0]) {
+			t.Errorf("step %d: expected %v, got %v", i, step.want, got)
+		}
+	}
+}
+
+func TestDriftDetectedLongNames(t *testing.T) {
+	if err := config.Set(&config.Config{EventSinkURL: "http://sink"}); err != nil {
+		t.Fatal(err)
+	}
+	h := &handler{states: map[string]fleet.BundleState{}}
+
+	cluster, bundle := strings.Repeat("c", 70), strings.Repeat("b", 70)
+	bd := &fleet.BundleDeployment{ObjectMeta: metav1.ObjectMeta{
+		Labels: map[string]string{
+			fleet.ClusterNamespaceLabel: "fleet-default",
+			fleet.ClusterLabel:          name.LabelValue(cluster),
+			fleet.BundleNamespaceLabel:  "fleet-local",
+			fleet.BundleLabel:           name.LabelValue(bundle),
+		},
+		Annotations: map[string]string{
+			fleet.ClusterAnnotation: cluster,
+			fleet.BundleAnnotation:  bundle,
+		},
+	}}
+	bd.Spec.DeploymentID = "1"
+	bd.Spec.StagedDeploymentID = "1"
+	bd.Status.AppliedDeploymentID = "1"
+	bd.Status.Ready = true
+	bd.Status.NonModified = true
+	if _, err := h.OnBundleDeploymentChange("ns/bd", bd); err != nil {
+		t.Fatal(err)
+	}
+	bd.Status.NonModified = false
+	if _, err := h.OnBundleDeploymentChange("ns/bd", bd); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-queue:
+		data := e.Data.(map[string]interface{})
+		if data["cluster"] != "fleet-default/"+cluster || data["bundle"] != "fleet-local/"+bundle {
+			t.Errorf("unexpected event data %v", data)
+		}
+	default:
+		t.Fatal("expected drift event")
+	}
+}
+
+func TestEmitWithoutSink(t *testing.T) {
+	if err := config.Set(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	Emit(ClusterOffline, "/fleet/clusters/ns/c", "ns/c", nil)
+	if got := drain(); len(got) != 0 {
+		t.Errorf("expected no events, got %v", got)
+	}
+}
+
+func TestPost(t *testing.T) {
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != contentType {
+			t.Errorf("unexpected content type %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	event := Event{SpecVersion: "1.0", ID: "1", Type: DriftDetected, Source: "/fleet/bundledeployments/ns/bd"}
+	if err := post(context.Background(), server.Client(), server.URL, event); err != nil {
+		t.Fatal(err)
+	}
+	if received.Type != DriftDetected || received.ID != "1" {
+		t.Errorf("unexpected event %+v", received)
+	}
+}
@@ -0,0 +1,120 @@
+// Package cloudevents emits CloudEvents for significant state transitions of Fleet resources to an HTTP sink. (fleetcontroller)
+//
+// Events are sent in structured JSON mode. Other transports, like NATS or
+// Kafka, can be reached through an HTTP bridge.
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/rancher/fleet/pkg/config"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+const (
+	BundleRolloutStarted   = "io.cattle.fleet.bundle.rollout.started"
+	BundleRolloutCompleted = "io.cattle.fleet.bundle.rollout.completed"
+	BundleRolloutFailed    = "io.cattle.fleet.bundle.rollout.failed"
+	BundleOrphanPurged     = "io.cattle.fleet.bundle.orphan.purged"
+	BundleExpired          = "io.cattle.fleet.bundle.expired"
+	DriftDetected          = "io.cattle.fleet.bundledeployment.drift.detected"
+	ClusterOffline         = "io.cattle.fleet.cluster.offline"
+	ClusterOnline          = "io.cattle.fleet.cluster.online"
+
+	contentType = "application/cloudevents+json"
+	queueSize   = 1000
+	timeout     = 10 * time.Second
+)
+
+// Event is a CloudEvent in structured mode
+type Event struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype,omitempty"`
+	Data            interface{} `json:"data,omitempty"`
+}
+
+var queue = make(chan Event, queueSize)
+
+// Emit queues an event for the configured sink. Events are dropped if no
+// sink is configured or the queue is full.
+func Emit(eventType, source, subject string, data interface{}) {
+	if sinkURL() == "" {
+		return
+	}
+
+	event := Event{
+		SpecVersion:     "1.0",
+		ID:              string(uuid.NewUUID()),
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+	select {
+	case queue <- event:
+	default:
+		logrus.Warnf("Dropping event %s for %s, queue is full", eventType, subject)
+	}
+}
+
+// send delivers queued events until the context is done
+func send(ctx context.Context) {
+	client := &http.Client{Timeout: timeout}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-queue:
+			url := sinkURL()
+			if url == "" {
+				continue
+			}
+			if err := post(ctx, client, url, event); err != nil {
+				logrus.Warnf("Failed to send event %s for %s to %s: %v", event.Type, event.Subject, url, err)
+			}
+		}
+	}
+}
+
+func post(ctx context.Context, client *http.Client, url string, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func sinkURL() string {
+	return config.Get().EventSinkURL
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"sync"
 
+	"github.com/rancher/fleet/pkg/cost"
 	"github.com/rancher/fleet/pkg/version"
 
 	corev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
@@ -59,6 +60,44 @@ type Config struct {
 	APIServerCA                     []byte            `json:"apiServerCA,omitempty"`
 	Bootstrap                       Bootstrap         `json:"bootstrap,omitempty"`
 	IgnoreClusterRegistrationLabels bool              `json:"ignoreClusterRegistrationLabels,omitempty"`
+
+	// EventSinkURL is the HTTP endpoint CloudEvents for state changes are
+	// sent to, no events are sent if empty
+	EventSinkURL string `json:"eventSinkURL,omitempty"`
+
+	// BundleRevisionHistoryLimit is the number of bundle revisions kept
+	// per bundle, no revisions are recorded if zero
+	BundleRevisionHistoryLimit int `json:"bundleRevisionHistoryLimit,omitempty"`
+	// BundleRevisionRetention determines how long the revisions of a
+	// deleted bundle are kept, defaults to 168h
+	BundleRevisionRetention metav1.Duration `json:"bundleRevisionRetention,omitempty"`
+
+	// WebhookReceiverURL is the public URL of the webhook receiver, i.e.
+	// gitjob's or fleet controller's, used when registering webhooks for
+	// GitRepos
+	WebhookReceiverURL string `json:"webhookReceiverURL,omitempty"`
+
+	// GitSyncConcurrency is the number of GitRepos, whose image updates
+	// are cloned and pushed at the same time, defaults to 4
+	GitSyncConcurrency int `json:"gitSyncConcurrency,omitempty"`
+
+	// MaxNewBundleDeployments is the number of BundleDeployments created
+	// per bundle at once, unless the bundle's rollout strategy sets it,
+	// defaults to 50
+	MaxNewBundleDeployments int `json:"maxNewBundleDeployments,omitempty"`
+
+	// CostPriceSheet contains the prices used to estimate the monthly
+	// cost of bundles, no costs are estimated if empty
+	CostPriceSheet *cost.PriceSheet `json:"costPriceSheet,omitempty"`
+
+	// Controllers enables or disables subsystems of the fleet-controller
+	// by name, e.g. gitops or imagescan, flags take precedence
+	Controllers map[string]bool `json:"controllers,omitempty"`
+
+	// WorkspaceWeights weights the share of controller workers each
+	// workspace gets, when several workspaces have work queued. Workspaces
+	// not listed have a weight of 1.
+	WorkspaceWeights map[string]int `json:"workspaceWeights,omitempty"`
 }
 
 type Bootstrap struct {
@@ -0,0 +1,282 @@
+// Package apideprecation stores a report of the APIs, which are used by the bundle deployments of each cluster and removed in a Kubernetes version, in a config map of each workspace. (fleetcontroller)
+//
+// The report lists the upgrade blockers of each cluster, so upgrades of
+// many clusters can be planned, before the APIs are migrated.
+package apideprecation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/helmdeployer"
+	"github.com/rancher/fleet/pkg/kubeapis"
+	"github.com/rancher/fleet/pkg/manifest"
+	"github.com/rancher/fleet/pkg/name"
+
+	"github.com/rancher/wrangler/pkg/apply"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/kv"
+	"github.com/rancher/wrangler/pkg/relatedresource"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// ConfigMapName is the name of the config map in each workspace,
+	// which contains the report
+	ConfigMapName = "fleet-api-deprecations"
+	// JSONKey is the config map key of the report in JSON
+	JSONKey = "report.json"
+
+	// maxUsedCacheSize limits the number of rendered deployments, whose
+	// used APIs are cached
+	maxUsedCacheSize = 1000
+)
+
+// Report lists the removed APIs used by the clusters of a workspace
+type Report struct {
+	Clusters []ClusterReport `json:"clusters"`
+}
+
+// ClusterReport lists the removed APIs used by the bundle deployments of a
+// cluster
+type ClusterReport struct {
+	Name              string `json:"name"`
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// BlockedUpgrade is the lowest Kubernetes minor version above the
+	// cluster's version, which removes APIs used by the cluster. The
+	// cluster can't be upgraded to it, until they are migrated.
+	BlockedUpgrade string   `json:"blockedUpgrade,omitempty"`
+	APIs           []APIUse `json:"apis"`
+}
+
+// APIUse is a removed API used by a bundle
+type APIUse struct {
+	Bundle      string `json:"bundle"`
+	API         string `json:"api"`
+	RemovedIn   string `json:"removedIn"`
+	Replacement string `json:"replacement,omitempty"`
+	// Removed is true, if the API was removed in the cluster's version
+	// already
+	Removed bool `json:"removed,omitempty"`
+}
+
+type handler struct {
+	apply             apply.Apply
+	clusters          fleetcontrollers.ClusterCache
+	bundleDeployments fleetcontrollers.BundleDeploymentCache
+	manifests         manifest.Lookup
+
+	lock sync.Mutex
+	// used caches the APIs used by deployments, by deployment ID and
+	// cluster capabilities
+	used map[string][]string
+}
+
+func Register(ctx context.Context,
+	apply apply.Apply,
+	namespaces corecontrollers.NamespaceController,
+	clusters fleetcontrollers.ClusterController,
+	bundleDeployments fleetcontrollers.BundleDeploymentController,
+	contents fleetcontrollers.ContentController,
+) {
+	h := &handler{
+		apply:             apply.WithSetID("fleet-api-deprecations"),
+		clusters:          clusters.Cache(),
+		bundleDeployments: bundleDeployments.Cache(),
+		manifests:         manifest.NewLookup(contents),
+	}
+
+	namespaces.OnChange(ctx, "api-deprecations", h.OnNamespace)
+	relatedresource.WatchClusterScoped(ctx, "api-deprecations-resolver", resolveNamespace, namespaces, clusters, bundleDeployments)
+}
+
+// resolveNamespace returns the workspace of the cluster or bundle
+// deployment
+func resolveNamespace(namespace, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+	switch o := obj.(type) {
+	case *fleet.Cluster:
+		return []relatedresource.Key{{Name: namespace}}, nil
+	case *fleet.BundleDeployment:
+		if ns := o.Labels[fleet.ClusterNamespaceLabel]; ns != "" {
+			return []relatedresource.Key{{Name: ns}}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (h *handler) OnNamespace(key string, namespace *corev1.Namespace) (*corev1.Namespace, error) {
+	if namespace == nil || namespace.DeletionTimestamp != nil {
+		return namespace, nil
+	}
+
+	clusters, err := h.clusters.List(namespace.Name, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+
+	var objs []runtime.Object
+	if len(clusters) > 0 {
+		report := Report{Clusters: []ClusterReport{}}
+		for _, cluster := range clusters {
+			used, err := h.clusterUsedAPIs(cluster)
+			if err != nil {
+				return nil, err
+			}
+			report.Clusters = append(report.Clusters, clusterReport(cluster, used))
+		}
+		cm, err := configMap(namespace.Name, report)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, cm)
+	}
+
+	return namespace, h.apply.
+		WithOwner(namespace).
+		ApplyObjects(objs...)
+}
+
+// clusterUsedAPIs returns the APIs used by the bundles deployed to the
+// cluster, by bundle name. Deployments, which are rendered by the agent or
+// fail to render, are left out.
+func (h *handler) clusterUsedAPIs(cluster *fleet.Cluster) (map[string][]string, error) {
+	bds, err := h.bundleDeployments.List("", labels.SelectorFromSet(labels.Set{
+		fleet.ClusterNamespaceLabel: cluster.Namespace,
+		fleet.ClusterLabel:          name.LabelValue(cluster.Name),
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string][]string{}
+	for _, bd := range bds {
+		if bd.Spec.DeploymentID == "" || (bd.Spec.Options.Helm != nil && bd.Spec.Options.Helm.AgentRendering) {
+			continue
+		}
+		used, err := h.usedAPIs(bd, cluster)
+		if err != nil {
+			logrus.Debugf("Skipping API deprecations of bundle deployment %s/%s: %v", bd.Namespace, bd.Name, err)
+			continue
+		}
+		result[fleet.DeploymentBundleName(bd)] = used
+	}
+	return result, nil
+}
+
+// usedAPIs renders the bundle deployment against the cluster's capabilities
+// and returns the APIs used by its resources
+func (h *handler) usedAPIs(bd *fleet.BundleDeployment, cluster *fleet.Cluster) ([]string, error) {
+	agent := cluster.Status.Agent
+	key := fmt.Sprintf("%s/%x", bd.Spec.DeploymentID, sha256.Sum256([]byte(agent.KubernetesVersion+strings.Join(agent.APIVersions, ","))))
+
+	h.lock.Lock()
+	used, ok := h.used[key]
+	h.lock.Unlock()
+	if ok {
+		return used, nil
+	}
+
+	manifestID, _ := kv.Split(bd.Spec.DeploymentID, ":")
+	m, err := h.manifests.Get(manifestID)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := helmdeployer.TemplateBundleDeployment(bd, m, cluster)
+	if err != nil {
+		return nil, err
+	}
+	used = kubeapis.Used(objs)
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.used == nil || len(h.used) >= maxUsedCacheSize {
+		h.used = map[string][]string{}
+	}
+	h.used[key] = used
+	return used, nil
+}
+
+// clusterReport lists the removed APIs among the APIs used by each bundle
+// on the cluster (pure function)
+func clusterReport(cluster *fleet.Cluster, used map[string][]string) ClusterReport {
+	report := ClusterReport{
+		Name:              cluster.Name,
+		KubernetesVersion: cluster.Status.Agent.KubernetesVersion,
+		APIs:              []APIUse{},
+	}
+	// nil, if the cluster didn't report a valid version
+	version, _ := semver.NewVersion(report.KubernetesVersion)
+
+	bundles := make([]string, 0, len(used))
+	for bundle := range used {
+		bundles = append(bundles, bundle)
+	}
+	sort.Strings(bundles)
+
+	for _, bundle := range bundles {
+		for _, r := range kubeapis.Removals(used[bundle]) {
+			use := APIUse{
+				Bundle:      bundle,
+				API:         r.API,
+				RemovedIn:   r.RemovedIn,
+				Replacement: r.Replacement,
+			}
+			if version != nil && !kubeapis.Before(version, r.RemovedIn) {
+				use.Removed = true
+			} else if report.BlockedUpgrade == "" || lower(r.RemovedIn, report.BlockedUpgrade) {
+				report.BlockedUpgrade = r.RemovedIn
+			}
+			report.APIs = append(report.APIs, use)
+		}
+	}
+	if version == nil {
+		// the upgrade is unknown without the cluster's version
+		report.BlockedUpgrade = ""
+	}
+	return report
+}
+
+// lower returns true, if the minor version a precedes b
+func lower(a, b string) bool {
+	v, err := semver.NewVersion(a)
+	if err != nil {
+		return false
+	}
+	return kubeapis.Before(v, b)
+}
+
+func configMap(namespace string, report Report) (*corev1.ConfigMap, error) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				fleet.ManagedLabel: "true",
+			},
+		},
+		Data: map[string]string{
+			JSONKey: string(data),
+		},
+	}, nil
+}
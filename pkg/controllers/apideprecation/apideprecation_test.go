@@ -0,0 +1,90 @@
+package apideprecation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/name"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type fakeBundleDeploymentCache struct {
+	fleetcontrollers.BundleDeploymentCache
+	selector labels.Selector
+}
+
+func (f *fakeBundleDeploymentCache) List(namespace string, selector labels.Selector) ([]*fleet.BundleDeployment, error) {
+	f.selector = selector
+	return nil, nil
+}
+
+func TestClusterUsedAPIsLongClusterName(t *testing.T) {
+	bds := &fakeBundleDeploymentCache{}
+	h := &handler{bundleDeployments: bds}
+
+	long := strings.Repeat("c", 70)
+	if _, err := h.clusterUsedAPIs(&fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: long}}); err != nil {
+		t.Fatal(err)
+	}
+	if !bds.selector.Matches(labels.Set{fleet.ClusterNamespaceLabel: "fleet-default", fleet.ClusterLabel: name.LabelValue(long)}) {
+		t.Errorf("selector %s does not match the cluster's label value", bds.selector)
+	}
+}
+
+func TestClusterReport(t *testing.T) {
+	cluster := func(version string) *fleet.Cluster {
+		c := &fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "downstream"}}
+		c.Status.Agent.KubernetesVersion = version
+		return c
+	}
+	used := map[string][]string{
+		"monitoring": {"apps/v1/Deployment", "flowcontrol.apiserver.k8s.io/v1beta2/FlowSchema"},
+		"cron":       {"batch/v1beta1/CronJob", "autoscaling/v2beta2/HorizontalPodAutoscaler"},
+	}
+	apis := []APIUse{
+		{Bundle: "cron", API: "batch/v1beta1/CronJob", RemovedIn: "1.25", Replacement: "batch/v1"},
+		{Bundle: "cron", API: "autoscaling/v2beta2/HorizontalPodAutoscaler", RemovedIn: "1.26", Replacement: "autoscaling/v2"},
+		{Bundle: "monitoring", API: "flowcontrol.apiserver.k8s.io/v1beta2/FlowSchema", RemovedIn: "1.29", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	}
+
+	report := clusterReport(cluster("v1.24.9+k3s1"), used)
+	if report.BlockedUpgrade != "1.25" || !reflect.DeepEqual(report.APIs, apis) {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	report = clusterReport(cluster("v1.25.3"), used)
+	if report.BlockedUpgrade != "1.26" || !report.APIs[0].Removed || report.APIs[1].Removed || report.APIs[2].Removed {
+		t.Errorf("expected the CronJob API to be removed and 1.26 to be blocked, got %+v", report)
+	}
+
+	report = clusterReport(cluster(""), used)
+	if report.BlockedUpgrade != "" || len(report.APIs) != 3 || report.APIs[0].Removed {
+		t.Errorf("expected no blocked upgrade without a version, got %+v", report)
+	}
+
+	report = clusterReport(cluster("v1.29.0"), map[string][]string{"app": {"apps/v1/Deployment"}})
+	if report.BlockedUpgrade != "" || len(report.APIs) != 0 {
+		t.Errorf("expected an empty report, got %+v", report)
+	}
+}
+
+func TestResolveNamespace(t *testing.T) {
+	keys, _ := resolveNamespace("fleet-default", "downstream", &fleet.Cluster{})
+	if len(keys) != 1 || keys[0].Name != "fleet-default" {
+		t.Errorf("expected the cluster's namespace, got %v", keys)
+	}
+
+	bd := &fleet.BundleDeployment{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "cluster-fleet-default-downstream",
+		Labels:    map[string]string{fleet.ClusterNamespaceLabel: "fleet-default"},
+	}}
+	keys, _ = resolveNamespace(bd.Namespace, "app", bd)
+	if len(keys) != 1 || keys[0].Name != "fleet-default" {
+		t.Errorf("expected the workspace of the bundle deployment, got %v", keys)
+	}
+}
@@ -0,0 +1,229 @@
+package bundle
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/cost"
+	"github.com/rancher/fleet/pkg/helmdeployer"
+	"github.com/rancher/fleet/pkg/kubeapis"
+	"github.com/rancher/fleet/pkg/manifest"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+
+	"k8s.io/utils/lru"
+)
+
+// maxRenderCacheSize limits the number of rendered deployments, whose
+// results are cached
+const maxRenderCacheSize = 1000
+
+// renderResult is what the controller needs from a rendered deployment
+type renderResult struct {
+	// apis are the APIs used by the deployment
+	apis []string
+	// workload are the resource requests of the deployment, unless
+	// workloadErr is set
+	workload    cost.Workload
+	workloadErr error
+	// recreate lists the changes to immutable fields between the deployed
+	// and the staged content
+	recreate []string
+}
+
+// renderCache caches results of rendering deployments, like the APIs used
+// by a deployment when rendered with a cluster's capabilities, so unchanged
+// deployments are not rendered again. The least recently used results are
+// evicted.
+type renderCache struct {
+	once    sync.Once
+	results *lru.Cache
+}
+
+func (c *renderCache) cache() *lru.Cache {
+	c.once.Do(func() {
+		c.results = lru.New(maxRenderCacheSize)
+	})
+	return c.results
+}
+
+func (c *renderCache) get(key string) (*renderResult, bool) {
+	result, ok := c.cache().Get(key)
+	if !ok {
+		return nil, false
+	}
+	return result.(*renderResult), true
+}
+
+func (c *renderCache) reset() {
+	c.cache().Clear()
+}
+
+func (c *renderCache) set(key string, result *renderResult) {
+	c.cache().Add(key, result)
+}
+
+// render renders the target's deployment with the capabilities of its
+// cluster, unless the result is cached
+func (h *handler) render(bundle *fleet.Bundle, manifest *manifest.Manifest, t *target.Target) (*renderResult, error) {
+	key := t.DeploymentID + "/" + capabilitiesKey(t.Cluster)
+	if result, ok := h.renders.get(key); ok {
+		return result, nil
+	}
+
+	objs, err := helmdeployer.TemplateWithCapabilities(bundle.Name, manifest, t.Options, helmdeployer.ClusterCapabilities(t.Cluster))
+	if err != nil {
+		return nil, err
+	}
+	result := &renderResult{apis: kubeapis.Used(objs)}
+	result.workload, result.workloadErr = cost.ResourceRequests(objs)
+	h.renders.set(key, result)
+	return result, nil
+}
+
+// setMissingAPIs checks the targets, which are about to be updated, against
+// the APIs and Kubernetes version reported by their cluster's agent and
+// records missing APIs on the target. APIs removed in the cluster's version
+// are missing, even if the agent didn't report its APIs. Clusters which
+// don't report either are not checked.
+func (h *handler) setMissingAPIs(bundle *fleet.Bundle, manifest *manifest.Manifest, targets []*target.Target) {
+	for _, t := range targets {
+		if t.Cluster == nil {
+			continue
+		}
+		agent := t.Cluster.Status.Agent
+		if len(agent.APIVersions) == 0 && agent.KubernetesVersion == "" {
+			continue
+		}
+		if t.Deployment != nil && t.Deployment.Spec.DeploymentID == t.DeploymentID {
+			continue
+		}
+		if t.Options.Helm != nil && t.Options.Helm.AgentRendering {
+			continue
+		}
+
+		rendered, err := h.render(bundle, manifest, t)
+		if err != nil {
+			// the agent will report the rendering error
+			logrus.Debugf("Skipping API check for bundle %s/%s on cluster %s/%s: %v", bundle.Namespace, bundle.Name, t.Cluster.Namespace, t.Cluster.Name, err)
+			continue
+		}
+		used := rendered.apis
+
+		t.MissingAPIs = nil
+		if len(agent.APIVersions) > 0 {
+			t.MissingAPIs = missingAPIs(used, agent.APIVersions)
+		}
+		t.RemovedAPIs = kubeapis.Removed(used, agent.KubernetesVersion)
+		if len(t.RemovedAPIs) > 0 {
+			missing := map[string]bool{}
+			for _, api := range t.MissingAPIs {
+				missing[api] = true
+			}
+			for _, r := range t.RemovedAPIs {
+				if !missing[r.API] {
+					t.MissingAPIs = append(t.MissingAPIs, r.API)
+				}
+			}
+			sort.Strings(t.MissingAPIs)
+		}
+	}
+}
+
+// capabilitiesKey identifies the capabilities reported by the cluster's
+// agent, which the rendered content of a deployment depends on.
+func capabilitiesKey(cluster *fleet.Cluster) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(cluster.Status.Agent.KubernetesVersion+strings.Join(cluster.Status.Agent.APIVersions, ","))))
+}
+
+// missingAPIs returns the used APIs, which are not in the available API
+// versions. The available list contains "group/version" and
+// "group/version/kind" entries, as reported by the agent.
+func missingAPIs(used, available []string) []string {
+	apis := map[string]bool{}
+	for _, api := range available {
+		apis[api] = true
+	}
+
+	var result []string
+	for _, api := range used {
+		if !apis[api] {
+			result = append(result, api)
+		}
+	}
+	return result
+}
+
+// describeAPIs adds the version and the replacement of removed APIs to the
+// missing APIs
+func describeAPIs(missing []string, removed []fleet.RemovedAPI) []string {
+	result := make([]string, 0, len(missing))
+	for _, api := range missing {
+		for _, r := range removed {
+			if r.API != api {
+				continue
+			}
+			if r.Replacement == "" {
+				api = fmt.Sprintf("%s (removed in %s)", api, r.RemovedIn)
+			} else {
+				api = fmt.Sprintf("%s (removed in %s, use %s)", api, r.RemovedIn, r.Replacement)
+			}
+			break
+		}
+		result = append(result, api)
+	}
+	return result
+}
+
+// setMissingAPIsStatus records the targets with missing APIs in the bundle
+// status and sets the MissingAPIs condition.
+func setMissingAPIsStatus(status *fleet.BundleStatus, targets []*target.Target) {
+	status.MissingAPIs = nil
+	var messages []string
+	for _, t := range targets {
+		if len(t.MissingAPIs) == 0 {
+			continue
+		}
+		cluster := t.Cluster.Namespace + "/" + t.Cluster.Name
+		status.MissingAPIs = append(status.MissingAPIs, fleet.TargetMissingAPIs{
+			Cluster: cluster,
+			APIs:    t.MissingAPIs,
+			Removed: t.RemovedAPIs,
+		})
+		messages = append(messages, fmt.Sprintf("%s: %s", cluster, strings.Join(describeAPIs(t.MissingAPIs, t.RemovedAPIs), ", ")))
+	}
+	sort.Slice(status.MissingAPIs, func(i, j int) bool {
+		return status.MissingAPIs[i].Cluster < status.MissingAPIs[j].Cluster
+	})
+	if len(status.MissingAPIs) > maxStatusClusters {
+		status.MissingAPIs = status.MissingAPIs[:maxStatusClusters]
+	}
+	sort.Strings(messages)
+
+	c := condition.Cond(fleet.BundleConditionMissingAPIs)
+	if len(messages) == 0 {
+		if c.IsTrue(status) {
+			c.SetStatusBool(status, false)
+			c.Message(status, "")
+		}
+		return
+	}
+	c.SetStatusBool(status, true)
+	c.Message(status, "missing APIs: "+limitMessages(messages))
+}
+
+// limitMessages joins the messages of the first maxStatusClusters clusters
+// and counts the others.
+func limitMessages(messages []string) string {
+	if len(messages) <= maxStatusClusters {
+		return strings.Join(messages, "; ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(messages[:maxStatusClusters], "; "), len(messages)-maxStatusClusters)
+}
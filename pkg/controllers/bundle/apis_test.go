@@ -0,0 +1,88 @@
+package bundle
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/target"
+)
+
+func TestMissingAPIs(t *testing.T) {
+	used := []string{"apps/v1/Deployment", "monitoring.coreos.com/v1/ServiceMonitor", "v1/ConfigMap"}
+	available := []string{"apps/v1", "apps/v1/Deployment", "v1", "v1/ConfigMap"}
+
+	missing := missingAPIs(used, available)
+	if !reflect.DeepEqual(missing, []string{"monitoring.coreos.com/v1/ServiceMonitor"}) {
+		t.Errorf("unexpected missing APIs: %v", missing)
+	}
+
+	status := &fleet.BundleStatus{}
+	cluster := &fleet.Cluster{}
+	cluster.Namespace, cluster.Name = "fleet-default", "downstream"
+	setMissingAPIsStatus(status, []*target.Target{{Cluster: cluster, MissingAPIs: missing}})
+	if len(status.MissingAPIs) != 1 || status.MissingAPIs[0].Cluster != "fleet-default/downstream" {
+		t.Errorf("unexpected status: %v", status.MissingAPIs)
+	}
+	if len(status.Conditions) != 1 || status.Conditions[0].Type != fleet.BundleConditionMissingAPIs {
+		t.Errorf("expected MissingAPIs condition, got %v", status.Conditions)
+	}
+}
+
+func TestMissingAPIsStatusWithRemovedAPIs(t *testing.T) {
+	removed := []fleet.RemovedAPI{{API: "policy/v1beta1/PodDisruptionBudget", RemovedIn: "1.25", Replacement: "policy/v1"}}
+	cluster := &fleet.Cluster{}
+	cluster.Namespace, cluster.Name = "fleet-default", "downstream"
+
+	status := &fleet.BundleStatus{}
+	setMissingAPIsStatus(status, []*target.Target{{Cluster: cluster, MissingAPIs: []string{"policy/v1beta1/PodDisruptionBudget"}, RemovedAPIs: removed}})
+	if len(status.MissingAPIs) != 1 || !reflect.DeepEqual(status.MissingAPIs[0].Removed, removed) {
+		t.Errorf("expected removed APIs in status, got %v", status.MissingAPIs)
+	}
+	want := "missing APIs: fleet-default/downstream: policy/v1beta1/PodDisruptionBudget (removed in 1.25, use policy/v1)"
+	if len(status.Conditions) != 1 || status.Conditions[0].Message != want {
+		t.Errorf("expected condition message %q, got %v", want, status.Conditions)
+	}
+}
+
+func TestMissingAPIsStatusLimit(t *testing.T) {
+	var targets []*target.Target
+	for i := 0; i < maxStatusClusters+3; i++ {
+		cluster := &fleet.Cluster{}
+		cluster.Namespace, cluster.Name = "fleet-default", fmt.Sprintf("c-%02d", i)
+		targets = append(targets, &target.Target{Cluster: cluster, MissingAPIs: []string{"monitoring.coreos.com/v1/ServiceMonitor"}})
+	}
+
+	status := &fleet.BundleStatus{}
+	setMissingAPIsStatus(status, targets)
+	if len(status.MissingAPIs) != maxStatusClusters {
+		t.Errorf("expected %d clusters in status, got %d", maxStatusClusters, len(status.MissingAPIs))
+	}
+	msg := status.Conditions[0].Message
+	if !strings.HasSuffix(msg, " and 3 more") || strings.Contains(msg, "c-10") {
+		t.Errorf("expected message to be limited, got %q", msg)
+	}
+}
+
+func TestRenderCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := &renderCache{}
+	for i := 0; i < maxRenderCacheSize; i++ {
+		c.set(fmt.Sprintf("key-%d", i), &renderResult{})
+	}
+	if _, ok := c.get("key-0"); !ok {
+		t.Fatal("expected cached result")
+	}
+	c.set("new", &renderResult{})
+
+	if _, ok := c.get("key-0"); !ok {
+		t.Error("expected recently used result to be kept")
+	}
+	if _, ok := c.get("key-1"); ok {
+		t.Error("expected least recently used result to be evicted")
+	}
+	if _, ok := c.get("new"); !ok {
+		t.Error("expected new result to be cached")
+	}
+}
@@ -5,31 +5,48 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/bundlereader"
+	"github.com/rancher/fleet/pkg/cloudevents"
+	"github.com/rancher/fleet/pkg/config"
+	"github.com/rancher/fleet/pkg/durations"
+	"github.com/rancher/fleet/pkg/fairness"
 	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/helmdeployer"
 	"github.com/rancher/fleet/pkg/manifest"
 	"github.com/rancher/fleet/pkg/options"
+	"github.com/rancher/fleet/pkg/rollout"
 	"github.com/rancher/fleet/pkg/summary"
 	"github.com/rancher/fleet/pkg/target"
 
 	"github.com/rancher/wrangler/pkg/apply"
+	"github.com/rancher/wrangler/pkg/condition"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/pkg/generic"
+	"github.com/rancher/wrangler/pkg/kv"
 	"github.com/rancher/wrangler/pkg/relatedresource"
 
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 const (
-	maxNew = 50
+	defaultMaxNew = 50
+	// maxStatusClusters limits the number of clusters listed in the
+	// bundle's status, so it doesn't grow with the number of targets
+	maxStatusClusters = 10
 )
 
 type handler struct {
@@ -38,7 +55,11 @@ type handler struct {
 	images            fleetcontrollers.ImageScanController
 	bundles           fleetcontrollers.BundleController
 	bundleDeployments fleetcontrollers.BundleDeploymentController
+	clusters          fleetcontrollers.ClusterCache
 	mapper            meta.RESTMapper
+	renders           renderCache
+	manifests         manifest.Lookup
+	scheduler         *fairness.Scheduler
 }
 
 func Register(ctx context.Context,
@@ -50,14 +71,21 @@ func Register(ctx context.Context,
 	images fleetcontrollers.ImageScanController,
 	gitRepo fleetcontrollers.GitRepoCache,
 	bundleDeployments fleetcontrollers.BundleDeploymentController,
+	contents fleetcontrollers.ContentController,
+	secrets corecontrollers.SecretController,
+	configMaps corecontrollers.ConfigMapController,
+	scheduler *fairness.Scheduler,
 ) {
 	h := &handler{
 		mapper:            mapper,
 		targets:           targets,
 		bundles:           bundles,
 		bundleDeployments: bundleDeployments,
+		clusters:          clusters.Cache(),
 		images:            images,
 		gitRepo:           gitRepo,
+		manifests:         manifest.NewLookup(contents),
+		scheduler:         scheduler,
 	}
 
 	// A generating handler returns a list of objects to be created and
@@ -68,15 +96,65 @@ func Register(ctx context.Context,
 		apply.WithCacheTypes(bundleDeployments),
 		"Processed",
 		"bundle",
-		h.OnBundleChange,
+		h.fair(h.OnBundleChange),
 		&generic.GeneratingHandlerOptions{
 			AllowClusterScoped: true,
 		})
 
 	relatedresource.Watch(ctx, "app", h.resolveApp, bundles, bundleDeployments)
+	relatedresource.Watch(ctx, "bundle-values-from", h.resolveValuesFrom, bundles, secrets, configMaps)
+	relatedresource.Watch(ctx, "bundle-extends", h.resolveExtends, bundles, bundles)
 	clusters.OnChange(ctx, "app", h.OnClusterChange)
 	bundles.OnChange(ctx, "bundle-orphan", h.OnPurgeOrphaned)
-	images.OnChange(ctx, "imagescan-orphan", h.OnPurgeOrphanedImageScan)
+	bundles.OnChange(ctx, "bundle-ttl", h.OnExpired)
+	bundleDeployments.OnChange(ctx, "bundledeployment-post-delete", h.OnPostDelete)
+	if images != nil {
+		images.OnChange(ctx, "imagescan-orphan", h.OnPurgeOrphanedImageScan)
+	}
+}
+
+// fair defers bundles of workspaces, which already use their share of the
+// workers, so other workspaces are reconciled in between
+func (h *handler) fair(next fleetcontrollers.BundleGeneratingHandler) fleetcontrollers.BundleGeneratingHandler {
+	if h.scheduler == nil {
+		return next
+	}
+	return func(bundle *fleet.Bundle, status fleet.BundleStatus) ([]runtime.Object, fleet.BundleStatus, error) {
+		done, ok := h.scheduler.Admit(bundle.Namespace, bundle.Name)
+		if !ok {
+			h.bundles.EnqueueAfter(bundle.Namespace, bundle.Name, durations.WorkspaceDeferDelay)
+			// skip keeps the status and bundle deployments unchanged
+			return nil, status, generic.ErrSkip
+		}
+		defer done()
+		return next(bundle, status)
+	}
+}
+
+// resolveValuesFrom enqueues the bundles in the namespace of the changed
+// secret or config map, whose targets load helm values from the clusters'
+// namespace
+func (h *handler) resolveValuesFrom(namespace, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+	switch obj.(type) {
+	case *corev1.Secret, *corev1.ConfigMap:
+	default:
+		return nil, nil
+	}
+
+	bundles, err := h.bundles.Cache().List(namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var keys []relatedresource.Key
+	for _, bundle := range bundles {
+		for _, target := range bundle.Spec.Targets {
+			if len(target.ValuesFrom) > 0 {
+				keys = append(keys, relatedresource.Key{Namespace: bundle.Namespace, Name: bundle.Name})
+				break
+			}
+		}
+	}
+	return keys, nil
 }
 
 func (h *handler) resolveApp(_ string, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
@@ -137,13 +215,19 @@ func (h *handler) OnPurgeOrphaned(key string, bundle *fleet.Bundle) (*fleet.Bund
 	logrus.Debugf("OnPurgeOrphaned for bundle '%s' change, checking if gitrepo still exists", bundle.Name)
 
 	repo := bundle.Labels[fleet.RepoLabel]
-	if repo == "" {
+	if repo == "" || bundle.Labels[fleet.LocalBundleLabel] == "true" {
 		return nil, nil
 	}
 
 	_, err := h.gitRepo.Get(bundle.Namespace, repo)
 	if apierrors.IsNotFound(err) {
-		return nil, h.bundles.Delete(bundle.Namespace, bundle.Name, nil)
+		if err := h.bundles.Delete(bundle.Namespace, bundle.Name, nil); err != nil {
+			return nil, err
+		}
+		cloudevents.Emit(cloudevents.BundleOrphanPurged, "/fleet/bundles/"+key, key, map[string]interface{}{
+			"repo": repo,
+		})
+		return nil, nil
 	} else if err != nil {
 		return nil, err
 	}
@@ -173,15 +257,29 @@ func (h *handler) OnBundleChange(bundle *fleet.Bundle, status fleet.BundleStatus
 	logrus.Debugf("OnBundleChange for bundle '%s', checking targets, calculating changes, building objects", bundle.Name)
 	start := time.Now()
 
+	bundle, err := extend(bundle, h.bundles.Cache().Get)
+	if err != nil {
+		return nil, status, err
+	}
+
 	manifest, err := manifest.New(bundle.Spec.Resources)
 	if err != nil {
 		return nil, status, err
 	}
 
+	// recompute what is usually only computed when the bundle changes
+	resync := bundle.Annotations[fleet.ResyncAnnotation] != status.Resync
+	if resync {
+		logrus.Infof("Resyncing targets of bundle %s/%s, as requested by annotation %s", bundle.Namespace, bundle.Name, fleet.ResyncAnnotation)
+		h.renders.reset()
+		status.Resync = bundle.Annotations[fleet.ResyncAnnotation]
+	}
+
 	// this does not need to happen after merging the
 	// BundleDeploymentOptions, since 'fleet apply' already put the right
 	// resources into bundle.Spec.Resources
-	if _, err := h.targets.StoreManifest(manifest); err != nil {
+	manifestID, err := h.targets.StoreManifest(manifest)
+	if err != nil {
 		return nil, status, err
 	}
 
@@ -189,23 +287,91 @@ func (h *handler) OnBundleChange(bundle *fleet.Bundle, status fleet.BundleStatus
 	if err != nil {
 		return nil, status, err
 	}
+	matchedTargets = limitTargets(&status, bundle, matchedTargets)
 
-	if err := h.updateStatusAndTargets(&status, matchedTargets); err != nil {
+	h.setMissingAPIs(bundle, manifest, matchedTargets)
+	h.setRecreateRequired(bundle, manifest, matchedTargets)
+
+	redeploy, err := updateSchedule(&status, bundle, time.Now())
+	if err != nil {
+		updateDisplay(&status)
+		return nil, status, err
+	}
+	if s := status.Schedule; s != nil && s.Next != nil {
+		h.bundles.EnqueueAfter(bundle.Namespace, bundle.Name, time.Until(s.Next.Time))
+	}
+
+	wait, err := h.autoRollback(&status, bundle, matchedTargets, time.Now())
+	if err != nil {
+		updateDisplay(&status)
+		return nil, status, err
+	}
+	if wait > 0 {
+		h.bundles.EnqueueAfter(bundle.Namespace, bundle.Name, wait)
+	}
+
+	if wait := setClusterOffline(matchedTargets, time.Now()); wait > 0 {
+		h.bundles.EnqueueAfter(bundle.Namespace, bundle.Name, wait)
+	}
+	if wait := setPinned(matchedTargets, time.Now()); wait > 0 {
+		h.bundles.EnqueueAfter(bundle.Namespace, bundle.Name, wait)
+	}
+
+	wait, err = updateRolloutSteps(&status, matchedTargets, time.Now())
+	if err != nil {
 		updateDisplay(&status)
 		return nil, status, err
 	}
+	if wait > 0 {
+		h.bundles.EnqueueAfter(bundle.Namespace, bundle.Name, wait)
+	}
+
+	// the resources are needed before updating the targets, to hold
+	// updates which prune resources
+	previousKeys := status.ResourceKey
+	if resync || status.ObservedGeneration != bundle.Generation {
+		if err := setResourceKey(&status, bundle, manifest, targetCapabilities(matchedTargets), h.isNamespaced); err != nil {
+			updateDisplay(&status)
+			return nil, status, err
+		}
 
-	if status.ObservedGeneration != bundle.Generation {
-		if err := setResourceKey(&status, bundle, manifest, h.isNamespaced); err != nil {
+		chartDigests, err := bundlereader.ChartDigests(&bundle.Spec)
+		if err != nil {
 			updateDisplay(&status)
 			return nil, status, err
 		}
+		status.ChartDigests = keepLatestVersions(chartDigests, status.ChartDigests)
+	}
+
+	wait = updatePendingPrune(&status, bundle, manifestID, previousKeys, status.ObservedGeneration != bundle.Generation, time.Now())
+	if wait > 0 {
+		h.bundles.EnqueueAfter(bundle.Namespace, bundle.Name, wait)
+	}
+
+	if err := h.updateStatusAndTargets(&status, matchedTargets); err != nil {
+		updateDisplay(&status)
+		return nil, status, err
+	}
+	if wait, ok := nextPropagation(&status, matchedTargets, time.Now()); ok {
+		h.bundles.EnqueueAfter(bundle.Namespace, bundle.Name, wait)
 	}
 
 	summary.SetReadyConditions(&status, "Cluster", status.Summary)
+	setManualInterventionCondition(&status, matchedTargets)
+	setMissingAPIsStatus(&status, matchedTargets)
+	setRecreateRequiredStatus(&status, matchedTargets)
+	if wait := setWaitingForWindowStatus(&status, matchedTargets, time.Now()); wait > 0 {
+		h.bundles.EnqueueAfter(bundle.Namespace, bundle.Name, wait)
+	}
+	setRunOnceStatus(&status, matchedTargets)
+	setPinnedStatus(&status, matchedTargets)
+	setPrunePendingStatus(&status)
+	setNamespacesStatus(&status, matchedTargets)
+	h.setCost(&status, bundle, manifest, matchedTargets)
+	updateExpiry(&status, bundle, time.Now())
 	status.ObservedGeneration = bundle.Generation
 
-	objs := bundleDeployments(matchedTargets, bundle)
+	objs := bundleDeployments(matchedTargets, bundle, redeploy)
 
 	elapsed := time.Since(start)
 
@@ -224,39 +390,47 @@ func (h *handler) isNamespaced(gvk schema.GroupVersionKind) bool {
 	return mapping.Scope.Name() == meta.RESTScopeNameNamespace
 }
 
+// targetCapabilities returns the distinct capabilities reported by the
+// clusters of the targets. A nil entry stands for Helm's default
+// capabilities, which are used for clusters that did not report any. (pure function)
+func targetCapabilities(targets []*target.Target) []*chartutil.Capabilities {
+	var (
+		result []*chartutil.Capabilities
+		seen   = map[string]struct{}{}
+	)
+	for _, t := range targets {
+		agent := t.Cluster.Status.Agent
+		key := agent.KubernetesVersion + "/" + strings.Join(agent.APIVersions, ",")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, helmdeployer.ClusterCapabilities(t.Cluster))
+	}
+	if len(result) == 0 {
+		result = append(result, nil)
+	}
+	return result
+}
+
 // setResourceKey updates status.ResourceKey from the bundle, by running helm template (does not mutate bundle)
-func setResourceKey(status *fleet.BundleStatus, bundle *fleet.Bundle, manifest *manifest.Manifest, isNSed func(schema.GroupVersionKind) bool) error {
+// for each of the given capabilities
+func setResourceKey(status *fleet.BundleStatus, bundle *fleet.Bundle, manifest *manifest.Manifest, capabilities []*chartutil.Capabilities, isNSed func(schema.GroupVersionKind) bool) error {
 	seen := map[fleet.ResourceKey]struct{}{}
 
 	// iterate over the defined targets, from "targets.yaml", not the
 	// actually matched targets to avoid duplicates
 	for i := range bundle.Spec.Targets {
 		opts := options.Merge(bundle.Spec.BundleDeploymentOptions, bundle.Spec.Targets[i].BundleDeploymentOptions)
-		objs, err := helmdeployer.Template(bundle.Name, manifest, opts)
-		if err != nil {
-			logrus.Infof("While calculating status.ResourceKey, error running helm template for bundle %s with target options from %s: %v", bundle.Name, bundle.Spec.Targets[i].Name, err)
+		if opts.Helm != nil && opts.Helm.AgentRendering {
+			// chart can only be rendered against the downstream cluster
+			logrus.Debugf("Skipping status.ResourceKey for bundle %s with target options from %s, rendering happens on the agent", bundle.Name, bundle.Spec.Targets[i].Name)
 			continue
 		}
-
-		for _, obj := range objs {
-			m, err := meta.Accessor(obj)
-			if err != nil {
-				return err
-			}
-			key := fleet.ResourceKey{
-				Namespace: m.GetNamespace(),
-				Name:      m.GetName(),
+		for _, caps := range capabilities {
+			if err := addResourceKeys(seen, bundle, manifest, opts, caps, isNSed); err != nil {
+				logrus.Infof("While calculating status.ResourceKey, error running helm template for bundle %s with target options from %s: %v", bundle.Name, bundle.Spec.Targets[i].Name, err)
 			}
-			gvk := obj.GetObjectKind().GroupVersionKind()
-			if key.Namespace == "" && isNSed(gvk) {
-				if opts.DefaultNamespace == "" {
-					key.Namespace = "default"
-				} else {
-					key.Namespace = opts.DefaultNamespace
-				}
-			}
-			key.APIVersion, key.Kind = gvk.ToAPIVersionAndKind()
-			seen[key] = struct{}{}
 		}
 	}
 
@@ -286,24 +460,91 @@ func setResourceKey(status *fleet.BundleStatus, bundle *fleet.Bundle, manifest *
 	return nil
 }
 
+// addResourceKeys runs helm template with the given capabilities and adds the keys of the resulting objects to seen
+func addResourceKeys(seen map[fleet.ResourceKey]struct{}, bundle *fleet.Bundle, manifest *manifest.Manifest, opts fleet.BundleDeploymentOptions, capabilities *chartutil.Capabilities, isNSed func(schema.GroupVersionKind) bool) error {
+	objs, err := helmdeployer.TemplateWithCapabilities(bundle.Name, manifest, opts, capabilities)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objs {
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		key := fleet.ResourceKey{
+			Namespace: m.GetNamespace(),
+			Name:      m.GetName(),
+		}
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		if key.Namespace == "" && isNSed(gvk) {
+			if opts.DefaultNamespace == "" {
+				key.Namespace = "default"
+			} else {
+				key.Namespace = opts.DefaultNamespace
+			}
+		}
+		key.APIVersion, key.Kind = gvk.ToAPIVersionAndKind()
+		seen[key] = struct{}{}
+	}
+
+	return nil
+}
+
 // bundleDeployments copies BundleDeployments out of targets and into a new slice of runtime.Object
 // discarding Status, replacing DependsOn with the bundle's DependsOn (pure function) and replacing the labels with the
-// bundle's labels
-func bundleDeployments(targets []*target.Target, bundle *fleet.Bundle) (result []runtime.Object) {
+// bundle's labels. If redeploy is set, it's used as the redeploy annotation.
+// keepLatestVersions copies the latest chart versions, which are looked up
+// by the chart version controller, from the previous digests, so they
+// survive computing the digests of a new bundle generation.
+func keepLatestVersions(digests, previous []fleet.ChartDigest) []fleet.ChartDigest {
+	for i := range digests {
+		for _, p := range previous {
+			if p.Chart == digests[i].Chart && p.Repo == digests[i].Repo && p.Version == digests[i].Version {
+				digests[i].LatestVersion = p.LatestVersion
+				break
+			}
+		}
+	}
+	return digests
+}
+
+func bundleDeployments(targets []*target.Target, bundle *fleet.Bundle, redeploy string) (result []runtime.Object) {
 	for _, target := range targets {
 		if target.Deployment == nil {
 			continue
 		}
 		// NOTE we don't use the existing BundleDeployment, we discard annotations, status, etc
-		// copy labels from Bundle as they might have changed
+		// copy labels and propagated annotations from Bundle as they might have changed
 		dp := &fleet.BundleDeployment{
 			ObjectMeta: v1.ObjectMeta{
-				Name:      target.Deployment.Name,
-				Namespace: target.Deployment.Namespace,
-				Labels:    target.BundleDeploymentLabels(target.Cluster.Namespace, target.Cluster.Name),
+				Name:        target.Deployment.Name,
+				Namespace:   target.Deployment.Namespace,
+				Labels:      target.BundleDeploymentLabels(target.Cluster.Namespace, target.Cluster.Name),
+				Annotations: target.BundleDeploymentAnnotations(),
 			},
 			Spec: target.Deployment.Spec,
 		}
+		if redeploy != "" {
+			if dp.Annotations == nil {
+				dp.Annotations = map[string]string{}
+			}
+			dp.Annotations[fleet.RedeployAnnotation] = redeploy
+		}
+		if target.Pinned {
+			// keep the pin, until it expires and apply removes it
+			for _, k := range []string{fleet.PinnedUntilAnnotation, fleet.PinnedByAnnotation} {
+				if v, ok := target.Deployment.Annotations[k]; ok {
+					if dp.Annotations == nil {
+						dp.Annotations = map[string]string{}
+					}
+					dp.Annotations[k] = v
+				}
+			}
+		}
+		if dp.Spec.Options.PostDeleteHooks {
+			dp.Finalizers = []string{fleet.PostDeleteHooksFinalizer}
+		}
 		dp.Spec.Paused = target.IsPaused()
 		dp.Spec.DependsOn = bundle.Spec.DependsOn
 		result = append(result, dp)
@@ -317,7 +558,7 @@ func bundleDeployments(targets []*target.Target, bundle *fleet.Bundle) (result [
 // it updates Deployments in allTargets if they are out of sync (DeploymentID != StagedDeploymentID)
 func (h *handler) updateStatusAndTargets(status *fleet.BundleStatus, allTargets []*target.Target) (err error) {
 	// reset
-	status.MaxNew = maxNew
+	status.MaxNew = maxNew(allTargets)
 	status.Summary = fleet.BundleSummary{}
 	status.PartitionStatus = nil
 	status.Unavailable = 0
@@ -334,32 +575,43 @@ func (h *handler) updateStatusAndTargets(status *fleet.BundleStatus, allTargets
 		return err
 	}
 
+	if manifestID := stagedManifestID(allTargets); manifestID != "" && manifestID != status.PromotedManifestID && allDelayed(allTargets) {
+		// no target would start the propagation delay
+		setPromoted(status, manifestID, time.Now())
+	}
+
 	status.UnavailablePartitions = 0
 	status.MaxUnavailablePartitions, err = target.MaxUnavailablePartitions(partitions, allTargets)
 	if err != nil {
 		return err
 	}
 
+	// partitions wait for partitions of a lower order to be ready
+	gate := rollout.Gate{}
 	for _, partition := range partitions {
+		open := gate.Open(partition.Order)
 		for _, target := range partition.Targets {
-			if target.Deployment == nil {
+			if target.Deployment == nil && !target.ClusterOffline {
 				resetDeployment(target, status)
 			}
-			if target.Deployment != nil {
+			if target.Deployment != nil && !target.Pinned {
 				// NOTE merged options from targets.Targets() are set to be staged
 				target.Deployment.Spec.StagedOptions = target.Options
 				target.Deployment.Spec.StagedDeploymentID = target.DeploymentID
 			}
 		}
 
-		for _, currentTarget := range partition.Targets {
-			// NOTE this will propagate the merged options to the current deployment
-			updateTarget(currentTarget, status, &partition.Status)
+		if open {
+			for _, currentTarget := range partition.Targets {
+				// NOTE this will propagate the merged options to the current deployment
+				updateTarget(currentTarget, status, &partition.Status)
+			}
 		}
 
 		if target.UpdateStatusUnavailable(&partition.Status, partition.Targets) {
 			status.UnavailablePartitions++
 		}
+		gate.Ready(partition.Status.Unavailable == 0)
 
 		if status.UnavailablePartitions > status.MaxUnavailablePartitions {
 			break
@@ -373,12 +625,50 @@ func (h *handler) updateStatusAndTargets(status *fleet.BundleStatus, allTargets
 	return nil
 }
 
+// setManualInterventionCondition sets the bundle's ManualInterventionRequired
+// condition, if any of its deployments is blocked by a breaking CRD change.
+func setManualInterventionCondition(status *fleet.BundleStatus, targets []*target.Target) {
+	c := condition.Cond(fleet.BundleConditionManualIntervention)
+
+	var messages []string
+	for _, t := range targets {
+		if t.Deployment == nil || !c.IsTrue(t.Deployment) {
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("%s/%s: %s", t.Cluster.Namespace, t.Cluster.Name, c.GetMessage(t.Deployment)))
+	}
+
+	if len(messages) == 0 {
+		if c.IsTrue(status) {
+			c.SetStatusBool(status, false)
+			c.Message(status, "")
+		}
+		return
+	}
+
+	sort.Strings(messages)
+	c.SetStatusBool(status, true)
+	c.Message(status, strings.Join(messages, "; "))
+}
+
 // updateTarget will update DeploymentID and Options for the target to the
 // staging values, if it's in a deployable state
 func updateTarget(t *target.Target, status *fleet.BundleStatus, partitionStatus *fleet.PartitionStatus) {
 	if t.Deployment != nil &&
 		// Not Paused
 		!t.IsPaused() &&
+		// Control plane not upgrading
+		!t.ClusterUpgrading() &&
+		// Agent checked in recently, if required
+		!t.ClusterOffline &&
+		// Not pinned by an operator
+		!t.Pinned &&
+		// Not installed already, if it runs once
+		!t.RunOnceApplied() &&
+		// Cluster provides all APIs
+		len(t.MissingAPIs) == 0 &&
+		// Doesn't change immutable fields of stateful resources
+		len(t.RecreateRequired) == 0 &&
 		// Has been staged
 		t.Deployment.Spec.StagedDeploymentID != "" &&
 		// Is out of sync
@@ -386,7 +676,19 @@ func updateTarget(t *target.Target, status *fleet.BundleStatus, partitionStatus
 		// Global max unavailable not reached
 		(status.Unavailable < status.MaxUnavailable || target.IsUnavailable(t.Deployment)) &&
 		// Partition max unavailable not reached
-		(partitionStatus.Unavailable < partitionStatus.MaxUnavailable || target.IsUnavailable(t.Deployment)) {
+		(partitionStatus.Unavailable < partitionStatus.MaxUnavailable || target.IsUnavailable(t.Deployment)) &&
+		// Not rolled back
+		!rolledBack(t, status) &&
+		// Scheduled time reached
+		scheduleReleased(status, t.Bundle) &&
+		// Deployed elsewhere long enough
+		propagationDelayPassed(t, status, time.Now()) &&
+		// Inside maintenance windows
+		windowOpen(t, time.Now()) &&
+		// Rollout step started
+		!t.StepPending &&
+		// Removed resources confirmed
+		pruneConfirmed(t, status) {
 
 		if !target.IsUnavailable(t.Deployment) {
 			// If this was previously available, now increment unavailable count. "Upgrading" is treated as unavailable.
@@ -395,7 +697,136 @@ func updateTarget(t *target.Target, status *fleet.BundleStatus, partitionStatus
 		}
 		t.Deployment.Spec.DeploymentID = t.Deployment.Spec.StagedDeploymentID
 		t.Deployment.Spec.Options = t.Deployment.Spec.StagedOptions
+
+		if manifestID, _ := kv.Split(t.Deployment.Spec.DeploymentID, ":"); manifestID != status.PromotedManifestID {
+			setPromoted(status, manifestID, time.Now())
+		}
+	}
+}
+
+// setRunOnceStatus counts the clusters, which installed a runOnce bundle,
+// and lists the first of them
+func setRunOnceStatus(status *fleet.BundleStatus, targets []*target.Target) {
+	status.RunOnce = nil
+	for _, t := range targets {
+		if t.RunOnceApplied() {
+			status.RunOnce = append(status.RunOnce, fleet.RunOnceStatus{
+				Cluster:      t.Cluster.Namespace + "/" + t.Cluster.Name,
+				DeploymentID: t.Deployment.Status.AppliedDeploymentID,
+			})
+		}
+	}
+	sort.Slice(status.RunOnce, func(i, j int) bool {
+		return status.RunOnce[i].Cluster < status.RunOnce[j].Cluster
+	})
+	status.RunOnceClusters = len(status.RunOnce)
+	if len(status.RunOnce) > maxStatusClusters {
+		status.RunOnce = status.RunOnce[:maxStatusClusters]
+	}
+}
+
+// setNamespacesStatus counts the clusters, whose namespace was rendered
+// from a template, and lists the namespaces of the first of them
+func setNamespacesStatus(status *fleet.BundleStatus, targets []*target.Target) {
+	status.Namespaces = nil
+	for _, t := range targets {
+		if !t.NamespaceTemplated {
+			continue
+		}
+		namespace := t.Options.TargetNamespace
+		if namespace == "" {
+			namespace = t.Options.DefaultNamespace
+		}
+		status.Namespaces = append(status.Namespaces, fleet.ClusterNamespace{
+			Cluster:   t.Cluster.Namespace + "/" + t.Cluster.Name,
+			Namespace: namespace,
+		})
+	}
+	sort.Slice(status.Namespaces, func(i, j int) bool {
+		return status.Namespaces[i].Cluster < status.Namespaces[j].Cluster
+	})
+	status.NamespacesClusters = len(status.Namespaces)
+	if len(status.Namespaces) > maxStatusClusters {
+		status.Namespaces = status.Namespaces[:maxStatusClusters]
+	}
+}
+
+func setPromoted(status *fleet.BundleStatus, manifestID string, now time.Time) {
+	status.PromotedManifestID = manifestID
+	status.PromotedAt = &v1.Time{Time: now}
+}
+
+// propagationDelayPassed returns true, if the staged content of the target
+// was deployed elsewhere for at least the target's propagation delay
+func propagationDelayPassed(t *target.Target, status *fleet.BundleStatus, now time.Time) bool {
+	if t.PropagationDelay <= 0 {
+		return true
+	}
+	manifestID, _ := kv.Split(t.Deployment.Spec.StagedDeploymentID, ":")
+	return manifestID == status.PromotedManifestID &&
+		status.PromotedAt != nil &&
+		!now.Before(status.PromotedAt.Add(t.PropagationDelay))
+}
+
+// nextPropagation returns how long to wait until the propagation delay of
+// the next delayed target passed
+func nextPropagation(status *fleet.BundleStatus, targets []*target.Target, now time.Time) (time.Duration, bool) {
+	if status.PromotedAt == nil {
+		return 0, false
+	}
+	var (
+		next  time.Duration
+		found bool
+	)
+	for _, t := range targets {
+		if t.Deployment == nil || t.PropagationDelay <= 0 ||
+			t.Deployment.Spec.DeploymentID == t.Deployment.Spec.StagedDeploymentID {
+			continue
+		}
+		if manifestID, _ := kv.Split(t.Deployment.Spec.StagedDeploymentID, ":"); manifestID != status.PromotedManifestID {
+			continue
+		}
+		wait := status.PromotedAt.Add(t.PropagationDelay).Sub(now)
+		if wait <= 0 {
+			continue
+		}
+		if !found || wait < next {
+			next, found = wait, true
+		}
+	}
+	return next, found
+}
+
+// maxNew returns the number of bundle deployments, which are created at once
+func maxNew(targets []*target.Target) int {
+	if len(targets) > 0 {
+		if rollout := targets[0].Bundle.Spec.RolloutStrategy; rollout != nil && rollout.MaxNewPerReconcile > 0 {
+			return rollout.MaxNewPerReconcile
+		}
+	}
+	if n := config.Get().MaxNewBundleDeployments; n > 0 {
+		return n
+	}
+	return defaultMaxNew
+}
+
+func stagedManifestID(targets []*target.Target) string {
+	for _, t := range targets {
+		if t.DeploymentID != "" {
+			manifestID, _ := kv.Split(t.DeploymentID, ":")
+			return manifestID
+		}
+	}
+	return ""
+}
+
+func allDelayed(targets []*target.Target) bool {
+	for _, t := range targets {
+		if t.PropagationDelay <= 0 {
+			return false
+		}
 	}
+	return len(targets) > 0
 }
 
 // resetDeployment resets target's Deployment with a new one and updates status accordingly
@@ -0,0 +1,448 @@
+package bundle
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/config"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/name"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateTargetPropagationDelay(t *testing.T) {
+	newTarget := func(delay time.Duration) *target.Target {
+		return &target.Target{
+			Cluster:          &fleet.Cluster{},
+			Bundle:           &fleet.Bundle{},
+			DeploymentID:     "s-new:opts",
+			PropagationDelay: delay,
+			Deployment: &fleet.BundleDeployment{
+				Spec: fleet.BundleDeploymentSpec{DeploymentID: "s-old:opts", StagedDeploymentID: "s-new:opts"},
+			},
+		}
+	}
+	status := &fleet.BundleStatus{MaxUnavailable: 10}
+	partition := &fleet.PartitionStatus{MaxUnavailable: 10}
+
+	prod := newTarget(24 * time.Hour)
+	updateTarget(prod, status, partition)
+	if prod.Deployment.Spec.DeploymentID != "s-old:opts" {
+		t.Error("expected delayed target not to be updated before the content was deployed elsewhere")
+	}
+
+	staging := newTarget(0)
+	updateTarget(staging, status, partition)
+	if staging.Deployment.Spec.DeploymentID != "s-new:opts" {
+		t.Error("expected target without delay to be updated")
+	}
+	if status.PromotedManifestID != "s-new" || status.PromotedAt == nil {
+		t.Fatalf("expected promotion to be recorded, got %q %v", status.PromotedManifestID, status.PromotedAt)
+	}
+
+	wait, ok := nextPropagation(status, []*target.Target{prod, staging}, status.PromotedAt.Time)
+	if !ok || wait != 24*time.Hour {
+		t.Errorf("expected to wait 24h, got %v %v", wait, ok)
+	}
+
+	updateTarget(prod, status, partition)
+	if prod.Deployment.Spec.DeploymentID != "s-old:opts" {
+		t.Error("expected delayed target not to be updated before the delay passed")
+	}
+
+	status.PromotedAt = &v1.Time{Time: time.Now().Add(-25 * time.Hour)}
+	updateTarget(prod, status, partition)
+	if prod.Deployment.Spec.DeploymentID != "s-new:opts" {
+		t.Error("expected delayed target to be updated after the delay passed")
+	}
+	if _, ok := nextPropagation(status, []*target.Target{prod, staging}, time.Now()); ok {
+		t.Error("expected nothing left to wait for")
+	}
+}
+
+func TestAllDelayed(t *testing.T) {
+	if allDelayed(nil) {
+		t.Error("expected no targets not to be delayed")
+	}
+	if !allDelayed([]*target.Target{{PropagationDelay: time.Hour}}) {
+		t.Error("expected delayed targets")
+	}
+	if allDelayed([]*target.Target{{PropagationDelay: time.Hour}, {}}) {
+		t.Error("expected a target without delay")
+	}
+}
+
+func TestUpdateSchedule(t *testing.T) {
+	now := time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC)
+	bundle := &fleet.Bundle{
+		ObjectMeta: v1.ObjectMeta{Generation: 2},
+		Spec:       fleet.BundleSpec{DeployAt: "2026-03-01T02:00:00Z"},
+	}
+	status := &fleet.BundleStatus{}
+
+	if _, err := updateSchedule(status, bundle, now); err != nil {
+		t.Fatal(err)
+	}
+	if status.Schedule.Next == nil || !status.Schedule.Next.Equal(&v1.Time{Time: now.Add(time.Hour)}) {
+		t.Fatalf("expected next deployment in an hour, got %v", status.Schedule.Next)
+	}
+	if scheduleReleased(status, bundle) {
+		t.Error("expected bundle not to be released before the scheduled time")
+	}
+
+	redeploy, err := updateSchedule(status, bundle, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !scheduleReleased(status, bundle) || status.Schedule.Next != nil || redeploy != "" {
+		t.Errorf("expected bundle to be released once, got %+v %q", status.Schedule, redeploy)
+	}
+
+	// changes after the point in time are deployed right away
+	bundle.Generation = 3
+	if _, err := updateSchedule(status, bundle, now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if !scheduleReleased(status, bundle) {
+		t.Error("expected later changes to be released")
+	}
+
+	bundle.Spec.DeployAt = "0 2 * * *"
+	if _, err := updateSchedule(status, bundle, now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if scheduleReleased(status, bundle) {
+		t.Error("expected changed schedule to hold the bundle back")
+	}
+	redeploy, err = updateSchedule(status, bundle, now.Add(25*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !scheduleReleased(status, bundle) || redeploy != "2026-03-02T02:00:00Z" {
+		t.Errorf("expected cron schedule to release and redeploy the bundle, got %+v %q", status.Schedule, redeploy)
+	}
+
+	bundle.Spec.DeployAt = ""
+	if _, err := updateSchedule(status, bundle, now); err != nil || status.Schedule != nil || !scheduleReleased(status, bundle) {
+		t.Errorf("expected schedule to be removed, got %+v %v", status.Schedule, err)
+	}
+}
+
+func TestUpdateExpiry(t *testing.T) {
+	created := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	bundle := &fleet.Bundle{
+		ObjectMeta: v1.ObjectMeta{CreationTimestamp: v1.Time{Time: created}},
+		Spec:       fleet.BundleSpec{TTL: &v1.Duration{Duration: 2 * time.Hour}},
+	}
+	status := &fleet.BundleStatus{}
+
+	updateExpiry(status, bundle, created.Add(time.Hour))
+	if status.ExpiresAt == nil || !status.ExpiresAt.Time.Equal(created.Add(2*time.Hour)) {
+		t.Errorf("expected bundle to expire 2h after creation, got %v", status.ExpiresAt)
+	}
+
+	bundle.Spec.TTLAfter = fleet.TTLAfterReady
+	updateExpiry(status, bundle, created.Add(time.Hour))
+	if status.ExpiresAt != nil {
+		t.Errorf("expected no expiry before the bundle is ready, got %v", status.ExpiresAt)
+	}
+
+	condition.Cond(fleet.BundleConditionReady).SetStatusBool(status, true)
+	updateExpiry(status, bundle, created.Add(3*time.Hour))
+	condition.Cond(fleet.BundleConditionReady).SetStatusBool(status, false)
+	updateExpiry(status, bundle, created.Add(4*time.Hour))
+	if status.ExpiresAt == nil || !status.ExpiresAt.Time.Equal(created.Add(5*time.Hour)) {
+		t.Errorf("expected bundle to expire 2h after it was first ready, got %v", status.ExpiresAt)
+	}
+
+	bundle.Labels = map[string]string{fleet.RepoLabel: "repo"}
+	updateExpiry(status, bundle, created.Add(4*time.Hour))
+	if status.ExpiresAt != nil {
+		t.Errorf("expected no expiry for bundles of a GitRepo, got %v", status.ExpiresAt)
+	}
+
+	bundle.Labels = nil
+	bundle.Spec.TTL = nil
+	updateExpiry(status, bundle, created.Add(4*time.Hour))
+	if status.ExpiresAt != nil || status.ReadyAt != nil {
+		t.Errorf("expected expiry to be removed, got %v %v", status.ExpiresAt, status.ReadyAt)
+	}
+}
+
+func TestLimitTargets(t *testing.T) {
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{MaxTargetClusters: 2}}
+	targets := []*target.Target{
+		{Cluster: &fleet.Cluster{}, Deployment: &fleet.BundleDeployment{}},
+		{Cluster: &fleet.Cluster{}},
+		{Cluster: &fleet.Cluster{}},
+	}
+	status := &fleet.BundleStatus{}
+	tooMany := condition.Cond(fleet.BundleConditionTooManyTargets)
+
+	if result := limitTargets(status, bundle, targets); len(result) != 1 || result[0] != targets[0] {
+		t.Errorf("expected only the existing deployment to be kept, got %v", result)
+	}
+	if !tooMany.IsTrue(status) {
+		t.Error("expected TooManyTargets condition")
+	}
+
+	if result := limitTargets(status, bundle, targets[:2]); len(result) != 2 {
+		t.Errorf("expected all targets within the limit, got %v", result)
+	}
+	if tooMany.IsTrue(status) {
+		t.Error("expected TooManyTargets condition to be cleared")
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	installed := &fleet.BundleDeployment{
+		Spec: fleet.BundleDeploymentSpec{
+			DeploymentID:       "s-old:opts",
+			StagedDeploymentID: "s-new:opts",
+			Options:            fleet.BundleDeploymentOptions{RunOnce: true},
+		},
+		Status: fleet.BundleDeploymentStatus{AppliedDeploymentID: "s-old:opts"},
+	}
+	condition.Cond(fleet.BundleDeploymentConditionInstalled).SetStatusBool(installed, true)
+	pending := installed.DeepCopy()
+	pending.Status = fleet.BundleDeploymentStatus{}
+
+	targets := []*target.Target{
+		{Cluster: &fleet.Cluster{ObjectMeta: v1.ObjectMeta{Namespace: "fleet-default", Name: "b"}}, Bundle: &fleet.Bundle{}, Deployment: installed},
+		{Cluster: &fleet.Cluster{ObjectMeta: v1.ObjectMeta{Namespace: "fleet-default", Name: "a"}}, Bundle: &fleet.Bundle{}, Deployment: pending},
+	}
+	status := &fleet.BundleStatus{MaxUnavailable: 10}
+	partition := &fleet.PartitionStatus{MaxUnavailable: 10}
+	for _, target := range targets {
+		updateTarget(target, status, partition)
+	}
+	if installed.Spec.DeploymentID != "s-old:opts" {
+		t.Error("expected installed run-once deployment not to be updated")
+	}
+	if pending.Spec.DeploymentID != "s-new:opts" {
+		t.Error("expected run-once deployment, which was not installed, to be updated")
+	}
+
+	setRunOnceStatus(status, targets)
+	if len(status.RunOnce) != 1 || status.RunOnce[0].Cluster != "fleet-default/b" || status.RunOnce[0].DeploymentID != "s-old:opts" {
+		t.Errorf("unexpected run-once status %+v", status.RunOnce)
+	}
+
+	var many []*target.Target
+	for i := 0; i < maxStatusClusters+5; i++ {
+		many = append(many, &target.Target{
+			Cluster:    &fleet.Cluster{ObjectMeta: v1.ObjectMeta{Namespace: "fleet-default", Name: fmt.Sprintf("c-%02d", i)}},
+			Bundle:     &fleet.Bundle{},
+			Deployment: installed,
+		})
+	}
+	setRunOnceStatus(status, many)
+	if len(status.RunOnce) != maxStatusClusters || status.RunOnceClusters != maxStatusClusters+5 || status.RunOnce[0].Cluster != "fleet-default/c-00" {
+		t.Errorf("expected run-once status to be limited, got %d of %d clusters", len(status.RunOnce), status.RunOnceClusters)
+	}
+}
+
+func TestBundleDeploymentsPostDeleteFinalizer(t *testing.T) {
+	newTarget := func(hooks bool) *target.Target {
+		return &target.Target{
+			Cluster: &fleet.Cluster{},
+			Bundle:  &fleet.Bundle{},
+			Deployment: &fleet.BundleDeployment{
+				Spec: fleet.BundleDeploymentSpec{Options: fleet.BundleDeploymentOptions{PostDeleteHooks: hooks}},
+			},
+		}
+	}
+
+	objs := bundleDeployments([]*target.Target{newTarget(true), newTarget(false)}, &fleet.Bundle{}, "")
+	if len(objs) != 2 {
+		t.Fatalf("expected 2 bundle deployments, got %d", len(objs))
+	}
+	if bd := objs[0].(*fleet.BundleDeployment); !hasPostDeleteFinalizer(bd) {
+		t.Error("expected finalizer on deployment with post-delete hooks")
+	}
+	if bd := objs[1].(*fleet.BundleDeployment); hasPostDeleteFinalizer(bd) {
+		t.Error("expected no finalizer on deployment without post-delete hooks")
+	}
+}
+
+func TestUpdateTargetPaused(t *testing.T) {
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{Paused: true}}
+	tgt := &target.Target{
+		Cluster: &fleet.Cluster{},
+		Bundle:  bundle,
+		Deployment: &fleet.BundleDeployment{
+			Spec: fleet.BundleDeploymentSpec{DeploymentID: "s-old:opts", StagedDeploymentID: "s-new:opts"},
+		},
+	}
+	status := &fleet.BundleStatus{MaxUnavailable: 10}
+	partition := &fleet.PartitionStatus{MaxUnavailable: 10}
+
+	updateTarget(tgt, status, partition)
+	if tgt.Deployment.Spec.DeploymentID != "s-old:opts" {
+		t.Error("expected paused bundle not to promote staged content")
+	}
+
+	bundle.Spec.Paused = false
+	updateTarget(tgt, status, partition)
+	if tgt.Deployment.Spec.DeploymentID != "s-new:opts" {
+		t.Error("expected resumed bundle to promote the staged content")
+	}
+}
+
+func TestMaxNew(t *testing.T) {
+	if err := config.Set(&config.Config{MaxNewBundleDeployments: 200}); err != nil {
+		t.Fatal(err)
+	}
+	bundle := &fleet.Bundle{}
+	targets := []*target.Target{{Bundle: bundle}}
+	if n := maxNew(targets); n != 200 {
+		t.Errorf("expected controller default of 200, got %d", n)
+	}
+	bundle.Spec.RolloutStrategy = &fleet.RolloutStrategy{MaxNewPerReconcile: 500}
+	if n := maxNew(targets); n != 500 {
+		t.Errorf("expected bundle's maxNewPerReconcile of 500, got %d", n)
+	}
+}
+
+type fakeGitRepoCache struct {
+	fleetcontrollers.GitRepoCache
+}
+
+func (fakeGitRepoCache) Get(namespace, name string) (*fleet.GitRepo, error) {
+	return nil, apierrors.NewNotFound(fleet.Resource("gitrepos"), name)
+}
+
+type fakeBundles struct {
+	fleetcontrollers.BundleController
+	deleted []string
+}
+
+func (f *fakeBundles) Delete(namespace, name string, opts *v1.DeleteOptions) error {
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+func TestOnPurgeOrphaned(t *testing.T) {
+	if err := config.Set(&config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	bundles := &fakeBundles{}
+	h := &handler{gitRepo: fakeGitRepoCache{}, bundles: bundles}
+	bundle := func(name string, labels map[string]string) *fleet.Bundle {
+		return &fleet.Bundle{ObjectMeta: v1.ObjectMeta{Namespace: "fleet-local", Name: name, Labels: labels}}
+	}
+
+	if _, err := h.OnPurgeOrphaned("fleet-local/local", bundle("local", map[string]string{
+		fleet.RepoLabel:        "local-bundles",
+		fleet.LocalBundleLabel: "true",
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.OnPurgeOrphaned("fleet-local/orphan", bundle("orphan", map[string]string{
+		fleet.RepoLabel: "deleted",
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles.deleted) != 1 || bundles.deleted[0] != "orphan" {
+		t.Errorf("expected only the orphaned bundle to be deleted, got %v", bundles.deleted)
+	}
+}
+
+type fakeClusterCache struct {
+	fleetcontrollers.ClusterCache
+	names []string
+}
+
+func (f fakeClusterCache) Get(namespace, name string) (*fleet.Cluster, error) {
+	for _, n := range f.names {
+		if n == name {
+			return &fleet.Cluster{ObjectMeta: v1.ObjectMeta{Namespace: namespace, Name: name}}, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(fleet.Resource("clusters"), name)
+}
+
+func TestOnPostDeleteLongClusterName(t *testing.T) {
+	cluster := strings.Repeat("c", 70)
+	h := &handler{clusters: fakeClusterCache{names: []string{cluster}}}
+
+	bd := &fleet.BundleDeployment{ObjectMeta: v1.ObjectMeta{
+		Name:              "bd",
+		DeletionTimestamp: &v1.Time{Time: time.Now()},
+		Finalizers:        []string{fleet.PostDeleteHooksFinalizer},
+		Labels: map[string]string{
+			fleet.ClusterNamespaceLabel: "fleet-default",
+			fleet.ClusterLabel:          name.LabelValue(cluster),
+		},
+		Annotations: map[string]string{fleet.ClusterAnnotation: cluster},
+	}}
+	bd.Spec.Options.PostDeleteHooks = true
+
+	result, err := h.OnPostDelete("cluster-ns/bd", bd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasPostDeleteFinalizer(result) {
+		t.Error("expected the finalizer to be kept, until the agent ran the hooks")
+	}
+}
+
+func TestSetNamespacesStatus(t *testing.T) {
+	var targets []*target.Target
+	for i := 0; i < maxStatusClusters+2; i++ {
+		targets = append(targets, &target.Target{
+			Cluster:            &fleet.Cluster{ObjectMeta: v1.ObjectMeta{Namespace: "fleet-default", Name: fmt.Sprintf("c-%02d", i)}},
+			Options:            fleet.BundleDeploymentOptions{DefaultNamespace: fmt.Sprintf("app-c-%02d", i)},
+			NamespaceTemplated: i%2 == 0,
+		})
+	}
+	targets = append(targets, &target.Target{
+		Cluster:            &fleet.Cluster{ObjectMeta: v1.ObjectMeta{Namespace: "fleet-default", Name: "c-99"}},
+		Options:            fleet.BundleDeploymentOptions{DefaultNamespace: "app", TargetNamespace: "app-c-99"},
+		NamespaceTemplated: true,
+	})
+
+	status := &fleet.BundleStatus{}
+	setNamespacesStatus(status, targets)
+	if status.NamespacesClusters != 7 || len(status.Namespaces) != 7 {
+		t.Fatalf("expected 7 templated namespaces, got %+v", status.Namespaces)
+	}
+	if ns := status.Namespaces[6]; ns.Cluster != "fleet-default/c-99" || ns.Namespace != "app-c-99" {
+		t.Errorf("expected target namespace of c-99, got %+v", ns)
+	}
+
+	for _, t := range targets {
+		t.NamespaceTemplated = true
+	}
+	setNamespacesStatus(status, targets)
+	if status.NamespacesClusters != maxStatusClusters+3 || len(status.Namespaces) != maxStatusClusters {
+		t.Errorf("expected namespaces status to be limited, got %d of %d clusters", len(status.Namespaces), status.NamespacesClusters)
+	}
+}
+
+func TestKeepLatestVersions(t *testing.T) {
+	previous := []fleet.ChartDigest{
+		{Chart: "app", Repo: "https://charts.example.com", Version: "~1.2.0", ResolvedVersion: "1.2.0", LatestVersion: "1.2.3"},
+		{Chart: "db", Repo: "https://charts.example.com", Version: "~2.0.0", ResolvedVersion: "2.0.0", LatestVersion: "2.0.1"},
+	}
+	digests := []fleet.ChartDigest{
+		{Chart: "app", Repo: "https://charts.example.com", Version: "~1.2.0", ResolvedVersion: "1.2.3"},
+		{Chart: "db", Repo: "https://charts.example.com", Version: "~3.0.0", ResolvedVersion: "3.0.0"},
+	}
+
+	digests = keepLatestVersions(digests, previous)
+	if digests[0].LatestVersion != "1.2.3" {
+		t.Errorf("expected latest version to be kept, got %q", digests[0].LatestVersion)
+	}
+	if digests[1].LatestVersion != "" {
+		t.Errorf("expected no latest version for a changed version range, got %q", digests[1].LatestVersion)
+	}
+}
@@ -0,0 +1,107 @@
+package bundle
+
+import (
+	"sort"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/config"
+	"github.com/rancher/fleet/pkg/cost"
+	"github.com/rancher/fleet/pkg/manifest"
+	"github.com/rancher/fleet/pkg/target"
+)
+
+// maxCostClusters limits the number of clusters listed in the cost status,
+// so it doesn't grow with the number of targeted clusters
+const maxCostClusters = 10
+
+// setCost estimates the monthly cost of the content staged for each
+// target, so it can be reviewed before it is rolled out. The status holds
+// the totals over all clusters and the most expensive clusters. The cost is
+// removed from the status, if no price sheet is configured.
+func (h *handler) setCost(status *fleet.BundleStatus, bundle *fleet.Bundle, manifest *manifest.Manifest, targets []*target.Target) {
+	prices := config.Get().CostPriceSheet
+	if !prices.Enabled() {
+		status.Cost = nil
+		return
+	}
+
+	var (
+		total    float64
+		totals   cost.Requests
+		clusters []clusterCost
+	)
+	for _, t := range targets {
+		if t.Options.Helm != nil && t.Options.Helm.AgentRendering {
+			continue
+		}
+
+		rendered, err := h.render(bundle, manifest, t)
+		if err == nil {
+			err = rendered.workloadErr
+		}
+		if err != nil {
+			// the agent will report the rendering error
+			logrus.Debugf("Skipping cost estimation for bundle %s/%s on cluster %s/%s: %v", bundle.Namespace, bundle.Name, t.Cluster.Namespace, t.Cluster.Name, err)
+			continue
+		}
+
+		nodes := int64(t.Cluster.Status.Agent.ReadyNodes + t.Cluster.Status.Agent.NonReadyNodes)
+		if nodes == 0 {
+			nodes = 1
+		}
+		requests := rendered.workload.Total(nodes)
+		monthly := prices.Estimate(requests)
+		total += monthly
+		totals.Add(requests, 1)
+
+		clusters = append(clusters, clusterCost{
+			monthly: monthly,
+			ClusterCost: fleet.ClusterCost{
+				Cluster: t.Cluster.Namespace + "/" + t.Cluster.Name,
+				CPU:     requests.CPU.String(),
+				Memory:  requests.Memory.String(),
+				Storage: requests.Storage.String(),
+				Monthly: cost.Format(monthly),
+			},
+		})
+	}
+
+	status.Cost = &fleet.BundleCost{
+		Currency:     prices.Currency,
+		Monthly:      cost.Format(total),
+		CPU:          totals.CPU.String(),
+		Memory:       totals.Memory.String(),
+		Storage:      totals.Storage.String(),
+		ClusterCount: len(clusters),
+		Clusters:     topClusterCosts(clusters, maxCostClusters),
+	}
+}
+
+type clusterCost struct {
+	fleet.ClusterCost
+	monthly float64
+}
+
+// topClusterCosts returns the n most expensive clusters, sorted by name
+func topClusterCosts(clusters []clusterCost, n int) []fleet.ClusterCost {
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if clusters[i].monthly != clusters[j].monthly {
+			return clusters[i].monthly > clusters[j].monthly
+		}
+		return clusters[i].Cluster < clusters[j].Cluster
+	})
+	if len(clusters) > n {
+		clusters = clusters[:n]
+	}
+
+	var result []fleet.ClusterCost
+	for _, c := range clusters {
+		result = append(result, c.ClusterCost)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Cluster < result[j].Cluster
+	})
+	return result
+}
@@ -0,0 +1,33 @@
+package bundle
+
+import (
+	"fmt"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestTopClusterCosts(t *testing.T) {
+	var clusters []clusterCost
+	for i := 0; i < 5; i++ {
+		clusters = append(clusters, clusterCost{
+			monthly:     float64(i % 3),
+			ClusterCost: fleet.ClusterCost{Cluster: fmt.Sprintf("c-%d", i)},
+		})
+	}
+
+	top := topClusterCosts(clusters, 3)
+	if len(top) != 3 {
+		t.Fatalf("expected 3 clusters, got %v", top)
+	}
+	// c-2 costs 2, c-1 and c-4 cost 1
+	for i, name := range []string{"c-1", "c-2", "c-4"} {
+		if top[i].Cluster != name {
+			t.Errorf("expected cluster %s at %d, got %v", name, i, top)
+		}
+	}
+
+	if top := topClusterCosts(clusters[:2], 3); len(top) != 2 {
+		t.Errorf("expected all clusters, got %v", top)
+	}
+}
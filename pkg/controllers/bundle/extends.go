@@ -0,0 +1,158 @@
+package bundle
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/bundlereader"
+	"github.com/rancher/fleet/pkg/fleetyaml"
+	"github.com/rancher/fleet/pkg/manifest"
+	"github.com/rancher/fleet/pkg/options"
+	"github.com/rancher/fleet/pkg/rawyaml"
+
+	"github.com/rancher/wrangler/pkg/relatedresource"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// maxExtendsDepth limits how many base bundles a bundle can inherit from
+const maxExtendsDepth = 10
+
+// extend returns a copy of the bundle, which inherits from its base bundles,
+// or the bundle itself, if it doesn't extend another bundle.
+func extend(bundle *fleet.Bundle, get func(namespace, name string) (*fleet.Bundle, error)) (*fleet.Bundle, error) {
+	if bundle.Spec.Extends == "" {
+		return bundle, nil
+	}
+
+	seen := map[string]bool{bundle.Name: true}
+	var chain []*fleet.Bundle
+	for name := bundle.Spec.Extends; name != ""; {
+		if seen[name] {
+			return nil, fmt.Errorf("bundle %s/%s extends itself through base bundle %s", bundle.Namespace, bundle.Name, name)
+		}
+		if len(chain) >= maxExtendsDepth {
+			return nil, fmt.Errorf("bundle %s/%s extends more than %d base bundles", bundle.Namespace, bundle.Name, maxExtendsDepth)
+		}
+		base, err := get(bundle.Namespace, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get base bundle %s/%s: %w", bundle.Namespace, name, err)
+		}
+		seen[name] = true
+		chain = append(chain, base)
+		name = base.Spec.Extends
+	}
+
+	// merge from the root of the chain down to the bundle
+	spec := chain[len(chain)-1].Spec.DeepCopy()
+	for i := len(chain) - 2; i >= 0; i-- {
+		var err error
+		if spec, err = inherit(spec, &chain[i].Spec, chain[i+1].Name); err != nil {
+			return nil, err
+		}
+	}
+	spec, err := inherit(spec, &bundle.Spec, chain[0].Name)
+	if err != nil {
+		return nil, err
+	}
+
+	result := bundle.DeepCopy()
+	result.Spec = *spec
+	return result, nil
+}
+
+// inherit merges the spec over the spec of its base bundle
+func inherit(base, spec *fleet.BundleSpec, baseName string) (*fleet.BundleSpec, error) {
+	result := spec.DeepCopy()
+	result.BundleDeploymentOptions = options.Merge(base.BundleDeploymentOptions, spec.BundleDeploymentOptions)
+
+	resources, err := inheritResources(base, result, baseName)
+	if err != nil {
+		return nil, err
+	}
+	result.Resources = resources
+
+	if len(result.Targets) == 0 {
+		result.Targets = base.DeepCopy().Targets
+	}
+	if len(result.TargetRestrictions) == 0 {
+		result.TargetRestrictions = base.DeepCopy().TargetRestrictions
+	}
+	if result.RolloutStrategy == nil && base.RolloutStrategy != nil {
+		result.RolloutStrategy = base.RolloutStrategy.DeepCopy()
+	}
+	return result, nil
+}
+
+// inheritResources returns the resources of the base, which are not
+// replaced by a resource of the same name, followed by the spec's
+// resources. Base bundles have to contain raw manifests. If the spec
+// contains a chart, the base's manifests are added to the chart's raw YAML
+// files, which are deployed together with the chart.
+func inheritResources(base, spec *fleet.BundleSpec, baseName string) ([]fleet.BundleResource, error) {
+	baseManifest, err := manifest.New(base.Resources)
+	if err != nil {
+		return nil, err
+	}
+	if style := bundlereader.DetermineStyle(baseManifest, base.BundleDeploymentOptions); !style.IsRawYAML() {
+		return nil, fmt.Errorf("base bundle %s has to contain raw manifests, not a helm chart or kustomization", baseName)
+	}
+
+	m, err := manifest.New(spec.Resources)
+	if err != nil {
+		return nil, err
+	}
+	prefix := ""
+	if style := bundlereader.DetermineStyle(m, spec.BundleDeploymentOptions); style.ChartPath != "" {
+		prefix = filepath.Join(filepath.Dir(style.ChartPath), filepath.Base(rawyaml.YAMLPrefix)) + "/"
+	}
+
+	names := map[string]bool{}
+	for _, resource := range spec.Resources {
+		names[resource.Name] = true
+	}
+	var result []fleet.BundleResource
+	for _, resource := range base.Resources {
+		if prefix != "" && !isManifest(resource.Name) {
+			continue
+		}
+		resource.Name = prefix + resource.Name
+		if names[resource.Name] {
+			continue
+		}
+		result = append(result, resource)
+	}
+	return append(result, spec.Resources...), nil
+}
+
+// isManifest returns true for the files, which are deployed from raw YAML
+// bundles, without their fleet.yaml and overlays
+func isManifest(name string) bool {
+	if fleetyaml.IsFleetYaml(name) || strings.HasPrefix(name, "overlays/") {
+		return false
+	}
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml" || ext == ".json"
+}
+
+// resolveExtends enqueues the bundles, which extend the changed bundle
+func (h *handler) resolveExtends(namespace, name string, obj runtime.Object) ([]relatedresource.Key, error) {
+	if _, ok := obj.(*fleet.Bundle); !ok && obj != nil {
+		return nil, nil
+	}
+
+	bundles, err := h.bundles.Cache().List(namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var keys []relatedresource.Key
+	for _, bundle := range bundles {
+		if bundle.Spec.Extends == name {
+			keys = append(keys, relatedresource.Key{Namespace: bundle.Namespace, Name: bundle.Name})
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,96 @@
+package bundle
+
+import (
+	"fmt"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExtend(t *testing.T) {
+	newBundle := func(name, extends string, resources ...string) *fleet.Bundle {
+		b := &fleet.Bundle{
+			ObjectMeta: v1.ObjectMeta{Namespace: "fleet-default", Name: name},
+			Spec:       fleet.BundleSpec{Extends: extends},
+		}
+		for _, r := range resources {
+			b.Spec.Resources = append(b.Spec.Resources, fleet.BundleResource{Name: r, Content: name})
+		}
+		return b
+	}
+	bundles := map[string]*fleet.Bundle{}
+	get := func(namespace, name string) (*fleet.Bundle, error) {
+		if b, ok := bundles[name]; ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("bundle %s/%s not found", namespace, name)
+	}
+
+	root := newBundle("root", "", "quota.yaml", "netpol.yaml")
+	root.Spec.DefaultNamespace = "root"
+	root.Spec.Targets = []fleet.BundleTarget{{Name: "all", ClusterSelector: &v1.LabelSelector{}}}
+	base := newBundle("base", "root", "netpol.yaml", "README.md")
+	base.Spec.TargetNamespace = "base"
+	bundles["root"], bundles["base"] = root, base
+
+	app := newBundle("app", "base", "deployment.yaml")
+	result, err := extend(app, get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := map[string]string{}
+	for _, r := range result.Spec.Resources {
+		contents[r.Name] = r.Content
+	}
+	if len(contents) != 4 || contents["quota.yaml"] != "root" || contents["netpol.yaml"] != "base" || contents["deployment.yaml"] != "app" {
+		t.Errorf("unexpected resources %v", contents)
+	}
+	if result.Spec.DefaultNamespace != "root" || result.Spec.TargetNamespace != "base" {
+		t.Errorf("expected options to be inherited, got %q %q", result.Spec.DefaultNamespace, result.Spec.TargetNamespace)
+	}
+	if len(result.Spec.Targets) != 1 || result.Spec.Targets[0].Name != "all" {
+		t.Errorf("expected targets to be inherited, got %v", result.Spec.Targets)
+	}
+	if len(app.Spec.Resources) != 1 {
+		t.Error("expected bundle not to be modified")
+	}
+
+	// own targets and options override the base's
+	app.Spec.Targets = []fleet.BundleTarget{{Name: "prod"}}
+	app.Spec.DefaultNamespace = "app"
+	result, err = extend(app, get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Spec.Targets) != 1 || result.Spec.Targets[0].Name != "prod" || result.Spec.DefaultNamespace != "app" {
+		t.Errorf("expected targets and options of the bundle, got %v %q", result.Spec.Targets, result.Spec.DefaultNamespace)
+	}
+
+	// manifests of the base are added to the raw YAML of a chart
+	chart := newBundle("chart", "root", "chart/Chart.yaml", "chart/templates/deployment.yaml")
+	chart.Spec.Helm = &fleet.HelmOptions{Chart: "chart"}
+	result, err = extend(chart, get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := result.Spec.Resources[0]; r.Name != "chart/raw-yaml/quota.yaml" {
+		t.Errorf("expected base manifests in raw-yaml directory of chart, got %s", r.Name)
+	}
+
+	// charts can't be used as base
+	bundles["chart"] = chart
+	if _, err := extend(newBundle("app", "chart"), get); err == nil {
+		t.Error("expected error for chart base")
+	}
+
+	root.Spec.Extends = "app"
+	bundles["app"] = app
+	if _, err := extend(app, get); err == nil {
+		t.Error("expected error for cycle")
+	}
+	if _, err := extend(newBundle("app", "missing"), get); err == nil {
+		t.Error("expected error for missing base")
+	}
+}
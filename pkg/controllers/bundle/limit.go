@@ -0,0 +1,37 @@
+package bundle
+
+import (
+	"fmt"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+)
+
+// limitTargets drops the targets without a deployment, if more clusters
+// match than maxTargetClusters allows, and sets the TooManyTargets
+// condition. Existing deployments are kept, so a selector mistake does
+// not remove the bundle from the clusters it was deployed to. (pure function)
+func limitTargets(status *fleet.BundleStatus, bundle *fleet.Bundle, targets []*target.Target) []*target.Target {
+	c := condition.Cond(fleet.BundleConditionTooManyTargets)
+	max := bundle.Spec.MaxTargetClusters
+	if max <= 0 || len(targets) <= max {
+		if c.IsTrue(status) {
+			c.SetStatusBool(status, false)
+			c.Message(status, "")
+		}
+		return targets
+	}
+
+	c.SetStatusBool(status, true)
+	c.Message(status, fmt.Sprintf("%d clusters match, but maxTargetClusters is %d, no deployments are created", len(targets), max))
+
+	var result []*target.Target
+	for _, t := range targets {
+		if t.Deployment != nil {
+			result = append(result, t)
+		}
+	}
+	return result
+}
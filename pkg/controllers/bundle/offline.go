@@ -0,0 +1,33 @@
+package bundle
+
+import (
+	"time"
+
+	"github.com/rancher/fleet/pkg/cloudevents"
+	"github.com/rancher/fleet/pkg/target"
+)
+
+// setClusterOffline marks the targets, which require a ready cluster, whose
+// agent didn't check in recently. It returns how long to wait until the
+// next of the other clusters would be offline, or zero.
+func setClusterOffline(targets []*target.Target, now time.Time) time.Duration {
+	threshold := cloudevents.OfflineThreshold()
+
+	var wait time.Duration
+	for _, t := range targets {
+		t.ClusterOffline = false
+		if !t.ClusterReady || t.Cluster == nil {
+			continue
+		}
+		lastSeen := t.Cluster.Status.Agent.LastSeen
+		if lastSeen.IsZero() || now.Sub(lastSeen.Time) > threshold {
+			t.ClusterOffline = true
+			continue
+		}
+		// the cluster doesn't change, if its agent stops checking in
+		if w := threshold - now.Sub(lastSeen.Time) + time.Second; wait == 0 || w < wait {
+			wait = w
+		}
+	}
+	return wait
+}
@@ -0,0 +1,72 @@
+package bundle
+
+import (
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/config"
+	"github.com/rancher/fleet/pkg/target"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterOffline(t *testing.T) {
+	if err := config.Set(&config.Config{AgentCheckinInterval: v1.Duration{Duration: time.Minute}}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	newTarget := func(clusterReady bool, lastSeen time.Time) *target.Target {
+		cluster := &fleet.Cluster{ObjectMeta: v1.ObjectMeta{Namespace: "fleet-default", Name: "cluster"}}
+		cluster.Status.Agent.LastSeen = v1.Time{Time: lastSeen}
+		return &target.Target{
+			Cluster:      cluster,
+			Bundle:       &fleet.Bundle{},
+			DeploymentID: "s-new:opts",
+			ClusterReady: clusterReady,
+			Deployment: &fleet.BundleDeployment{
+				Spec: fleet.BundleDeploymentSpec{
+					DeploymentID:       "s-old:opts",
+					StagedDeploymentID: "s-new:opts",
+				},
+				Status: fleet.BundleDeploymentStatus{AppliedDeploymentID: "s-old:opts"},
+			},
+		}
+	}
+
+	online := newTarget(true, now.Add(-time.Minute))
+	offline := newTarget(true, now.Add(-time.Hour))
+	unregistered := newTarget(true, time.Time{})
+	unrequired := newTarget(false, now.Add(-time.Hour))
+	targets := []*target.Target{online, offline, unregistered, unrequired}
+
+	// three missed check-ins
+	if wait := setClusterOffline(targets, now); wait != 2*time.Minute+time.Second {
+		t.Errorf("expected to recheck, when the online cluster would be offline, got %s", wait)
+	}
+	if online.ClusterOffline || !offline.ClusterOffline || !unregistered.ClusterOffline || unrequired.ClusterOffline {
+		t.Errorf("unexpected offline targets: %v %v %v %v", online.ClusterOffline, offline.ClusterOffline, unregistered.ClusterOffline, unrequired.ClusterOffline)
+	}
+
+	// offline clusters are pending and don't count as unavailable
+	if n := target.Unavailable(targets); n != 2 {
+		t.Errorf("expected the online and the unrequired target to be unavailable, got %d", n)
+	}
+	summary := target.Summary([]*target.Target{offline})
+	if summary.Pending != 1 {
+		t.Errorf("expected offline cluster to be pending, got %+v", summary)
+	}
+
+	status := &fleet.BundleStatus{MaxUnavailable: 10}
+	partition := &fleet.PartitionStatus{MaxUnavailable: 10}
+	for _, tgt := range targets {
+		updateTarget(tgt, status, partition)
+	}
+	if offline.Deployment.Spec.DeploymentID != "s-old:opts" {
+		t.Error("expected deployment on offline cluster not to be updated")
+	}
+	if online.Deployment.Spec.DeploymentID != "s-new:opts" || unrequired.Deployment.Spec.DeploymentID != "s-new:opts" {
+		t.Error("expected deployments to be updated")
+	}
+}
@@ -0,0 +1,94 @@
+package bundle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// pinnedUntil returns the expiry of the deployment's pin, or the zero time
+// if it isn't pinned. Invalid times are ignored.
+func pinnedUntil(bd *fleet.BundleDeployment) time.Time {
+	if bd == nil {
+		return time.Time{}
+	}
+	v, ok := bd.Annotations[fleet.PinnedUntilAnnotation]
+	if !ok {
+		return time.Time{}
+	}
+	until, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		logrus.Warnf("Ignoring invalid %s annotation %q on bundle deployment %s/%s: %v", fleet.PinnedUntilAnnotation, v, bd.Namespace, bd.Name, err)
+		return time.Time{}
+	}
+	return until
+}
+
+// setPinned marks the targets, whose bundle deployment is pinned until a
+// time in the future. It returns how long to wait until the next pin
+// expires, or zero.
+func setPinned(targets []*target.Target, now time.Time) time.Duration {
+	var wait time.Duration
+	for _, t := range targets {
+		t.Pinned = false
+		until := pinnedUntil(t.Deployment)
+		if until.IsZero() {
+			continue
+		}
+		if !until.After(now) {
+			logrus.Infof("Pin of bundle deployment %s/%s expired at %s", t.Deployment.Namespace, t.Deployment.Name, until.Format(time.RFC3339))
+			continue
+		}
+		t.Pinned = true
+		if w := until.Sub(now) + time.Second; wait == 0 || w < wait {
+			wait = w
+		}
+	}
+	return wait
+}
+
+// setPinnedStatus lists the pinned targets in the status and sets the
+// Pinned condition.
+func setPinnedStatus(status *fleet.BundleStatus, targets []*target.Target) {
+	status.Pinned = nil
+	var messages []string
+	for _, t := range targets {
+		if !t.Pinned {
+			continue
+		}
+		cluster := t.Cluster.Namespace + "/" + t.Cluster.Name
+		until := pinnedUntil(t.Deployment)
+		status.Pinned = append(status.Pinned, fleet.PinnedStatus{
+			Cluster:      cluster,
+			Until:        v1.NewTime(until),
+			By:           t.Deployment.Annotations[fleet.PinnedByAnnotation],
+			DeploymentID: t.Deployment.Spec.DeploymentID,
+		})
+		messages = append(messages, fmt.Sprintf("%s until %s", cluster, until.Format(time.RFC3339)))
+	}
+	sort.Slice(status.Pinned, func(i, j int) bool {
+		return status.Pinned[i].Cluster < status.Pinned[j].Cluster
+	})
+	sort.Strings(messages)
+
+	c := condition.Cond(fleet.BundleConditionPinned)
+	if len(messages) == 0 {
+		if c.IsTrue(status) {
+			c.SetStatusBool(status, false)
+			c.Message(status, "")
+		}
+		return
+	}
+	c.SetStatusBool(status, true)
+	c.Message(status, "pinned: "+strings.Join(messages, "; "))
+}
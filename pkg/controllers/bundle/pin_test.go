@@ -0,0 +1,82 @@
+package bundle
+
+import (
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/target"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPinned(t *testing.T) {
+	now := time.Now()
+
+	newTarget := func(name string, annotations map[string]string) *target.Target {
+		return &target.Target{
+			Cluster:      &fleet.Cluster{ObjectMeta: v1.ObjectMeta{Namespace: "fleet-default", Name: name}},
+			Bundle:       &fleet.Bundle{},
+			DeploymentID: "s-new:opts",
+			Deployment: &fleet.BundleDeployment{
+				ObjectMeta: v1.ObjectMeta{Annotations: annotations},
+				Spec: fleet.BundleDeploymentSpec{
+					DeploymentID:       "s-old:opts",
+					StagedDeploymentID: "s-new:opts",
+				},
+				Status: fleet.BundleDeploymentStatus{AppliedDeploymentID: "s-old:opts"},
+			},
+		}
+	}
+
+	pinned := newTarget("pinned", map[string]string{
+		fleet.PinnedUntilAnnotation: now.Add(time.Hour).UTC().Format(time.RFC3339),
+		fleet.PinnedByAnnotation:    "ops: incident",
+	})
+	expired := newTarget("expired", map[string]string{
+		fleet.PinnedUntilAnnotation: now.Add(-time.Hour).UTC().Format(time.RFC3339),
+	})
+	invalid := newTarget("invalid", map[string]string{fleet.PinnedUntilAnnotation: "tomorrow"})
+	unpinned := newTarget("unpinned", nil)
+	targets := []*target.Target{pinned, expired, invalid, unpinned}
+
+	wait := setPinned(targets, now)
+	if wait <= 59*time.Minute || wait > time.Hour+time.Second {
+		t.Errorf("expected to recheck, when the pin expires, got %s", wait)
+	}
+	if !pinned.Pinned || expired.Pinned || invalid.Pinned || unpinned.Pinned {
+		t.Errorf("unexpected pinned targets: %v %v %v %v", pinned.Pinned, expired.Pinned, invalid.Pinned, unpinned.Pinned)
+	}
+
+	status := &fleet.BundleStatus{MaxUnavailable: 10}
+	partition := &fleet.PartitionStatus{MaxUnavailable: 10}
+	for _, tgt := range targets {
+		updateTarget(tgt, status, partition)
+	}
+	if pinned.Deployment.Spec.DeploymentID != "s-old:opts" {
+		t.Error("expected pinned deployment not to be updated")
+	}
+	if expired.Deployment.Spec.DeploymentID != "s-new:opts" || unpinned.Deployment.Spec.DeploymentID != "s-new:opts" {
+		t.Error("expected deployments to be updated")
+	}
+
+	setPinnedStatus(status, targets)
+	if len(status.Pinned) != 1 || status.Pinned[0].Cluster != "fleet-default/pinned" ||
+		status.Pinned[0].By != "ops: incident" || status.Pinned[0].DeploymentID != "s-old:opts" {
+		t.Errorf("unexpected pinned status: %+v", status.Pinned)
+	}
+
+	objs := bundleDeployments(targets, &fleet.Bundle{}, "")
+	if bd := objs[0].(*fleet.BundleDeployment); bd.Annotations[fleet.PinnedByAnnotation] != "ops: incident" {
+		t.Errorf("expected pin to be kept, got %v", bd.Annotations)
+	}
+	if bd := objs[1].(*fleet.BundleDeployment); bd.Annotations[fleet.PinnedUntilAnnotation] != "" {
+		t.Errorf("expected expired pin to be removed, got %v", bd.Annotations)
+	}
+
+	setPinned(targets, now.Add(2*time.Hour))
+	setPinnedStatus(status, targets)
+	if len(status.Pinned) != 0 {
+		t.Errorf("expected no pinned clusters, got %+v", status.Pinned)
+	}
+}
@@ -0,0 +1,52 @@
+package bundle
+
+import (
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"github.com/rancher/wrangler/pkg/condition"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// OnPostDelete removes the post-delete hooks finalizer from terminating
+// bundle deployments, once the agent ran the hooks, or if their cluster
+// doesn't exist anymore. Deployments without the option have no hooks to
+// wait for.
+func (h *handler) OnPostDelete(key string, bd *fleet.BundleDeployment) (*fleet.BundleDeployment, error) {
+	if bd == nil || bd.DeletionTimestamp == nil || !hasPostDeleteFinalizer(bd) {
+		return bd, nil
+	}
+
+	if bd.Spec.Options.PostDeleteHooks && !condition.Cond(fleet.BundleDeploymentConditionPostDeleteHooks).IsTrue(bd) {
+		_, err := h.clusters.Get(bd.Labels[fleet.ClusterNamespaceLabel], fleet.DeploymentClusterName(bd))
+		if err == nil {
+			// wait for the agent
+			return bd, nil
+		}
+		if !apierrors.IsNotFound(err) {
+			return bd, err
+		}
+		logrus.Infof("Removing post-delete hooks finalizer from bundledeployment %s, its cluster was deleted", key)
+	}
+
+	bd = bd.DeepCopy()
+	var finalizers []string
+	for _, f := range bd.Finalizers {
+		if f != fleet.PostDeleteHooksFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	bd.Finalizers = finalizers
+	return h.bundleDeployments.Update(bd)
+}
+
+func hasPostDeleteFinalizer(bd *fleet.BundleDeployment) bool {
+	for _, f := range bd.Finalizers {
+		if f == fleet.PostDeleteHooksFinalizer {
+			return true
+		}
+	}
+	return false
+}
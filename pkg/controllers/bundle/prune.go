@@ -0,0 +1,140 @@
+package bundle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/kv"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updatePendingPrune holds an update of the bundle, which removes resources,
+// for the bundle's prune confirmation period. previous are the resource
+// keys before the bundle changed, the status already lists the new ones.
+// It returns how long to wait until the period passes, or zero.
+func updatePendingPrune(status *fleet.BundleStatus, bundle *fleet.Bundle, manifestID string, previous []fleet.ResourceKey, changed bool, now time.Time) time.Duration {
+	period := bundle.Spec.PruneConfirmationPeriod
+	if bundle.Spec.AutoPrune || period == nil || period.Duration <= 0 {
+		status.PendingPrune = nil
+		return 0
+	}
+
+	pending := status.PendingPrune
+	if changed && (pending == nil || pending.ManifestID != manifestID) {
+		// resources removed by a held update are still pending, unless
+		// they were added again
+		removed := removedResources(previous, status.ResourceKey)
+		if pending != nil {
+			removed = append(removed, removedResources(pending.Resources, status.ResourceKey)...)
+		}
+		pending = nil
+		if removed = uniqueResources(removed); len(removed) > 0 {
+			pending = &fleet.PendingPrune{
+				ManifestID: manifestID,
+				Resources:  removed,
+				Since:      v1.NewTime(now),
+				Until:      v1.NewTime(now.Add(period.Duration)),
+			}
+		}
+		status.PendingPrune = pending
+	}
+	if pending == nil {
+		return 0
+	}
+
+	if confirmed := bundle.Annotations[fleet.PruneConfirmedAnnotation]; confirmed == pending.ManifestID {
+		logrus.Infof("Pruning %d resources of bundle %s/%s, as confirmed by annotation %s", len(pending.Resources), bundle.Namespace, bundle.Name, fleet.PruneConfirmedAnnotation)
+		status.PendingPrune = nil
+		return 0
+	}
+	if !now.Before(pending.Until.Time) {
+		logrus.Infof("Pruning %d resources of bundle %s/%s, its prune confirmation period passed", len(pending.Resources), bundle.Namespace, bundle.Name)
+		status.PendingPrune = nil
+		return 0
+	}
+	return pending.Until.Sub(now) + time.Second
+}
+
+// pruneConfirmed returns false, if updating the target would prune
+// resources, whose removal waits for confirmation. Targets, which were not
+// deployed yet, have nothing to prune.
+func pruneConfirmed(t *target.Target, status *fleet.BundleStatus) bool {
+	pending := status.PendingPrune
+	if pending == nil || t.Deployment == nil || t.Deployment.Spec.DeploymentID == "" {
+		return true
+	}
+	manifestID, _ := kv.Split(t.Deployment.Spec.StagedDeploymentID, ":")
+	return manifestID != pending.ManifestID
+}
+
+// setPrunePendingStatus sets the bundle's PrunePending condition, while an
+// update waits for its prune confirmation period.
+func setPrunePendingStatus(status *fleet.BundleStatus) {
+	c := condition.Cond(fleet.BundleConditionPrunePending)
+	pending := status.PendingPrune
+	if pending == nil {
+		if c.IsTrue(status) {
+			c.SetStatusBool(status, false)
+			c.Message(status, "")
+		}
+		return
+	}
+
+	resources := make([]string, 0, len(pending.Resources))
+	for _, r := range pending.Resources {
+		name := r.Name
+		if r.Namespace != "" {
+			name = r.Namespace + "/" + r.Name
+		}
+		resources = append(resources, r.Kind+" "+name)
+	}
+	c.SetStatusBool(status, true)
+	c.Message(status, fmt.Sprintf("pruning %s after %s, unless confirmed by annotation %s=%s",
+		strings.Join(resources, ", "), pending.Until.UTC().Format(time.RFC3339), fleet.PruneConfirmedAnnotation, pending.ManifestID))
+}
+
+// removedResources returns the keys, which are in previous, but not in keys
+func removedResources(previous, keys []fleet.ResourceKey) []fleet.ResourceKey {
+	current := map[fleet.ResourceKey]bool{}
+	for _, k := range keys {
+		current[k] = true
+	}
+	var result []fleet.ResourceKey
+	for _, k := range previous {
+		if !current[k] {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+func uniqueResources(keys []fleet.ResourceKey) []fleet.ResourceKey {
+	seen := map[fleet.ResourceKey]bool{}
+	var result []fleet.ResourceKey
+	for _, k := range keys {
+		if !seen[k] {
+			seen[k] = true
+			result = append(result, k)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return result
+}
@@ -0,0 +1,76 @@
+package bundle
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPendingPrune(t *testing.T) {
+	now := time.Now()
+	cm := fleet.ResourceKey{Kind: "ConfigMap", APIVersion: "v1", Namespace: "app", Name: "config"}
+	secret := fleet.ResourceKey{Kind: "Secret", APIVersion: "v1", Namespace: "app", Name: "db"}
+	bundle := &fleet.Bundle{
+		ObjectMeta: v1.ObjectMeta{Namespace: "fleet-default", Name: "app"},
+		Spec:       fleet.BundleSpec{PruneConfirmationPeriod: &v1.Duration{Duration: time.Hour}},
+	}
+
+	status := &fleet.BundleStatus{ResourceKey: []fleet.ResourceKey{cm}}
+	wait := updatePendingPrune(status, bundle, "s-new", []fleet.ResourceKey{cm, secret}, true, now)
+	if status.PendingPrune == nil || len(status.PendingPrune.Resources) != 1 || status.PendingPrune.Resources[0] != secret {
+		t.Fatalf("expected the secret to be pending, got %+v", status.PendingPrune)
+	}
+	if wait <= 59*time.Minute || wait > time.Hour+time.Second {
+		t.Errorf("expected to wait an hour, got %v", wait)
+	}
+
+	setPrunePendingStatus(status)
+	c := condition.Cond(fleet.BundleConditionPrunePending)
+	if !c.IsTrue(status) || !strings.Contains(c.GetMessage(status), "Secret app/db") {
+		t.Errorf("expected PrunePending condition, got %q", c.GetMessage(status))
+	}
+
+	deployed := &target.Target{Deployment: &fleet.BundleDeployment{
+		Spec: fleet.BundleDeploymentSpec{DeploymentID: "s-old:opts", StagedDeploymentID: "s-new:opts"},
+	}}
+	created := &target.Target{Deployment: &fleet.BundleDeployment{
+		Spec: fleet.BundleDeploymentSpec{StagedDeploymentID: "s-new:opts"},
+	}}
+	if pruneConfirmed(deployed, status) || !pruneConfirmed(created, status) {
+		t.Error("expected only the deployed target to be held")
+	}
+
+	// a newer update keeps the secret pending
+	wait = updatePendingPrune(status, bundle, "s-newer", []fleet.ResourceKey{cm}, true, now)
+	if status.PendingPrune == nil || status.PendingPrune.ManifestID != "s-newer" || status.PendingPrune.Resources[0] != secret || wait == 0 {
+		t.Errorf("expected the secret to stay pending, got %+v", status.PendingPrune)
+	}
+
+	bundle.Annotations = map[string]string{fleet.PruneConfirmedAnnotation: "s-newer"}
+	if updatePendingPrune(status, bundle, "s-newer", nil, false, now) != 0 || status.PendingPrune != nil {
+		t.Error("expected the annotation to confirm the prune")
+	}
+	setPrunePendingStatus(status)
+	if c.IsTrue(status) {
+		t.Error("expected condition to be cleared")
+	}
+
+	bundle.Annotations = nil
+	status.ResourceKey = nil
+	updatePendingPrune(status, bundle, "s-empty", []fleet.ResourceKey{cm}, true, now)
+	if updatePendingPrune(status, bundle, "s-empty", nil, false, now.Add(2*time.Hour)) != 0 || status.PendingPrune != nil {
+		t.Error("expected the prune to proceed after the period")
+	}
+
+	bundle.Spec.AutoPrune = true
+	if updatePendingPrune(status, bundle, "s-new", []fleet.ResourceKey{cm}, true, now) != 0 || status.PendingPrune != nil {
+		t.Error("expected autoPrune to prune immediately")
+	}
+}
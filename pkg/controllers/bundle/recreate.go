@@ -0,0 +1,94 @@
+package bundle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/helmdeployer"
+	"github.com/rancher/fleet/pkg/immutable"
+	"github.com/rancher/fleet/pkg/manifest"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/kv"
+)
+
+// setRecreateRequired compares the deployed and the staged content of the
+// targets, which are about to be updated, and records changes to immutable
+// fields of StatefulSets and PersistentVolumeClaims on the target, unless
+// the target allows recreating them.
+func (h *handler) setRecreateRequired(bundle *fleet.Bundle, staged *manifest.Manifest, targets []*target.Target) {
+	for _, t := range targets {
+		if t.Deployment == nil || t.Deployment.Spec.DeploymentID == "" ||
+			t.Deployment.Spec.DeploymentID == t.DeploymentID {
+			continue
+		}
+		if t.Options.AllowRecreate || (t.Options.Helm != nil && t.Options.Helm.AgentRendering) {
+			continue
+		}
+
+		key := "recreate/" + t.Deployment.Spec.DeploymentID + "/" + t.DeploymentID
+		rendered, ok := h.renders.get(key)
+		if !ok {
+			changes, err := h.recreateChanges(bundle, staged, t)
+			if err != nil {
+				// the agent will report the rendering error
+				logrus.Debugf("Skipping immutable field check for bundle %s/%s on cluster %s/%s: %v", bundle.Namespace, bundle.Name, t.Cluster.Namespace, t.Cluster.Name, err)
+				continue
+			}
+			rendered = &renderResult{recreate: changes}
+			h.renders.set(key, rendered)
+		}
+
+		t.RecreateRequired = rendered.recreate
+	}
+}
+
+// recreateChanges renders the deployed and the staged content of the target
+// and returns the changes, which require recreating stateful resources.
+func (h *handler) recreateChanges(bundle *fleet.Bundle, staged *manifest.Manifest, t *target.Target) ([]string, error) {
+	manifestID, _ := kv.Split(t.Deployment.Spec.DeploymentID, ":")
+	deployed, err := h.manifests.Get(manifestID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := helmdeployer.Template(bundle.Name, deployed, t.Deployment.Spec.Options)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := helmdeployer.Template(bundle.Name, staged, t.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return immutable.Changes(existing, desired), nil
+}
+
+// setRecreateRequiredStatus sets the RecreateRequired condition, if targets
+// are not updated because of changes to immutable fields.
+func setRecreateRequiredStatus(status *fleet.BundleStatus, targets []*target.Target) {
+	var messages []string
+	for _, t := range targets {
+		if len(t.RecreateRequired) == 0 {
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("%s/%s: %s", t.Cluster.Namespace, t.Cluster.Name, strings.Join(t.RecreateRequired, ", ")))
+	}
+	sort.Strings(messages)
+
+	c := condition.Cond(fleet.BundleConditionRecreateRequired)
+	if len(messages) == 0 {
+		if c.IsTrue(status) {
+			c.SetStatusBool(status, false)
+			c.Message(status, "")
+		}
+		return
+	}
+	c.SetStatusBool(status, true)
+	c.Message(status, "update requires recreating resources, set allowRecreate to roll it out: "+limitMessages(messages))
+}
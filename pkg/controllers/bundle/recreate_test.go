@@ -0,0 +1,74 @@
+package bundle
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+)
+
+func TestRecreateRequiredStatus(t *testing.T) {
+	status := &fleet.BundleStatus{}
+	cluster := &fleet.Cluster{}
+	cluster.Namespace, cluster.Name = "fleet-default", "downstream"
+	c := condition.Cond(fleet.BundleConditionRecreateRequired)
+
+	setRecreateRequiredStatus(status, []*target.Target{{
+		Cluster:          cluster,
+		RecreateRequired: []string{"StatefulSet app/db changes immutable field spec.serviceName"},
+	}})
+	if !c.IsTrue(status) {
+		t.Fatal("expected RecreateRequired condition")
+	}
+
+	setRecreateRequiredStatus(status, []*target.Target{{Cluster: cluster}})
+	if c.IsTrue(status) || c.GetMessage(status) != "" {
+		t.Errorf("expected condition to be cleared, got %q", c.GetMessage(status))
+	}
+}
+
+func TestRecreateRequiredStatusLimit(t *testing.T) {
+	var targets []*target.Target
+	for i := 0; i < maxStatusClusters+2; i++ {
+		cluster := &fleet.Cluster{}
+		cluster.Namespace, cluster.Name = "fleet-default", fmt.Sprintf("c-%02d", i)
+		targets = append(targets, &target.Target{
+			Cluster:          cluster,
+			RecreateRequired: []string{"StatefulSet app/db changes immutable field spec.serviceName"},
+		})
+	}
+
+	status := &fleet.BundleStatus{}
+	setRecreateRequiredStatus(status, targets)
+	msg := condition.Cond(fleet.BundleConditionRecreateRequired).GetMessage(status)
+	if strings.Count(msg, "fleet-default/") != maxStatusClusters || !strings.HasSuffix(msg, " and 2 more") {
+		t.Errorf("expected message to be limited, got %q", msg)
+	}
+}
+
+func TestUpdateTargetRecreateRequired(t *testing.T) {
+	tgt := &target.Target{
+		Cluster:          &fleet.Cluster{},
+		Bundle:           &fleet.Bundle{},
+		RecreateRequired: []string{"StatefulSet app/db changes immutable field spec.serviceName"},
+		Deployment: &fleet.BundleDeployment{Spec: fleet.BundleDeploymentSpec{
+			DeploymentID:       "a:1",
+			StagedDeploymentID: "b:1",
+		}},
+	}
+	status := &fleet.BundleStatus{MaxUnavailable: 10}
+	updateTarget(tgt, status, &fleet.PartitionStatus{MaxUnavailable: 10})
+	if tgt.Deployment.Spec.DeploymentID != "a:1" {
+		t.Error("expected the target not to be updated")
+	}
+
+	tgt.RecreateRequired = nil
+	updateTarget(tgt, status, &fleet.PartitionStatus{MaxUnavailable: 10})
+	if tgt.Deployment.Spec.DeploymentID != "b:1" {
+		t.Error("expected the target to be updated")
+	}
+}
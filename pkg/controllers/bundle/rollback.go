@@ -0,0 +1,159 @@
+package bundle
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+	"github.com/rancher/fleet/pkg/manifest"
+	"github.com/rancher/fleet/pkg/options"
+	"github.com/rancher/fleet/pkg/summary"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/kv"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// autoRollback reverts the targets, which were updated to the staged
+// content, to the known good content, if too many of them failed. It
+// returns how long to wait, before not ready targets count as failed.
+func (h *handler) autoRollback(status *fleet.BundleStatus, bundle *fleet.Bundle, targets []*target.Target, now time.Time) (time.Duration, error) {
+	updateKnownGood(status, targets)
+
+	c := condition.Cond(fleet.BundleConditionRolledBack)
+	staged := stagedManifestID(targets)
+	var rollback *fleet.AutoRollback
+	if bundle.Spec.RolloutStrategy != nil {
+		rollback = bundle.Spec.RolloutStrategy.AutoRollback
+	}
+	if rollback == nil || (status.RolledBackManifestID != "" && status.RolledBackManifestID != staged) {
+		// disabled or the bundle changed since
+		status.RolledBackManifestID = ""
+		if c.IsTrue(status) {
+			c.SetStatusBool(status, false)
+			c.Message(status, "")
+		}
+	}
+	if rollback == nil || staged == "" || status.KnownGoodManifestID == "" || staged == status.KnownGoodManifestID {
+		return 0, nil
+	}
+
+	var wait time.Duration
+	if status.RolledBackManifestID == "" {
+		var failed, max int
+		var err error
+		failed, max, wait, err = failedTargets(status, rollback, targets, staged, now)
+		if err != nil || failed <= max {
+			return wait, err
+		}
+		logrus.Infof("Rolling back bundle %s/%s from %s to %s, %d targets failed", bundle.Namespace, bundle.Name, staged, status.KnownGoodManifestID, failed)
+		status.RolledBackManifestID = staged
+		c.SetStatusBool(status, true)
+		c.Message(status, fmt.Sprintf("rolled back to %s, %d targets failed with %s, more than %d allowed", status.KnownGoodManifestID, failed, staged, max))
+	}
+
+	return wait, h.revert(status, targets)
+}
+
+// updateKnownGood records the content, if all targets are ready with the
+// same content
+func updateKnownGood(status *fleet.BundleStatus, targets []*target.Target) {
+	manifestID := ""
+	for _, t := range targets {
+		if t.Deployment == nil || !t.Deployment.Status.Ready ||
+			t.Deployment.Status.AppliedDeploymentID != t.Deployment.Spec.DeploymentID {
+			return
+		}
+		m, _ := kv.Split(t.Deployment.Spec.DeploymentID, ":")
+		if manifestID != "" && m != manifestID {
+			return
+		}
+		manifestID = m
+	}
+	if manifestID != "" {
+		status.KnownGoodManifestID = manifestID
+	}
+}
+
+// failedTargets counts the targets, which failed to apply the staged
+// content, or were not ready with it after the timeout. (pure function)
+func failedTargets(status *fleet.BundleStatus, rollback *fleet.AutoRollback, targets []*target.Target, staged string, now time.Time) (int, int, time.Duration, error) {
+	max := 0
+	if rollback.MaxFailures != nil {
+		var err error
+		if max, err = intstr.GetScaledValueFromIntOrPercent(rollback.MaxFailures, len(targets), false); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+
+	timeout := durations.DefaultAutoRollbackTimeout
+	if rollback.Timeout != nil {
+		timeout = rollback.Timeout.Duration
+	}
+	var wait time.Duration
+	timedOut := false
+	if status.PromotedManifestID == staged && status.PromotedAt != nil {
+		wait = status.PromotedAt.Add(timeout).Sub(now)
+		timedOut = wait <= 0
+	}
+
+	failed := 0
+	for _, t := range targets {
+		if t.Deployment == nil {
+			continue
+		}
+		if m, _ := kv.Split(t.Deployment.Spec.DeploymentID, ":"); m != staged {
+			continue
+		}
+		switch state := summary.GetDeploymentState(t.Deployment); {
+		case state == fleet.ErrApplied:
+			failed++
+		case timedOut && state != fleet.Ready:
+			failed++
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return failed, max, wait, nil
+}
+
+// revert deploys the known good content to the targets, which were
+// updated to the rolled back content. Their options are kept.
+func (h *handler) revert(status *fleet.BundleStatus, targets []*target.Target) error {
+	var known *manifest.Manifest
+	for _, t := range targets {
+		if t.Deployment == nil {
+			continue
+		}
+		if m, _ := kv.Split(t.Deployment.Spec.DeploymentID, ":"); m != status.RolledBackManifestID {
+			continue
+		}
+		if known == nil {
+			var err error
+			if known, err = h.manifests.Get(status.KnownGoodManifestID); err != nil {
+				return err
+			}
+		}
+		deploymentID, err := options.DeploymentID(known, t.Deployment.Spec.Options)
+		if err != nil {
+			return err
+		}
+		t.Deployment.Spec.DeploymentID = deploymentID
+	}
+	return nil
+}
+
+// rolledBack returns true, if the target's staged content was rolled back
+func rolledBack(t *target.Target, status *fleet.BundleStatus) bool {
+	if status.RolledBackManifestID == "" {
+		return false
+	}
+	m, _ := kv.Split(t.Deployment.Spec.StagedDeploymentID, ":")
+	return m == status.RolledBackManifestID
+}
@@ -0,0 +1,95 @@
+package bundle
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/manifest"
+	"github.com/rancher/fleet/pkg/options"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type fakeLookup map[string]*manifest.Manifest
+
+func (f fakeLookup) Get(id string) (*manifest.Manifest, error) {
+	return f[id], nil
+}
+
+func TestAutoRollback(t *testing.T) {
+	good, err := manifest.New([]fleet.BundleResource{{Name: "cm.yaml", Content: "kind: ConfigMap"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	goodID := "s-good"
+	h := &handler{manifests: fakeLookup{goodID: good}}
+
+	now := time.Now()
+	newTarget := func(deployed string, state fleet.BundleState) *target.Target {
+		bd := &fleet.BundleDeployment{Spec: fleet.BundleDeploymentSpec{DeploymentID: deployed + ":opts", StagedDeploymentID: "s-bad:opts"}}
+		bd.Status.AppliedDeploymentID = bd.Spec.DeploymentID
+		bd.Status.Ready = state == fleet.Ready
+		if state == fleet.ErrApplied {
+			bd.Status.AppliedDeploymentID = ""
+			condition.Cond(fleet.BundleDeploymentConditionDeployed).SetError(&bd.Status, "", errors.New("apply failed"))
+		}
+		return &target.Target{Cluster: &fleet.Cluster{}, Bundle: &fleet.Bundle{}, DeploymentID: "s-bad:opts", Deployment: bd}
+	}
+	targets := []*target.Target{
+		newTarget("s-bad", fleet.ErrApplied),
+		newTarget("s-bad", fleet.NotReady),
+		newTarget(goodID, fleet.Ready),
+	}
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{RolloutStrategy: &fleet.RolloutStrategy{
+		AutoRollback: &fleet.AutoRollback{MaxFailures: &intstr.IntOrString{Type: intstr.Int, IntVal: 1}},
+	}}}
+	status := &fleet.BundleStatus{
+		KnownGoodManifestID: goodID,
+		PromotedManifestID:  "s-bad",
+		PromotedAt:          &v1.Time{Time: now.Add(-time.Minute)},
+	}
+
+	wait, err := h.autoRollback(status, bundle, targets, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.RolledBackManifestID != "" || wait != 9*time.Minute {
+		t.Fatalf("expected to wait for the timeout with one failure, got %q %v", status.RolledBackManifestID, wait)
+	}
+
+	if _, err := h.autoRollback(status, bundle, targets, now.Add(10*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if status.RolledBackManifestID != "s-bad" || !condition.Cond(fleet.BundleConditionRolledBack).IsTrue(status) {
+		t.Fatalf("expected rollback after the timeout, got %q", status.RolledBackManifestID)
+	}
+	reverted, err := options.DeploymentID(good, fleet.BundleDeploymentOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tgt := range targets[:2] {
+		if tgt.Deployment.Spec.DeploymentID != reverted {
+			t.Errorf("expected target to be reverted to %s, got %s", reverted, tgt.Deployment.Spec.DeploymentID)
+		}
+	}
+	if !rolledBack(targets[2], status) {
+		t.Error("expected rolled back content not to be promoted")
+	}
+
+	// new content clears the rollback
+	for _, tgt := range targets {
+		tgt.DeploymentID = "s-fix:opts"
+	}
+	if _, err := h.autoRollback(status, bundle, targets, now.Add(11*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if status.RolledBackManifestID != "" || condition.Cond(fleet.BundleConditionRolledBack).IsTrue(status) {
+		t.Errorf("expected rollback to be cleared for new content, got %q", status.RolledBackManifestID)
+	}
+}
@@ -0,0 +1,59 @@
+package bundle
+
+import (
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/schedule"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updateSchedule releases the current generation of the bundle, once the
+// time scheduled by deployAt is reached. It returns the value of the
+// redeploy annotation for the bundle deployments, which changes at every
+// time of a cron schedule.
+func updateSchedule(status *fleet.BundleStatus, bundle *fleet.Bundle, now time.Time) (string, error) {
+	if bundle.Spec.DeployAt == "" {
+		status.Schedule = nil
+		return "", nil
+	}
+	sched, err := schedule.Parse(bundle.Spec.DeployAt)
+	if err != nil {
+		return "", err
+	}
+
+	s := status.Schedule
+	if s == nil || s.DeployAt != bundle.Spec.DeployAt {
+		s = &fleet.BundleScheduleStatus{DeployAt: bundle.Spec.DeployAt}
+		s.Next = toTime(sched.Next(now))
+		status.Schedule = s
+	}
+
+	switch {
+	case s.Next != nil && !now.Before(s.Next.Time):
+		s.Last = &v1.Time{Time: now}
+		s.Next = toTime(sched.Next(now))
+		s.ReleasedGeneration = bundle.Generation
+	case s.Next == nil && !sched.Recurring():
+		// the point in time passed, later changes are deployed right away
+		s.ReleasedGeneration = bundle.Generation
+	}
+
+	if sched.Recurring() && s.Last != nil {
+		return s.Last.UTC().Format(time.RFC3339), nil
+	}
+	return "", nil
+}
+
+// scheduleReleased returns true, if the bundle's content may be deployed
+func scheduleReleased(status *fleet.BundleStatus, bundle *fleet.Bundle) bool {
+	return status.Schedule == nil || status.Schedule.ReleasedGeneration == bundle.Generation
+}
+
+func toTime(t time.Time) *v1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &v1.Time{Time: t}
+}
@@ -0,0 +1,133 @@
+package bundle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/genericcondition"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updateRolloutSteps records the progress of the rollout strategy's steps
+// and marks the targets, whose step didn't start yet, as pending. It
+// returns how long to wait for the pause of a completed step, or zero.
+func updateRolloutSteps(status *fleet.BundleStatus, targets []*target.Target, now time.Time) (time.Duration, error) {
+	var defs []fleet.RolloutStep
+	if len(targets) > 0 && targets[0].Bundle.Spec.RolloutStrategy != nil {
+		defs = targets[0].Bundle.Spec.RolloutStrategy.Steps
+	}
+	if len(defs) == 0 {
+		status.RolloutSteps = nil
+		removeStepConditions(status, 0)
+		return 0, nil
+	}
+
+	steps, err := target.Steps(targets)
+	if err != nil {
+		return 0, err
+	}
+
+	// progress is tracked per content
+	manifestID := stagedManifestID(targets)
+	previous := status.RolloutSteps
+	if previous == nil || previous.ManifestID != manifestID {
+		previous = &fleet.RolloutStepsStatus{}
+	}
+	progress := &fleet.RolloutStepsStatus{ManifestID: manifestID}
+
+	var wait time.Duration
+	current := -1
+	for i, stepTargets := range steps {
+		step := fleet.RolloutStepStatus{
+			ClusterGroup: defs[i].ClusterGroup,
+			Weight:       defs[i].Weight,
+			Clusters:     len(stepTargets),
+		}
+		if step.Weight == 0 {
+			step.Weight = 100
+		}
+		for _, t := range stepTargets {
+			if updated(t) {
+				step.Ready++
+			}
+		}
+		if i < len(previous.Steps) {
+			step.CompletedAt = previous.Steps[i].CompletedAt
+		}
+
+		if current < 0 {
+			if step.CompletedAt == nil && step.Ready == step.Clusters {
+				step.CompletedAt = &v1.Time{Time: now}
+			}
+			switch {
+			case step.CompletedAt == nil:
+				current = i
+			case defs[i].Pause != nil && now.Before(step.CompletedAt.Add(defs[i].Pause.Duration)):
+				current = i
+				wait = step.CompletedAt.Add(defs[i].Pause.Duration).Sub(now) + time.Second
+			}
+		}
+		progress.Steps = append(progress.Steps, step)
+	}
+
+	progress.Current = current
+	if current < 0 {
+		progress.Current = len(steps)
+	} else {
+		started := map[*target.Target]bool{}
+		for _, t := range steps[current] {
+			started[t] = true
+		}
+		for _, t := range targets {
+			t.StepPending = !started[t]
+		}
+	}
+	status.RolloutSteps = progress
+
+	setStepConditions(status, progress)
+	return wait, nil
+}
+
+// updated returns true, if the target is ready with the staged content
+func updated(t *target.Target) bool {
+	return t.Deployment != nil &&
+		t.Deployment.Spec.DeploymentID == t.Deployment.Spec.StagedDeploymentID &&
+		!target.IsUnavailable(t.Deployment)
+}
+
+// setStepConditions sets a condition for each step, which is true once the
+// step completed
+func setStepConditions(status *fleet.BundleStatus, progress *fleet.RolloutStepsStatus) {
+	for i, step := range progress.Steps {
+		c := condition.Cond(fmt.Sprintf("%s%d", fleet.BundleConditionRolloutStep, i+1))
+		group := "all clusters"
+		if step.ClusterGroup != "" {
+			group = "cluster group " + step.ClusterGroup
+		}
+		c.SetStatusBool(status, step.CompletedAt != nil)
+		c.Message(status, fmt.Sprintf("%d%% of %s: %d/%d clusters ready", step.Weight, group, step.Ready, step.Clusters))
+	}
+	removeStepConditions(status, len(progress.Steps))
+}
+
+// removeStepConditions removes the conditions of steps, which no longer
+// exist
+func removeStepConditions(status *fleet.BundleStatus, steps int) {
+	var conds []genericcondition.GenericCondition
+	for _, cond := range status.Conditions {
+		var i int
+		if strings.HasPrefix(cond.Type, fleet.BundleConditionRolloutStep) {
+			if _, err := fmt.Sscanf(strings.TrimPrefix(cond.Type, fleet.BundleConditionRolloutStep), "%d", &i); err == nil && i > steps {
+				continue
+			}
+		}
+		conds = append(conds, cond)
+	}
+	status.Conditions = conds
+}
@@ -0,0 +1,93 @@
+package bundle
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRolloutSteps(t *testing.T) {
+	now := time.Now()
+	bundle := &fleet.Bundle{Spec: fleet.BundleSpec{RolloutStrategy: &fleet.RolloutStrategy{
+		Steps: []fleet.RolloutStep{
+			{ClusterGroup: "prod", Weight: 10, Pause: &v1.Duration{Duration: 30 * time.Minute}},
+			{ClusterGroup: "prod", Weight: 100},
+		},
+	}}}
+	prod := &fleet.ClusterGroup{ObjectMeta: v1.ObjectMeta{Name: "prod"}}
+
+	var targets []*target.Target
+	for i := 0; i < 10; i++ {
+		targets = append(targets, &target.Target{
+			Cluster:       &fleet.Cluster{ObjectMeta: v1.ObjectMeta{Namespace: "fleet-default", Name: fmt.Sprintf("cluster-%d", i)}},
+			ClusterGroups: []*fleet.ClusterGroup{prod},
+			Bundle:        bundle,
+			DeploymentID:  "s-new:opts",
+			Deployment: &fleet.BundleDeployment{
+				Spec:   fleet.BundleDeploymentSpec{DeploymentID: "s-old:opts", StagedDeploymentID: "s-new:opts"},
+				Status: fleet.BundleDeploymentStatus{AppliedDeploymentID: "s-old:opts", Ready: true},
+			},
+		})
+	}
+	status := &fleet.BundleStatus{MaxUnavailable: 10}
+	partition := &fleet.PartitionStatus{MaxUnavailable: 10}
+	step1 := condition.Cond(fleet.BundleConditionRolloutStep + "1")
+
+	reconcile := func(now time.Time) time.Duration {
+		wait, err := updateRolloutSteps(status, targets, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, t := range targets {
+			updateTarget(t, status, partition)
+		}
+		return wait
+	}
+	updatedTargets := func() (n int) {
+		for _, t := range targets {
+			if t.Deployment.Spec.DeploymentID == "s-new:opts" {
+				n++
+			}
+		}
+		return n
+	}
+
+	// only the first cluster is updated
+	if wait := reconcile(now); wait != 0 || updatedTargets() != 1 {
+		t.Fatalf("expected one updated target without waiting, got %d, %v", updatedTargets(), wait)
+	}
+	if status.RolloutSteps.Current != 0 || step1.IsTrue(status) {
+		t.Errorf("expected first step in progress, got %+v", status.RolloutSteps)
+	}
+
+	// the first step completed, the next one waits for its pause
+	targets[0].Deployment.Status.AppliedDeploymentID = "s-new:opts"
+	if wait := reconcile(now); wait < 30*time.Minute || updatedTargets() != 1 {
+		t.Fatalf("expected to wait for the pause, got %d, %v", updatedTargets(), wait)
+	}
+	if !step1.IsTrue(status) {
+		t.Error("expected condition for the completed step")
+	}
+
+	// after the pause, all clusters are updated
+	if wait := reconcile(now.Add(31 * time.Minute)); wait != 0 || updatedTargets() != 10 {
+		t.Fatalf("expected all targets to be updated, got %d, %v", updatedTargets(), wait)
+	}
+	if status.RolloutSteps.Current != 1 {
+		t.Errorf("expected second step in progress, got %d", status.RolloutSteps.Current)
+	}
+
+	// removing the steps removes their conditions
+	bundle.Spec.RolloutStrategy.Steps = nil
+	reconcile(now)
+	if status.RolloutSteps != nil || len(status.Conditions) != 0 {
+		t.Errorf("expected steps status to be removed, got %+v", status.Conditions)
+	}
+}
@@ -0,0 +1,71 @@
+package bundle
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/cloudevents"
+
+	"github.com/rancher/wrangler/pkg/condition"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updateExpiry calculates when the bundle is deleted because of its TTL.
+// It has to be called after the ready condition was updated. Bundles of a
+// GitRepo don't expire, as they would be created again by the next update
+// of the GitRepo.
+func updateExpiry(status *fleet.BundleStatus, bundle *fleet.Bundle, now time.Time) {
+	if bundle.Spec.TTL == nil || bundle.Labels[fleet.RepoLabel] != "" {
+		status.ReadyAt = nil
+		status.ExpiresAt = nil
+		return
+	}
+
+	start := bundle.CreationTimestamp
+	if bundle.Spec.TTLAfter == fleet.TTLAfterReady {
+		if status.ReadyAt == nil && condition.Cond(fleet.BundleConditionReady).IsTrue(status) {
+			status.ReadyAt = &v1.Time{Time: now}
+		}
+		if status.ReadyAt == nil {
+			status.ExpiresAt = nil
+			return
+		}
+		start = *status.ReadyAt
+	} else {
+		status.ReadyAt = nil
+	}
+
+	status.ExpiresAt = &v1.Time{Time: start.Add(bundle.Spec.TTL.Duration)}
+}
+
+// OnExpired deletes bundles, whose TTL passed. Their bundle deployments
+// are owned by the bundle and removed with it. Bundles of a GitRepo are
+// skipped.
+func (h *handler) OnExpired(key string, bundle *fleet.Bundle) (*fleet.Bundle, error) {
+	if bundle == nil || bundle.DeletionTimestamp != nil || bundle.Spec.TTL == nil || bundle.Status.ExpiresAt == nil ||
+		bundle.Labels[fleet.RepoLabel] != "" {
+		return bundle, nil
+	}
+	// wait for the expiry to be calculated from the current TTL
+	if bundle.Status.ObservedGeneration != bundle.Generation {
+		return bundle, nil
+	}
+
+	if wait := time.Until(bundle.Status.ExpiresAt.Time); wait > 0 {
+		h.bundles.EnqueueAfter(bundle.Namespace, bundle.Name, wait)
+		return bundle, nil
+	}
+
+	logrus.Infof("Deleting bundle %s, its TTL of %s passed", key, bundle.Spec.TTL.Duration)
+	if err := h.bundles.Delete(bundle.Namespace, bundle.Name, nil); err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	cloudevents.Emit(cloudevents.BundleExpired, "/fleet/bundles/"+key, key, map[string]interface{}{
+		"expiresAt": bundle.Status.ExpiresAt.Time,
+	})
+	return nil, nil
+}
@@ -0,0 +1,105 @@
+package bundle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/schedule"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+)
+
+// windowsOpen returns true, if the maintenance windows of the target and of
+// its cluster are open. Otherwise it returns when the next closed window
+// opens, or the zero time. Invalid windows are never open.
+func windowsOpen(t *target.Target, now time.Time) (bool, time.Time, error) {
+	open, next, err := anyWindowOpen(t.Windows, now)
+	if err != nil || !open {
+		return false, next, err
+	}
+	if t.Cluster == nil {
+		return true, time.Time{}, nil
+	}
+	return anyWindowOpen(t.Cluster.Spec.MaintenanceWindows, now)
+}
+
+// anyWindowOpen returns true, if there are no windows or one of them is
+// open
+func anyWindowOpen(windows []fleet.MaintenanceWindow, now time.Time) (bool, time.Time, error) {
+	if len(windows) == 0 {
+		return true, time.Time{}, nil
+	}
+	var next time.Time
+	for _, w := range windows {
+		window, err := schedule.NewWindow(w.Start, w.Duration.Duration, w.TimeZone)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid maintenance window: %w", err)
+		}
+		if window.Open(now) {
+			return true, time.Time{}, nil
+		}
+		if n := window.Next(now); !n.IsZero() && (next.IsZero() || n.Before(next)) {
+			next = n
+		}
+	}
+	return false, next, nil
+}
+
+// windowOpen returns true, if the target may be updated now
+func windowOpen(t *target.Target, now time.Time) bool {
+	open, _, _ := windowsOpen(t, now)
+	return open
+}
+
+// setWaitingForWindowStatus sets the bundle's WaitingForWindow condition,
+// if out of sync targets are not updated because their maintenance windows
+// are closed. It returns how long to wait until the next window opens, or
+// zero.
+func setWaitingForWindowStatus(status *fleet.BundleStatus, targets []*target.Target, now time.Time) time.Duration {
+	var (
+		messages []string
+		next     time.Time
+	)
+	for _, t := range targets {
+		if t.Deployment == nil || t.Deployment.Spec.DeploymentID == t.Deployment.Spec.StagedDeploymentID {
+			continue
+		}
+		open, n, err := windowsOpen(t, now)
+		if open {
+			continue
+		}
+		cluster := t.Cluster.Namespace + "/" + t.Cluster.Name
+		switch {
+		case err != nil:
+			messages = append(messages, fmt.Sprintf("%s: %v", cluster, err))
+		case n.IsZero():
+			messages = append(messages, cluster+": no window opens again")
+		default:
+			messages = append(messages, fmt.Sprintf("%s: until %s", cluster, n.UTC().Format(time.RFC3339)))
+			if next.IsZero() || n.Before(next) {
+				next = n
+			}
+		}
+	}
+	sort.Strings(messages)
+
+	c := condition.Cond(fleet.BundleConditionWaitingForWindow)
+	if len(messages) == 0 {
+		if c.IsTrue(status) {
+			c.SetStatusBool(status, false)
+			c.Message(status, "")
+		}
+		return 0
+	}
+	c.SetStatusBool(status, true)
+	c.Message(status, "waiting for maintenance windows: "+strings.Join(messages, "; "))
+
+	if next.IsZero() {
+		return 0
+	}
+	return next.Sub(now) + time.Second
+}
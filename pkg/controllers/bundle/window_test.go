@@ -0,0 +1,69 @@
+package bundle
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/target"
+
+	"github.com/rancher/wrangler/pkg/condition"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWaitingForWindow(t *testing.T) {
+	now := time.Now()
+	// a one-off window opening in an hour
+	later := []fleet.MaintenanceWindow{{Start: now.Add(time.Hour).UTC().Format(time.RFC3339), Duration: v1.Duration{Duration: time.Hour}}}
+	// a window, which is open right now
+	open := []fleet.MaintenanceWindow{{Start: now.Add(-time.Minute).UTC().Format(time.RFC3339), Duration: v1.Duration{Duration: time.Hour}}}
+
+	newTarget := func(name string, windows, clusterWindows []fleet.MaintenanceWindow) *target.Target {
+		return &target.Target{
+			Cluster: &fleet.Cluster{
+				ObjectMeta: v1.ObjectMeta{Namespace: "fleet-default", Name: name},
+				Spec:       fleet.ClusterSpec{MaintenanceWindows: clusterWindows},
+			},
+			Bundle:       &fleet.Bundle{},
+			DeploymentID: "s-new:opts",
+			Windows:      windows,
+			Deployment: &fleet.BundleDeployment{
+				Spec: fleet.BundleDeploymentSpec{DeploymentID: "s-old:opts", StagedDeploymentID: "s-new:opts"},
+			},
+		}
+	}
+	status := &fleet.BundleStatus{MaxUnavailable: 10}
+	partition := &fleet.PartitionStatus{MaxUnavailable: 10}
+
+	closed := newTarget("closed", later, nil)
+	clusterClosed := newTarget("cluster-closed", open, later)
+	inside := newTarget("inside", open, open)
+	targets := []*target.Target{closed, clusterClosed, inside}
+	for _, t := range targets {
+		updateTarget(t, status, partition)
+	}
+
+	if closed.Deployment.Spec.DeploymentID != "s-old:opts" || clusterClosed.Deployment.Spec.DeploymentID != "s-old:opts" {
+		t.Error("expected targets outside of their windows not to be updated")
+	}
+	if inside.Deployment.Spec.DeploymentID != "s-new:opts" {
+		t.Error("expected target inside its windows to be updated")
+	}
+
+	wait := setWaitingForWindowStatus(status, targets, now)
+	c := condition.Cond(fleet.BundleConditionWaitingForWindow)
+	if !c.IsTrue(status) || !strings.Contains(c.GetMessage(status), "fleet-default/cluster-closed: until") {
+		t.Errorf("expected WaitingForWindow condition, got %q", c.GetMessage(status))
+	}
+	if wait <= 59*time.Minute || wait > time.Hour+time.Second {
+		t.Errorf("expected to wait an hour, got %v", wait)
+	}
+
+	closed.Deployment.Spec.DeploymentID = "s-new:opts"
+	clusterClosed.Deployment.Spec.DeploymentID = "s-new:opts"
+	if setWaitingForWindowStatus(status, targets, now) != 0 || c.IsTrue(status) {
+		t.Error("expected condition to be cleared")
+	}
+}
@@ -0,0 +1,229 @@
+// Package bundlegraph stores the dependency graph of the bundles in a workspace in a config map. (fleetcontroller)
+//
+// The graph is available as JSON and in the DOT format, so UIs and the CLI
+// can show which bundles block others during a rollout.
+package bundlegraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/summary"
+
+	"github.com/rancher/wrangler/pkg/apply"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/relatedresource"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// ConfigMapName is the name of the config map in each workspace,
+	// which contains the graph
+	ConfigMapName = "fleet-bundle-graph"
+	// JSONKey is the config map key of the graph in JSON
+	JSONKey = "graph.json"
+	// DOTKey is the config map key of the graph in the DOT format
+	DOTKey = "graph.dot"
+
+	// StateMissing is the state of a dependency, which matches no bundle
+	StateMissing = "Missing"
+)
+
+// Graph is the dependency graph of the bundles in a workspace
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Node is a bundle
+type Node struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+	State string `json:"state,omitempty"`
+}
+
+// Edge points from a bundle to a bundle it depends on. It's blocking if
+// the dependency is not ready.
+type Edge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Blocking bool   `json:"blocking"`
+}
+
+type handler struct {
+	apply   apply.Apply
+	bundles fleetcontrollers.BundleCache
+}
+
+func Register(ctx context.Context,
+	apply apply.Apply,
+	namespaces corecontrollers.NamespaceController,
+	bundles fleetcontrollers.BundleController,
+) {
+	h := &handler{
+		apply:   apply.WithSetID("fleet-bundle-graph"),
+		bundles: bundles.Cache(),
+	}
+
+	namespaces.OnChange(ctx, "bundle-graph", h.OnNamespace)
+	relatedresource.WatchClusterScoped(ctx, "bundle-graph-resolver", resolveNamespace, namespaces, bundles)
+}
+
+func resolveNamespace(namespace, _ string, _ runtime.Object) ([]relatedresource.Key, error) {
+	return []relatedresource.Key{{Name: namespace}}, nil
+}
+
+func (h *handler) OnNamespace(key string, namespace *corev1.Namespace) (*corev1.Namespace, error) {
+	if namespace == nil || namespace.DeletionTimestamp != nil {
+		return namespace, nil
+	}
+
+	bundles, err := h.bundles.List(namespace.Name, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []runtime.Object
+	if hasDependencies(bundles) {
+		cm, err := configMap(namespace.Name, Build(bundles))
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, cm)
+	}
+
+	return namespace, h.apply.
+		WithOwner(namespace).
+		ApplyObjects(objs...)
+}
+
+func hasDependencies(bundles []*fleet.Bundle) bool {
+	for _, bundle := range bundles {
+		if len(bundle.Spec.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Build returns the dependency graph of the bundles, which must be in the
+// same namespace (pure function)
+func Build(bundles []*fleet.Bundle) Graph {
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].Name < bundles[j].Name
+	})
+
+	graph := Graph{Nodes: []Node{}, Edges: []Edge{}}
+	ready := map[string]bool{}
+	for _, bundle := range bundles {
+		ready[bundle.Name] = summary.IsReady(bundle.Status.Summary)
+		graph.Nodes = append(graph.Nodes, Node{
+			Name:  bundle.Name,
+			Ready: ready[bundle.Name],
+			State: string(summary.GetSummaryState(bundle.Status.Summary)),
+		})
+	}
+
+	missing := map[string]bool{}
+	for _, bundle := range bundles {
+		for _, dep := range bundle.Spec.DependsOn {
+			targets := dependencies(bundles, dep)
+			if len(targets) == 0 && dep.Name != "" {
+				if !missing[dep.Name] {
+					missing[dep.Name] = true
+					graph.Nodes = append(graph.Nodes, Node{Name: dep.Name, State: StateMissing})
+				}
+				graph.Edges = append(graph.Edges, Edge{From: bundle.Name, To: dep.Name, Blocking: true})
+				continue
+			}
+			for _, target := range targets {
+				graph.Edges = append(graph.Edges, Edge{From: bundle.Name, To: target, Blocking: !ready[target]})
+			}
+		}
+	}
+
+	return graph
+}
+
+// dependencies returns the names of the bundles matching ref
+func dependencies(bundles []*fleet.Bundle, ref fleet.BundleRef) []string {
+	if ref.Name == "" && ref.Selector == nil {
+		return nil
+	}
+
+	selector := labels.Everything()
+	if ref.Selector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(ref.Selector)
+		if err != nil {
+			return nil
+		}
+	}
+
+	var result []string
+	for _, bundle := range bundles {
+		if ref.Name != "" && bundle.Name != ref.Name {
+			continue
+		}
+		if selector.Matches(labels.Set(bundle.Labels)) {
+			result = append(result, bundle.Name)
+		}
+	}
+	return result
+}
+
+// DOT renders the graph in the DOT format, blocking edges and bundles,
+// which are not ready, are highlighted
+func (g Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph bundles {\n")
+	for _, n := range g.Nodes {
+		color := "green"
+		if !n.Ready {
+			color = "red"
+		}
+		label := n.Name
+		if n.State != "" {
+			label += " (" + n.State + ")"
+		}
+		fmt.Fprintf(&b, "  %q [label=%q, color=%s];\n", n.Name, label, color)
+	}
+	for _, e := range g.Edges {
+		style := "solid"
+		if e.Blocking {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  %q -> %q [style=%s];\n", e.From, e.To, style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func configMap(namespace string, graph Graph) (*corev1.ConfigMap, error) {
+	data, err := json.Marshal(graph)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				fleet.ManagedLabel: "true",
+			},
+		},
+		Data: map[string]string{
+			JSONKey: string(data),
+			DOTKey:  graph.DOT(),
+		},
+	}, nil
+}
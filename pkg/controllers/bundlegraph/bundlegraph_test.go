@@ -0,0 +1,53 @@
+package bundlegraph
+
+import (
+	"strings"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuild(t *testing.T) {
+	bundle := func(name string, ready bool, lbls map[string]string, deps ...fleet.BundleRef) *fleet.Bundle {
+		b := &fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: lbls}}
+		b.Spec.DependsOn = deps
+		b.Status.Summary.DesiredReady = 1
+		if ready {
+			b.Status.Summary.Ready = 1
+		}
+		return b
+	}
+
+	graph := Build([]*fleet.Bundle{
+		bundle("app", false, nil,
+			fleet.BundleRef{Name: "crds"},
+			fleet.BundleRef{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "db"}}},
+			fleet.BundleRef{Name: "gone"}),
+		bundle("crds", true, nil),
+		bundle("postgres", false, map[string]string{"role": "db"}),
+	})
+
+	if len(graph.Nodes) != 4 || graph.Nodes[3].Name != "gone" || graph.Nodes[3].State != StateMissing {
+		t.Errorf("unexpected nodes %+v", graph.Nodes)
+	}
+
+	expected := []Edge{
+		{From: "app", To: "crds", Blocking: false},
+		{From: "app", To: "postgres", Blocking: true},
+		{From: "app", To: "gone", Blocking: true},
+	}
+	if len(graph.Edges) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, graph.Edges)
+	}
+	for i := range expected {
+		if graph.Edges[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], graph.Edges[i])
+		}
+	}
+
+	if dot := graph.DOT(); !strings.Contains(dot, `"app" -> "postgres" [style=dashed];`) {
+		t.Errorf("unexpected dot output:\n%s", dot)
+	}
+}
@@ -0,0 +1,366 @@
+// Package chartversion registers a controller, which checks helm repos for
+// newer chart versions matching a bundle's version range. (fleetcontroller)
+//
+// New versions are reported in the bundle status. With the "auto" update
+// policy they are rolled out by re-creating the bundle from its git repo,
+// with the "commit" policy the version in fleet.yaml is updated and pushed
+// to the git repo, like the image scan write-back.
+package chartversion
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/bundlereader"
+	"github.com/rancher/fleet/pkg/durations"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/git"
+	"github.com/rancher/fleet/pkg/gitrepodefaults"
+	"github.com/rancher/fleet/pkg/update"
+
+	corev1controller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type handler struct {
+	ctx             context.Context
+	bundles         fleetcontrollers.BundleController
+	gitRepos        fleetcontrollers.GitRepoController
+	gitRepoDefaults fleetcontrollers.GitRepoDefaultsCache
+	secretCache     corev1controller.SecretCache
+	git             git.Client
+
+	lock      sync.Mutex
+	lastCheck map[string]time.Time
+}
+
+func Register(ctx context.Context,
+	bundles fleetcontrollers.BundleController,
+	gitRepos fleetcontrollers.GitRepoController,
+	gitRepoDefaults fleetcontrollers.GitRepoDefaultsCache,
+	secrets corev1controller.SecretController) {
+	h := &handler{
+		ctx:             ctx,
+		bundles:         bundles,
+		gitRepos:        gitRepos,
+		gitRepoDefaults: gitRepoDefaults,
+		secretCache:     secrets.Cache(),
+		git:             git.NewClient(),
+		lastCheck:       map[string]time.Time{},
+	}
+
+	bundles.OnChange(ctx, "chart-version", h.OnBundleChange)
+}
+
+// OnBundleChange looks up the latest chart versions for the bundle's charts,
+// which use a version range. It's run at most once per interval per bundle.
+func (h *handler) OnBundleChange(key string, bundle *fleet.Bundle) (*fleet.Bundle, error) {
+	if bundle == nil || bundle.DeletionTimestamp != nil {
+		h.lock.Lock()
+		delete(h.lastCheck, key)
+		h.lock.Unlock()
+		return bundle, nil
+	}
+
+	charts := rangeCharts(bundle)
+	if len(charts) == 0 {
+		return bundle, nil
+	}
+
+	h.lock.Lock()
+	last, ok := h.lastCheck[key]
+	if ok && time.Since(last) < durations.DefaultChartVersionInterval {
+		h.lock.Unlock()
+		return bundle, nil
+	}
+	h.lastCheck[key] = time.Now()
+	h.lock.Unlock()
+	h.bundles.EnqueueAfter(bundle.Namespace, bundle.Name, durations.DefaultChartVersionInterval)
+
+	auth, err := h.auth(bundle)
+	if err != nil {
+		return bundle, err
+	}
+
+	var (
+		status   = bundle.Status.DeepCopy()
+		roll     bool
+		versions []update.ChartVersion
+	)
+	for i, digest := range status.ChartDigests {
+		helm := findChart(charts, digest)
+		if helm == nil {
+			continue
+		}
+
+		lookup := *helm
+		lookup.Version = versionRange(helm)
+		latest, err := bundlereader.LatestChartVersion(&lookup, auth)
+		if err != nil {
+			logrus.Warnf("Failed to look up latest version of chart %s in %s for bundle %s: %v", helm.Chart, helm.Repo, key, err)
+			continue
+		}
+		status.ChartDigests[i].LatestVersion = latest
+
+		if digest.ResolvedVersion == "" || latest == digest.ResolvedVersion {
+			continue
+		}
+		switch helm.VersionUpdatePolicy {
+		case fleet.VersionUpdateAuto:
+			logrus.Infof("Chart %s of bundle %s has new version %s, updating from %s", helm.Chart, key, latest, digest.ResolvedVersion)
+			roll = true
+		case fleet.VersionUpdateCommit:
+			logrus.Infof("Chart %s of bundle %s has new version %s, committing update from %s", helm.Chart, key, latest, digest.ResolvedVersion)
+			versions = append(versions, update.ChartVersion{
+				Chart:        helm.Chart,
+				Repo:         helm.Repo,
+				VersionRange: helm.VersionRange,
+				Version:      latest,
+			})
+		}
+	}
+
+	if !equalDigests(status.ChartDigests, bundle.Status.ChartDigests) {
+		bundle = bundle.DeepCopy()
+		bundle.Status = *status
+		var err error
+		if bundle, err = h.bundles.UpdateStatus(bundle); err != nil {
+			return bundle, err
+		}
+	}
+
+	if len(versions) > 0 {
+		if err := h.commitVersions(bundle, versions); err != nil {
+			return bundle, err
+		}
+	}
+
+	if roll {
+		if err := h.syncGitRepo(bundle); err != nil {
+			return bundle, err
+		}
+	}
+
+	return bundle, nil
+}
+
+// syncGitRepo forces the git repo, which created the bundle, to re-create its
+// bundles, so that the chart version ranges are resolved again. It counts up
+// an annotation, as the ForceSyncGeneration in the spec is owned by the user.
+func (h *handler) syncGitRepo(bundle *fleet.Bundle) error {
+	repoName := bundle.Labels[fleet.RepoLabel]
+	if repoName == "" {
+		return nil
+	}
+
+	gitrepo, err := h.gitRepos.Get(bundle.Namespace, repoName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	gitrepo = gitrepo.DeepCopy()
+	if gitrepo.Annotations == nil {
+		gitrepo.Annotations = map[string]string{}
+	}
+	generation, _ := strconv.ParseInt(gitrepo.Annotations[fleet.ChartVersionSyncAnnotation], 10, 64)
+	gitrepo.Annotations[fleet.ChartVersionSyncAnnotation] = strconv.FormatInt(generation+1, 10)
+	_, err = h.gitRepos.Update(gitrepo)
+	return err
+}
+
+// commitVersions sets the new chart versions in the fleet.yaml files of the
+// git repo, which created the bundle, and pushes the change. The new commit
+// makes the git job re-create the bundle.
+func (h *handler) commitVersions(bundle *fleet.Bundle, versions []update.ChartVersion) error {
+	gitrepo, err := h.gitRepo(bundle)
+	if err != nil || gitrepo == nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(h.ctx, durations.ImageSyncTimeout)
+	defer cancel()
+
+	tmp, err := os.MkdirTemp("", fmt.Sprintf("%s-%s", gitrepo.Namespace, gitrepo.Name))
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	auth, err := h.writeAuth(gitrepo)
+	if err != nil {
+		return err
+	}
+
+	repo, err := h.git.Clone(ctx, tmp, &git.Options{
+		URL:             gitrepo.Spec.Repo,
+		Branch:          gitrepo.Spec.Branch,
+		Auth:            auth,
+		CABundle:        gitrepo.Spec.CABundle,
+		InsecureSkipTLS: gitrepo.Spec.InsecureSkipTLSverify,
+		Depth:           1,
+	})
+	if err != nil {
+		return err
+	}
+
+	paths := gitrepo.Spec.Paths
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+	for _, path := range paths {
+		if _, err := update.WithChartVersions(filepath.Join(tmp, path), versions); err != nil {
+			return err
+		}
+	}
+
+	var msg []string
+	for _, v := range versions {
+		msg = append(msg, fmt.Sprintf("Update chart %s to %s", v.Chart, v.Version))
+	}
+	commit, err := repo.CommitAllAndPush(ctx, strings.Join(msg, "\n"), git.Signature{
+		Name:  gitrepo.Spec.ImageScanCommit.AuthorName,
+		Email: gitrepo.Spec.ImageScanCommit.AuthorEmail,
+	})
+	if err != nil {
+		return err
+	}
+	if commit != "" {
+		logrus.Infof("Repo %s, commit %s pushed", gitrepo.Spec.Repo, commit)
+	}
+	return nil
+}
+
+// writeAuth returns the credentials to push to the git repo, like for the
+// image scan write-back.
+func (h *handler) writeAuth(gitrepo *fleet.GitRepo) (transport.AuthMethod, error) {
+	if gitrepo.Spec.ClientSecretName == "" {
+		return nil, fmt.Errorf("versionUpdatePolicy %s requires git secret for write access", fleet.VersionUpdateCommit)
+	}
+
+	secret, err := h.secretCache.Get(gitrepo.Namespace, gitrepo.Spec.ClientSecretName)
+	if err != nil {
+		return nil, err
+	}
+
+	return git.AuthFromSecret(secret, gitrepo.Spec.Repo)
+}
+
+// gitRepo returns the git repo, which created the bundle, with the
+// inherited defaults. It returns nil if there is none.
+func (h *handler) gitRepo(bundle *fleet.Bundle) (*fleet.GitRepo, error) {
+	repoName := bundle.Labels[fleet.RepoLabel]
+	if repoName == "" {
+		return nil, nil
+	}
+	gitrepo, err := h.gitRepos.Cache().Get(bundle.Namespace, repoName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return gitrepodefaults.Inherit(h.gitRepoDefaults, gitrepo)
+}
+
+// auth returns the helm repo credentials of the git repo, which created the
+// bundle.
+func (h *handler) auth(bundle *fleet.Bundle) (bundlereader.Auth, error) {
+	auth := bundlereader.Auth{}
+
+	gitrepo, err := h.gitRepo(bundle)
+	if err != nil || gitrepo == nil {
+		return auth, err
+	}
+	if gitrepo.Spec.HelmSecretName == "" {
+		return auth, nil
+	}
+
+	secret, err := h.secretCache.Get(gitrepo.Namespace, gitrepo.Spec.HelmSecretName)
+	if err != nil {
+		return auth, err
+	}
+	auth.Username = string(secret.Data["username"])
+	auth.Password = string(secret.Data["password"])
+	auth.CABundle = secret.Data["cacerts"]
+
+	return auth, nil
+}
+
+// rangeCharts returns the helm options of the bundle, which download a chart
+// from a helm repo with a version range.
+func rangeCharts(bundle *fleet.Bundle) []*fleet.HelmOptions {
+	helms := []*fleet.HelmOptions{bundle.Spec.Helm}
+	for _, target := range bundle.Spec.Targets {
+		helms = append(helms, target.Helm)
+	}
+
+	var result []*fleet.HelmOptions
+	for _, helm := range helms {
+		if helm == nil || helm.Repo == "" || versionRange(helm) == "" {
+			continue
+		}
+		result = append(result, helm)
+	}
+	return result
+}
+
+// versionRange returns the version range to look for new versions in. With
+// the "commit" policy the version is a single version and the range is
+// separate.
+func versionRange(helm *fleet.HelmOptions) string {
+	if helm.VersionUpdatePolicy == fleet.VersionUpdateCommit {
+		return helm.VersionRange
+	}
+	if isRange(helm.Version) {
+		return helm.Version
+	}
+	return ""
+}
+
+// isRange returns true if version is a semver constraint and not a single
+// version.
+func isRange(version string) bool {
+	if version == "" {
+		return false
+	}
+	if _, err := semver.NewVersion(version); err == nil {
+		return false
+	}
+	_, err := semver.NewConstraint(version)
+	return err == nil
+}
+
+func findChart(charts []*fleet.HelmOptions, digest fleet.ChartDigest) *fleet.HelmOptions {
+	for _, helm := range charts {
+		if helm.Chart == digest.Chart && helm.Repo == digest.Repo && helm.Version == digest.Version {
+			return helm
+		}
+	}
+	return nil
+}
+
+func equalDigests(a, b []fleet.ChartDigest) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+package chartversion
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/git"
+	"github.com/rancher/fleet/pkg/update"
+
+	corev1controller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type fakeGitRepos struct {
+	fleetcontrollers.GitRepoController
+	gitrepo *fleet.GitRepo
+}
+
+func (f *fakeGitRepos) Cache() fleetcontrollers.GitRepoCache {
+	return fakeGitRepoCache{gitRepos: f}
+}
+
+func (f *fakeGitRepos) Get(namespace, name string, opts metav1.GetOptions) (*fleet.GitRepo, error) {
+	return f.gitrepo, nil
+}
+
+func (f *fakeGitRepos) Update(gitrepo *fleet.GitRepo) (*fleet.GitRepo, error) {
+	f.gitrepo = gitrepo
+	return gitrepo, nil
+}
+
+type fakeGitRepoCache struct {
+	fleetcontrollers.GitRepoCache
+	gitRepos *fakeGitRepos
+}
+
+func (f fakeGitRepoCache) Get(namespace, name string) (*fleet.GitRepo, error) {
+	return f.gitRepos.gitrepo, nil
+}
+
+type fakeDefaultsCache struct {
+	fleetcontrollers.GitRepoDefaultsCache
+}
+
+func (fakeDefaultsCache) List(namespace string, selector labels.Selector) ([]*fleet.GitRepoDefaults, error) {
+	return nil, nil
+}
+
+type fakeSecretCache struct {
+	corev1controller.SecretCache
+}
+
+func (fakeSecretCache) Get(namespace, name string) (*corev1.Secret, error) {
+	return &corev1.Secret{
+		Type: corev1.SecretTypeBasicAuth,
+		Data: map[string][]byte{corev1.BasicAuthUsernameKey: []byte("fleet"), corev1.BasicAuthPasswordKey: []byte("secret")},
+	}, nil
+}
+
+// fakeGit clones a repository containing a single fleet.yaml
+type fakeGit struct {
+	git.Client
+	fleetYAML string
+	opts      *git.Options
+	repo      *fakeRepository
+}
+
+func (f *fakeGit) Clone(ctx context.Context, dir string, opts *git.Options) (git.Repository, error) {
+	f.opts = opts
+	if err := os.MkdirAll(filepath.Join(dir, "app"), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app", "fleet.yaml"), []byte(f.fleetYAML), 0644); err != nil {
+		return nil, err
+	}
+	f.repo = &fakeRepository{dir: dir}
+	return f.repo, nil
+}
+
+type fakeRepository struct {
+	dir       string
+	message   string
+	author    git.Signature
+	fleetYAML string
+}
+
+func (f *fakeRepository) CommitAllAndPush(ctx context.Context, message string, author git.Signature) (string, error) {
+	data, err := os.ReadFile(filepath.Join(f.dir, "app", "fleet.yaml"))
+	if err != nil {
+		return "", err
+	}
+	f.message, f.author, f.fleetYAML = message, author, string(data)
+	return "abc", nil
+}
+
+func TestRangeCharts(t *testing.T) {
+	bundle := &fleet.Bundle{
+		Spec: fleet.BundleSpec{
+			BundleDeploymentOptions: fleet.BundleDeploymentOptions{
+				Helm: &fleet.HelmOptions{Chart: "app", Repo: "https://charts.example.com", Version: "~1.2.0"},
+			},
+			Targets: []fleet.BundleTarget{
+				{BundleDeploymentOptions: fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{Chart: "app", Repo: "https://charts.example.com", Version: "1.2.3"}}},
+				{BundleDeploymentOptions: fleet.BundleDeploymentOptions{Helm: &fleet.HelmOptions{Chart: "./local", Version: ">=1"}}},
+				{},
+			},
+		},
+	}
+
+	charts := rangeCharts(bundle)
+	if len(charts) != 1 || charts[0].Version != "~1.2.0" {
+		t.Errorf("expected only the chart with a version range, got %v", charts)
+	}
+}
+
+func TestVersionRange(t *testing.T) {
+	tests := []struct {
+		helm     fleet.HelmOptions
+		expected string
+	}{
+		{fleet.HelmOptions{Version: "~1.2.0"}, "~1.2.0"},
+		{fleet.HelmOptions{Version: "1.2.0"}, ""},
+		{fleet.HelmOptions{Version: "1.2.0", VersionRange: "~1.2.0", VersionUpdatePolicy: fleet.VersionUpdateCommit}, "~1.2.0"},
+	}
+	for _, test := range tests {
+		if actual := versionRange(&test.helm); actual != test.expected {
+			t.Errorf("expected version range %q for %+v, got %q", test.expected, test.helm, actual)
+		}
+	}
+}
+
+func TestCommitVersions(t *testing.T) {
+	gitRepos := &fakeGitRepos{gitrepo: &fleet.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "repo"},
+		Spec: fleet.GitRepoSpec{
+			Repo:             "https://git.example.com/repo",
+			Branch:           "main",
+			ClientSecretName: "git-auth",
+			Paths:            []string{"app"},
+			ImageScanCommit:  fleet.CommitSpec{AuthorName: "fleet", AuthorEmail: "fleet@example.com"},
+		},
+	}}
+	g := &fakeGit{fleetYAML: "helm:\n  chart: app\n  repo: https://charts.example.com\n  version: 1.2.0\n  versionRange: ~1.2.0\n  versionUpdatePolicy: commit\n"}
+	h := &handler{
+		ctx:             context.Background(),
+		gitRepos:        gitRepos,
+		gitRepoDefaults: fakeDefaultsCache{},
+		secretCache:     fakeSecretCache{},
+		git:             g,
+	}
+	bundle := &fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "repo-app", Labels: map[string]string{fleet.RepoLabel: "repo"}}}
+
+	err := h.commitVersions(bundle, []update.ChartVersion{{Chart: "app", Repo: "https://charts.example.com", VersionRange: "~1.2.0", Version: "1.2.3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.opts.Branch != "main" || g.opts.Auth == nil {
+		t.Errorf("expected the branch to be cloned with write credentials, got %+v", g.opts)
+	}
+	if !strings.Contains(g.repo.fleetYAML, "version: 1.2.3") {
+		t.Errorf("expected the new version to be committed, got:\n%s", g.repo.fleetYAML)
+	}
+	if g.repo.message != "Update chart app to 1.2.3" || g.repo.author.Email != "fleet@example.com" {
+		t.Errorf("unexpected commit %q by %+v", g.repo.message, g.repo.author)
+	}
+}
+
+func TestSyncGitRepo(t *testing.T) {
+	gitRepos := &fakeGitRepos{gitrepo: &fleet.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "repo"},
+		Spec:       fleet.GitRepoSpec{ForceSyncGeneration: 3},
+	}}
+	h := &handler{gitRepos: gitRepos}
+	bundle := &fleet.Bundle{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "repo-app", Labels: map[string]string{fleet.RepoLabel: "repo"}}}
+
+	for i := 0; i < 2; i++ {
+		if err := h.syncGitRepo(bundle); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if gitRepos.gitrepo.Spec.ForceSyncGeneration != 3 {
+		t.Errorf("expected the user's sync generation to be kept, got %d", gitRepos.gitrepo.Spec.ForceSyncGeneration)
+	}
+	if v := gitRepos.gitrepo.Annotations[fleet.ChartVersionSyncAnnotation]; v != "2" {
+		t.Errorf("expected the sync annotation to be counted up, got %q", v)
+	}
+}
@@ -67,6 +67,7 @@ func Register(ctx context.Context,
 		h.OnClusterChanged)
 
 	relatedresource.Watch(ctx, "managed-cluster", h.findClusters(namespaces.Cache()), clusters, bundleDeployment)
+	bundleDeployment.OnChange(ctx, "cluster-tombstone", h.recordTombstone)
 }
 
 func (h *handler) ensureNSDeleted(key string, obj *fleet.Cluster) (*fleet.Cluster, error) {
@@ -142,6 +143,8 @@ func (h *handler) OnClusterChanged(cluster *fleet.Cluster, status fleet.ClusterS
 		return status, err
 	}
 
+	prunePendingDeletions(&status, bundleDeployments)
+
 	status.DesiredReadyGitRepos = 0
 	status.ReadyGitRepos = 0
 	status.ResourceCounts = fleet.GitRepoResourceCounts{}
@@ -163,6 +166,7 @@ func (h *handler) OnClusterChanged(cluster *fleet.Cluster, status fleet.ClusterS
 			repos[repoKey{repo: repo, ns: ns}] = (state == fleet.Ready) || repos[repoKey{repo: repo, ns: ns}]
 		}
 	}
+	summary.LimitErrors(&status.Summary)
 
 	allReady := true
 	for repo, ready := range repos {
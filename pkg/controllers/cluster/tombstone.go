@@ -0,0 +1,89 @@
+package cluster
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/cloudevents"
+
+	"github.com/rancher/wrangler/pkg/kv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// recordTombstone adds a deleted bundle deployment to the pending deletions
+// of its cluster, if the cluster's agent is offline, so the agent
+// uninstalls it when it reconnects.
+func (h *handler) recordTombstone(key string, bd *fleet.BundleDeployment) (*fleet.BundleDeployment, error) {
+	if bd != nil {
+		return bd, nil
+	}
+
+	namespace, name := kv.Split(key, "/")
+	ns, err := h.namespaceCache.Get(namespace)
+	if err != nil {
+		// the cluster namespace is deleted along with its cluster
+		return nil, nil
+	}
+	clusterNS := ns.Annotations[fleet.ClusterNamespaceAnnotation]
+	clusterName := ns.Annotations[fleet.ClusterAnnotation]
+	if clusterNS == "" || clusterName == "" {
+		return nil, nil
+	}
+
+	cluster, err := h.clusterCache.Get(clusterNS, clusterName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if cluster.DeletionTimestamp != nil || !agentOffline(cluster, time.Now()) {
+		return nil, nil
+	}
+	for _, t := range cluster.Status.PendingDeletions {
+		if t.Name == name {
+			return nil, nil
+		}
+	}
+
+	logrus.Infof("Bundle deployment %s was deleted while the agent of cluster %s/%s is offline, uninstalling it once the agent reconnects", key, clusterNS, clusterName)
+	cluster = cluster.DeepCopy()
+	cluster.Status.PendingDeletions = append(cluster.Status.PendingDeletions, fleet.BundleDeploymentTombstone{
+		Name:      name,
+		DeletedAt: metav1.Now(),
+	})
+	_, err = h.clusters.UpdateStatus(cluster)
+	return nil, err
+}
+
+// agentOffline returns true, if the cluster's agent didn't check in
+// recently
+func agentOffline(cluster *fleet.Cluster, now time.Time) bool {
+	lastSeen := cluster.Status.Agent.LastSeen
+	return lastSeen.IsZero() || now.Sub(lastSeen.Time) > cloudevents.OfflineThreshold()
+}
+
+// prunePendingDeletions removes the pending deletions, which the agent
+// completed or whose bundle deployment was created again.
+func prunePendingDeletions(status *fleet.ClusterStatus, bundleDeployments []*fleet.BundleDeployment) {
+	if len(status.PendingDeletions) == 0 {
+		return
+	}
+
+	done := sets.NewString(status.Agent.CompletedDeletions...)
+	for _, bd := range bundleDeployments {
+		done.Insert(bd.Name)
+	}
+
+	var pending []fleet.BundleDeploymentTombstone
+	for _, t := range status.PendingDeletions {
+		if !done.Has(t.Name) {
+			pending = append(pending, t)
+		}
+	}
+	status.PendingDeletions = pending
+}
@@ -9,10 +9,10 @@ import (
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/match"
 	"github.com/rancher/fleet/pkg/summary"
 
 	"github.com/rancher/wrangler/pkg/kv"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
@@ -64,19 +64,20 @@ func (h *handler) OnClusterChange(key string, cluster *fleet.Cluster) (*fleet.Cl
 	}
 
 	for _, cg := range cgs {
-		if cg.Spec.Selector == nil {
-			continue
-		}
-		sel, err := metav1.LabelSelectorAsSelector(cg.Spec.Selector)
+		m, err := match.NewClusterGroupMatcher(cg)
 		if err != nil {
 			logrus.Errorf("invalid selector on clustergroup %s/%s: %v", cg.Namespace, cg.Name, err)
 			continue
 		}
-		if sel.Matches(labels.Set(cluster.Labels)) {
+		if m == nil {
+			continue
+		}
+		if m.Match(cluster) {
 			h.clusterGroups.Enqueue(cg.Namespace, cg.Name)
+			continue
 		}
 		// if cluster is removed from CG, need to reconcile if ClusterCount doesnt match
-		clusters, err := h.clusterCache.List(cg.Namespace, sel)
+		clusters, err := h.members(cg.Namespace, m)
 		if err != nil {
 			logrus.Errorf("error fetching clusters in clustergroup %s%s: %v", cg.Namespace, cg.Name, err)
 		}
@@ -88,18 +89,33 @@ func (h *handler) OnClusterChange(key string, cluster *fleet.Cluster) (*fleet.Cl
 	return cluster, nil
 }
 
-func (h *handler) OnClusterGroup(clusterGroup *fleet.ClusterGroup, status fleet.ClusterGroupStatus) (fleet.ClusterGroupStatus, error) {
-	var clusters []*fleet.Cluster
-	if clusterGroup.Spec.Selector != nil {
-		sel, err := metav1.LabelSelectorAsSelector(clusterGroup.Spec.Selector)
-		if err != nil {
-			return status, err
+// members returns the clusters in the namespace, which match the cluster
+// group, or none if the matcher is nil
+func (h *handler) members(namespace string, m *match.ClusterGroupMatcher) ([]*fleet.Cluster, error) {
+	if m == nil {
+		return nil, nil
+	}
+	clusters, err := h.clusterCache.List(namespace, m.Selector())
+	if err != nil {
+		return nil, err
+	}
+	var result []*fleet.Cluster
+	for _, cluster := range clusters {
+		if m.Match(cluster) {
+			result = append(result, cluster)
 		}
+	}
+	return result, nil
+}
 
-		clusters, err = h.clusterCache.List(clusterGroup.Namespace, sel)
-		if err != nil {
-			return status, err
-		}
+func (h *handler) OnClusterGroup(clusterGroup *fleet.ClusterGroup, status fleet.ClusterGroupStatus) (fleet.ClusterGroupStatus, error) {
+	m, err := match.NewClusterGroupMatcher(clusterGroup)
+	if err != nil {
+		return status, err
+	}
+	clusters, err := h.members(clusterGroup.Namespace, m)
+	if err != nil {
+		return status, err
 	}
 
 	logrus.Debugf("ClusterGroupStatusHandler for '%s/%s', updating its status summary", clusterGroup.Namespace, clusterGroup.Name)
@@ -125,6 +141,7 @@ func (h *handler) OnClusterGroup(clusterGroup *fleet.ClusterGroup, status fleet.
 			}
 		}
 	}
+	summary.LimitErrors(&status.Summary)
 
 	summary.SetReadyConditions(&status, "Bundle", status.Summary)
 	return status, nil
@@ -0,0 +1,200 @@
+// Package clusterimport imports downstream clusters from existing secrets. (fleetcontroller)
+//
+// A ClusterImport refers to a secret in one of the supported formats, e.g.
+// created by Cluster API or Rancher. The controller converts it into a
+// kubeconfig secret in the format fleet expects and creates a cluster
+// resource, which uses it. Both are owned by the ClusterImport.
+package clusterimport
+
+import (
+	"context"
+	"fmt"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+
+	"github.com/rancher/wrangler/pkg/apply"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/name"
+	"github.com/rancher/wrangler/pkg/relatedresource"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// kubeConfigKey is the key of the kubeconfig in secrets referenced by
+	// Cluster.Spec.KubeConfigSecret
+	kubeConfigKey = "value"
+)
+
+type handler struct {
+	clusterImports fleetcontrollers.ClusterImportCache
+	secrets        corecontrollers.SecretCache
+}
+
+func Register(ctx context.Context,
+	apply apply.Apply,
+	clusterImports fleetcontrollers.ClusterImportController,
+	secrets corecontrollers.SecretController,
+) {
+	h := &handler{
+		clusterImports: clusterImports.Cache(),
+		secrets:        secrets.Cache(),
+	}
+
+	fleetcontrollers.RegisterClusterImportGeneratingHandler(ctx,
+		clusterImports,
+		apply,
+		"Imported",
+		"cluster-import",
+		h.OnChange,
+		nil)
+
+	relatedresource.Watch(ctx, "secret-to-cluster-import", h.resolveSecret, clusterImports, secrets)
+}
+
+// resolveSecret enqueues the cluster imports using the changed secret
+func (h *handler) resolveSecret(namespace, name string, obj runtime.Object) ([]relatedresource.Key, error) {
+	if _, ok := obj.(*corev1.Secret); !ok {
+		return nil, nil
+	}
+	imports, err := h.clusterImports.List(namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var keys []relatedresource.Key
+	for _, ci := range imports {
+		if ci.Spec.SecretName == name {
+			keys = append(keys, relatedresource.Key{Namespace: ci.Namespace, Name: ci.Name})
+		}
+	}
+	return keys, nil
+}
+
+func (h *handler) OnChange(ci *fleet.ClusterImport, status fleet.ClusterImportStatus) ([]runtime.Object, fleet.ClusterImportStatus, error) {
+	if ci.Spec.SecretName == "" {
+		return nil, status, fmt.Errorf("cluster import %s/%s: secretName is required", ci.Namespace, ci.Name)
+	}
+
+	secret, err := h.secrets.Get(ci.Namespace, ci.Spec.SecretName)
+	if err != nil {
+		return nil, status, err
+	}
+
+	data, err := kubeConfig(ci.Spec, secret)
+	if err != nil {
+		return nil, status, fmt.Errorf("cluster import %s/%s: %w", ci.Namespace, ci.Name, err)
+	}
+
+	clusterName := ci.Spec.ClusterName
+	if clusterName == "" {
+		clusterName = ci.Name
+	}
+	secretName := name.SafeConcatName(ci.Name, "kubeconfig")
+
+	kubeConfigSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: ci.Namespace,
+		},
+		Data: map[string][]byte{
+			kubeConfigKey: data,
+		},
+	}
+	// the agent's connection settings are passed through
+	for _, key := range []string{"apiServerURL", "apiServerCA"} {
+		if v, ok := secret.Data[key]; ok {
+			kubeConfigSecret.Data[key] = v
+		}
+	}
+
+	cluster := &fleet.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName,
+			Namespace: ci.Namespace,
+			Labels:    ci.Spec.ClusterLabels,
+		},
+		Spec: fleet.ClusterSpec{
+			KubeConfigSecret: secretName,
+		},
+	}
+
+	status.ClusterName = clusterName
+	status.SecretName = secretName
+	return []runtime.Object{kubeConfigSecret, cluster}, status, nil
+}
+
+// kubeConfig converts the secret into a kubeconfig, which only contains the
+// selected context, as expected by Cluster.Spec.KubeConfigSecret.
+func kubeConfig(spec fleet.ClusterImportSpec, secret *corev1.Secret) ([]byte, error) {
+	var (
+		cfg *clientcmdapi.Config
+		err error
+	)
+
+	switch spec.Format {
+	case "", fleet.ClusterImportFormatFleet, fleet.ClusterImportFormatCAPI:
+		cfg, err = load(secret, spec.Key, kubeConfigKey)
+	case fleet.ClusterImportFormatKubeConfig:
+		cfg, err = load(secret, spec.Key, "kubeconfig")
+	case fleet.ClusterImportFormatRancher:
+		cfg, err = rancherKubeConfig(secret)
+	default:
+		return nil, fmt.Errorf("unknown secret format %q", spec.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Context != "" {
+		cfg.CurrentContext = spec.Context
+	}
+	if _, ok := cfg.Contexts[cfg.CurrentContext]; !ok {
+		return nil, fmt.Errorf("context %q not found in secret %s", cfg.CurrentContext, secret.Name)
+	}
+	if err := clientcmdapi.MinifyConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	return clientcmd.Write(*cfg)
+}
+
+func load(secret *corev1.Secret, key, defaultKey string) (*clientcmdapi.Config, error) {
+	if key == "" {
+		key = defaultKey
+	}
+	data, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in secret %s", key, secret.Name)
+	}
+	return clientcmd.Load(data)
+}
+
+// rancherKubeConfig builds a kubeconfig from the API server's url, CA and a
+// bearer token
+func rancherKubeConfig(secret *corev1.Secret) (*clientcmdapi.Config, error) {
+	url, token := string(secret.Data["url"]), string(secret.Data["token"])
+	if url == "" || token == "" {
+		return nil, fmt.Errorf("secret %s needs the keys url and token", secret.Name)
+	}
+
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["cluster"] = &clientcmdapi.Cluster{
+		Server:                   url,
+		CertificateAuthorityData: secret.Data["ca.crt"],
+	}
+	cfg.AuthInfos["user"] = &clientcmdapi.AuthInfo{
+		Token: token,
+	}
+	cfg.Contexts["default"] = &clientcmdapi.Context{
+		Cluster:  "cluster",
+		AuthInfo: "user",
+	}
+	cfg.CurrentContext = "default"
+	return cfg, nil
+}
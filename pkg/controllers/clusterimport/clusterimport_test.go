@@ -0,0 +1,141 @@
+package clusterimport
+
+import (
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: one
+  cluster:
+    server: https://one.example.com
+- name: two
+  cluster:
+    server: https://two.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: one
+  context:
+    cluster: one
+    user: admin
+- name: two
+  context:
+    cluster: two
+    user: admin
+current-context: one
+`
+
+func TestKubeConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   fleet.ClusterImportSpec
+		data   map[string]string
+		server string
+		token  string
+		err    bool
+	}{
+		{
+			name:   "fleet uses the current context",
+			data:   map[string]string{"value": kubeconfig},
+			server: "https://one.example.com",
+			token:  "secret",
+		},
+		{
+			name:   "capi",
+			spec:   fleet.ClusterImportSpec{Format: fleet.ClusterImportFormatCAPI},
+			data:   map[string]string{"value": kubeconfig},
+			server: "https://one.example.com",
+			token:  "secret",
+		},
+		{
+			name:   "kubeconfig with context",
+			spec:   fleet.ClusterImportSpec{Format: fleet.ClusterImportFormatKubeConfig, Context: "two"},
+			data:   map[string]string{"kubeconfig": kubeconfig},
+			server: "https://two.example.com",
+			token:  "secret",
+		},
+		{
+			name:   "kubeconfig with custom key",
+			spec:   fleet.ClusterImportSpec{Format: fleet.ClusterImportFormatKubeConfig, Key: "config"},
+			data:   map[string]string{"config": kubeconfig},
+			server: "https://one.example.com",
+			token:  "secret",
+		},
+		{
+			name:   "rancher",
+			spec:   fleet.ClusterImportSpec{Format: fleet.ClusterImportFormatRancher},
+			data:   map[string]string{"url": "https://rancher.example.com/k8s/clusters/c-1", "token": "kubeconfig-u-1:abc"},
+			server: "https://rancher.example.com/k8s/clusters/c-1",
+			token:  "kubeconfig-u-1:abc",
+		},
+		{
+			name: "missing context",
+			spec: fleet.ClusterImportSpec{Context: "three"},
+			data: map[string]string{"value": kubeconfig},
+			err:  true,
+		},
+		{
+			name: "missing key",
+			spec: fleet.ClusterImportSpec{Format: fleet.ClusterImportFormatKubeConfig},
+			data: map[string]string{"value": kubeconfig},
+			err:  true,
+		},
+		{
+			name: "rancher without token",
+			spec: fleet.ClusterImportSpec{Format: fleet.ClusterImportFormatRancher},
+			data: map[string]string{"url": "https://rancher.example.com"},
+			err:  true,
+		},
+		{
+			name: "unknown format",
+			spec: fleet.ClusterImportSpec{Format: "other"},
+			data: map[string]string{"value": kubeconfig},
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := &corev1.Secret{Data: map[string][]byte{}}
+			secret.Name = "downstream"
+			for k, v := range test.data {
+				secret.Data[k] = []byte(v)
+			}
+
+			data, err := kubeConfig(test.spec, secret)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := clientcmd.Load(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cfg.Contexts) != 1 || len(cfg.Clusters) != 1 {
+				t.Fatalf("expected a single context and cluster, got %v and %v", cfg.Contexts, cfg.Clusters)
+			}
+			ctx := cfg.Contexts[cfg.CurrentContext]
+			if server := cfg.Clusters[ctx.Cluster].Server; server != test.server {
+				t.Errorf("expected server %s, got %s", test.server, server)
+			}
+			if token := cfg.AuthInfos[ctx.AuthInfo].Token; token != test.token {
+				t.Errorf("expected token %s, got %s", test.token, token)
+			}
+		})
+	}
+}
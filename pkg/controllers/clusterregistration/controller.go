@@ -257,6 +257,13 @@ func (h *handler) OnChange(request *fleet.ClusterRegistration, status fleet.Clus
 					Resources:     []string{fleet.ClusterResourceName + "/status"},
 					ResourceNames: []string{cluster.Name},
 				},
+				{
+					// the agent reads the pending deletions
+					Verbs:         []string{"get"},
+					APIGroups:     []string{fleetgroup.GroupName},
+					Resources:     []string{fleet.ClusterResourceName},
+					ResourceNames: []string{cluster.Name},
+				},
 			},
 		},
 		&rbacv1.RoleBinding{
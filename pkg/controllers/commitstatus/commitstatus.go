@@ -0,0 +1,124 @@
+// Package commitstatus reports the deployment state of GitRepos as commit status to GitHub. (fleetcontroller)
+//
+// Statuses are only reported for GitRepos, which authenticate as a GitHub
+// App. The app needs write access to commit statuses.
+package commitstatus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/git"
+	"github.com/rancher/fleet/pkg/gitrepodefaults"
+
+	corev1controller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+type handler struct {
+	ctx             context.Context
+	gitRepoDefaults fleetcontrollers.GitRepoDefaultsCache
+	secretCache     corev1controller.SecretCache
+	setStatus       func(ctx context.Context, repoURL, token, commit string, status git.CommitStatus) error
+
+	// reported holds the last reported status per GitRepo, so it is only
+	// sent when it changes
+	lock     sync.Mutex
+	reported map[string]git.CommitStatus
+}
+
+func Register(ctx context.Context,
+	gitRepos fleetcontrollers.GitRepoController,
+	gitRepoDefaults fleetcontrollers.GitRepoDefaultsCache,
+	secrets corev1controller.SecretCache) {
+	h := &handler{
+		ctx:             ctx,
+		gitRepoDefaults: gitRepoDefaults,
+		secretCache:     secrets,
+		setStatus:       git.SetGitHubCommitStatus,
+		reported:        map[string]git.CommitStatus{},
+	}
+
+	gitRepos.OnChange(ctx, "gitrepo-commit-status", h.OnChange)
+}
+
+// OnChange reports the state of the GitRepo's bundles for its current
+// commit. GitRepos following multiple branches are skipped.
+func (h *handler) OnChange(key string, gitrepo *fleet.GitRepo) (*fleet.GitRepo, error) {
+	if gitrepo == nil || gitrepo.DeletionTimestamp != nil {
+		h.lock.Lock()
+		delete(h.reported, key)
+		h.lock.Unlock()
+		return gitrepo, nil
+	}
+	if gitrepo.Status.Commit == "" || len(gitrepo.Status.Branches) > 0 ||
+		git.DetectProvider(gitrepo.Spec.Repo) != git.ProviderGitHub {
+		return gitrepo, nil
+	}
+
+	inherited, err := gitrepodefaults.Inherit(h.gitRepoDefaults, gitrepo)
+	if err != nil {
+		return gitrepo, err
+	}
+	if inherited.Spec.ClientSecretName == "" {
+		return gitrepo, nil
+	}
+	secret, err := h.secretCache.Get(gitrepo.Namespace, inherited.Spec.ClientSecretName)
+	if err != nil {
+		return gitrepo, err
+	}
+	if secret.Type != corev1.SecretTypeOpaque || secret.Data[git.GitHubAppIDKey] == nil {
+		return gitrepo, nil
+	}
+
+	status := commitStatus(gitrepo)
+	h.lock.Lock()
+	last, ok := h.reported[key]
+	h.lock.Unlock()
+	if ok && last == status {
+		return gitrepo, nil
+	}
+
+	token, err := git.APIToken(secret, gitrepo.Spec.Repo)
+	if err != nil {
+		return gitrepo, err
+	}
+	if err := h.setStatus(h.ctx, gitrepo.Spec.Repo, token, gitrepo.Status.Commit, status); err != nil {
+		logrus.Warnf("Failed to report commit status of gitrepo %s: %v", key, err)
+		return gitrepo, err
+	}
+
+	h.lock.Lock()
+	h.reported[key] = status
+	h.lock.Unlock()
+	return gitrepo, nil
+}
+
+// commitStatus returns the status of the GitRepo's current commit. It is
+// pending until the bundles of the commit are created and ready.
+func commitStatus(gitrepo *fleet.GitRepo) git.CommitStatus {
+	status := git.CommitStatus{
+		State:   git.CommitStatePending,
+		Context: "fleet/" + gitrepo.Namespace + "/" + gitrepo.Name,
+	}
+	summary := gitrepo.Status.Summary
+	switch {
+	case gitrepo.Status.AppliedCommit != gitrepo.Status.Commit:
+		status.Description = "Creating bundles"
+	case summary.ErrApplied > 0:
+		status.State = git.CommitStateFailure
+		status.Description = fmt.Sprintf("%d of %d bundle deployments failed", summary.ErrApplied, summary.DesiredReady)
+	case summary.DesiredReady > 0 && summary.Ready == summary.DesiredReady:
+		status.State = git.CommitStateSuccess
+		status.Description = fmt.Sprintf("%d bundle deployments ready", summary.Ready)
+	default:
+		status.Description = fmt.Sprintf("%d of %d bundle deployments ready", summary.Ready, summary.DesiredReady)
+	}
+	return status
+}
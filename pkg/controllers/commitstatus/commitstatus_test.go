@@ -0,0 +1,92 @@
+package commitstatus
+
+import (
+	"context"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/git"
+
+	corev1controller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type fakeSecretCache struct {
+	corev1controller.SecretCache
+	secret    *corev1.Secret
+	requested string
+}
+
+func (f *fakeSecretCache) Get(namespace, name string) (*corev1.Secret, error) {
+	f.requested = name
+	return f.secret, nil
+}
+
+type fakeDefaultsCache struct {
+	fleetcontrollers.GitRepoDefaultsCache
+	defaults []*fleet.GitRepoDefaults
+}
+
+func (f fakeDefaultsCache) List(namespace string, selector labels.Selector) ([]*fleet.GitRepoDefaults, error) {
+	return f.defaults, nil
+}
+
+func TestCommitStatus(t *testing.T) {
+	gitrepo := &fleet.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "repo"},
+		Status:     fleet.GitRepoStatus{Commit: "b", AppliedCommit: "a"},
+	}
+	if s := commitStatus(gitrepo); s.State != git.CommitStatePending || s.Context != "fleet/fleet-default/repo" {
+		t.Errorf("expected pending status while bundles are created, got %+v", s)
+	}
+
+	gitrepo.Status.AppliedCommit = "b"
+	gitrepo.Status.Summary = fleet.BundleSummary{DesiredReady: 2, Ready: 1}
+	if s := commitStatus(gitrepo); s.State != git.CommitStatePending {
+		t.Errorf("expected pending status, got %+v", s)
+	}
+
+	gitrepo.Status.Summary.ErrApplied = 1
+	if s := commitStatus(gitrepo); s.State != git.CommitStateFailure {
+		t.Errorf("expected failure status, got %+v", s)
+	}
+
+	gitrepo.Status.Summary = fleet.BundleSummary{DesiredReady: 2, Ready: 2}
+	if s := commitStatus(gitrepo); s.State != git.CommitStateSuccess {
+		t.Errorf("expected success status, got %+v", s)
+	}
+}
+
+func TestOnChangeSkipsNonGitHubAppSecrets(t *testing.T) {
+	secrets := &fakeSecretCache{secret: &corev1.Secret{
+		Type: corev1.SecretTypeBasicAuth,
+	}}
+	h := &handler{
+		ctx: context.Background(),
+		gitRepoDefaults: fakeDefaultsCache{defaults: []*fleet.GitRepoDefaults{{
+			Spec: fleet.GitRepoDefaultsSpec{ClientSecretName: "auth"},
+		}}},
+		secretCache: secrets,
+		setStatus: func(ctx context.Context, repoURL, token, commit string, status git.CommitStatus) error {
+			t.Errorf("unexpected commit status for %s", commit)
+			return nil
+		},
+		reported: map[string]git.CommitStatus{},
+	}
+
+	gitrepo := &fleet.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "repo"},
+		Spec:       fleet.GitRepoSpec{Repo: "https://github.com/rancher/fleet"},
+		Status:     fleet.GitRepoStatus{Commit: "b", AppliedCommit: "b"},
+	}
+	if _, err := h.OnChange("fleet-default/repo", gitrepo); err != nil {
+		t.Fatal(err)
+	}
+	if secrets.requested != "auth" {
+		t.Errorf("expected the default client secret to be used, got %q", secrets.requested)
+	}
+}
@@ -1,4 +1,4 @@
-// Package content purges orphaned content objects by inspecting bundledeployments in all namespaces. Runs every 5 minutes. (fleetcontroller)
+// Package content purges orphaned content objects by inspecting bundledeployments, bundle revisions and the known good content of bundles in all namespaces. Runs every 5 minutes. (fleetcontroller)
 package content
 
 import (
@@ -21,6 +21,8 @@ import (
 type handler struct {
 	content          fleetcontrollers.ContentController
 	bundleDeployment fleetcontrollers.BundleDeploymentController
+	bundleRevisions  fleetcontrollers.BundleRevisionClient
+	bundles          fleetcontrollers.BundleClient
 	namespaces       corecontrollers.NamespaceClient
 }
 
@@ -33,11 +35,15 @@ type contentRef struct {
 func Register(ctx context.Context,
 	content fleetcontrollers.ContentController,
 	bundleDeployment fleetcontrollers.BundleDeploymentController,
+	bundleRevisions fleetcontrollers.BundleRevisionController,
+	bundles fleetcontrollers.BundleController,
 	namespaces corecontrollers.NamespaceController) {
 
 	h := &handler{
 		content:          content,
 		bundleDeployment: bundleDeployment,
+		bundleRevisions:  bundleRevisions,
+		bundles:          bundles,
 		namespaces:       namespaces,
 	}
 
@@ -68,6 +74,20 @@ func (h *handler) purgeOrphaned(ctx context.Context) {
 			bundleDeployments = append(bundleDeployments, nsBundleDeployments.Items...)
 		}
 
+		// revisions keep the content of previous bundle generations
+		revisions, err := h.bundleRevisions.List("", metav1.ListOptions{})
+		if err != nil {
+			logrus.Warnf("Error listing bundle revisions %v", err)
+			continue
+		}
+
+		// rollbacks restore the last known good content of bundles
+		bundles, err := h.bundles.List("", metav1.ListOptions{})
+		if err != nil {
+			logrus.Warnf("Error listing bundles %v", err)
+			continue
+		}
+
 		contentRefs := make(map[string]*contentRef)
 
 		contents, err := h.content.List(metav1.ListOptions{})
@@ -76,7 +96,22 @@ func (h *handler) purgeOrphaned(ctx context.Context) {
 			continue
 		}
 
+		// chunks are deleted together with the content they belong to
+		chunks := map[string][]string{}
+		isChunk := map[string]bool{}
+		for _, content := range contents.Items {
+			chunks[content.Name] = content.Chunks
+			for _, chunk := range content.Chunks {
+				isChunk[chunk] = true
+				// chunks created before their content was listed
+				delete(deleteRefs, chunk)
+			}
+		}
+
 		for _, content := range contents.Items {
+			if isChunk[content.Name] {
+				continue
+			}
 			contentRefs[content.Name] = &contentRef{
 				safeToDelete: false,
 				bundleCount:  0,
@@ -95,6 +130,18 @@ func (h *handler) purgeOrphaned(ctx context.Context) {
 			}
 		}
 
+		for _, rev := range revisions.Items {
+			if val, ok := contentRefs[rev.ManifestID]; ok {
+				val.bundleCount++
+			}
+		}
+
+		for _, bundle := range bundles.Items {
+			if val, ok := contentRefs[bundle.Status.KnownGoodManifestID]; ok {
+				val.bundleCount++
+			}
+		}
+
 		for contentName, cr := range contentRefs {
 			_, deleteCandidate := deleteRefs[contentName]
 			if cr.bundleCount > 0 {
@@ -122,6 +169,11 @@ func (h *handler) purgeOrphaned(ctx context.Context) {
 					logrus.Warnf("Error deleting contentbundle %v", err)
 				} else {
 					delete(deleteRefs, contentName)
+					for _, chunk := range chunks[contentName] {
+						if err := h.content.Delete(chunk, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+							logrus.Warnf("Error deleting content chunk %v", err)
+						}
+					}
 				}
 			}
 		}
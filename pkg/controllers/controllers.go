@@ -8,23 +8,38 @@ import (
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	"github.com/rancher/fleet/pkg/cloudevents"
+	fleetconfig "github.com/rancher/fleet/pkg/config"
+	"github.com/rancher/fleet/pkg/controllers/apideprecation"
 	"github.com/rancher/fleet/pkg/controllers/bootstrap"
 	"github.com/rancher/fleet/pkg/controllers/bundle"
+	"github.com/rancher/fleet/pkg/controllers/bundlegraph"
+	"github.com/rancher/fleet/pkg/controllers/chartversion"
 	"github.com/rancher/fleet/pkg/controllers/cleanup"
 	"github.com/rancher/fleet/pkg/controllers/cluster"
 	"github.com/rancher/fleet/pkg/controllers/clustergroup"
+	"github.com/rancher/fleet/pkg/controllers/clusterimport"
 	"github.com/rancher/fleet/pkg/controllers/clusterregistration"
 	"github.com/rancher/fleet/pkg/controllers/clusterregistrationtoken"
+	"github.com/rancher/fleet/pkg/controllers/commitstatus"
 	"github.com/rancher/fleet/pkg/controllers/config"
 	"github.com/rancher/fleet/pkg/controllers/content"
 	"github.com/rancher/fleet/pkg/controllers/display"
 	"github.com/rancher/fleet/pkg/controllers/git"
+	"github.com/rancher/fleet/pkg/controllers/gitrevision"
+	"github.com/rancher/fleet/pkg/controllers/gitwebhook"
 	"github.com/rancher/fleet/pkg/controllers/image"
+	"github.com/rancher/fleet/pkg/controllers/localbundle"
 	"github.com/rancher/fleet/pkg/controllers/manageagent"
+	"github.com/rancher/fleet/pkg/controllers/observer"
+	"github.com/rancher/fleet/pkg/controllers/revision"
+	"github.com/rancher/fleet/pkg/controllers/workspace"
 	"github.com/rancher/fleet/pkg/durations"
+	"github.com/rancher/fleet/pkg/fairness"
 	"github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io"
 	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/manifest"
+	"github.com/rancher/fleet/pkg/metrics"
 	fleetns "github.com/rancher/fleet/pkg/namespace"
 	"github.com/rancher/fleet/pkg/target"
 
@@ -53,6 +68,9 @@ import (
 	"k8s.io/client-go/util/workqueue"
 )
 
+// workers is the number of workers of each controller
+const workers = 50
+
 type appContext struct {
 	fleetcontrollers.Interface
 
@@ -69,10 +87,13 @@ type appContext struct {
 }
 
 func (a *appContext) start(ctx context.Context) error {
-	return start.All(ctx, 50, a.starters...)
+	return start.All(ctx, workers, a.starters...)
 }
 
-func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientConfig, disableGitops bool, disableBootstrap bool) error {
+// Register sets up the controllers of the enabled subsystems. Subsystems not
+// set by flags can be disabled in the config map, changes to it require a
+// restart. If webhookAddr is set, git webhook events are received on it.
+func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientConfig, subsystems Subsystems, webhookAddr string) error {
 	appCtx, err := newContext(cfg)
 	if err != nil {
 		return err
@@ -92,6 +113,20 @@ func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientC
 		return err
 	}
 
+	subsystems, err = subsystems.WithDefaults(fleetconfig.Get().Controllers)
+	if err != nil {
+		return err
+	}
+	if disabled := subsystems.String(); disabled != "" {
+		logrus.Infof("Disabled subsystems: %s", disabled)
+	}
+
+	// nil disables the image scan handlers of other controllers
+	var images fleetcontrollers.ImageScanController
+	if subsystems.Enabled(SubsystemImageScan) {
+		images = appCtx.ImageScan()
+	}
+
 	clusterregistration.Register(ctx,
 		appCtx.Apply.WithCacheTypes(
 			appCtx.RBAC.ClusterRole(),
@@ -122,24 +157,56 @@ func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientC
 		appCtx.Bundle(),
 		appCtx.Core.Namespace())
 
-	bundle.Register(ctx,
-		appCtx.Apply,
-		appCtx.RESTMapper,
-		appCtx.TargetManager,
-		appCtx.Bundle(),
-		appCtx.Cluster(),
-		appCtx.ImageScan(),
-		appCtx.GitRepo().Cache(),
-		appCtx.BundleDeployment())
+	if subsystems.Enabled(SubsystemBundle) {
+		bundle.Register(ctx,
+			appCtx.Apply,
+			appCtx.RESTMapper,
+			appCtx.TargetManager,
+			appCtx.Bundle(),
+			appCtx.Cluster(),
+			images,
+			appCtx.GitRepo().Cache(),
+			appCtx.BundleDeployment(),
+			appCtx.Content(),
+			appCtx.Core.Secret(),
+			appCtx.Core.ConfigMap(),
+			fairness.New("bundle", workers, workspaceWeight))
 
-	clustergroup.Register(ctx,
-		appCtx.Cluster(),
-		appCtx.ClusterGroup())
+		chartversion.Register(ctx,
+			appCtx.Bundle(),
+			appCtx.GitRepo(),
+			appCtx.GitRepoDefaults().Cache(),
+			appCtx.Core.Secret())
 
-	content.Register(ctx,
-		appCtx.Content(),
-		appCtx.BundleDeployment(),
-		appCtx.Core.Namespace())
+		content.Register(ctx,
+			appCtx.Content(),
+			appCtx.BundleDeployment(),
+			appCtx.BundleRevision(),
+			appCtx.Bundle(),
+			appCtx.Core.Namespace())
+
+		bundlegraph.Register(ctx,
+			appCtx.Apply.WithCacheTypes(appCtx.Core.ConfigMap()),
+			appCtx.Core.Namespace(),
+			appCtx.Bundle())
+
+		apideprecation.Register(ctx,
+			appCtx.Apply.WithCacheTypes(appCtx.Core.ConfigMap()),
+			appCtx.Core.Namespace(),
+			appCtx.Cluster(),
+			appCtx.BundleDeployment(),
+			appCtx.Content())
+
+		revision.Register(ctx,
+			appCtx.Bundle(),
+			appCtx.BundleRevision())
+	}
+
+	if subsystems.Enabled(SubsystemClusterGroups) {
+		clustergroup.Register(ctx,
+			appCtx.Cluster(),
+			appCtx.ClusterGroup())
+	}
 
 	clusterregistrationtoken.Register(ctx,
 		systemNamespace,
@@ -154,6 +221,13 @@ func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientC
 		appCtx.Core.Secret().Cache(),
 		appCtx.Core.Secret())
 
+	clusterimport.Register(ctx,
+		appCtx.Apply.WithCacheTypes(
+			appCtx.Core.Secret(),
+			appCtx.Cluster()),
+		appCtx.ClusterImport(),
+		appCtx.Core.Secret())
+
 	cleanup.Register(ctx,
 		appCtx.Apply.WithCacheTypes(
 			appCtx.Core.Secret(),
@@ -185,24 +259,76 @@ func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientC
 		appCtx.Cluster(),
 		appCtx.Bundle())
 
-	if !disableGitops {
+	if metrics.Enabled() {
+		metrics.Register(ctx,
+			appCtx.Bundle(),
+			appCtx.BundleDeployment(),
+			appCtx.GitRepo())
+	}
+
+	if subsystems.Enabled(SubsystemNotifications) {
+		cloudevents.Register(ctx,
+			appCtx.Bundle(),
+			appCtx.BundleDeployment(),
+			appCtx.Cluster())
+	}
+
+	workspace.Register(ctx,
+		appCtx.Apply.WithCacheTypes(appCtx.FleetWorkspaceStatus()),
+		appCtx.Core.Namespace(),
+		appCtx.GitRepo(),
+		appCtx.Bundle(),
+		appCtx.Cluster())
+
+	observer.Register(ctx,
+		appCtx.Apply.WithCacheTypes(appCtx.RBAC.RoleBinding()),
+		appCtx.Core.Namespace(),
+		appCtx.Cluster(),
+		appCtx.GitRepo())
+
+	var receiver *gitwebhook.Receiver
+	if subsystems.Enabled(SubsystemGitOps) {
 		git.Register(ctx,
 			appCtx.Apply.WithCacheTypes(
 				appCtx.RBAC.Role(),
 				appCtx.RBAC.RoleBinding(),
 				appCtx.GitJob.GitJob(),
 				appCtx.Core.ConfigMap(),
+				appCtx.Core.Secret(),
 				appCtx.Core.ServiceAccount()),
 			appCtx.GitJob.GitJob(),
 			appCtx.BundleDeployment(),
 			appCtx.GitRepoRestriction().Cache(),
+			appCtx.GitRepoDefaults(),
 			appCtx.Bundle(),
-			appCtx.ImageScan(),
+			images,
 			appCtx.GitRepo(),
 			appCtx.Core.Secret().Cache())
+
+		gitwebhook.Register(ctx,
+			systemNamespace,
+			appCtx.GitRepo(),
+			appCtx.GitRepoDefaults().Cache(),
+			appCtx.Core.Secret())
+
+		gitrevision.Register(ctx,
+			appCtx.GitRepo(),
+			appCtx.GitRepoDefaults().Cache(),
+			appCtx.Core.Secret().Cache())
+
+		commitstatus.Register(ctx,
+			appCtx.GitRepo(),
+			appCtx.GitRepoDefaults().Cache(),
+			appCtx.Core.Secret().Cache())
+
+		if webhookAddr != "" {
+			receiver = gitwebhook.NewReceiver(systemNamespace,
+				appCtx.GitJob.GitJob(),
+				appCtx.Core.Secret())
+		}
 	}
 
-	if !disableBootstrap {
+	if subsystems.Enabled(SubsystemBootstrap) {
 		bootstrap.Register(ctx,
 			systemNamespace,
 			appCtx.Apply.WithCacheTypes(
@@ -223,21 +349,33 @@ func Register(ctx context.Context, systemNamespace string, cfg clientcmd.ClientC
 		appCtx.GitRepo(),
 		appCtx.BundleDeployment())
 
-	image.Register(ctx,
-		appCtx.Core,
-		appCtx.GitRepo(),
-		appCtx.ImageScan())
+	if subsystems.Enabled(SubsystemImageScan) {
+		image.Register(ctx,
+			appCtx.Core,
+			appCtx.GitRepo(),
+			appCtx.GitRepoDefaults().Cache(),
+			images)
+	}
 
 	leader.RunOrDie(ctx, systemNamespace, "fleet-controller-lock", appCtx.K8s, func(ctx context.Context) {
 		if err := appCtx.start(ctx); err != nil {
 			logrus.Fatal(err)
 		}
 		logrus.Info("All controllers have been started")
+		localbundle.Register(ctx)
+		if receiver != nil {
+			go receiver.Serve(ctx, webhookAddr)
+		}
 	})
 
 	return nil
 }
 
+// workspaceWeight returns the configured weight of a workspace
+func workspaceWeight(namespace string) int {
+	return fleetconfig.Get().WorkspaceWeights[namespace]
+}
+
 func controllerFactory(rest *rest.Config) (controller.SharedControllerFactory, error) {
 	rateLimit := workqueue.NewItemExponentialFailureRateLimiter(durations.FailureRateLimiterBase, durations.FailureRateLimiterMax)
 	clusterRateLimiter := workqueue.NewItemExponentialFailureRateLimiter(durations.SlowFailureRateLimiterBase, durations.SlowFailureRateLimiterMax)
@@ -249,7 +387,7 @@ func controllerFactory(rest *rest.Config) (controller.SharedControllerFactory, e
 	cacheFactory := cache.NewSharedCachedFactory(clientFactory, nil)
 	return controller.NewSharedControllerFactory(cacheFactory, &controller.SharedControllerFactoryOptions{
 		DefaultRateLimiter:     rateLimit,
-		DefaultWorkers:         50,
+		DefaultWorkers:         workers,
 		SyncOnlyChangedObjects: true,
 		KindRateLimiter: map[schema.GroupVersionKind]workqueue.RateLimiter{
 			v1alpha1.SchemeGroupVersion.WithKind("Cluster"): clusterRateLimiter,
@@ -329,6 +467,8 @@ func newContext(cfg clientcmd.ClientConfig) (*appContext, error) {
 		fleetv.Bundle().Cache(),
 		fleetv.BundleNamespaceMapping().Cache(),
 		corev.Namespace().Cache(),
+		corev.Secret().Cache(),
+		corev.ConfigMap().Cache(),
 		manifest.NewStore(fleetv.Content()),
 		fleetv.BundleDeployment().Cache())
 
@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"github.com/rancher/fleet/modules/agent/pkg/register"
 	fleetgroup "github.com/rancher/fleet/pkg/apis/fleet.cattle.io"
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/controllers/bundlegraph"
+	"github.com/rancher/fleet/pkg/controllers/observer"
 	fleetns "github.com/rancher/fleet/pkg/namespace"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -31,6 +34,14 @@ func applyBootstrapResources(systemNamespace, systemRegistrationNamespace string
 						APIGroups: []string{fleetgroup.GroupName},
 						Resources: []string{fleet.BundleDeploymentResourceName + "/status"},
 					},
+					{
+						// the agent's credential for a new management
+						// cluster, see fleet migrate-manager
+						Verbs:         []string{"get"},
+						APIGroups:     []string{""},
+						Resources:     []string{"secrets"},
+						ResourceNames: []string{register.MigrationSecretName},
+					},
 				},
 			},
 			// used by request-* service accounts from agents
@@ -46,6 +57,43 @@ func applyBootstrapResources(systemNamespace, systemRegistrationNamespace string
 					},
 				},
 			},
+			// bound to the observer group of each workspace
+			&rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: observer.ClusterRoleName,
+				},
+				Rules: []rbacv1.PolicyRule{
+					{
+						Verbs:     []string{"get", "list", "watch"},
+						APIGroups: []string{fleetgroup.GroupName},
+						Resources: []string{
+							fleet.BundleResourceName,
+							fleet.BundleDeploymentResourceName,
+							fleet.BundleNamespaceMappingResourceName,
+							fleet.BundleRevisionResourceName,
+							fleet.ClusterResourceName,
+							fleet.ClusterGroupResourceName,
+							fleet.ClusterImportResourceName,
+							fleet.FleetWorkspaceStatusResourceName,
+							fleet.GitRepoResourceName,
+							fleet.GitRepoDefaultsResourceName,
+							fleet.GitRepoRestrictionResourceName,
+							fleet.ImageScanResourceName,
+						},
+					},
+					{
+						Verbs:     []string{"get", "list", "watch"},
+						APIGroups: []string{"gitjob.cattle.io"},
+						Resources: []string{"gitjobs"},
+					},
+					{
+						Verbs:         []string{"get"},
+						APIGroups:     []string{""},
+						Resources:     []string{"configmaps"},
+						ResourceNames: []string{bundlegraph.ConfigMapName},
+					},
+				},
+			},
 			&corev1.Namespace{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: systemNamespace,
@@ -51,6 +51,8 @@ func (h *handler) OnClusterChange(cluster *fleet.Cluster, status fleet.ClusterSt
 	status.Display.State = string(state)
 	if status.Agent.LastSeen.IsZero() {
 		status.Display.State = "WaitCheckIn"
+	} else if status.Agent.Upgrading {
+		status.Display.State = "ClusterUpgrading"
 	}
 	return status, nil
 }
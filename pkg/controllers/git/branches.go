@@ -0,0 +1,149 @@
+package git
+
+import (
+	"fmt"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetname "github.com/rancher/fleet/pkg/name"
+
+	"github.com/rancher/wrangler/pkg/name"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// source is a branch or revision of a GitRepo, which is checked out by its
+// own GitJob
+type source struct {
+	// jobName is the name of the GitJob
+	jobName  string
+	branch   string
+	revision string
+	// bundlePrefix is prepended to the names of the bundles
+	bundlePrefix string
+	// branchLabel is the value of the RepoBranchLabel on the bundles, it's
+	// empty for GitRepos, which don't follow multiple branches
+	branchLabel string
+	targets     []fleet.GitTarget
+}
+
+// sources returns the branches of the GitRepo, or its only branch or
+// revision, if it doesn't follow multiple branches
+func sources(gitrepo *fleet.GitRepo, status fleet.GitRepoStatus) ([]source, error) {
+	branch, rev := gitrepo.Spec.Branch, gitrepo.Spec.Revision
+	if selector := gitrepo.Spec.RevisionSelector; selector != nil && selector.Semver != "" {
+		if len(gitrepo.Spec.Branches) > 0 {
+			return nil, fmt.Errorf("branches can't be followed together with a revision selector")
+		}
+		if status.ResolvedRevision == nil {
+			return nil, fmt.Errorf("waiting for a tag matching %s", selector.Semver)
+		}
+		branch, rev = "", status.ResolvedRevision.Tag
+	}
+
+	if len(gitrepo.Spec.Branches) == 0 {
+		if branch == "" && rev == "" {
+			branch = "master"
+		}
+		return []source{{
+			jobName:      gitrepo.Name,
+			branch:       branch,
+			revision:     rev,
+			bundlePrefix: gitrepo.Name,
+			targets:      gitrepo.Spec.Targets,
+		}}, nil
+	}
+
+	var result []source
+	seen := map[string]string{}
+	for _, b := range gitrepo.Spec.Branches {
+		if b.Name == "" {
+			return nil, fmt.Errorf("branches require a name")
+		}
+		prefix := b.BundlePrefix
+		if prefix == "" {
+			prefix = gitrepo.Name + "-" + b.Name
+		}
+		branchLabel := fleetname.HelmReleaseName(prefix)
+		if other, ok := seen[branchLabel]; ok {
+			return nil, fmt.Errorf("branches %s and %s have the same bundle prefix", other, b.Name)
+		}
+		seen[branchLabel] = b.Name
+
+		targets := b.Targets
+		if len(targets) == 0 {
+			targets = gitrepo.Spec.Targets
+		}
+		result = append(result, source{
+			jobName:      name.SafeConcatName(gitrepo.Name, "branch", branchLabel),
+			branch:       b.Name,
+			bundlePrefix: prefix,
+			branchLabel:  branchLabel,
+			targets:      targets,
+		})
+	}
+	return result, nil
+}
+
+// setGitJobStatus copies the commits and job states of the sources'
+// GitJobs into the status. The GitRepo is only current, if all its
+// branches are.
+func (h *handler) setGitJobStatus(gitrepo *fleet.GitRepo, sources []source, status fleet.GitRepoStatus) fleet.GitRepoStatus {
+	status.Commit = ""
+	status.GitJobStatus = ""
+	status.AppliedCommit = ""
+	status.Branches = nil
+	for _, src := range sources {
+		branchStatus := fleet.GitBranchStatus{Name: src.branch}
+		gitJob, err := h.gitjobCache.Get(gitrepo.Namespace, src.jobName)
+		if err == nil {
+			branchStatus.Commit = gitJob.Status.Commit
+			branchStatus.GitJobStatus = gitJob.Status.JobStatus
+			branchStatus.AppliedCommit = gitJob.Status.LastExecutedCommit
+			status.Conditions = mergeConditions(status.Conditions, gitJob.Status.Conditions)
+		}
+
+		if src.branchLabel == "" {
+			status.Commit = branchStatus.Commit
+			status.GitJobStatus = branchStatus.GitJobStatus
+			status.AppliedCommit = branchStatus.AppliedCommit
+			continue
+		}
+		status.Branches = append(status.Branches, branchStatus)
+		if len(status.Branches) == 1 || status.GitJobStatus == "Current" {
+			status.GitJobStatus = branchStatus.GitJobStatus
+		}
+	}
+	if len(status.Branches) > 0 {
+		// the jobs of branches don't report path errors
+		status.PathErrors = nil
+	}
+	return status
+}
+
+// pruneBranchBundles deletes the bundles of branches, which the GitRepo
+// doesn't follow anymore. This includes the bundles created before it
+// started or after it stopped following multiple branches.
+func (h *handler) pruneBranchBundles(gitrepo *fleet.GitRepo, sources []source) error {
+	bundles, err := h.bundleCache.List(gitrepo.Namespace, labels.SelectorFromSet(labels.Set{
+		fleet.RepoLabel: gitrepo.Name,
+	}))
+	if err != nil {
+		return err
+	}
+
+	followed := map[string]bool{}
+	for _, src := range sources {
+		followed[src.branchLabel] = true
+	}
+	for _, bundle := range bundles {
+		if bundle.DeletionTimestamp != nil || followed[bundle.Labels[fleet.RepoBranchLabel]] {
+			continue
+		}
+		err := h.bundles.Delete(bundle.Namespace, bundle.Name, nil)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package git
+
+import (
+	"fmt"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+	fleetgit "github.com/rancher/fleet/pkg/git"
+
+	"github.com/rancher/wrangler/pkg/name"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// cloneCredentialsIssuedAnnotation records when the credentials of a clone
+// secret were issued
+const cloneCredentialsIssuedAnnotation = "fleet.cattle.io/clone-credentials-issued"
+
+// cloneSecret returns a basic auth secret for cloning with the git binary,
+// if the GitRepo's client secret is an opaque secret with provider specific
+// credentials. The credentials expire, so they are renewed every
+// durations.GitCloneCredentialsRenew. It returns nil for basic auth and SSH
+// secrets, which are used as they are.
+func (h *handler) cloneSecret(gitrepo *fleet.GitRepo) (*corev1.Secret, error) {
+	if gitrepo.Spec.ClientSecretName == "" {
+		return nil, nil
+	}
+	secret, err := h.secrets.Get(gitrepo.Namespace, gitrepo.Spec.ClientSecretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up clientSecretName, error: %v", err)
+	}
+	if secret.Type != corev1.SecretTypeOpaque {
+		return nil, nil
+	}
+
+	secretName := name.SafeConcatName("git", gitrepo.Name, "credentials")
+	existing, err := h.secrets.Get(gitrepo.Namespace, secretName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil && !needsRenewal(existing, time.Now()) {
+		return cloneSecretFor(gitrepo, secretName, existing.Annotations[cloneCredentialsIssuedAnnotation], existing.Data), nil
+	}
+
+	auth, err := fleetgit.BasicAuthFromSecret(secret, gitrepo.Spec.Repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clone credentials from secret %s: %w", secret.Name, err)
+	}
+	return cloneSecretFor(gitrepo, secretName, time.Now().UTC().Format(time.RFC3339), map[string][]byte{
+		corev1.BasicAuthUsernameKey: []byte(auth.Username),
+		corev1.BasicAuthPasswordKey: []byte(auth.Password),
+	}), nil
+}
+
+func cloneSecretFor(gitrepo *fleet.GitRepo, secretName, issued string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: gitrepo.Namespace,
+			Annotations: map[string]string{
+				cloneCredentialsIssuedAnnotation: issued,
+			},
+		},
+		Type: corev1.SecretTypeBasicAuth,
+		Data: data,
+	}
+}
+
+// needsRenewal returns true if the clone secret's credentials were issued
+// more than durations.GitCloneCredentialsRenew ago
+func needsRenewal(secret *corev1.Secret, now time.Time) bool {
+	issued, err := time.Parse(time.RFC3339, secret.Annotations[cloneCredentialsIssuedAnnotation])
+	if err != nil {
+		return true
+	}
+	return now.Sub(issued) >= durations.GitCloneCredentialsRenew
+}
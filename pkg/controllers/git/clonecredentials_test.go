@@ -0,0 +1,30 @@
+package git
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rancher/fleet/pkg/durations"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNeedsRenewal(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	secret := func(issued string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{cloneCredentialsIssuedAnnotation: issued},
+		}}
+	}
+
+	if needsRenewal(secret(now.Add(-time.Minute).Format(time.RFC3339)), now) {
+		t.Error("expected recent credentials to be kept")
+	}
+	if !needsRenewal(secret(now.Add(-durations.GitCloneCredentialsRenew).Format(time.RFC3339)), now) {
+		t.Error("expected old credentials to be renewed")
+	}
+	if !needsRenewal(secret(""), now) {
+		t.Error("expected credentials without issue time to be renewed")
+	}
+}
@@ -0,0 +1,22 @@
+package git
+
+import (
+	"github.com/rancher/wrangler/pkg/relatedresource"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// resolveGitRepoDefaults enqueues all gitrepos in the namespace of the
+// changed GitRepoDefaults
+func (h *handler) resolveGitRepoDefaults(namespace, name string, obj runtime.Object) ([]relatedresource.Key, error) {
+	gitrepos, err := h.gitRepos.List(namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]relatedresource.Key, 0, len(gitrepos))
+	for _, gitrepo := range gitrepos {
+		keys = append(keys, relatedresource.Key{Namespace: gitrepo.Namespace, Name: gitrepo.Name})
+	}
+	return keys, nil
+}
@@ -0,0 +1,18 @@
+package git
+
+import (
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/gitrepodefaults"
+)
+
+func TestInheritedProxy(t *testing.T) {
+	defaults := fleet.GitRepoDefaultsSpec{Proxy: &fleet.ProxyConfig{HTTPSProxy: "http://proxy:3128"}}
+	result := gitrepodefaults.Apply(&fleet.GitRepo{}, defaults)
+
+	_, env := argsAndEnvs(result, source{bundlePrefix: result.Name})
+	if last := env[len(env)-1]; last.Name != "HTTPS_PROXY" {
+		t.Errorf("expected HTTPS_PROXY env, got %v", env)
+	}
+}
@@ -1,6 +1,6 @@
 // Package git implements a controller that watches for GitRepo objects. (fleetcontrollers)
 //
-// It manages the lifecycle of GitJob resources for GitRepos. It cleans up orphaned bundles and image scans. Also updates the GitRepo and bundle status.
+// It manages the lifecycle of GitJob resources for GitRepos. It cleans up orphaned bundles and image scans and keeps a deleted GitRepo until its bundle deployments are removed. Also updates the GitRepo and bundle status.
 package git
 
 import (
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -17,7 +19,9 @@ import (
 	"github.com/rancher/fleet/pkg/config"
 	"github.com/rancher/fleet/pkg/controllers/clusterregistration"
 	"github.com/rancher/fleet/pkg/display"
+	"github.com/rancher/fleet/pkg/durations"
 	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/gitrepodefaults"
 	"github.com/rancher/fleet/pkg/summary"
 
 	gitjob "github.com/rancher/gitjob/pkg/apis/gitjob.cattle.io/v1"
@@ -33,11 +37,20 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+const (
+	pathsRenderedCond  = "PathsRendered"
+	commitVerifiedCond = "CommitVerified"
+	// verificationKeysDir is where the trusted keys of the GitRepo's
+	// verification are mounted in the job
+	verificationKeysDir = "/etc/fleet/verification"
+)
+
 var (
 	two = int32(2)
 )
@@ -47,6 +60,7 @@ func Register(ctx context.Context,
 	gitJobs v1.GitJobController,
 	bundleDeployments fleetcontrollers.BundleDeploymentController,
 	gitRepoRestrictions fleetcontrollers.GitRepoRestrictionCache,
+	gitRepoDefaults fleetcontrollers.GitRepoDefaultsController,
 	bundles fleetcontrollers.BundleController,
 	images fleetcontrollers.ImageScanController,
 	gitRepos fleetcontrollers.GitRepoController,
@@ -58,17 +72,25 @@ func Register(ctx context.Context,
 		images:              images,
 		bundleDeployments:   bundleDeployments.Cache(),
 		gitRepoRestrictions: gitRepoRestrictions,
+		gitRepoDefaults:     gitRepoDefaults.Cache(),
+		gitRepos:            gitRepos.Cache(),
+		gitRepoClient:       gitRepos,
 		display:             display.NewFactory(bundles.Cache()),
 		secrets:             secrets,
 	}
 
 	gitRepos.OnChange(ctx, "gitjob-purge", h.DeleteOnChange)
+	gitRepos.OnRemove(ctx, "gitrepo-teardown", h.OnRemove)
+	// enqueue deleted gitrepos when their bundle deployments are removed
+	relatedresource.Watch(ctx, "gitrepo-teardown", resolveBundleDeploymentGitRepo, gitRepos, bundleDeployments)
 	// this will update the lastUpdateTime of the Accepted condition
 	fleetcontrollers.RegisterGitRepoGeneratingHandler(ctx, gitRepos, apply, "Accepted", "gitjobs", h.OnChange, nil)
 	// enqueue gitrepo when gitjob changes
 	relatedresource.Watch(ctx, "gitjobs",
 		relatedresource.OwnerResolver(true, fleet.SchemeGroupVersion.String(), "GitRepo"), gitRepos, gitJobs)
 	relatedresource.Watch(ctx, "gitjobs", resolveGitRepo, gitRepos, bundles)
+	// enqueue all gitrepos of the namespace when their defaults change
+	relatedresource.Watch(ctx, "gitrepo-defaults", h.resolveGitRepoDefaults, gitRepos, gitRepoDefaults)
 }
 
 // resolveGitRepo enqueues a GitRepo event for a bundle change
@@ -92,6 +114,9 @@ type handler struct {
 	bundles             fleetcontrollers.BundleClient
 	images              fleetcontrollers.ImageScanController
 	gitRepoRestrictions fleetcontrollers.GitRepoRestrictionCache
+	gitRepoDefaults     fleetcontrollers.GitRepoDefaultsCache
+	gitRepos            fleetcontrollers.GitRepoCache
+	gitRepoClient       fleetcontrollers.GitRepoController
 	bundleDeployments   fleetcontrollers.BundleDeploymentCache
 	display             *display.Factory
 }
@@ -108,22 +133,28 @@ func targetsOrDefault(targets []fleet.GitTarget) []fleet.GitTarget {
 	return targets
 }
 
-// getConfig builds a config map, containing the GitTarget cluster matchers, converted to BundleTargets.
+// getConfig builds a config map, containing the source's GitTarget cluster matchers, converted to BundleTargets, and the
+// GitRepo's helm values.
 // The BundleTargets are duplicated into TargetRestrictions. TargetRestrictions is a whilelist. A BundleDeployment
 // will be created for a Target just if it is inside a TargetRestrictions. If it is not inside TargetRestrictions a Target
 // is a TargetCustomization.
-func (h *handler) getConfig(repo *fleet.GitRepo) (*corev1.ConfigMap, error) {
+func (h *handler) getConfig(repo *fleet.GitRepo, src source) (*corev1.ConfigMap, error) {
 	spec := &fleet.BundleSpec{}
-	for _, target := range targetsOrDefault(repo.Spec.Targets) {
+	for _, target := range targetsOrDefault(src.targets) {
 		spec.Targets = append(spec.Targets, fleet.BundleTarget{
-			Name:                 target.Name,
-			ClusterName:          target.ClusterName,
-			ClusterSelector:      target.ClusterSelector,
-			ClusterGroup:         target.ClusterGroup,
-			ClusterGroupSelector: target.ClusterGroupSelector,
+			Name:                   target.Name,
+			ClusterName:            target.ClusterName,
+			ClusterSelector:        target.ClusterSelector,
+			ClusterGroup:           target.ClusterGroup,
+			ClusterGroupSelector:   target.ClusterGroupSelector,
+			ExcludeClusterSelector: target.ExcludeClusterSelector,
+			ExcludeClusterGroup:    target.ExcludeClusterGroup,
 		})
 		spec.TargetRestrictions = append(spec.TargetRestrictions, fleet.BundleTargetRestriction(target))
 	}
+	if repo.Spec.HelmValues != nil {
+		spec.Helm = &fleet.HelmOptions{Values: repo.Spec.HelmValues.DeepCopy()}
+	}
 	data, err := json.Marshal(spec)
 	if err != nil {
 		return nil, err
@@ -254,35 +285,46 @@ func (h *handler) DeleteOnChange(key string, gitrepo *fleet.GitRepo) (*fleet.Git
 	logrus.Debugf("GitRepo '%s' deleted, deleting bundle, image scane", key)
 
 	ns, name := kv.Split(key, "/")
+	return nil, h.deleteBundlesAndImageScans(ns, name)
+}
+
+func (h *handler) deleteBundlesAndImageScans(ns, name string) error {
 	bundles, err := h.bundleCache.List(ns, labels.SelectorFromSet(labels.Set{
 		fleet.RepoLabel: name,
 	}))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	for _, bundle := range bundles {
+		if bundle.DeletionTimestamp != nil {
+			continue
+		}
 		err := h.bundles.Delete(bundle.Namespace, bundle.Name, nil)
-		if err != nil {
-			return nil, err
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
 		}
 	}
 
+	if h.images == nil {
+		return nil
+	}
+
 	images, err := h.images.Cache().List(ns, labels.Everything())
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	for _, image := range images {
 		if image.Spec.GitRepoName == name {
 			err := h.images.Delete(image.Namespace, image.Name, nil)
-			if err != nil {
-				return nil, err
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
 			}
 		}
 
 	}
-	return nil, nil
+	return nil
 }
 
 func mergeConditions(existing, next []genericcondition.GenericCondition) []genericcondition.GenericCondition {
@@ -302,6 +344,73 @@ func mergeConditions(existing, next []genericcondition.GenericCondition) []gener
 	return result
 }
 
+// pathsCondition returns the PathsRendered condition, which is false if
+// bundles could not be created for some of the paths
+func pathsCondition(status fleet.GitRepoStatus) genericcondition.GenericCondition {
+	cond := genericcondition.GenericCondition{
+		Type:   pathsRenderedCond,
+		Status: corev1.ConditionTrue,
+	}
+	if len(status.PathErrors) > 0 {
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = "Error"
+		cond.Message = pathErrorsMessage(status.PathErrors)
+	}
+
+	for _, existing := range status.Conditions {
+		if existing.Type == cond.Type && existing.Status == cond.Status && existing.Message == cond.Message {
+			return existing
+		}
+	}
+	cond.LastUpdateTime = time.Now().UTC().Format(time.RFC3339)
+	return cond
+}
+
+// verifiedCondition returns the CommitVerified condition, which is false if
+// the checked out commit is not signed by a trusted key
+func verifiedCondition(status fleet.GitRepoStatus) genericcondition.GenericCondition {
+	cond := genericcondition.GenericCondition{
+		Type:   commitVerifiedCond,
+		Status: corev1.ConditionUnknown,
+	}
+	if v := status.Verification; v != nil {
+		cond.Status = corev1.ConditionTrue
+		cond.Message = fmt.Sprintf("commit %s signed by %s", v.Commit, v.Signer)
+		if !v.Verified {
+			cond.Status = corev1.ConditionFalse
+			cond.Reason = "Error"
+			cond.Message = fmt.Sprintf("commit %s: %s", v.Commit, v.Message)
+		}
+	}
+
+	for _, existing := range status.Conditions {
+		if existing.Type == cond.Type && existing.Status == cond.Status && existing.Message == cond.Message {
+			return existing
+		}
+	}
+	cond.LastUpdateTime = time.Now().UTC().Format(time.RFC3339)
+	return cond
+}
+
+// removeCondition returns the conditions without the one of the type
+func removeCondition(conds []genericcondition.GenericCondition, condType string) []genericcondition.GenericCondition {
+	var result []genericcondition.GenericCondition
+	for _, cond := range conds {
+		if cond.Type != condType {
+			result = append(result, cond)
+		}
+	}
+	return result
+}
+
+func pathErrorsMessage(pathErrors []fleet.GitRepoPathError) string {
+	messages := make([]string, 0, len(pathErrors))
+	for _, e := range pathErrors {
+		messages = append(messages, fmt.Sprintf("path %s: %s", e.Path, e.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
 func acceptedLastUpdate(conds []genericcondition.GenericCondition) string {
 	for _, cond := range conds {
 		if cond.Type == "Accepted" {
@@ -320,6 +429,11 @@ func (h *handler) OnChange(gitrepo *fleet.GitRepo, status fleet.GitRepoStatus) (
 		return nil, status, nil
 	}
 
+	gitrepo, err := gitrepodefaults.Inherit(h.gitRepoDefaults, gitrepo)
+	if err != nil {
+		return nil, status, err
+	}
+
 	if gitrepo.Spec.HelmSecretNameForPaths != "" {
 		if _, err := h.secrets.Get(gitrepo.Namespace, gitrepo.Spec.HelmSecretNameForPaths); err != nil {
 			return nil, status, fmt.Errorf("failed to look up HelmSecretNameForPaths, error: %v", err)
@@ -330,10 +444,37 @@ func (h *handler) OnChange(gitrepo *fleet.GitRepo, status fleet.GitRepoStatus) (
 		}
 	}
 
-	gitrepo, err := h.authorizeAndAssignDefaults(gitrepo)
+	if v := gitrepo.Spec.Verification; v != nil {
+		if v.PublicKeysSecret == "" {
+			return nil, status, fmt.Errorf("verification requires a public keys secret")
+		}
+		if _, err := h.secrets.Get(gitrepo.Namespace, v.PublicKeysSecret); err != nil {
+			return nil, status, fmt.Errorf("failed to look up verification publicKeysSecret, error: %v", err)
+		}
+	}
+
+	if gitrepo.Spec.Submodules != nil {
+		if err := h.validateSubmodules(gitrepo); err != nil {
+			return nil, status, err
+		}
+	}
+
+	gitrepo, err = h.authorizeAndAssignDefaults(gitrepo)
+	if err != nil {
+		return nil, status, err
+	}
+
+	// the job clones with the git binary, which can't use provider
+	// specific credentials, so it gets a basic auth secret instead
+	cloneSecret, err := h.cloneSecret(gitrepo)
 	if err != nil {
 		return nil, status, err
 	}
+	if cloneSecret != nil {
+		gitrepo = gitrepo.DeepCopy()
+		gitrepo.Spec.ClientSecretName = cloneSecret.Name
+		h.gitRepoClient.EnqueueAfter(gitrepo.Namespace, gitrepo.Name, durations.GitCloneCredentialsRenew)
+	}
 
 	status, err = h.setBundleStatus(gitrepo, status)
 	if err != nil {
@@ -345,27 +486,36 @@ func (h *handler) OnChange(gitrepo *fleet.GitRepo, status fleet.GitRepoStatus) (
 		paths = []string{"."}
 	}
 
-	gitJob, err := h.gitjobCache.Get(gitrepo.Namespace, gitrepo.Name)
-	if err == nil {
-		status.Commit = gitJob.Status.Commit
-		status.Conditions = mergeConditions(status.Conditions, gitJob.Status.Conditions)
-		status.GitJobStatus = gitJob.Status.JobStatus
-	} else {
-		status.Commit = ""
+	srcs, err := sources(gitrepo, status)
+	if err != nil {
+		return nil, status, err
+	}
+	status = h.setGitJobStatus(gitrepo, srcs, status)
+	if err := h.pruneBranchBundles(gitrepo, srcs); err != nil {
+		return nil, status, err
 	}
 
 	if status.GitJobStatus != "Current" {
 		status.Display.State = "GitUpdating"
 	}
 
-	branch, rev := gitrepo.Spec.Branch, gitrepo.Spec.Revision
-	if branch == "" && rev == "" {
-		branch = "master"
+	status.Conditions = mergeConditions(status.Conditions, []genericcondition.GenericCondition{pathsCondition(status)})
+	if len(status.PathErrors) > 0 {
+		status.Display.Error = true
+		if status.Display.Message == "" {
+			status.Display.Message = pathErrorsMessage(status.PathErrors)
+		}
 	}
 
-	configMap, err := h.getConfig(gitrepo)
-	if err != nil {
-		return nil, status, err
+	if gitrepo.Spec.Verification != nil {
+		status.Conditions = mergeConditions(status.Conditions, []genericcondition.GenericCondition{verifiedCondition(status)})
+		if v := status.Verification; v != nil && !v.Verified {
+			status.Display.Error = true
+			status.Display.Message = fmt.Sprintf("commit %s failed verification: %s", v.Commit, v.Message)
+		}
+	} else {
+		status.Verification = nil
+		status.Conditions = removeCondition(status.Conditions, commitVerifiedCond)
 	}
 
 	syncSeconds := 0
@@ -384,10 +534,7 @@ func (h *handler) OnChange(gitrepo *fleet.GitRepo, status fleet.GitRepoStatus) (
 	}
 	status.Resources, status.ResourceErrors = h.display.Render(gitrepo.Namespace, gitrepo.Name, bundleErrorState)
 	status = countResources(status)
-	volumes, volumeMounts := volumes(gitrepo, configMap)
-	args, envs := argsAndEnvs(gitrepo)
-	return []runtime.Object{
-		configMap,
+	objs := []runtime.Object{
 		&corev1.ServiceAccount{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      saName,
@@ -410,6 +557,11 @@ func (h *handler) OnChange(gitrepo *fleet.GitRepo, status fleet.GitRepoStatus) (
 					APIGroups: []string{"fleet.cattle.io"},
 					Resources: []string{"gitrepos"},
 				},
+				{
+					Verbs:     []string{"patch"},
+					APIGroups: []string{"fleet.cattle.io"},
+					Resources: []string{"gitrepos/status"},
+				},
 			},
 		},
 		&rbacv1.RoleBinding{
@@ -430,16 +582,29 @@ func (h *handler) OnChange(gitrepo *fleet.GitRepo, status fleet.GitRepoStatus) (
 				Name:     saName,
 			},
 		},
-		&gitjob.GitJob{
+	}
+
+	if cloneSecret != nil {
+		objs = append(objs, cloneSecret)
+	}
+
+	for _, src := range srcs {
+		configMap, err := h.getConfig(gitrepo, src)
+		if err != nil {
+			return nil, status, err
+		}
+		volumes, volumeMounts := volumes(gitrepo, configMap)
+		args, envs := argsAndEnvs(gitrepo, src)
+		objs = append(objs, configMap, &gitjob.GitJob{
 			ObjectMeta: metav1.ObjectMeta{
 				Labels:      yaml.CleanAnnotationsForExport(gitrepo.Labels),
 				Annotations: yaml.CleanAnnotationsForExport(gitrepo.Annotations),
-				Name:        gitrepo.Name,
+				Name:        src.jobName,
 				Namespace:   gitrepo.Namespace,
 			},
 			Spec: gitjob.GitJobSpec{
 				SyncInterval:          syncSeconds,
-				ForceUpdateGeneration: gitrepo.Spec.ForceSyncGeneration,
+				ForceUpdateGeneration: syncGeneration(gitrepo),
 				Git: gitjob.GitInfo{
 					Credential: gitjob.Credential{
 						ClientSecretName:      gitrepo.Spec.ClientSecretName,
@@ -448,8 +613,8 @@ func (h *handler) OnChange(gitrepo *fleet.GitRepo, status fleet.GitRepoStatus) (
 					},
 					Provider: "polling",
 					Repo:     gitrepo.Spec.Repo,
-					Revision: rev,
-					Branch:   branch,
+					Revision: src.revision,
+					Branch:   src.branch,
 				},
 				JobSpec: batchv1.JobSpec{
 					BackoffLimit: &two,
@@ -487,8 +652,9 @@ func (h *handler) OnChange(gitrepo *fleet.GitRepo, status fleet.GitRepoStatus) (
 					},
 				},
 			},
-		},
-	}, status, nil
+		})
+	}
+	return objs, status, nil
 }
 
 func countResources(status fleet.GitRepoStatus) fleet.GitRepoStatus {
@@ -550,6 +716,7 @@ func (h *handler) setBundleStatus(gitrepo *fleet.GitRepo, status fleet.GitRepoSt
 			message = summary.MessageFromDeployment(app)
 		}
 	}
+	summary.LimitErrors(&status.Summary)
 
 	if maxState == fleet.Ready {
 		maxState = ""
@@ -642,10 +809,42 @@ func volumes(gitrepo *fleet.GitRepo, configMap *corev1.ConfigMap) ([]corev1.Volu
 			MountPath: "/etc/fleet/helm",
 		})
 	}
+
+	if v := gitrepo.Spec.Verification; v != nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: "verification",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: v.PublicKeysSecret,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "verification",
+			MountPath: verificationKeysDir,
+		})
+	}
+
+	if gitrepo.Spec.Submodules != nil || gitrepo.Spec.LFS {
+		credentialVolumes, credentialMounts := submoduleVolumes(gitrepo)
+		volumes = append(volumes, credentialVolumes...)
+		volumeMounts = append(volumeMounts, credentialMounts...)
+	}
 	return volumes, volumeMounts
 }
 
-func argsAndEnvs(gitrepo *fleet.GitRepo) ([]string, []corev1.EnvVar) {
+// syncGeneration returns the generation, which forces the git job to
+// re-create the bundles. Besides the user's ForceSyncGeneration it includes
+// the syncs requested by the chart version controller.
+func syncGeneration(gitrepo *fleet.GitRepo) int64 {
+	generation := gitrepo.Spec.ForceSyncGeneration
+	if v, err := strconv.ParseInt(gitrepo.Annotations[fleet.ChartVersionSyncAnnotation], 10, 64); err == nil && v > 0 {
+		generation += v
+	}
+	return generation
+}
+
+func argsAndEnvs(gitrepo *fleet.GitRepo, src source) ([]string, []corev1.EnvVar) {
 	args := []string{
 		"fleet",
 		"apply",
@@ -658,21 +857,45 @@ func argsAndEnvs(gitrepo *fleet.GitRepo) ([]string, []corev1.EnvVar) {
 	bundleLabels := labels.Merge(gitrepo.Labels, map[string]string{
 		fleet.RepoLabel: gitrepo.Name,
 	})
+	if src.branchLabel != "" {
+		bundleLabels[fleet.RepoBranchLabel] = src.branchLabel
+	}
 
 	args = append(args,
 		"--targets-file=/run/config/targets.yaml",
 		"--label="+bundleLabels.String(),
 		"--namespace", gitrepo.Namespace,
 		"--service-account", gitrepo.Spec.ServiceAccount,
-		fmt.Sprintf("--sync-generation=%d", gitrepo.Spec.ForceSyncGeneration),
+		fmt.Sprintf("--sync-generation=%d", syncGeneration(gitrepo)),
 		fmt.Sprintf("--paused=%v", gitrepo.Spec.Paused),
 		"--target-namespace", gitrepo.Spec.TargetNamespace,
 	)
 
+	// the path errors of several branches would overwrite each other
+	if src.branchLabel == "" {
+		args = append(args, "--report-path-errors")
+	}
+
 	if gitrepo.Spec.KeepResources {
 		args = append(args, "--keep-resources")
 	}
 
+	if gitrepo.Spec.SkipUnchangedPaths {
+		args = append(args, "--skip-unchanged")
+		if src.branchLabel != "" {
+			// finds the branch's applied commit in the status
+			args = append(args, "--branch", src.branch)
+		}
+	}
+
+	if gitrepo.Spec.Verification != nil {
+		args = append(args, "--verify-keys-dir", verificationKeysDir)
+	}
+
+	if gitrepo.Spec.Submodules != nil || gitrepo.Spec.LFS {
+		args = append(args, submoduleArgs(gitrepo)...)
+	}
+
 	var env []corev1.EnvVar
 	if gitrepo.Spec.HelmSecretNameForPaths != "" {
 		helmArgs := []string{
@@ -721,5 +944,17 @@ func argsAndEnvs(gitrepo *fleet.GitRepo) ([]string, []corev1.EnvVar) {
 			})
 	}
 
-	return append(args, "--", gitrepo.Name), env
+	if proxy := gitrepo.Spec.Proxy; proxy != nil {
+		for _, e := range []corev1.EnvVar{
+			{Name: "HTTP_PROXY", Value: proxy.HTTPProxy},
+			{Name: "HTTPS_PROXY", Value: proxy.HTTPSProxy},
+			{Name: "NO_PROXY", Value: proxy.NoProxy},
+		} {
+			if e.Value != "" {
+				env = append(env, e)
+			}
+		}
+	}
+
+	return append(args, "--", src.bundlePrefix), env
 }
@@ -0,0 +1,78 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPathsCondition(t *testing.T) {
+	status := fleet.GitRepoStatus{}
+	cond := pathsCondition(status)
+	if cond.Type != pathsRenderedCond || cond.Status != corev1.ConditionTrue {
+		t.Errorf("expected true condition without path errors, got %+v", cond)
+	}
+
+	status.PathErrors = []fleet.GitRepoPathError{{Path: "charts/broken", Message: "chart not found"}}
+	cond = pathsCondition(status)
+	if cond.Status != corev1.ConditionFalse || !strings.Contains(cond.Message, "charts/broken: chart not found") {
+		t.Errorf("expected false condition naming the path, got %+v", cond)
+	}
+
+	status.Conditions = append(status.Conditions, cond)
+	status.Conditions[0].LastUpdateTime = "unchanged"
+	if next := pathsCondition(status); next.LastUpdateTime != "unchanged" {
+		t.Errorf("expected unchanged condition to be kept, got %+v", next)
+	}
+}
+
+func TestSources(t *testing.T) {
+	gitrepo := &fleet.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "app"},
+		Spec: fleet.GitRepoSpec{
+			Targets: []fleet.GitTarget{{ClusterGroup: "all"}},
+		},
+	}
+	srcs, err := sources(gitrepo, gitrepo.Status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(srcs) != 1 || srcs[0].jobName != "app" || srcs[0].branch != "master" || srcs[0].branchLabel != "" {
+		t.Errorf("expected a single source for the master branch, got %+v", srcs)
+	}
+
+	gitrepo.Spec.Branches = []fleet.GitBranch{
+		{Name: "dev"},
+		{Name: "release/prod", BundlePrefix: "prod", Targets: []fleet.GitTarget{{ClusterGroup: "prod"}}},
+	}
+	srcs, err = sources(gitrepo, gitrepo.Status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(srcs) != 2 {
+		t.Fatalf("expected a source per branch, got %+v", srcs)
+	}
+	if srcs[0].bundlePrefix != "app-dev" || srcs[0].branchLabel != "app-dev" || srcs[0].targets[0].ClusterGroup != "all" {
+		t.Errorf("expected dev branch to use the default prefix and the gitrepo's targets, got %+v", srcs[0])
+	}
+	if srcs[1].branch != "release/prod" || srcs[1].bundlePrefix != "prod" || srcs[1].targets[0].ClusterGroup != "prod" {
+		t.Errorf("expected prod branch to use its own prefix and targets, got %+v", srcs[1])
+	}
+	if srcs[0].jobName == srcs[1].jobName || srcs[0].jobName == gitrepo.Name {
+		t.Errorf("expected distinct gitjob names, got %s and %s", srcs[0].jobName, srcs[1].jobName)
+	}
+
+	args, _ := argsAndEnvs(gitrepo, srcs[1])
+	if args[len(args)-1] != "prod" || !strings.Contains(strings.Join(args, " "), fleet.RepoBranchLabel+"=prod") {
+		t.Errorf("expected the branch's bundle prefix and label, got %v", args)
+	}
+
+	gitrepo.Spec.Branches = append(gitrepo.Spec.Branches, fleet.GitBranch{Name: "staging", BundlePrefix: "prod"})
+	if _, err := sources(gitrepo, gitrepo.Status); err == nil {
+		t.Error("expected error for branches with the same bundle prefix")
+	}
+}
@@ -0,0 +1,105 @@
+package git
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// gitCredentialsDir is where the secrets of the credentials for the hosts of
+// submodules and LFS objects are mounted in the job, each in a directory
+// named by its index
+const gitCredentialsDir = "/etc/fleet/git-credentials"
+
+// gitCredentials returns the credentials for the hosts of the
+// submodules and LFS objects. The GitRepo's client secret is used for the
+// repo's host, unless another secret is configured for it.
+func gitCredentials(gitrepo *fleet.GitRepo) []fleet.SubmoduleCredential {
+	var result []fleet.SubmoduleCredential
+	if host := repoHost(gitrepo.Spec.Repo); host != "" && gitrepo.Spec.ClientSecretName != "" {
+		result = append(result, fleet.SubmoduleCredential{Host: host, SecretName: gitrepo.Spec.ClientSecretName})
+	}
+	if gitrepo.Spec.Submodules != nil {
+		result = append(result, gitrepo.Spec.Submodules.Credentials...)
+	}
+	return result
+}
+
+// validateSubmodules checks that the secrets of the submodule credentials
+// exist
+func (h *handler) validateSubmodules(gitrepo *fleet.GitRepo) error {
+	for _, c := range gitrepo.Spec.Submodules.Credentials {
+		if c.Host == "" || c.SecretName == "" {
+			return fmt.Errorf("submodule credentials require a host and a secret name")
+		}
+		if _, err := h.secrets.Get(gitrepo.Namespace, c.SecretName); err != nil {
+			return fmt.Errorf("failed to look up submodule credentials for %s, error: %v", c.Host, err)
+		}
+	}
+	return nil
+}
+
+// submoduleVolumes mounts the secrets of the git credentials
+func submoduleVolumes(gitrepo *fleet.GitRepo) ([]corev1.Volume, []corev1.VolumeMount) {
+	var (
+		volumes      []corev1.Volume
+		volumeMounts []corev1.VolumeMount
+	)
+	for i, c := range gitCredentials(gitrepo) {
+		name := "git-credentials-" + strconv.Itoa(i)
+		volumes = append(volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: c.SecretName,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: path.Join(gitCredentialsDir, strconv.Itoa(i)),
+		})
+	}
+	return volumes, volumeMounts
+}
+
+// submoduleArgs makes fleet apply check out the submodules and download
+// LFS objects, with the mounted credentials of their hosts
+func submoduleArgs(gitrepo *fleet.GitRepo) []string {
+	var args []string
+	if gitrepo.Spec.Submodules != nil {
+		args = append(args, "--submodules")
+		if gitrepo.Spec.Submodules.Recursive {
+			args = append(args, "--submodules-recursive")
+		}
+	}
+	if gitrepo.Spec.LFS {
+		args = append(args, "--lfs")
+	}
+	for i, c := range gitCredentials(gitrepo) {
+		args = append(args, "--git-credentials", c.Host+"="+path.Join(gitCredentialsDir, strconv.Itoa(i)))
+	}
+	return args
+}
+
+// repoHost returns the host of a git repo URL, including scp-like URLs of
+// ssh repos, like git@github.com:org/repo
+func repoHost(repo string) string {
+	if u, err := url.Parse(repo); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	host, _, ok := strings.Cut(repo, ":")
+	if !ok {
+		return ""
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	return host
+}
@@ -0,0 +1,62 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestRepoHost(t *testing.T) {
+	for repo, want := range map[string]string{
+		"https://github.com/org/repo":                    "github.com",
+		"https://user@gitlab.example.com:8443/org/repo":  "gitlab.example.com",
+		"ssh://git@bitbucket.example.com:7999/proj/repo": "bitbucket.example.com",
+		"git@github.com:org/repo.git":                    "github.com",
+		"repo":                                           "",
+	} {
+		if got := repoHost(repo); got != want {
+			t.Errorf("expected host %q of %s, got %q", want, repo, got)
+		}
+	}
+}
+
+func TestSubmoduleArgs(t *testing.T) {
+	gitrepo := &fleet.GitRepo{
+		Spec: fleet.GitRepoSpec{
+			Repo:             "git@github.com:org/repo.git",
+			ClientSecretName: "github",
+			Submodules: &fleet.GitSubmodules{
+				Recursive:   true,
+				Credentials: []fleet.SubmoduleCredential{{Host: "gitlab.com", SecretName: "gitlab"}},
+			},
+		},
+	}
+	want := []string{
+		"--submodules", "--submodules-recursive",
+		"--git-credentials", "github.com=/etc/fleet/git-credentials/0",
+		"--git-credentials", "gitlab.com=/etc/fleet/git-credentials/1",
+	}
+	if args := submoduleArgs(gitrepo); !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+
+	volumes, mounts := submoduleVolumes(gitrepo)
+	if len(volumes) != 2 || volumes[1].Secret.SecretName != "gitlab" || mounts[1].MountPath != "/etc/fleet/git-credentials/1" {
+		t.Errorf("expected the gitlab secret to be mounted, got %+v %+v", volumes, mounts)
+	}
+}
+
+func TestLFSArgs(t *testing.T) {
+	gitrepo := &fleet.GitRepo{
+		Spec: fleet.GitRepoSpec{
+			Repo:             "https://github.com/org/repo",
+			ClientSecretName: "github",
+			LFS:              true,
+		},
+	}
+	want := []string{"--lfs", "--git-credentials", "github.com=/etc/fleet/git-credentials/0"}
+	if args := submoduleArgs(gitrepo); !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+}
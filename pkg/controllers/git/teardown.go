@@ -0,0 +1,106 @@
+package git
+
+import (
+	"sort"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+
+	"github.com/rancher/wrangler/pkg/generic"
+	"github.com/rancher/wrangler/pkg/relatedresource"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// resolveBundleDeploymentGitRepo enqueues the GitRepo of a bundle
+// deployment, so a deleted GitRepo notices its removal
+func resolveBundleDeploymentGitRepo(_, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+	if bd, ok := obj.(*fleet.BundleDeployment); ok {
+		repo := bd.Labels[fleet.RepoLabel]
+		ns := bd.Labels[fleet.BundleNamespaceLabel]
+		if repo != "" && ns != "" {
+			return []relatedresource.Key{{Namespace: ns, Name: repo}}, nil
+		}
+	}
+	return nil, nil
+}
+
+// OnRemove deletes the bundles and image scans of a deleted GitRepo. The
+// GitRepo's finalizer is kept until all its bundle deployments are removed,
+// unless the force delete annotation is set. Meanwhile the remaining
+// resources are listed in the status.
+func (h *handler) OnRemove(key string, gitrepo *fleet.GitRepo) (*fleet.GitRepo, error) {
+	if err := h.deleteBundlesAndImageScans(gitrepo.Namespace, gitrepo.Name); err != nil {
+		return nil, err
+	}
+
+	teardown, err := h.teardownStatus(gitrepo.Namespace, gitrepo.Name)
+	if err != nil {
+		return nil, err
+	}
+	if teardown == nil {
+		return gitrepo, nil
+	}
+	if gitrepo.Annotations[fleet.GitRepoForceDeleteAnnotation] == "true" {
+		logrus.Infof("GitRepo %s is force deleted, not waiting for %d bundle deployments", key, teardown.RemainingBundleDeployments)
+		return gitrepo, nil
+	}
+
+	if !equalTeardown(gitrepo.Status.Teardown, teardown) {
+		gitrepo = gitrepo.DeepCopy()
+		gitrepo.Status.Teardown = teardown
+		gitrepo.Status.Display.State = "Deleting"
+		if gitrepo, err = h.gitRepoClient.UpdateStatus(gitrepo); err != nil {
+			return nil, err
+		}
+	}
+
+	logrus.Debugf("GitRepo %s is waiting for %d bundles and %d bundle deployments to be removed", key, len(teardown.RemainingBundles), teardown.RemainingBundleDeployments)
+	h.gitRepoClient.EnqueueAfter(gitrepo.Namespace, gitrepo.Name, durations.GitRepoTeardownRecheck)
+	return gitrepo, generic.ErrSkip
+}
+
+// teardownStatus returns the remaining resources of a gitrepo, or nil if
+// all were removed
+func (h *handler) teardownStatus(namespace, name string) (*fleet.GitRepoTeardown, error) {
+	bundles, err := h.bundleCache.List(namespace, labels.SelectorFromSet(labels.Set{
+		fleet.RepoLabel: name,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	bds, err := h.bundleDeployments.List("", labels.SelectorFromSet(labels.Set{
+		fleet.RepoLabel:            name,
+		fleet.BundleNamespaceLabel: namespace,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if len(bundles) == 0 && len(bds) == 0 {
+		return nil, nil
+	}
+
+	teardown := &fleet.GitRepoTeardown{RemainingBundleDeployments: len(bds)}
+	for _, bundle := range bundles {
+		teardown.RemainingBundles = append(teardown.RemainingBundles, bundle.Name)
+	}
+	sort.Strings(teardown.RemainingBundles)
+	return teardown, nil
+}
+
+func equalTeardown(a, b *fleet.GitRepoTeardown) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.RemainingBundleDeployments != b.RemainingBundleDeployments || len(a.RemainingBundles) != len(b.RemainingBundles) {
+		return false
+	}
+	for i := range a.RemainingBundles {
+		if a.RemainingBundles[i] != b.RemainingBundles[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package git
+
+import (
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveBundleDeploymentGitRepo(t *testing.T) {
+	bd := &fleet.BundleDeployment{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "cluster-fleet-default-local-123",
+		Labels: map[string]string{
+			fleet.RepoLabel:            "repo",
+			fleet.BundleNamespaceLabel: "fleet-default",
+		},
+	}}
+
+	keys, err := resolveBundleDeploymentGitRepo("", "", bd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Namespace != "fleet-default" || keys[0].Name != "repo" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	keys, _ = resolveBundleDeploymentGitRepo("", "", &fleet.BundleDeployment{})
+	if len(keys) != 0 {
+		t.Errorf("expected no keys for bundle deployment without gitrepo, got %v", keys)
+	}
+}
+
+func TestEqualTeardown(t *testing.T) {
+	a := &fleet.GitRepoTeardown{RemainingBundles: []string{"a", "b"}, RemainingBundleDeployments: 2}
+	b := a.DeepCopy()
+	if !equalTeardown(a, b) {
+		t.Error("expected copies to be equal")
+	}
+	b.RemainingBundleDeployments = 1
+	if equalTeardown(a, b) {
+		t.Error("expected different counts to differ")
+	}
+	if equalTeardown(a, nil) || !equalTeardown(nil, nil) {
+		t.Error("unexpected result for nil")
+	}
+}
@@ -0,0 +1,159 @@
+// Package gitrevision resolves the revision selectors of GitRepos to the highest matching git tag. (fleetcontroller)
+package gitrevision
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/git"
+	"github.com/rancher/fleet/pkg/gitrepodefaults"
+
+	corev1controller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const revisionCond = "RevisionResolved"
+
+type handler struct {
+	ctx             context.Context
+	gitrepos        fleetcontrollers.GitRepoController
+	gitRepoDefaults fleetcontrollers.GitRepoDefaultsCache
+	secretCache     corev1controller.SecretCache
+	git             git.Client
+}
+
+func Register(ctx context.Context,
+	gitRepos fleetcontrollers.GitRepoController,
+	gitRepoDefaults fleetcontrollers.GitRepoDefaultsCache,
+	secrets corev1controller.SecretCache) {
+	h := &handler{
+		ctx:             ctx,
+		gitrepos:        gitRepos,
+		gitRepoDefaults: gitRepoDefaults,
+		secretCache:     secrets,
+		git:             git.NewClient(),
+	}
+
+	fleetcontrollers.RegisterGitRepoStatusHandler(ctx, gitRepos, revisionCond, "gitrepo-revision", h.OnChange)
+}
+
+// OnChange lists the tags of the GitRepo's repository every polling
+// interval and records the highest tag matching its semver constraint.
+// The git controller checks out the recorded tag.
+func (h *handler) OnChange(gitrepo *fleet.GitRepo, status fleet.GitRepoStatus) (fleet.GitRepoStatus, error) {
+	if gitrepo == nil || gitrepo.DeletionTimestamp != nil {
+		return status, nil
+	}
+
+	selector := gitrepo.Spec.RevisionSelector
+	if selector == nil || selector.Semver == "" {
+		status.ResolvedRevision = nil
+		return status, nil
+	}
+	constraint, err := semver.NewConstraint(selector.Semver)
+	if err != nil {
+		return status, fmt.Errorf("invalid semver constraint %q: %w", selector.Semver, err)
+	}
+
+	gitrepo, err = gitrepodefaults.Inherit(h.gitRepoDefaults, gitrepo)
+	if err != nil {
+		return status, err
+	}
+
+	interval := durations.DefaultGitPollingInterval
+	if gitrepo.Spec.PollingInterval != nil && gitrepo.Spec.PollingInterval.Duration > 0 {
+		interval = gitrepo.Spec.PollingInterval.Duration
+	}
+	if wait := recheckAfter(status.ResolvedRevision, selector.Semver, interval, time.Now()); wait > 0 {
+		h.gitrepos.EnqueueAfter(gitrepo.Namespace, gitrepo.Name, wait)
+		return status, nil
+	}
+
+	opts, err := h.options(gitrepo)
+	if err != nil {
+		return status, err
+	}
+	tags, err := h.git.Tags(h.ctx, opts)
+	if err != nil {
+		return status, err
+	}
+	tag := latestTag(constraint, tags)
+	if tag == "" {
+		return status, fmt.Errorf("no tag of %s matches %s", gitrepo.Spec.Repo, selector.Semver)
+	}
+
+	if status.ResolvedRevision == nil || status.ResolvedRevision.Tag != tag {
+		logrus.Infof("Gitrepo %s/%s follows tag %s, the highest matching %s", gitrepo.Namespace, gitrepo.Name, tag, selector.Semver)
+	}
+	status.ResolvedRevision = &fleet.ResolvedRevision{
+		Semver:      selector.Semver,
+		Tag:         tag,
+		Commit:      tags[tag],
+		LastChecked: metav1.Now(),
+	}
+	h.gitrepos.EnqueueAfter(gitrepo.Namespace, gitrepo.Name, interval)
+	return status, nil
+}
+
+// options returns the options for listing the tags of the GitRepo's
+// repository, with the credentials of its client secret
+func (h *handler) options(gitrepo *fleet.GitRepo) (*git.Options, error) {
+	opts := &git.Options{
+		URL:             gitrepo.Spec.Repo,
+		CABundle:        gitrepo.Spec.CABundle,
+		InsecureSkipTLS: gitrepo.Spec.InsecureSkipTLSverify,
+	}
+	if gitrepo.Spec.ClientSecretName == "" {
+		return opts, nil
+	}
+
+	secret, err := h.secretCache.Get(gitrepo.Namespace, gitrepo.Spec.ClientSecretName)
+	if err != nil {
+		return nil, err
+	}
+	opts.Auth, err = git.AuthFromSecret(secret, gitrepo.Spec.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// latestTag returns the highest tag, which is a semantic version matching
+// the constraint, or an empty string
+func latestTag(constraint *semver.Constraints, tags map[string]string) string {
+	var latest *semver.Version
+	for tag := range tags {
+		version, err := semver.NewVersion(tag)
+		if err != nil || !constraint.Check(version) {
+			continue
+		}
+		if latest == nil || version.GreaterThan(latest) {
+			latest = version
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.Original()
+}
+
+// recheckAfter returns how long to wait until the tags are listed again,
+// zero if the constraint changed or the interval passed
+func recheckAfter(resolved *fleet.ResolvedRevision, constraint string, interval time.Duration, now time.Time) time.Duration {
+	if resolved == nil || resolved.Semver != constraint {
+		return 0
+	}
+	wait := resolved.LastChecked.Add(interval).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
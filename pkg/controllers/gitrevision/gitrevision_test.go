@@ -0,0 +1,65 @@
+package gitrevision
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLatestTag(t *testing.T) {
+	tags := map[string]string{
+		"v1.1.0":     "a",
+		"v1.2.0":     "b",
+		"1.10.1":     "c",
+		"v2.0.0":     "d",
+		"v1.3.0-rc1": "e",
+		"latest":     "f",
+	}
+
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{constraint: ">=1.2.0 <2.0.0", want: "1.10.1"},
+		{constraint: "~1.2", want: "v1.2.0"},
+		{constraint: ">=2.0.0", want: "v2.0.0"},
+		{constraint: "~1.3.0-0", want: "v1.3.0-rc1"},
+		{constraint: ">=3.0.0", want: ""},
+	}
+	for _, tt := range tests {
+		constraint, err := semver.NewConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := latestTag(constraint, tags); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.constraint, tt.want, got)
+		}
+	}
+}
+
+func TestRecheckAfter(t *testing.T) {
+	now := time.Now()
+	resolved := &fleet.ResolvedRevision{
+		Semver:      ">=1.0.0",
+		Tag:         "v1.0.0",
+		LastChecked: metav1.NewTime(now.Add(-5 * time.Second)),
+	}
+
+	if wait := recheckAfter(resolved, ">=1.0.0", 15*time.Second, now); wait != 10*time.Second {
+		t.Errorf("expected to wait until the next poll, got %s", wait)
+	}
+	if wait := recheckAfter(resolved, ">=2.0.0", 15*time.Second, now); wait != 0 {
+		t.Errorf("expected changed constraint to be resolved now, got %s", wait)
+	}
+	if wait := recheckAfter(resolved, ">=1.0.0", time.Second, now); wait != 0 {
+		t.Errorf("expected overdue poll to run now, got %s", wait)
+	}
+	if wait := recheckAfter(nil, ">=1.0.0", 15*time.Second, now); wait != 0 {
+		t.Errorf("expected unresolved selector to be resolved now, got %s", wait)
+	}
+}
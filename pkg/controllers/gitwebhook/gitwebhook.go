@@ -0,0 +1,238 @@
+// Package gitwebhook registers push webhooks for GitRepos on their git provider and receives their push events. (fleetcontroller)
+package gitwebhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/config"
+	"github.com/rancher/fleet/pkg/durations"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/git"
+	"github.com/rancher/fleet/pkg/gitrepodefaults"
+
+	corev1controller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/randomtoken"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ReceiverSecretName is the secret of the webhook receivers in the
+	// system namespace, it holds the webhook secret per provider
+	ReceiverSecretName = "gitjob-webhook"
+
+	webhookCond = "WebhookRegistered"
+)
+
+type handler struct {
+	ctx             context.Context
+	systemNamespace string
+	gitrepos        fleetcontrollers.GitRepoController
+	gitRepoDefaults fleetcontrollers.GitRepoDefaultsCache
+	secrets         corev1controller.SecretClient
+	secretCache     corev1controller.SecretCache
+	newClient       func(provider, repoURL, token string) (git.WebhookClient, error)
+}
+
+func Register(ctx context.Context,
+	systemNamespace string,
+	gitRepos fleetcontrollers.GitRepoController,
+	gitRepoDefaults fleetcontrollers.GitRepoDefaultsCache,
+	secrets corev1controller.SecretController) {
+	h := &handler{
+		ctx:             ctx,
+		systemNamespace: systemNamespace,
+		gitrepos:        gitRepos,
+		gitRepoDefaults: gitRepoDefaults,
+		secrets:         secrets,
+		secretCache:     secrets.Cache(),
+		newClient:       git.NewWebhookClient,
+	}
+
+	fleetcontrollers.RegisterGitRepoStatusHandler(ctx, gitRepos, webhookCond, "gitrepo-webhook", h.OnChange)
+	gitRepos.OnRemove(ctx, "gitrepo-webhook", h.OnRemove)
+}
+
+// OnChange registers or updates the GitRepo's webhook and checks its
+// health periodically. The webhook is deleted if the registration is
+// removed from the spec.
+func (h *handler) OnChange(gitrepo *fleet.GitRepo, status fleet.GitRepoStatus) (fleet.GitRepoStatus, error) {
+	if gitrepo == nil || gitrepo.DeletionTimestamp != nil {
+		return status, nil
+	}
+
+	if gitrepo.Spec.Webhook == nil {
+		if status.Webhook != nil {
+			h.delete(gitrepo, status.Webhook)
+			status.Webhook = nil
+		}
+		return status, nil
+	}
+
+	receiverURL := config.Get().WebhookReceiverURL
+	if receiverURL == "" {
+		return status, errors.New("webhookReceiverURL is not configured")
+	}
+	provider := provider(gitrepo)
+	if provider == "" {
+		return status, fmt.Errorf("cannot detect the git provider of %s, please set it in the webhook registration", gitrepo.Spec.Repo)
+	}
+
+	if wait := recheckAfter(status.Webhook, provider, receiverURL, time.Now()); wait > 0 {
+		h.gitrepos.EnqueueAfter(gitrepo.Namespace, gitrepo.Name, wait)
+		return status, nil
+	}
+
+	client, err := h.client(gitrepo, provider)
+	if err != nil {
+		return status, err
+	}
+	secret, err := h.hookSecret(provider)
+	if err != nil {
+		return status, err
+	}
+
+	var id string
+	if status.Webhook != nil && status.Webhook.Provider == provider {
+		id = status.Webhook.ID
+	}
+	webhook, err := client.EnsureWebhook(h.ctx, id, receiverURL, secret)
+	if err != nil {
+		return status, err
+	}
+
+	status.Webhook = &fleet.WebhookStatus{
+		Provider:    provider,
+		ID:          webhook.ID,
+		URL:         receiverURL,
+		Healthy:     webhook.Healthy,
+		Message:     webhook.Message,
+		LastChecked: metav1.Now(),
+	}
+	h.gitrepos.EnqueueAfter(gitrepo.Namespace, gitrepo.Name, durations.WebhookCheckInterval)
+	return status, nil
+}
+
+// OnRemove deletes the webhook of a deleted GitRepo. Failures are logged,
+// but don't block the deletion.
+func (h *handler) OnRemove(key string, gitrepo *fleet.GitRepo) (*fleet.GitRepo, error) {
+	if gitrepo.Status.Webhook != nil {
+		h.delete(gitrepo, gitrepo.Status.Webhook)
+	}
+	return gitrepo, nil
+}
+
+func (h *handler) delete(gitrepo *fleet.GitRepo, webhook *fleet.WebhookStatus) {
+	if webhook.ID == "" {
+		return
+	}
+	client, err := h.client(gitrepo, webhook.Provider)
+	if err == nil {
+		err = client.DeleteWebhook(h.ctx, webhook.ID)
+	}
+	if err != nil {
+		logrus.Warnf("Failed to delete webhook %s of gitrepo %s/%s, please remove it manually: %v", webhook.ID, gitrepo.Namespace, gitrepo.Name, err)
+	}
+}
+
+// client returns the client of the provider's API, which authenticates
+// with the token of the webhook's client secret. It defaults to the
+// GitRepo's client secret.
+func (h *handler) client(gitrepo *fleet.GitRepo, provider string) (git.WebhookClient, error) {
+	gitrepo, err := gitrepodefaults.Inherit(h.gitRepoDefaults, gitrepo)
+	if err != nil {
+		return nil, err
+	}
+	secretName := gitrepo.Spec.ClientSecretName
+	if gitrepo.Spec.Webhook != nil && gitrepo.Spec.Webhook.ClientSecretName != "" {
+		secretName = gitrepo.Spec.Webhook.ClientSecretName
+	}
+	if secretName == "" {
+		return nil, errors.New("registering a webhook requires a client secret")
+	}
+	secret, err := h.secretCache.Get(gitrepo.Namespace, secretName)
+	if err != nil {
+		return nil, err
+	}
+	token, err := git.APIToken(secret, gitrepo.Spec.Repo)
+	if err != nil {
+		return nil, err
+	}
+	return h.newClient(provider, gitrepo.Spec.Repo, token)
+}
+
+// hookSecret returns the secret the webhook receiver verifies events of
+// the provider with. A random secret is generated, if the receiver's secret
+// doesn't contain one for the provider, so webhooks are never registered
+// without a secret.
+func (h *handler) hookSecret(provider string) (string, error) {
+	key := receiverSecretKey(provider)
+	secret, err := h.secretCache.Get(h.systemNamespace, ReceiverSecretName)
+	notFound := apierrors.IsNotFound(err)
+	if err != nil && !notFound {
+		return "", err
+	}
+	if !notFound && len(secret.Data[key]) > 0 {
+		return string(secret.Data[key]), nil
+	}
+
+	token, err := randomtoken.Generate()
+	if err != nil {
+		return "", err
+	}
+	if notFound {
+		_, err = h.secrets.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: h.systemNamespace,
+				Name:      ReceiverSecretName,
+			},
+			Data: map[string][]byte{key: []byte(token)},
+		})
+		return token, err
+	}
+
+	secret = secret.DeepCopy()
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[key] = []byte(token)
+	_, err = h.secrets.Update(secret)
+	return token, err
+}
+
+// receiverSecretKey returns the key of the provider's secret in the
+// receiver's secret. Gitea sends Gogs compatible events.
+func receiverSecretKey(provider string) string {
+	if provider == git.ProviderGitea {
+		return "gogs"
+	}
+	return provider
+}
+
+func provider(gitrepo *fleet.GitRepo) string {
+	if gitrepo.Spec.Webhook.Provider != "" {
+		return gitrepo.Spec.Webhook.Provider
+	}
+	return git.DetectProvider(gitrepo.Spec.Repo)
+}
+
+// recheckAfter returns how long to wait until the webhook is checked
+// again, zero if it needs to be registered or updated now
+func recheckAfter(webhook *fleet.WebhookStatus, provider, receiverURL string, now time.Time) time.Duration {
+	if webhook == nil || webhook.ID == "" || webhook.Provider != provider || webhook.URL != receiverURL {
+		return 0
+	}
+	wait := webhook.LastChecked.Add(durations.WebhookCheckInterval).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
@@ -0,0 +1,179 @@
+package gitwebhook
+
+import (
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/durations"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/git"
+
+	gitjob "github.com/rancher/gitjob/pkg/apis/gitjob.cattle.io/v1"
+	corev1controller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeSecrets struct {
+	corev1controller.SecretClient
+	secret *corev1.Secret
+}
+
+func (f *fakeSecrets) Create(secret *corev1.Secret) (*corev1.Secret, error) {
+	f.secret = secret
+	return secret, nil
+}
+
+func (f *fakeSecrets) Update(secret *corev1.Secret) (*corev1.Secret, error) {
+	f.secret = secret
+	return secret, nil
+}
+
+type fakeSecretCache struct {
+	corev1controller.SecretCache
+	secrets *fakeSecrets
+}
+
+func (f fakeSecretCache) Get(namespace, name string) (*corev1.Secret, error) {
+	if f.secrets.secret == nil {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+	}
+	return f.secrets.secret, nil
+}
+
+type fakeDefaultsCache struct {
+	fleetcontrollers.GitRepoDefaultsCache
+	defaults []*fleet.GitRepoDefaults
+}
+
+func (f fakeDefaultsCache) List(namespace string, selector labels.Selector) ([]*fleet.GitRepoDefaults, error) {
+	return f.defaults, nil
+}
+
+type tokenSecretCache struct {
+	corev1controller.SecretCache
+}
+
+func (tokenSecretCache) Get(namespace, name string) (*corev1.Secret, error) {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{git.TokenKey: []byte("token-of-" + name)},
+	}, nil
+}
+
+func TestRecheckAfter(t *testing.T) {
+	now := time.Now()
+	webhook := &fleet.WebhookStatus{
+		Provider:    "github",
+		ID:          "1",
+		URL:         "https://fleet.example.com/",
+		LastChecked: metav1.NewTime(now.Add(-time.Minute)),
+	}
+
+	if wait := recheckAfter(webhook, "github", "https://fleet.example.com/", now); wait != durations.WebhookCheckInterval-time.Minute {
+		t.Errorf("expected to wait until the next check, got %s", wait)
+	}
+	if wait := recheckAfter(webhook, "github", "https://new.example.com/", now); wait != 0 {
+		t.Errorf("expected changed receiver URL to be updated now, got %s", wait)
+	}
+	if wait := recheckAfter(webhook, "gitlab", "https://fleet.example.com/", now); wait != 0 {
+		t.Errorf("expected changed provider to be registered now, got %s", wait)
+	}
+	if wait := recheckAfter(webhook, "github", "https://fleet.example.com/", now.Add(durations.WebhookCheckInterval)); wait != 0 {
+		t.Errorf("expected overdue check to run now, got %s", wait)
+	}
+	if wait := recheckAfter(nil, "github", "https://fleet.example.com/", now); wait != 0 {
+		t.Errorf("expected missing webhook to be registered now, got %s", wait)
+	}
+}
+
+func TestReceiverSecretKey(t *testing.T) {
+	if key := receiverSecretKey("gitea"); key != "gogs" {
+		t.Errorf("expected gitea events to be verified as gogs events, got %s", key)
+	}
+	if key := receiverSecretKey("github"); key != "github" {
+		t.Errorf("unexpected key %s", key)
+	}
+}
+
+func TestPushedCommit(t *testing.T) {
+	refs := []git.PushedRef{{Tag: "v1", Commit: "tag"}, {Branch: "main", Commit: "abc"}}
+
+	gj := &gitjob.GitJob{}
+	gj.Spec.Git.Branch = "main"
+	if commit := pushedCommit(gj, refs); commit != "abc" {
+		t.Errorf("expected the push to the gitjob's branch, got %q", commit)
+	}
+
+	gj.Spec.Git.Revision = "v1"
+	if commit := pushedCommit(gj, refs); commit != "" {
+		t.Errorf("expected gitjobs with a fixed revision not to poll, got %q", commit)
+	}
+}
+
+func TestHookSecret(t *testing.T) {
+	secrets := &fakeSecrets{}
+	h := &handler{systemNamespace: "cattle-fleet-system", secrets: secrets, secretCache: fakeSecretCache{secrets: secrets}}
+
+	github, err := h.hookSecret("github")
+	if err != nil || github == "" {
+		t.Fatalf("expected a generated secret, got %q, %v", github, err)
+	}
+	if secrets.secret.Name != ReceiverSecretName || string(secrets.secret.Data["github"]) != github {
+		t.Errorf("expected generated secret to be stored, got %+v", secrets.secret)
+	}
+
+	if s, err := h.hookSecret("github"); err != nil || s != github {
+		t.Errorf("expected stored secret %q, got %q, %v", github, s, err)
+	}
+
+	gitea, err := h.hookSecret("gitea")
+	if err != nil || gitea == "" || gitea == github {
+		t.Fatalf("expected another generated secret, got %q, %v", gitea, err)
+	}
+	if string(secrets.secret.Data["gogs"]) != gitea || string(secrets.secret.Data["github"]) != github {
+		t.Errorf("expected secret to be added, got %+v", secrets.secret.Data)
+	}
+}
+
+func TestClientSecret(t *testing.T) {
+	var token string
+	h := &handler{
+		gitRepoDefaults: fakeDefaultsCache{},
+		secretCache:     tokenSecretCache{},
+		newClient: func(provider, repoURL, t string) (git.WebhookClient, error) {
+			token = t
+			return nil, nil
+		},
+	}
+	gitrepo := &fleet.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "repo"},
+		Spec: fleet.GitRepoSpec{
+			Repo:    "https://github.com/rancher/fleet-examples",
+			Webhook: &fleet.WebhookRegistration{},
+		},
+	}
+
+	if _, err := h.client(gitrepo, "github"); err == nil {
+		t.Error("expected an error without a client secret")
+	}
+
+	h.gitRepoDefaults = fakeDefaultsCache{defaults: []*fleet.GitRepoDefaults{{
+		Spec: fleet.GitRepoDefaultsSpec{ClientSecretName: "clone", WebhookClientSecretName: "webhooks"},
+	}}}
+	if _, err := h.client(gitrepo, "github"); err != nil || token != "token-of-webhooks" {
+		t.Errorf("expected the default webhook client secret, got %q, %v", token, err)
+	}
+
+	gitrepo.Spec.ClientSecretName = "own"
+	gitrepo.Spec.Webhook.ClientSecretName = "own-webhooks"
+	if _, err := h.client(gitrepo, "github"); err != nil || token != "token-of-own-webhooks" {
+		t.Errorf("expected the gitrepo's webhook client secret, got %q, %v", token, err)
+	}
+}
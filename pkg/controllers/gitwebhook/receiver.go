@@ -0,0 +1,129 @@
+package gitwebhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/rancher/fleet/pkg/git"
+
+	gitjob "github.com/rancher/gitjob/pkg/apis/gitjob.cattle.io/v1"
+	gitcontrollers "github.com/rancher/gitjob/pkg/generated/controllers/gitjob.cattle.io/v1"
+	corev1controller "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// maxEventSize limits the size of webhook requests
+const maxEventSize = 10 << 20
+
+// Receiver receives push events from GitHub, GitLab, Bitbucket Cloud and
+// Server, Gitea and Azure DevOps and makes the GitJobs, whose repo and
+// branch match, poll their repo immediately, instead of waiting for the
+// next interval. The pushed commit isn't trusted, GitJobs only deploy
+// commits they fetched from the repo themselves. Events are rejected,
+// unless the receiver's secret holds a secret for their provider.
+type Receiver struct {
+	systemNamespace string
+	gitjobs         gitcontrollers.GitJobController
+	secretCache     corev1controller.SecretCache
+}
+
+func NewReceiver(systemNamespace string, gitjobs gitcontrollers.GitJobController, secrets corev1controller.SecretController) *Receiver {
+	return &Receiver{
+		systemNamespace: systemNamespace,
+		gitjobs:         gitjobs,
+		secretCache:     secrets.Cache(),
+	}
+}
+
+// Serve serves the receiver on addr, until the context is done.
+func (r *Receiver) Serve(ctx context.Context, addr string) {
+	server := &http.Server{Addr: addr, Handler: r, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	logrus.Infof("Receiving git webhook events on %s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logrus.Errorf("Git webhook receiver failed: %v", err)
+	}
+}
+
+func (r *Receiver) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req.Body = http.MaxBytesReader(rw, req.Body, maxEventSize)
+	event, err := git.ParsePushEvent(req, r.secret)
+	switch {
+	case errors.Is(err, git.ErrIgnoredEvent):
+		rw.WriteHeader(http.StatusOK)
+		return
+	case errors.Is(err, git.ErrUnauthorized):
+		http.Error(rw, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		logrus.Debugf("Invalid git webhook event: %v", err)
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	gitjobs, err := r.gitjobs.Cache().List("", labels.Everything())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, gj := range gitjobs {
+		if !event.Matches(gj.Spec.Git.Repo) {
+			continue
+		}
+		commit := pushedCommit(gj, event.Refs)
+		if commit == "" || commit == gj.Status.Commit {
+			continue
+		}
+		logrus.Infof("Received %s push of commit %s for gitjob %s/%s, polling its repo", event.Provider, commit, gj.Namespace, gj.Name)
+		gj = gj.DeepCopy()
+		// gitjob polls the repo, once its last sync is older than the
+		// sync interval
+		gj.Status.LastSyncedTime = metav1.Time{}
+		if _, err := r.gitjobs.UpdateStatus(gj); err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+// pushedCommit returns the commit pushed to the gitjob's branch. GitJobs
+// with a fixed revision don't poll.
+func pushedCommit(gj *gitjob.GitJob, refs []git.PushedRef) string {
+	if gj.Spec.Git.Revision != "" {
+		return ""
+	}
+	for _, ref := range refs {
+		if ref.Branch != "" && ref.Branch == gj.Spec.Git.Branch {
+			return ref.Commit
+		}
+	}
+	return ""
+}
+
+// secret returns the receiver's secret for the provider, if any
+func (r *Receiver) secret(provider string) string {
+	secret, err := r.secretCache.Get(r.systemNamespace, ReceiverSecretName)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			logrus.Warnf("Failed to get git webhook secret: %v", err)
+		}
+		return ""
+	}
+	return string(secret.Data[receiverSecretKey(provider)])
+}
@@ -8,21 +8,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path"
 	"path/filepath"
 	"sort"
 	"strings"
-	"sync"
 	"text/template"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
-	gogit "github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
-	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
@@ -31,6 +24,8 @@ import (
 	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/durations"
 	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/git"
+	"github.com/rancher/fleet/pkg/gitrepodefaults"
 	"github.com/rancher/fleet/pkg/update"
 
 	"github.com/rancher/wrangler/pkg/condition"
@@ -44,7 +39,7 @@ import (
 )
 
 var (
-	lock sync.Mutex
+	limiter = newSyncLimiter(gitSyncConcurrency)
 
 	defaultInterval = durations.DefaultImageInterval
 )
@@ -62,12 +57,14 @@ const (
 	imageSyncCond = "ImageSynced"
 )
 
-func Register(ctx context.Context, core corev1controller.Interface, gitRepos fleetcontrollers.GitRepoController, images fleetcontrollers.ImageScanController) {
+func Register(ctx context.Context, core corev1controller.Interface, gitRepos fleetcontrollers.GitRepoController, gitRepoDefaults fleetcontrollers.GitRepoDefaultsCache, images fleetcontrollers.ImageScanController) {
 	h := handler{
-		ctx:         ctx,
-		secretCache: core.Secret().Cache(),
-		gitrepos:    gitRepos,
-		imagescans:  images,
+		ctx:             ctx,
+		secretCache:     core.Secret().Cache(),
+		gitrepos:        gitRepos,
+		gitRepoDefaults: gitRepoDefaults,
+		imagescans:      images,
+		git:             git.NewClient(),
 	}
 
 	fleetcontrollers.RegisterImageScanStatusHandler(ctx, images, imageScanCond, "image-scan", h.onChange)
@@ -76,10 +73,12 @@ func Register(ctx context.Context, core corev1controller.Interface, gitRepos fle
 }
 
 type handler struct {
-	ctx         context.Context
-	secretCache corev1controller.SecretCache
-	gitrepos    fleetcontrollers.GitRepoController
-	imagescans  fleetcontrollers.ImageScanController
+	ctx             context.Context
+	secretCache     corev1controller.SecretCache
+	gitrepos        fleetcontrollers.GitRepoController
+	gitRepoDefaults fleetcontrollers.GitRepoDefaultsCache
+	imagescans      fleetcontrollers.ImageScanController
+	git             git.Client
 }
 
 func (h handler) onChange(image *v1alpha1.ImageScan, status v1alpha1.ImageScanStatus) (v1alpha1.ImageScanStatus, error) {
@@ -214,10 +213,17 @@ func (h handler) onChangeGitRepo(gitrepo *v1alpha1.GitRepo, status v1alpha1.GitR
 
 	logrus.Debugf("onChangeGitRepo: gitrepo %s/%s changed, syncing repo for image scans", gitrepo.Namespace, gitrepo.Name)
 
-	// This lock is required to prevent conflicts while using the environment variable SSH_KNOWN_HOSTS.
-	// It was added before the SSH support so there might be other potential conflicts without it.
-	lock.Lock()
-	defer lock.Unlock()
+	gitrepo, err = gitrepodefaults.Inherit(h.gitRepoDefaults, gitrepo)
+	if err != nil {
+		return status, err
+	}
+
+	release := limiter.acquire(gitrepo.Spec.Repo + "#" + gitrepo.Spec.Branch)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(h.ctx, durations.ImageSyncTimeout)
+	defer cancel()
+
 	// todo: maybe we should preserve the dir
 	tmp, err := os.MkdirTemp("", fmt.Sprintf("%s-%s", gitrepo.Namespace, gitrepo.Name))
 	if err != nil {
@@ -232,23 +238,13 @@ func (h handler) onChangeGitRepo(gitrepo *v1alpha1.GitRepo, status v1alpha1.GitR
 		return status, err
 	}
 
-	// Remove SSH known_hosts tmpdir unless it was provided by the user
-	if os.Getenv("SSH_KNOWN_HOSTS") != "" {
-		tmpdir := filepath.Dir(os.Getenv("SSH_KNOWN_HOSTS"))
-		if strings.HasPrefix(tmpdir, "/tmp/"+fmt.Sprintf("ssh-%s-%s-", gitrepo.Namespace, gitrepo.Name)) {
-			defer os.RemoveAll(tmpdir)
-		}
-	}
-
-	repo, err := gogit.PlainClone(tmp, false, &gogit.CloneOptions{
-		URL:           gitrepo.Spec.Repo,
-		Auth:          auth,
-		RemoteName:    "origin",
-		ReferenceName: plumbing.NewBranchReferenceName(gitrepo.Spec.Branch),
-		SingleBranch:  true,
-		Depth:         1,
-		Progress:      nil,
-		Tags:          gogit.NoTags,
+	repo, err := h.git.Clone(ctx, tmp, &git.Options{
+		URL:             gitrepo.Spec.Repo,
+		Branch:          gitrepo.Spec.Branch,
+		Auth:            auth,
+		CABundle:        gitrepo.Spec.CABundle,
+		InsecureSkipTLS: gitrepo.Spec.InsecureSkipTLSverify,
+		Depth:           1,
 	})
 	if err != nil {
 		kstatus.SetError(gitrepo, err.Error())
@@ -270,7 +266,7 @@ func (h handler) onChangeGitRepo(gitrepo *v1alpha1.GitRepo, status v1alpha1.GitR
 		}
 	}
 
-	commit, err := commitAllAndPush(context.Background(), repo, auth, gitrepo.Spec.ImageScanCommit)
+	commit, err := commitAllAndPush(ctx, repo, gitrepo.Spec.ImageScanCommit)
 	if err != nil {
 		kstatus.SetError(gitrepo, err.Error())
 		return status, err
@@ -289,32 +285,6 @@ func (h handler) onChangeGitRepo(gitrepo *v1alpha1.GitRepo, status v1alpha1.GitR
 	return status, err
 }
 
-func setupKnownHosts(gitrepo *v1alpha1.GitRepo, data []byte) error {
-	tmpdir, err := os.MkdirTemp("", fmt.Sprintf("ssh-%s-%s-", gitrepo.Namespace, gitrepo.Name))
-	if err != nil {
-		return err
-	}
-
-	known := path.Join(tmpdir, "known_hosts")
-	err = os.Setenv("SSH_KNOWN_HOSTS", known)
-	if err != nil {
-		return err
-	}
-
-	file, err := os.Create(known)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	_, err = file.Write(data)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func shouldSync(gitrepo *v1alpha1.GitRepo) bool {
 	interval := gitrepo.Spec.ImageSyncInterval
 	if interval == nil || interval.Seconds() == 0.0 {
@@ -329,19 +299,7 @@ func shouldSync(gitrepo *v1alpha1.GitRepo) bool {
 	return true
 }
 
-func commitAllAndPush(ctx context.Context, repo *gogit.Repository, auth transport.AuthMethod, commit v1alpha1.CommitSpec) (string, error) {
-	working, err := repo.Worktree()
-	if err != nil {
-		return "", err
-	}
-
-	status, err := working.Status()
-	if err != nil {
-		return "", err
-	} else if status.IsClean() {
-		return "", nil
-	}
-
+func commitAllAndPush(ctx context.Context, repo git.Repository, commit v1alpha1.CommitSpec) (string, error) {
 	msgTmpl := commit.MessageTemplate
 	if msgTmpl == "" {
 		msgTmpl = defaultMessageTemplate
@@ -355,20 +313,9 @@ func commitAllAndPush(ctx context.Context, repo *gogit.Repository, auth transpor
 		return "", err
 	}
 
-	var rev plumbing.Hash
-	if rev, err = working.Commit(buf.String(), &gogit.CommitOptions{
-		All: true,
-		Author: &object.Signature{
-			Name:  commit.AuthorName,
-			Email: commit.AuthorEmail,
-			When:  time.Now(),
-		},
-	}); err != nil {
-		return "", err
-	}
-
-	return rev.String(), repo.PushContext(ctx, &gogit.PushOptions{
-		Auth: auth,
+	return repo.CommitAllAndPush(ctx, buf.String(), git.Signature{
+		Name:  commit.AuthorName,
+		Email: commit.AuthorEmail,
 	})
 }
 
@@ -382,30 +329,11 @@ func (h handler) auth(gitrepo *v1alpha1.GitRepo) (transport.AuthMethod, error) {
 		return nil, err
 	}
 
-	switch secret.Type {
-	case corev1.SecretTypeBasicAuth:
-		return &http.BasicAuth{
-			Username: string(secret.Data[corev1.BasicAuthUsernameKey]),
-			Password: string(secret.Data[corev1.BasicAuthPasswordKey]),
-		}, nil
-	case corev1.SecretTypeSSHAuth:
-		knownHosts := secret.Data["known_hosts"]
-		if knownHosts == nil {
-			logrus.Infof("The git secret `%s` does not have a known_hosts field, so no host key verification possible!", gitrepo.Spec.ClientSecretName)
-		} else {
-			err := setupKnownHosts(gitrepo, knownHosts)
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		publicKey, err := ssh.NewPublicKeys("git", secret.Data[corev1.SSHAuthPrivateKey], "")
-		if err != nil {
-			return nil, err
-		}
-		return publicKey, nil
+	if secret.Type == corev1.SecretTypeSSHAuth && secret.Data[git.KnownHostsKey] == nil {
+		logrus.Infof("The git secret `%s` does not have a known_hosts field, so the default known hosts are used for host key verification!", gitrepo.Spec.ClientSecretName)
 	}
-	return nil, errors.New("invalid secret type")
+
+	return git.AuthFromSecret(secret, gitrepo.Spec.Repo)
 }
 
 // authFromSecret creates an Authenticator that can be given to the
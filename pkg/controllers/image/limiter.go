@@ -0,0 +1,56 @@
+package image
+
+import (
+	"sync"
+
+	"github.com/rancher/fleet/pkg/config"
+)
+
+const defaultGitSyncConcurrency = 4
+
+// syncLimiter bounds the number of GitRepos synced at the same time and
+// serializes syncs pushing to the same branch of a repository, so one
+// large repository doesn't delay all others.
+type syncLimiter struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	active  int
+	running map[string]bool
+	limit   func() int
+}
+
+func newSyncLimiter(limit func() int) *syncLimiter {
+	l := &syncLimiter{
+		running: map[string]bool{},
+		limit:   limit,
+	}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire blocks until a sync for key may start and returns the function
+// releasing it.
+func (l *syncLimiter) acquire(key string) func() {
+	l.mu.Lock()
+	for l.running[key] || l.active >= l.limit() {
+		l.cond.Wait()
+	}
+	l.active++
+	l.running[key] = true
+	l.mu.Unlock()
+
+	return func() {
+		l.mu.Lock()
+		l.active--
+		delete(l.running, key)
+		l.mu.Unlock()
+		l.cond.Broadcast()
+	}
+}
+
+func gitSyncConcurrency() int {
+	if n := config.Get().GitSyncConcurrency; n > 0 {
+		return n
+	}
+	return defaultGitSyncConcurrency
+}
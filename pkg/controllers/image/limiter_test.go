@@ -0,0 +1,64 @@
+package image
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSyncLimiter(t *testing.T) {
+	l := newSyncLimiter(func() int { return 2 })
+
+	var active, maxActive int32
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			release := l.acquire(key)
+			defer release()
+			n := atomic.AddInt32(&active, 1)
+			for {
+				m := atomic.LoadInt32(&maxActive)
+				if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+		}(key)
+	}
+	wg.Wait()
+
+	if maxActive > 2 {
+		t.Errorf("expected at most 2 concurrent syncs, got %d", maxActive)
+	}
+}
+
+func TestSyncLimiterSerializesKey(t *testing.T) {
+	l := newSyncLimiter(func() int { return 10 })
+
+	release := l.acquire("repo#main")
+	acquired := make(chan struct{})
+	go func() {
+		l.acquire("repo#main")()
+		close(acquired)
+	}()
+
+	other := l.acquire("repo#dev")
+	other()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected second sync of the same branch to wait")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("expected second sync to start after release")
+	}
+}
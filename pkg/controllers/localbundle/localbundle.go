@@ -0,0 +1,103 @@
+// Package localbundle creates bundles in the local workspace from a directory
+// mounted into the controller, and recreates them whenever a file in it
+// changes. It is meant for iterating on fleet.yaml files without pushing to
+// git. (fleetcontroller)
+//
+// The directory is read like a git repository by 'fleet apply'. Bundles
+// whose directory was removed are deleted again.
+package localbundle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/rancher/fleet/modules/cli/apply"
+	"github.com/rancher/fleet/modules/cli/pkg/client"
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/config"
+	"github.com/rancher/fleet/pkg/durations"
+
+	"github.com/rancher/wrangler/pkg/ticker"
+)
+
+const (
+	// DirEnv is the environment variable holding the directory to watch
+	DirEnv = "FLEET_LOCAL_BUNDLE_DIR"
+	// RepoName is used as the fleet.cattle.io/repo-name label of the
+	// created bundles, like the name of a gitrepo. They are marked by
+	// fleet.LocalBundleLabel, so they are not purged as orphans of a missing
+	// gitrepo.
+	RepoName = "local-bundles"
+)
+
+// Register watches the directory named by FLEET_LOCAL_BUNDLE_DIR, if set
+func Register(ctx context.Context) {
+	dir := os.Getenv(DirEnv)
+	if dir == "" {
+		return
+	}
+
+	logrus.Infof("Creating local bundles from directory %s", dir)
+	go watch(ctx, dir)
+}
+
+func watch(ctx context.Context, dir string) {
+	var last string
+	for range ticker.Context(ctx, durations.LocalBundleDirPollInterval) {
+		namespace := config.Get().Bootstrap.Namespace
+		if namespace == "" || namespace == "-" {
+			continue
+		}
+
+		sum, err := fingerprint(dir)
+		if err != nil {
+			logrus.Warnf("Error reading local bundle directory %s: %v", dir, err)
+			continue
+		}
+		if sum == last {
+			continue
+		}
+		// failures are retried once a file changes
+		last = sum
+
+		if err := apply.Apply(ctx, &client.Getter{Namespace: namespace}, RepoName, nil, apply.Options{
+			Root: dir,
+			Labels: map[string]string{
+				fleet.RepoLabel:        RepoName,
+				fleet.LocalBundleLabel: "true",
+			},
+		}); err != nil {
+			logrus.Errorf("Failed to create local bundles from %s: %v", dir, err)
+			continue
+		}
+		logrus.Infof("Updated local bundles from %s", dir)
+	}
+}
+
+// fingerprint returns a checksum of the names, sizes and modification times
+// of all files in dir
+func fingerprint(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\x00", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,44 @@
+package localbundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fleet.yaml"), []byte("namespace: a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := fingerprint(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := fingerprint(dir); again != first {
+		t.Error("expected unchanged directory to have the same fingerprint")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "fleet.yaml"), []byte("namespace: b2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := fingerprint(dir); changed == first {
+		t.Error("expected changed file to change the fingerprint")
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "app"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := fingerprint(dir)
+	if err := os.Remove(filepath.Join(dir, "app")); err != nil {
+		t.Fatal(err)
+	}
+	if removed, _ := fingerprint(dir); removed == second {
+		t.Error("expected removed directory to change the fingerprint")
+	}
+
+	if _, err := fingerprint(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
@@ -0,0 +1,123 @@
+// Package observer creates role bindings, which grant read-only access to the Fleet resources of a workspace. (fleetcontroller)
+//
+// A workspace is a namespace, which contains clusters or gitrepos. Members of
+// the workspace's observer group can read its resources, as well as the
+// bundle deployments in the namespaces of its clusters.
+package observer
+
+import (
+	"context"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+
+	"github.com/rancher/wrangler/pkg/apply"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/relatedresource"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// ClusterRoleName is the cluster role, which grants read-only access
+	// to Fleet resources
+	ClusterRoleName = "fleet-observer"
+	// GroupPrefix is the prefix of the observer group of a workspace
+	GroupPrefix = "fleet:observer:"
+)
+
+type handler struct {
+	apply    apply.Apply
+	clusters fleetcontrollers.ClusterCache
+	gitRepos fleetcontrollers.GitRepoCache
+}
+
+func Register(ctx context.Context,
+	apply apply.Apply,
+	namespaces corecontrollers.NamespaceController,
+	clusters fleetcontrollers.ClusterController,
+	gitRepos fleetcontrollers.GitRepoController,
+) {
+	h := &handler{
+		apply:    apply.WithSetID("fleet-observer"),
+		clusters: clusters.Cache(),
+		gitRepos: gitRepos.Cache(),
+	}
+
+	namespaces.OnChange(ctx, "observer", h.OnNamespace)
+	relatedresource.WatchClusterScoped(ctx, "observer-resolver", resolveNamespace, namespaces, clusters, gitRepos)
+}
+
+// Group returns the observer group of the workspace
+func Group(workspace string) string {
+	return GroupPrefix + workspace
+}
+
+func resolveNamespace(namespace, _ string, _ runtime.Object) ([]relatedresource.Key, error) {
+	return []relatedresource.Key{{Name: namespace}}, nil
+}
+
+func (h *handler) OnNamespace(key string, namespace *corev1.Namespace) (*corev1.Namespace, error) {
+	if namespace == nil || namespace.DeletionTimestamp != nil {
+		return namespace, nil
+	}
+
+	clusters, err := h.clusters.List(namespace.Name, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	gitRepos, err := h.gitRepos.List(namespace.Name, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []runtime.Object
+	if len(clusters) > 0 || len(gitRepos) > 0 {
+		objs = roleBindings(namespace.Name, clusters)
+	}
+
+	return namespace, h.apply.
+		WithOwner(namespace).
+		ApplyObjects(objs...)
+}
+
+// roleBindings binds the observer group of the workspace to the observer
+// cluster role in the workspace and in each cluster's namespace
+func roleBindings(workspace string, clusters []*fleet.Cluster) []runtime.Object {
+	namespaces := []string{workspace}
+	for _, cluster := range clusters {
+		if cluster.Status.Namespace != "" {
+			namespaces = append(namespaces, cluster.Status.Namespace)
+		}
+	}
+
+	var objs []runtime.Object
+	for _, ns := range namespaces {
+		objs = append(objs, &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ClusterRoleName,
+				Namespace: ns,
+				Labels: map[string]string{
+					fleet.ManagedLabel: "true",
+				},
+			},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:     rbacv1.GroupKind,
+					APIGroup: rbacv1.GroupName,
+					Name:     Group(workspace),
+				},
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     ClusterRoleName,
+			},
+		})
+	}
+	return objs
+}
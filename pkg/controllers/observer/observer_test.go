@@ -0,0 +1,34 @@
+package observer
+
+import (
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestRoleBindings(t *testing.T) {
+	clusters := []*fleet.Cluster{
+		{Status: fleet.ClusterStatus{Namespace: "cluster-fleet-default-c-1"}},
+		{Status: fleet.ClusterStatus{}},
+	}
+
+	objs := roleBindings("fleet-default", clusters)
+	if len(objs) != 2 {
+		t.Fatalf("expected 2 role bindings, got %d", len(objs))
+	}
+
+	for i, ns := range []string{"fleet-default", "cluster-fleet-default-c-1"} {
+		rb := objs[i].(*rbacv1.RoleBinding)
+		if rb.Namespace != ns {
+			t.Errorf("expected role binding in %s, got %s", ns, rb.Namespace)
+		}
+		if rb.Subjects[0].Name != "fleet:observer:fleet-default" {
+			t.Errorf("unexpected subject %s", rb.Subjects[0].Name)
+		}
+		if rb.RoleRef.Name != ClusterRoleName {
+			t.Errorf("unexpected role %s", rb.RoleRef.Name)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package revision records the generations of bundles as bundle revisions, so deleted or broken bundles can be restored. (fleetcontroller)
+//
+// A revision refers to the content resource holding the bundle's resources,
+// which is not purged while the revision exists. The newest revisions are
+// kept per bundle, the revisions of deleted bundles expire after the
+// configured retention.
+package revision
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/config"
+	"github.com/rancher/fleet/pkg/durations"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/manifest"
+	name2 "github.com/rancher/fleet/pkg/name"
+
+	"github.com/rancher/wrangler/pkg/name"
+	"github.com/rancher/wrangler/pkg/ticker"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type handler struct {
+	revisions     fleetcontrollers.BundleRevisionClient
+	revisionCache fleetcontrollers.BundleRevisionCache
+	bundleCache   fleetcontrollers.BundleCache
+}
+
+func Register(ctx context.Context,
+	bundles fleetcontrollers.BundleController,
+	revisions fleetcontrollers.BundleRevisionController) {
+	h := &handler{
+		revisions:     revisions,
+		revisionCache: revisions.Cache(),
+		bundleCache:   bundles.Cache(),
+	}
+
+	bundles.OnChange(ctx, "bundle-revision", h.OnBundleChange)
+
+	go h.purgeExpired(ctx)
+}
+
+// Selector selects the revisions of a bundle
+func Selector(bundleName string) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{fleet.BundleLabel: name2.LabelValue(bundleName)})
+}
+
+// SortNewestFirst sorts revisions by creation time and generation, newest first
+func SortNewestFirst(revisions []*fleet.BundleRevision) {
+	sort.SliceStable(revisions, func(i, j int) bool {
+		ti, tj := revisions[i].CreationTimestamp, revisions[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return tj.Before(&ti)
+		}
+		return revisions[i].BundleGeneration > revisions[j].BundleGeneration
+	})
+}
+
+// OnBundleChange records a revision for each generation of a bundle, once
+// the bundle controller has observed it and stored its content.
+func (h *handler) OnBundleChange(key string, bundle *fleet.Bundle) (*fleet.Bundle, error) {
+	limit := config.Get().BundleRevisionHistoryLimit
+	if bundle == nil || bundle.DeletionTimestamp != nil || limit <= 0 {
+		return bundle, nil
+	}
+	if bundle.Status.ObservedGeneration != bundle.Generation {
+		return bundle, nil
+	}
+
+	revisions, err := h.revisionCache.List(bundle.Namespace, Selector(bundle.Name))
+	if err != nil {
+		return nil, err
+	}
+	for _, rev := range revisions {
+		if rev.BundleUID == bundle.UID && rev.BundleGeneration == bundle.Generation {
+			return bundle, nil
+		}
+	}
+
+	rev, err := newRevision(bundle)
+	if err != nil {
+		return nil, err
+	}
+	created, err := h.revisions.Create(rev)
+	if apierrors.IsAlreadyExists(err) {
+		return bundle, nil
+	} else if err != nil {
+		return nil, err
+	}
+	logrus.Debugf("Recorded revision %s of bundle %s", created.Name, key)
+
+	return bundle, h.prune(append(revisions, created), limit)
+}
+
+// newRevision returns a revision for the current generation of the bundle
+// (pure function)
+func newRevision(bundle *fleet.Bundle) (*fleet.BundleRevision, error) {
+	m, err := manifest.New(bundle.Spec.Resources)
+	if err != nil {
+		return nil, err
+	}
+	_, manifestID, err := m.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	spec := bundle.Spec.DeepCopy()
+	spec.Resources = nil
+
+	return &fleet.BundleRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.SafeConcatName(bundle.Name, strconv.FormatInt(bundle.Generation, 10), string(bundle.UID)),
+			Namespace: bundle.Namespace,
+			Labels: map[string]string{
+				fleet.BundleLabel: name2.LabelValue(bundle.Name),
+			},
+		},
+		BundleName:       bundle.Name,
+		BundleUID:        bundle.UID,
+		BundleGeneration: bundle.Generation,
+		BundleLabels:     bundle.Labels,
+		ManifestID:       manifestID,
+		Spec:             *spec,
+	}, nil
+}
+
+// prune deletes the oldest revisions exceeding the limit
+func (h *handler) prune(revisions []*fleet.BundleRevision, limit int) error {
+	if len(revisions) <= limit {
+		return nil
+	}
+	SortNewestFirst(revisions)
+	for _, rev := range revisions[limit:] {
+		if err := h.revisions.Delete(rev.Namespace, rev.Name, nil); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeExpired deletes the revisions of deleted bundles after the
+// retention expired
+func (h *handler) purgeExpired(ctx context.Context) {
+	for range ticker.Context(ctx, durations.GarbageCollect) {
+		retention := config.Get().BundleRevisionRetention.Duration
+		if retention <= 0 {
+			retention = durations.DefaultBundleRevisionRetention
+		}
+
+		revisions, err := h.revisionCache.List("", labels.Everything())
+		if err != nil {
+			logrus.Warnf("Error listing bundle revisions: %v", err)
+			continue
+		}
+
+		for _, rev := range expired(revisions, h.bundleExists, time.Now().Add(-retention)) {
+			if err := h.revisions.Delete(rev.Namespace, rev.Name, nil); err != nil && !apierrors.IsNotFound(err) {
+				logrus.Warnf("Error deleting expired bundle revision %s/%s: %v", rev.Namespace, rev.Name, err)
+			}
+		}
+	}
+}
+
+func (h *handler) bundleExists(namespace, name string) bool {
+	_, err := h.bundleCache.Get(namespace, name)
+	return !apierrors.IsNotFound(err)
+}
+
+// expired returns the revisions of deleted bundles, whose newest revision
+// was created before the deadline
+func expired(revisions []*fleet.BundleRevision, exists func(namespace, name string) bool, deadline time.Time) []*fleet.BundleRevision {
+	byBundle := map[string][]*fleet.BundleRevision{}
+	for _, rev := range revisions {
+		key := rev.Namespace + "/" + rev.BundleName
+		byBundle[key] = append(byBundle[key], rev)
+	}
+
+	var result []*fleet.BundleRevision
+	for _, revs := range byBundle {
+		if exists(revs[0].Namespace, revs[0].BundleName) {
+			continue
+		}
+		SortNewestFirst(revs)
+		if revs[0].CreationTimestamp.Time.Before(deadline) {
+			result = append(result, revs...)
+		}
+	}
+	return result
+}
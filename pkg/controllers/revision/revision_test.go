@@ -0,0 +1,77 @@
+package revision
+
+import (
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/manifest"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestNewRevision(t *testing.T) {
+	bundle := &fleet.Bundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "app",
+			Namespace:  "fleet-default",
+			UID:        "1234",
+			Generation: 3,
+			Labels:     map[string]string{fleet.RepoLabel: "repo"},
+		},
+		Spec: fleet.BundleSpec{
+			Resources: []fleet.BundleResource{{Name: "cm.yaml", Content: "kind: ConfigMap"}},
+		},
+	}
+
+	rev, err := newRevision(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ := manifest.New(bundle.Spec.Resources)
+	_, id, _ := m.Content()
+	if rev.ManifestID != id {
+		t.Errorf("expected manifest id %s, got %s", id, rev.ManifestID)
+	}
+	if rev.Spec.Resources != nil {
+		t.Error("expected resources to be stored in the content only")
+	}
+	if rev.BundleUID != "1234" || rev.BundleGeneration != 3 || rev.BundleLabels[fleet.RepoLabel] != "repo" {
+		t.Errorf("unexpected revision %+v", rev)
+	}
+	if !Selector("app").Matches(labels.Set(rev.Labels)) {
+		t.Error("expected selector to match revision")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	rev := func(bundle string, age time.Duration) *fleet.BundleRevision {
+		return &fleet.BundleRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              bundle + "-" + age.String(),
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			BundleName: bundle,
+		}
+	}
+	exists := func(namespace, name string) bool { return name == "live" }
+
+	result := expired([]*fleet.BundleRevision{
+		rev("live", 300*time.Hour),
+		rev("old", 300*time.Hour),
+		rev("old", 200*time.Hour),
+		rev("recent", 300*time.Hour),
+		rev("recent", time.Hour),
+	}, exists, now.Add(-168*time.Hour))
+
+	if len(result) != 2 {
+		t.Fatalf("expected the two revisions of the old bundle, got %d", len(result))
+	}
+	for _, r := range result {
+		if r.BundleName != "old" {
+			t.Errorf("unexpected expired revision %s", r.Name)
+		}
+	}
+}
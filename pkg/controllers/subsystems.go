@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Subsystem is a group of controllers, which can be enabled or disabled as
+// a whole. There is no drift subsystem, drift is detected and corrected by
+// the agents. The fleet-controller only reports it, as part of the
+// notifications subsystem.
+type Subsystem string
+
+const (
+	// SubsystemBundle deploys bundles to clusters, it includes the content,
+	// revision, chart version and bundle graph controllers
+	SubsystemBundle Subsystem = "bundle"
+	// SubsystemGitOps watches GitRepos and creates their bundles
+	SubsystemGitOps Subsystem = "gitops"
+	// SubsystemImageScan scans image repositories and updates GitRepos
+	SubsystemImageScan Subsystem = "imagescan"
+	// SubsystemClusterGroups computes the status of cluster groups
+	SubsystemClusterGroups Subsystem = "clustergroups"
+	// SubsystemNotifications sends CloudEvents on state changes
+	SubsystemNotifications Subsystem = "notifications"
+	// SubsystemBootstrap sets up the local cluster
+	SubsystemBootstrap Subsystem = "bootstrap"
+)
+
+var subsystems = []Subsystem{
+	SubsystemBundle,
+	SubsystemGitOps,
+	SubsystemImageScan,
+	SubsystemClusterGroups,
+	SubsystemNotifications,
+	SubsystemBootstrap,
+}
+
+// Subsystems maps subsystems to whether they are enabled. Subsystems which
+// are not in the map are enabled.
+type Subsystems map[Subsystem]bool
+
+// ParseSubsystems parses a comma separated list of subsystem=bool pairs,
+// e.g. "gitops=false,imagescan=false".
+func ParseSubsystems(s string) (Subsystems, error) {
+	result := Subsystems{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid subsystem %q, expected subsystem=true|false", pair)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for subsystem %q: %w", k, err)
+		}
+		if err := result.set(strings.TrimSpace(k), enabled); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Enabled returns true unless the subsystem was disabled.
+func (s Subsystems) Enabled(subsystem Subsystem) bool {
+	enabled, ok := s[subsystem]
+	return !ok || enabled
+}
+
+// WithDefaults returns a copy, which uses defaults for the subsystems not
+// set in s. It's used to let flags take precedence over the config map.
+func (s Subsystems) WithDefaults(defaults map[string]bool) (Subsystems, error) {
+	result := Subsystems{}
+	for k, v := range defaults {
+		if err := result.set(k, v); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range s {
+		result[k] = v
+	}
+	return result, nil
+}
+
+// String returns the disabled subsystems, sorted by name.
+func (s Subsystems) String() string {
+	var disabled []string
+	for k, v := range s {
+		if !v {
+			disabled = append(disabled, string(k))
+		}
+	}
+	sort.Strings(disabled)
+	return strings.Join(disabled, ",")
+}
+
+func (s Subsystems) set(name string, enabled bool) error {
+	for _, subsystem := range subsystems {
+		if string(subsystem) == name {
+			s[subsystem] = enabled
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown subsystem %q", name)
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"testing"
+)
+
+func TestParseSubsystems(t *testing.T) {
+	subsystems, err := ParseSubsystems("gitops=false, imagescan=0,bundle=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subsystems.Enabled(SubsystemGitOps) || subsystems.Enabled(SubsystemImageScan) {
+		t.Errorf("expected gitops and imagescan to be disabled: %v", subsystems)
+	}
+	if !subsystems.Enabled(SubsystemBundle) || !subsystems.Enabled(SubsystemNotifications) {
+		t.Errorf("expected bundle and notifications to be enabled: %v", subsystems)
+	}
+	if s := subsystems.String(); s != "gitops,imagescan" {
+		t.Errorf("unexpected disabled subsystems %q", s)
+	}
+
+	for _, s := range []string{"drift=false", "gitops", "gitops=no"} {
+		if _, err := ParseSubsystems(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestSubsystemsWithDefaults(t *testing.T) {
+	subsystems := Subsystems{SubsystemGitOps: true}
+	result, err := subsystems.WithDefaults(map[string]bool{"gitops": false, "clustergroups": false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Enabled(SubsystemGitOps) {
+		t.Error("expected flag to take precedence over config map")
+	}
+	if result.Enabled(SubsystemClusterGroups) {
+		t.Error("expected clustergroups to be disabled by config map")
+	}
+
+	if _, err := subsystems.WithDefaults(map[string]bool{"unknown": true}); err == nil {
+		t.Error("expected error for unknown subsystem")
+	}
+}
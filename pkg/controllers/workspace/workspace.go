@@ -0,0 +1,194 @@
+// Package workspace maintains a FleetWorkspaceStatus in each namespace, which summarizes its gitrepos, bundles and clusters. (fleetcontroller)
+package workspace
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/cloudevents"
+	"github.com/rancher/fleet/pkg/durations"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/summary"
+
+	"github.com/rancher/wrangler/pkg/apply"
+	"github.com/rancher/wrangler/pkg/condition"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/genericcondition"
+	"github.com/rancher/wrangler/pkg/relatedresource"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// maxNames is the number of stalled bundles and offline clusters listed
+const maxNames = 100
+
+var ready = condition.Cond("Ready")
+
+type handler struct {
+	apply      apply.Apply
+	namespaces corecontrollers.NamespaceController
+	gitrepos   fleetcontrollers.GitRepoCache
+	bundles    fleetcontrollers.BundleCache
+	clusters   fleetcontrollers.ClusterCache
+}
+
+func Register(ctx context.Context,
+	apply apply.Apply,
+	namespaces corecontrollers.NamespaceController,
+	gitrepos fleetcontrollers.GitRepoController,
+	bundles fleetcontrollers.BundleController,
+	clusters fleetcontrollers.ClusterController,
+) {
+	h := &handler{
+		apply:      apply.WithSetID("fleet-workspace-status"),
+		namespaces: namespaces,
+		gitrepos:   gitrepos.Cache(),
+		bundles:    bundles.Cache(),
+		clusters:   clusters.Cache(),
+	}
+
+	namespaces.OnChange(ctx, "workspace-status", h.OnNamespace)
+	relatedresource.WatchClusterScoped(ctx, "workspace-status-resolver", resolveNamespace, namespaces, gitrepos, bundles, clusters)
+}
+
+func resolveNamespace(namespace, _ string, _ runtime.Object) ([]relatedresource.Key, error) {
+	return []relatedresource.Key{{Name: namespace}}, nil
+}
+
+func (h *handler) OnNamespace(key string, namespace *corev1.Namespace) (*corev1.Namespace, error) {
+	if namespace == nil || namespace.DeletionTimestamp != nil {
+		return namespace, nil
+	}
+
+	gitrepos, err := h.gitrepos.List(namespace.Name, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	bundles, err := h.bundles.List(namespace.Name, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := h.clusters.List(namespace.Name, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []runtime.Object
+	if len(gitrepos) > 0 || len(bundles) > 0 || len(clusters) > 0 {
+		status, recheck := Summarize(gitrepos, bundles, clusters, time.Now())
+		objs = append(objs, fleet.NewFleetWorkspaceStatus(namespace.Name, fleet.FleetWorkspaceStatusName, *status))
+		if recheck > 0 {
+			// bundles become stalled and clusters go offline without a change
+			h.namespaces.EnqueueAfter(namespace.Name, recheck)
+		}
+	}
+
+	return namespace, h.apply.
+		WithOwner(namespace).
+		ApplyObjects(objs...)
+}
+
+// Summarize returns the summary of the resources of a namespace and the
+// duration after which it changes, because a bundle becomes stalled or a
+// cluster goes offline, or zero (pure function)
+func Summarize(gitrepos []*fleet.GitRepo, bundles []*fleet.Bundle, clusters []*fleet.Cluster, now time.Time) (*fleet.FleetWorkspaceStatus, time.Duration) {
+	status := &fleet.FleetWorkspaceStatus{
+		GitRepos: len(gitrepos),
+		Bundles:  len(bundles),
+		Clusters: len(clusters),
+	}
+	var recheck time.Duration
+	next := func(d time.Duration) {
+		if d > 0 && (recheck == 0 || d < recheck) {
+			recheck = d
+		}
+	}
+	activity := func(conds []genericcondition.GenericCondition) {
+		for _, cond := range conds {
+			t, err := time.Parse(time.RFC3339, cond.LastUpdateTime)
+			if err != nil {
+				continue
+			}
+			if status.LastActivity == nil || t.After(status.LastActivity.Time) {
+				status.LastActivity = &metav1.Time{Time: t}
+			}
+		}
+	}
+
+	for _, gitrepo := range gitrepos {
+		if ready.IsTrue(gitrepo) {
+			status.ReadyGitRepos++
+		}
+		activity(gitrepo.Status.Conditions)
+	}
+
+	for _, bundle := range bundles {
+		summary.Increment(&status.Summary, bundle.Status.Summary)
+		activity(bundle.Status.Conditions)
+
+		state := string(summary.GetSummaryState(bundle.Status.Summary))
+		if summary.IsReady(bundle.Status.Summary) {
+			status.ReadyBundles++
+			state = string(fleet.Ready)
+		} else if since, ok := notReadySince(bundle.Status.Conditions); ok {
+			stalled := now.Sub(since)
+			if stalled >= durations.StalledRolloutTimeout {
+				status.StalledBundles = append(status.StalledBundles, bundle.Name)
+			} else {
+				next(durations.StalledRolloutTimeout - stalled + time.Second)
+			}
+		}
+		if state != "" {
+			if status.BundleStates == nil {
+				status.BundleStates = map[string]int{}
+			}
+			status.BundleStates[state]++
+		}
+	}
+
+	threshold := cloudevents.OfflineThreshold()
+	for _, cluster := range clusters {
+		if ready.IsTrue(cluster) {
+			status.ReadyClusters++
+		}
+		lastSeen := cluster.Status.Agent.LastSeen
+		if lastSeen.IsZero() {
+			continue
+		}
+		if offline := now.Sub(lastSeen.Time); offline > threshold {
+			status.OfflineClusters = append(status.OfflineClusters, cluster.Name)
+		} else {
+			next(threshold - offline + time.Second)
+		}
+	}
+
+	summary.LimitErrors(&status.Summary)
+	status.StalledBundles = limit(status.StalledBundles)
+	status.OfflineClusters = limit(status.OfflineClusters)
+	return status, recheck
+}
+
+// notReadySince returns when the ready condition became false
+func notReadySince(conds []genericcondition.GenericCondition) (time.Time, bool) {
+	for _, cond := range conds {
+		if cond.Type != "Ready" || cond.Status != corev1.ConditionFalse {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, cond.LastTransitionTime)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+func limit(names []string) []string {
+	sort.Strings(names)
+	if len(names) > maxNames {
+		return names[:maxNames]
+	}
+	return names
+}
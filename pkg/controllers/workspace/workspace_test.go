@@ -0,0 +1,74 @@
+package workspace
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/config"
+
+	"github.com/rancher/wrangler/pkg/genericcondition"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSummarize(t *testing.T) {
+	if err := config.Set(&config.Config{AgentCheckinInterval: metav1.Duration{Duration: time.Minute}}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	cond := func(age time.Duration) []genericcondition.GenericCondition {
+		ts := now.Add(-age).Format(time.RFC3339)
+		return []genericcondition.GenericCondition{{Type: "Ready", Status: "False", LastUpdateTime: ts, LastTransitionTime: ts}}
+	}
+	bundle := func(name string, summary fleet.BundleSummary, conds []genericcondition.GenericCondition) *fleet.Bundle {
+		return &fleet.Bundle{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     fleet.BundleStatus{Summary: summary, Conditions: conds},
+		}
+	}
+	cluster := func(name string, lastSeen time.Duration) *fleet.Cluster {
+		return &fleet.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     fleet.ClusterStatus{Agent: fleet.AgentStatus{LastSeen: metav1.NewTime(now.Add(-lastSeen))}},
+		}
+	}
+
+	notReady := fleet.BundleSummary{DesiredReady: 1, ErrApplied: 1, NonReadyResources: []fleet.NonReadyResource{{Name: "c", State: fleet.ErrApplied}}}
+	bundles := []*fleet.Bundle{
+		bundle("ready", fleet.BundleSummary{DesiredReady: 1, Ready: 1}, nil),
+		bundle("stalled", notReady, cond(time.Hour)),
+		bundle("failing", notReady, cond(20*time.Minute)),
+	}
+	clusters := []*fleet.Cluster{
+		cluster("online", time.Minute),
+		cluster("offline", time.Hour),
+	}
+	gitrepos := []*fleet.GitRepo{{Status: fleet.GitRepoStatus{Conditions: cond(time.Second)}}}
+
+	status, recheck := Summarize(gitrepos, bundles, clusters, now)
+
+	if status.GitRepos != 1 || status.Bundles != 3 || status.ReadyBundles != 1 || status.Clusters != 2 {
+		t.Errorf("unexpected counts %+v", status)
+	}
+	if !reflect.DeepEqual(status.BundleStates, map[string]int{"Ready": 1, "ErrApplied": 2}) {
+		t.Errorf("unexpected bundle states %v", status.BundleStates)
+	}
+	if !reflect.DeepEqual(status.StalledBundles, []string{"stalled"}) {
+		t.Errorf("expected stalled bundle, got %v", status.StalledBundles)
+	}
+	if !reflect.DeepEqual(status.OfflineClusters, []string{"offline"}) {
+		t.Errorf("expected offline cluster, got %v", status.OfflineClusters)
+	}
+	if status.Summary.ErrApplied != 2 {
+		t.Errorf("expected bundle summaries to add up, got %+v", status.Summary)
+	}
+	if status.LastActivity == nil || !status.LastActivity.Time.Equal(now.Add(-time.Second)) {
+		t.Errorf("unexpected last activity %v", status.LastActivity)
+	}
+	// the online cluster goes offline after 3 missed check-ins
+	if recheck != 2*time.Minute+time.Second {
+		t.Errorf("unexpected recheck %v", recheck)
+	}
+}
@@ -0,0 +1,199 @@
+// Package cost estimates the monthly cost of rendered resources from their resource requests and a price sheet. (fleetcontroller, fleetapply)
+package cost
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const gib = 1024 * 1024 * 1024
+
+// PriceSheet contains the monthly prices of requested resources. Costs are
+// only estimated, if a price is set.
+type PriceSheet struct {
+	Currency string `json:"currency,omitempty"`
+	// CPU is the price of a requested CPU core per month
+	CPU float64 `json:"cpu,omitempty"`
+	// Memory is the price of a requested GiB of memory per month
+	Memory float64 `json:"memory,omitempty"`
+	// Storage is the price of a GiB of persistent volume claims per month
+	Storage float64 `json:"storage,omitempty"`
+}
+
+// Enabled returns true, if the price sheet contains any price.
+func (p *PriceSheet) Enabled() bool {
+	return p != nil && (p.CPU > 0 || p.Memory > 0 || p.Storage > 0)
+}
+
+// Estimate returns the monthly cost of the requests.
+func (p *PriceSheet) Estimate(r Requests) float64 {
+	return r.CPU.AsApproximateFloat64()*p.CPU +
+		r.Memory.AsApproximateFloat64()/gib*p.Memory +
+		r.Storage.AsApproximateFloat64()/gib*p.Storage
+}
+
+// Format formats a cost, as stored in the status of resources.
+func Format(cost float64) string {
+	return strconv.FormatFloat(cost, 'f', 2, 64)
+}
+
+// Parse parses a cost written by Format.
+func Parse(cost string) (float64, error) {
+	if cost == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(cost, 64)
+}
+
+// Requests are the resources requested by a set of resources.
+type Requests struct {
+	CPU     resource.Quantity
+	Memory  resource.Quantity
+	Storage resource.Quantity
+}
+
+// Add adds the requests of o, n times.
+func (r *Requests) Add(o Requests, n int64) {
+	r.CPU.Add(*resource.NewMilliQuantity(o.CPU.MilliValue()*n, resource.DecimalSI))
+	r.Memory.Add(*resource.NewQuantity(o.Memory.Value()*n, resource.BinarySI))
+	r.Storage.Add(*resource.NewQuantity(o.Storage.Value()*n, resource.BinarySI))
+}
+
+// Workload are the requests of rendered resources. DaemonSets request
+// their resources once per node.
+type Workload struct {
+	Requests
+	PerNode Requests
+}
+
+// Total returns the requests of the workload on a cluster with the given
+// number of nodes.
+func (w Workload) Total(nodes int64) Requests {
+	result := Requests{}
+	result.Add(w.Requests, 1)
+	result.Add(w.PerNode, nodes)
+	return result
+}
+
+// ResourceRequests sums up the requests of the pods, workloads and
+// persistent volume claims in objs.
+func ResourceRequests(objs []runtime.Object) (Workload, error) {
+	var result Workload
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if err := add(&result, u); err != nil {
+			return result, fmt.Errorf("%s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+	}
+	return result, nil
+}
+
+func add(w *Workload, obj *unstructured.Unstructured) error {
+	gk := obj.GroupVersionKind().GroupKind()
+	switch {
+	case gk.Group == "" && gk.Kind == "Pod":
+		return addPod(&w.Requests, obj.Object, 1, "spec")
+	case gk.Group == "" && gk.Kind == "PersistentVolumeClaim":
+		return addClaim(&w.Requests, obj.Object, 1, "spec")
+	case gk.Group == "" && gk.Kind == "ReplicationController",
+		gk.Group == "apps" && (gk.Kind == "Deployment" || gk.Kind == "ReplicaSet"):
+		return addPod(&w.Requests, obj.Object, replicas(obj, "spec", "replicas"), "spec", "template", "spec")
+	case gk.Group == "apps" && gk.Kind == "StatefulSet":
+		n := replicas(obj, "spec", "replicas")
+		if err := addPod(&w.Requests, obj.Object, n, "spec", "template", "spec"); err != nil {
+			return err
+		}
+		templates, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates")
+		for _, t := range templates {
+			if template, ok := t.(map[string]interface{}); ok {
+				if err := addClaim(&w.Requests, template, n, "spec"); err != nil {
+					return err
+				}
+			}
+		}
+	case gk.Group == "apps" && gk.Kind == "DaemonSet":
+		return addPod(&w.PerNode, obj.Object, 1, "spec", "template", "spec")
+	case gk.Group == "batch" && gk.Kind == "Job":
+		return addPod(&w.Requests, obj.Object, replicas(obj, "spec", "parallelism"), "spec", "template", "spec")
+	case gk.Group == "batch" && gk.Kind == "CronJob":
+		return addPod(&w.Requests, obj.Object, replicas(obj, "spec", "jobTemplate", "spec", "parallelism"), "spec", "jobTemplate", "spec", "template", "spec")
+	}
+	return nil
+}
+
+// replicas returns the number of pods, which defaults to one
+func replicas(obj *unstructured.Unstructured, fields ...string) int64 {
+	n, ok, _ := unstructured.NestedFieldNoCopy(obj.Object, fields...)
+	if !ok {
+		return 1
+	}
+	switch n := n.(type) {
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	}
+	return 1
+}
+
+// addPod adds the requests of the pod spec at fields. Init containers run
+// before the other containers, so only their maximum counts.
+func addPod(r *Requests, obj map[string]interface{}, n int64, fields ...string) error {
+	data, ok, _ := unstructured.NestedMap(obj, fields...)
+	if !ok || n <= 0 {
+		return nil
+	}
+	spec := corev1.PodSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(data, &spec); err != nil {
+		return err
+	}
+
+	pod := Requests{}
+	for _, c := range spec.Containers {
+		pod.CPU.Add(request(c.Resources, corev1.ResourceCPU))
+		pod.Memory.Add(request(c.Resources, corev1.ResourceMemory))
+	}
+	for _, c := range spec.InitContainers {
+		if cpu := request(c.Resources, corev1.ResourceCPU); cpu.Cmp(pod.CPU) > 0 {
+			pod.CPU = cpu
+		}
+		if memory := request(c.Resources, corev1.ResourceMemory); memory.Cmp(pod.Memory) > 0 {
+			pod.Memory = memory
+		}
+	}
+
+	r.Add(pod, n)
+	return nil
+}
+
+// request returns the request of the resource, which defaults to its limit
+func request(resources corev1.ResourceRequirements, name corev1.ResourceName) resource.Quantity {
+	if q, ok := resources.Requests[name]; ok {
+		return q.DeepCopy()
+	}
+	if q, ok := resources.Limits[name]; ok {
+		return q.DeepCopy()
+	}
+	return resource.Quantity{}
+}
+
+func addClaim(r *Requests, obj map[string]interface{}, n int64, fields ...string) error {
+	v, ok, _ := unstructured.NestedFieldNoCopy(obj, append(fields, "resources", "requests", "storage")...)
+	if !ok || v == nil {
+		return nil
+	}
+	q, err := resource.ParseQuantity(fmt.Sprint(v))
+	if err != nil {
+		return err
+	}
+	r.Add(Requests{Storage: q}, n)
+	return nil
+}
@@ -0,0 +1,113 @@
+package cost
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const manifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        resources:
+          requests:
+            cpu: "1"
+      containers:
+      - name: web
+        resources:
+          requests:
+            cpu: 250m
+            memory: 256Mi
+      - name: sidecar
+        resources:
+          limits:
+            cpu: 50m
+            memory: 64Mi
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: db
+        resources:
+          requests:
+            memory: 1Gi
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+    spec:
+      resources:
+        requests:
+          storage: 10Gi
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    spec:
+      containers:
+      - name: agent
+        resources:
+          requests:
+            cpu: 100m
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`
+
+func decode(t *testing.T, docs string) []runtime.Object {
+	var result []runtime.Object
+	for _, doc := range strings.Split(docs, "\n---\n") {
+		u := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(doc), &u.Object); err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, u)
+	}
+	return result
+}
+
+func TestResourceRequests(t *testing.T) {
+	workload, err := ResourceRequests(decode(t, manifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := workload.Total(2)
+	// init container cpu exceeds the containers' sum: 2 x 1 + 2 x 100m
+	if requests.CPU.String() != "2200m" {
+		t.Errorf("unexpected cpu %s", requests.CPU.String())
+	}
+	// 2 x 320Mi + 3 x 1Gi
+	if requests.Memory.String() != "3712Mi" {
+		t.Errorf("unexpected memory %s", requests.Memory.String())
+	}
+	if requests.Storage.String() != "30Gi" {
+		t.Errorf("unexpected storage %s", requests.Storage.String())
+	}
+
+	prices := &PriceSheet{CPU: 10, Memory: 2, Storage: 0.5}
+	if got := Format(prices.Estimate(requests)); got != "44.25" {
+		t.Errorf("unexpected cost %s", got)
+	}
+}
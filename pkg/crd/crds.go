@@ -86,11 +86,28 @@ func list() []crd.CRD {
 				WithSchema(schema).
 				WithColumn("Deployed", ".status.display.deployed").
 				WithColumn("Monitored", ".status.display.monitored").
+				WithColumn("Last-Deployed", ".status.history[0].deployedAt").
+				WithColumn("Result", ".status.history[0].result").
 				WithColumn("Status", ".status.conditions[?(@.type==\"Ready\")].message")
 		}),
 		newCRD(&fleet.BundleNamespaceMapping{}, func(c crd.CRD) crd.CRD {
 			return c
 		}),
+		newCRD(&fleet.BundleRevision{}, func(c crd.CRD) crd.CRD {
+			c.Status = false
+			return c.
+				WithColumn("Bundle", ".bundleName").
+				WithColumn("Generation", ".bundleGeneration")
+		}),
+		newCRD(&fleet.FleetWorkspaceStatus{}, func(c crd.CRD) crd.CRD {
+			c.Status = false
+			return c.
+				WithColumn("Bundles", ".bundles").
+				WithColumn("Bundles-Ready", ".readyBundles").
+				WithColumn("Clusters", ".clusters").
+				WithColumn("Clusters-Ready", ".readyClusters").
+				WithColumn("Last-Activity", ".lastActivity")
+		}),
 		newCRD(&fleet.ClusterGroup{}, func(c crd.CRD) crd.CRD {
 			return c.
 				WithCategories("fleet").
@@ -141,6 +158,13 @@ func list() []crd.CRD {
 				WithSchema(schema).
 				WithColumn("Secret-Name", ".status.secretName")
 		}),
+		newCRD(&fleet.ClusterImport{}, func(c crd.CRD) crd.CRD {
+			return c.
+				WithColumn("Secret", ".spec.secretName").
+				WithColumn("Format", ".spec.format").
+				WithColumn("Cluster-Name", ".status.clusterName").
+				WithColumn("Status", ".status.conditions[?(@.type==\"Imported\")].message")
+		}),
 		newCRD(&fleet.GitRepo{}, func(c crd.CRD) crd.CRD {
 			return c.
 				WithCategories("fleet").
@@ -159,6 +183,12 @@ func list() []crd.CRD {
 				WithColumn("Default-ServiceAccount", ".defaultServiceAccount").
 				WithColumn("Allowed-ServiceAccounts", ".allowedServiceAccounts")
 		}),
+		newCRD(&fleet.GitRepoDefaults{}, func(c crd.CRD) crd.CRD {
+			c.PluralName = "gitrepodefaults"
+			return c.
+				WithColumn("Client-Secret", ".spec.clientSecretName").
+				WithColumn("Polling-Interval", ".spec.pollingInterval")
+		}),
 		newCRD(&fleet.Content{}, func(c crd.CRD) crd.CRD {
 			c.NonNamespace = true
 			c.Status = false
@@ -5,26 +5,48 @@ import "time"
 const (
 	AgentRegistrationRetry         = time.Minute * 1
 	AgentSecretTimeout             = time.Minute * 1
+	AgentMigrationCheckInterval    = time.Minute * 1
 	DefaultClusterEnqueueDelay     = time.Second * 15
 	ClusterImportTokenTTL          = time.Hour * 12
 	ClusterRegisterDelay           = time.Second * 15
 	ClusterRegistrationDeleteDelay = time.Minute * 40
 	ClusterSecretRetry             = time.Second * 2
+	ClusterUpgradeCheckInterval    = time.Second * 30
+	ClusterUpgradeStabilization    = time.Minute * 5
 	ContentPurgeInterval           = time.Minute * 5
 	CreateClusterSecretTimeout     = time.Minute * 30
 	DefaultClusterCheckInterval    = time.Minute * 15
 	DefaultImageInterval           = time.Minute * 15
+	DefaultChartVersionInterval    = time.Minute * 15
+	DefaultGitPollingInterval      = time.Second * 15
+	ImageSyncTimeout               = time.Minute * 10
 	DefaultResyncAgent             = time.Minute * 30
 	FailureRateLimiterBase         = time.Millisecond * 5
 	FailureRateLimiterMax          = time.Second * 60
 	SlowFailureRateLimiterBase     = time.Second * 2
 	SlowFailureRateLimiterMax      = time.Minute * 10 // hit after 10 failures in a row
 	GarbageCollect                 = time.Minute * 15
+	GitCloneCredentialsRenew       = time.Minute * 5
+	GitRepoTeardownRecheck         = time.Second * 10
+	ImmutableReplaceRetryBase      = time.Second * 30
+	ImmutableReplaceRetryMax       = time.Minute * 30
+	DefaultBundleRevisionRetention = time.Hour * 168
+	DefaultAutoRollbackTimeout     = time.Minute * 10
+	LocalBundleDirPollInterval     = time.Second * 2
+	WebhookCheckInterval           = time.Minute * 15
+	WebhookRejectionRetryBase      = time.Second * 10
+	WebhookRejectionRetryMax       = time.Minute * 10
+	WorkspaceDeferDelay            = time.Second * 1
+	WorkspaceDeferExpiry           = time.Minute * 1
 	MonitorBundleDelay             = time.Minute * 5
+	ManualInterventionRecheck      = time.Minute * 1
+	PostDeleteHooksTimeout         = time.Minute * 10
 	RestConfigTimeout              = time.Second * 15
 	ServiceTokenSleep              = time.Second * 2
+	StalledRolloutTimeout          = time.Minute * 30
 	TokenClusterEnqueueDelay       = time.Second * 2
 	TriggerSleep                   = time.Second * 2
 	DefaultCpuPprofPeriod          = time.Minute
 	ReleaseCacheTTL                = time.Minute * 5
+	ChartCacheTTL                  = time.Minute * 5
 )
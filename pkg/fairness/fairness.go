@@ -0,0 +1,145 @@
+// Package fairness shares the workers of a controller between workspaces, so a workspace with thousands of objects can't starve the reconciliation of the others. (fleetcontroller)
+//
+// The workqueues of the controllers are shared by all namespaces and can't
+// be replaced. Instead, handlers ask the scheduler to admit an object before
+// reconciling it. While several workspaces are busy, each may use a share of
+// the workers proportional to its weight. Objects of a workspace, which
+// already uses its share, are deferred and enqueued again, so the workers
+// reach the objects of other workspaces queued behind them.
+package fairness
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rancher/fleet/pkg/durations"
+	"github.com/rancher/fleet/pkg/metrics"
+)
+
+// Scheduler admits objects of workspaces to the workers of a controller
+type Scheduler struct {
+	name    string
+	workers int
+	weight  func(namespace string) int
+	now     func() time.Time
+
+	lock       sync.Mutex
+	lastExpiry time.Time
+	active     map[string]int
+	// deferred keys per namespace and when they were deferred, keys which
+	// are not seen again, e.g. because the object was deleted, expire
+	deferred map[string]map[string]time.Time
+}
+
+// New returns a scheduler for the controller's workers. Weight returns the
+// weight of a namespace, values below 1 are treated as 1.
+func New(name string, workers int, weight func(namespace string) int) *Scheduler {
+	return &Scheduler{
+		name:     name,
+		workers:  workers,
+		weight:   weight,
+		now:      time.Now,
+		active:   map[string]int{},
+		deferred: map[string]map[string]time.Time{},
+	}
+}
+
+// Admit returns true, if the object may be reconciled now. The returned
+// func has to be called once the reconcile is done. If the object is not
+// admitted, it has to be enqueued again after
+// durations.WorkspaceDeferDelay.
+func (s *Scheduler) Admit(namespace, name string) (func(), bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	s.expire(now)
+	if s.active[namespace] >= s.share(namespace) {
+		if s.deferred[namespace] == nil {
+			s.deferred[namespace] = map[string]time.Time{}
+		}
+		s.deferred[namespace][name] = now
+		s.report(namespace)
+		return nil, false
+	}
+
+	delete(s.deferred[namespace], name)
+	s.active[namespace]++
+	s.report(namespace)
+
+	return func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		s.active[namespace]--
+		if s.active[namespace] <= 0 {
+			delete(s.active, namespace)
+		}
+		metrics.ObserveWorkspaceReconcile(s.name, namespace, s.now().Sub(now))
+		s.report(namespace)
+	}, true
+}
+
+// share returns how many workers the namespace may use, proportional to its
+// weight among the busy namespaces, but at least one
+func (s *Scheduler) share(namespace string) int {
+	total := s.weightOf(namespace)
+	for ns := range s.busy() {
+		if ns != namespace {
+			total += s.weightOf(ns)
+		}
+	}
+	share := s.workers * s.weightOf(namespace) / total
+	if share < 1 {
+		return 1
+	}
+	return share
+}
+
+// busy returns the namespaces with reconciles in progress or deferred
+// objects
+func (s *Scheduler) busy() map[string]bool {
+	result := map[string]bool{}
+	for ns := range s.active {
+		result[ns] = true
+	}
+	for ns, keys := range s.deferred {
+		if len(keys) > 0 {
+			result[ns] = true
+		}
+	}
+	return result
+}
+
+func (s *Scheduler) weightOf(namespace string) int {
+	if s.weight == nil {
+		return 1
+	}
+	if w := s.weight(namespace); w > 0 {
+		return w
+	}
+	return 1
+}
+
+func (s *Scheduler) expire(now time.Time) {
+	if now.Sub(s.lastExpiry) < durations.WorkspaceDeferDelay {
+		return
+	}
+	s.lastExpiry = now
+
+	for ns, keys := range s.deferred {
+		for key, t := range keys {
+			if now.Sub(t) > durations.WorkspaceDeferExpiry {
+				delete(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			delete(s.deferred, ns)
+			s.report(ns)
+		}
+	}
+}
+
+func (s *Scheduler) report(namespace string) {
+	metrics.SetWorkspaceQueue(s.name, namespace, len(s.deferred[namespace]), s.active[namespace])
+}
@@ -0,0 +1,69 @@
+package fairness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rancher/fleet/pkg/durations"
+)
+
+func TestAdmit(t *testing.T) {
+	weights := map[string]int{"heavy": 1, "light": 3}
+	s := New("test", 4, func(ns string) int { return weights[ns] })
+
+	// a single busy workspace may use all workers
+	var done []func()
+	for i := 0; i < 4; i++ {
+		d, ok := s.Admit("heavy", "b")
+		if !ok {
+			t.Fatalf("expected heavy to be admitted while alone, %d", i)
+		}
+		done = append(done, d)
+	}
+	for _, d := range done[:3] {
+		d()
+	}
+
+	// the other workspace is admitted, heavy is limited to its share
+	if _, ok := s.Admit("light", "a"); !ok {
+		t.Fatal("expected light to be admitted")
+	}
+	if _, ok := s.Admit("heavy", "c"); ok {
+		t.Error("expected heavy to be deferred, it uses its share of 1")
+	}
+	if n := len(s.deferred["heavy"]); n != 1 {
+		t.Errorf("expected one deferred key, got %d", n)
+	}
+	if _, ok := s.Admit("light", "b"); !ok {
+		t.Error("expected light to be admitted up to its share of 3")
+	}
+
+	// once heavy's reconcile is done, the deferred key is admitted
+	done[3]()
+	if _, ok := s.Admit("heavy", "c"); !ok {
+		t.Error("expected heavy to be admitted again")
+	}
+	if n := len(s.deferred["heavy"]); n != 0 {
+		t.Errorf("expected deferred key to be removed, got %d", n)
+	}
+}
+
+func TestDeferredExpiry(t *testing.T) {
+	now := time.Now()
+	s := New("test", 1, nil)
+	s.now = func() time.Time { return now }
+
+	done, _ := s.Admit("a", "1")
+	if _, ok := s.Admit("a", "2"); ok {
+		t.Fatal("expected to be deferred")
+	}
+	done()
+
+	now = now.Add(durations.WorkspaceDeferExpiry + durations.WorkspaceDeferDelay)
+	if _, ok := s.Admit("b", "1"); !ok {
+		t.Fatal("expected to be admitted")
+	}
+	if len(s.deferred) != 0 {
+		t.Errorf("expected deferred keys of deleted objects to expire, got %v", s.deferred)
+	}
+}
@@ -11,8 +11,19 @@ import (
 const (
 	fleetYaml         = "fleet.yaml"
 	fallbackFleetYaml = "fleet.yml"
+
+	// APIVersionV1alpha1 is the original fleet.yaml schema. It's assumed if
+	// fleet.yaml has no apiVersion.
+	APIVersionV1alpha1 = "fleet.cattle.io/v1alpha1"
+	// APIVersionV1beta1 renames overrideTargets to targets and only reads
+	// customizations from targetCustomizations.
+	APIVersionV1beta1 = "fleet.cattle.io/v1beta1"
 )
 
+// SupportedAPIVersions lists the fleet.yaml schema versions this version of
+// fleet can read.
+var SupportedAPIVersions = []string{APIVersionV1alpha1, APIVersionV1beta1}
+
 func FoundFleetYamlInDirectory(baseDir string) bool {
 	if _, err := os.Stat(GetFleetYamlPath(baseDir, false)); err != nil {
 		if _, err := os.Stat(GetFleetYamlPath(baseDir, true)); err != nil {
@@ -0,0 +1,256 @@
+/*
+Copyright (c) 2020 - 2023 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/rancher/wrangler/pkg/generic"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type BundleRevisionHandler func(string, *v1alpha1.BundleRevision) (*v1alpha1.BundleRevision, error)
+
+type BundleRevisionController interface {
+	generic.ControllerMeta
+	BundleRevisionClient
+
+	OnChange(ctx context.Context, name string, sync BundleRevisionHandler)
+	OnRemove(ctx context.Context, name string, sync BundleRevisionHandler)
+	Enqueue(namespace, name string)
+	EnqueueAfter(namespace, name string, duration time.Duration)
+
+	Cache() BundleRevisionCache
+}
+
+type BundleRevisionClient interface {
+	Create(*v1alpha1.BundleRevision) (*v1alpha1.BundleRevision, error)
+	Update(*v1alpha1.BundleRevision) (*v1alpha1.BundleRevision, error)
+
+	Delete(namespace, name string, options *metav1.DeleteOptions) error
+	Get(namespace, name string, options metav1.GetOptions) (*v1alpha1.BundleRevision, error)
+	List(namespace string, opts metav1.ListOptions) (*v1alpha1.BundleRevisionList, error)
+	Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.BundleRevision, err error)
+}
+
+type BundleRevisionCache interface {
+	Get(namespace, name string) (*v1alpha1.BundleRevision, error)
+	List(namespace string, selector labels.Selector) ([]*v1alpha1.BundleRevision, error)
+
+	AddIndexer(indexName string, indexer BundleRevisionIndexer)
+	GetByIndex(indexName, key string) ([]*v1alpha1.BundleRevision, error)
+}
+
+type BundleRevisionIndexer func(obj *v1alpha1.BundleRevision) ([]string, error)
+
+type bundleRevisionController struct {
+	controller    controller.SharedController
+	client        *client.Client
+	gvk           schema.GroupVersionKind
+	groupResource schema.GroupResource
+}
+
+func NewBundleRevisionController(gvk schema.GroupVersionKind, resource string, namespaced bool, controller controller.SharedControllerFactory) BundleRevisionController {
+	c := controller.ForResourceKind(gvk.GroupVersion().WithResource(resource), gvk.Kind, namespaced)
+	return &bundleRevisionController{
+		controller: c,
+		client:     c.Client(),
+		gvk:        gvk,
+		groupResource: schema.GroupResource{
+			Group:    gvk.Group,
+			Resource: resource,
+		},
+	}
+}
+
+func FromBundleRevisionHandlerToHandler(sync BundleRevisionHandler) generic.Handler {
+	return func(key string, obj runtime.Object) (ret runtime.Object, err error) {
+		var v *v1alpha1.BundleRevision
+		if obj == nil {
+			v, err = sync(key, nil)
+		} else {
+			v, err = sync(key, obj.(*v1alpha1.BundleRevision))
+		}
+		if v == nil {
+			return nil, err
+		}
+		return v, err
+	}
+}
+
+func (c *bundleRevisionController) Updater() generic.Updater {
+	return func(obj runtime.Object) (runtime.Object, error) {
+		newObj, err := c.Update(obj.(*v1alpha1.BundleRevision))
+		if newObj == nil {
+			return nil, err
+		}
+		return newObj, err
+	}
+}
+
+func UpdateBundleRevisionDeepCopyOnChange(client BundleRevisionClient, obj *v1alpha1.BundleRevision, handler func(obj *v1alpha1.BundleRevision) (*v1alpha1.BundleRevision, error)) (*v1alpha1.BundleRevision, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	copyObj := obj.DeepCopy()
+	newObj, err := handler(copyObj)
+	if newObj != nil {
+		copyObj = newObj
+	}
+	if obj.ResourceVersion == copyObj.ResourceVersion && !equality.Semantic.DeepEqual(obj, copyObj) {
+		return client.Update(copyObj)
+	}
+
+	return copyObj, err
+}
+
+func (c *bundleRevisionController) AddGenericHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.controller.RegisterHandler(ctx, name, controller.SharedControllerHandlerFunc(handler))
+}
+
+func (c *bundleRevisionController) AddGenericRemoveHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), handler))
+}
+
+func (c *bundleRevisionController) OnChange(ctx context.Context, name string, sync BundleRevisionHandler) {
+	c.AddGenericHandler(ctx, name, FromBundleRevisionHandlerToHandler(sync))
+}
+
+func (c *bundleRevisionController) OnRemove(ctx context.Context, name string, sync BundleRevisionHandler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), FromBundleRevisionHandlerToHandler(sync)))
+}
+
+func (c *bundleRevisionController) Enqueue(namespace, name string) {
+	c.controller.Enqueue(namespace, name)
+}
+
+func (c *bundleRevisionController) EnqueueAfter(namespace, name string, duration time.Duration) {
+	c.controller.EnqueueAfter(namespace, name, duration)
+}
+
+func (c *bundleRevisionController) Informer() cache.SharedIndexInformer {
+	return c.controller.Informer()
+}
+
+func (c *bundleRevisionController) GroupVersionKind() schema.GroupVersionKind {
+	return c.gvk
+}
+
+func (c *bundleRevisionController) Cache() BundleRevisionCache {
+	return &bundleRevisionCache{
+		indexer:  c.Informer().GetIndexer(),
+		resource: c.groupResource,
+	}
+}
+
+func (c *bundleRevisionController) Create(obj *v1alpha1.BundleRevision) (*v1alpha1.BundleRevision, error) {
+	result := &v1alpha1.BundleRevision{}
+	return result, c.client.Create(context.TODO(), obj.Namespace, obj, result, metav1.CreateOptions{})
+}
+
+func (c *bundleRevisionController) Update(obj *v1alpha1.BundleRevision) (*v1alpha1.BundleRevision, error) {
+	result := &v1alpha1.BundleRevision{}
+	return result, c.client.Update(context.TODO(), obj.Namespace, obj, result, metav1.UpdateOptions{})
+}
+
+func (c *bundleRevisionController) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	}
+	return c.client.Delete(context.TODO(), namespace, name, *options)
+}
+
+func (c *bundleRevisionController) Get(namespace, name string, options metav1.GetOptions) (*v1alpha1.BundleRevision, error) {
+	result := &v1alpha1.BundleRevision{}
+	return result, c.client.Get(context.TODO(), namespace, name, result, options)
+}
+
+func (c *bundleRevisionController) List(namespace string, opts metav1.ListOptions) (*v1alpha1.BundleRevisionList, error) {
+	result := &v1alpha1.BundleRevisionList{}
+	return result, c.client.List(context.TODO(), namespace, result, opts)
+}
+
+func (c *bundleRevisionController) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.client.Watch(context.TODO(), namespace, opts)
+}
+
+func (c *bundleRevisionController) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*v1alpha1.BundleRevision, error) {
+	result := &v1alpha1.BundleRevision{}
+	return result, c.client.Patch(context.TODO(), namespace, name, pt, data, result, metav1.PatchOptions{}, subresources...)
+}
+
+type bundleRevisionCache struct {
+	indexer  cache.Indexer
+	resource schema.GroupResource
+}
+
+func (c *bundleRevisionCache) Get(namespace, name string) (*v1alpha1.BundleRevision, error) {
+	obj, exists, err := c.indexer.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(c.resource, name)
+	}
+	return obj.(*v1alpha1.BundleRevision), nil
+}
+
+func (c *bundleRevisionCache) List(namespace string, selector labels.Selector) (ret []*v1alpha1.BundleRevision, err error) {
+
+	err = cache.ListAllByNamespace(c.indexer, namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.BundleRevision))
+	})
+
+	return ret, err
+}
+
+func (c *bundleRevisionCache) AddIndexer(indexName string, indexer BundleRevisionIndexer) {
+	utilruntime.Must(c.indexer.AddIndexers(map[string]cache.IndexFunc{
+		indexName: func(obj interface{}) (strings []string, e error) {
+			return indexer(obj.(*v1alpha1.BundleRevision))
+		},
+	}))
+}
+
+func (c *bundleRevisionCache) GetByIndex(indexName, key string) (result []*v1alpha1.BundleRevision, err error) {
+	objs, err := c.indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	result = make([]*v1alpha1.BundleRevision, 0, len(objs))
+	for _, obj := range objs {
+		result = append(result, obj.(*v1alpha1.BundleRevision))
+	}
+	return result, nil
+}
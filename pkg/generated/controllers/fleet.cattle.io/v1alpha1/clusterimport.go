@@ -0,0 +1,376 @@
+/*
+Copyright (c) 2020 - 2023 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/rancher/wrangler/pkg/apply"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/generic"
+	"github.com/rancher/wrangler/pkg/kv"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type ClusterImportHandler func(string, *v1alpha1.ClusterImport) (*v1alpha1.ClusterImport, error)
+
+type ClusterImportController interface {
+	generic.ControllerMeta
+	ClusterImportClient
+
+	OnChange(ctx context.Context, name string, sync ClusterImportHandler)
+	OnRemove(ctx context.Context, name string, sync ClusterImportHandler)
+	Enqueue(namespace, name string)
+	EnqueueAfter(namespace, name string, duration time.Duration)
+
+	Cache() ClusterImportCache
+}
+
+type ClusterImportClient interface {
+	Create(*v1alpha1.ClusterImport) (*v1alpha1.ClusterImport, error)
+	Update(*v1alpha1.ClusterImport) (*v1alpha1.ClusterImport, error)
+	UpdateStatus(*v1alpha1.ClusterImport) (*v1alpha1.ClusterImport, error)
+	Delete(namespace, name string, options *metav1.DeleteOptions) error
+	Get(namespace, name string, options metav1.GetOptions) (*v1alpha1.ClusterImport, error)
+	List(namespace string, opts metav1.ListOptions) (*v1alpha1.ClusterImportList, error)
+	Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.ClusterImport, err error)
+}
+
+type ClusterImportCache interface {
+	Get(namespace, name string) (*v1alpha1.ClusterImport, error)
+	List(namespace string, selector labels.Selector) ([]*v1alpha1.ClusterImport, error)
+
+	AddIndexer(indexName string, indexer ClusterImportIndexer)
+	GetByIndex(indexName, key string) ([]*v1alpha1.ClusterImport, error)
+}
+
+type ClusterImportIndexer func(obj *v1alpha1.ClusterImport) ([]string, error)
+
+type clusterImportController struct {
+	controller    controller.SharedController
+	client        *client.Client
+	gvk           schema.GroupVersionKind
+	groupResource schema.GroupResource
+}
+
+func NewClusterImportController(gvk schema.GroupVersionKind, resource string, namespaced bool, controller controller.SharedControllerFactory) ClusterImportController {
+	c := controller.ForResourceKind(gvk.GroupVersion().WithResource(resource), gvk.Kind, namespaced)
+	return &clusterImportController{
+		controller: c,
+		client:     c.Client(),
+		gvk:        gvk,
+		groupResource: schema.GroupResource{
+			Group:    gvk.Group,
+			Resource: resource,
+		},
+	}
+}
+
+func FromClusterImportHandlerToHandler(sync ClusterImportHandler) generic.Handler {
+	return func(key string, obj runtime.Object) (ret runtime.Object, err error) {
+		var v *v1alpha1.ClusterImport
+		if obj == nil {
+			v, err = sync(key, nil)
+		} else {
+			v, err = sync(key, obj.(*v1alpha1.ClusterImport))
+		}
+		if v == nil {
+			return nil, err
+		}
+		return v, err
+	}
+}
+
+func (c *clusterImportController) Updater() generic.Updater {
+	return func(obj runtime.Object) (runtime.Object, error) {
+		newObj, err := c.Update(obj.(*v1alpha1.ClusterImport))
+		if newObj == nil {
+			return nil, err
+		}
+		return newObj, err
+	}
+}
+
+func UpdateClusterImportDeepCopyOnChange(client ClusterImportClient, obj *v1alpha1.ClusterImport, handler func(obj *v1alpha1.ClusterImport) (*v1alpha1.ClusterImport, error)) (*v1alpha1.ClusterImport, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	copyObj := obj.DeepCopy()
+	newObj, err := handler(copyObj)
+	if newObj != nil {
+		copyObj = newObj
+	}
+	if obj.ResourceVersion == copyObj.ResourceVersion && !equality.Semantic.DeepEqual(obj, copyObj) {
+		return client.Update(copyObj)
+	}
+
+	return copyObj, err
+}
+
+func (c *clusterImportController) AddGenericHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.controller.RegisterHandler(ctx, name, controller.SharedControllerHandlerFunc(handler))
+}
+
+func (c *clusterImportController) AddGenericRemoveHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), handler))
+}
+
+func (c *clusterImportController) OnChange(ctx context.Context, name string, sync ClusterImportHandler) {
+	c.AddGenericHandler(ctx, name, FromClusterImportHandlerToHandler(sync))
+}
+
+func (c *clusterImportController) OnRemove(ctx context.Context, name string, sync ClusterImportHandler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), FromClusterImportHandlerToHandler(sync)))
+}
+
+func (c *clusterImportController) Enqueue(namespace, name string) {
+	c.controller.Enqueue(namespace, name)
+}
+
+func (c *clusterImportController) EnqueueAfter(namespace, name string, duration time.Duration) {
+	c.controller.EnqueueAfter(namespace, name, duration)
+}
+
+func (c *clusterImportController) Informer() cache.SharedIndexInformer {
+	return c.controller.Informer()
+}
+
+func (c *clusterImportController) GroupVersionKind() schema.GroupVersionKind {
+	return c.gvk
+}
+
+func (c *clusterImportController) Cache() ClusterImportCache {
+	return &clusterImportCache{
+		indexer:  c.Informer().GetIndexer(),
+		resource: c.groupResource,
+	}
+}
+
+func (c *clusterImportController) Create(obj *v1alpha1.ClusterImport) (*v1alpha1.ClusterImport, error) {
+	result := &v1alpha1.ClusterImport{}
+	return result, c.client.Create(context.TODO(), obj.Namespace, obj, result, metav1.CreateOptions{})
+}
+
+func (c *clusterImportController) Update(obj *v1alpha1.ClusterImport) (*v1alpha1.ClusterImport, error) {
+	result := &v1alpha1.ClusterImport{}
+	return result, c.client.Update(context.TODO(), obj.Namespace, obj, result, metav1.UpdateOptions{})
+}
+
+func (c *clusterImportController) UpdateStatus(obj *v1alpha1.ClusterImport) (*v1alpha1.ClusterImport, error) {
+	result := &v1alpha1.ClusterImport{}
+	return result, c.client.UpdateStatus(context.TODO(), obj.Namespace, obj, result, metav1.UpdateOptions{})
+}
+
+func (c *clusterImportController) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	}
+	return c.client.Delete(context.TODO(), namespace, name, *options)
+}
+
+func (c *clusterImportController) Get(namespace, name string, options metav1.GetOptions) (*v1alpha1.ClusterImport, error) {
+	result := &v1alpha1.ClusterImport{}
+	return result, c.client.Get(context.TODO(), namespace, name, result, options)
+}
+
+func (c *clusterImportController) List(namespace string, opts metav1.ListOptions) (*v1alpha1.ClusterImportList, error) {
+	result := &v1alpha1.ClusterImportList{}
+	return result, c.client.List(context.TODO(), namespace, result, opts)
+}
+
+func (c *clusterImportController) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.client.Watch(context.TODO(), namespace, opts)
+}
+
+func (c *clusterImportController) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*v1alpha1.ClusterImport, error) {
+	result := &v1alpha1.ClusterImport{}
+	return result, c.client.Patch(context.TODO(), namespace, name, pt, data, result, metav1.PatchOptions{}, subresources...)
+}
+
+type clusterImportCache struct {
+	indexer  cache.Indexer
+	resource schema.GroupResource
+}
+
+func (c *clusterImportCache) Get(namespace, name string) (*v1alpha1.ClusterImport, error) {
+	obj, exists, err := c.indexer.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(c.resource, name)
+	}
+	return obj.(*v1alpha1.ClusterImport), nil
+}
+
+func (c *clusterImportCache) List(namespace string, selector labels.Selector) (ret []*v1alpha1.ClusterImport, err error) {
+
+	err = cache.ListAllByNamespace(c.indexer, namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ClusterImport))
+	})
+
+	return ret, err
+}
+
+func (c *clusterImportCache) AddIndexer(indexName string, indexer ClusterImportIndexer) {
+	utilruntime.Must(c.indexer.AddIndexers(map[string]cache.IndexFunc{
+		indexName: func(obj interface{}) (strings []string, e error) {
+			return indexer(obj.(*v1alpha1.ClusterImport))
+		},
+	}))
+}
+
+func (c *clusterImportCache) GetByIndex(indexName, key string) (result []*v1alpha1.ClusterImport, err error) {
+	objs, err := c.indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	result = make([]*v1alpha1.ClusterImport, 0, len(objs))
+	for _, obj := range objs {
+		result = append(result, obj.(*v1alpha1.ClusterImport))
+	}
+	return result, nil
+}
+
+type ClusterImportStatusHandler func(obj *v1alpha1.ClusterImport, status v1alpha1.ClusterImportStatus) (v1alpha1.ClusterImportStatus, error)
+
+type ClusterImportGeneratingHandler func(obj *v1alpha1.ClusterImport, status v1alpha1.ClusterImportStatus) ([]runtime.Object, v1alpha1.ClusterImportStatus, error)
+
+func RegisterClusterImportStatusHandler(ctx context.Context, controller ClusterImportController, condition condition.Cond, name string, handler ClusterImportStatusHandler) {
+	statusHandler := &clusterImportStatusHandler{
+		client:    controller,
+		condition: condition,
+		handler:   handler,
+	}
+	controller.AddGenericHandler(ctx, name, FromClusterImportHandlerToHandler(statusHandler.sync))
+}
+
+func RegisterClusterImportGeneratingHandler(ctx context.Context, controller ClusterImportController, apply apply.Apply,
+	condition condition.Cond, name string, handler ClusterImportGeneratingHandler, opts *generic.GeneratingHandlerOptions) {
+	statusHandler := &clusterImportGeneratingHandler{
+		ClusterImportGeneratingHandler: handler,
+		apply:                          apply,
+		name:                           name,
+		gvk:                            controller.GroupVersionKind(),
+	}
+	if opts != nil {
+		statusHandler.opts = *opts
+	}
+	controller.OnChange(ctx, name, statusHandler.Remove)
+	RegisterClusterImportStatusHandler(ctx, controller, condition, name, statusHandler.Handle)
+}
+
+type clusterImportStatusHandler struct {
+	client    ClusterImportClient
+	condition condition.Cond
+	handler   ClusterImportStatusHandler
+}
+
+func (a *clusterImportStatusHandler) sync(key string, obj *v1alpha1.ClusterImport) (*v1alpha1.ClusterImport, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	origStatus := obj.Status.DeepCopy()
+	obj = obj.DeepCopy()
+	newStatus, err := a.handler(obj, obj.Status)
+	if err != nil {
+		// Revert to old status on error
+		newStatus = *origStatus.DeepCopy()
+	}
+
+	if a.condition != "" {
+		if errors.IsConflict(err) {
+			a.condition.SetError(&newStatus, "", nil)
+		} else {
+			a.condition.SetError(&newStatus, "", err)
+		}
+	}
+	if !equality.Semantic.DeepEqual(origStatus, &newStatus) {
+		if a.condition != "" {
+			// Since status has changed, update the lastUpdatedTime
+			a.condition.LastUpdated(&newStatus, time.Now().UTC().Format(time.RFC3339))
+		}
+
+		var newErr error
+		obj.Status = newStatus
+		newObj, newErr := a.client.UpdateStatus(obj)
+		if err == nil {
+			err = newErr
+		}
+		if newErr == nil {
+			obj = newObj
+		}
+	}
+	return obj, err
+}
+
+type clusterImportGeneratingHandler struct {
+	ClusterImportGeneratingHandler
+	apply apply.Apply
+	opts  generic.GeneratingHandlerOptions
+	gvk   schema.GroupVersionKind
+	name  string
+}
+
+func (a *clusterImportGeneratingHandler) Remove(key string, obj *v1alpha1.ClusterImport) (*v1alpha1.ClusterImport, error) {
+	if obj != nil {
+		return obj, nil
+	}
+
+	obj = &v1alpha1.ClusterImport{}
+	obj.Namespace, obj.Name = kv.RSplit(key, "/")
+	obj.SetGroupVersionKind(a.gvk)
+
+	return nil, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects()
+}
+
+func (a *clusterImportGeneratingHandler) Handle(obj *v1alpha1.ClusterImport, status v1alpha1.ClusterImportStatus) (v1alpha1.ClusterImportStatus, error) {
+	if !obj.DeletionTimestamp.IsZero() {
+		return status, nil
+	}
+
+	objs, newStatus, err := a.ClusterImportGeneratingHandler(obj, status)
+	if err != nil {
+		return newStatus, err
+	}
+
+	return newStatus, generic.ConfigureApplyForObject(a.apply, obj, &a.opts).
+		WithOwner(obj).
+		WithSetID(a.name).
+		ApplyObjects(objs...)
+}
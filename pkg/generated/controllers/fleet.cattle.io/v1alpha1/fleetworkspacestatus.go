@@ -0,0 +1,256 @@
+/*
+Copyright (c) 2020 - 2023 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/rancher/wrangler/pkg/generic"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type FleetWorkspaceStatusHandler func(string, *v1alpha1.FleetWorkspaceStatus) (*v1alpha1.FleetWorkspaceStatus, error)
+
+type FleetWorkspaceStatusController interface {
+	generic.ControllerMeta
+	FleetWorkspaceStatusClient
+
+	OnChange(ctx context.Context, name string, sync FleetWorkspaceStatusHandler)
+	OnRemove(ctx context.Context, name string, sync FleetWorkspaceStatusHandler)
+	Enqueue(namespace, name string)
+	EnqueueAfter(namespace, name string, duration time.Duration)
+
+	Cache() FleetWorkspaceStatusCache
+}
+
+type FleetWorkspaceStatusClient interface {
+	Create(*v1alpha1.FleetWorkspaceStatus) (*v1alpha1.FleetWorkspaceStatus, error)
+	Update(*v1alpha1.FleetWorkspaceStatus) (*v1alpha1.FleetWorkspaceStatus, error)
+
+	Delete(namespace, name string, options *metav1.DeleteOptions) error
+	Get(namespace, name string, options metav1.GetOptions) (*v1alpha1.FleetWorkspaceStatus, error)
+	List(namespace string, opts metav1.ListOptions) (*v1alpha1.FleetWorkspaceStatusList, error)
+	Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.FleetWorkspaceStatus, err error)
+}
+
+type FleetWorkspaceStatusCache interface {
+	Get(namespace, name string) (*v1alpha1.FleetWorkspaceStatus, error)
+	List(namespace string, selector labels.Selector) ([]*v1alpha1.FleetWorkspaceStatus, error)
+
+	AddIndexer(indexName string, indexer FleetWorkspaceStatusIndexer)
+	GetByIndex(indexName, key string) ([]*v1alpha1.FleetWorkspaceStatus, error)
+}
+
+type FleetWorkspaceStatusIndexer func(obj *v1alpha1.FleetWorkspaceStatus) ([]string, error)
+
+type fleetWorkspaceStatusController struct {
+	controller    controller.SharedController
+	client        *client.Client
+	gvk           schema.GroupVersionKind
+	groupResource schema.GroupResource
+}
+
+func NewFleetWorkspaceStatusController(gvk schema.GroupVersionKind, resource string, namespaced bool, controller controller.SharedControllerFactory) FleetWorkspaceStatusController {
+	c := controller.ForResourceKind(gvk.GroupVersion().WithResource(resource), gvk.Kind, namespaced)
+	return &fleetWorkspaceStatusController{
+		controller: c,
+		client:     c.Client(),
+		gvk:        gvk,
+		groupResource: schema.GroupResource{
+			Group:    gvk.Group,
+			Resource: resource,
+		},
+	}
+}
+
+func FromFleetWorkspaceStatusHandlerToHandler(sync FleetWorkspaceStatusHandler) generic.Handler {
+	return func(key string, obj runtime.Object) (ret runtime.Object, err error) {
+		var v *v1alpha1.FleetWorkspaceStatus
+		if obj == nil {
+			v, err = sync(key, nil)
+		} else {
+			v, err = sync(key, obj.(*v1alpha1.FleetWorkspaceStatus))
+		}
+		if v == nil {
+			return nil, err
+		}
+		return v, err
+	}
+}
+
+func (c *fleetWorkspaceStatusController) Updater() generic.Updater {
+	return func(obj runtime.Object) (runtime.Object, error) {
+		newObj, err := c.Update(obj.(*v1alpha1.FleetWorkspaceStatus))
+		if newObj == nil {
+			return nil, err
+		}
+		return newObj, err
+	}
+}
+
+func UpdateFleetWorkspaceStatusDeepCopyOnChange(client FleetWorkspaceStatusClient, obj *v1alpha1.FleetWorkspaceStatus, handler func(obj *v1alpha1.FleetWorkspaceStatus) (*v1alpha1.FleetWorkspaceStatus, error)) (*v1alpha1.FleetWorkspaceStatus, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	copyObj := obj.DeepCopy()
+	newObj, err := handler(copyObj)
+	if newObj != nil {
+		copyObj = newObj
+	}
+	if obj.ResourceVersion == copyObj.ResourceVersion && !equality.Semantic.DeepEqual(obj, copyObj) {
+		return client.Update(copyObj)
+	}
+
+	return copyObj, err
+}
+
+func (c *fleetWorkspaceStatusController) AddGenericHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.controller.RegisterHandler(ctx, name, controller.SharedControllerHandlerFunc(handler))
+}
+
+func (c *fleetWorkspaceStatusController) AddGenericRemoveHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), handler))
+}
+
+func (c *fleetWorkspaceStatusController) OnChange(ctx context.Context, name string, sync FleetWorkspaceStatusHandler) {
+	c.AddGenericHandler(ctx, name, FromFleetWorkspaceStatusHandlerToHandler(sync))
+}
+
+func (c *fleetWorkspaceStatusController) OnRemove(ctx context.Context, name string, sync FleetWorkspaceStatusHandler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), FromFleetWorkspaceStatusHandlerToHandler(sync)))
+}
+
+func (c *fleetWorkspaceStatusController) Enqueue(namespace, name string) {
+	c.controller.Enqueue(namespace, name)
+}
+
+func (c *fleetWorkspaceStatusController) EnqueueAfter(namespace, name string, duration time.Duration) {
+	c.controller.EnqueueAfter(namespace, name, duration)
+}
+
+func (c *fleetWorkspaceStatusController) Informer() cache.SharedIndexInformer {
+	return c.controller.Informer()
+}
+
+func (c *fleetWorkspaceStatusController) GroupVersionKind() schema.GroupVersionKind {
+	return c.gvk
+}
+
+func (c *fleetWorkspaceStatusController) Cache() FleetWorkspaceStatusCache {
+	return &fleetWorkspaceStatusCache{
+		indexer:  c.Informer().GetIndexer(),
+		resource: c.groupResource,
+	}
+}
+
+func (c *fleetWorkspaceStatusController) Create(obj *v1alpha1.FleetWorkspaceStatus) (*v1alpha1.FleetWorkspaceStatus, error) {
+	result := &v1alpha1.FleetWorkspaceStatus{}
+	return result, c.client.Create(context.TODO(), obj.Namespace, obj, result, metav1.CreateOptions{})
+}
+
+func (c *fleetWorkspaceStatusController) Update(obj *v1alpha1.FleetWorkspaceStatus) (*v1alpha1.FleetWorkspaceStatus, error) {
+	result := &v1alpha1.FleetWorkspaceStatus{}
+	return result, c.client.Update(context.TODO(), obj.Namespace, obj, result, metav1.UpdateOptions{})
+}
+
+func (c *fleetWorkspaceStatusController) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	}
+	return c.client.Delete(context.TODO(), namespace, name, *options)
+}
+
+func (c *fleetWorkspaceStatusController) Get(namespace, name string, options metav1.GetOptions) (*v1alpha1.FleetWorkspaceStatus, error) {
+	result := &v1alpha1.FleetWorkspaceStatus{}
+	return result, c.client.Get(context.TODO(), namespace, name, result, options)
+}
+
+func (c *fleetWorkspaceStatusController) List(namespace string, opts metav1.ListOptions) (*v1alpha1.FleetWorkspaceStatusList, error) {
+	result := &v1alpha1.FleetWorkspaceStatusList{}
+	return result, c.client.List(context.TODO(), namespace, result, opts)
+}
+
+func (c *fleetWorkspaceStatusController) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.client.Watch(context.TODO(), namespace, opts)
+}
+
+func (c *fleetWorkspaceStatusController) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*v1alpha1.FleetWorkspaceStatus, error) {
+	result := &v1alpha1.FleetWorkspaceStatus{}
+	return result, c.client.Patch(context.TODO(), namespace, name, pt, data, result, metav1.PatchOptions{}, subresources...)
+}
+
+type fleetWorkspaceStatusCache struct {
+	indexer  cache.Indexer
+	resource schema.GroupResource
+}
+
+func (c *fleetWorkspaceStatusCache) Get(namespace, name string) (*v1alpha1.FleetWorkspaceStatus, error) {
+	obj, exists, err := c.indexer.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(c.resource, name)
+	}
+	return obj.(*v1alpha1.FleetWorkspaceStatus), nil
+}
+
+func (c *fleetWorkspaceStatusCache) List(namespace string, selector labels.Selector) (ret []*v1alpha1.FleetWorkspaceStatus, err error) {
+
+	err = cache.ListAllByNamespace(c.indexer, namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.FleetWorkspaceStatus))
+	})
+
+	return ret, err
+}
+
+func (c *fleetWorkspaceStatusCache) AddIndexer(indexName string, indexer FleetWorkspaceStatusIndexer) {
+	utilruntime.Must(c.indexer.AddIndexers(map[string]cache.IndexFunc{
+		indexName: func(obj interface{}) (strings []string, e error) {
+			return indexer(obj.(*v1alpha1.FleetWorkspaceStatus))
+		},
+	}))
+}
+
+func (c *fleetWorkspaceStatusCache) GetByIndex(indexName, key string) (result []*v1alpha1.FleetWorkspaceStatus, err error) {
+	objs, err := c.indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	result = make([]*v1alpha1.FleetWorkspaceStatus, 0, len(objs))
+	for _, obj := range objs {
+		result = append(result, obj.(*v1alpha1.FleetWorkspaceStatus))
+	}
+	return result, nil
+}
@@ -0,0 +1,256 @@
+/*
+Copyright (c) 2020 - 2023 SUSE LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/rancher/wrangler/pkg/generic"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type GitRepoDefaultsHandler func(string, *v1alpha1.GitRepoDefaults) (*v1alpha1.GitRepoDefaults, error)
+
+type GitRepoDefaultsController interface {
+	generic.ControllerMeta
+	GitRepoDefaultsClient
+
+	OnChange(ctx context.Context, name string, sync GitRepoDefaultsHandler)
+	OnRemove(ctx context.Context, name string, sync GitRepoDefaultsHandler)
+	Enqueue(namespace, name string)
+	EnqueueAfter(namespace, name string, duration time.Duration)
+
+	Cache() GitRepoDefaultsCache
+}
+
+type GitRepoDefaultsClient interface {
+	Create(*v1alpha1.GitRepoDefaults) (*v1alpha1.GitRepoDefaults, error)
+	Update(*v1alpha1.GitRepoDefaults) (*v1alpha1.GitRepoDefaults, error)
+
+	Delete(namespace, name string, options *metav1.DeleteOptions) error
+	Get(namespace, name string, options metav1.GetOptions) (*v1alpha1.GitRepoDefaults, error)
+	List(namespace string, opts metav1.ListOptions) (*v1alpha1.GitRepoDefaultsList, error)
+	Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.GitRepoDefaults, err error)
+}
+
+type GitRepoDefaultsCache interface {
+	Get(namespace, name string) (*v1alpha1.GitRepoDefaults, error)
+	List(namespace string, selector labels.Selector) ([]*v1alpha1.GitRepoDefaults, error)
+
+	AddIndexer(indexName string, indexer GitRepoDefaultsIndexer)
+	GetByIndex(indexName, key string) ([]*v1alpha1.GitRepoDefaults, error)
+}
+
+type GitRepoDefaultsIndexer func(obj *v1alpha1.GitRepoDefaults) ([]string, error)
+
+type gitRepoDefaultsController struct {
+	controller    controller.SharedController
+	client        *client.Client
+	gvk           schema.GroupVersionKind
+	groupResource schema.GroupResource
+}
+
+func NewGitRepoDefaultsController(gvk schema.GroupVersionKind, resource string, namespaced bool, controller controller.SharedControllerFactory) GitRepoDefaultsController {
+	c := controller.ForResourceKind(gvk.GroupVersion().WithResource(resource), gvk.Kind, namespaced)
+	return &gitRepoDefaultsController{
+		controller: c,
+		client:     c.Client(),
+		gvk:        gvk,
+		groupResource: schema.GroupResource{
+			Group:    gvk.Group,
+			Resource: resource,
+		},
+	}
+}
+
+func FromGitRepoDefaultsHandlerToHandler(sync GitRepoDefaultsHandler) generic.Handler {
+	return func(key string, obj runtime.Object) (ret runtime.Object, err error) {
+		var v *v1alpha1.GitRepoDefaults
+		if obj == nil {
+			v, err = sync(key, nil)
+		} else {
+			v, err = sync(key, obj.(*v1alpha1.GitRepoDefaults))
+		}
+		if v == nil {
+			return nil, err
+		}
+		return v, err
+	}
+}
+
+func (c *gitRepoDefaultsController) Updater() generic.Updater {
+	return func(obj runtime.Object) (runtime.Object, error) {
+		newObj, err := c.Update(obj.(*v1alpha1.GitRepoDefaults))
+		if newObj == nil {
+			return nil, err
+		}
+		return newObj, err
+	}
+}
+
+func UpdateGitRepoDefaultsDeepCopyOnChange(client GitRepoDefaultsClient, obj *v1alpha1.GitRepoDefaults, handler func(obj *v1alpha1.GitRepoDefaults) (*v1alpha1.GitRepoDefaults, error)) (*v1alpha1.GitRepoDefaults, error) {
+	if obj == nil {
+		return obj, nil
+	}
+
+	copyObj := obj.DeepCopy()
+	newObj, err := handler(copyObj)
+	if newObj != nil {
+		copyObj = newObj
+	}
+	if obj.ResourceVersion == copyObj.ResourceVersion && !equality.Semantic.DeepEqual(obj, copyObj) {
+		return client.Update(copyObj)
+	}
+
+	return copyObj, err
+}
+
+func (c *gitRepoDefaultsController) AddGenericHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.controller.RegisterHandler(ctx, name, controller.SharedControllerHandlerFunc(handler))
+}
+
+func (c *gitRepoDefaultsController) AddGenericRemoveHandler(ctx context.Context, name string, handler generic.Handler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), handler))
+}
+
+func (c *gitRepoDefaultsController) OnChange(ctx context.Context, name string, sync GitRepoDefaultsHandler) {
+	c.AddGenericHandler(ctx, name, FromGitRepoDefaultsHandlerToHandler(sync))
+}
+
+func (c *gitRepoDefaultsController) OnRemove(ctx context.Context, name string, sync GitRepoDefaultsHandler) {
+	c.AddGenericHandler(ctx, name, generic.NewRemoveHandler(name, c.Updater(), FromGitRepoDefaultsHandlerToHandler(sync)))
+}
+
+func (c *gitRepoDefaultsController) Enqueue(namespace, name string) {
+	c.controller.Enqueue(namespace, name)
+}
+
+func (c *gitRepoDefaultsController) EnqueueAfter(namespace, name string, duration time.Duration) {
+	c.controller.EnqueueAfter(namespace, name, duration)
+}
+
+func (c *gitRepoDefaultsController) Informer() cache.SharedIndexInformer {
+	return c.controller.Informer()
+}
+
+func (c *gitRepoDefaultsController) GroupVersionKind() schema.GroupVersionKind {
+	return c.gvk
+}
+
+func (c *gitRepoDefaultsController) Cache() GitRepoDefaultsCache {
+	return &gitRepoDefaultsCache{
+		indexer:  c.Informer().GetIndexer(),
+		resource: c.groupResource,
+	}
+}
+
+func (c *gitRepoDefaultsController) Create(obj *v1alpha1.GitRepoDefaults) (*v1alpha1.GitRepoDefaults, error) {
+	result := &v1alpha1.GitRepoDefaults{}
+	return result, c.client.Create(context.TODO(), obj.Namespace, obj, result, metav1.CreateOptions{})
+}
+
+func (c *gitRepoDefaultsController) Update(obj *v1alpha1.GitRepoDefaults) (*v1alpha1.GitRepoDefaults, error) {
+	result := &v1alpha1.GitRepoDefaults{}
+	return result, c.client.Update(context.TODO(), obj.Namespace, obj, result, metav1.UpdateOptions{})
+}
+
+func (c *gitRepoDefaultsController) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	if options == nil {
+		options = &metav1.DeleteOptions{}
+	}
+	return c.client.Delete(context.TODO(), namespace, name, *options)
+}
+
+func (c *gitRepoDefaultsController) Get(namespace, name string, options metav1.GetOptions) (*v1alpha1.GitRepoDefaults, error) {
+	result := &v1alpha1.GitRepoDefaults{}
+	return result, c.client.Get(context.TODO(), namespace, name, result, options)
+}
+
+func (c *gitRepoDefaultsController) List(namespace string, opts metav1.ListOptions) (*v1alpha1.GitRepoDefaultsList, error) {
+	result := &v1alpha1.GitRepoDefaultsList{}
+	return result, c.client.List(context.TODO(), namespace, result, opts)
+}
+
+func (c *gitRepoDefaultsController) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.client.Watch(context.TODO(), namespace, opts)
+}
+
+func (c *gitRepoDefaultsController) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*v1alpha1.GitRepoDefaults, error) {
+	result := &v1alpha1.GitRepoDefaults{}
+	return result, c.client.Patch(context.TODO(), namespace, name, pt, data, result, metav1.PatchOptions{}, subresources...)
+}
+
+type gitRepoDefaultsCache struct {
+	indexer  cache.Indexer
+	resource schema.GroupResource
+}
+
+func (c *gitRepoDefaultsCache) Get(namespace, name string) (*v1alpha1.GitRepoDefaults, error) {
+	obj, exists, err := c.indexer.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(c.resource, name)
+	}
+	return obj.(*v1alpha1.GitRepoDefaults), nil
+}
+
+func (c *gitRepoDefaultsCache) List(namespace string, selector labels.Selector) (ret []*v1alpha1.GitRepoDefaults, err error) {
+
+	err = cache.ListAllByNamespace(c.indexer, namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.GitRepoDefaults))
+	})
+
+	return ret, err
+}
+
+func (c *gitRepoDefaultsCache) AddIndexer(indexName string, indexer GitRepoDefaultsIndexer) {
+	utilruntime.Must(c.indexer.AddIndexers(map[string]cache.IndexFunc{
+		indexName: func(obj interface{}) (strings []string, e error) {
+			return indexer(obj.(*v1alpha1.GitRepoDefaults))
+		},
+	}))
+}
+
+func (c *gitRepoDefaultsCache) GetByIndex(indexName, key string) (result []*v1alpha1.GitRepoDefaults, err error) {
+	objs, err := c.indexer.ByIndex(indexName, key)
+	if err != nil {
+		return nil, err
+	}
+	result = make([]*v1alpha1.GitRepoDefaults, 0, len(objs))
+	for _, obj := range objs {
+		result = append(result, obj.(*v1alpha1.GitRepoDefaults))
+	}
+	return result, nil
+}
@@ -33,12 +33,16 @@ type Interface interface {
 	Bundle() BundleController
 	BundleDeployment() BundleDeploymentController
 	BundleNamespaceMapping() BundleNamespaceMappingController
+	BundleRevision() BundleRevisionController
 	Cluster() ClusterController
 	ClusterGroup() ClusterGroupController
+	ClusterImport() ClusterImportController
 	ClusterRegistration() ClusterRegistrationController
 	ClusterRegistrationToken() ClusterRegistrationTokenController
 	Content() ContentController
+	FleetWorkspaceStatus() FleetWorkspaceStatusController
 	GitRepo() GitRepoController
+	GitRepoDefaults() GitRepoDefaultsController
 	GitRepoRestriction() GitRepoRestrictionController
 	ImageScan() ImageScanController
 }
@@ -62,12 +66,19 @@ func (c *version) BundleDeployment() BundleDeploymentController {
 func (c *version) BundleNamespaceMapping() BundleNamespaceMappingController {
 	return NewBundleNamespaceMappingController(schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "BundleNamespaceMapping"}, "bundlenamespacemappings", true, c.controllerFactory)
 }
+func (c *version) BundleRevision() BundleRevisionController {
+	return NewBundleRevisionController(schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "BundleRevision"}, "bundlerevisions", true, c.controllerFactory)
+}
 func (c *version) Cluster() ClusterController {
 	return NewClusterController(schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "Cluster"}, "clusters", true, c.controllerFactory)
 }
 func (c *version) ClusterGroup() ClusterGroupController {
 	return NewClusterGroupController(schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "ClusterGroup"}, "clustergroups", true, c.controllerFactory)
 }
+
+func (c *version) ClusterImport() ClusterImportController {
+	return NewClusterImportController(schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "ClusterImport"}, "clusterimports", true, c.controllerFactory)
+}
 func (c *version) ClusterRegistration() ClusterRegistrationController {
 	return NewClusterRegistrationController(schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "ClusterRegistration"}, "clusterregistrations", true, c.controllerFactory)
 }
@@ -77,9 +88,16 @@ func (c *version) ClusterRegistrationToken() ClusterRegistrationTokenController
 func (c *version) Content() ContentController {
 	return NewContentController(schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "Content"}, "contents", false, c.controllerFactory)
 }
+func (c *version) FleetWorkspaceStatus() FleetWorkspaceStatusController {
+	return NewFleetWorkspaceStatusController(schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "FleetWorkspaceStatus"}, "fleetworkspacestatuses", true, c.controllerFactory)
+}
 func (c *version) GitRepo() GitRepoController {
 	return NewGitRepoController(schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "GitRepo"}, "gitrepos", true, c.controllerFactory)
 }
+func (c *version) GitRepoDefaults() GitRepoDefaultsController {
+	return NewGitRepoDefaultsController(schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "GitRepoDefaults"}, "gitrepodefaults", true, c.controllerFactory)
+}
+
 func (c *version) GitRepoRestriction() GitRepoRestrictionController {
 	return NewGitRepoRestrictionController(schema.GroupVersionKind{Group: "fleet.cattle.io", Version: "v1alpha1", Kind: "GitRepoRestriction"}, "gitreporestrictions", true, c.controllerFactory)
 }
@@ -0,0 +1,144 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// KnownHostsKey is the secret key holding the known_hosts entries to
+	// verify SSH host keys against
+	KnownHostsKey = "known_hosts"
+	// SSHPassphraseKey is the secret key holding the passphrase of an
+	// encrypted SSH private key
+	SSHPassphraseKey = "passphrase"
+)
+
+// AuthFromSecret returns the auth method for accessing the repository with
+// a basic auth, SSH auth or opaque secret.
+//
+// SSH host keys are verified against the secret's known_hosts. If the secret
+// has none, the files in SSH_KNOWN_HOSTS or ~/.ssh/known_hosts are used.
+//
+// Opaque secrets hold provider specific credentials: a GitHub App
+// installation, an OAuth bearer token, e.g. for Azure DevOps, or AWS
+// credentials for CodeCommit. Azure DevOps personal access tokens work with
+// basic auth secrets and any username. GitHub App installation tokens are
+// cached and renewed before they expire.
+func AuthFromSecret(secret *corev1.Secret, repoURL string) (transport.AuthMethod, error) {
+	switch secret.Type {
+	case corev1.SecretTypeBasicAuth:
+		return &http.BasicAuth{
+			Username: string(secret.Data[corev1.BasicAuthUsernameKey]),
+			Password: string(secret.Data[corev1.BasicAuthPasswordKey]),
+		}, nil
+	case corev1.SecretTypeSSHAuth:
+		publicKey, err := ssh.NewPublicKeys("git", secret.Data[corev1.SSHAuthPrivateKey], string(secret.Data[SSHPassphraseKey]))
+		if err != nil {
+			return nil, err
+		}
+		if knownHosts := secret.Data[KnownHostsKey]; knownHosts != nil {
+			if err := setKnownHosts(publicKey, knownHosts); err != nil {
+				return nil, err
+			}
+		}
+		return publicKey, nil
+	case corev1.SecretTypeOpaque:
+		if secret.Data[GitHubAppIDKey] != nil {
+			token, err := gitHubAppToken(secret, repoURL)
+			if err != nil {
+				return nil, err
+			}
+			return &http.BasicAuth{Username: "x-access-token", Password: token}, nil
+		}
+		if token := secret.Data[TokenKey]; token != nil {
+			return &http.TokenAuth{Token: string(token)}, nil
+		}
+		if secret.Data[AWSAccessKeyIDKey] != nil {
+			return newCodeCommitAuth(repoURL,
+				string(secret.Data[AWSAccessKeyIDKey]),
+				string(secret.Data[AWSSecretAccessKeyKey]),
+				string(secret.Data[AWSSessionTokenKey]))
+		}
+		return nil, fmt.Errorf("opaque secret requires GitHub App credentials, %s or AWS credentials", TokenKey)
+	}
+	return nil, errors.New("invalid secret type")
+}
+
+// setKnownHosts verifies host keys against the known_hosts data, without
+// changing the process environment
+func setKnownHosts(auth *ssh.PublicKeys, data []byte) error {
+	tmpdir, err := os.MkdirTemp("", "ssh-known-hosts-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	file := filepath.Join(tmpdir, "known_hosts")
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return err
+	}
+
+	// the file is read when creating the callback
+	auth.HostKeyCallback, err = ssh.NewKnownHostsCallback(file)
+	return err
+}
+
+// APIToken returns the token for calling the git provider's API: the
+// password of a basic auth secret, the token of an opaque secret or a
+// GitHub App installation token.
+func APIToken(secret *corev1.Secret, repoURL string) (string, error) {
+	switch {
+	case secret.Type == corev1.SecretTypeBasicAuth:
+		return string(secret.Data[corev1.BasicAuthPasswordKey]), nil
+	case secret.Type == corev1.SecretTypeOpaque && secret.Data[GitHubAppIDKey] != nil:
+		return gitHubAppToken(secret, repoURL)
+	case secret.Type == corev1.SecretTypeOpaque && secret.Data[TokenKey] != nil:
+		return string(secret.Data[TokenKey]), nil
+	}
+	return "", fmt.Errorf("secret %s has no credentials for the git provider's API", secret.Name)
+}
+
+// BasicAuthFromSecret returns basic auth credentials for cloning with the
+// git binary from an opaque secret. GitHub App installation tokens and
+// CodeCommit signed passwords expire, so the credentials have to be renewed.
+// Bearer tokens are used as password, which GitHub, GitLab and Azure DevOps
+// accept for personal access tokens.
+func BasicAuthFromSecret(secret *corev1.Secret, repoURL string) (*http.BasicAuth, error) {
+	if secret.Type != corev1.SecretTypeOpaque {
+		return nil, fmt.Errorf("secret %s is not an opaque secret", secret.Name)
+	}
+	auth, err := AuthFromSecret(secret, repoURL)
+	if err != nil {
+		return nil, err
+	}
+	switch auth := auth.(type) {
+	case *http.BasicAuth:
+		return auth, nil
+	case *http.TokenAuth:
+		return &http.BasicAuth{Username: "oauth2", Password: auth.Token}, nil
+	case *codeCommitAuth:
+		username, password := auth.credentials()
+		return &http.BasicAuth{Username: username, Password: password}, nil
+	}
+	return nil, fmt.Errorf("secret %s has no credentials for cloning", secret.Name)
+}
+
+func gitHubAppToken(secret *corev1.Secret, repoURL string) (string, error) {
+	app, err := newGitHubApp(repoURL,
+		string(secret.Data[GitHubAppIDKey]),
+		string(secret.Data[GitHubAppInstallationIDKey]),
+		secret.Data[GitHubAppPrivateKeyKey])
+	if err != nil {
+		return "", err
+	}
+	return app.Token()
+}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// States of a commit status
+const (
+	CommitStatePending = "pending"
+	CommitStateSuccess = "success"
+	CommitStateFailure = "failure"
+)
+
+// CommitStatus is the state of a commit reported to the git provider
+type CommitStatus struct {
+	State       string `json:"state"`
+	Context     string `json:"context"`
+	Description string `json:"description,omitempty"`
+}
+
+// SetGitHubCommitStatus reports the status of the commit through the
+// statuses API of GitHub, authenticated with the token
+func SetGitHubCommitStatus(ctx context.Context, repoURL, token, commit string, status CommitStatus) error {
+	u, err := parseRepoURL(repoURL)
+	if err != nil {
+		return err
+	}
+	repoPath := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if strings.Count(repoPath, "/") < 1 {
+		return fmt.Errorf("cannot find the repository in %s", repoURL)
+	}
+	base, err := gitHubAPIURL(repoURL)
+	if err != nil {
+		return err
+	}
+
+	api := &apiClient{
+		client:     &http.Client{Timeout: 30 * time.Second},
+		authHeader: "Bearer " + token,
+	}
+	code, err := api.do(ctx, http.MethodPost, base+"/repos/"+repoPath+"/statuses/"+commit, status, nil)
+	if err != nil {
+		return err
+	}
+	if code == http.StatusNotFound {
+		return fmt.Errorf("commit %s not found in %s", commit, repoURL)
+	}
+	return nil
+}
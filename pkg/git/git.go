@@ -0,0 +1,179 @@
+// Package git reads and updates git repositories, using a pure Go
+// implementation that doesn't depend on a git binary. (fleetcontroller)
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// Options describe a branch of a remote repository and how to access it
+type Options struct {
+	URL    string
+	Branch string
+	Auth   transport.AuthMethod
+	// CABundle is added to the system's certificate pool for HTTPS
+	CABundle        []byte
+	InsecureSkipTLS bool
+	// Depth limits the number of commits cloned, zero clones the full
+	// history
+	Depth int
+}
+
+// Signature identifies the author of a commit
+type Signature struct {
+	Name  string
+	Email string
+}
+
+// Client clones and inspects remote repositories. Controllers use it
+// instead of go-git directly, so tests can replace it.
+type Client interface {
+	// LatestCommit returns the commit the branch points to, without
+	// cloning the repository.
+	LatestCommit(ctx context.Context, opts *Options) (string, error)
+	// Tags returns the commits of the repository's tags by tag name,
+	// without cloning the repository. The branch is ignored.
+	Tags(ctx context.Context, opts *Options) (map[string]string, error)
+	// Clone checks out the branch into dir.
+	Clone(ctx context.Context, dir string, opts *Options) (Repository, error)
+}
+
+// Repository is a cloned repository
+type Repository interface {
+	// CommitAllAndPush commits all changes in the worktree and pushes them
+	// to the cloned branch. It returns an empty commit if nothing changed.
+	CommitAllAndPush(ctx context.Context, message string, author Signature) (string, error)
+}
+
+// NewClient returns the go-git based client
+func NewClient() Client {
+	return goGit{}
+}
+
+type goGit struct{}
+
+func (goGit) LatestCommit(ctx context.Context, opts *Options) (string, error) {
+	refs, err := listRefs(ctx, opts, gogit.IgnorePeeled)
+	if err != nil {
+		return "", err
+	}
+
+	branch := plumbing.NewBranchReferenceName(opts.Branch)
+	for _, ref := range refs {
+		if ref.Name() == branch {
+			return ref.Hash().String(), nil
+		}
+	}
+	return "", errors.New("branch " + opts.Branch + " not found in " + opts.URL)
+}
+
+func (goGit) Tags(ctx context.Context, opts *Options) (map[string]string, error) {
+	refs, err := listRefs(ctx, opts, gogit.AppendPeeled)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]string{}
+	peeled := map[string]string{}
+	for _, ref := range refs {
+		if !ref.Name().IsTag() {
+			continue
+		}
+		// annotated tags are listed twice, the peeled name refers to
+		// the tagged commit instead of the tag object
+		tag := ref.Name().Short()
+		if strings.HasSuffix(tag, peeledSuffix) {
+			peeled[strings.TrimSuffix(tag, peeledSuffix)] = ref.Hash().String()
+			continue
+		}
+		tags[tag] = ref.Hash().String()
+	}
+	for tag, commit := range peeled {
+		tags[tag] = commit
+	}
+	return tags, nil
+}
+
+// peeledSuffix marks the names of peeled references
+const peeledSuffix = "^{}"
+
+// listRefs lists the references of the remote repository
+func listRefs(ctx context.Context, opts *Options, peeling gogit.PeelingOption) ([]*plumbing.Reference, error) {
+	prepareProvider(opts.URL)
+	remote := gogit.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{opts.URL},
+	})
+	return remote.ListContext(ctx, &gogit.ListOptions{
+		Auth:            opts.Auth,
+		CABundle:        opts.CABundle,
+		InsecureSkipTLS: opts.InsecureSkipTLS,
+		PeelingOption:   peeling,
+	})
+}
+
+func (goGit) Clone(ctx context.Context, dir string, opts *Options) (Repository, error) {
+	prepareProvider(opts.URL)
+	repo, err := gogit.PlainCloneContext(ctx, dir, false, &gogit.CloneOptions{
+		URL:             opts.URL,
+		Auth:            opts.Auth,
+		RemoteName:      "origin",
+		ReferenceName:   plumbing.NewBranchReferenceName(opts.Branch),
+		SingleBranch:    true,
+		Depth:           opts.Depth,
+		Tags:            gogit.NoTags,
+		CABundle:        opts.CABundle,
+		InsecureSkipTLS: opts.InsecureSkipTLS,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &goGitRepository{repo: repo, opts: opts}, nil
+}
+
+type goGitRepository struct {
+	repo *gogit.Repository
+	opts *Options
+}
+
+func (r *goGitRepository) CommitAllAndPush(ctx context.Context, message string, author Signature) (string, error) {
+	working, err := r.repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+
+	status, err := working.Status()
+	if err != nil {
+		return "", err
+	} else if status.IsClean() {
+		return "", nil
+	}
+
+	rev, err := working.Commit(message, &gogit.CommitOptions{
+		All: true,
+		Author: &object.Signature{
+			Name:  author.Name,
+			Email: author.Email,
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return rev.String(), r.repo.PushContext(ctx, &gogit.PushOptions{
+		Auth:            r.opts.Auth,
+		CABundle:        r.opts.CABundle,
+		InsecureSkipTLS: r.opts.InsecureSkipTLS,
+	})
+}
@@ -0,0 +1,158 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func init() {
+	// serve file:// URLs in-process, instead of running git-upload-pack
+	client.InstallProtocol("file", server.DefaultServer)
+}
+
+// newRemote returns the URL of a bare repository with one commit on master
+func newRemote(t *testing.T) (string, string) {
+	remoteDir := filepath.Join(t.TempDir(), "remote.git")
+	if _, err := gogit.PlainInit(remoteDir, true); err != nil {
+		t.Fatal(err)
+	}
+	url := "file://" + remoteDir
+
+	seedDir := t.TempDir()
+	seed, err := gogit.PlainInit(seedDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(seedDir, "fleet.yaml"), []byte("namespace: a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := seed.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("fleet.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := wt.Commit("seed", &gogit.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := seed.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{url}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := seed.Push(&gogit.PushOptions{RefSpecs: []config.RefSpec{"refs/heads/master:refs/heads/master"}}); err != nil {
+		t.Fatal(err)
+	}
+	return url, hash.String()
+}
+
+func TestCloneCommitAndPush(t *testing.T) {
+	ctx := context.Background()
+	url, seed := newRemote(t)
+	c := NewClient()
+	opts := &Options{URL: url, Branch: "master"}
+
+	commit, err := c.LatestCommit(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit != seed {
+		t.Errorf("expected latest commit %s, got %s", seed, commit)
+	}
+
+	dir := t.TempDir()
+	repo, err := c.Clone(ctx, dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if commit, err := repo.CommitAllAndPush(ctx, "nothing", Signature{Name: "fleet"}); err != nil || commit != "" {
+		t.Errorf("expected no commit for a clean worktree, got %q, %v", commit, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "fleet.yaml"), []byte("namespace: b\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pushed, err := repo.CommitAllAndPush(ctx, "update", Signature{Name: "fleet", Email: "fleet@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit, _ := c.LatestCommit(ctx, opts); commit != pushed || pushed == seed {
+		t.Errorf("expected pushed commit %s to be the latest, got %s", pushed, commit)
+	}
+
+	if _, err := c.LatestCommit(ctx, &Options{URL: url, Branch: "missing"}); err == nil {
+		t.Error("expected error for missing branch")
+	}
+}
+
+func TestTags(t *testing.T) {
+	ctx := context.Background()
+	url, seed := newRemote(t)
+
+	repo, err := gogit.PlainClone(t.TempDir(), false, &gogit.CloneOptions{URL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTag("v1.0.0", plumbing.NewHash(seed), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTag("v1.1.0", plumbing.NewHash(seed), &gogit.CreateTagOptions{
+		Message: "release",
+		Tagger:  &object.Signature{Name: "test", When: time.Now()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Push(&gogit.PushOptions{RefSpecs: []config.RefSpec{"refs/tags/*:refs/tags/*"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := NewClient().Tags(ctx, &Options{URL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the in-process server doesn't advertise peeled references, so the
+	// annotated tag refers to the tag object instead of the commit
+	if len(tags) != 2 || tags["v1.0.0"] != seed || tags["v1.1.0"] == "" {
+		t.Errorf("expected tags v1.0.0 at %s and v1.1.0, got %v", seed, tags)
+	}
+}
+
+func TestAuthFromSecret(t *testing.T) {
+	auth, err := AuthFromSecret(&corev1.Secret{
+		Type: corev1.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			corev1.BasicAuthUsernameKey: []byte("user"),
+			corev1.BasicAuthPasswordKey: []byte("pass"),
+		},
+	}, "https://example.com/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if basic, ok := auth.(*http.BasicAuth); !ok || basic.Username != "user" || basic.Password != "pass" {
+		t.Errorf("unexpected auth %v", auth)
+	}
+
+	if _, err := AuthFromSecret(&corev1.Secret{Type: corev1.SecretTypeOpaque}, "https://example.com/repo"); err == nil {
+		t.Error("expected error for opaque secret")
+	}
+	if _, err := AuthFromSecret(&corev1.Secret{
+		Type: corev1.SecretTypeSSHAuth,
+		Data: map[string][]byte{corev1.SSHAuthPrivateKey: []byte("not a key")},
+	}, "ssh://git@example.com/repo"); err == nil {
+		t.Error("expected error for invalid private key")
+	}
+}
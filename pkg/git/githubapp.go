@@ -0,0 +1,196 @@
+package git
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// GitHubAppIDKey, GitHubAppInstallationIDKey and GitHubAppPrivateKeyKey
+	// are the keys of an opaque secret holding the credentials of a
+	// GitHub App installation
+	GitHubAppIDKey             = "github_app_id"
+	GitHubAppInstallationIDKey = "github_app_installation_id"
+	GitHubAppPrivateKeyKey     = "github_app_private_key"
+
+	// installation tokens are valid for an hour, they are renewed when
+	// less than this is left
+	gitHubTokenRenewBefore = 10 * time.Minute
+)
+
+var (
+	gitHubTokensLock sync.Mutex
+	gitHubTokens     = map[string]*gitHubToken{}
+)
+
+type gitHubToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// gitHubApp requests installation access tokens for a GitHub App
+type gitHubApp struct {
+	apiURL         string
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	keyID          string
+	client         *http.Client
+	now            func() time.Time
+}
+
+func newGitHubApp(repoURL string, appID, installationID string, privateKey []byte) (*gitHubApp, error) {
+	app := &gitHubApp{
+		client: &http.Client{Timeout: 30 * time.Second},
+		now:    time.Now,
+	}
+
+	var err error
+	if app.appID, err = strconv.ParseInt(appID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", GitHubAppIDKey, err)
+	}
+	if app.installationID, err = strconv.ParseInt(installationID, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", GitHubAppInstallationIDKey, err)
+	}
+	if app.key, err = parseRSAPrivateKey(privateKey); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", GitHubAppPrivateKeyKey, err)
+	}
+	sum := sha256.Sum256(privateKey)
+	app.keyID = base64.RawURLEncoding.EncodeToString(sum[:])
+
+	if app.apiURL, err = gitHubAPIURL(repoURL); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// gitHubAPIURL returns the API of github.com or of the GitHub Enterprise
+// server hosting the repository
+func gitHubAPIURL(repoURL string) (string, error) {
+	u, err := parseRepoURL(repoURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Hostname() == "github.com" || u.Hostname() == "www.github.com" {
+		return "https://api.github.com", nil
+	}
+	return apiBaseURL(u) + "/api/v3", nil
+}
+
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+	return rsaKey, nil
+}
+
+// Token returns a cached installation token, or requests a new one if the
+// cached token expires soon
+func (a *gitHubApp) Token() (string, error) {
+	cacheKey := fmt.Sprintf("%s/%d/%d/%s", a.apiURL, a.appID, a.installationID, a.keyID)
+
+	gitHubTokensLock.Lock()
+	defer gitHubTokensLock.Unlock()
+
+	if t, ok := gitHubTokens[cacheKey]; ok && a.now().Add(gitHubTokenRenewBefore).Before(t.ExpiresAt) {
+		return t.Token, nil
+	}
+
+	t, err := a.requestToken()
+	if err != nil {
+		return "", err
+	}
+	gitHubTokens[cacheKey] = t
+	return t.Token, nil
+}
+
+func (a *gitHubApp) requestToken() (*gitHubToken, error) {
+	jwt, err := a.jwt()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost,
+		fmt.Sprintf("%s/app/installations/%d/access_tokens", a.apiURL, a.installationID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("requesting GitHub App installation token: %s", resp.Status)
+	}
+
+	t := &gitHubToken{}
+	if err := json.NewDecoder(resp.Body).Decode(t); err != nil {
+		return nil, err
+	}
+	if t.Token == "" {
+		return nil, errors.New("GitHub returned an empty installation token")
+	}
+	return t, nil
+}
+
+// jwt returns the RS256 signed JSON web token authenticating the app
+func (a *gitHubApp) jwt() (string, error) {
+	now := a.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		// allow for clock drift
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.FormatInt(a.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(header))
+	buf.WriteByte('.')
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(claims))
+
+	digest := sha256.Sum256(buf.Bytes())
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	buf.WriteByte('.')
+	buf.WriteString(base64.RawURLEncoding.EncodeToString(sig))
+	return buf.String(), nil
+}
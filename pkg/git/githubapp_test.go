@@ -0,0 +1,88 @@
+package git
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGitHubAppToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			t.Fatalf("expected JWT, got %s", r.Header.Get("Authorization"))
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+			t.Errorf("invalid JWT signature: %v", err)
+		}
+		claims := map[string]interface{}{}
+		data, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		_ = json.Unmarshal(data, &claims)
+		if claims["iss"] != "7" {
+			t.Errorf("unexpected issuer %v", claims["iss"])
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(gitHubToken{Token: "token-" + string(rune('0'+requests)), ExpiresAt: now.Add(time.Hour)})
+	}))
+	defer server.Close()
+
+	app, err := newGitHubApp("https://github.com/rancher/fleet", "7", "42", keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.apiURL != "https://api.github.com" {
+		t.Errorf("unexpected API URL %s", app.apiURL)
+	}
+	app.apiURL = server.URL
+	app.now = func() time.Time { return now }
+
+	token, err := app.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := app.Token(); again != token || requests != 1 {
+		t.Errorf("expected cached token, got %s after %d requests", again, requests)
+	}
+
+	app.now = func() time.Time { return now.Add(55 * time.Minute) }
+	if renewed, _ := app.Token(); renewed == token || requests != 2 {
+		t.Errorf("expected renewed token before expiry, got %s after %d requests", renewed, requests)
+	}
+}
+
+func TestGitHubAPIURL(t *testing.T) {
+	for repo, expected := range map[string]string{
+		"https://github.com/rancher/fleet":        "https://api.github.com",
+		"https://github.example.com/org/repo.git": "https://github.example.com/api/v3",
+	} {
+		if u, _ := gitHubAPIURL(repo); u != expected {
+			t.Errorf("expected API URL %s for %s, got %s", expected, repo, u)
+		}
+	}
+}
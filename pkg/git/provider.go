@@ -0,0 +1,139 @@
+package git
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	nethttp "net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+const (
+	// TokenKey is the key of an opaque secret holding an OAuth bearer
+	// token, e.g. for Azure DevOps
+	TokenKey = "token"
+	// AWSAccessKeyIDKey, AWSSecretAccessKeyKey and the optional
+	// AWSSessionTokenKey are the keys of an opaque secret holding AWS
+	// credentials for CodeCommit
+	AWSAccessKeyIDKey     = "aws_access_key_id"
+	AWSSecretAccessKeyKey = "aws_secret_access_key"
+	AWSSessionTokenKey    = "aws_session_token"
+)
+
+var azureDevOpsOnce sync.Once
+
+// prepareProvider adjusts go-git to the quirks of the repository's host.
+//
+// Azure DevOps requires the multi_ack capabilities, which go-git only
+// supports for fresh clones and disables by default. As the capabilities
+// are global, they stay enabled once an Azure DevOps repository was used.
+func prepareProvider(repoURL string) {
+	if !isAzureDevOps(repoURL) {
+		return
+	}
+	azureDevOpsOnce.Do(func() {
+		transport.UnsupportedCapabilities = []capability.Capability{
+			capability.ThinPack,
+		}
+	})
+}
+
+func isAzureDevOps(repoURL string) bool {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	return host == "dev.azure.com" || host == "ssh.dev.azure.com" || strings.HasSuffix(host, ".visualstudio.com")
+}
+
+// codeCommitAuth authenticates HTTPS requests to AWS CodeCommit with SigV4
+// signed credentials, like the AWS git credential helper. The password is
+// signed for every request, so it doesn't expire during long operations.
+type codeCommitAuth struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	host            string
+	path            string
+	region          string
+	now             func() time.Time
+}
+
+func newCodeCommitAuth(repoURL, accessKeyID, secretAccessKey, sessionToken string) (*codeCommitAuth, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	// e.g. git-codecommit.eu-west-1.amazonaws.com
+	parts := strings.Split(u.Hostname(), ".")
+	if len(parts) < 4 || parts[0] != "git-codecommit" {
+		return nil, fmt.Errorf("%s is not a CodeCommit HTTPS URL", repoURL)
+	}
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, fmt.Errorf("CodeCommit secret requires %s and %s", AWSAccessKeyIDKey, AWSSecretAccessKeyKey)
+	}
+	return &codeCommitAuth{
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		sessionToken:    sessionToken,
+		host:            u.Hostname(),
+		path:            u.EscapedPath(),
+		region:          parts[1],
+		now:             time.Now,
+	}, nil
+}
+
+func (a *codeCommitAuth) Name() string {
+	return "codecommit-sigv4"
+}
+
+func (a *codeCommitAuth) String() string {
+	return fmt.Sprintf("%s - %s:*******", a.Name(), a.accessKeyID)
+}
+
+func (a *codeCommitAuth) SetAuth(r *nethttp.Request) {
+	username, password := a.credentials()
+	r.SetBasicAuth(username, password)
+}
+
+// credentials returns the username and the signed password
+func (a *codeCommitAuth) credentials() (string, string) {
+	now := a.now().UTC()
+	timestamp := now.Format("20060102T150405")
+	date := now.Format("20060102")
+
+	canonicalRequest := "GIT\n" + a.path + "\n\nhost:" + a.host + "\n\nhost\n"
+	scope := date + "/" + a.region + "/codecommit/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + timestamp + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+a.secretAccessKey), date)
+	key = hmacSHA256(key, a.region)
+	key = hmacSHA256(key, "codecommit")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	username := a.accessKeyID
+	if a.sessionToken != "" {
+		username += "%" + a.sessionToken
+	}
+	return username, timestamp + "Z" + signature
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
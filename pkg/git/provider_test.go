@@ -0,0 +1,118 @@
+package git
+
+import (
+	nethttp "net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCodeCommitAuth(t *testing.T) {
+	const repo = "https://git-codecommit.eu-west-1.amazonaws.com/v1/repos/fleet"
+	auth, err := AuthFromSecret(&corev1.Secret{
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			AWSAccessKeyIDKey:     []byte("AKIDEXAMPLE"),
+			AWSSecretAccessKeyKey: []byte("secret"),
+			AWSSessionTokenKey:    []byte("session"),
+		},
+	}, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc, ok := auth.(*codeCommitAuth)
+	if !ok {
+		t.Fatalf("expected CodeCommit auth, got %T", auth)
+	}
+	if _, ok := auth.(http.AuthMethod); !ok {
+		t.Fatal("expected CodeCommit auth to be usable for HTTPS")
+	}
+	if cc.region != "eu-west-1" || cc.path != "/v1/repos/fleet" {
+		t.Errorf("unexpected region %s or path %s", cc.region, cc.path)
+	}
+
+	cc.now = func() time.Time { return time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC) }
+	username, password := cc.credentials()
+	if username != "AKIDEXAMPLE%session" {
+		t.Errorf("unexpected username %s", username)
+	}
+	if !strings.HasPrefix(password, "20230501T100000Z") || len(password) != len("20230501T100000Z")+64 {
+		t.Errorf("unexpected password %s", password)
+	}
+	if _, again := cc.credentials(); again != password {
+		t.Error("expected signature to be deterministic")
+	}
+
+	req, _ := nethttp.NewRequest("GET", repo+"/info/refs", nil)
+	cc.SetAuth(req)
+	if u, p, ok := req.BasicAuth(); !ok || u != username || p != password {
+		t.Errorf("expected basic auth to be set, got %s:%s", u, p)
+	}
+
+	if _, err := AuthFromSecret(&corev1.Secret{
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{AWSAccessKeyIDKey: []byte("AKIDEXAMPLE"), AWSSecretAccessKeyKey: []byte("secret")},
+	}, "https://github.com/rancher/fleet"); err == nil {
+		t.Error("expected error for non CodeCommit URL")
+	}
+}
+
+func TestTokenAuth(t *testing.T) {
+	auth, err := AuthFromSecret(&corev1.Secret{
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{TokenKey: []byte("oauth")},
+	}, "https://dev.azure.com/org/project/_git/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, ok := auth.(*http.TokenAuth); !ok || token.Token != "oauth" {
+		t.Errorf("unexpected auth %v", auth)
+	}
+}
+
+func TestBasicAuthFromSecret(t *testing.T) {
+	auth, err := BasicAuthFromSecret(&corev1.Secret{
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{TokenKey: []byte("pat")},
+	}, "https://dev.azure.com/org/project/_git/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth.Password != "pat" {
+		t.Errorf("unexpected password %q", auth.Password)
+	}
+
+	auth, err = BasicAuthFromSecret(&corev1.Secret{
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{AWSAccessKeyIDKey: []byte("AKIDEXAMPLE"), AWSSecretAccessKeyKey: []byte("secret")},
+	}, "https://git-codecommit.eu-west-1.amazonaws.com/v1/repos/fleet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth.Username != "AKIDEXAMPLE" || !strings.Contains(auth.Password, "Z") {
+		t.Errorf("unexpected CodeCommit credentials %s:%s", auth.Username, auth.Password)
+	}
+
+	if _, err := BasicAuthFromSecret(&corev1.Secret{
+		Type: corev1.SecretTypeBasicAuth,
+	}, "https://github.com/rancher/fleet"); err == nil {
+		t.Error("expected error for basic auth secret")
+	}
+}
+
+func TestIsAzureDevOps(t *testing.T) {
+	for url, expected := range map[string]bool{
+		"https://dev.azure.com/org/project/_git/repo":     true,
+		"https://org.visualstudio.com/project/_git/repo":  true,
+		"ssh://git@ssh.dev.azure.com/v3/org/project/repo": true,
+		"https://github.com/rancher/fleet":                false,
+	} {
+		if isAzureDevOps(url) != expected {
+			t.Errorf("expected isAzureDevOps(%s) to be %v", url, expected)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package git
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	ProviderBitbucket       = "bitbucket"
+	ProviderBitbucketServer = "bitbucket-server"
+	ProviderAzureDevOps     = "azure-devops"
+
+	branchRefPrefix = "refs/heads/"
+	tagRefPrefix    = "refs/tags/"
+	// zeroCommit is sent as the new commit of deleted refs
+	zeroCommit = "0000000000000000000000000000000000000000"
+)
+
+var (
+	// ErrIgnoredEvent is returned for requests, which are not push events
+	// of a known git provider, e.g. pings
+	ErrIgnoredEvent = errors.New("ignored webhook event")
+	// ErrUnauthorized is returned if the request's signature or token
+	// doesn't match the provider's secret, or no secret is configured for
+	// the provider
+	ErrUnauthorized = errors.New("webhook event failed verification")
+)
+
+// PushEvent is a push to a repository, received from a git provider's
+// webhook
+type PushEvent struct {
+	Provider string
+	// RepoURLs are the URLs of the pushed repository, e.g. its web and
+	// clone URLs
+	RepoURLs []string
+	// Refs are the pushed branches and tags, deleted refs are left out
+	Refs []PushedRef
+}
+
+// PushedRef is a branch or tag and the commit it was pushed to
+type PushedRef struct {
+	Branch string
+	Tag    string
+	Commit string
+}
+
+// ParsePushEvent detects the git provider of the webhook request, verifies
+// its signature or token with the provider's secret, as returned by
+// secret, and parses the push event. Events of providers without a secret
+// are rejected, as they can't be verified.
+func ParsePushEvent(r *http.Request, secret func(provider string) string) (*PushEvent, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	// Gitea sends GitHub and Gogs compatible headers as well
+	case r.Header.Get("X-Gitea-Event") != "":
+		if r.Header.Get("X-Gitea-Event") != "push" {
+			return nil, ErrIgnoredEvent
+		}
+		if err := verifyHMAC(body, r.Header.Get("X-Gitea-Signature"), secret(ProviderGitea)); err != nil {
+			return nil, err
+		}
+		return parseGitHubPush(ProviderGitea, body)
+	case r.Header.Get("X-GitHub-Event") != "":
+		if r.Header.Get("X-GitHub-Event") != "push" {
+			return nil, ErrIgnoredEvent
+		}
+		if err := verifyHMAC(body, strings.TrimPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256="), secret(ProviderGitHub)); err != nil {
+			return nil, err
+		}
+		return parseGitHubPush(ProviderGitHub, body)
+	case r.Header.Get("X-Gitlab-Event") != "":
+		switch r.Header.Get("X-Gitlab-Event") {
+		case "Push Hook", "Tag Push Hook":
+		default:
+			return nil, ErrIgnoredEvent
+		}
+		if err := verifyToken(r.Header.Get("X-Gitlab-Token"), secret(ProviderGitLab)); err != nil {
+			return nil, err
+		}
+		return parseGitLabPush(body)
+	case r.Header.Get("X-Event-Key") == "repo:push":
+		// Bitbucket Cloud signs events, if a secret is configured for
+		// the webhook, older webhooks are identified by their UUID
+		if sig := r.Header.Get("X-Hub-Signature"); sig != "" {
+			if err := verifyHMAC(body, strings.TrimPrefix(sig, "sha256="), secret(ProviderBitbucket)); err != nil {
+				return nil, err
+			}
+		} else if err := verifyToken(r.Header.Get("X-Hook-UUID"), secret(ProviderBitbucket)); err != nil {
+			return nil, err
+		}
+		return parseBitbucketPush(body)
+	case r.Header.Get("X-Event-Key") == "repo:refs_changed":
+		if err := verifyHMAC(body, strings.TrimPrefix(r.Header.Get("X-Hub-Signature"), "sha256="), secret(ProviderBitbucketServer)); err != nil {
+			return nil, err
+		}
+		return parseBitbucketServerPush(body)
+	case r.Header.Get("X-Vss-Activityid") != "":
+		// Azure DevOps service hooks authenticate with basic auth, the
+		// password is the secret
+		_, password, _ := r.BasicAuth()
+		if err := verifyToken(password, secret(ProviderAzureDevOps)); err != nil {
+			return nil, err
+		}
+		return parseAzureDevOpsPush(body)
+	}
+	return nil, ErrIgnoredEvent
+}
+
+// verifyHMAC checks the hex encoded HMAC-SHA256 signature of the body
+func verifyHMAC(body []byte, signature, secret string) error {
+	if secret == "" {
+		return ErrUnauthorized
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrUnauthorized
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+func verifyToken(token, secret string) error {
+	if secret == "" {
+		return ErrUnauthorized
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// pushedRef returns the branch or tag of a full ref, e.g. refs/heads/main
+func pushedRef(ref, commit string) (PushedRef, bool) {
+	if commit == "" || commit == zeroCommit {
+		return PushedRef{}, false
+	}
+	switch {
+	case strings.HasPrefix(ref, branchRefPrefix):
+		return PushedRef{Branch: strings.TrimPrefix(ref, branchRefPrefix), Commit: commit}, true
+	case strings.HasPrefix(ref, tagRefPrefix):
+		return PushedRef{Tag: strings.TrimPrefix(ref, tagRefPrefix), Commit: commit}, true
+	}
+	return PushedRef{}, false
+}
+
+func newPushEvent(provider string, urls ...string) *PushEvent {
+	event := &PushEvent{Provider: provider}
+	for _, u := range urls {
+		if u != "" {
+			event.RepoURLs = append(event.RepoURLs, u)
+		}
+	}
+	return event
+}
+
+// parseGitHubPush parses GitHub's push events, Gitea sends the same payload
+func parseGitHubPush(provider string, body []byte) (*PushEvent, error) {
+	var payload struct {
+		Ref        string `json:"ref"`
+		After      string `json:"after"`
+		Repository struct {
+			HTMLURL  string `json:"html_url"`
+			CloneURL string `json:"clone_url"`
+			SSHURL   string `json:"ssh_url"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid %s push event: %w", provider, err)
+	}
+
+	event := newPushEvent(provider, payload.Repository.HTMLURL, payload.Repository.CloneURL, payload.Repository.SSHURL)
+	if ref, ok := pushedRef(payload.Ref, payload.After); ok {
+		event.Refs = append(event.Refs, ref)
+	}
+	return event, nil
+}
+
+func parseGitLabPush(body []byte) (*PushEvent, error) {
+	var payload struct {
+		Ref         string `json:"ref"`
+		After       string `json:"after"`
+		CheckoutSHA string `json:"checkout_sha"`
+		Project     struct {
+			WebURL     string `json:"web_url"`
+			GitHTTPURL string `json:"git_http_url"`
+			GitSSHURL  string `json:"git_ssh_url"`
+		} `json:"project"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid gitlab push event: %w", err)
+	}
+
+	event := newPushEvent(ProviderGitLab, payload.Project.WebURL, payload.Project.GitHTTPURL, payload.Project.GitSSHURL)
+	commit := payload.CheckoutSHA
+	if commit == "" {
+		commit = payload.After
+	}
+	if ref, ok := pushedRef(payload.Ref, commit); ok {
+		event.Refs = append(event.Refs, ref)
+	}
+	return event, nil
+}
+
+func parseBitbucketPush(body []byte) (*PushEvent, error) {
+	var payload struct {
+		Push struct {
+			Changes []struct {
+				New *struct {
+					Type   string `json:"type"`
+					Name   string `json:"name"`
+					Target struct {
+						Hash string `json:"hash"`
+					} `json:"target"`
+				} `json:"new"`
+			} `json:"changes"`
+		} `json:"push"`
+		Repository struct {
+			FullName string `json:"full_name"`
+			Links    struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid bitbucket push event: %w", err)
+	}
+
+	event := newPushEvent(ProviderBitbucket, payload.Repository.Links.HTML.Href)
+	if payload.Repository.FullName != "" {
+		event.RepoURLs = append(event.RepoURLs, "git@bitbucket.org:"+payload.Repository.FullName+".git")
+	}
+	for _, change := range payload.Push.Changes {
+		// new is null for deleted branches and tags
+		if change.New == nil || change.New.Target.Hash == "" {
+			continue
+		}
+		switch change.New.Type {
+		case "branch":
+			event.Refs = append(event.Refs, PushedRef{Branch: change.New.Name, Commit: change.New.Target.Hash})
+		case "tag", "annotated_tag":
+			event.Refs = append(event.Refs, PushedRef{Tag: change.New.Name, Commit: change.New.Target.Hash})
+		}
+	}
+	return event, nil
+}
+
+func parseBitbucketServerPush(body []byte) (*PushEvent, error) {
+	var payload struct {
+		Changes []struct {
+			Ref struct {
+				ID string `json:"id"`
+			} `json:"ref"`
+			ToHash string `json:"toHash"`
+			Type   string `json:"type"`
+		} `json:"changes"`
+		Repository struct {
+			Links struct {
+				Clone []struct {
+					Href string `json:"href"`
+				} `json:"clone"`
+			} `json:"links"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid bitbucket server push event: %w", err)
+	}
+
+	event := newPushEvent(ProviderBitbucketServer)
+	for _, link := range payload.Repository.Links.Clone {
+		event.RepoURLs = append(event.RepoURLs, link.Href)
+	}
+	for _, change := range payload.Changes {
+		if change.Type == "DELETE" {
+			continue
+		}
+		if ref, ok := pushedRef(change.Ref.ID, change.ToHash); ok {
+			event.Refs = append(event.Refs, ref)
+		}
+	}
+	return event, nil
+}
+
+func parseAzureDevOpsPush(body []byte) (*PushEvent, error) {
+	var payload struct {
+		EventType string `json:"eventType"`
+		Resource  struct {
+			RefUpdates []struct {
+				Name        string `json:"name"`
+				NewObjectID string `json:"newObjectId"`
+			} `json:"refUpdates"`
+			Repository struct {
+				RemoteURL string `json:"remoteUrl"`
+				SSHURL    string `json:"sshUrl"`
+			} `json:"repository"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid azure devops push event: %w", err)
+	}
+	if payload.EventType != "git.push" {
+		return nil, ErrIgnoredEvent
+	}
+
+	event := newPushEvent(ProviderAzureDevOps, payload.Resource.Repository.RemoteURL, payload.Resource.Repository.SSHURL)
+	for _, update := range payload.Resource.RefUpdates {
+		if ref, ok := pushedRef(update.Name, update.NewObjectID); ok {
+			event.Refs = append(event.Refs, ref)
+		}
+	}
+	return event, nil
+}
+
+// Matches returns true, if the event is a push to the repo URL.
+// Schemes, users, ports and a ".git" suffix are ignored, so HTTP and SSH
+// URLs of the same repository match.
+func (e *PushEvent) Matches(repoURL string) bool {
+	repo := normalizeRepoURL(repoURL)
+	if repo == "" {
+		return false
+	}
+	for _, u := range e.RepoURLs {
+		if normalizeRepoURL(u) == repo {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeRepoURL(repoURL string) string {
+	u, err := parseRepoURL(repoURL)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	// Bitbucket Server serves HTTP clones below /scm, Azure DevOps' SSH
+	// URLs have a version prefix and no _git segment
+	path = strings.TrimPrefix(path, "scm/")
+	path = strings.TrimPrefix(path, "v3/")
+	path = strings.Replace(path, "/_git/", "/", 1)
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "ssh.")
+	return host + "/" + strings.ToLower(path)
+}
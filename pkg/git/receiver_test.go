@@ -0,0 +1,176 @@
+package git
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sign(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestParsePushEvent(t *testing.T) {
+	secrets := map[string]string{
+		ProviderGitHub:          "gh-secret",
+		ProviderGitLab:          "gl-secret",
+		ProviderGitea:           "gitea-secret",
+		ProviderBitbucketServer: "bbs-secret",
+		ProviderAzureDevOps:     "azure-secret",
+		ProviderBitbucket:       "uuid",
+	}
+	secret := func(provider string) string { return secrets[provider] }
+
+	gitHubBody := `{"ref":"refs/heads/main","after":"abc","repository":{"html_url":"https://github.com/org/repo","ssh_url":"git@github.com:org/repo.git"}}`
+	bitbucketServerBody := `{"changes":[{"ref":{"id":"refs/tags/v1.0.0"},"toHash":"def","type":"ADD"},{"ref":{"id":"refs/heads/old"},"toHash":"0000000000000000000000000000000000000000","type":"DELETE"}],
+		"repository":{"links":{"clone":[{"href":"https://bitbucket.example.com/scm/proj/repo.git","name":"http"},{"href":"ssh://git@bitbucket.example.com:7999/proj/repo.git","name":"ssh"}]}}}`
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    string
+		auth    string
+		want    *PushEvent
+		err     error
+	}{
+		{
+			name:    "github",
+			headers: map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(gitHubBody, "gh-secret")},
+			body:    gitHubBody,
+			want: &PushEvent{Provider: ProviderGitHub, RepoURLs: []string{"https://github.com/org/repo", "git@github.com:org/repo.git"},
+				Refs: []PushedRef{{Branch: "main", Commit: "abc"}}},
+		},
+		{
+			name:    "github with wrong signature",
+			headers: map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(gitHubBody, "other")},
+			body:    gitHubBody,
+			err:     ErrUnauthorized,
+		},
+		{
+			name:    "github ping",
+			headers: map[string]string{"X-GitHub-Event": "ping"},
+			body:    `{}`,
+			err:     ErrIgnoredEvent,
+		},
+		{
+			name:    "gitea",
+			headers: map[string]string{"X-Gitea-Event": "push", "X-GitHub-Event": "push", "X-Gitea-Signature": sign(gitHubBody, "gitea-secret")},
+			body:    gitHubBody,
+			want: &PushEvent{Provider: ProviderGitea, RepoURLs: []string{"https://github.com/org/repo", "git@github.com:org/repo.git"},
+				Refs: []PushedRef{{Branch: "main", Commit: "abc"}}},
+		},
+		{
+			name:    "gitlab tag",
+			headers: map[string]string{"X-Gitlab-Event": "Tag Push Hook", "X-Gitlab-Token": "gl-secret"},
+			body:    `{"ref":"refs/tags/v1","checkout_sha":"abc","project":{"web_url":"https://gitlab.com/group/repo"}}`,
+			want:    &PushEvent{Provider: ProviderGitLab, RepoURLs: []string{"https://gitlab.com/group/repo"}, Refs: []PushedRef{{Tag: "v1", Commit: "abc"}}},
+		},
+		{
+			name:    "gitlab with wrong token",
+			headers: map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "wrong"},
+			body:    `{}`,
+			err:     ErrUnauthorized,
+		},
+		{
+			name:    "bitbucket cloud identified by its uuid",
+			headers: map[string]string{"X-Event-Key": "repo:push", "X-Hook-UUID": "uuid"},
+			body:    `{"push":{"changes":[{"new":{"type":"branch","name":"main","target":{"hash":"abc"}}},{"new":null}]},"repository":{"full_name":"org/repo","links":{"html":{"href":"https://bitbucket.org/org/repo"}}}}`,
+			want: &PushEvent{Provider: ProviderBitbucket, RepoURLs: []string{"https://bitbucket.org/org/repo", "git@bitbucket.org:org/repo.git"},
+				Refs: []PushedRef{{Branch: "main", Commit: "abc"}}},
+		},
+		{
+			name:    "bitbucket server",
+			headers: map[string]string{"X-Event-Key": "repo:refs_changed", "X-Hub-Signature": "sha256=" + sign(bitbucketServerBody, "bbs-secret")},
+			body:    bitbucketServerBody,
+			want: &PushEvent{Provider: ProviderBitbucketServer, RepoURLs: []string{"https://bitbucket.example.com/scm/proj/repo.git", "ssh://git@bitbucket.example.com:7999/proj/repo.git"},
+				Refs: []PushedRef{{Tag: "v1.0.0", Commit: "def"}}},
+		},
+		{
+			name:    "azure devops",
+			headers: map[string]string{"X-Vss-Activityid": "1"},
+			auth:    "azure-secret",
+			body:    `{"eventType":"git.push","resource":{"refUpdates":[{"name":"refs/heads/main","newObjectId":"abc"}],"repository":{"remoteUrl":"https://dev.azure.com/org/proj/_git/repo"}}}`,
+			want:    &PushEvent{Provider: ProviderAzureDevOps, RepoURLs: []string{"https://dev.azure.com/org/proj/_git/repo"}, Refs: []PushedRef{{Branch: "main", Commit: "abc"}}},
+		},
+		{
+			name:    "azure devops with wrong password",
+			headers: map[string]string{"X-Vss-Activityid": "1"},
+			auth:    "wrong",
+			body:    `{"eventType":"git.push"}`,
+			err:     ErrUnauthorized,
+		},
+		{
+			name: "unknown provider",
+			body: `{}`,
+			err:  ErrIgnoredEvent,
+		},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		if tt.auth != "" {
+			r.SetBasicAuth("fleet", tt.auth)
+		}
+		event, err := ParsePushEvent(r, secret)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: expected error %v, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(event, tt.want) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, event)
+		}
+	}
+}
+
+func TestParsePushEventWithoutSecret(t *testing.T) {
+	body := `{"ref":"refs/heads/main","after":"abc","repository":{"html_url":"https://github.com/org/repo"}}`
+	for name, headers := range map[string]map[string]string{
+		"github":       {"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(body, "")},
+		"gitlab":       {"X-Gitlab-Event": "Push Hook"},
+		"bitbucket":    {"X-Event-Key": "repo:push"},
+		"azure devops": {"X-Vss-Activityid": "1"},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		if _, err := ParsePushEvent(r, func(string) string { return "" }); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("%s: expected events without a secret to be rejected, got %v", name, err)
+		}
+	}
+}
+
+func TestPushEventMatches(t *testing.T) {
+	for _, tt := range []struct {
+		eventURL string
+		repo     string
+		want     bool
+	}{
+		{eventURL: "https://github.com/org/repo", repo: "git@github.com:org/repo.git", want: true},
+		{eventURL: "https://GitHub.com/Org/Repo", repo: "https://github.com/org/repo.git", want: true},
+		{eventURL: "https://github.com/org/repo", repo: "https://github.com/org/repo-other", want: false},
+		{eventURL: "https://bitbucket.example.com/scm/proj/repo.git", repo: "ssh://git@bitbucket.example.com:7999/proj/repo.git", want: true},
+		{eventURL: "https://org@dev.azure.com/org/proj/_git/repo", repo: "git@ssh.dev.azure.com:v3/org/proj/repo", want: true},
+	} {
+		event := &PushEvent{RepoURLs: []string{tt.eventURL}}
+		if got := event.Matches(tt.repo); got != tt.want {
+			t.Errorf("expected %s matching %s to be %v", tt.eventURL, tt.repo, tt.want)
+		}
+	}
+}
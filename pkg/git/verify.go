@@ -0,0 +1,175 @@
+package git
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	pgpKeyHeader       = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	sshSignatureHeader = "-----BEGIN SSH SIGNATURE-----"
+	// sshSigMagic prefixes SSH signatures and the data they sign
+	sshSigMagic = "SSHSIG"
+	// sshSigNamespace is the namespace git signs commits in
+	sshSigNamespace = "git"
+)
+
+var (
+	ErrUnsigned  = errors.New("commit is not signed")
+	ErrUntrusted = errors.New("commit is not signed by a trusted key")
+)
+
+// TrustedKeys are the public keys, which may sign commits
+type TrustedKeys struct {
+	// pgp are armored PGP key rings
+	pgp []string
+	ssh []ssh.PublicKey
+}
+
+// ParseTrustedKeys reads armored PGP public keys and SSH public keys, in the
+// authorized_keys format, one per line.
+func ParseTrustedKeys(files ...[]byte) (*TrustedKeys, error) {
+	keys := &TrustedKeys{}
+	for _, data := range files {
+		if bytes.Contains(data, []byte(pgpKeyHeader)) {
+			keys.pgp = append(keys.pgp, string(data))
+			continue
+		}
+		for rest := bytes.TrimSpace(data); len(rest) > 0; rest = bytes.TrimSpace(rest) {
+			key, _, _, next, err := ssh.ParseAuthorizedKey(rest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid public key: %w", err)
+			}
+			keys.ssh = append(keys.ssh, key)
+			rest = next
+		}
+	}
+	if len(keys.pgp) == 0 && len(keys.ssh) == 0 {
+		return nil, errors.New("no trusted public keys")
+	}
+	return keys, nil
+}
+
+// VerifyCommit checks that the commit of the repository in dir is signed by
+// one of the trusted keys, with a PGP or an SSH signature. It returns the
+// ID of the PGP key or the fingerprint of the SSH key, which signed it. An
+// empty commit verifies HEAD.
+func VerifyCommit(dir, commit string, keys *TrustedKeys) (string, error) {
+	repo, err := gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", err
+	}
+	commitHash := plumbing.NewHash(commit)
+	if commit == "" {
+		head, err := repo.Head()
+		if err != nil {
+			return "", err
+		}
+		commitHash = head.Hash()
+	}
+	c, err := repo.CommitObject(commitHash)
+	if err != nil {
+		return "", err
+	}
+	if c.PGPSignature == "" {
+		return "", ErrUnsigned
+	}
+
+	if strings.HasPrefix(c.PGPSignature, sshSignatureHeader) {
+		encoded := &plumbing.MemoryObject{}
+		if err := c.EncodeWithoutSignature(encoded); err != nil {
+			return "", err
+		}
+		r, err := encoded.Reader()
+		if err != nil {
+			return "", err
+		}
+		message, err := io.ReadAll(r)
+		if err != nil {
+			return "", err
+		}
+		return verifySSHSignature(c.PGPSignature, message, keys.ssh)
+	}
+
+	for _, ring := range keys.pgp {
+		if entity, err := c.Verify(ring); err == nil {
+			return entity.PrimaryKey.KeyIdString(), nil
+		}
+	}
+	return "", ErrUntrusted
+}
+
+// verifySSHSignature verifies an armored SSH signature of the message, as
+// created by "ssh-keygen -Y sign -n git", against the trusted keys.
+func verifySSHSignature(armored string, message []byte, trusted []ssh.PublicKey) (string, error) {
+	block, _ := pem.Decode([]byte(armored))
+	if block == nil || block.Type != "SSH SIGNATURE" || !bytes.HasPrefix(block.Bytes, []byte(sshSigMagic)) {
+		return "", errors.New("invalid SSH signature")
+	}
+	var sig struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}
+	if err := ssh.Unmarshal(block.Bytes[len(sshSigMagic):], &sig); err != nil {
+		return "", fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	if sig.Namespace != sshSigNamespace {
+		return "", fmt.Errorf("invalid SSH signature namespace %q", sig.Namespace)
+	}
+
+	pub, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	trustedKey := false
+	for _, k := range trusted {
+		if bytes.Equal(k.Marshal(), pub.Marshal()) {
+			trustedKey = true
+			break
+		}
+	}
+	if !trustedKey {
+		return "", ErrUntrusted
+	}
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported SSH signature hash algorithm %q", sig.HashAlgorithm)
+	}
+	h.Write(message)
+
+	signature := &ssh.Signature{}
+	if err := ssh.Unmarshal(sig.Signature, signature); err != nil {
+		return "", fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	signed := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sig.Namespace, sig.Reserved, sig.HashAlgorithm, h.Sum(nil)})...)
+	if err := pub.Verify(signed, signature); err != nil {
+		return "", ErrUntrusted
+	}
+	return ssh.FingerprintSHA256(pub), nil
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/pem"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshSign signs the message like "ssh-keygen -Y sign -n git"
+func sshSign(t *testing.T, signer ssh.Signer, message []byte) string {
+	h := sha512.Sum512(message)
+	signed := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sshSigNamespace, "", "sha512", h[:]})...)
+	sig, err := signer.Sign(rand.Reader, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}{1, signer.PublicKey().Marshal(), sshSigNamespace, "", "sha512", ssh.Marshal(sig)})...)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "SSH SIGNATURE", Bytes: blob}))
+}
+
+// signedCommit stores a commit in a new repository, signed by the signer, if
+// it's not nil
+func signedCommit(t *testing.T, signer ssh.Signer) (string, string) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := repo.Storer.NewEncodedObject()
+	if err := (&object.Tree{}).Encode(tree); err != nil {
+		t.Fatal(err)
+	}
+	treeHash, err := repo.Storer.SetEncodedObject(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig := object.Signature{Name: "fleet", Email: "fleet@example.com", When: time.Unix(0, 0)}
+	c := &object.Commit{Author: sig, Committer: sig, Message: "test\n", TreeHash: treeHash}
+	if signer != nil {
+		unsigned := &plumbing.MemoryObject{}
+		if err := c.EncodeWithoutSignature(unsigned); err != nil {
+			t.Fatal(err)
+		}
+		r, _ := unsigned.Reader()
+		message, _ := io.ReadAll(r)
+		c.PGPSignature = sshSign(t, signer, message)
+	}
+	obj := repo.Storer.NewEncodedObject()
+	if err := c.Encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/master", hash)); err != nil {
+		t.Fatal(err)
+	}
+	return dir, hash.String()
+}
+
+func newSigner(t *testing.T) ssh.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestVerifyCommit(t *testing.T) {
+	trusted, untrusted := newSigner(t), newSigner(t)
+	keys, err := ParseTrustedKeys([]byte("# ci\n" + string(ssh.MarshalAuthorizedKey(trusted.PublicKey()))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, hash := signedCommit(t, trusted)
+	signer, err := VerifyCommit(dir, hash, keys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if signer != ssh.FingerprintSHA256(trusted.PublicKey()) {
+		t.Errorf("expected signer %s, got %s", ssh.FingerprintSHA256(trusted.PublicKey()), signer)
+	}
+	if _, err := VerifyCommit(dir, "", keys); err != nil {
+		t.Errorf("unexpected error verifying HEAD: %v", err)
+	}
+
+	dir, hash = signedCommit(t, untrusted)
+	if _, err := VerifyCommit(dir, hash, keys); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("expected %v, got %v", ErrUntrusted, err)
+	}
+
+	dir, hash = signedCommit(t, nil)
+	if _, err := VerifyCommit(dir, hash, keys); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("expected %v, got %v", ErrUnsigned, err)
+	}
+}
+
+func TestParseTrustedKeys(t *testing.T) {
+	if _, err := ParseTrustedKeys([]byte("\n")); err == nil {
+		t.Error("expected an error without keys")
+	}
+	if _, err := ParseTrustedKeys([]byte("not a key")); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+}
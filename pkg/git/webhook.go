@@ -0,0 +1,332 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+)
+
+// Webhook is a push webhook registered at a git provider
+type Webhook struct {
+	ID string
+	// Healthy is false if the provider reported failed deliveries
+	Healthy bool
+	Message string
+}
+
+// WebhookClient manages the push webhooks of a repository through the API
+// of its git provider
+type WebhookClient interface {
+	// EnsureWebhook creates or updates the webhook sending push events to
+	// url. It is looked up by its ID, if known, or by its URL.
+	EnsureWebhook(ctx context.Context, id, url, secret string) (*Webhook, error)
+	// DeleteWebhook deletes the webhook, it succeeds if the webhook
+	// doesn't exist
+	DeleteWebhook(ctx context.Context, id string) error
+}
+
+// DetectProvider guesses the git provider from the host of the repo URL
+func DetectProvider(repoURL string) string {
+	u, err := parseRepoURL(repoURL)
+	if err != nil {
+		return ""
+	}
+	host := u.Hostname()
+	switch {
+	case strings.Contains(host, "github"):
+		return ProviderGitHub
+	case strings.Contains(host, "gitlab"):
+		return ProviderGitLab
+	case strings.Contains(host, "gitea"):
+		return ProviderGitea
+	}
+	return ""
+}
+
+// NewWebhookClient returns a client for the provider's API, authenticated
+// with the token
+func NewWebhookClient(provider, repoURL, token string) (WebhookClient, error) {
+	u, err := parseRepoURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	repoPath := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if strings.Count(repoPath, "/") < 1 {
+		return nil, fmt.Errorf("cannot find the repository in %s", repoURL)
+	}
+
+	api := &apiClient{
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+	switch provider {
+	case ProviderGitHub:
+		base, err := gitHubAPIURL(repoURL)
+		if err != nil {
+			return nil, err
+		}
+		api.authHeader = "Bearer " + token
+		return &gitHubHooks{api: api, hooksURL: base + "/repos/" + repoPath + "/hooks"}, nil
+	case ProviderGitLab:
+		api.authHeader = "Bearer " + token
+		return &gitLabHooks{api: api, hooksURL: apiBaseURL(u) + "/api/v4/projects/" + url.PathEscape(repoPath) + "/hooks"}, nil
+	case ProviderGitea:
+		api.authHeader = "token " + token
+		return &gitHubHooks{api: api, hooksURL: apiBaseURL(u) + "/api/v1/repos/" + repoPath + "/hooks", gitea: true}, nil
+	}
+	return nil, fmt.Errorf("unsupported git provider %q", provider)
+}
+
+// parseRepoURL parses URLs and scp-like SSH addresses, e.g.
+// git@github.com:rancher/fleet.git
+func parseRepoURL(repoURL string) (*url.URL, error) {
+	if !strings.Contains(repoURL, "://") {
+		if at := strings.Index(repoURL, "@"); at >= 0 {
+			if colon := strings.Index(repoURL[at:], ":"); colon > 0 {
+				repoURL = "ssh://" + repoURL[:at+colon] + "/" + repoURL[at+colon+1:]
+			}
+		}
+	}
+	return url.Parse(repoURL)
+}
+
+// apiBaseURL returns the base URL of the provider's API, which uses the
+// same scheme as the repo URL, if it is HTTP based
+func apiBaseURL(u *url.URL) string {
+	scheme := "https"
+	if u.Scheme == "http" {
+		scheme = "http"
+	}
+	return scheme + "://" + u.Host
+}
+
+type apiClient struct {
+	client     *http.Client
+	authHeader string
+}
+
+// do sends the request and decodes the response into out, if set. It
+// returns the status code of the response.
+func (c *apiClient) do(ctx context.Context, method, url string, in, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", c.authHeader)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.StatusCode, nil
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("%s %s: %s: %s", method, url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode, nil
+}
+
+// gitHubHooks manages GitHub webhooks, Gitea implements the same API
+type gitHubHooks struct {
+	api      *apiClient
+	hooksURL string
+	gitea    bool
+}
+
+type gitHubHook struct {
+	ID           int64             `json:"id,omitempty"`
+	Type         string            `json:"type,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	Active       bool              `json:"active"`
+	Events       []string          `json:"events"`
+	Config       map[string]string `json:"config"`
+	LastResponse *struct {
+		Code    *int   `json:"code"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"last_response,omitempty"`
+}
+
+func (h *gitHubHooks) EnsureWebhook(ctx context.Context, id, hookURL, secret string) (*Webhook, error) {
+	existing, err := h.find(ctx, id, hookURL)
+	if err != nil {
+		return nil, err
+	}
+
+	hook := gitHubHook{
+		Active: true,
+		Events: []string{"push"},
+		Config: map[string]string{
+			"url":          hookURL,
+			"content_type": "json",
+			"secret":       secret,
+		},
+	}
+	if h.gitea {
+		hook.Type = "gitea"
+	} else {
+		hook.Name = "web"
+		hook.Config["insecure_ssl"] = "0"
+	}
+
+	result := &gitHubHook{}
+	if existing == nil {
+		_, err = h.api.do(ctx, http.MethodPost, h.hooksURL, hook, result)
+	} else {
+		hook.Type = ""
+		var status int
+		status, err = h.api.do(ctx, http.MethodPatch, h.hookURL(existing.ID), hook, result)
+		if status == http.StatusNotFound {
+			return nil, fmt.Errorf("webhook %d was deleted while updating it", existing.ID)
+		}
+		// the update response doesn't include the delivery state
+		result.LastResponse = existing.LastResponse
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &Webhook{ID: strconv.FormatInt(result.ID, 10), Healthy: true}
+	if r := result.LastResponse; r != nil && r.Status != "" && r.Status != "active" && r.Status != "unused" {
+		webhook.Healthy = false
+		webhook.Message = fmt.Sprintf("last delivery %s: %s", r.Status, r.Message)
+	}
+	return webhook, nil
+}
+
+func (h *gitHubHooks) find(ctx context.Context, id, hookURL string) (*gitHubHook, error) {
+	if id != "" {
+		hook := &gitHubHook{}
+		status, err := h.api.do(ctx, http.MethodGet, h.hooksURL+"/"+id, nil, hook)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusNotFound {
+			return hook, nil
+		}
+	}
+
+	var hooks []gitHubHook
+	if _, err := h.api.do(ctx, http.MethodGet, h.hooksURL+"?per_page=100", nil, &hooks); err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		if hooks[i].Config["url"] == hookURL {
+			return &hooks[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (h *gitHubHooks) hookURL(id int64) string {
+	return h.hooksURL + "/" + strconv.FormatInt(id, 10)
+}
+
+func (h *gitHubHooks) DeleteWebhook(ctx context.Context, id string) error {
+	_, err := h.api.do(ctx, http.MethodDelete, h.hooksURL+"/"+id, nil, nil)
+	return err
+}
+
+// gitLabHooks manages GitLab project hooks
+type gitLabHooks struct {
+	api      *apiClient
+	hooksURL string
+}
+
+type gitLabHook struct {
+	ID                    int64  `json:"id,omitempty"`
+	URL                   string `json:"url"`
+	Token                 string `json:"token,omitempty"`
+	PushEvents            bool   `json:"push_events"`
+	TagPushEvents         bool   `json:"tag_push_events"`
+	EnableSSLVerification bool   `json:"enable_ssl_verification"`
+	AlertStatus           string `json:"alert_status,omitempty"`
+}
+
+func (h *gitLabHooks) EnsureWebhook(ctx context.Context, id, hookURL, secret string) (*Webhook, error) {
+	var existing *gitLabHook
+	if id != "" {
+		hook := &gitLabHook{}
+		status, err := h.api.do(ctx, http.MethodGet, h.hooksURL+"/"+id, nil, hook)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusNotFound {
+			existing = hook
+		}
+	}
+	if existing == nil {
+		var hooks []gitLabHook
+		if _, err := h.api.do(ctx, http.MethodGet, h.hooksURL+"?per_page=100", nil, &hooks); err != nil {
+			return nil, err
+		}
+		for i := range hooks {
+			if hooks[i].URL == hookURL {
+				existing = &hooks[i]
+				break
+			}
+		}
+	}
+
+	hook := gitLabHook{
+		URL:                   hookURL,
+		Token:                 secret,
+		PushEvents:            true,
+		TagPushEvents:         true,
+		EnableSSLVerification: true,
+	}
+	result := &gitLabHook{}
+	var err error
+	if existing == nil {
+		_, err = h.api.do(ctx, http.MethodPost, h.hooksURL, hook, result)
+	} else {
+		_, err = h.api.do(ctx, http.MethodPut, h.hooksURL+"/"+strconv.FormatInt(existing.ID, 10), hook, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &Webhook{ID: strconv.FormatInt(result.ID, 10), Healthy: true}
+	if result.AlertStatus != "" && result.AlertStatus != "executable" {
+		webhook.Healthy = false
+		webhook.Message = "webhook is " + strings.ReplaceAll(result.AlertStatus, "_", " ")
+	}
+	return webhook, nil
+}
+
+func (h *gitLabHooks) DeleteWebhook(ctx context.Context, id string) error {
+	_, err := h.api.do(ctx, http.MethodDelete, h.hooksURL+"/"+id, nil, nil)
+	return err
+}
@@ -0,0 +1,148 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectProvider(t *testing.T) {
+	for repo, expected := range map[string]string{
+		"https://github.com/rancher/fleet":          ProviderGitHub,
+		"git@github.com:rancher/fleet.git":          ProviderGitHub,
+		"https://gitlab.example.com/group/repo.git": ProviderGitLab,
+		"https://gitea.example.com/org/repo":        ProviderGitea,
+		"https://git.example.com/org/repo":          "",
+	} {
+		if p := DetectProvider(repo); p != expected {
+			t.Errorf("expected provider %q for %s, got %q", expected, repo, p)
+		}
+	}
+}
+
+func TestGitHubWebhook(t *testing.T) {
+	hooks := map[int64]*gitHubHook{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret-token" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repos/org/repo/hooks":
+			var list []*gitHubHook
+			for _, h := range hooks {
+				list = append(list, h)
+			}
+			_ = json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/repos/org/repo/hooks":
+			hook := &gitHubHook{}
+			_ = json.NewDecoder(r.Body).Decode(hook)
+			if hook.Type != "gitea" || hook.Config["secret"] != "hook-secret" {
+				t.Errorf("unexpected hook %+v", hook)
+			}
+			hook.ID = int64(len(hooks) + 1)
+			hooks[hook.ID] = hook
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(hook)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repos/org/repo/hooks/1":
+			_ = json.NewEncoder(w).Encode(hooks[1])
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v1/repos/org/repo/hooks/1":
+			hook := &gitHubHook{}
+			_ = json.NewDecoder(r.Body).Decode(hook)
+			hook.ID = 1
+			hooks[1] = hook
+			_ = json.NewEncoder(w).Encode(hook)
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v1/repos/org/repo/hooks/1":
+			delete(hooks, 1)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client, err := NewWebhookClient(ProviderGitea, server.URL+"/org/repo.git", "secret-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hook, err := client.EnsureWebhook(ctx, "", "https://fleet.example.com/", "hook-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.ID != "1" || !hook.Healthy || len(hooks) != 1 {
+		t.Fatalf("unexpected webhook %+v, hooks %v", hook, hooks)
+	}
+
+	// the existing webhook is found by its URL and updated
+	if hook, err = client.EnsureWebhook(ctx, "", "https://fleet.example.com/", "hook-secret"); err != nil || hook.ID != "1" {
+		t.Fatalf("expected existing webhook to be updated, got %+v, %v", hook, err)
+	}
+	// or by its ID, if the receiver URL changed
+	if hook, err = client.EnsureWebhook(ctx, "1", "https://new.example.com/", "hook-secret"); err != nil || hook.ID != "1" {
+		t.Fatalf("expected webhook to be updated by ID, got %+v, %v", hook, err)
+	}
+	if hooks[1].Config["url"] != "https://new.example.com/" || len(hooks) != 1 {
+		t.Errorf("expected URL to be updated, got %v", hooks[1].Config)
+	}
+
+	if err := client.DeleteWebhook(ctx, "1"); err != nil || len(hooks) != 0 {
+		t.Errorf("expected webhook to be deleted, got %v", err)
+	}
+	if err := client.DeleteWebhook(ctx, "1"); err != nil {
+		t.Errorf("expected deleting a missing webhook to succeed, got %v", err)
+	}
+}
+
+func TestGitLabWebhookHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Frepo/hooks" {
+			t.Errorf("unexpected path %s", r.URL.EscapedPath())
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode([]gitLabHook{})
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(gitLabHook{ID: 5, AlertStatus: "temporarily_disabled"})
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewWebhookClient(ProviderGitLab, server.URL+"/group/repo", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook, err := client.EnsureWebhook(context.Background(), "", "https://fleet.example.com/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.ID != "5" || hook.Healthy || hook.Message != "webhook is temporarily disabled" {
+		t.Errorf("unexpected webhook %+v", hook)
+	}
+}
+
+func TestSetGitHubCommitStatus(t *testing.T) {
+	var got CommitStatus
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer app-token" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v3/repos/org/repo/statuses/abc123" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	status := CommitStatus{State: CommitStateSuccess, Context: "fleet/repo", Description: "1/1 ready"}
+	if err := SetGitHubCommitStatus(context.Background(), server.URL+"/org/repo.git", "app-token", "abc123", status); err != nil {
+		t.Fatal(err)
+	}
+	if got != status {
+		t.Errorf("unexpected status %+v", got)
+	}
+}
@@ -0,0 +1,80 @@
+// Package gitrepodefaults applies the GitRepoDefaults of a namespace to the GitRepos in it. Values set on a GitRepo take precedence.
+package gitrepodefaults
+
+import (
+	"sort"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Inherit returns the gitrepo, with the fields, which are empty, set to the
+// values of the GitRepoDefaults in its namespace. The gitrepo is copied, if
+// any defaults exist.
+func Inherit(cache fleetcontrollers.GitRepoDefaultsCache, gitrepo *fleet.GitRepo) (*fleet.GitRepo, error) {
+	defaults, err := cache.List(gitrepo.Namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	if len(defaults) == 0 {
+		return gitrepo, nil
+	}
+
+	return Apply(gitrepo, Aggregate(defaults)), nil
+}
+
+// Apply returns a copy of the gitrepo, with the fields, which are empty,
+// set to the defaults.
+func Apply(gitrepo *fleet.GitRepo, defaults fleet.GitRepoDefaultsSpec) *fleet.GitRepo {
+	gitrepo = gitrepo.DeepCopy()
+	if gitrepo.Spec.ClientSecretName == "" {
+		gitrepo.Spec.ClientSecretName = defaults.ClientSecretName
+	}
+	if gitrepo.Spec.HelmSecretName == "" && gitrepo.Spec.HelmSecretNameForPaths == "" {
+		gitrepo.Spec.HelmSecretName = defaults.HelmSecretName
+	}
+	if len(gitrepo.Spec.CABundle) == 0 {
+		gitrepo.Spec.CABundle = defaults.CABundle
+	}
+	if gitrepo.Spec.PollingInterval == nil {
+		gitrepo.Spec.PollingInterval = defaults.PollingInterval
+	}
+	if gitrepo.Spec.Proxy == nil {
+		gitrepo.Spec.Proxy = defaults.Proxy
+	}
+	if gitrepo.Spec.Webhook != nil && gitrepo.Spec.Webhook.ClientSecretName == "" {
+		gitrepo.Spec.Webhook.ClientSecretName = defaults.WebhookClientSecretName
+	}
+	return gitrepo
+}
+
+// Aggregate merges the defaults, ordered by name. The first non-empty value
+// wins.
+func Aggregate(defaults []*fleet.GitRepoDefaults) (result fleet.GitRepoDefaultsSpec) {
+	sort.Slice(defaults, func(i, j int) bool {
+		return defaults[i].Name < defaults[j].Name
+	})
+	for _, d := range defaults {
+		if result.ClientSecretName == "" {
+			result.ClientSecretName = d.Spec.ClientSecretName
+		}
+		if result.HelmSecretName == "" {
+			result.HelmSecretName = d.Spec.HelmSecretName
+		}
+		if len(result.CABundle) == 0 {
+			result.CABundle = d.Spec.CABundle
+		}
+		if result.PollingInterval == nil {
+			result.PollingInterval = d.Spec.PollingInterval
+		}
+		if result.Proxy == nil {
+			result.Proxy = d.Spec.Proxy
+		}
+		if result.WebhookClientSecretName == "" {
+			result.WebhookClientSecretName = d.Spec.WebhookClientSecretName
+		}
+	}
+	return
+}
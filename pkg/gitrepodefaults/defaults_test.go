@@ -0,0 +1,58 @@
+package gitrepodefaults
+
+import (
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApply(t *testing.T) {
+	defaults := Aggregate([]*fleet.GitRepoDefaults{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "b"},
+			Spec: fleet.GitRepoDefaultsSpec{
+				ClientSecretName:        "secret-b",
+				HelmSecretName:          "helm-b",
+				WebhookClientSecretName: "webhook-b",
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a"},
+			Spec: fleet.GitRepoDefaultsSpec{
+				ClientSecretName: "secret-a",
+				PollingInterval:  &metav1.Duration{Duration: time.Minute},
+				Proxy:            &fleet.ProxyConfig{HTTPSProxy: "http://proxy:3128"},
+			},
+		},
+	})
+
+	gitrepo := &fleet.GitRepo{Spec: fleet.GitRepoSpec{HelmSecretName: "own", Webhook: &fleet.WebhookRegistration{}}}
+	result := Apply(gitrepo, defaults)
+
+	if result.Spec.ClientSecretName != "secret-a" {
+		t.Errorf("expected clientSecretName secret-a, got %s", result.Spec.ClientSecretName)
+	}
+	if result.Spec.HelmSecretName != "own" {
+		t.Errorf("expected helmSecretName to be kept, got %s", result.Spec.HelmSecretName)
+	}
+	if result.Spec.PollingInterval == nil || result.Spec.PollingInterval.Duration != time.Minute {
+		t.Errorf("expected pollingInterval 1m, got %v", result.Spec.PollingInterval)
+	}
+	if result.Spec.Proxy == nil || result.Spec.Proxy.HTTPSProxy != "http://proxy:3128" {
+		t.Errorf("expected proxy to be inherited, got %v", result.Spec.Proxy)
+	}
+	if result.Spec.Webhook.ClientSecretName != "webhook-b" {
+		t.Errorf("expected webhook clientSecretName webhook-b, got %s", result.Spec.Webhook.ClientSecretName)
+	}
+	if gitrepo.Spec.ClientSecretName != "" || gitrepo.Spec.Webhook.ClientSecretName != "" {
+		t.Errorf("expected original gitrepo to be unchanged")
+	}
+
+	// webhooks are not registered, unless requested
+	if result := Apply(&fleet.GitRepo{}, defaults); result.Spec.Webhook != nil {
+		t.Errorf("expected no webhook registration, got %v", result.Spec.Webhook)
+	}
+}
@@ -0,0 +1,155 @@
+package helmdeployer
+
+import (
+	"sort"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/releaseutil"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// concurrentClient is a helm kube client, which applies independent resources
+// in parallel with bounded concurrency. The resources are applied kind by
+// kind in helm's install order, e.g. Namespaces and CRDs first, and only
+// resources of the same kind are applied in parallel. Helm's client updates
+// resources one by one and creates all resources of a kind at once.
+type concurrentClient struct {
+	*kube.Client
+	concurrency int
+}
+
+func (c *concurrentClient) Create(resources kube.ResourceList) (*kube.Result, error) {
+	result := &kube.Result{}
+	for _, group := range groupByInstallOrder(resources) {
+		err := c.parallel(group, func(chunk kube.ResourceList) (*kube.Result, error) {
+			return c.Client.Create(chunk)
+		}, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (c *concurrentClient) Update(original, target kube.ResourceList, force bool) (*kube.Result, error) {
+	result := &kube.Result{}
+	for _, group := range groupByInstallOrder(target) {
+		err := c.parallel(group, func(chunk kube.ResourceList) (*kube.Result, error) {
+			return c.Client.Update(original.Intersect(chunk), chunk, force)
+		}, result)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// deletes the resources, which are no longer part of the release
+	if removed := original.Difference(target); len(removed) > 0 {
+		res, err := c.Client.Update(removed, kube.ResourceList{}, force)
+		mergeResult(result, res)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// parallel splits the resources into chunks, one per worker, and runs fn for
+// each chunk concurrently.
+func (c *concurrentClient) parallel(resources kube.ResourceList, fn func(kube.ResourceList) (*kube.Result, error), result *kube.Result) error {
+	if len(resources) == 0 {
+		return nil
+	}
+
+	chunks := make([]kube.ResourceList, c.concurrency)
+	for i, info := range resources {
+		chunks[i%c.concurrency] = append(chunks[i%c.concurrency], info)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		firstErr error
+	)
+	for _, chunk := range chunks {
+		if len(chunk) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(chunk kube.ResourceList) {
+			defer wg.Done()
+			res, err := fn(chunk)
+
+			lock.Lock()
+			defer lock.Unlock()
+			mergeResult(result, res)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}(chunk)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+func mergeResult(result, res *kube.Result) {
+	if res == nil {
+		return
+	}
+	result.Created = append(result.Created, res.Created...)
+	result.Updated = append(result.Updated, res.Updated...)
+	result.Deleted = append(result.Deleted, res.Deleted...)
+}
+
+// groupByInstallOrder splits the resources into groups of the same kind,
+// sorted by helm's install order. Unknown kinds are grouped last.
+func groupByInstallOrder(resources kube.ResourceList) []kube.ResourceList {
+	var (
+		groups = map[int]kube.ResourceList{}
+		ranks  []int
+	)
+	for _, info := range resources {
+		rank := installRank(info.Object)
+		if _, ok := groups[rank]; !ok {
+			ranks = append(ranks, rank)
+		}
+		groups[rank] = append(groups[rank], info)
+	}
+	sort.Ints(ranks)
+
+	result := make([]kube.ResourceList, 0, len(ranks))
+	for _, rank := range ranks {
+		result = append(result, groups[rank])
+	}
+	return result
+}
+
+// installOrder maps the kinds to their position in helm's install order
+var installOrder = func() map[string]int {
+	order := map[string]int{}
+	for i, kind := range releaseutil.InstallOrder {
+		order[kind] = i + 1
+	}
+	return order
+}()
+
+// installRank returns the position of the object's kind in helm's install
+// order, unknown kinds are ranked last.
+func installRank(obj runtime.Object) int {
+	if r, ok := installOrder[obj.GetObjectKind().GroupVersionKind().Kind]; ok {
+		return r
+	}
+	return len(installOrder) + 1
+}
+
+// sortByInstallOrder sorts the objects by helm's install order of their kinds,
+// unknown kinds are sorted last. Objects of the same kind keep their order.
+func sortByInstallOrder(objs []runtime.Object) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		return installRank(objs[i]) < installRank(objs[j])
+	})
+}
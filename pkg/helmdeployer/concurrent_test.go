@@ -0,0 +1,46 @@
+package helmdeployer
+
+import (
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/kube"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+func TestGroupByInstallOrder(t *testing.T) {
+	info := func(kind, name string) *resource.Info {
+		obj := &unstructured.Unstructured{}
+		obj.SetKind(kind)
+		obj.SetName(name)
+		return &resource.Info{Name: name, Object: obj}
+	}
+	resources := kube.ResourceList{
+		info("Widget", "custom"),
+		info("Deployment", "app"),
+		info("ConfigMap", "config"),
+		info("Service", "svc"),
+		info("Deployment", "worker"),
+		info("ServiceAccount", "sa"),
+		info("CustomResourceDefinition", "widgets"),
+		info("Namespace", "ns"),
+		info("ConfigMap", "other"),
+	}
+
+	groups := groupByInstallOrder(resources)
+
+	var actual [][]string
+	for _, group := range groups {
+		var names []string
+		for _, info := range group {
+			names = append(names, info.Name)
+		}
+		actual = append(actual, names)
+	}
+	expected := [][]string{{"ns"}, {"sa"}, {"config", "other"}, {"widgets"}, {"svc"}, {"app", "worker"}, {"custom"}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected groups %v, got %v", expected, actual)
+	}
+}
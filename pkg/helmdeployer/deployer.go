@@ -20,6 +20,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/immutable"
 	"github.com/rancher/fleet/pkg/kustomize"
 	"github.com/rancher/fleet/pkg/manifest"
 	name2 "github.com/rancher/fleet/pkg/name"
@@ -33,8 +34,10 @@ import (
 	"github.com/rancher/wrangler/pkg/yaml"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
 )
 
 const (
@@ -44,6 +47,9 @@ const (
 	ServiceAccountNameAnnotation = "fleet.cattle.io/service-account"
 	DefaultServiceAccount        = "fleet-default"
 	KeepResourcesAnnotation      = "fleet.cattle.io/keep-resources"
+	OptionalAnnotation           = "fleet.cattle.io/optional"
+	ApplyStrategyAnnotation      = "fleet.cattle.io/apply-strategy"
+	SpecHashAnnotation           = "fleet.cattle.io/spec-hash"
 	HelmUpgradeInterruptedError  = "another operation (install/upgrade/rollback) is in progress"
 )
 
@@ -61,6 +67,17 @@ type postRender struct {
 	chart       *chart.Chart
 	mapper      meta.RESTMapper
 	opts        fleet.BundleDeploymentOptions
+	// client is used to verify optional objects before installing them,
+	// it is nil for templates and dry runs
+	client           dynamic.Interface
+	defaultNamespace string
+	// optionalErrors lists the optional objects, which were left out
+	optionalErrors []string
+	// webhookRejections lists the objects, which were left out, because
+	// admission webhooks rejected them
+	webhookRejections []fleet.WebhookRejection
+	// objs are the objects of the release, once rendered
+	objs []runtime.Object
 }
 
 type Helm struct {
@@ -77,6 +94,8 @@ type Helm struct {
 	labelPrefix      string
 	labelSuffix      string
 	releaseCache     cache.Store
+	// applyConcurrency is the number of resources applied in parallel
+	applyConcurrency int
 }
 
 func releaseKeyfunc(obj interface{}) (string, error) {
@@ -88,6 +107,12 @@ type Resources struct {
 	ID               string           `json:"id,omitempty"`
 	DefaultNamespace string           `json:"defaultNamespace,omitempty"`
 	Objects          []runtime.Object `json:"objects,omitempty"`
+	// OptionalErrors lists the optional objects, which failed to apply
+	// and were left out of the release.
+	OptionalErrors []string `json:"-"`
+	// WebhookRejections lists the objects, which admission webhooks
+	// rejected and were left out of the release.
+	WebhookRejections []fleet.WebhookRejection `json:"-"`
 }
 
 type DeployedBundle struct {
@@ -119,6 +144,12 @@ func NewHelm(namespace, defaultNamespace, labelPrefix, labelSuffix string, gette
 	return h, nil
 }
 
+// SetApplyConcurrency sets the number of resources, which are applied in
+// parallel. Namespaces and CRDs are always applied first.
+func (h *Helm) SetApplyConcurrency(concurrency int) {
+	h.applyConcurrency = concurrency
+}
+
 func (p *postRender) Run(renderedManifests *bytes.Buffer) (modifiedManifests *bytes.Buffer, err error) {
 	data := renderedManifests.Bytes()
 
@@ -159,6 +190,22 @@ func (p *postRender) Run(renderedManifests *bytes.Buffer) (modifiedManifests *by
 	}
 	objs = append(objs, yamlObjs...)
 
+	objs, err = injectSystem(objs, p.opts.System)
+	if err != nil {
+		return nil, err
+	}
+
+	if app := application(objs, p.manifest.Bundle, p.manifest.Labels, p.opts.Identity); app != nil {
+		objs = append(objs, app)
+		// the Application CRD is not installed in every cluster
+		optional := p.opts.OptionalResources
+		p.opts.OptionalResources = append(optional[:len(optional):len(optional)], fleet.OptionalResource{
+			Kind:       applicationKind,
+			APIVersion: applicationAPIVersion,
+			Name:       app.GetName(),
+		})
+	}
+
 	setID := GetSetID(p.bundleID, p.labelPrefix, p.labelSuffix)
 	labels, annotations, err := apply.GetLabelsAndAnnotations(setID, nil)
 	if err != nil {
@@ -170,8 +217,15 @@ func (p *postRender) Run(renderedManifests *bytes.Buffer) (modifiedManifests *by
 		if err != nil {
 			return nil, err
 		}
-		m.SetLabels(mergeMaps(m.GetLabels(), labels))
+		m.SetLabels(mergeMaps(mergeMaps(m.GetLabels(), p.manifest.Labels), labels))
 		m.SetAnnotations(mergeMaps(m.GetAnnotations(), annotations))
+		if err := validateApplyStrategy(m, obj.GetObjectKind().GroupVersionKind().Kind); err != nil {
+			return nil, err
+		}
+		if u, ok := obj.(*unstructured.Unstructured); ok && Replaced(u, p.opts) {
+			// the agent replaces these, when the hash changes
+			u.SetAnnotations(mergeMaps(u.GetAnnotations(), map[string]string{SpecHashAnnotation: immutable.SpecHash(u)}))
+		}
 
 		if p.opts.TargetNamespace != "" {
 			if p.mapper != nil {
@@ -190,18 +244,99 @@ func (p *postRender) Run(renderedManifests *bytes.Buffer) (modifiedManifests *by
 		}
 	}
 
+	if p.client != nil {
+		objs, p.optionalErrors = p.verifyOptional(objs)
+		objs, err = p.applyStrategies(objs)
+		if err != nil {
+			return nil, err
+		}
+		if p.opts.WebhookPolicy == fleet.WebhookPolicyForce {
+			objs, p.webhookRejections = p.verifyWebhooks(objs)
+		}
+		p.objs = objs
+	}
+
+	// kustomize and raw yaml objects are not sorted by helm
+	sortByInstallOrder(objs)
+
 	data, err = yaml.ToBytes(objs)
 	return bytes.NewBuffer(data), err
 }
 
 func (h *Helm) Deploy(bundleID string, manifest *manifest.Manifest, options fleet.BundleDeploymentOptions) (*Resources, error) {
+	options = withDefaultOptions(options)
+	chart, err := h.loadChart(bundleID, manifest, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// The dry run renders without access to the cluster, skip it if the
+	// chart relies on being rendered against the downstream cluster.
+	if h.template || !options.Helm.AgentRendering {
+		release, err := h.install(bundleID, manifest, chart, options, true, h.newPostRender(bundleID, manifest, chart, options))
+		if err != nil {
+			return nil, err
+		} else if h.template {
+			return releaseToResources(release)
+		}
+		if release != nil && strings.TrimSpace(release.Manifest) == "" {
+			if resources, err := h.emptyRender(bundleID, options); resources != nil || err != nil {
+				return resources, err
+			}
+		}
+	}
+
+	pr := h.newPostRender(bundleID, manifest, chart, options)
+	release, err := h.install(bundleID, manifest, chart, options, false, pr)
+	if err != nil {
+		return nil, pr.webhookError(err)
+	}
+
+	resources, err := releaseToResources(release)
+	if err != nil {
+		return nil, err
+	}
+	resources.OptionalErrors = pr.optionalErrors
+	resources.WebhookRejections = pr.webhookRejections
+
+	return resources, nil
+}
+
+// Render returns the resources Deploy would apply, by running the install
+// or upgrade action as a dry run against the cluster, so the chart is
+// rendered with the cluster's capabilities.
+func (h *Helm) Render(bundleID string, manifest *manifest.Manifest, options fleet.BundleDeploymentOptions) ([]runtime.Object, error) {
+	options = withDefaultOptions(options)
+	chart, err := h.loadChart(bundleID, manifest, options)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := h.install(bundleID, manifest, chart, options, true, h.newPostRender(bundleID, manifest, chart, options))
+	if err != nil || release == nil {
+		return nil, err
+	}
+
+	resources, err := releaseToResources(release)
+	if err != nil {
+		return nil, err
+	}
+	return resources.Objects, nil
+}
+
+func withDefaultOptions(options fleet.BundleDeploymentOptions) fleet.BundleDeploymentOptions {
 	if options.Helm == nil {
 		options.Helm = &fleet.HelmOptions{}
 	}
 	if options.Kustomize == nil {
 		options.Kustomize = &fleet.KustomizeOptions{}
 	}
+	return options
+}
 
+// loadChart renders the bundle's manifest into a helm chart, annotated
+// with the bundle deployment's options.
+func (h *Helm) loadChart(bundleID string, manifest *manifest.Manifest, options fleet.BundleDeploymentOptions) (*chart.Chart, error) {
 	tar, err := render.HelmChart(bundleID, manifest, options)
 	if err != nil {
 		return nil, err
@@ -218,24 +353,48 @@ func (h *Helm) Deploy(bundleID string, manifest *manifest.Manifest, options flee
 	chart.Metadata.Annotations[ServiceAccountNameAnnotation] = options.ServiceAccount
 	chart.Metadata.Annotations[BundleIDAnnotation] = bundleID
 	chart.Metadata.Annotations[AgentNamespaceAnnotation] = h.agentNamespace
-	chart.Metadata.Annotations[KeepResourcesAnnotation] = strconv.FormatBool(options.KeepResources)
+	chart.Metadata.Annotations[KeepResourcesAnnotation] = strconv.FormatBool(options.KeepResources || options.RunOnce)
 
 	if manifest.Commit != "" {
 		chart.Metadata.Annotations[CommitAnnotation] = manifest.Commit
 	}
 
-	if resources, err := h.install(bundleID, manifest, chart, options, true); err != nil {
-		return nil, err
-	} else if h.template {
-		return releaseToResources(resources)
-	}
+	return chart, nil
+}
 
-	release, err := h.install(bundleID, manifest, chart, options, false)
-	if err != nil {
-		return nil, err
+// emptyRender handles a bundle, which rendered no resources, according to
+// options.EmptyRender. It returns nil, if the empty release should be
+// deployed, which removes the previously deployed resources.
+func (h *Helm) emptyRender(bundleID string, options fleet.BundleDeploymentOptions) (*Resources, error) {
+	switch options.EmptyRender {
+	case fleet.EmptyRenderError:
+		return nil, fmt.Errorf("bundle %s rendered no resources", bundleID)
+	case fleet.EmptyRenderKeep:
+		_, defaultNamespace, releaseName := h.getOpts(bundleID, options)
+		cfg, err := h.getCfg(defaultNamespace, options.ServiceAccount)
+		if err != nil {
+			return nil, err
+		}
+		deployed, err := cfg.Releases.Deployed(releaseName)
+		if err != nil {
+			// nothing to keep
+			return nil, nil
+		}
+		logrus.Infof("Bundle %s rendered no resources, keeping release %s/%s:%d", bundleID, deployed.Namespace, deployed.Name, deployed.Version)
+		return releaseToResources(deployed)
 	}
+	return nil, nil
+}
 
-	return releaseToResources(release)
+func (h *Helm) newPostRender(bundleID string, manifest *manifest.Manifest, chart *chart.Chart, options fleet.BundleDeploymentOptions) *postRender {
+	return &postRender{
+		labelPrefix: h.labelPrefix,
+		labelSuffix: h.labelSuffix,
+		bundleID:    bundleID,
+		manifest:    manifest,
+		opts:        options,
+		chart:       chart,
+	}
 }
 
 func (h *Helm) mustUninstall(cfg *action.Configuration, releaseName string) (bool, error) {
@@ -312,13 +471,16 @@ func (h *Helm) getCfg(namespace, serviceAccountName string) (action.Configuratio
 	err = cfg.Init(getter, namespace, "secrets", logrus.Infof)
 	cfg.Releases.MaxHistory = 5
 	cfg.KubeClient = kClient
+	if h.applyConcurrency > 1 {
+		cfg.KubeClient = &concurrentClient{Client: kClient, concurrency: h.applyConcurrency}
+	}
 
 	cfg.Capabilities, _ = getCapabilities(cfg)
 
 	return cfg, err
 }
 
-func (h *Helm) install(bundleID string, manifest *manifest.Manifest, chart *chart.Chart, options fleet.BundleDeploymentOptions, dryRun bool) (*release.Release, error) {
+func (h *Helm) install(bundleID string, manifest *manifest.Manifest, chart *chart.Chart, options fleet.BundleDeploymentOptions, dryRun bool, pr *postRender) (*release.Release, error) {
 	timeout, defaultNamespace, releaseName := h.getOpts(bundleID, options)
 
 	values, err := h.getValues(options, defaultNamespace)
@@ -350,21 +512,24 @@ func (h *Helm) install(bundleID string, manifest *manifest.Manifest, chart *char
 		return nil, err
 	}
 
-	pr := &postRender{
-		labelPrefix: h.labelPrefix,
-		labelSuffix: h.labelSuffix,
-		bundleID:    bundleID,
-		manifest:    manifest,
-		opts:        options,
-		chart:       chart,
-	}
-
 	if !h.useGlobalCfg {
 		mapper, err := cfg.RESTClientGetter.ToRESTMapper()
 		if err != nil {
 			return nil, err
 		}
 		pr.mapper = mapper
+
+		if !dryRun && !h.template {
+			restConfig, err := cfg.RESTClientGetter.ToRESTConfig()
+			if err != nil {
+				return nil, err
+			}
+			pr.client, err = dynamic.NewForConfig(restConfig)
+			if err != nil {
+				return nil, err
+			}
+			pr.defaultNamespace = defaultNamespace
+		}
 	}
 
 	if install {
@@ -723,6 +888,8 @@ func (h *Helm) deleteByRelease(bundleID, releaseName string, keepResources bool)
 	}
 
 	u := action.NewUninstall(&cfg)
+	// post-delete hooks are waited for
+	u.Timeout = durations.PostDeleteHooksTimeout
 	_, err = u.Run(releaseName)
 	return err
 }
@@ -3,12 +3,17 @@ package helmdeployer
 import (
 	"fmt"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 )
 
 func TestValuesFrom(t *testing.T) {
@@ -60,3 +65,228 @@ func TestValuesFrom(t *testing.T) {
 	totalValues = mergeValues(totalValues, configMapValues)
 	a.Equal(expected, totalValues)
 }
+
+func TestClusterCapabilities(t *testing.T) {
+	a := assert.New(t)
+
+	a.Nil(ClusterCapabilities(&fleet.Cluster{}))
+
+	caps := ClusterCapabilities(&fleet.Cluster{
+		Status: fleet.ClusterStatus{
+			Agent: fleet.AgentStatus{
+				KubernetesVersion: "v1.26.4+k3s1",
+				APIVersions:       []string{"v1", "apps/v1", "apps/v1/Deployment"},
+			},
+		},
+	})
+	a.NotNil(caps)
+	a.Equal("v1.26.4+k3s1", caps.KubeVersion.Version)
+	a.Equal("1", caps.KubeVersion.Major)
+	a.Equal("26", caps.KubeVersion.Minor)
+	a.True(caps.APIVersions.Has("apps/v1/Deployment"))
+	a.False(caps.APIVersions.Has("batch/v1"))
+}
+
+func TestIsOptional(t *testing.T) {
+	a := assert.New(t)
+
+	obj := func(kind, name string, annotations map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("monitoring.coreos.com/v1")
+		u.SetKind(kind)
+		u.SetName(name)
+		u.SetAnnotations(annotations)
+		return u
+	}
+	selectors := []fleet.OptionalResource{{}, {Kind: "ServiceMonitor", APIVersion: "monitoring.coreos.com/v1"}}
+
+	a.True(isOptional(obj("ServiceMonitor", "app", nil), selectors))
+	a.True(isOptional(obj("PrometheusRule", "app", map[string]string{OptionalAnnotation: "true"}), nil))
+	a.False(isOptional(obj("PrometheusRule", "app", nil), selectors))
+}
+
+func TestApplyStrategy(t *testing.T) {
+	a := assert.New(t)
+
+	obj := func(kind, name string, annotations map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind(kind)
+		u.SetName(name)
+		u.SetAnnotations(annotations)
+		return u
+	}
+	strategies := []fleet.ApplyStrategy{
+		{Kind: "Secret", Name: "password", Strategy: fleet.ApplyStrategyCreateOnly},
+		{Kind: "Secret", Strategy: fleet.ApplyStrategyReplace},
+	}
+
+	a.Equal(fleet.ApplyStrategyCreateOnly, ApplyStrategy(obj("Secret", "password", nil), strategies))
+	a.Equal(fleet.ApplyStrategyReplace, ApplyStrategy(obj("Secret", "tls", nil), strategies))
+	a.Equal(fleet.ApplyStrategyPatch, ApplyStrategy(obj("ConfigMap", "password", nil), strategies))
+	a.Equal(fleet.ApplyStrategyReplace, ApplyStrategy(obj("Job", "migrate", map[string]string{ApplyStrategyAnnotation: "replace"}), nil))
+
+	a.True(Replaced(obj("Secret", "tls", nil), fleet.BundleDeploymentOptions{ApplyStrategies: strategies}))
+	a.False(Replaced(obj("Secret", "password", nil), fleet.BundleDeploymentOptions{ApplyStrategies: strategies}))
+	a.False(Replaced(obj("ConfigMap", "app", nil), fleet.BundleDeploymentOptions{ReplaceImmutable: true}))
+
+	a.NoError(validateApplyStrategy(obj("Job", "migrate", map[string]string{ApplyStrategyAnnotation: "create-only"}), "Job"))
+	a.ErrorContains(validateApplyStrategy(obj("Job", "migrate", map[string]string{ApplyStrategyAnnotation: "Replace"}), "Job"), "must be one of patch, replace or create-only")
+}
+
+func TestWebhookName(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("validate.kyverno.svc-fail", webhookName(fmt.Errorf(`admission webhook "validate.kyverno.svc-fail" denied the request: policy require-labels failed`)))
+	a.Equal("vpod.gatekeeper.sh", webhookName(fmt.Errorf(`Internal error occurred: failed calling webhook "vpod.gatekeeper.sh": context deadline exceeded`)))
+	a.Equal("", webhookName(fmt.Errorf("error validating data: unknown field")))
+	a.Equal("", webhookName(nil))
+}
+
+func TestSortByInstallOrder(t *testing.T) {
+	a := assert.New(t)
+
+	obj := func(kind, name string) k8sruntime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind(kind)
+		u.SetName(name)
+		return u
+	}
+	objs := []k8sruntime.Object{
+		obj("Widget", "w"),
+		obj("Deployment", "d"),
+		obj("ConfigMap", "c1"),
+		obj("CustomResourceDefinition", "crd"),
+		obj("ConfigMap", "c2"),
+		obj("Namespace", "ns"),
+	}
+
+	sortByInstallOrder(objs)
+
+	var names []string
+	for _, o := range objs {
+		names = append(names, o.(*unstructured.Unstructured).GetName())
+	}
+	a.Equal([]string{"ns", "c1", "c2", "crd", "d", "w"}, names)
+}
+
+func TestEmptyRender(t *testing.T) {
+	a := assert.New(t)
+	h := &Helm{}
+
+	resources, err := h.emptyRender("bundle", fleet.BundleDeploymentOptions{EmptyRender: fleet.EmptyRenderError})
+	a.Nil(resources)
+	a.EqualError(err, "bundle bundle rendered no resources")
+
+	for _, emptyRender := range []string{"", fleet.EmptyRenderDelete} {
+		resources, err = h.emptyRender("bundle", fleet.BundleDeploymentOptions{EmptyRender: emptyRender})
+		a.Nil(resources)
+		a.NoError(err)
+	}
+}
+
+func TestInjectSystem(t *testing.T) {
+	a := assert.New(t)
+	workload := func(kind, name string, podSpec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": "ns"},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": name}},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": name}},
+					"spec":     podSpec,
+				},
+			},
+		}}
+	}
+	objs := []k8sruntime.Object{
+		workload("Deployment", "d", map[string]interface{}{}),
+		workload("Deployment", "custom", map[string]interface{}{"priorityClassName": "low"}),
+		workload("DaemonSet", "ds", map[string]interface{}{}),
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "policy/v1",
+			"kind":       "PodDisruptionBudget",
+			"metadata":   map[string]interface{}{"name": "custom", "namespace": "ns"},
+		}},
+		workload("StatefulSet", "selected", map[string]interface{}{}),
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "policy/v1",
+			"kind":       "PodDisruptionBudget",
+			"metadata":   map[string]interface{}{"name": "selected-pdb", "namespace": "ns"},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "selected"}},
+			},
+		}},
+	}
+
+	result, err := injectSystem(objs, &fleet.SystemOptions{
+		PriorityClassName:   "system-cluster-critical",
+		PodDisruptionBudget: &fleet.PodDisruptionBudgetOptions{},
+	})
+	a.NoError(err)
+	a.Len(result, 7)
+
+	priority := func(obj k8sruntime.Object) string {
+		v, _, _ := unstructured.NestedString(obj.(*unstructured.Unstructured).Object, "spec", "template", "spec", "priorityClassName")
+		return v
+	}
+	a.Equal("system-cluster-critical", priority(result[0]))
+	a.Equal("low", priority(result[1]))
+	a.Equal("system-cluster-critical", priority(result[2]))
+
+	pdb := result[6].(*unstructured.Unstructured)
+	a.Equal("PodDisruptionBudget", pdb.GetKind())
+	a.Equal("d", pdb.GetName())
+	a.Equal("ns", pdb.GetNamespace())
+	a.Equal(map[string]interface{}{
+		"selector":       map[string]interface{}{"matchLabels": map[string]interface{}{"app": "d"}},
+		"maxUnavailable": int64(1),
+	}, pdb.Object["spec"])
+}
+
+func TestIdentity(t *testing.T) {
+	a := assert.New(t)
+	bd := &fleet.BundleDeployment{}
+	bd.Labels = map[string]string{
+		fleet.BundleLabel:          "app",
+		fleet.BundleNamespaceLabel: "fleet-default",
+		"env":                      "prod",
+	}
+	bd.Spec.Options.Propagation = &fleet.PropagationOptions{ResourceLabels: []string{"env"}}
+	a.Equal(map[string]string{"env": "prod"}, ResourceLabels(bd))
+
+	bd.Spec.Options.Identity = &fleet.IdentityOptions{}
+	labels := ResourceLabels(bd)
+	a.Equal(map[string]string{
+		"env":                      "prod",
+		fleet.BundleLabel:          "app",
+		fleet.BundleNamespaceLabel: "fleet-default",
+		partOfLabel:                "app",
+	}, labels)
+
+	objs := []k8sruntime.Object{
+		&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment"}},
+		&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}},
+		&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}},
+	}
+	a.Nil(application(objs, "app", labels, bd.Spec.Options.Identity))
+
+	long := strings.Repeat("a", 70)
+	app := application(objs, long, labels, &fleet.IdentityOptions{Application: true})
+	a.Equal("Application", app.GetKind())
+	a.Equal(long, app.GetName())
+	a.Equal(map[string]interface{}{
+		"selector": map[string]interface{}{"matchLabels": map[string]interface{}{
+			fleet.BundleLabel:          "app",
+			fleet.BundleNamespaceLabel: "fleet-default",
+		}},
+		"componentKinds": []interface{}{
+			map[string]interface{}{"group": "", "kind": "ConfigMap"},
+			map[string]interface{}{"group": "apps", "kind": "Deployment"},
+		},
+		"descriptor": map[string]interface{}{"type": "fleet-bundle"},
+	}, app.Object["spec"])
+}
@@ -0,0 +1,96 @@
+package helmdeployer
+
+import (
+	"sort"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	partOfLabel           = "app.kubernetes.io/part-of"
+	applicationAPIVersion = "app.k8s.io/v1beta1"
+	applicationKind       = "Application"
+	applicationType       = "fleet-bundle"
+)
+
+// ResourceLabels returns the labels the agent adds to all resources of the
+// bundle deployment, the propagated labels and the identity labels.
+func ResourceLabels(bd *fleet.BundleDeployment) map[string]string {
+	var labels map[string]string
+	if bd.Spec.Options.Propagation != nil {
+		labels = fleet.Propagate(bd.Spec.Options.Propagation.ResourceLabels, bd.Labels)
+	}
+	if bd.Spec.Options.Identity == nil {
+		return labels
+	}
+
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for _, k := range []string{fleet.BundleLabel, fleet.BundleNamespaceLabel, fleet.RepoLabel} {
+		if v, ok := bd.Labels[k]; ok {
+			labels[k] = v
+		}
+	}
+	if v, ok := bd.Labels[fleet.BundleLabel]; ok {
+		labels[partOfLabel] = v
+	}
+	return labels
+}
+
+// application returns an Application named after the bundle, which selects
+// the resources of the bundle by their identity labels, or nil if it's not
+// enabled.
+func application(objs []runtime.Object, bundleName string, labels map[string]string, opts *fleet.IdentityOptions) *unstructured.Unstructured {
+	if opts == nil || !opts.Application || bundleName == "" || labels[fleet.BundleLabel] == "" {
+		return nil
+	}
+
+	selector := map[string]interface{}{}
+	for _, k := range []string{fleet.BundleLabel, fleet.BundleNamespaceLabel} {
+		if v, ok := labels[k]; ok {
+			selector[k] = v
+		}
+	}
+
+	seen := map[schema.GroupKind]bool{}
+	var kinds []schema.GroupKind
+	for _, obj := range objs {
+		gk := obj.GetObjectKind().GroupVersionKind().GroupKind()
+		if gk.Kind == "" || seen[gk] {
+			continue
+		}
+		seen[gk] = true
+		kinds = append(kinds, gk)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i].String() < kinds[j].String()
+	})
+	componentKinds := []interface{}{}
+	for _, gk := range kinds {
+		componentKinds = append(componentKinds, map[string]interface{}{
+			"group": gk.Group,
+			"kind":  gk.Kind,
+		})
+	}
+
+	app := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": applicationAPIVersion,
+		"kind":       applicationKind,
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": selector,
+			},
+			"componentKinds": componentKinds,
+			"descriptor": map[string]interface{}{
+				"type": applicationType,
+			},
+		},
+	}}
+	app.SetName(bundleName)
+	return app
+}
@@ -0,0 +1,97 @@
+package helmdeployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// verifyOptional applies the optional objects in server side dry-run mode.
+// Objects which fail, e.g. because their CRD is missing, are removed from the
+// release and their errors are returned.
+func (p *postRender) verifyOptional(objs []runtime.Object) ([]runtime.Object, []string) {
+	var (
+		result []runtime.Object
+		errs   []string
+	)
+	for _, obj := range objs {
+		if !isOptional(obj, p.opts.OptionalResources) {
+			result = append(result, obj)
+			continue
+		}
+		if err := p.dryRunApply(obj); err != nil {
+			m, _ := meta.Accessor(obj)
+			errs = append(errs, fmt.Sprintf("skipped optional %s %s: %v", obj.GetObjectKind().GroupVersionKind().Kind, m.GetName(), err))
+			continue
+		}
+		result = append(result, obj)
+	}
+	return result, errs
+}
+
+func (p *postRender) dryRunApply(obj runtime.Object) error {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	mapping, err := p.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	}
+
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	opts := metav1.PatchOptions{
+		DryRun:       []string{metav1.DryRunAll},
+		FieldManager: "fleet-agent",
+		Force:        &[]bool{true}[0],
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		_, err = p.client.Resource(mapping.Resource).Patch(context.TODO(), m.GetName(), types.ApplyPatchType, data, opts)
+		return err
+	}
+
+	ns := m.GetNamespace()
+	if ns == "" {
+		ns = p.defaultNamespace
+	}
+	_, err = p.client.Resource(mapping.Resource).Namespace(ns).Patch(context.TODO(), m.GetName(), types.ApplyPatchType, data, opts)
+	return err
+}
+
+// isOptional returns true if the object is annotated as optional or matches
+// one of the bundle's optional resources.
+func isOptional(obj runtime.Object, selectors []fleet.OptionalResource) bool {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	if m.GetAnnotations()[OptionalAnnotation] == "true" {
+		return true
+	}
+
+	apiVersion, kind := obj.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+	for _, s := range selectors {
+		if s == (fleet.OptionalResource{}) {
+			continue
+		}
+		if (s.Kind == "" || s.Kind == kind) &&
+			(s.APIVersion == "" || s.APIVersion == apiVersion) &&
+			(s.Namespace == "" || s.Namespace == m.GetNamespace()) &&
+			(s.Name == "" || s.Name == m.GetName()) {
+			return true
+		}
+	}
+	return false
+}
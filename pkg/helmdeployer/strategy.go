@@ -0,0 +1,137 @@
+package helmdeployer
+
+import (
+	"context"
+	"fmt"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/bundlereader"
+	"github.com/rancher/fleet/pkg/immutable"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+// ApplyStrategy returns how the object is applied. The annotation takes
+// precedence over the bundle's strategies, of which the first matching one
+// is used. Objects without a strategy are patched.
+func ApplyStrategy(obj runtime.Object, strategies []fleet.ApplyStrategy) string {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return fleet.ApplyStrategyPatch
+	}
+	if s := m.GetAnnotations()[ApplyStrategyAnnotation]; s != "" {
+		return s
+	}
+
+	apiVersion, kind := obj.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+	for _, s := range strategies {
+		if s.Strategy == "" {
+			continue
+		}
+		if (s.Kind == "" || s.Kind == kind) &&
+			(s.APIVersion == "" || s.APIVersion == apiVersion) &&
+			(s.Namespace == "" || s.Namespace == m.GetNamespace()) &&
+			(s.Name == "" || s.Name == m.GetName()) {
+			return s.Strategy
+		}
+	}
+	return fleet.ApplyStrategyPatch
+}
+
+// Replaced returns true, if the object is deleted and created again, instead
+// of updated, when its spec changes. That's the case for objects with the
+// replace strategy and for Jobs and Pods, if immutable resources are
+// replaced.
+func Replaced(obj *unstructured.Unstructured, options fleet.BundleDeploymentOptions) bool {
+	return ApplyStrategy(obj, options.ApplyStrategies) == fleet.ApplyStrategyReplace ||
+		options.ReplaceImmutable && immutable.IsReplaceable(obj)
+}
+
+// validateApplyStrategy returns an error, if the object's apply strategy
+// annotation is invalid.
+func validateApplyStrategy(m metav1.Object, kind string) error {
+	s, ok := m.GetAnnotations()[ApplyStrategyAnnotation]
+	if !ok {
+		return nil
+	}
+	if err := bundlereader.ValidateApplyStrategy(s); err != nil {
+		return fmt.Errorf("invalid %s annotation %q on %s %s, %w", ApplyStrategyAnnotation, s, kind, m.GetName(), err)
+	}
+	return nil
+}
+
+// applyStrategies prepares the create-only objects for the helm upgrade.
+// Existing create-only objects are replaced by their live state, so helm
+// leaves them unchanged. Objects, which are replaced, are deleted by the
+// agent before deploying, if their spec hash changed.
+func (p *postRender) applyStrategies(objs []runtime.Object) ([]runtime.Object, error) {
+	result := make([]runtime.Object, 0, len(objs))
+	for _, obj := range objs {
+		if ApplyStrategy(obj, p.opts.ApplyStrategies) != fleet.ApplyStrategyCreateOnly {
+			result = append(result, obj)
+			continue
+		}
+
+		m, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		client, err := p.resourceClient(obj)
+		if err != nil {
+			return nil, err
+		}
+		live, err := client.Get(context.TODO(), m.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			result = append(result, obj)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		result = append(result, keepLive(live, m))
+	}
+	return result, nil
+}
+
+// keepLive returns the live object without its server populated fields, but
+// with the labels and annotations of the rendered object, so fleet and helm
+// keep tracking it.
+func keepLive(live *unstructured.Unstructured, rendered metav1.Object) *unstructured.Unstructured {
+	obj := live.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "status")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetSelfLink("")
+	obj.SetLabels(mergeMaps(obj.GetLabels(), rendered.GetLabels()))
+	obj.SetAnnotations(mergeMaps(obj.GetAnnotations(), rendered.GetAnnotations()))
+	return obj
+}
+
+func (p *postRender) resourceClient(obj runtime.Object) (dynamic.ResourceInterface, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	mapping, err := p.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return p.client.Resource(mapping.Resource), nil
+	}
+
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	ns := m.GetNamespace()
+	if ns == "" {
+		ns = p.defaultNamespace
+	}
+	return p.client.Resource(mapping.Resource).Namespace(ns), nil
+}
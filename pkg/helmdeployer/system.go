@@ -0,0 +1,145 @@
+package helmdeployer
+
+import (
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// existingPDB is a PodDisruptionBudget of the bundle
+type existingPDB struct {
+	namespace string
+	name      string
+	selector  labels.Selector
+}
+
+// injectSystem sets the priority class on the pod templates of the
+// workloads and adds a PodDisruptionBudget for each Deployment and
+// StatefulSet, as configured by the system options. Workloads, whose pods
+// are selected by a PodDisruptionBudget of the bundle, don't get another.
+func injectSystem(objs []runtime.Object, opts *fleet.SystemOptions) ([]runtime.Object, error) {
+	if opts == nil {
+		return objs, nil
+	}
+
+	var pdbs []existingPDB
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok || !isKind(u, "policy", "PodDisruptionBudget") {
+			continue
+		}
+		pdb := existingPDB{namespace: u.GetNamespace(), name: u.GetName(), selector: labels.Nothing()}
+		if m, found, err := unstructured.NestedMap(u.Object, "spec", "selector"); err == nil && found {
+			selector := &metav1.LabelSelector{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, selector); err != nil {
+				return nil, err
+			}
+			if pdb.selector, err = metav1.LabelSelectorAsSelector(selector); err != nil {
+				return nil, err
+			}
+		}
+		pdbs = append(pdbs, pdb)
+	}
+
+	result := objs
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok || !(isKind(u, "apps", "Deployment") || isKind(u, "apps", "StatefulSet") || isKind(u, "apps", "DaemonSet")) {
+			continue
+		}
+
+		if opts.PriorityClassName != "" {
+			_, found, err := unstructured.NestedString(u.Object, "spec", "template", "spec", "priorityClassName")
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				if err := unstructured.SetNestedField(u.Object, opts.PriorityClassName, "spec", "template", "spec", "priorityClassName"); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// drains don't evict the pods of daemonsets
+		if opts.PodDisruptionBudget == nil || isKind(u, "apps", "DaemonSet") {
+			continue
+		}
+		covered, err := hasPDB(u, pdbs)
+		if err != nil {
+			return nil, err
+		}
+		if covered {
+			continue
+		}
+		selector, found, err := unstructured.NestedMap(u.Object, "spec", "selector")
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		result = append(result, podDisruptionBudget(u, selector, opts.PodDisruptionBudget))
+	}
+
+	return result, nil
+}
+
+// hasPDB returns true if a PodDisruptionBudget has the workload's name or
+// selects the pods of the workload
+func hasPDB(workload *unstructured.Unstructured, pdbs []existingPDB) (bool, error) {
+	podLabels, _, err := unstructured.NestedStringMap(workload.Object, "spec", "template", "metadata", "labels")
+	if err != nil {
+		return false, err
+	}
+	for _, pdb := range pdbs {
+		if pdb.namespace != workload.GetNamespace() {
+			continue
+		}
+		if pdb.name == workload.GetName() || pdb.selector.Matches(labels.Set(podLabels)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func podDisruptionBudget(workload *unstructured.Unstructured, selector map[string]interface{}, opts *fleet.PodDisruptionBudgetOptions) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"selector": selector,
+	}
+	if opts.MinAvailable != nil {
+		spec["minAvailable"] = intOrString(*opts.MinAvailable)
+	}
+	if opts.MaxUnavailable != nil {
+		spec["maxUnavailable"] = intOrString(*opts.MaxUnavailable)
+	}
+	if opts.MinAvailable == nil && opts.MaxUnavailable == nil {
+		spec["maxUnavailable"] = int64(1)
+	}
+
+	pdb := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy/v1",
+		"kind":       "PodDisruptionBudget",
+		"spec":       spec,
+	}}
+	pdb.SetName(workload.GetName())
+	if ns := workload.GetNamespace(); ns != "" {
+		pdb.SetNamespace(ns)
+	}
+	return pdb
+}
+
+func intOrString(v intstr.IntOrString) interface{} {
+	if v.Type == intstr.String {
+		return v.StrVal
+	}
+	return int64(v.IntVal)
+}
+
+func isKind(u *unstructured.Unstructured, group, kind string) bool {
+	gvk := u.GroupVersionKind()
+	return gvk.Group == group && gvk.Kind == kind
+}
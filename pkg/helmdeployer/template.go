@@ -2,10 +2,12 @@ package helmdeployer
 
 import (
 	"io"
+	"strconv"
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/manifest"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/sirupsen/logrus"
 
 	"helm.sh/helm/v3/pkg/action"
@@ -19,6 +21,12 @@ import (
 
 // Template runs helm template and returns the resources as a list of objects, without applying them.
 func Template(bundleID string, manifest *manifest.Manifest, options fleet.BundleDeploymentOptions) ([]runtime.Object, error) {
+	return TemplateWithCapabilities(bundleID, manifest, options, nil)
+}
+
+// TemplateWithCapabilities is like Template, but renders against the given
+// capabilities instead of Helm's defaults, if not nil.
+func TemplateWithCapabilities(bundleID string, manifest *manifest.Manifest, options fleet.BundleDeploymentOptions, capabilities *chartutil.Capabilities) ([]runtime.Object, error) {
 	h := &Helm{
 		globalCfg:    action.Configuration{},
 		useGlobalCfg: true,
@@ -29,6 +37,9 @@ func Template(bundleID string, manifest *manifest.Manifest, options fleet.Bundle
 	mem.SetNamespace("default")
 
 	h.globalCfg.Capabilities = chartutil.DefaultCapabilities
+	if capabilities != nil {
+		h.globalCfg.Capabilities = capabilities
+	}
 	h.globalCfg.KubeClient = &kubefake.PrintingKubeClient{Out: io.Discard}
 	h.globalCfg.Log = logrus.Infof
 	h.globalCfg.Releases = storage.Init(mem)
@@ -40,3 +51,49 @@ func Template(bundleID string, manifest *manifest.Manifest, options fleet.Bundle
 
 	return resources.Objects, nil
 }
+
+// TemplateBundleDeployment renders the bundle deployment like the agent
+// deploys it, with its commit and propagated labels, against the
+// capabilities reported by the cluster. Blue/green namespaces are not
+// applied.
+func TemplateBundleDeployment(bd *fleet.BundleDeployment, manifest *manifest.Manifest, cluster *fleet.Cluster) ([]runtime.Object, error) {
+	manifest.Commit = bd.Labels["fleet.cattle.io/commit"]
+	manifest.Bundle = fleet.DeploymentBundleName(bd)
+	manifest.Labels = ResourceLabels(bd)
+
+	var capabilities *chartutil.Capabilities
+	if cluster != nil {
+		capabilities = ClusterCapabilities(cluster)
+	}
+	return TemplateWithCapabilities(bd.Name, manifest, bd.Spec.Options, capabilities)
+}
+
+// ClusterCapabilities returns the capabilities reported by the cluster's
+// agent. It returns nil if the agent did not report them yet.
+func ClusterCapabilities(cluster *fleet.Cluster) *chartutil.Capabilities {
+	agent := cluster.Status.Agent
+	if agent.KubernetesVersion == "" {
+		return nil
+	}
+
+	kubeVersion, err := semver.NewVersion(agent.KubernetesVersion)
+	if err != nil {
+		logrus.Debugf("ignoring invalid kubernetes version %q reported by cluster %s/%s", agent.KubernetesVersion, cluster.Namespace, cluster.Name)
+		return nil
+	}
+
+	apiVersions := chartutil.DefaultVersionSet
+	if len(agent.APIVersions) > 0 {
+		apiVersions = agent.APIVersions
+	}
+
+	return &chartutil.Capabilities{
+		APIVersions: apiVersions,
+		KubeVersion: chartutil.KubeVersion{
+			Version: agent.KubernetesVersion,
+			Major:   strconv.FormatUint(kubeVersion.Major(), 10),
+			Minor:   strconv.FormatUint(kubeVersion.Minor(), 10),
+		},
+		HelmVersion: chartutil.DefaultCapabilities.HelmVersion,
+	}
+}
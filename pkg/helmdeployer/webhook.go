@@ -0,0 +1,91 @@
+package helmdeployer
+
+import (
+	"regexp"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// webhookErrorRegexp matches the API server's errors for requests, which an
+// admission webhook denied or which could not be sent to the webhook.
+var webhookErrorRegexp = regexp.MustCompile(`(?:admission webhook|failed calling webhook) "([^"]+)"`)
+
+// WebhookError is returned by Deploy, if admission webhooks of the cluster
+// rejected resources of the release.
+type WebhookError struct {
+	Rejections []fleet.WebhookRejection
+	Err        error
+}
+
+func (e *WebhookError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *WebhookError) Unwrap() error {
+	return e.Err
+}
+
+// webhookName returns the name of the admission webhook, which rejected
+// the request, or an empty string if the error is not a webhook's.
+func webhookName(err error) string {
+	if err == nil {
+		return ""
+	}
+	m := webhookErrorRegexp.FindStringSubmatch(err.Error())
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// verifyWebhooks applies the objects in server side dry-run mode, which
+// calls the admission webhooks. Objects, which a webhook rejects, are
+// removed and returned as rejections. Other errors are left to the
+// deployment.
+func (p *postRender) verifyWebhooks(objs []runtime.Object) ([]runtime.Object, []fleet.WebhookRejection) {
+	var (
+		result     []runtime.Object
+		rejections []fleet.WebhookRejection
+	)
+	for _, obj := range objs {
+		err := p.dryRunApply(obj)
+		webhook := webhookName(err)
+		if webhook == "" {
+			result = append(result, obj)
+			continue
+		}
+
+		rejection := fleet.WebhookRejection{Webhook: webhook, Message: err.Error()}
+		rejection.APIVersion, rejection.Kind = obj.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+		if m, err := meta.Accessor(obj); err == nil {
+			rejection.Namespace = m.GetNamespace()
+			rejection.Name = m.GetName()
+		}
+		rejections = append(rejections, rejection)
+	}
+	return result, rejections
+}
+
+// webhookError returns a WebhookError, listing the objects of the release
+// rejected by admission webhooks, if err is a webhook's.
+func (p *postRender) webhookError(err error) error {
+	webhook := webhookName(err)
+	if webhook == "" || p.client == nil {
+		return err
+	}
+
+	_, rejections := p.verifyWebhooks(p.objs)
+	if len(rejections) == 0 {
+		// e.g. a hook, which is not part of the release's objects
+		rejections = []fleet.WebhookRejection{{Webhook: webhook, Message: err.Error()}}
+	}
+	for _, r := range rejections {
+		logrus.Warnf("Admission webhook %q rejected %s %s/%s of bundle %s", r.Webhook, r.Kind, r.Namespace, r.Name, p.bundleID)
+	}
+	return &WebhookError{Rejections: rejections, Err: err}
+}
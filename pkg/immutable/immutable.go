@@ -0,0 +1,175 @@
+// Package immutable detects updates of StatefulSets, PersistentVolumeClaims, Jobs and Pods, which change immutable fields and can only be applied by recreating the resource. (fleetcontroller, fleetagent)
+package immutable
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	statefulSet = schema.GroupKind{Group: "apps", Kind: "StatefulSet"}
+	claim       = schema.GroupKind{Kind: "PersistentVolumeClaim"}
+)
+
+// IsStateful returns true for the kinds, whose immutable fields are checked.
+func IsStateful(obj *unstructured.Unstructured) bool {
+	gk := obj.GroupVersionKind().GroupKind()
+	return gk == statefulSet || gk == claim
+}
+
+// Changes compares the stateful resources in desired with the ones of the
+// same kind, namespace and name in existing. It returns a description of
+// every change, which requires recreating a resource.
+func Changes(existing, desired []runtime.Object) []string {
+	old := map[string]*unstructured.Unstructured{}
+	for _, obj := range existing {
+		if u, ok := obj.(*unstructured.Unstructured); ok && IsStateful(u) {
+			old[key(u)] = u
+		}
+	}
+
+	var result []string
+	for _, obj := range desired {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok || !IsStateful(u) {
+			continue
+		}
+		if prev, ok := old[key(u)]; ok {
+			result = append(result, Compare(prev, u)...)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Compare returns a description of every immutable field, which differs
+// between the existing and the desired resource. Fields which are not set
+// on both are not compared, as they might be defaulted by the API server.
+func Compare(existing, desired *unstructured.Unstructured) []string {
+	var fields []string
+	switch desired.GroupVersionKind().GroupKind() {
+	case statefulSet:
+		fields = statefulSetChanges(existing.Object, desired.Object)
+	case claim:
+		fields = claimChanges(spec(existing.Object), spec(desired.Object), true, "spec")
+	}
+
+	result := make([]string, 0, len(fields))
+	for _, field := range fields {
+		result = append(result, fmt.Sprintf("%s %s changes immutable field %s", desired.GetKind(), name(desired), field))
+	}
+	return result
+}
+
+func statefulSetChanges(existing, desired map[string]interface{}) []string {
+	var result []string
+	oldSpec, newSpec := spec(existing), spec(desired)
+
+	for _, field := range []string{"selector", "serviceName", "podManagementPolicy"} {
+		if changed(oldSpec[field], newSpec[field]) {
+			result = append(result, "spec."+field)
+		}
+	}
+
+	oldTemplates, newTemplates := claimTemplates(oldSpec), claimTemplates(newSpec)
+	if len(oldTemplates) != len(newTemplates) {
+		return append(result, "spec.volumeClaimTemplates")
+	}
+	for name, t := range newTemplates {
+		prev, ok := oldTemplates[name]
+		if !ok {
+			return append(result, "spec.volumeClaimTemplates")
+		}
+		result = append(result, claimChanges(spec(prev), spec(t), false, "spec.volumeClaimTemplates["+name+"].spec")...)
+	}
+	return result
+}
+
+// claimChanges compares the specs of two claims. Claims can be resized,
+// if canGrow is set, claim templates can't be changed at all.
+func claimChanges(existing, desired map[string]interface{}, canGrow bool, path string) []string {
+	var result []string
+	for _, field := range []string{"storageClassName", "volumeMode", "volumeName", "accessModes", "selector", "dataSource"} {
+		if changed(existing[field], desired[field]) {
+			result = append(result, path+"."+field)
+		}
+	}
+
+	oldSize, oldOK := storage(existing)
+	newSize, newOK := storage(desired)
+	if oldOK && newOK {
+		if cmp := newSize.Cmp(oldSize); cmp < 0 || (cmp > 0 && !canGrow) {
+			result = append(result, path+".resources.requests.storage")
+		}
+	}
+	return result
+}
+
+func changed(existing, desired interface{}) bool {
+	if isEmpty(existing) || isEmpty(desired) {
+		return false
+	}
+	return !reflect.DeepEqual(existing, desired)
+}
+
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func storage(spec map[string]interface{}) (resource.Quantity, bool) {
+	v, ok, _ := unstructured.NestedFieldNoCopy(spec, "resources", "requests", "storage")
+	if !ok || v == nil {
+		return resource.Quantity{}, false
+	}
+	q, err := resource.ParseQuantity(fmt.Sprint(v))
+	if err != nil {
+		return resource.Quantity{}, false
+	}
+	return q, true
+}
+
+func claimTemplates(spec map[string]interface{}) map[string]map[string]interface{} {
+	result := map[string]map[string]interface{}{}
+	templates, _, _ := unstructured.NestedSlice(spec, "volumeClaimTemplates")
+	for _, t := range templates {
+		template, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(template, "metadata", "name")
+		result[name] = template
+	}
+	return result
+}
+
+func spec(obj map[string]interface{}) map[string]interface{} {
+	spec, _, _ := unstructured.NestedMap(obj, "spec")
+	return spec
+}
+
+func key(obj *unstructured.Unstructured) string {
+	return obj.GroupVersionKind().GroupKind().String() + "/" + name(obj)
+}
+
+func name(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
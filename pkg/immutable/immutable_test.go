@@ -0,0 +1,145 @@
+package immutable
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newStatefulSet(serviceName, size string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "StatefulSet",
+		"metadata":   map[string]interface{}{"name": "db", "namespace": "app"},
+		"spec": map[string]interface{}{
+			"replicas":    int64(1),
+			"serviceName": serviceName,
+			"volumeClaimTemplates": []interface{}{
+				map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "data"},
+					"spec": map[string]interface{}{
+						"accessModes": []interface{}{"ReadWriteOnce"},
+						"resources":   map[string]interface{}{"requests": map[string]interface{}{"storage": size}},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func newClaim(storageClass, size string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolumeClaim",
+		"metadata":   map[string]interface{}{"name": "data"},
+		"spec": map[string]interface{}{
+			"storageClassName": storageClass,
+			"resources":        map[string]interface{}{"requests": map[string]interface{}{"storage": size}},
+		},
+	}}
+}
+
+func TestChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing runtime.Object
+		desired  runtime.Object
+		want     []string
+	}{
+		{
+			name:     "unchanged",
+			existing: newStatefulSet("db", "1Gi"),
+			desired:  newStatefulSet("db", "1024Mi"),
+		},
+		{
+			name:     "service name",
+			existing: newStatefulSet("db", "1Gi"),
+			desired:  newStatefulSet("db-headless", "1Gi"),
+			want:     []string{"StatefulSet app/db changes immutable field spec.serviceName"},
+		},
+		{
+			name:     "claim template size",
+			existing: newStatefulSet("db", "1Gi"),
+			desired:  newStatefulSet("db", "2Gi"),
+			want:     []string{"StatefulSet app/db changes immutable field spec.volumeClaimTemplates[data].spec.resources.requests.storage"},
+		},
+		{
+			name:     "defaulted storage class",
+			existing: newClaim("", "1Gi"),
+			desired:  newClaim("fast", "1Gi"),
+		},
+		{
+			name:     "claim grows",
+			existing: newClaim("fast", "1Gi"),
+			desired:  newClaim("fast", "2Gi"),
+		},
+		{
+			name:     "claim shrinks and changes class",
+			existing: newClaim("fast", "2Gi"),
+			desired:  newClaim("slow", "1Gi"),
+			want: []string{
+				"PersistentVolumeClaim data changes immutable field spec.resources.requests.storage",
+				"PersistentVolumeClaim data changes immutable field spec.storageClassName",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Changes([]runtime.Object{tt.existing}, []runtime.Object{tt.desired})
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSpecHash(t *testing.T) {
+	newJob := func(image string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "batch/v1",
+			"kind":       "Job",
+			"metadata":   map[string]interface{}{"name": "migrate"},
+			"spec": map[string]interface{}{
+				"backoffLimit": int64(2),
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{map[string]interface{}{"name": "migrate", "image": image}},
+					},
+				},
+			},
+		}}
+	}
+
+	if !IsReplaceable(newJob("db:1")) || IsReplaceable(newClaim("fast", "1Gi")) {
+		t.Error("expected only jobs to be replaceable")
+	}
+	if SpecHash(newJob("db:1")) != SpecHash(newJob("db:1")) {
+		t.Error("expected equal specs to have the same hash")
+	}
+	if SpecHash(newJob("db:1")) == SpecHash(newJob("db:2")) {
+		t.Error("expected changed specs to have different hashes")
+	}
+
+	newSecret := func(password string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "db", "labels": map[string]interface{}{"app": password}},
+			"data":       map[string]interface{}{"password": password},
+		}}
+	}
+	if SpecHash(newSecret("a")) == SpecHash(newSecret("b")) {
+		t.Error("expected changed secrets to have different hashes")
+	}
+	secret := newSecret("a")
+	secret.SetLabels(nil)
+	if SpecHash(secret) != SpecHash(newSecret("a")) {
+		t.Error("expected metadata not to be hashed")
+	}
+}
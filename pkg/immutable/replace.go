@@ -0,0 +1,45 @@
+package immutable
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	job = schema.GroupKind{Group: "batch", Kind: "Job"}
+	pod = schema.GroupKind{Kind: "Pod"}
+)
+
+// IsReplaceable returns true for the kinds, whose spec is mostly immutable,
+// so they are replaced instead of updated, when their spec changes.
+func IsReplaceable(obj *unstructured.Unstructured) bool {
+	gk := obj.GroupVersionKind().GroupKind()
+	return gk == job || gk == pod
+}
+
+// SpecHash returns a hash of the resource's spec, as rendered. Resources
+// without a spec, like Secrets, are hashed by their other top-level fields,
+// except for the metadata and status. The API server defaults fields of the
+// spec, e.g. the selector of Jobs, so the hash is stored on the resource,
+// instead of comparing live specs.
+func SpecHash(obj *unstructured.Unstructured) string {
+	content := map[string]interface{}{}
+	for k, v := range obj.Object {
+		switch k {
+		case "apiVersion", "kind", "metadata", "status":
+		default:
+			content[k] = v
+		}
+	}
+	// maps are marshalled with sorted keys
+	data, err := json.Marshal(content)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,113 @@
+// Package kubeapis finds the Kubernetes APIs used by rendered resources and knows the APIs, which were removed from the API server, and the versions they were removed in. (fleetcontroller)
+package kubeapis
+
+import (
+	"github.com/Masterminds/semver/v3"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+// removals lists the group/version/kinds removed from Kubernetes, as
+// documented in the deprecated API migration guide
+var removals = []fleet.RemovedAPI{
+	{API: "extensions/v1beta1/DaemonSet", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{API: "extensions/v1beta1/Deployment", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{API: "extensions/v1beta1/ReplicaSet", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{API: "extensions/v1beta1/NetworkPolicy", RemovedIn: "1.16", Replacement: "networking.k8s.io/v1"},
+	{API: "extensions/v1beta1/PodSecurityPolicy", RemovedIn: "1.16", Replacement: "policy/v1beta1"},
+	{API: "apps/v1beta1/Deployment", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{API: "apps/v1beta1/StatefulSet", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{API: "apps/v1beta2/DaemonSet", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{API: "apps/v1beta2/Deployment", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{API: "apps/v1beta2/ReplicaSet", RemovedIn: "1.16", Replacement: "apps/v1"},
+	{API: "apps/v1beta2/StatefulSet", RemovedIn: "1.16", Replacement: "apps/v1"},
+
+	{API: "admissionregistration.k8s.io/v1beta1/MutatingWebhookConfiguration", RemovedIn: "1.22", Replacement: "admissionregistration.k8s.io/v1"},
+	{API: "admissionregistration.k8s.io/v1beta1/ValidatingWebhookConfiguration", RemovedIn: "1.22", Replacement: "admissionregistration.k8s.io/v1"},
+	{API: "apiextensions.k8s.io/v1beta1/CustomResourceDefinition", RemovedIn: "1.22", Replacement: "apiextensions.k8s.io/v1"},
+	{API: "apiregistration.k8s.io/v1beta1/APIService", RemovedIn: "1.22", Replacement: "apiregistration.k8s.io/v1"},
+	{API: "authentication.k8s.io/v1beta1/TokenReview", RemovedIn: "1.22", Replacement: "authentication.k8s.io/v1"},
+	{API: "authorization.k8s.io/v1beta1/LocalSubjectAccessReview", RemovedIn: "1.22", Replacement: "authorization.k8s.io/v1"},
+	{API: "authorization.k8s.io/v1beta1/SelfSubjectAccessReview", RemovedIn: "1.22", Replacement: "authorization.k8s.io/v1"},
+	{API: "authorization.k8s.io/v1beta1/SubjectAccessReview", RemovedIn: "1.22", Replacement: "authorization.k8s.io/v1"},
+	{API: "certificates.k8s.io/v1beta1/CertificateSigningRequest", RemovedIn: "1.22", Replacement: "certificates.k8s.io/v1"},
+	{API: "coordination.k8s.io/v1beta1/Lease", RemovedIn: "1.22", Replacement: "coordination.k8s.io/v1"},
+	{API: "extensions/v1beta1/Ingress", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+	{API: "networking.k8s.io/v1beta1/Ingress", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+	{API: "networking.k8s.io/v1beta1/IngressClass", RemovedIn: "1.22", Replacement: "networking.k8s.io/v1"},
+	{API: "rbac.authorization.k8s.io/v1beta1/ClusterRole", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+	{API: "rbac.authorization.k8s.io/v1beta1/ClusterRoleBinding", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+	{API: "rbac.authorization.k8s.io/v1beta1/Role", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+	{API: "rbac.authorization.k8s.io/v1beta1/RoleBinding", RemovedIn: "1.22", Replacement: "rbac.authorization.k8s.io/v1"},
+	{API: "scheduling.k8s.io/v1beta1/PriorityClass", RemovedIn: "1.22", Replacement: "scheduling.k8s.io/v1"},
+	{API: "storage.k8s.io/v1beta1/CSIDriver", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+	{API: "storage.k8s.io/v1beta1/CSINode", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+	{API: "storage.k8s.io/v1beta1/StorageClass", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+	{API: "storage.k8s.io/v1beta1/VolumeAttachment", RemovedIn: "1.22", Replacement: "storage.k8s.io/v1"},
+
+	{API: "batch/v1beta1/CronJob", RemovedIn: "1.25", Replacement: "batch/v1"},
+	{API: "discovery.k8s.io/v1beta1/EndpointSlice", RemovedIn: "1.25", Replacement: "discovery.k8s.io/v1"},
+	{API: "events.k8s.io/v1beta1/Event", RemovedIn: "1.25", Replacement: "events.k8s.io/v1"},
+	{API: "autoscaling/v2beta1/HorizontalPodAutoscaler", RemovedIn: "1.25", Replacement: "autoscaling/v2"},
+	{API: "policy/v1beta1/PodDisruptionBudget", RemovedIn: "1.25", Replacement: "policy/v1"},
+	{API: "policy/v1beta1/PodSecurityPolicy", RemovedIn: "1.25"},
+	{API: "node.k8s.io/v1beta1/RuntimeClass", RemovedIn: "1.25", Replacement: "node.k8s.io/v1"},
+
+	{API: "flowcontrol.apiserver.k8s.io/v1beta1/FlowSchema", RemovedIn: "1.26", Replacement: "flowcontrol.apiserver.k8s.io/v1beta3"},
+	{API: "flowcontrol.apiserver.k8s.io/v1beta1/PriorityLevelConfiguration", RemovedIn: "1.26", Replacement: "flowcontrol.apiserver.k8s.io/v1beta3"},
+	{API: "autoscaling/v2beta2/HorizontalPodAutoscaler", RemovedIn: "1.26", Replacement: "autoscaling/v2"},
+
+	{API: "storage.k8s.io/v1beta1/CSIStorageCapacity", RemovedIn: "1.27", Replacement: "storage.k8s.io/v1"},
+
+	{API: "flowcontrol.apiserver.k8s.io/v1beta2/FlowSchema", RemovedIn: "1.29", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{API: "flowcontrol.apiserver.k8s.io/v1beta2/PriorityLevelConfiguration", RemovedIn: "1.29", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+
+	{API: "flowcontrol.apiserver.k8s.io/v1beta3/FlowSchema", RemovedIn: "1.32", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+	{API: "flowcontrol.apiserver.k8s.io/v1beta3/PriorityLevelConfiguration", RemovedIn: "1.32", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// Removals returns the removals of the used group/version/kinds, in any
+// Kubernetes version
+func Removals(used []string) []fleet.RemovedAPI {
+	var result []fleet.RemovedAPI
+	for _, api := range used {
+		for _, r := range removals {
+			if r.API == api {
+				result = append(result, r)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Removed returns the removals of the used group/version/kinds, which the
+// Kubernetes version no longer serves. Nothing is removed from invalid
+// versions.
+func Removed(used []string, kubernetesVersion string) []fleet.RemovedAPI {
+	version, err := semver.NewVersion(kubernetesVersion)
+	if err != nil {
+		return nil
+	}
+
+	var result []fleet.RemovedAPI
+	for _, r := range Removals(used) {
+		if !Before(version, r.RemovedIn) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// Before returns true, if the version's minor release precedes the minor
+// version, like "1.25"
+func Before(version *semver.Version, minor string) bool {
+	v, err := semver.NewVersion(minor)
+	if err != nil {
+		return true
+	}
+	if version.Major() != v.Major() {
+		return version.Major() < v.Major()
+	}
+	return version.Minor() < v.Minor()
+}
@@ -0,0 +1,34 @@
+package kubeapis
+
+import (
+	"reflect"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestRemoved(t *testing.T) {
+	used := []string{"apps/v1/Deployment", "batch/v1beta1/CronJob", "flowcontrol.apiserver.k8s.io/v1beta2/FlowSchema", "policy/v1beta1/PodSecurityPolicy"}
+
+	tests := []struct {
+		version string
+		want    []fleet.RemovedAPI
+	}{
+		{version: "v1.24.9+k3s1"},
+		{version: "v1.25.0", want: []fleet.RemovedAPI{
+			{API: "batch/v1beta1/CronJob", RemovedIn: "1.25", Replacement: "batch/v1"},
+			{API: "policy/v1beta1/PodSecurityPolicy", RemovedIn: "1.25"},
+		}},
+		{version: "v1.29.1-eks-123", want: []fleet.RemovedAPI{
+			{API: "batch/v1beta1/CronJob", RemovedIn: "1.25", Replacement: "batch/v1"},
+			{API: "flowcontrol.apiserver.k8s.io/v1beta2/FlowSchema", RemovedIn: "1.29", Replacement: "flowcontrol.apiserver.k8s.io/v1"},
+			{API: "policy/v1beta1/PodSecurityPolicy", RemovedIn: "1.25"},
+		}},
+		{version: "invalid"},
+	}
+	for _, tt := range tests {
+		if got := Removed(used, tt.version); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.version, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package kubeapis
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Used returns the sorted group/version/kinds of the resources, like
+// "apps/v1/Deployment", which are not defined by CRDs among the resources
+func Used(objs []runtime.Object) []string {
+	defined := map[schema.GroupKind]bool{}
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok || u.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(u.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(u.Object, "spec", "names", "kind")
+		defined[schema.GroupKind{Group: group, Kind: kind}] = true
+	}
+
+	seen := map[string]bool{}
+	var result []string
+	for _, obj := range objs {
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		if gvk.Kind == "" || defined[gvk.GroupKind()] {
+			continue
+		}
+		api := gvk.GroupVersion().String() + "/" + gvk.Kind
+		if !seen[api] {
+			seen[api] = true
+			result = append(result, api)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
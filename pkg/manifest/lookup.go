@@ -26,7 +26,16 @@ func (l *lookup) Get(id string) (*Manifest, error) {
 		return nil, err
 	}
 
-	bytes, err := content.GUnzip(c.Content)
+	data := c.Content
+	for _, chunk := range c.Chunks {
+		part, err := l.content.Get(chunk, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, part.Content...)
+	}
+
+	bytes, err := content.GUnzip(data)
 	if err != nil {
 		return nil, err
 	}
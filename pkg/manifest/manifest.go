@@ -14,7 +14,11 @@ import (
 )
 
 type Manifest struct {
-	Commit    string                 `json:"-"`
+	Commit string `json:"-"`
+	// Bundle is the full name of the deployed bundle
+	Bundle string `json:"-"`
+	// Labels are added to all deployed resources
+	Labels    map[string]string      `json:"-"`
 	Resources []fleet.BundleResource `json:"resources,omitempty"`
 	raw       []byte
 	digest    string
@@ -1,6 +1,7 @@
 package manifest
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/rancher/fleet/pkg/content"
@@ -11,6 +12,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// MaxContentSize is the maximum size of the compressed content stored in a
+// single content object. Larger content is split into chunks, to stay below
+// etcd's size limit.
+const MaxContentSize = 1024 * 1024
+
 type Store interface {
 	Store(manifest *Manifest) (string, error)
 }
@@ -47,11 +53,46 @@ func (c *contentStore) Store(manifest *Manifest) (string, error) {
 		return id, err
 	}
 
+	if len(compressed) <= MaxContentSize {
+		_, err = c.content.Create(&fleet.Content{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: id,
+			},
+			Content: compressed,
+		})
+		return id, err
+	}
+
+	// the chunks are created first, so agents never see incomplete content
+	var chunks []string
+	for i := 0; len(compressed) > 0; i++ {
+		size := MaxContentSize
+		if len(compressed) < size {
+			size = len(compressed)
+		}
+		name := chunkName(id, i)
+		_, err = c.content.Create(&fleet.Content{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Content: compressed[:size],
+		})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return id, err
+		}
+		chunks = append(chunks, name)
+		compressed = compressed[size:]
+	}
+
 	_, err = c.content.Create(&fleet.Content{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: id,
 		},
-		Content: compressed,
+		Chunks: chunks,
 	})
 	return id, err
 }
+
+func chunkName(id string, i int) string {
+	return fmt.Sprintf("%s-chunk-%d", id, i)
+}
@@ -0,0 +1,92 @@
+package manifest
+
+import (
+	"encoding/base64"
+	"math/rand"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeContents struct {
+	fleetcontrollers.ContentClient
+	objs map[string]*fleet.Content
+}
+
+func (f *fakeContents) Create(c *fleet.Content) (*fleet.Content, error) {
+	if _, ok := f.objs[c.Name]; ok {
+		return nil, apierrors.NewAlreadyExists(schema.GroupResource{Resource: "contents"}, c.Name)
+	}
+	f.objs[c.Name] = c.DeepCopy()
+	return c, nil
+}
+
+func (f *fakeContents) Get(name string, _ metav1.GetOptions) (*fleet.Content, error) {
+	if c, ok := f.objs[name]; ok {
+		return c.DeepCopy(), nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "contents"}, name)
+}
+
+type fakeContentCache struct {
+	fleetcontrollers.ContentCache
+	contents *fakeContents
+}
+
+func (f *fakeContentCache) Get(name string) (*fleet.Content, error) {
+	return f.contents.Get(name, metav1.GetOptions{})
+}
+
+func TestStoreChunks(t *testing.T) {
+	// random data doesn't compress, so it needs three chunks
+	data := make([]byte, MaxContentSize*2)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	for _, tt := range []struct {
+		name    string
+		content string
+		chunks  int
+	}{
+		{name: "small", content: "kind: ConfigMap", chunks: 0},
+		{name: "large", content: base64.StdEncoding.EncodeToString(data), chunks: 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			contents := &fakeContents{objs: map[string]*fleet.Content{}}
+			store := &contentStore{contentCache: &fakeContentCache{contents: contents}, content: contents}
+
+			m, _ := New([]fleet.BundleResource{{Name: "cm.yaml", Content: tt.content}})
+			id, err := store.Store(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := contents.objs[id]
+			if len(c.Chunks) != tt.chunks || len(contents.objs) != tt.chunks+1 {
+				t.Fatalf("expected %d chunks, got %v", tt.chunks, c.Chunks)
+			}
+			for _, chunk := range c.Chunks {
+				if len(contents.objs[chunk].Content) > MaxContentSize {
+					t.Errorf("expected chunk %s to be at most %d bytes", chunk, MaxContentSize)
+				}
+			}
+
+			// storing again is a no-op
+			if _, err := store.Store(m); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := NewLookup(contents).Get(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Resources) != 1 || got.Resources[0].Content != tt.content {
+				t.Error("expected the chunks to be reassembled")
+			}
+		})
+	}
+}
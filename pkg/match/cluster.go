@@ -64,3 +64,41 @@ func (t *ClusterMatcher) Match(clusterName, clusterGroup string, clusterGroupLab
 	}
 	return true
 }
+
+// Exclusion matches clusters, which are excluded from a target, either by
+// their labels or by one of their cluster groups. A nil Exclusion doesn't
+// exclude any cluster.
+type Exclusion struct {
+	clusterGroup string
+	selector     labels.Selector
+}
+
+// NewExclusion returns nil, if neither a cluster group nor a selector is
+// given.
+func NewExclusion(clusterGroup string, clusterSelector *metav1.LabelSelector) (*Exclusion, error) {
+	if clusterGroup == "" && clusterSelector == nil {
+		return nil, nil
+	}
+
+	e := &Exclusion{clusterGroup: clusterGroup}
+	if clusterSelector != nil {
+		selector, err := toSelector(clusterSelector)
+		if err != nil {
+			return nil, err
+		}
+		e.selector = selector
+	}
+	return e, nil
+}
+
+// Excludes returns true, if the cluster is a member of the excluded
+// cluster group or its labels match the exclude selector.
+func (e *Exclusion) Excludes(clusterGroups map[string]map[string]string, clusterLabels map[string]string) bool {
+	if e == nil {
+		return false
+	}
+	if _, ok := clusterGroups[e.clusterGroup]; ok && e.clusterGroup != "" {
+		return true
+	}
+	return e.selector != nil && !e.selector.Empty() && e.selector.Matches(labels.Set(clusterLabels))
+}
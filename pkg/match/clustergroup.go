@@ -0,0 +1,88 @@
+package match
+
+import (
+	"sync"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/lru"
+)
+
+// ClusterGroupMatcher decides which clusters are members of a cluster group
+type ClusterGroupMatcher struct {
+	selector   labels.Selector
+	expression *Expression
+}
+
+// maxCachedExpressions limits the number of compiled selector expressions,
+// which are cached
+const maxCachedExpressions = 500
+
+var (
+	expressionsLock sync.Mutex
+	// expressions caches compiled selector expressions of cluster groups,
+	// as membership is evaluated for each cluster and bundle. The least
+	// recently used expressions are evicted.
+	expressions = lru.New(maxCachedExpressions)
+)
+
+// NewClusterGroupMatcher returns the matcher for the cluster group's
+// selector and selector expression, or nil if the group has neither and
+// contains no clusters.
+func NewClusterGroupMatcher(cg *fleet.ClusterGroup) (*ClusterGroupMatcher, error) {
+	if cg.Spec.Selector == nil && cg.Spec.SelectorExpression == "" {
+		return nil, nil
+	}
+
+	m := &ClusterGroupMatcher{selector: labels.Everything()}
+	if cg.Spec.Selector != nil {
+		sel, err := metav1.LabelSelectorAsSelector(cg.Spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+		m.selector = sel
+	}
+	if cg.Spec.SelectorExpression != "" {
+		expr, err := cachedExpression(cg.Spec.SelectorExpression)
+		if err != nil {
+			return nil, err
+		}
+		m.expression = expr
+	}
+	return m, nil
+}
+
+// Selector returns the label selector, which preselects the clusters, e.g.
+// when listing them from a cache
+func (m *ClusterGroupMatcher) Selector() labels.Selector {
+	return m.selector
+}
+
+// Match returns true, if the cluster is a member of the group
+func (m *ClusterGroupMatcher) Match(cluster *fleet.Cluster) bool {
+	if !m.selector.Matches(labels.Set(cluster.Labels)) {
+		return false
+	}
+	if m.expression == nil {
+		return true
+	}
+	ok, err := m.expression.Match(cluster)
+	return err == nil && ok
+}
+
+func cachedExpression(expr string) (*Expression, error) {
+	expressionsLock.Lock()
+	defer expressionsLock.Unlock()
+
+	if e, ok := expressions.Get(expr); ok {
+		return e.(*Expression), nil
+	}
+	e, err := NewExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+	expressions.Add(expr, e)
+	return e, nil
+}
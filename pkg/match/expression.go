@@ -0,0 +1,123 @@
+package match
+
+import (
+	"fmt"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Expression is a CEL expression, which selects clusters. It can use the
+// variables name, namespace, labels, annotations and status of the cluster,
+// as well as kubernetesMajor and kubernetesMinor, the Kubernetes version
+// reported by the cluster's agent, which are 0 if it's unknown, and the
+// capabilities reported by the agent: nodeArchitectures, apiVersions,
+// cloudProvider and cni.
+type Expression struct {
+	program cel.Program
+}
+
+// expressionCostLimit stops evaluating expressions, which are too
+// expensive, e.g. nested comprehensions over the cluster's API versions.
+// It leaves room for a few comprehensions over hundreds of list entries.
+const expressionCostLimit = 100000
+
+var expressionEnv = func() *cel.Env {
+	env, err := cel.NewEnv(
+		cel.Variable("name", cel.StringType),
+		cel.Variable("namespace", cel.StringType),
+		cel.Variable("labels", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("annotations", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("status", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("kubernetesMajor", cel.IntType),
+		cel.Variable("kubernetesMinor", cel.IntType),
+		cel.Variable("nodeArchitectures", cel.ListType(cel.StringType)),
+		cel.Variable("apiVersions", cel.ListType(cel.StringType)),
+		cel.Variable("cloudProvider", cel.StringType),
+		cel.Variable("cni", cel.StringType),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return env
+}()
+
+// NewExpression compiles the expression, which has to evaluate to a bool.
+func NewExpression(expr string) (*Expression, error) {
+	ast, issues := expressionEnv.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid cluster selector expression %q: %w", expr, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("invalid cluster selector expression %q: evaluates to %s, not bool", expr, ast.OutputType())
+	}
+	program, err := expressionEnv.Program(ast, cel.CostLimit(expressionCostLimit))
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{program: program}, nil
+}
+
+// Match evaluates the expression for the cluster. Errors, like accessing
+// a missing label or exceeding the cost limit, are returned.
+func (e *Expression) Match(cluster *fleet.Cluster) (bool, error) {
+	// converting the status is expensive, it's only done if the
+	// expression uses it
+	var status interface{}
+	lazyStatus := func() interface{} {
+		if status == nil {
+			s, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&cluster.Status)
+			if err != nil {
+				status = types.NewErr("converting cluster status: %v", err)
+			} else {
+				status = s
+			}
+		}
+		return status
+	}
+
+	var major, minor int64
+	if v, err := semver.NewVersion(cluster.Status.Agent.KubernetesVersion); err == nil {
+		major, minor = int64(v.Major()), int64(v.Minor())
+	}
+
+	labels := cluster.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	annotations := cluster.Annotations
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	agent := cluster.Status.Agent
+	out, _, err := e.program.Eval(map[string]interface{}{
+		"name":              cluster.Name,
+		"namespace":         cluster.Namespace,
+		"labels":            labels,
+		"annotations":       annotations,
+		"status":            lazyStatus,
+		"kubernetesMajor":   major,
+		"kubernetesMinor":   minor,
+		"nodeArchitectures": nonNil(agent.NodeArchitectures),
+		"apiVersions":       nonNil(agent.APIVersions),
+		"cloudProvider":     agent.CloudProvider,
+		"cni":               agent.CNI,
+	})
+	if err != nil {
+		return false, err
+	}
+	return out == types.True, nil
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package match
+
+import (
+	"fmt"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+)
+
+func TestExpressionStatus(t *testing.T) {
+	e, err := NewExpression(`status.agent.kubernetesVersion == "v1.25.4" && kubernetesMinor == 25`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := &fleet.Cluster{}
+	cluster.Status.Agent.KubernetesVersion = "v1.25.4"
+	if ok, err := e.Match(cluster); err != nil || !ok {
+		t.Errorf("expected expression to match the cluster status, got %v, %v", ok, err)
+	}
+}
+
+func TestExpressionCostLimit(t *testing.T) {
+	e, err := NewExpression(`apiVersions.all(a, apiVersions.all(b, apiVersions.all(c, a != "" || b != c)))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := &fleet.Cluster{}
+	for i := 0; i < 100; i++ {
+		cluster.Status.Agent.APIVersions = append(cluster.Status.Agent.APIVersions, fmt.Sprintf("example.com/v%d", i))
+	}
+	if _, err := e.Match(cluster); err == nil {
+		t.Error("expected expression to exceed the cost limit")
+	}
+}
+
+func TestCachedExpressionEviction(t *testing.T) {
+	first, err := cachedExpression(`name == "first"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxCachedExpressions; i++ {
+		if _, err := cachedExpression(fmt.Sprintf(`name == "cluster-%d"`, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if expressions.Len() != maxCachedExpressions {
+		t.Errorf("expected %d cached expressions, got %d", maxCachedExpressions, expressions.Len())
+	}
+	if e, _ := cachedExpression(`name == "first"`); e == first {
+		t.Error("expected least recently used expression to be evicted")
+	}
+}
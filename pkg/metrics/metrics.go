@@ -0,0 +1,268 @@
+// Package metrics exports the state of bundles, bundle deployments and gitrepos as prometheus gauges, and how controllers share their workers between workspaces. (fleetcontroller)
+//
+// The state gauges follow the state set pattern: every object has a series
+// for each possible state, with the value 1 for its current state and 0
+// otherwise.
+package metrics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/summary"
+
+	"github.com/rancher/wrangler/pkg/kv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "fleet"
+
+var (
+	enabled bool
+
+	bundleState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "bundle_state",
+			Help:      "Current state of a bundle, aggregated over its deployments",
+		},
+		[]string{"namespace", "bundle", "repo", "state"},
+	)
+	bundleDeploymentState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "bundledeployment_state",
+			Help:      "Current state of a bundle deployment on a cluster",
+		},
+		[]string{"cluster_namespace", "cluster", "bundle_namespace", "bundle", "state"},
+	)
+	gitRepoCommitInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "gitrepo_commit_info",
+			Help:      "Commit currently deployed by a gitrepo, the value is always 1",
+		},
+		[]string{"namespace", "gitrepo", "repo", "branch", "commit"},
+	)
+	workspaceQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "workspace_queue_depth",
+			Help:      "Objects of a workspace deferred by a controller, because the workspace used its share of workers",
+		},
+		[]string{"controller", "namespace"},
+	)
+	workspaceWorkers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "workspace_workers",
+			Help:      "Workers of a controller currently reconciling objects of a workspace",
+		},
+		[]string{"controller", "namespace"},
+	)
+	workspaceProcessingSeconds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "workspace_processing_seconds_total",
+			Help:      "Time a controller spent reconciling objects of a workspace, its rate relative to all workspaces is the workspace's processing share",
+		},
+		[]string{"controller", "namespace"},
+	)
+	workspaceReconciles = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "workspace_reconciles_total",
+			Help:      "Objects of a workspace reconciled by a controller",
+		},
+		[]string{"controller", "namespace"},
+	)
+
+	// states are all possible bundle states, sorted
+	states = func() []string {
+		var result []string
+		for state := range fleet.StateRank {
+			result = append(result, string(state))
+		}
+		sort.Strings(result)
+		return result
+	}()
+)
+
+// Enable registers the gauges with the default prometheus registry. It
+// needs to be called before the controllers are registered.
+func Enable() {
+	if enabled {
+		return
+	}
+	enabled = true
+	prometheus.MustRegister(bundleState, bundleDeploymentState, gitRepoCommitInfo,
+		workspaceQueueDepth, workspaceWorkers, workspaceProcessingSeconds, workspaceReconciles)
+}
+
+// Enabled returns true if metrics are exported
+func Enabled() bool {
+	return enabled
+}
+
+// SetWorkspaceQueue reports the deferred objects and busy workers of a
+// controller for a workspace. The series are deleted, once both are zero.
+func SetWorkspaceQueue(controller, namespace string, deferred, workers int) {
+	labels := prometheus.Labels{"controller": controller, "namespace": namespace}
+	if deferred == 0 && workers == 0 {
+		workspaceQueueDepth.Delete(labels)
+		workspaceWorkers.Delete(labels)
+		return
+	}
+	workspaceQueueDepth.With(labels).Set(float64(deferred))
+	workspaceWorkers.With(labels).Set(float64(workers))
+}
+
+// ObserveWorkspaceReconcile counts a reconcile of a workspace's object
+func ObserveWorkspaceReconcile(controller, namespace string, d time.Duration) {
+	labels := prometheus.Labels{"controller": controller, "namespace": namespace}
+	workspaceReconciles.With(labels).Inc()
+	workspaceProcessingSeconds.With(labels).Add(d.Seconds())
+}
+
+type handler struct {
+	lock sync.Mutex
+	// labels of the series per object key, to delete them when the
+	// object is deleted or its labels change
+	series map[string]prometheus.Labels
+}
+
+func Register(ctx context.Context,
+	bundles fleetcontrollers.BundleController,
+	bundleDeployments fleetcontrollers.BundleDeploymentController,
+	gitRepos fleetcontrollers.GitRepoController) {
+	h := &handler{
+		series: map[string]prometheus.Labels{},
+	}
+
+	bundles.OnChange(ctx, "bundle-metrics", h.OnBundleChange)
+	bundleDeployments.OnChange(ctx, "bundledeployment-metrics", h.OnBundleDeploymentChange)
+	gitRepos.OnChange(ctx, "gitrepo-metrics", h.OnGitRepoChange)
+}
+
+func (h *handler) OnBundleChange(key string, bundle *fleet.Bundle) (*fleet.Bundle, error) {
+	if bundle == nil {
+		h.deleteStates(bundleState, "bundle/"+key)
+		return nil, nil
+	}
+
+	state := string(summary.GetSummaryState(bundle.Status.Summary))
+	if state == "" {
+		state = string(fleet.Ready)
+	}
+	h.setStates(bundleState, "bundle/"+key, prometheus.Labels{
+		"namespace": bundle.Namespace,
+		"bundle":    bundle.Name,
+		"repo":      bundle.Labels[fleet.RepoLabel],
+	}, state)
+
+	return bundle, nil
+}
+
+func (h *handler) OnBundleDeploymentChange(key string, bd *fleet.BundleDeployment) (*fleet.BundleDeployment, error) {
+	if bd == nil {
+		h.deleteStates(bundleDeploymentState, "bundledeployment/"+key)
+		return nil, nil
+	}
+
+	h.setStates(bundleDeploymentState, "bundledeployment/"+key, prometheus.Labels{
+		"cluster_namespace": bd.Labels[fleet.ClusterNamespaceLabel],
+		"cluster":           fleet.DeploymentClusterName(bd),
+		"bundle_namespace":  bd.Labels[fleet.BundleNamespaceLabel],
+		"bundle":            fleet.DeploymentBundleName(bd),
+	}, string(summary.GetDeploymentState(bd)))
+
+	return bd, nil
+}
+
+func (h *handler) OnGitRepoChange(key string, gitrepo *fleet.GitRepo) (*fleet.GitRepo, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	seriesKey := "gitrepo/" + key
+	if old, ok := h.series[seriesKey]; ok {
+		gitRepoCommitInfo.Delete(old)
+		delete(h.series, seriesKey)
+	}
+	if gitrepo == nil || gitrepo.Status.Commit == "" {
+		return gitrepo, nil
+	}
+
+	ns, name := kv.Split(key, "/")
+	labels := prometheus.Labels{
+		"namespace": ns,
+		"gitrepo":   name,
+		"repo":      gitrepo.Spec.Repo,
+		"branch":    gitrepo.Spec.Branch,
+		"commit":    gitrepo.Status.Commit,
+	}
+	gitRepoCommitInfo.With(labels).Set(1)
+	h.series[seriesKey] = labels
+
+	return gitrepo, nil
+}
+
+// setStates sets the series of the current state to 1 and of all other
+// states to 0
+func (h *handler) setStates(gauge *prometheus.GaugeVec, key string, labels prometheus.Labels, current string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if old, ok := h.series[key]; ok && !equal(old, labels) {
+		deleteStates(gauge, old)
+	}
+	h.series[key] = labels
+
+	for _, state := range states {
+		value := 0.0
+		if state == current {
+			value = 1
+		}
+		gauge.With(withState(labels, state)).Set(value)
+	}
+}
+
+func (h *handler) deleteStates(gauge *prometheus.GaugeVec, key string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if old, ok := h.series[key]; ok {
+		deleteStates(gauge, old)
+		delete(h.series, key)
+	}
+}
+
+func deleteStates(gauge *prometheus.GaugeVec, labels prometheus.Labels) {
+	for _, state := range states {
+		gauge.Delete(withState(labels, state))
+	}
+}
+
+func withState(labels prometheus.Labels, state string) prometheus.Labels {
+	result := prometheus.Labels{"state": state}
+	for k, v := range labels {
+		result[k] = v
+	}
+	return result
+}
+
+func equal(a, b prometheus.Labels) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
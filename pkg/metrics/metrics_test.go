@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/name"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBundleDeploymentState(t *testing.T) {
+	h := &handler{series: map[string]prometheus.Labels{}}
+	cluster := strings.Repeat("c", 70)
+	bd := &fleet.BundleDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				fleet.ClusterNamespaceLabel: "fleet-default",
+				fleet.ClusterLabel:          name.LabelValue(cluster),
+				fleet.BundleNamespaceLabel:  "fleet-default",
+				fleet.BundleLabel:           "app",
+			},
+			Annotations: map[string]string{fleet.ClusterAnnotation: cluster},
+		},
+		Spec: fleet.BundleDeploymentSpec{DeploymentID: "2"},
+	}
+
+	_, _ = h.OnBundleDeploymentChange("ns/app", bd)
+	if n := testutil.CollectAndCount(bundleDeploymentState); n != len(states) {
+		t.Fatalf("expected %d series, got %d", len(states), n)
+	}
+	labels := prometheus.Labels{
+		"cluster_namespace": "fleet-default",
+		"cluster":           cluster,
+		"bundle_namespace":  "fleet-default",
+		"bundle":            "app",
+	}
+	if v := testutil.ToFloat64(bundleDeploymentState.With(withState(labels, string(fleet.WaitApplied)))); v != 1 {
+		t.Errorf("expected WaitApplied to be 1, got %v", v)
+	}
+	if v := testutil.ToFloat64(bundleDeploymentState.With(withState(labels, string(fleet.Ready)))); v != 0 {
+		t.Errorf("expected Ready to be 0, got %v", v)
+	}
+
+	_, _ = h.OnBundleDeploymentChange("ns/app", nil)
+	if n := testutil.CollectAndCount(bundleDeploymentState); n != 0 {
+		t.Errorf("expected series to be deleted, got %d", n)
+	}
+}
+
+func TestGitRepoCommitInfo(t *testing.T) {
+	h := &handler{series: map[string]prometheus.Labels{}}
+	gitrepo := &fleet.GitRepo{
+		Spec:   fleet.GitRepoSpec{Repo: "https://example.com/repo", Branch: "main"},
+		Status: fleet.GitRepoStatus{Commit: "abc"},
+	}
+
+	_, _ = h.OnGitRepoChange("ns/repo", gitrepo)
+	gitrepo.Status.Commit = "def"
+	_, _ = h.OnGitRepoChange("ns/repo", gitrepo)
+
+	if n := testutil.CollectAndCount(gitRepoCommitInfo); n != 1 {
+		t.Errorf("expected only the latest commit, got %d series", n)
+	}
+}
@@ -35,6 +35,19 @@ func Limit(s string, count int) string {
 	return fmt.Sprintf("%s-%s", s[:count-6], Hex(s, 5))
 }
 
+// MaxLabelValueLen is the maximum length of a label value
+const MaxLabelValueLen = 63
+
+// LabelValue returns s, if it fits into a label value. Longer strings are
+// truncated and a stable hash of the full string is appended, so distinct
+// strings with a common prefix don't collide.
+func LabelValue(s string) string {
+	if len(s) <= MaxLabelValueLen {
+		return s
+	}
+	return fmt.Sprintf("%s-%s", s[:MaxLabelValueLen-17], Hex(s, 16))
+}
+
 // Hex returns a hex-encoded hash of the string and truncates it to length.
 // Warning: truncating the 32 character hash makes collisions more likely.
 func Hex(s string, length int) string {
@@ -22,6 +22,21 @@ var _ = Describe("Name", func() {
 		str63 = str50 + "1234567890" + "123"
 	)
 
+	Context("LabelValue", func() {
+		It("keeps short values", func() {
+			Expect(name.LabelValue(str63)).To(Equal(str63))
+		})
+
+		It("hashes long values", func() {
+			a := name.LabelValue(str63 + "a")
+			b := name.LabelValue(str63 + "b")
+			Expect(a).To(HaveLen(63))
+			Expect(a).To(HavePrefix(str50[:46]))
+			Expect(a).ToNot(Equal(b))
+			Expect(name.LabelValue(str63 + "a")).To(Equal(a))
+		})
+	})
+
 	Context("Limit", func() {
 		tests := []test{
 			{arg: "1234567", n: 5, result: "12345"},
@@ -78,6 +78,7 @@ func Merge(base, custom fleet.BundleDeploymentOptions) fleet.BundleDeploymentOpt
 		result.Helm.TakeOwnership = result.Helm.TakeOwnership || custom.Helm.TakeOwnership
 		result.Helm.DisablePreProcess = result.Helm.DisablePreProcess || custom.Helm.DisablePreProcess
 		result.Helm.WaitForJobs = result.Helm.WaitForJobs || custom.Helm.WaitForJobs
+		result.Helm.AgentRendering = result.Helm.AgentRendering || custom.Helm.AgentRendering
 	}
 	if custom.Kustomize != nil {
 		if result.Kustomize == nil {
@@ -92,17 +93,51 @@ func Merge(base, custom fleet.BundleDeploymentOptions) fleet.BundleDeploymentOpt
 			result.Diff = &fleet.DiffOptions{}
 		}
 		result.Diff.ComparePatches = append(result.Diff.ComparePatches, custom.Diff.ComparePatches...)
+		result.Diff.Presets = append(result.Diff.Presets, custom.Diff.Presets...)
+	}
+	if custom.BlueGreen != nil {
+		result.BlueGreen = custom.BlueGreen.DeepCopy()
+	}
+	if custom.EmptyRender != "" {
+		result.EmptyRender = custom.EmptyRender
+	}
+	if custom.Propagation != nil {
+		result.Propagation = custom.Propagation.DeepCopy()
 	}
 	if custom.YAML != nil {
 		if result.YAML == nil {
 			result.YAML = &fleet.YAMLOptions{}
 		}
 		result.YAML.Overlays = append(result.YAML.Overlays, custom.YAML.Overlays...)
+		result.YAML.Template = result.YAML.Template || custom.YAML.Template
+	}
+	if custom.System != nil {
+		result.System = custom.System.DeepCopy()
+	}
+	if custom.StatusDetail != "" {
+		result.StatusDetail = custom.StatusDetail
+	}
+	if custom.WebhookPolicy != "" {
+		result.WebhookPolicy = custom.WebhookPolicy
+	}
+	if custom.StatusRetention != nil {
+		result.StatusRetention = custom.StatusRetention.DeepCopy()
+	}
+	if custom.Identity != nil {
+		result.Identity = custom.Identity.DeepCopy()
 	}
 	if custom.ForceSyncGeneration > 0 {
 		result.ForceSyncGeneration = custom.ForceSyncGeneration
 	}
 	result.KeepResources = result.KeepResources || custom.KeepResources
+	result.AllowRecreate = result.AllowRecreate || custom.AllowRecreate
+	result.ReplaceImmutable = result.ReplaceImmutable || custom.ReplaceImmutable
+	result.RunOnce = result.RunOnce || custom.RunOnce
+	result.StrictTemplates = result.StrictTemplates || custom.StrictTemplates
+	result.PostDeleteHooks = result.PostDeleteHooks || custom.PostDeleteHooks
+	result.OptionalResources = append(result.OptionalResources, custom.OptionalResources...)
+	// custom strategies are matched before the bundle's
+	result.ApplyStrategies = append(custom.ApplyStrategies[:len(custom.ApplyStrategies):len(custom.ApplyStrategies)], result.ApplyStrategies...)
 
 	return result
 }
@@ -19,7 +19,7 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
-// HelmChart applies overlays to "manifest"-style gitrepos and transforms the
+// HelmChart renders templates, applies overlays to "manifest"-style gitrepos and transforms the
 // manifest into a helm chart tgz
 func HelmChart(name string, m *manifest.Manifest, options fleet.BundleDeploymentOptions) (io.Reader, error) {
 	var (
@@ -28,6 +28,17 @@ func HelmChart(name string, m *manifest.Manifest, options fleet.BundleDeployment
 	)
 
 	if style.IsRawYAML() {
+		if options.YAML != nil && options.YAML.Template {
+			var context map[string]interface{}
+			if options.YAML.TemplateContext != nil {
+				context = options.YAML.TemplateContext.Data
+			}
+			m, err = templateManifests(m, context)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		var overlays []string
 		if options.YAML != nil {
 			overlays = options.YAML.Overlays
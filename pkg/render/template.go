@@ -0,0 +1,59 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/content"
+	"github.com/rancher/fleet/pkg/fleetyaml"
+	"github.com/rancher/fleet/pkg/manifest"
+)
+
+// TemplateFuncs returns a mapping of all of the functions from sprig but
+// removes potentially dangerous operations
+func TemplateFuncs() template.FuncMap {
+	f := sprig.TxtFuncMap()
+	delete(f, "env")
+	delete(f, "expandenv")
+	delete(f, "include")
+	delete(f, "tpl")
+
+	return f
+}
+
+// templateManifests renders the "${ }" templates in the raw manifests and
+// overlays with the context, which the controller computed for the cluster.
+func templateManifests(m *manifest.Manifest, context map[string]interface{}) (*manifest.Manifest, error) {
+	newManifest := &manifest.Manifest{Commit: m.Commit}
+	for _, resource := range m.Resources {
+		if fleetyaml.IsFleetYaml(resource.Name) ||
+			!strings.HasSuffix(resource.Name, ".yaml") &&
+				!strings.HasSuffix(resource.Name, ".json") &&
+				!strings.HasSuffix(resource.Name, ".yml") {
+			newManifest.Resources = append(newManifest.Resources, resource)
+			continue
+		}
+
+		data, err := content.Decode(resource.Content, resource.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(resource.Name).Funcs(TemplateFuncs()).Option("missingkey=error").Delims("${", "}").Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template in %s: %w", resource.Name, err)
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, context); err != nil {
+			return nil, fmt.Errorf("failed to render template in %s: %w", resource.Name, err)
+		}
+		newManifest.Resources = append(newManifest.Resources, fleet.BundleResource{
+			Name:    resource.Name,
+			Content: b.String(),
+		})
+	}
+	return newManifest, nil
+}
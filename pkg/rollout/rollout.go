@@ -0,0 +1,267 @@
+// Package rollout computes how the targets of a bundle are split into partitions and how many of them may be unavailable during a rollout. (fleetcontroller)
+//
+// The functions are deterministic: they only depend on their arguments and
+// keep the order of the clusters, so tools like simulators or UIs can
+// predict the partitions the controller uses for the same clusters. The
+// controller passes the clusters sorted by name.
+package rollout
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/match"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// AutoPartitionThreshold is the number of clusters, from which on clusters
+// are split into partitions automatically, if no partitions are defined.
+const AutoPartitionThreshold = 200
+
+var (
+	// DefaultMaxUnavailable allows all clusters to be unavailable.
+	DefaultMaxUnavailable = intstr.FromString("100%")
+	// DefaultAutoPartitionSize is the size of automatic partitions.
+	DefaultAutoPartitionSize = intstr.FromString("25%")
+	// DefaultMaxUnavailablePartitions doesn't allow unavailable partitions.
+	DefaultMaxUnavailablePartitions = intstr.FromInt(0)
+)
+
+// Options configure the computation.
+type Options struct {
+	// Strategy is the rollout strategy of the bundle. If nil, the defaults
+	// are used.
+	Strategy *fleet.RolloutStrategy
+}
+
+func (o Options) strategy() *fleet.RolloutStrategy {
+	if o.Strategy == nil {
+		return &fleet.RolloutStrategy{}
+	}
+	return o.Strategy
+}
+
+// Cluster is a cluster targeted by a bundle.
+type Cluster struct {
+	Name   string
+	Labels map[string]string
+	// Groups are the cluster groups the cluster belongs to. Manually
+	// defined partitions only match clusters, which belong to a group.
+	Groups []Group
+}
+
+// Group is a cluster group.
+type Group struct {
+	Name   string
+	Labels map[string]string
+}
+
+// Partition is a set of clusters, which is rolled out together.
+type Partition struct {
+	Name string `json:"name"`
+	// Order is the order of the partition's definition, partitions are
+	// sorted by it.
+	Order int `json:"order,omitempty"`
+	// MaxUnavailable is the number of clusters of the partition, which may
+	// be unavailable, before the partition counts as unavailable.
+	MaxUnavailable int `json:"maxUnavailable"`
+	// Clusters are the indexes of the partition's clusters in the slice
+	// passed to Partitions.
+	Clusters []int `json:"clusters"`
+}
+
+// Partitions distributes clusters into partitions. Partitions defined by
+// the strategy are sorted by their order, partitions of the same order are
+// kept in the order they are defined. A cluster matching several of them
+// is part of each. Without them, more than AutoPartitionThreshold clusters
+// are split into partitions of AutoPartitionSize, everything else is a
+// single partition named "All".
+func Partitions(clusters []Cluster, opts Options) ([]Partition, error) {
+	rollout := opts.strategy()
+	if len(rollout.Partitions) == 0 {
+		return autoPartition(rollout, clusters)
+	}
+	return manualPartition(rollout, clusters)
+}
+
+func manualPartition(rollout *fleet.RolloutStrategy, clusters []Cluster) ([]Partition, error) {
+	defs := append([]fleet.Partition{}, rollout.Partitions...)
+	sort.SliceStable(defs, func(i, j int) bool {
+		return defs[i].Order < defs[j].Order
+	})
+
+	var partitions []Partition
+	for _, def := range defs {
+		matcher, err := match.NewClusterMatcher(def.ClusterName, def.ClusterGroup, def.ClusterGroupSelector, def.ClusterSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		var indexes []int
+		for i, cluster := range clusters {
+			for _, group := range cluster.Groups {
+				if matcher.Match(cluster.Name, group.Name, group.Labels, cluster.Labels) {
+					indexes = append(indexes, i)
+					break
+				}
+			}
+		}
+
+		partitions, err = appendPartition(partitions, def.Name, indexes, def.MaxUnavailable, rollout.MaxUnavailable)
+		if err != nil {
+			return nil, err
+		}
+		partitions[len(partitions)-1].Order = def.Order
+	}
+	return partitions, nil
+}
+
+func autoPartition(rollout *fleet.RolloutStrategy, clusters []Cluster) ([]Partition, error) {
+	all := indexes(0, len(clusters))
+
+	// if auto is disabled
+	if rollout.AutoPartitionSize != nil && rollout.AutoPartitionSize.Type == intstr.Int &&
+		rollout.AutoPartitionSize.IntVal <= 0 {
+		return appendPartition(nil, "All", all, rollout.MaxUnavailable)
+	}
+
+	// Also disable if less than the threshold
+	if len(clusters) < AutoPartitionThreshold {
+		return appendPartition(nil, "All", all, rollout.MaxUnavailable)
+	}
+
+	maxSize, err := Limit(len(clusters), rollout.AutoPartitionSize, &DefaultAutoPartitionSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []Partition
+	for offset := 0; offset < len(clusters); offset += maxSize {
+		end := offset + maxSize
+		if end > len(clusters) {
+			end = len(clusters)
+		}
+		name := fmt.Sprintf("Partition %d - %d", offset, end)
+		partitions, err = appendPartition(partitions, name, indexes(offset, end), rollout.MaxUnavailable)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return partitions, nil
+}
+
+func appendPartition(partitions []Partition, name string, clusters []int, maxUnavailable ...*intstr.IntOrString) ([]Partition, error) {
+	max, err := Limit(len(clusters), maxUnavailable...)
+	if err != nil {
+		return nil, err
+	}
+	return append(partitions, Partition{
+		Name:           name,
+		MaxUnavailable: max,
+		Clusters:       clusters,
+	}), nil
+}
+
+func indexes(from, to int) []int {
+	result := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		result = append(result, i)
+	}
+	return result
+}
+
+// Gate holds back partitions, until all partitions with a lower order are
+// ready. The partitions have to be passed in the order returned by
+// Partitions.
+type Gate struct {
+	started bool
+	order   int
+	// notReady is true, if a partition of the current order isn't ready
+	notReady bool
+	// closed is true, if a partition of a lower order isn't ready
+	closed bool
+}
+
+// Open returns true, if a partition of the order may be rolled out.
+func (g *Gate) Open(order int) bool {
+	if !g.started || order > g.order {
+		g.closed = g.closed || g.notReady
+		g.started = true
+		g.order = order
+		g.notReady = false
+	}
+	return !g.closed
+}
+
+// Ready records, whether the partition last passed to Open is ready, i.e.
+// all its clusters are up to date and available.
+func (g *Gate) Ready(ready bool) {
+	if !ready {
+		g.notReady = true
+	}
+}
+
+// MaxUnavailable returns the number of clusters, which may be unavailable
+// during the rollout.
+func MaxUnavailable(clusters int, opts Options) (int, error) {
+	return Limit(clusters, opts.strategy().MaxUnavailable)
+}
+
+// MaxUnavailablePartitions returns the number of partitions, which may be
+// unavailable during the rollout.
+func MaxUnavailablePartitions(partitions int, opts Options) (int, error) {
+	return Limit(partitions, opts.strategy().MaxUnavailablePartitions, &DefaultMaxUnavailablePartitions)
+}
+
+// Limit resolves the first non-nil value, an absolute number or a
+// percentage of count, which is rounded down, but at least 1. If all values
+// are nil, DefaultMaxUnavailable is used. Zero clusters have a limit of 1.
+func Limit(count int, val ...*intstr.IntOrString) (int, error) {
+	if count == 0 {
+		return 1, nil
+	}
+
+	var limit *intstr.IntOrString
+	for _, val := range val {
+		if val != nil {
+			limit = val
+			break
+		}
+	}
+	if limit == nil {
+		limit = &DefaultMaxUnavailable
+	}
+
+	if limit.Type == intstr.Int {
+		return limit.IntValue(), nil
+	}
+
+	i := limit.IntValue()
+	if i > 0 {
+		return i, nil
+	}
+
+	if !strings.HasSuffix(limit.StrVal, "%") {
+		return 0, fmt.Errorf("invalid maxUnavailable, must be int or percentage (ending with %%): %s", limit)
+	}
+
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(limit.StrVal, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", limit.StrVal, err)
+	}
+
+	if percent <= 0 {
+		return 1, nil
+	}
+
+	i = int(float64(count)*percent) / 100
+	if i <= 0 {
+		return 1, nil
+	}
+
+	return i, nil
+}
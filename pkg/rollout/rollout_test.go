@@ -0,0 +1,134 @@
+package rollout
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// result is written to the golden files
+type result struct {
+	MaxUnavailable           int         `json:"maxUnavailable"`
+	MaxUnavailablePartitions int         `json:"maxUnavailablePartitions"`
+	Partitions               []Partition `json:"partitions"`
+}
+
+func clusters(n int, groups ...Group) []Cluster {
+	var result []Cluster
+	for i := 0; i < n; i++ {
+		result = append(result, Cluster{
+			Name:   fmt.Sprintf("cluster-%03d", i),
+			Labels: map[string]string{"env": []string{"dev", "prod"}[i%2]},
+			Groups: groups,
+		})
+	}
+	return result
+}
+
+func TestGolden(t *testing.T) {
+	percent := func(s string) *intstr.IntOrString {
+		v := intstr.FromString(s)
+		return &v
+	}
+	number := func(i int) *intstr.IntOrString {
+		v := intstr.FromInt(i)
+		return &v
+	}
+
+	tests := []struct {
+		name     string
+		clusters []Cluster
+		strategy *fleet.RolloutStrategy
+	}{
+		{name: "defaults", clusters: clusters(3)},
+		{name: "no-clusters", clusters: nil},
+		{name: "auto-partitions", clusters: clusters(250)},
+		{
+			name:     "auto-partition-size",
+			clusters: clusters(210),
+			strategy: &fleet.RolloutStrategy{AutoPartitionSize: number(100), MaxUnavailable: percent("10%"), MaxUnavailablePartitions: number(1)},
+		},
+		{
+			name:     "auto-partitions-disabled",
+			clusters: clusters(250),
+			strategy: &fleet.RolloutStrategy{AutoPartitionSize: number(0)},
+		},
+		{
+			name:     "manual-partitions",
+			clusters: clusters(6, Group{Name: "default", Labels: map[string]string{"region": "eu"}}),
+			strategy: &fleet.RolloutStrategy{
+				MaxUnavailable: percent("50%"),
+				Partitions: []fleet.Partition{
+					{Name: "canary", ClusterName: "cluster-000", MaxUnavailable: number(0)},
+					{Name: "prod", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}},
+					{Name: "eu", ClusterGroupSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}}, MaxUnavailable: percent("34%")},
+				},
+			},
+		},
+		{
+			// manual partitions only match clusters in a group
+			name:     "manual-partitions-without-groups",
+			clusters: clusters(2),
+			strategy: &fleet.RolloutStrategy{Partitions: []fleet.Partition{{Name: "all", ClusterSelector: &metav1.LabelSelector{}}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := Options{Strategy: test.strategy}
+			var r result
+			var err error
+			if r.Partitions, err = Partitions(test.clusters, opts); err != nil {
+				t.Fatal(err)
+			}
+			if r.MaxUnavailable, err = MaxUnavailable(len(test.clusters), opts); err != nil {
+				t.Fatal(err)
+			}
+			if r.MaxUnavailablePartitions, err = MaxUnavailablePartitions(len(r.Partitions), opts); err != nil {
+				t.Fatal(err)
+			}
+			actual, err := json.MarshalIndent(r, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", test.name+".golden.json")
+			if *update {
+				if err := os.WriteFile(golden, append(actual, '\n'), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v, run with -update to create it", err)
+			}
+			if string(expected) != string(actual)+"\n" {
+				t.Errorf("partitions differ from %s, got:\n%s", golden, actual)
+			}
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	invalid := intstr.FromString("ten")
+	if _, err := Limit(10, &invalid); err == nil {
+		t.Error("expected error for invalid limit")
+	}
+	small := intstr.FromString("1%")
+	if i, _ := Limit(10, &small); i != 1 {
+		t.Errorf("expected percentages to round to at least 1, got %d", i)
+	}
+	if i, _ := Limit(10, nil, &DefaultAutoPartitionSize); i != 2 {
+		t.Errorf("expected first non-nil value to be used, got %d", i)
+	}
+}
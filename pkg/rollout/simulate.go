@@ -0,0 +1,118 @@
+package rollout
+
+import "fmt"
+
+// Step is a reconcile of a simulated rollout.
+type Step struct {
+	// Clusters are the indexes of the clusters, which are updated in the
+	// step, in the order they are updated.
+	Clusters []int `json:"clusters"`
+}
+
+// Simulate returns the steps, in which the controller updates the clusters
+// to new content. It assumes all clusters are ready with the previous
+// content, and that updated clusters are unavailable until the next step,
+// so partitions wait at least a step for partitions of a lower order.
+// Clusters, which are not part of any partition, are never updated. An
+// error is returned, if the rollout would stop before all clusters of the
+// partitions were updated, e.g. because a maxUnavailable is zero. Rollout
+// steps of the strategy start once the clusters of the previous steps were
+// updated, their pauses are ignored.
+func Simulate(clusters []Cluster, opts Options) ([]Step, error) {
+	partitions, err := Partitions(clusters, opts)
+	if err != nil {
+		return nil, err
+	}
+	rolloutSteps, err := Steps(clusters, opts)
+	if err != nil {
+		return nil, err
+	}
+	max, err := MaxUnavailable(len(clusters), opts)
+	if err != nil {
+		return nil, err
+	}
+	maxPartitions, err := MaxUnavailablePartitions(len(partitions), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := map[int]bool{}
+	for _, p := range partitions {
+		for _, i := range p.Clusters {
+			pending[i] = true
+		}
+	}
+
+	var steps []Step
+	current := 0
+	for len(pending) > 0 {
+		for current < len(rolloutSteps) && !anyPending(rolloutSteps[current], pending) {
+			current++
+		}
+		started := func(i int) bool {
+			if current == len(rolloutSteps) {
+				return true
+			}
+			for _, j := range rolloutSteps[current] {
+				if i == j {
+					return true
+				}
+			}
+			return false
+		}
+
+		step := Step{}
+		updated := map[int]bool{}
+		unavailable, unavailablePartitions := 0, 0
+		gate := Gate{}
+
+		for _, p := range partitions {
+			open := gate.Open(p.Order)
+			partitionUnavailable := 0
+			for _, i := range p.Clusters {
+				if !open || !pending[i] || updated[i] || !started(i) {
+					continue
+				}
+				if unavailable < max && partitionUnavailable < p.MaxUnavailable {
+					unavailable++
+					partitionUnavailable++
+					updated[i] = true
+					step.Clusters = append(step.Clusters, i)
+				}
+			}
+
+			// out of date clusters count as unavailable for the partition
+			outOfDate := 0
+			for _, i := range p.Clusters {
+				if pending[i] {
+					outOfDate++
+				}
+			}
+			gate.Ready(outOfDate == 0)
+			if outOfDate > p.MaxUnavailable {
+				unavailablePartitions++
+			}
+			if unavailablePartitions > maxPartitions {
+				break
+			}
+		}
+
+		if len(step.Clusters) == 0 {
+			return steps, fmt.Errorf("rollout stops after %d steps with %d clusters not updated", len(steps), len(pending))
+		}
+		for _, i := range step.Clusters {
+			delete(pending, i)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func anyPending(clusters []int, pending map[int]bool) bool {
+	for _, i := range clusters {
+		if pending[i] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package rollout
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestSimulate(t *testing.T) {
+	number := func(i int) *intstr.IntOrString {
+		v := intstr.FromInt(i)
+		return &v
+	}
+	group := Group{Name: "default"}
+
+	tests := []struct {
+		name     string
+		clusters []Cluster
+		strategy *fleet.RolloutStrategy
+		steps    [][]int
+		err      bool
+	}{
+		{
+			name:     "defaults",
+			clusters: clusters(3),
+			steps:    [][]int{{0, 1, 2}},
+		},
+		{
+			name:     "max unavailable",
+			clusters: clusters(5),
+			strategy: &fleet.RolloutStrategy{MaxUnavailable: number(2)},
+			steps:    [][]int{{0, 1}, {2, 3}, {4}},
+		},
+		{
+			// the canary is never updated
+			name:     "zero max unavailable",
+			clusters: clusters(4, group),
+			strategy: &fleet.RolloutStrategy{
+				Partitions: []fleet.Partition{
+					{Name: "canary", ClusterName: "cluster-000", MaxUnavailable: number(0)},
+					{Name: "rest", ClusterGroup: "default", MaxUnavailable: number(2)},
+				},
+			},
+			err: true,
+		},
+		{
+			name:     "unavailable partitions",
+			clusters: clusters(4, group),
+			strategy: &fleet.RolloutStrategy{
+				Partitions: []fleet.Partition{
+					{Name: "canary", ClusterName: "cluster-000", MaxUnavailable: number(1)},
+					{Name: "rest", ClusterGroup: "default", MaxUnavailable: number(1)},
+				},
+			},
+			steps: [][]int{{0, 1}, {2}, {3}},
+		},
+		{
+			// prod is defined first, but waits for the canary to be ready
+			name:     "order",
+			clusters: clusters(4, group),
+			strategy: &fleet.RolloutStrategy{
+				MaxUnavailablePartitions: number(2),
+				Partitions: []fleet.Partition{
+					{Name: "prod", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}, Order: 2},
+					{Name: "canary", ClusterName: "cluster-000", Order: 1},
+				},
+			},
+			steps: [][]int{{0}, {1, 3}},
+		},
+		{
+			// 10% of the group, then 50%, then the rest
+			name:     "rollout steps",
+			clusters: clusters(10, group),
+			strategy: &fleet.RolloutStrategy{
+				Steps: []fleet.RolloutStep{
+					{ClusterGroup: "default", Weight: 10},
+					{ClusterGroup: "default", Weight: 50, Pause: &metav1.Duration{Duration: time.Hour}},
+				},
+			},
+			steps: [][]int{{0}, {1, 2, 3, 4}, {5, 6, 7, 8, 9}},
+		},
+		{
+			name:     "invalid step weight",
+			clusters: clusters(2),
+			strategy: &fleet.RolloutStrategy{
+				Steps: []fleet.RolloutStep{{Weight: 101}},
+			},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			steps, err := Simulate(test.clusters, Options{Strategy: test.strategy})
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error, got steps %v", steps)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual [][]int
+			for _, step := range steps {
+				actual = append(actual, step.Clusters)
+			}
+			if !reflect.DeepEqual(actual, test.steps) {
+				t.Errorf("expected steps %v, got %v", test.steps, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package rollout
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Steps returns the indexes of the clusters, which may be updated during
+// each step of the strategy. The clusters of a step include the clusters of
+// the previous steps and are in the order they were passed. A step's weight
+// selects the first clusters of its group.
+func Steps(clusters []Cluster, opts Options) ([][]int, error) {
+	var (
+		steps    [][]int
+		selected = map[int]bool{}
+	)
+	for _, step := range opts.strategy().Steps {
+		weight := step.Weight
+		if weight == 0 {
+			weight = 100
+		}
+		if weight < 0 || weight > 100 {
+			return nil, fmt.Errorf("invalid weight %d of rollout step, must be between 1 and 100", step.Weight)
+		}
+
+		var members []int
+		for i, cluster := range clusters {
+			if step.ClusterGroup == "" || inGroup(cluster, step.ClusterGroup) {
+				members = append(members, i)
+			}
+		}
+		if len(members) > 0 {
+			count, err := Limit(len(members), &intstr.IntOrString{Type: intstr.String, StrVal: fmt.Sprintf("%d%%", weight)})
+			if err != nil {
+				return nil, err
+			}
+			for _, i := range members[:count] {
+				selected[i] = true
+			}
+		}
+
+		var indexes []int
+		for i := range clusters {
+			if selected[i] {
+				indexes = append(indexes, i)
+			}
+		}
+		steps = append(steps, indexes)
+	}
+	return steps, nil
+}
+
+func inGroup(cluster Cluster, name string) bool {
+	for _, group := range cluster.Groups {
+		if group.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+// Package schedule parses the deployAt field of bundles and maintenance windows, which start at a point in time or on a cron schedule. (fleetcontroller, fleetapply)
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the times a bundle is deployed at.
+type Schedule interface {
+	// Next returns the first time after t, or the zero time if there is none.
+	Next(t time.Time) time.Time
+	// Recurring returns true for cron schedules.
+	Recurring() bool
+}
+
+// Parse parses an RFC3339 time, or a cron schedule with five fields
+// (minute, hour, day of month, month, day of week) evaluated in UTC. The
+// @hourly, @daily, @weekly and @monthly shortcuts are supported.
+func Parse(s string) (Schedule, error) {
+	return ParseInLocation(s, time.UTC)
+}
+
+// ParseInLocation is like Parse, but evaluates cron schedules in loc.
+func ParseInLocation(s string, loc *time.Location) (Schedule, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return at(t), nil
+	}
+
+	switch s {
+	case "@hourly":
+		s = "0 * * * *"
+	case "@daily", "@midnight":
+		s = "0 0 * * *"
+	case "@weekly":
+		s = "0 0 * * 0"
+	case "@monthly":
+		s = "0 0 1 * *"
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid deployAt %q: expected an RFC3339 time or a cron schedule with 5 fields", s)
+	}
+
+	c := &cron{loc: loc}
+	var err error
+	if c.minute, _, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute in deployAt %q: %w", s, err)
+	}
+	if c.hour, _, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour in deployAt %q: %w", s, err)
+	}
+	if c.dom, c.domAny, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day of month in deployAt %q: %w", s, err)
+	}
+	if c.month, _, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month in deployAt %q: %w", s, err)
+	}
+	if c.dow, c.dowAny, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid day of week in deployAt %q: %w", s, err)
+	}
+	// 7 is an alias for sunday
+	c.dow[0] = c.dow[0] || c.dow[7]
+
+	return c, nil
+}
+
+type at time.Time
+
+func (a at) Next(t time.Time) time.Time {
+	if t.Before(time.Time(a)) {
+		return time.Time(a)
+	}
+	return time.Time{}
+}
+
+func (a at) Recurring() bool {
+	return false
+}
+
+type cron struct {
+	minute, hour, dom, month, dow []bool
+	domAny, dowAny                bool
+	loc                           *time.Location
+}
+
+func (c *cron) Recurring() bool {
+	return true
+}
+
+func (c *cron) Next(t time.Time) time.Time {
+	t = t.In(c.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !c.month[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+		case !c.day(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+		case !c.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+		case !c.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// day matches the day of month and the day of week. If both are
+// restricted, either of them has to match, like in crontab.
+func (c *cron) day(t time.Time) bool {
+	dom, dow := c.dom[t.Day()], c.dow[t.Weekday()]
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parseField parses a comma separated list of values, ranges and steps,
+// like "*/15" or "1-5,10". It returns true, if the field is unrestricted.
+func parseField(field string, min, max int) ([]bool, bool, error) {
+	result := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return nil, false, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+		}
+
+		first, last := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if first, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, false, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			last = first
+			if len(bounds) == 2 {
+				if last, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, false, fmt.Errorf("invalid value %q", bounds[1])
+				}
+			} else if step > 1 {
+				last = max
+			}
+		}
+		if first < min || last > max || first > last {
+			return nil, false, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := first; v <= last; v += step {
+			result[v] = true
+		}
+	}
+	return result, strings.HasPrefix(field, "*"), nil
+}
@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	result, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestParseTime(t *testing.T) {
+	s, err := Parse("2026-03-01T02:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Recurring() {
+		t.Error("expected a point in time not to be recurring")
+	}
+	if next := s.Next(mustTime(t, "2026-02-28T12:00:00Z")); !next.Equal(mustTime(t, "2026-03-01T02:00:00Z")) {
+		t.Errorf("unexpected next time %v", next)
+	}
+	if next := s.Next(mustTime(t, "2026-03-01T02:00:00Z")); !next.IsZero() {
+		t.Errorf("expected no next time after the point in time, got %v", next)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		schedule string
+		now      string
+		next     string
+	}{
+		{"*/15 * * * *", "2026-03-01T10:07:30Z", "2026-03-01T10:15:00Z"},
+		{"0 2 * * *", "2026-03-01T02:00:00Z", "2026-03-02T02:00:00Z"},
+		{"30 22 * * 1-5", "2026-02-27T23:00:00Z", "2026-03-02T22:30:00Z"},
+		{"0 0 29 2 *", "2026-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		{"@monthly", "2026-01-31T12:00:00Z", "2026-02-01T00:00:00Z"},
+		{"0 0 * * 7", "2026-03-02T00:00:00Z", "2026-03-08T00:00:00Z"},
+		// day of month or day of week, if both are restricted
+		{"0 0 13 * 5", "2026-03-01T00:00:00Z", "2026-03-06T00:00:00Z"},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.schedule)
+		if err != nil {
+			t.Fatalf("%s: %v", test.schedule, err)
+		}
+		if !s.Recurring() {
+			t.Errorf("%s: expected cron schedule to be recurring", test.schedule)
+		}
+		if next := s.Next(mustTime(t, test.now)); !next.Equal(mustTime(t, test.next)) {
+			t.Errorf("%s: expected %s after %s, got %v", test.schedule, test.next, test.now, next)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{"", "tomorrow", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "2026-03-01 02:00"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("expected %q to be invalid", s)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+	// time zones of windows don't depend on the image's zoneinfo files
+	_ "time/tzdata"
+)
+
+// Window is a time window, which opens on a schedule and stays open for a
+// duration.
+type Window struct {
+	start    Schedule
+	duration time.Duration
+}
+
+// NewWindow parses the start of a window like ParseInLocation. The time zone
+// is an IANA name like "Europe/Berlin", it defaults to UTC.
+func NewWindow(start string, duration time.Duration, timeZone string) (*Window, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("invalid window %q: duration must be positive", start)
+	}
+	loc := time.UTC
+	if timeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+		}
+	}
+	sched, err := ParseInLocation(start, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &Window{start: sched, duration: duration}, nil
+}
+
+// Open returns true, if t is inside the window.
+func (w *Window) Open(t time.Time) bool {
+	start := w.start.Next(t.Add(-w.duration))
+	return !start.IsZero() && !start.After(t)
+}
+
+// Next returns when the window opens after t, or the zero time if it never
+// opens again.
+func (w *Window) Next(t time.Time) time.Time {
+	return w.start.Next(t)
+}
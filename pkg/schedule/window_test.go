@@ -0,0 +1,61 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	// weekdays from 22:00 to 02:00 in Berlin, which is UTC+1 in winter
+	w, err := NewWindow("0 22 * * 1-5", 4*time.Hour, "Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		now  string
+		open bool
+		next string
+	}{
+		{now: "2026-03-02T20:59:00Z", open: false, next: "2026-03-02T21:00:00Z"},
+		{now: "2026-03-02T21:00:00Z", open: true},
+		{now: "2026-03-03T00:59:00Z", open: true},
+		{now: "2026-03-03T01:00:00Z", open: false, next: "2026-03-03T21:00:00Z"},
+		// friday's window ends on saturday
+		{now: "2026-03-07T00:30:00Z", open: true},
+		{now: "2026-03-07T12:00:00Z", open: false, next: "2026-03-09T21:00:00Z"},
+	}
+	for _, test := range tests {
+		now := mustTime(t, test.now)
+		if open := w.Open(now); open != test.open {
+			t.Errorf("%s: expected open %v", test.now, test.open)
+		}
+		if test.next != "" {
+			if next := w.Next(now); !next.Equal(mustTime(t, test.next)) {
+				t.Errorf("%s: expected window to open at %s, got %v", test.now, test.next, next)
+			}
+		}
+	}
+}
+
+func TestWindowOneOff(t *testing.T) {
+	w, err := NewWindow("2026-03-01T02:00:00Z", time.Hour, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.Open(mustTime(t, "2026-03-01T02:30:00Z")) {
+		t.Error("expected window to be open")
+	}
+	if w.Open(mustTime(t, "2026-03-01T03:00:00Z")) || !w.Next(mustTime(t, "2026-03-01T03:00:00Z")).IsZero() {
+		t.Error("expected window to be closed for good")
+	}
+}
+
+func TestNewWindowInvalid(t *testing.T) {
+	if _, err := NewWindow("0 22 * * *", 0, ""); err == nil {
+		t.Error("expected error for missing duration")
+	}
+	if _, err := NewWindow("0 22 * * *", time.Hour, "Mars/Olympus"); err == nil {
+		t.Error("expected error for unknown time zone")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
 
@@ -13,6 +14,13 @@ import (
 	"github.com/rancher/wrangler/pkg/genericcondition"
 )
 
+const (
+	// maxErrors is the number of distinct errors kept in a summary
+	maxErrors = 10
+	// maxErrorLength is the length error messages are truncated to
+	maxErrorLength = 256
+)
+
 func IncrementState(summary *fleet.BundleSummary, name string, state fleet.BundleState, message string, modified []fleet.ModifiedStatus, nonReady []fleet.NonReadyStatus) {
 	switch state {
 	case fleet.Modified:
@@ -41,6 +49,78 @@ func IncrementState(summary *fleet.BundleSummary, name string, state fleet.Bundl
 			})
 		}
 	}
+	if state != fleet.Ready {
+		for _, msg := range errorMessages(state, message, nonReady) {
+			addError(summary, msg, 1)
+		}
+	}
+}
+
+// errorMessages returns the distinct error messages of a deployment, the
+// message of its conditions, if it failed to deploy or isn't ready, and the
+// messages of its resources, which are in an error state.
+func errorMessages(state fleet.BundleState, message string, nonReady []fleet.NonReadyStatus) []string {
+	var result []string
+	seen := map[string]bool{}
+	add := func(msg string) {
+		msg = truncate(msg, maxErrorLength)
+		if msg != "" && !seen[msg] {
+			seen[msg] = true
+			result = append(result, msg)
+		}
+	}
+
+	if state == fleet.ErrApplied || state == fleet.NotReady {
+		add(message)
+	}
+	for _, r := range nonReady {
+		if !r.Summary.Error {
+			continue
+		}
+		msg := strings.Join(r.Summary.Message, ", ")
+		if msg == "" {
+			msg = r.Summary.State
+		}
+		add(fmt.Sprintf("%s %s: %s", strings.ToLower(r.Kind), r.Name, msg))
+	}
+	return result
+}
+
+// truncate shortens msg to at most n bytes, without splitting a UTF-8
+// encoded character
+func truncate(msg string, n int) string {
+	if len(msg) <= n {
+		return msg
+	}
+	for n > 0 && !utf8.RuneStart(msg[n]) {
+		n--
+	}
+	return msg[:n]
+}
+
+// addError counts count more deployments reporting msg. All distinct errors
+// are counted, LimitErrors keeps the most common ones, once the summary is
+// complete.
+func addError(summary *fleet.BundleSummary, msg string, count int) {
+	for i := range summary.Errors {
+		if summary.Errors[i].Message == msg {
+			summary.Errors[i].Count += count
+			return
+		}
+	}
+	summary.Errors = append(summary.Errors, fleet.SummaryError{Message: msg, Count: count})
+}
+
+// LimitErrors sorts the errors of the summary by their count and keeps the
+// maxErrors most common ones. It's called after all deployments were added
+// to the summary by IncrementState or Increment.
+func LimitErrors(summary *fleet.BundleSummary) {
+	sort.SliceStable(summary.Errors, func(i, j int) bool {
+		return summary.Errors[i].Count > summary.Errors[j].Count
+	})
+	if len(summary.Errors) > maxErrors {
+		summary.Errors = summary.Errors[:maxErrors]
+	}
 }
 
 func IsReady(summary fleet.BundleSummary) bool {
@@ -59,6 +139,9 @@ func Increment(left *fleet.BundleSummary, right fleet.BundleSummary) {
 	if len(left.NonReadyResources) < 10 {
 		left.NonReadyResources = append(left.NonReadyResources, right.NonReadyResources...)
 	}
+	for _, e := range right.Errors {
+		addError(left, e.Message, e.Count)
+	}
 }
 
 func IncrementResourceCounts(left *fleet.GitRepoResourceCounts, right fleet.GitRepoResourceCounts) {
@@ -0,0 +1,74 @@
+package summary
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"github.com/rancher/wrangler/pkg/summary"
+)
+
+func TestIncrementStateErrors(t *testing.T) {
+	pullError := []fleet.NonReadyStatus{{
+		Kind:    "Deployment",
+		Name:    "web",
+		Summary: summary.Summary{State: "in-progress", Error: true, Message: []string{"ImagePullBackOff"}},
+	}}
+
+	s := fleet.BundleSummary{}
+	IncrementState(&s, "c-1", fleet.ErrApplied, "chart not found", nil, nil)
+	IncrementState(&s, "c-2", fleet.NotReady, "", nil, pullError)
+	IncrementState(&s, "c-3", fleet.NotReady, "", nil, pullError)
+	IncrementState(&s, "c-4", fleet.Ready, "ignored", nil, nil)
+	LimitErrors(&s)
+
+	expected := []fleet.SummaryError{
+		{Message: "deployment web: ImagePullBackOff", Count: 2},
+		{Message: "chart not found", Count: 1},
+	}
+	if !reflect.DeepEqual(s.Errors, expected) {
+		t.Errorf("expected errors %v, got %v", expected, s.Errors)
+	}
+
+	total := fleet.BundleSummary{}
+	Increment(&total, s)
+	Increment(&total, s)
+	LimitErrors(&total)
+	if total.Errors[0].Count != 4 || total.Errors[1].Count != 2 {
+		t.Errorf("expected counts to add up, got %v", total.Errors)
+	}
+}
+
+func TestLimitErrors(t *testing.T) {
+	s := fleet.BundleSummary{}
+	for i := 0; i < maxErrors; i++ {
+		IncrementState(&s, fmt.Sprintf("c-%d", i), fleet.ErrApplied, fmt.Sprintf("error %d", i), nil, nil)
+	}
+	// the most common error is reported last
+	for i := 0; i < 3; i++ {
+		IncrementState(&s, fmt.Sprintf("d-%d", i), fleet.ErrApplied, "common error", nil, nil)
+	}
+	LimitErrors(&s)
+
+	if len(s.Errors) != maxErrors {
+		t.Fatalf("expected %d errors, got %v", maxErrors, s.Errors)
+	}
+	if s.Errors[0].Message != "common error" || s.Errors[0].Count != 3 {
+		t.Errorf("expected the most common error first, got %v", s.Errors[0])
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	msg := strings.Repeat("a", maxErrorLength-1) + "ü"
+	got := truncate(msg, maxErrorLength)
+	if !utf8.ValidString(got) || got != strings.Repeat("a", maxErrorLength-1) {
+		t.Errorf("expected truncation before the multi-byte character, got %q", got[len(got)-3:])
+	}
+	if got := truncate("short", maxErrorLength); got != "short" {
+		t.Errorf("expected short message to be kept, got %q", got)
+	}
+}
@@ -1,120 +1,72 @@
 package target
 
 import (
-	"fmt"
-
 	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
-	"github.com/rancher/fleet/pkg/match"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	"github.com/rancher/fleet/pkg/rollout"
 )
 
 type Partition struct {
+	// Order of the partition's definition, see rollout.Gate
+	Order   int
 	Status  fleet.PartitionStatus
 	Targets []*Target
 }
 
-// Partitions distributes targets into partitions based on the rollout strategy (pure function)
+// Partitions distributes targets into partitions based on the rollout strategy, see rollout.Partitions (pure function)
 func Partitions(targets []*Target) ([]Partition, error) {
-	rollout := getRollout(targets)
-	if len(rollout.Partitions) == 0 {
-		return autoPartition(rollout, targets)
+	computed, err := rollout.Partitions(rolloutClusters(targets), rolloutOptions(targets))
+	if err != nil {
+		return nil, err
 	}
 
-	return manualPartition(rollout, targets)
-}
-
-// manualPartition computes a slice of Partition given some targets and rollout strategy that already has partitions (pure function)
-func manualPartition(rollout *fleet.RolloutStrategy, targets []*Target) ([]Partition, error) {
-	var (
-		partitions []Partition
-	)
-
-	for _, partitionDef := range rollout.Partitions {
-		matcher, err := match.NewClusterMatcher(partitionDef.ClusterName, partitionDef.ClusterGroup, partitionDef.ClusterGroupSelector, partitionDef.ClusterSelector)
-		if err != nil {
-			return nil, err
-		}
-
+	partitions := make([]Partition, 0, len(computed))
+	for _, p := range computed {
 		var partitionTargets []*Target
-	targetLoop:
-		for _, target := range targets {
-			for _, cg := range target.ClusterGroups {
-				if matcher.Match(target.Cluster.Name, cg.Name, cg.Labels, target.Cluster.Labels) {
-					partitionTargets = append(partitionTargets, target)
-					continue targetLoop
-				}
-			}
-		}
-
-		partitions, err = appendPartition(partitions, partitionDef.Name, partitionTargets, partitionDef.MaxUnavailable, rollout.MaxUnavailable)
-		if err != nil {
-			return nil, err
+		for _, i := range p.Clusters {
+			partitionTargets = append(partitionTargets, targets[i])
 		}
+		partitions = append(partitions, Partition{
+			Order: p.Order,
+			Status: fleet.PartitionStatus{
+				Name:           p.Name,
+				Count:          len(partitionTargets),
+				MaxUnavailable: p.MaxUnavailable,
+				Unavailable:    Unavailable(partitionTargets),
+				Summary:        Summary(partitionTargets),
+			},
+			Targets: partitionTargets,
+		})
 	}
-
 	return partitions, nil
 }
 
-// appendPartition appends a new partition to partitions with partitionTargets as targets (does not mutate partitionTargets)
-func appendPartition(partitions []Partition, name string, partitionTargets []*Target, maxUnavailable ...*intstr.IntOrString) ([]Partition, error) {
-	maxUnavailableValue, err := limit(len(partitionTargets), maxUnavailable...)
-	if err != nil {
-		return nil, err
-	}
-	return append(partitions, Partition{
-		Status: fleet.PartitionStatus{
-			Name:           name,
-			Count:          len(partitionTargets),
-			MaxUnavailable: maxUnavailableValue,
-			Unavailable:    Unavailable(partitionTargets),
-			Summary:        Summary(partitionTargets),
-		},
-		Targets: partitionTargets,
-	}), nil
-}
-
-// autoPartition computes a slice of Partition given some targets and rollout strategy (pure function)
-func autoPartition(rollout *fleet.RolloutStrategy, targets []*Target) ([]Partition, error) {
-	// if auto is disabled
-	if rollout.AutoPartitionSize != nil && rollout.AutoPartitionSize.Type == intstr.Int &&
-		rollout.AutoPartitionSize.IntVal <= 0 {
-		return appendPartition(nil, "All", targets, rollout.MaxUnavailable)
-	}
-
-	// Also disable if less than 200
-	if len(targets) < 200 {
-		return appendPartition(nil, "All", targets, rollout.MaxUnavailable)
-	}
-
-	maxSize, err := limit(len(targets), rollout.AutoPartitionSize, &defAutoPartitionSize)
+// Steps returns the targets, which may be updated during each step of the
+// rollout strategy, see rollout.Steps (pure function)
+func Steps(targets []*Target) ([][]*Target, error) {
+	computed, err := rollout.Steps(rolloutClusters(targets), rolloutOptions(targets))
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		partitions []Partition
-		offset     = 0
-	)
-
-	for {
-		if len(targets) == 0 {
-			return partitions, nil
-		}
-		end := maxSize
-		if len(targets) < maxSize {
-			end = len(targets)
+	steps := make([][]*Target, 0, len(computed))
+	for _, indexes := range computed {
+		stepTargets := make([]*Target, 0, len(indexes))
+		for _, i := range indexes {
+			stepTargets = append(stepTargets, targets[i])
 		}
+		steps = append(steps, stepTargets)
+	}
+	return steps, nil
+}
 
-		partitionTargets := targets[:end]
-		name := fmt.Sprintf("Partition %d - %d", offset, offset+end)
-
-		partitions, err = appendPartition(partitions, name, partitionTargets, rollout.MaxUnavailable)
-		if err != nil {
-			return nil, err
+func rolloutClusters(targets []*Target) []rollout.Cluster {
+	clusters := make([]rollout.Cluster, 0, len(targets))
+	for _, target := range targets {
+		cluster := rollout.Cluster{Name: target.Cluster.Name, Labels: target.Cluster.Labels}
+		for _, cg := range target.ClusterGroups {
+			cluster.Groups = append(cluster.Groups, rollout.Group{Name: cg.Name, Labels: cg.Labels})
 		}
-
-		// setup next loop
-		targets = targets[end:]
-		offset += end
+		clusters = append(clusters, cluster)
 	}
+	return clusters
 }
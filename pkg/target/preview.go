@@ -0,0 +1,94 @@
+package target
+
+import (
+	"fmt"
+	"sort"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/bundlematcher"
+)
+
+// Match describes how a bundle targets a cluster
+type Match struct {
+	// Cluster is the name of the cluster
+	Cluster string `json:"cluster"`
+	// ClusterGroups are the names of the cluster groups, which contain the cluster
+	ClusterGroups []string `json:"clusterGroups,omitempty"`
+	// Target is the name of the matched target, empty if no target matched
+	Target string `json:"target,omitempty"`
+	// Customization is the name of the matched target customization
+	Customization string `json:"customization,omitempty"`
+	// DoNotDeploy is true, if the customization prevents the deployment
+	DoNotDeploy bool `json:"doNotDeploy,omitempty"`
+	// Options are the merged options a bundle deployment would use
+	Options *fleet.BundleDeploymentOptions `json:"options,omitempty"`
+}
+
+// Deployed returns true if a bundle deployment would be created for the
+// cluster
+func (m Match) Deployed() bool {
+	return m.Options != nil
+}
+
+// Preview matches the bundle's targets against the clusters, like the
+// controller does, without creating bundle deployments. It's used to debug
+// why a cluster did or didn't receive a bundle.
+func Preview(bundle *fleet.Bundle, clusters []*fleet.Cluster, clusterGroups []*fleet.ClusterGroup) ([]Match, error) {
+	bm, err := bundlematcher.New(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	// targets with a percentage select clusters among all matching clusters
+	var (
+		matchingGroups [][]*fleet.ClusterGroup
+		matchingLabels []map[string]map[string]string
+	)
+	for _, cluster := range clusters {
+		groups := matchingClusterGroups(clusterGroups, cluster)
+		matchingGroups = append(matchingGroups, groups)
+		matchingLabels = append(matchingLabels, clusterGroupsToLabelMap(groups))
+	}
+	bm.Sample(clusters, matchingLabels)
+
+	var result []Match
+	for i, cluster := range clusters {
+		groups := matchingGroups[i]
+		groupLabels := matchingLabels[i]
+
+		m := Match{Cluster: cluster.Name}
+		for _, group := range groups {
+			m.ClusterGroups = append(m.ClusterGroups, group.Name)
+		}
+
+		target := bm.MatchCluster(cluster, groupLabels)
+		if target == nil {
+			result = append(result, m)
+			continue
+		}
+		m.Target = target.Name
+
+		targetOpts := target.BundleDeploymentOptions
+		if customized := bm.MatchClusterTargetCustomizations(cluster, groupLabels); customized != nil {
+			m.Customization = customized.Name
+			m.DoNotDeploy = customized.DoNotDeploy
+			targetOpts = customized.BundleDeploymentOptions
+		}
+		if m.DoNotDeploy {
+			result = append(result, m)
+			continue
+		}
+
+		opts, _, err := clusterOptions(bundle, targetOpts, cluster, groups)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", cluster.Name, err)
+		}
+		m.Options = &opts
+		result = append(result, m)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Cluster < result[j].Cluster
+	})
+	return result, nil
+}
@@ -9,11 +9,10 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
-	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
-	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	kyaml "sigs.k8s.io/yaml"
 
@@ -21,26 +20,22 @@ import (
 	"github.com/rancher/fleet/pkg/bundlematcher"
 	fleetcontrollers "github.com/rancher/fleet/pkg/generated/controllers/fleet.cattle.io/v1alpha1"
 	"github.com/rancher/fleet/pkg/manifest"
+	"github.com/rancher/fleet/pkg/match"
+	name2 "github.com/rancher/fleet/pkg/name"
 	"github.com/rancher/fleet/pkg/options"
+	"github.com/rancher/fleet/pkg/render"
+	"github.com/rancher/fleet/pkg/rollout"
 	"github.com/rancher/fleet/pkg/summary"
 
+	"github.com/rancher/wrangler/pkg/condition"
 	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/pkg/name"
 	"github.com/rancher/wrangler/pkg/yaml"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
-
-	"github.com/Masterminds/sprig/v3"
-)
-
-var (
-	// Default limit is 100%, make sure the default behavior doesn't block rollout
-	defLimit                    = intstr.FromString("100%")
-	defAutoPartitionSize        = intstr.FromString("25%")
-	defMaxUnavailablePartitions = intstr.FromInt(0)
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
@@ -55,6 +50,8 @@ type Manager struct {
 	bundleCache                 fleetcontrollers.BundleCache
 	bundleNamespaceMappingCache fleetcontrollers.BundleNamespaceMappingCache
 	namespaceCache              corecontrollers.NamespaceCache
+	secretCache                 corecontrollers.SecretCache
+	configMapCache              corecontrollers.ConfigMapCache
 	contentStore                manifest.Store
 }
 
@@ -64,6 +61,8 @@ func New(
 	bundles fleetcontrollers.BundleCache,
 	bundleNamespaceMappingCache fleetcontrollers.BundleNamespaceMappingCache,
 	namespaceCache corecontrollers.NamespaceCache,
+	secretCache corecontrollers.SecretCache,
+	configMapCache corecontrollers.ConfigMapCache,
 	contentStore manifest.Store,
 	bundleDeployments fleetcontrollers.BundleDeploymentCache) *Manager {
 
@@ -75,10 +74,15 @@ func New(
 		bundleCache:                 bundles,
 		contentStore:                contentStore,
 		namespaceCache:              namespaceCache,
+		secretCache:                 secretCache,
+		configMapCache:              configMapCache,
 	}
 }
 
 func (m *Manager) BundleFromDeployment(bd *fleet.BundleDeployment) (string, string) {
+	if bundleName, ok := bd.Annotations[fleet.BundleAnnotation]; ok {
+		return bd.Labels[fleet.BundleNamespaceLabel], bundleName
+	}
 	return bd.Labels[fleet.BundleNamespaceLabel],
 		bd.Labels[fleet.BundleLabel]
 }
@@ -97,28 +101,42 @@ func clusterGroupsToLabelMap(cgs []*fleet.ClusterGroup) map[string]map[string]st
 	return result
 }
 
-func (m *Manager) clusterGroupsForCluster(cluster *fleet.Cluster) (result []*fleet.ClusterGroup, _ error) {
+// groupPropagationDelay returns the longest propagation delay of the
+// cluster groups
+func groupPropagationDelay(cgs []*fleet.ClusterGroup) time.Duration {
+	var delay time.Duration
+	for _, cg := range cgs {
+		if cg.Spec.PropagationDelay != nil && cg.Spec.PropagationDelay.Duration > delay {
+			delay = cg.Spec.PropagationDelay.Duration
+		}
+	}
+	return delay
+}
+
+func (m *Manager) clusterGroupsForCluster(cluster *fleet.Cluster) ([]*fleet.ClusterGroup, error) {
 	cgs, err := m.clusterGroups.List(cluster.Namespace, labels.Everything())
 	if err != nil {
 		return nil, err
 	}
 
+	return matchingClusterGroups(cgs, cluster), nil
+}
+
+// matchingClusterGroups returns the cluster groups, whose selector and
+// selector expression match the cluster
+func matchingClusterGroups(cgs []*fleet.ClusterGroup, cluster *fleet.Cluster) (result []*fleet.ClusterGroup) {
 	for _, cg := range cgs {
-		if cg.Spec.Selector == nil {
-			continue
-		}
-		sel, err := metav1.LabelSelectorAsSelector(cg.Spec.Selector)
+		m, err := match.NewClusterGroupMatcher(cg)
 		if err != nil {
-			logrus.Errorf("invalid selector on clusterGroup %s/%s [%v]: %v", cg.Namespace, cg.Name,
-				cg.Spec.Selector, err)
+			logrus.Errorf("invalid selector on clusterGroup %s/%s: %v", cg.Namespace, cg.Name, err)
 			continue
 		}
-		if sel.Matches(labels.Set(cluster.Labels)) {
+		if m != nil && m.Match(cluster) {
 			result = append(result, cg)
 		}
 	}
 
-	return result, nil
+	return result
 }
 
 func (m *Manager) getBundlesInScopeForCluster(cluster *fleet.Cluster) ([]*fleet.Bundle, error) {
@@ -169,18 +187,30 @@ func (m *Manager) BundlesForCluster(cluster *fleet.Cluster) (bundlesToRefresh, b
 	}
 
 	for _, app := range bundles {
+		if app.Spec.Extends != "" {
+			// the targets may be inherited from the base bundle, the
+			// bundle's handler removes deployments no longer targeted
+			bundlesToRefresh = append(bundlesToRefresh, app)
+			continue
+		}
 		bm, err := bundlematcher.New(app)
 		if err != nil {
 			logrus.Errorf("ignore bad app %s/%s: %v", app.Namespace, app.Name, err)
 			continue
 		}
+		if bm.Samples() {
+			// a new cluster can change which clusters are sampled, the
+			// bundle's handler samples all its clusters
+			bundlesToRefresh = append(bundlesToRefresh, app)
+			continue
+		}
 
 		cgs, err := m.clusterGroupsForCluster(cluster)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		match := bm.Match(cluster.Name, clusterGroupsToLabelMap(cgs), cluster.Labels)
+		match := bm.MatchCluster(cluster, clusterGroupsToLabelMap(cgs))
 		if match != nil {
 			bundlesToRefresh = append(bundlesToRefresh, app)
 		} else {
@@ -255,53 +285,92 @@ func (m *Manager) Targets(bundle *fleet.Bundle, manifest *manifest.Manifest) ([]
 		return nil, err
 	}
 
-	var targets []*Target
+	var (
+		clusters    []*fleet.Cluster
+		groups      [][]*fleet.ClusterGroup
+		groupLabels []map[string]map[string]string
+	)
 	for _, namespace := range namespaces {
-		clusters, err := m.clusters.List(namespace, labels.Everything())
+		nsClusters, err := m.clusters.List(namespace, labels.Everything())
 		if err != nil {
 			return nil, err
 		}
-
-		for _, cluster := range clusters {
+		for _, cluster := range nsClusters {
 			clusterGroups, err := m.clusterGroupsForCluster(cluster)
 			if err != nil {
 				return nil, err
 			}
+			clusters = append(clusters, cluster)
+			groups = append(groups, clusterGroups)
+			groupLabels = append(groupLabels, clusterGroupsToLabelMap(clusterGroups))
+		}
+	}
+	// targets with a percentage select clusters among all matching clusters
+	bm.Sample(clusters, groupLabels)
+
+	var targets []*Target
+	for i, cluster := range clusters {
+		clusterGroups := groups[i]
 
-			target := bm.Match(cluster.Name, clusterGroupsToLabelMap(clusterGroups), cluster.Labels)
-			if target == nil {
+		target := bm.MatchCluster(cluster, groupLabels[i])
+		if target == nil {
+			continue
+		}
+		// check if there is any matching targetCustomization that should be applied
+		targetOpts := target.BundleDeploymentOptions
+		propagationDelay := target.PropagationDelay
+		windows := target.MaintenanceWindows
+		clusterReady := target.ClusterReady
+		valuesFrom := target.ValuesFrom
+		targetCustomized := bm.MatchClusterTargetCustomizations(cluster, groupLabels[i])
+		if targetCustomized != nil {
+			if targetCustomized.DoNotDeploy {
+				logrus.Debugf("BundleDeployment creation for Bundle '%s' was skipped because doNotDeploy is set to true.", bundle.Name)
 				continue
 			}
-			// check if there is any matching targetCustomization that should be applied
-			targetOpts := target.BundleDeploymentOptions
-			targetCustomized := bm.MatchTargetCustomizations(cluster.Name, clusterGroupsToLabelMap(clusterGroups), cluster.Labels)
-			if targetCustomized != nil {
-				if targetCustomized.DoNotDeploy {
-					logrus.Debugf("BundleDeployment creation for Bundle '%s' was skipped because doNotDeploy is set to true.", bundle.Name)
-					continue
-				}
-				targetOpts = targetCustomized.BundleDeploymentOptions
+			targetOpts = targetCustomized.BundleDeploymentOptions
+			if targetCustomized.PropagationDelay != nil {
+				propagationDelay = targetCustomized.PropagationDelay
 			}
-
-			opts := options.Merge(bundle.Spec.BundleDeploymentOptions, targetOpts)
-			err = preprocessHelmValues(&opts, cluster)
-			if err != nil {
-				return nil, err
+			if len(targetCustomized.MaintenanceWindows) > 0 {
+				windows = targetCustomized.MaintenanceWindows
 			}
-
-			deploymentID, err := options.DeploymentID(manifest, opts)
-			if err != nil {
-				return nil, err
+			clusterReady = clusterReady || targetCustomized.ClusterReady
+			if len(targetCustomized.ValuesFrom) > 0 {
+				valuesFrom = targetCustomized.ValuesFrom
 			}
+		}
 
-			targets = append(targets, &Target{
-				ClusterGroups: clusterGroups,
-				Cluster:       cluster,
-				Bundle:        bundle,
-				Options:       opts,
-				DeploymentID:  deploymentID,
-			})
+		opts, templated, err := clusterOptions(bundle, targetOpts, cluster, clusterGroups)
+		if err != nil {
+			return nil, err
 		}
+		if err := m.addClusterValues(&opts, valuesFrom, cluster, clusterGroups); err != nil {
+			return nil, err
+		}
+
+		deploymentID, err := options.DeploymentID(manifest, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		t := &Target{
+			ClusterGroups: clusterGroups,
+			Cluster:       cluster,
+			Bundle:        bundle,
+			Options:       opts,
+			DeploymentID:  deploymentID,
+			Windows:       windows,
+			ClusterReady:  clusterReady,
+			// the resolved namespace is reported in the bundle's status
+			NamespaceTemplated: templated,
+		}
+		if propagationDelay != nil {
+			t.PropagationDelay = propagationDelay.Duration
+		} else {
+			t.PropagationDelay = groupPropagationDelay(clusterGroups)
+		}
+		targets = append(targets, t)
 	}
 
 	sort.Slice(targets, func(i, j int) bool {
@@ -311,15 +380,82 @@ func (m *Manager) Targets(bundle *fleet.Bundle, manifest *manifest.Manifest) ([]
 	return targets, m.foldInDeployments(bundle, targets)
 }
 
-func preprocessHelmValues(opts *fleet.BundleDeploymentOptions, cluster *fleet.Cluster) (err error) {
-	clusterLabels := yaml.CleanAnnotationsForExport(cluster.Labels)
-	clusterAnnotations := yaml.CleanAnnotationsForExport(cluster.Annotations)
+// clusterOptions merges the target's options into the bundle's and renders
+// them for the cluster. It also returns whether the namespace was templated.
+func clusterOptions(bundle *fleet.Bundle, targetOpts fleet.BundleDeploymentOptions, cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) (fleet.BundleDeploymentOptions, bool, error) {
+	opts := options.Merge(bundle.Spec.BundleDeploymentOptions, targetOpts)
+	if err := preprocessHelmValues(&opts, cluster, clusterGroups); err != nil {
+		return opts, false, err
+	}
+	templated := templatedNamespace(opts)
+	if err := preprocessNamespaces(&opts, cluster, clusterGroups); err != nil {
+		return opts, false, err
+	}
+	if opts.YAML != nil && opts.YAML.Template {
+		// the agent renders the raw manifests with the cluster's context
+		opts.YAML = opts.YAML.DeepCopy()
+		opts.YAML.TemplateContext = &fleet.GenericMap{Data: templateContext(cluster, clusterGroups)}
+	}
+	return opts, templated, nil
+}
 
+// clusterLabels returns the labels of the cluster available to templates,
+// without the labels of other tools but fleet's and rancher's
+func clusterLabels(cluster *fleet.Cluster) map[string]string {
+	clusterLabels := yaml.CleanAnnotationsForExport(cluster.Labels)
 	for k, v := range cluster.Labels {
 		if strings.HasPrefix(k, "fleet.cattle.io/") || strings.HasPrefix(k, "management.cattle.io/") {
 			clusterLabels[k] = v
 		}
 	}
+	return clusterLabels
+}
+
+// templateContext returns the values available to templates in options.
+// ClusterGroups maps the names of the cluster's groups to their labels and
+// annotations, ClusterGroupLabels merges the labels of all groups.
+func templateContext(cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) map[string]interface{} {
+	templateValues := map[string]interface{}{}
+	if cluster.Spec.TemplateValues != nil {
+		templateValues = cluster.Spec.TemplateValues.Data
+	}
+
+	groups := map[string]interface{}{}
+	groupLabels := map[string]string{}
+	for _, cg := range sortedClusterGroups(clusterGroups) {
+		labels := yaml.CleanAnnotationsForExport(cg.Labels)
+		groups[cg.Name] = map[string]interface{}{
+			"Labels":      toDict(labels),
+			"Annotations": toDict(yaml.CleanAnnotationsForExport(cg.Annotations)),
+		}
+		for k, v := range labels {
+			groupLabels[k] = v
+		}
+	}
+
+	return map[string]interface{}{
+		"ClusterNamespace":   cluster.Namespace,
+		"ClusterName":        cluster.Name,
+		"ClusterLabels":      toDict(clusterLabels(cluster)),
+		"ClusterAnnotations": toDict(yaml.CleanAnnotationsForExport(cluster.Annotations)),
+		"ClusterValues":      templateValues,
+		"ClusterGroups":      groups,
+		"ClusterGroupLabels": toDict(groupLabels),
+	}
+}
+
+// sortedClusterGroups returns the cluster groups sorted by name, so the
+// labels of later groups win consistently
+func sortedClusterGroups(clusterGroups []*fleet.ClusterGroup) []*fleet.ClusterGroup {
+	result := append([]*fleet.ClusterGroup{}, clusterGroups...)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func preprocessHelmValues(opts *fleet.BundleDeploymentOptions, cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) (err error) {
+	clusterLabels := clusterLabels(cluster)
 	if len(clusterLabels) == 0 {
 		return
 	}
@@ -337,26 +473,12 @@ func preprocessHelmValues(opts *fleet.BundleDeploymentOptions, cluster *fleet.Cl
 		return nil
 	}
 
-	if err := processLabelValues(opts.Helm.Values.Data, clusterLabels, 0); err != nil {
+	if err := processLabelValues(opts.Helm.Values.Data, clusterLabels, opts.StrictTemplates, 0); err != nil {
 		return err
 	}
 
 	if !opts.Helm.DisablePreProcess {
-
-		templateValues := map[string]interface{}{}
-		if cluster.Spec.TemplateValues != nil {
-			templateValues = cluster.Spec.TemplateValues.Data
-		}
-
-		values := map[string]interface{}{
-			"ClusterNamespace":   cluster.Namespace,
-			"ClusterName":        cluster.Name,
-			"ClusterLabels":      toDict(clusterLabels),
-			"ClusterAnnotations": toDict(clusterAnnotations),
-			"ClusterValues":      templateValues,
-		}
-
-		opts.Helm.Values.Data, err = processTemplateValues(opts.Helm.Values.Data, values)
+		opts.Helm.Values.Data, err = processTemplateValues(opts.Helm.Values.Data, templateContext(cluster, clusterGroups))
 		if err != nil {
 			return err
 		}
@@ -367,6 +489,53 @@ func preprocessHelmValues(opts *fleet.BundleDeploymentOptions, cluster *fleet.Cl
 
 }
 
+// preprocessNamespaces renders templates in the namespace options, like
+// "app-${ .ClusterName }", so each cluster deploys to its own namespace.
+func preprocessNamespaces(opts *fleet.BundleDeploymentOptions, cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) (err error) {
+	if opts.DefaultNamespace, err = processTemplateNamespace(opts.DefaultNamespace, cluster, clusterGroups); err != nil {
+		return fmt.Errorf("failed to render defaultNamespace: %w", err)
+	}
+	if opts.TargetNamespace, err = processTemplateNamespace(opts.TargetNamespace, cluster, clusterGroups); err != nil {
+		return fmt.Errorf("failed to render namespace: %w", err)
+	}
+	return nil
+}
+
+func processTemplateNamespace(namespace string, cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) (string, error) {
+	if !strings.Contains(namespace, "${") {
+		return namespace, nil
+	}
+
+	result, err := processTemplateString(namespace, cluster, clusterGroups)
+	if err != nil {
+		return "", err
+	}
+	if errs := validation.IsDNS1123Label(result); len(errs) > 0 {
+		return "", fmt.Errorf("invalid namespace %q: %s", result, strings.Join(errs, ", "))
+	}
+	return result, nil
+}
+
+// processTemplateString renders a template with "${" and "}" delimiters,
+// which are used in names, with the cluster's template context
+func processTemplateString(s string, cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) (string, error) {
+	tmpl, err := template.New("name").Funcs(render.TemplateFuncs()).Option("missingkey=error").Delims("${", "}").Parse(s)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, templateContext(cluster, clusterGroups)); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// templatedNamespace returns true, if the options contain a namespace
+// template, which resolves differently per cluster
+func templatedNamespace(opts fleet.BundleDeploymentOptions) bool {
+	return strings.Contains(opts.DefaultNamespace, "${") || strings.Contains(opts.TargetNamespace, "${")
+}
+
 // sprig dictionary functions like "default" and "hasKey" expect map[string]interface{}
 func toDict(values map[string]string) map[string]interface{} {
 	dict := make(map[string]interface{}, len(values))
@@ -402,6 +571,53 @@ type Target struct {
 	Bundle        *fleet.Bundle
 	Options       fleet.BundleDeploymentOptions
 	DeploymentID  string
+	// MissingAPIs lists APIs used by the deployment, which the cluster
+	// does not provide. The deployment is not updated while it's set.
+	MissingAPIs []string
+	// RemovedAPIs are the missing APIs, which were removed in the
+	// cluster's Kubernetes version
+	RemovedAPIs []fleet.RemovedAPI
+	// RecreateRequired lists the changes to immutable fields of stateful
+	// resources. The deployment is not updated while it's set.
+	RecreateRequired []string
+	// PropagationDelay is how long a new version of the bundle has to be
+	// deployed elsewhere, before the deployment is updated to it
+	PropagationDelay time.Duration
+	// Windows are the maintenance windows of the bundle's target, the
+	// deployment is only updated while they and the cluster's windows are
+	// open
+	Windows []fleet.MaintenanceWindow
+	// NamespaceTemplated is true, if the namespace options were rendered
+	// from a template
+	NamespaceTemplated bool
+	// StepPending is true, if the deployment is not updated, because the
+	// rollout step selecting its cluster didn't start yet
+	StepPending bool
+	// ClusterReady is true, if the target only deploys to clusters, whose
+	// agent checked in recently
+	ClusterReady bool
+	// ClusterOffline is true, if the target requires a ready cluster, but
+	// the cluster's agent didn't check in recently. The deployment is
+	// neither created nor updated and it doesn't count as unavailable.
+	ClusterOffline bool
+	// Pinned is true, if an operator pinned the deployment until a time in
+	// the future. New content is neither staged nor rolled out to it.
+	Pinned bool
+}
+
+// ClusterUpgrading returns true, if the agent reports an upgrade of the
+// cluster's control plane. Such clusters are not updated and don't count as
+// unavailable, as the agent doesn't deploy until the upgrade finished.
+func (t *Target) ClusterUpgrading() bool {
+	return t.Cluster != nil && t.Cluster.Status.Agent.Upgrading
+}
+
+// RunOnceApplied returns true, if the target's bundle is only applied once
+// and its deployment was installed on the cluster.
+func (t *Target) RunOnceApplied() bool {
+	return t.Deployment != nil && t.Deployment.Spec.Options.RunOnce &&
+		t.Deployment.Status.AppliedDeploymentID != "" &&
+		condition.Cond(fleet.BundleDeploymentConditionInstalled).IsTrue(t.Deployment)
 }
 
 func (t *Target) IsPaused() bool {
@@ -413,9 +629,10 @@ func (t *Target) IsPaused() bool {
 func (t *Target) ResetDeployment() {
 	t.Deployment = &fleet.BundleDeployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      t.Bundle.Name,
-			Namespace: t.Cluster.Status.Namespace,
-			Labels:    t.BundleDeploymentLabels(t.Cluster.Namespace, t.Cluster.Name),
+			Name:        t.Bundle.Name,
+			Namespace:   t.Cluster.Status.Namespace,
+			Labels:      t.BundleDeploymentLabels(t.Cluster.Namespace, t.Cluster.Name),
+			Annotations: t.BundleDeploymentAnnotations(),
 		},
 	}
 }
@@ -433,6 +650,14 @@ func (t *Target) BundleDeploymentLabels(clusterNamespace string, clusterName str
 		}
 	}
 
+	// copy labels selected by the propagation policy, these might
+	// contain cattle.io
+	if t.Options.Propagation != nil {
+		for k, v := range fleet.Propagate(t.Options.Propagation.Labels, t.Bundle.Labels) {
+			labels[k] = v
+		}
+	}
+
 	// labels for the bundledeployment by bundle selector
 	for k, v := range deploymentLabelsForSelector(t.Bundle) {
 		labels[k] = v
@@ -443,90 +668,62 @@ func (t *Target) BundleDeploymentLabels(clusterNamespace string, clusterName str
 
 	// add labels to identify the cluster this bundledeployment belongs to
 	labels[fleet.ClusterNamespaceLabel] = clusterNamespace
-	labels[fleet.ClusterLabel] = clusterName
+	labels[fleet.ClusterLabel] = name2.LabelValue(clusterName)
 
 	return labels
 }
 
-// deploymentLabelsForSelector returns the labels that are used to select
-// bundledeployments for a given bundle
-func deploymentLabelsForSelector(bundle *fleet.Bundle) map[string]string {
-	return map[string]string{
-		fleet.BundleLabel:          bundle.Name,
-		fleet.BundleNamespaceLabel: bundle.Namespace,
-	}
-}
-
-// getRollout returns the rollout strategy for the specified targets (pure function)
-func getRollout(targets []*Target) *fleet.RolloutStrategy {
-	var rollout *fleet.RolloutStrategy
-	if len(targets) > 0 {
-		rollout = targets[0].Bundle.Spec.RolloutStrategy
-	}
-	if rollout == nil {
-		rollout = &fleet.RolloutStrategy{}
+// BundleDeploymentAnnotations returns the bundle annotations selected by the
+// propagation policy, no annotations are copied by default
+func (t *Target) BundleDeploymentAnnotations() map[string]string {
+	var annotations map[string]string
+	if t.Options.Propagation != nil && len(t.Options.Propagation.Annotations) > 0 {
+		annotations = fleet.Propagate(t.Options.Propagation.Annotations, t.Bundle.Annotations)
 	}
-	return rollout
-}
-
-func limit(count int, val ...*intstr.IntOrString) (int, error) {
-	if count == 0 {
-		return 1, nil
-	}
-
-	var maxUnavailable *intstr.IntOrString
 
-	for _, val := range val {
-		if val != nil {
-			maxUnavailable = val
-			break
+	// keep the full names for reverse lookup, if the label values were
+	// shortened
+	for k, v := range map[string]string{
+		fleet.BundleAnnotation:  t.Bundle.Name,
+		fleet.ClusterAnnotation: t.Cluster.Name,
+	} {
+		if name2.LabelValue(v) == v {
+			continue
 		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[k] = v
 	}
 
-	if maxUnavailable == nil {
-		maxUnavailable = &defLimit
-	}
-
-	if maxUnavailable.Type == intstr.Int {
-		return maxUnavailable.IntValue(), nil
-	}
-
-	i := maxUnavailable.IntValue()
-	if i > 0 {
-		return i, nil
-	}
-
-	if !strings.HasSuffix(maxUnavailable.StrVal, "%") {
-		return 0, fmt.Errorf("invalid maxUnavailable, must be int or percentage (ending with %%): %s", maxUnavailable)
-	}
-
-	percent, err := strconv.ParseFloat(strings.TrimSuffix(maxUnavailable.StrVal, "%"), 64)
-	if err != nil {
-		return 0, errors.Wrapf(err, "failed to parse %s", maxUnavailable.StrVal)
-	}
+	return annotations
+}
 
-	if percent <= 0 {
-		return 1, nil
+// deploymentLabelsForSelector returns the labels that are used to select
+// bundledeployments for a given bundle
+func deploymentLabelsForSelector(bundle *fleet.Bundle) map[string]string {
+	return map[string]string{
+		fleet.BundleLabel:          name2.LabelValue(bundle.Name),
+		fleet.BundleNamespaceLabel: bundle.Namespace,
 	}
+}
 
-	i = int(float64(count)*percent) / 100
-	if i <= 0 {
-		return 1, nil
+// rolloutOptions returns the rollout options for the specified targets (pure function)
+func rolloutOptions(targets []*Target) rollout.Options {
+	if len(targets) == 0 {
+		return rollout.Options{}
 	}
-
-	return i, nil
+	return rollout.Options{Strategy: targets[0].Bundle.Spec.RolloutStrategy}
 }
 
 // MaxUnavailable returns the maximum number of unavailable deployments given the targets rollout strategy (pure function)
 func MaxUnavailable(targets []*Target) (int, error) {
-	rollout := getRollout(targets)
-	return limit(len(targets), rollout.MaxUnavailable)
+	return rollout.MaxUnavailable(len(targets), rolloutOptions(targets))
 }
 
 // MaxUnavailablePartitions returns the maximum number of unavailable partitions given the targets and partitions (pure function)
 func MaxUnavailablePartitions(partitions []Partition, targets []*Target) (int, error) {
-	rollout := getRollout(targets)
-	return limit(len(partitions), rollout.MaxUnavailablePartitions, &defMaxUnavailablePartitions)
+	return rollout.MaxUnavailablePartitions(len(partitions), rolloutOptions(targets))
 }
 
 // UpdateStatusUnavailable recomputes and sets the status.Unavailable counter and returns true if the partition
@@ -536,6 +733,9 @@ func UpdateStatusUnavailable(status *fleet.PartitionStatus, targets []*Target) b
 	// For a partition a target must be available and update to date.
 	status.Unavailable = 0
 	for _, target := range targets {
+		if target.ClusterUpgrading() || target.ClusterOffline {
+			continue
+		}
 		if !upToDate(target) || IsUnavailable(target.Deployment) {
 			status.Unavailable++
 		}
@@ -559,7 +759,7 @@ func upToDate(target *Target) bool {
 // Unavailable counts the number of targets that are not available (pure function)
 func Unavailable(targets []*Target) (count int) {
 	for _, target := range targets {
-		if target.Deployment == nil {
+		if target.Deployment == nil || target.ClusterUpgrading() || target.ClusterOffline {
 			continue
 		}
 		if IsUnavailable(target.Deployment) {
@@ -595,7 +795,7 @@ func (t *Target) nonReady() []fleet.NonReadyStatus {
 // state calculates a fleet.BundleState from t (pure function)
 func (t *Target) state() fleet.BundleState {
 	switch {
-	case t.Deployment == nil:
+	case t.Deployment == nil, t.ClusterOffline:
 		return fleet.Pending
 	default:
 		return summary.GetDeploymentState(t.Deployment)
@@ -615,20 +815,10 @@ func Summary(targets []*Target) fleet.BundleSummary {
 		summary.IncrementState(&bundleSummary, cluster, currentTarget.state(), currentTarget.message(), currentTarget.modified(), currentTarget.nonReady())
 		bundleSummary.DesiredReady++
 	}
+	summary.LimitErrors(&bundleSummary)
 	return bundleSummary
 }
 
-// tplFuncMap returns a mapping of all of the functions from sprig but removes potentially dangerous operations
-func tplFuncMap() template.FuncMap {
-	f := sprig.TxtFuncMap()
-	delete(f, "env")
-	delete(f, "expandenv")
-	delete(f, "include")
-	delete(f, "tpl")
-
-	return f
-}
-
 func processTemplateValues(helmValues map[string]interface{}, templateContext map[string]interface{}) (map[string]interface{}, error) {
 	data, err := kyaml.Marshal(helmValues)
 	if err != nil {
@@ -639,7 +829,7 @@ func processTemplateValues(helmValues map[string]interface{}, templateContext ma
 	// characters and will be interpreted as JSON data structures. This
 	// causes issues when parsing the fleet.yaml so we change the delims
 	// for templating to '${ }'
-	tmpl := template.New("values").Funcs(tplFuncMap()).Option("missingkey=error").Delims("${", "}")
+	tmpl := template.New("values").Funcs(render.TemplateFuncs()).Option("missingkey=error").Delims("${", "}")
 	tmpl, err = tmpl.Parse(string(data))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse helm values template: %w", err)
@@ -660,7 +850,10 @@ func processTemplateValues(helmValues map[string]interface{}, templateContext ma
 	return renderedValues, nil
 }
 
-func processLabelValues(valuesMap map[string]interface{}, clusterLabels map[string]string, recursionDepth int) error {
+// processLabelValues replaces values with the clusterLabelPrefix by the
+// cluster's label. Missing labels are replaced by an empty string, unless
+// strict is set.
+func processLabelValues(valuesMap map[string]interface{}, clusterLabels map[string]string, strict bool, recursionDepth int) error {
 	if recursionDepth > maxTemplateRecursionDepth {
 		return fmt.Errorf("maximum recursion depth of %v exceeded for cluster label prefix processing, too many nested values", maxTemplateRecursionDepth)
 	}
@@ -672,6 +865,8 @@ func processLabelValues(valuesMap map[string]interface{}, clusterLabels map[stri
 			labelVal, labelPresent := clusterLabels[label]
 			if labelPresent {
 				valuesMap[key] = labelVal
+			} else if strict {
+				return fmt.Errorf("cluster label '%s' for key '%s' is missing", label, key)
 			} else {
 				valuesMap[key] = ""
 				logrus.Infof("Cluster label '%s' for key '%s' is missing from some clusters, setting value to empty string for these clusters.", valStr, key)
@@ -679,7 +874,7 @@ func processLabelValues(valuesMap map[string]interface{}, clusterLabels map[stri
 		}
 
 		if valMap, ok := val.(map[string]interface{}); ok {
-			err := processLabelValues(valMap, clusterLabels, recursionDepth+1)
+			err := processLabelValues(valMap, clusterLabels, strict, recursionDepth+1)
 			if err != nil {
 				return err
 			}
@@ -688,7 +883,7 @@ func processLabelValues(valuesMap map[string]interface{}, clusterLabels map[stri
 		if valArr, ok := val.([]interface{}); ok {
 			for _, item := range valArr {
 				if itemMap, ok := item.(map[string]interface{}); ok {
-					err := processLabelValues(itemMap, clusterLabels, recursionDepth+1)
+					err := processLabelValues(itemMap, clusterLabels, strict, recursionDepth+1)
 					if err != nil {
 						return err
 					}
@@ -1,12 +1,17 @@
 package target
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rancher/wrangler/pkg/yaml"
 
 	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const bundleYaml = `namespace: default
@@ -44,7 +49,7 @@ func TestProcessLabelValues(t *testing.T) {
 		t.Fatalf("error during yaml parsing %v", err)
 	}
 
-	err = processLabelValues(bundle.Helm.Values.Data, clusterLabels, 0)
+	err = processLabelValues(bundle.Helm.Values.Data, clusterLabels, false, 0)
 	if err != nil {
 		t.Fatalf("error during label processing %v", err)
 	}
@@ -358,7 +363,7 @@ func TestDisablePreProcessFlagEnabled(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
-	err = preprocessHelmValues(bundle, cluster)
+	err = preprocessHelmValues(bundle, cluster, nil)
 	if err != nil {
 		t.Fatalf("error during cluster processing %v", err)
 	}
@@ -412,7 +417,7 @@ func TestDisablePreProcessFlagDisabled(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
-	err = preprocessHelmValues(bundle, cluster)
+	err = preprocessHelmValues(bundle, cluster, nil)
 	if err != nil {
 		t.Fatalf("error during cluster processing %v", err)
 	}
@@ -445,7 +450,7 @@ func TestDisablePreProcessFlagMissing(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
-	err = preprocessHelmValues(bundle, cluster)
+	err = preprocessHelmValues(bundle, cluster, nil)
 	if err != nil {
 		t.Fatalf("error during cluster processing %v", err)
 	}
@@ -464,3 +469,235 @@ func TestDisablePreProcessFlagMissing(t *testing.T) {
 	}
 
 }
+
+func TestBundleDeploymentPropagation(t *testing.T) {
+	target := &Target{
+		Cluster: &v1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "local", Namespace: "fleet-default"}},
+		Bundle: &v1alpha1.Bundle{ObjectMeta: metav1.ObjectMeta{
+			Name:      "bundle",
+			Namespace: "fleet-default",
+			Labels: map[string]string{
+				"team":                   "a",
+				"cost.example.com/owner": "b",
+				"example.cattle.io/tier": "c",
+			},
+			Annotations: map[string]string{
+				"cost.example.com/center": "d",
+				"note":                    "e",
+			},
+		}},
+		Options: v1alpha1.BundleDeploymentOptions{
+			Propagation: &v1alpha1.PropagationOptions{
+				Labels:      []string{"example.cattle.io/*"},
+				Annotations: []string{"cost.example.com/*"},
+			},
+		},
+	}
+
+	labels := target.BundleDeploymentLabels("fleet-default", "local")
+	for _, k := range []string{"team", "cost.example.com/owner", "example.cattle.io/tier"} {
+		if _, ok := labels[k]; !ok {
+			t.Errorf("expected label %s in %v", k, labels)
+		}
+	}
+
+	annotations := target.BundleDeploymentAnnotations()
+	if len(annotations) != 1 || annotations["cost.example.com/center"] != "d" {
+		t.Errorf("unexpected annotations %v", annotations)
+	}
+}
+
+func TestBundleDeploymentLongNames(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	target := &Target{
+		Cluster: &v1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: long + "-cluster", Namespace: "fleet-default"}},
+		Bundle:  &v1alpha1.Bundle{ObjectMeta: metav1.ObjectMeta{Name: long + "-bundle", Namespace: "fleet-default"}},
+	}
+
+	labels := target.BundleDeploymentLabels("fleet-default", target.Cluster.Name)
+	for _, k := range []string{v1alpha1.BundleLabel, v1alpha1.ClusterLabel} {
+		if len(labels[k]) > 63 {
+			t.Errorf("label %s value %q is too long", k, labels[k])
+		}
+	}
+
+	bd := &v1alpha1.BundleDeployment{ObjectMeta: metav1.ObjectMeta{
+		Labels:      labels,
+		Annotations: target.BundleDeploymentAnnotations(),
+	}}
+	m := &Manager{}
+	if ns, name := m.BundleFromDeployment(bd); ns != "fleet-default" || name != target.Bundle.Name {
+		t.Errorf("unexpected bundle %s/%s", ns, name)
+	}
+}
+
+func TestUnavailableSkipsUpgradingClusters(t *testing.T) {
+	notReady := &v1alpha1.BundleDeployment{Spec: v1alpha1.BundleDeploymentSpec{DeploymentID: "a"}}
+	upgrading := &v1alpha1.Cluster{Status: v1alpha1.ClusterStatus{Agent: v1alpha1.AgentStatus{Upgrading: true}}}
+	targets := []*Target{
+		{Cluster: &v1alpha1.Cluster{}, Deployment: notReady, DeploymentID: "a"},
+		{Cluster: upgrading, Deployment: notReady, DeploymentID: "a"},
+	}
+
+	if n := Unavailable(targets); n != 1 {
+		t.Errorf("expected 1 unavailable target, got %d", n)
+	}
+	status := &v1alpha1.PartitionStatus{}
+	UpdateStatusUnavailable(status, targets)
+	if status.Unavailable != 1 {
+		t.Errorf("expected 1 unavailable target in partition, got %d", status.Unavailable)
+	}
+}
+
+func TestPreprocessNamespaces(t *testing.T) {
+	cluster := &v1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "Downstream-1", Namespace: "fleet-default", Labels: map[string]string{"env": "prod"}},
+	}
+
+	opts := v1alpha1.BundleDeploymentOptions{
+		DefaultNamespace: `app-${ .ClusterName | lower }`,
+		TargetNamespace:  `${ .ClusterLabels.env }-app`,
+	}
+	if !templatedNamespace(opts) {
+		t.Error("expected namespaces to be templated")
+	}
+	if err := preprocessNamespaces(&opts, cluster, nil); err != nil {
+		t.Fatal(err)
+	}
+	if opts.DefaultNamespace != "app-downstream-1" || opts.TargetNamespace != "prod-app" {
+		t.Errorf("unexpected namespaces %q and %q", opts.DefaultNamespace, opts.TargetNamespace)
+	}
+
+	opts = v1alpha1.BundleDeploymentOptions{DefaultNamespace: "plain"}
+	if err := preprocessNamespaces(&opts, cluster, nil); err != nil || opts.DefaultNamespace != "plain" {
+		t.Errorf("expected namespace without template to be kept, got %q %v", opts.DefaultNamespace, err)
+	}
+
+	opts = v1alpha1.BundleDeploymentOptions{DefaultNamespace: `app-${ .ClusterName }`}
+	if err := preprocessNamespaces(&opts, cluster, nil); err == nil {
+		t.Error("expected error for invalid namespace")
+	}
+}
+
+func TestClusterGroupTemplates(t *testing.T) {
+	cluster := &v1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "downstream", Namespace: "fleet-default", Labels: map[string]string{"region": "eu"}},
+	}
+	groups := []*v1alpha1.ClusterGroup{
+		{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"tier": "gold"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "all", Labels: map[string]string{"tier": "bronze", "team": "ops"}}},
+	}
+	bundle := &v1alpha1.Bundle{Spec: v1alpha1.BundleSpec{BundleDeploymentOptions: v1alpha1.BundleDeploymentOptions{
+		Helm: &v1alpha1.HelmOptions{Values: &v1alpha1.GenericMap{Data: map[string]interface{}{
+			"region": "${ .ClusterLabels.region }",
+			"tier":   "${ .ClusterGroupLabels.tier }",
+			"team":   "${ .ClusterGroups.all.Labels.team }",
+		}}},
+		YAML: &v1alpha1.YAMLOptions{Template: true},
+	}}}
+
+	opts, _, err := clusterOptions(bundle, v1alpha1.BundleDeploymentOptions{}, cluster, groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := opts.Helm.Values.Data
+	if values["region"] != "eu" || values["tier"] != "gold" || values["team"] != "ops" {
+		t.Errorf("unexpected values %v", values)
+	}
+	if opts.YAML.TemplateContext == nil || opts.YAML.TemplateContext.Data["ClusterName"] != "downstream" {
+		t.Errorf("expected template context for raw manifests, got %v", opts.YAML.TemplateContext)
+	}
+	if bundle.Spec.YAML.TemplateContext != nil {
+		t.Error("expected bundle options not to be modified")
+	}
+
+	bundle.Spec.Helm.Values.Data = map[string]interface{}{"zone": "${ .ClusterLabels.zone }"}
+	if _, _, err := clusterOptions(bundle, v1alpha1.BundleDeploymentOptions{}, cluster, groups); err == nil {
+		t.Error("expected error for missing label")
+	}
+}
+
+func TestStrictTemplates(t *testing.T) {
+	cluster := &v1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "downstream", Namespace: "fleet-default", Labels: map[string]string{"region": "eu"}},
+	}
+	newOpts := func(strict bool) *v1alpha1.BundleDeploymentOptions {
+		return &v1alpha1.BundleDeploymentOptions{
+			StrictTemplates: strict,
+			Helm: &v1alpha1.HelmOptions{Values: &v1alpha1.GenericMap{Data: map[string]interface{}{
+				"zone": "global.fleet.clusterLabels.zone",
+			}}},
+		}
+	}
+
+	opts := newOpts(false)
+	if err := preprocessHelmValues(opts, cluster, nil); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Helm.Values.Data["zone"] != "" {
+		t.Errorf("expected missing label to be empty, got %v", opts.Helm.Values.Data["zone"])
+	}
+
+	if err := preprocessHelmValues(newOpts(true), cluster, nil); err == nil {
+		t.Error("expected error for missing label in strict mode")
+	}
+}
+
+func TestMatchingClusterGroupsExpression(t *testing.T) {
+	groups := []*v1alpha1.ClusterGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod-or-staging"},
+			Spec: v1alpha1.ClusterGroupSpec{
+				SelectorExpression: `(labels.env == "prod" || labels.env == "staging") && !("canary" in labels)`,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "eu-prod"},
+			Spec: v1alpha1.ClusterGroupSpec{
+				Selector:           &metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}},
+				SelectorExpression: `labels.env == "prod"`,
+			},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "invalid"}, Spec: v1alpha1.ClusterGroupSpec{SelectorExpression: `labels.env`}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "empty"}},
+	}
+	newCluster := func(labels map[string]string) *v1alpha1.Cluster {
+		return &v1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "fleet-default", Labels: labels}}
+	}
+	names := func(cgs []*v1alpha1.ClusterGroup) (result []string) {
+		for _, cg := range cgs {
+			result = append(result, cg.Name)
+		}
+		return result
+	}
+
+	for _, tc := range []struct {
+		labels   map[string]string
+		expected []string
+	}{
+		{map[string]string{"env": "prod", "region": "eu"}, []string{"prod-or-staging", "eu-prod"}},
+		{map[string]string{"env": "staging", "region": "eu"}, []string{"prod-or-staging"}},
+		{map[string]string{"env": "prod", "canary": "true"}, nil},
+		// a missing label fails the expression
+		{map[string]string{"region": "eu"}, nil},
+	} {
+		got := names(matchingClusterGroups(groups, newCluster(tc.labels)))
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("cluster with labels %v: expected groups %v, got %v", tc.labels, tc.expected, got)
+		}
+	}
+}
+
+func TestGroupPropagationDelay(t *testing.T) {
+	groups := []*v1alpha1.ClusterGroup{
+		{Spec: v1alpha1.ClusterGroupSpec{PropagationDelay: &metav1.Duration{Duration: time.Hour}}},
+		{Spec: v1alpha1.ClusterGroupSpec{PropagationDelay: &metav1.Duration{Duration: 24 * time.Hour}}},
+		{},
+	}
+	if delay := groupPropagationDelay(groups); delay != 24*time.Hour {
+		t.Errorf("expected the longest delay of the groups, got %s", delay)
+	}
+	if delay := groupPropagationDelay(nil); delay != 0 {
+		t.Errorf("expected no delay without groups, got %s", delay)
+	}
+}
@@ -0,0 +1,121 @@
+package target
+
+import (
+	"fmt"
+	"strings"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	"github.com/rancher/wrangler/pkg/data"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// valuesKey is the default key of the values in referenced secrets and
+// config maps, like the agent's valuesFrom uses
+const valuesKey = "values.yaml"
+
+// addClusterValues merges the helm values, which the target's valuesFrom
+// references in the cluster's namespace, over the helm values of opts.
+// Secrets are merged last, like the agent does.
+func (m *Manager) addClusterValues(opts *fleet.BundleDeploymentOptions, valuesFrom []fleet.ValuesFrom, cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) error {
+	if len(valuesFrom) == 0 {
+		return nil
+	}
+
+	var values map[string]interface{}
+	for _, ref := range valuesFrom {
+		if ref.ConfigMapKeyRef != nil {
+			v, err := m.configMapValues(ref.ConfigMapKeyRef, cluster, clusterGroups)
+			if err != nil {
+				return err
+			}
+			values = data.MergeMaps(values, v)
+		}
+		if ref.SecretKeyRef != nil {
+			v, err := m.secretValues(ref.SecretKeyRef, cluster, clusterGroups)
+			if err != nil {
+				return err
+			}
+			values = data.MergeMaps(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	if opts.Helm == nil {
+		opts.Helm = &fleet.HelmOptions{}
+	} else {
+		opts.Helm = opts.Helm.DeepCopy()
+	}
+	if opts.Helm.Values == nil || opts.Helm.Values.Data == nil {
+		opts.Helm.Values = &fleet.GenericMap{Data: values}
+		return nil
+	}
+	opts.Helm.Values.Data = data.MergeMaps(opts.Helm.Values.Data, values)
+	return nil
+}
+
+func (m *Manager) configMapValues(ref *fleet.ConfigMapKeySelector, cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) (map[string]interface{}, error) {
+	name, key, err := valuesRef(ref.Name, ref.Namespace, ref.Key, cluster, clusterGroups)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := m.configMapCache.Get(cluster.Namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("valuesFrom of cluster %s: %w", cluster.Name, err)
+	}
+	value, ok := cm.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %s is missing from config map %s/%s, can't use it in valuesFrom", key, cluster.Namespace, name)
+	}
+	return parseValues([]byte(value), "config map", cluster.Namespace, name)
+}
+
+func (m *Manager) secretValues(ref *fleet.SecretKeySelector, cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) (map[string]interface{}, error) {
+	name, key, err := valuesRef(ref.Name, ref.Namespace, ref.Key, cluster, clusterGroups)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := m.secretCache.Get(cluster.Namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("valuesFrom of cluster %s: %w", cluster.Name, err)
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %s is missing from secret %s/%s, can't use it in valuesFrom", key, cluster.Namespace, name)
+	}
+	return parseValues(value, "secret", cluster.Namespace, name)
+}
+
+// valuesRef renders the referenced name for the cluster and defaults the
+// key. References to other namespaces than the cluster's are rejected, so
+// bundles can't read secrets of other workspaces.
+func valuesRef(name, namespace, key string, cluster *fleet.Cluster, clusterGroups []*fleet.ClusterGroup) (string, string, error) {
+	if namespace != "" && namespace != cluster.Namespace {
+		return "", "", fmt.Errorf("valuesFrom of cluster %s can only refer to namespace %s, not %s", cluster.Name, cluster.Namespace, namespace)
+	}
+	if strings.Contains(name, "${") {
+		var err error
+		if name, err = processTemplateString(name, cluster, clusterGroups); err != nil {
+			return "", "", fmt.Errorf("failed to render valuesFrom name: %w", err)
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return "", "", fmt.Errorf("invalid valuesFrom name %q: %s", name, strings.Join(errs, ", "))
+		}
+	}
+	if key == "" {
+		key = valuesKey
+	}
+	return name, key, nil
+}
+
+func parseValues(value []byte, kind, namespace, name string) (map[string]interface{}, error) {
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(value, &values); err != nil {
+		return nil, fmt.Errorf("invalid values in %s %s/%s: %w", kind, namespace, name, err)
+	}
+	return values, nil
+}
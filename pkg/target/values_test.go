@@ -0,0 +1,92 @@
+package target
+
+import (
+	"reflect"
+	"testing"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeSecretCache struct {
+	corecontrollers.SecretCache
+	secrets map[string]*corev1.Secret
+}
+
+func (f fakeSecretCache) Get(namespace, name string) (*corev1.Secret, error) {
+	if s, ok := f.secrets[namespace+"/"+name]; ok {
+		return s, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+}
+
+type fakeConfigMapCache struct {
+	corecontrollers.ConfigMapCache
+	configMaps map[string]*corev1.ConfigMap
+}
+
+func (f fakeConfigMapCache) Get(namespace, name string) (*corev1.ConfigMap, error) {
+	if cm, ok := f.configMaps[namespace+"/"+name]; ok {
+		return cm, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+}
+
+func TestAddClusterValues(t *testing.T) {
+	m := &Manager{
+		secretCache: fakeSecretCache{secrets: map[string]*corev1.Secret{
+			"fleet-default/values-c1": {Data: map[string][]byte{"values.yaml": []byte("password: secret\nimage:\n  tag: v2\n")}},
+		}},
+		configMapCache: fakeConfigMapCache{configMaps: map[string]*corev1.ConfigMap{
+			"fleet-default/common": {Data: map[string]string{"custom": "replicas: 3\npassword: plain\n"}},
+		}},
+	}
+	cluster := &fleet.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "fleet-default"}}
+	valuesFrom := []fleet.ValuesFrom{
+		{
+			ConfigMapKeyRef: &fleet.ConfigMapKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "common"}, Key: "custom"},
+			SecretKeyRef:    &fleet.SecretKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "values-${ .ClusterName }"}},
+		},
+	}
+
+	original := &fleet.HelmOptions{Values: &fleet.GenericMap{Data: map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "app", "tag": "v1"},
+		"replicas": 1,
+	}}}
+	opts := fleet.BundleDeploymentOptions{Helm: original}
+	if err := m.addClusterValues(&opts, valuesFrom, cluster, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "app", "tag": "v2"},
+		"replicas": float64(3),
+		"password": "secret",
+	}
+	if !reflect.DeepEqual(opts.Helm.Values.Data, expected) {
+		t.Errorf("unexpected values %v", opts.Helm.Values.Data)
+	}
+	if original.Values.Data["replicas"] != 1 {
+		t.Error("expected the bundle's options not to be modified")
+	}
+
+	for name, valuesFrom := range map[string][]fleet.ValuesFrom{
+		"missing secret": {{SecretKeyRef: &fleet.SecretKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "missing"}}}},
+		"missing key":    {{ConfigMapKeyRef: &fleet.ConfigMapKeySelector{LocalObjectReference: fleet.LocalObjectReference{Name: "common"}}}},
+		"other namespace": {{SecretKeyRef: &fleet.SecretKeySelector{
+			LocalObjectReference: fleet.LocalObjectReference{Name: "values-c1"},
+			Namespace:            "kube-system",
+		}}},
+	} {
+		opts := fleet.BundleDeploymentOptions{}
+		if err := m.addClusterValues(&opts, valuesFrom, cluster, nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package update
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	"github.com/rancher/fleet/pkg/fleetyaml"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ChartVersion is a newer version of a helm chart, which uses the "commit"
+// version update policy.
+type ChartVersion struct {
+	Chart        string
+	Repo         string
+	VersionRange string
+	Version      string
+}
+
+// WithChartVersions sets the helm version in all fleet.yaml files below
+// path, whose helm options use the "commit" version update policy and match
+// the chart, repo and version range of one of the versions. It returns true
+// if a file changed.
+func WithChartVersions(path string, versions []ChartVersion) (bool, error) {
+	changed := false
+	err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fleetyaml.IsFleetYaml(d.Name()) {
+			return nil
+		}
+
+		updated, err := setChartVersions(file, versions)
+		if updated {
+			changed = true
+		}
+		return err
+	})
+	return changed, err
+}
+
+// setChartVersions updates the helm options of the fleet.yaml file and of
+// its target customizations. Comments and formatting are kept.
+func setChartVersions(file string, versions []ChartVersion) (bool, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	node, err := yaml.Parse(string(data))
+	if err != nil {
+		return false, err
+	}
+
+	var helms []*yaml.RNode
+	if helm := node.Field("helm"); helm != nil {
+		helms = append(helms, helm.Value)
+	}
+	// the targets of the v1alpha1 schema are target customizations, too
+	for _, field := range []string{"targetCustomizations", "targets"} {
+		targets := node.Field(field)
+		if targets == nil {
+			continue
+		}
+		elements, err := targets.Value.Elements()
+		if err != nil {
+			return false, err
+		}
+		for _, target := range elements {
+			if helm := target.Field("helm"); helm != nil {
+				helms = append(helms, helm.Value)
+			}
+		}
+	}
+
+	changed := false
+	for _, helm := range helms {
+		for _, v := range versions {
+			if stringField(helm, "versionUpdatePolicy") != v1alpha1.VersionUpdateCommit ||
+				stringField(helm, "chart") != v.Chart ||
+				stringField(helm, "repo") != v.Repo ||
+				stringField(helm, "versionRange") != v.VersionRange ||
+				stringField(helm, "version") == v.Version {
+				continue
+			}
+			// update the existing node, to keep its comments and quotes
+			if version := helm.Field("version"); version != nil {
+				version.Value.YNode().Value = v.Version
+			} else if err := helm.PipeE(yaml.SetField("version", yaml.NewStringRNode(v.Version))); err != nil {
+				return false, err
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return false, nil
+	}
+
+	out, err := node.String()
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(file, []byte(out), info.Mode())
+}
+
+func stringField(node *yaml.RNode, field string) string {
+	f := node.Field(field)
+	if f == nil {
+		return ""
+	}
+	return yaml.GetValue(f.Value)
+}
@@ -0,0 +1,69 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithChartVersions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	fleetYAML := `# app chart
+helm:
+  chart: app
+  repo: https://charts.example.com
+  version: 1.2.0 # deployed version
+  versionRange: ~1.2.0
+  versionUpdatePolicy: commit
+targetCustomizations:
+- name: prod
+  helm:
+    chart: app
+    repo: https://charts.example.com
+    version: 1.2.0
+    versionRange: ~1.2.0
+- name: dev
+  helm:
+    chart: app
+    repo: https://charts.example.com
+    version: 1.2.0
+    versionRange: ~1.2.0
+    versionUpdatePolicy: commit
+`
+	file := filepath.Join(dir, "app", "fleet.yaml")
+	if err := os.WriteFile(file, []byte(fleetYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := WithChartVersions(dir, []ChartVersion{{Chart: "app", Repo: "https://charts.example.com", VersionRange: "~1.2.0", Version: "1.2.3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("expected fleet.yaml to change")
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if n := strings.Count(out, "version: 1.2.3"); n != 2 {
+		t.Errorf("expected the two helm options with the commit policy to be updated, got %d in:\n%s", n, out)
+	}
+	if !strings.Contains(out, "# app chart") || !strings.Contains(out, "# deployed version") {
+		t.Errorf("expected comments to be kept, got:\n%s", out)
+	}
+
+	changed, err = WithChartVersions(dir, []ChartVersion{{Chart: "app", Repo: "https://charts.example.com", VersionRange: "~1.2.0", Version: "1.2.3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Error("expected no change for the current version")
+	}
+}